@@ -97,6 +97,87 @@ type QualityReviewRequest = {
   resources?: string;
 };
 
+type TranslateLessonRequest = {
+  lessonId?: string;
+  targetLanguage?: string;
+  targetCode?: string;
+  title?: string;
+  subject?: string;
+  grade?: string;
+  objectives?: string;
+  content?: string;
+  activities?: string;
+  assessment?: string;
+  resources?: string;
+};
+
+type TranslatedLesson = {
+  title: string;
+  subject: string;
+  grade: string;
+  objectives: string;
+  content: string;
+  activities: string;
+  assessment: string;
+  resources: string;
+};
+
+type StudentVersionRequest = {
+  lessonId?: string;
+  title?: string;
+  subject?: string;
+  grade?: string;
+  objectives?: string;
+  content?: string;
+  activities?: string;
+  assessment?: string;
+  resources?: string;
+};
+
+type StudentVersionLesson = {
+  title: string;
+  objectives: string;
+  content: string;
+  activities: string;
+  assessment: string;
+  resources: string;
+};
+
+const STUDENT_VERSION_FIELDS: Array<keyof StudentVersionLesson> = [
+  'title',
+  'objectives',
+  'content',
+  'activities',
+  'assessment',
+  'resources',
+];
+
+type GlossaryRequest = {
+  lessonId?: string;
+  title?: string;
+  subject?: string;
+  grade?: string;
+  content?: string;
+  knownTerms?: string[];
+  maxTerms?: number;
+};
+
+type GlossaryTerm = {
+  term: string;
+  definition: string;
+};
+
+const TRANSLATABLE_LESSON_FIELDS: Array<keyof TranslatedLesson> = [
+  'title',
+  'subject',
+  'grade',
+  'objectives',
+  'content',
+  'activities',
+  'assessment',
+  'resources',
+];
+
 type QualityReviewDimension = {
   key: string;
   name: string;
@@ -889,6 +970,200 @@ export async function reviewLessonQuality(req: Request, res: Response) {
   }
 }
 
+export async function translateLesson(req: Request, res: Response) {
+  try {
+    const request = req.body as TranslateLessonRequest;
+    const lessonId = toText(request.lessonId);
+    const targetLanguage = toText(request.targetLanguage);
+
+    if (!lessonId || !targetLanguage) {
+      res.status(400).json({
+        success: false,
+        error: '缺少必要参数：lessonId 或 targetLanguage',
+      });
+      return;
+    }
+
+    // 只翻译非空字段，空字段原样返回
+    const source: Partial<TranslatedLesson> = {};
+    for (const field of TRANSLATABLE_LESSON_FIELDS) {
+      const value = toText(request[field]);
+      if (value) {
+        source[field] = value;
+      }
+    }
+
+    const schema = `{
+  "title": "string",
+  "subject": "string",
+  "grade": "string",
+  "objectives": "string",
+  "content": "string",
+  "activities": "string",
+  "assessment": "string",
+  "resources": "string"
+}`;
+
+    const apiKeyOverrides = resolveApiKeyOverrides(req);
+    const { data, usage } = await withRequestApiKeys(apiKeyOverrides, async () => {
+      const deepseek = getDeepSeekClient();
+      return deepseek.structuredChat<Partial<TranslatedLesson>>(
+        [
+          {
+            role: 'system',
+            content: `你是专业的教育内容译者。请将教案各字段翻译为 ${targetLanguage}，保持 Markdown 结构、列表与数字不变，学科术语使用目标语言的通用译法。未提供的字段返回空字符串。`,
+          },
+          { role: 'user', content: JSON.stringify(source, null, 2) },
+        ],
+        schema,
+        { temperature: 0.2, maxTokens: 4000 }
+      );
+    });
+
+    const translated = {} as TranslatedLesson;
+    for (const field of TRANSLATABLE_LESSON_FIELDS) {
+      translated[field] = source[field] ? toText(data?.[field]) || source[field] || '' : '';
+    }
+
+    res.json({
+      success: true,
+      data: translated,
+      usage,
+    });
+  } catch (error) {
+    logger.error('Translate lesson error', { error });
+    res.status(500).json({
+      success: false,
+      error: error instanceof Error ? error.message : 'Internal server error',
+    });
+  }
+}
+
+export async function buildStudentVersion(req: Request, res: Response) {
+  try {
+    const request = req.body as StudentVersionRequest;
+    const lessonId = toText(request.lessonId);
+    if (!lessonId) {
+      res.status(400).json({
+        success: false,
+        error: '缺少必要参数：lessonId',
+      });
+      return;
+    }
+
+    const source: Partial<StudentVersionLesson> = {};
+    for (const field of STUDENT_VERSION_FIELDS) {
+      const value = toText(request[field]);
+      if (value) {
+        source[field] = value;
+      }
+    }
+
+    const schema = `{
+  "title": "string",
+  "objectives": "string",
+  "content": "string",
+  "activities": "string",
+  "assessment": "string",
+  "resources": "string"
+}`;
+
+    const apiKeyOverrides = resolveApiKeyOverrides(req);
+    const { data, usage } = await withRequestApiKeys(apiKeyOverrides, async () => {
+      const deepseek = getDeepSeekClient();
+      return deepseek.structuredChat<Partial<StudentVersionLesson>>(
+        [
+          {
+            role: 'system',
+            content: `你是${toText(request.grade)}${toText(request.subject)}教师。请把教案改写为发给学生的学案：删除教师活动、设计意图、教学反思、板书设计等教师视角内容，保留并改写学习目标、学习任务、练习与自我检测，使用第二人称面向学生，保持 Markdown 结构。未提供的字段返回空字符串。`,
+          },
+          { role: 'user', content: JSON.stringify(source, null, 2) },
+        ],
+        schema,
+        { temperature: 0.3, maxTokens: 4000 }
+      );
+    });
+
+    const studentVersion = {} as StudentVersionLesson;
+    for (const field of STUDENT_VERSION_FIELDS) {
+      studentVersion[field] = source[field] ? toText(data?.[field]) : '';
+    }
+
+    res.json({
+      success: true,
+      data: studentVersion,
+      usage,
+    });
+  } catch (error) {
+    logger.error('Build student version error', { error });
+    res.status(500).json({
+      success: false,
+      error: error instanceof Error ? error.message : 'Internal server error',
+    });
+  }
+}
+
+export async function extractGlossary(req: Request, res: Response) {
+  try {
+    const request = req.body as GlossaryRequest;
+    const lessonId = toText(request.lessonId);
+    const content = toText(request.content);
+    if (!lessonId || !content) {
+      res.status(400).json({
+        success: false,
+        error: '缺少必要参数：lessonId 或 content',
+      });
+      return;
+    }
+
+    const knownTerms = toStringList(request.knownTerms);
+    const maxTerms = Math.min(Math.max(toFiniteInt(request.maxTerms, 20), 1), 50);
+
+    const schema = `{
+  "terms": [
+    { "term": "string", "definition": "string" }
+  ]
+}`;
+
+    const apiKeyOverrides = resolveApiKeyOverrides(req);
+    const { data, usage } = await withRequestApiKeys(apiKeyOverrides, async () => {
+      const deepseek = getDeepSeekClient();
+      return deepseek.structuredChat<{ terms?: GlossaryTerm[] }>(
+        [
+          {
+            role: 'system',
+            content: `你是${toText(request.grade)}${toText(request.subject)}教师。请从教案中找出学生可能不理解的专业术语（最多 ${maxTerms} 个），为每个术语写一句适合该学段的简明解释。术语必须与教案原文中的写法完全一致，不要收录常用词，也不要重复已有术语：${knownTerms.join('、') || '无'}。`,
+          },
+          {
+            role: 'user',
+            content: `标题：${toText(request.title)}\n\n${content}`,
+          },
+        ],
+        schema,
+        { temperature: 0.2, maxTokens: 3000 }
+      );
+    });
+
+    const known = new Set(knownTerms);
+    const terms = (Array.isArray(data?.terms) ? data.terms : [])
+      .map(item => ({ term: toText(item?.term), definition: toText(item?.definition) }))
+      .filter(item => item.term && item.definition && !known.has(item.term))
+      .slice(0, maxTerms);
+
+    res.json({
+      success: true,
+      data: { terms },
+      usage,
+    });
+  } catch (error) {
+    logger.error('Extract glossary error', { error });
+    res.status(500).json({
+      success: false,
+      error: error instanceof Error ? error.message : 'Internal server error',
+    });
+  }
+}
+
 /**
  * 知识图谱查询
  */
@@ -10,6 +10,9 @@ import {
   getLangSmithTokenUsage,
   chatAssistant,
   reviewLessonQuality,
+  translateLesson,
+  buildStudentVersion,
+  extractGlossary,
 } from '../controllers/lessonController';
 import { snapshotMetrics } from '../../shared/observability/metrics';
 
@@ -31,6 +34,9 @@ router.get('/metrics', (_req, res) => {
 router.post('/api/generate', generateLesson);
 router.post('/api/assistant/chat', chatAssistant);
 router.post('/api/quality-review', reviewLessonQuality);
+router.post('/api/translate', translateLesson);
+router.post('/api/student-version', buildStudentVersion);
+router.post('/api/glossary', extractGlossary);
 router.post('/api/embedding', createEmbedding);
 
 // 知识图谱
@@ -4,6 +4,59 @@ import logger from '../../shared/utils/logger';
 import { gradeToNumber } from '../../shared/utils/gradeNormalizer';
 import type { KnowledgeNode, KnowledgeLink, KnowledgePoint, SearchResult } from '../../shared/types';
 
+/**
+ * 写入知识点节点的参数
+ */
+export type KnowledgePointWrite = {
+  id: string;
+  name: string;
+  type?: string;
+  description: string;
+  difficulty: string;
+  grade: string;
+  importance: number;
+  content: string;
+  examples: string[];
+  documentId?: string;
+  userId?: string;
+  tenantId?: string;
+  subject?: string;
+};
+
+/**
+ * 写入关系的参数
+ */
+export type KnowledgeRelationWrite = {
+  sourceId: string;
+  targetId: string;
+  type: string;
+  properties?: Record<string, unknown>;
+};
+
+// 单条 UNWIND 语句写入的最大条数，避免大文档一次事务过大
+const NEO4J_BATCH_SIZE = 500;
+
+// 关系类型只能拼接进 Cypher，限制为合法标识符防止注入
+const RELATION_TYPE_PATTERN = /^[A-Za-z_][A-Za-z0-9_]*$/;
+
+function toKnowledgePointRow(point: KnowledgePointWrite): Record<string, unknown> {
+  return {
+    id: point.id,
+    name: point.name,
+    type: point.type || 'KnowledgePoint',
+    description: point.description,
+    difficulty: point.difficulty,
+    grade: point.grade,
+    importance: point.importance,
+    content: point.content,
+    examples: point.examples,
+    documentId: point.documentId || null,
+    userId: point.userId || null,
+    tenantId: point.tenantId || 'default',
+    subject: point.subject || null,
+  };
+}
+
 /**
  * Neo4j 知识图谱工具类
  */
@@ -383,25 +436,13 @@ class Neo4jTool {
   /**
    * 创建知识点节点
    */
-  async createKnowledgePoint(point: {
-    id: string;
-    name: string;
-    type?: string;
-    description: string;
-    difficulty: string;
-    grade: string;
-    importance: number;
-    content: string;
-    examples: string[];
-    documentId?: string;
-    userId?: string;
-    subject?: string;
-  }): Promise<void> {
+  async createKnowledgePoint(point: KnowledgePointWrite): Promise<void> {
     const session = this.getSession();
     
     try {
       const query = `
         MERGE (k:KnowledgePoint {id: $id})
+        ON CREATE SET k.createdAt = datetime()
         SET k.name = $name,
             k.type = $type,
             k.description = $description,
@@ -412,25 +453,13 @@ class Neo4jTool {
             k.examples = $examples,
             k.documentId = $documentId,
             k.userId = $userId,
+            k.tenantId = $tenantId,
             k.subject = $subject,
-            k.createdAt = datetime()
+            k.updatedAt = datetime()
         RETURN k
       `;
       
-      await session.run(query, {
-        id: point.id,
-        name: point.name,
-        type: point.type || 'KnowledgePoint',
-        description: point.description,
-        difficulty: point.difficulty,
-        grade: point.grade,
-        importance: point.importance,
-        content: point.content,
-        examples: point.examples,
-        documentId: point.documentId || null,
-        userId: point.userId || null,
-        subject: point.subject || null,
-      });
+      await session.run(query, toKnowledgePointRow(point));
       
       logger.debug('Created knowledge point', { id: point.id, name: point.name });
     } catch (error) {
@@ -458,7 +487,8 @@ class Neo4jTool {
         MATCH (source:KnowledgePoint {id: $sourceId})
         MATCH (target:KnowledgePoint {id: $targetId})
         MERGE (source)-[r:${relationType}]->(target)
-        SET r.createdAt = datetime()
+        ON CREATE SET r.createdAt = datetime()
+        SET r.updatedAt = datetime()
         ${properties ? ', r += $properties' : ''}
         RETURN r
       `;
@@ -478,6 +508,107 @@ class Neo4jTool {
     }
   }
 
+  /**
+   * 使用 UNWIND 批量创建知识点节点，每批一个事务，返回写入条数
+   */
+  async createKnowledgePointsBatch(points: KnowledgePointWrite[]): Promise<number> {
+    if (points.length === 0) {
+      return 0;
+    }
+
+    const session = this.getSession();
+    const query = `
+      UNWIND $rows AS row
+      MERGE (k:KnowledgePoint {id: row.id})
+      ON CREATE SET k.createdAt = datetime()
+      SET k.name = row.name,
+          k.type = row.type,
+          k.description = row.description,
+          k.difficulty = row.difficulty,
+          k.grade = row.grade,
+          k.importance = row.importance,
+          k.content = row.content,
+          k.examples = row.examples,
+          k.documentId = row.documentId,
+          k.userId = row.userId,
+          k.tenantId = row.tenantId,
+          k.subject = row.subject,
+          k.updatedAt = datetime()
+    `;
+
+    let written = 0;
+    try {
+      for (let start = 0; start < points.length; start += NEO4J_BATCH_SIZE) {
+        const rows = points.slice(start, start + NEO4J_BATCH_SIZE).map(toKnowledgePointRow);
+        await session.executeWrite((tx) => tx.run(query, { rows }));
+        written += rows.length;
+      }
+
+      logger.debug('Created knowledge points in batch', { count: written });
+      return written;
+    } catch (error) {
+      logger.error('Failed to create knowledge points in batch', { error, written });
+      throw error;
+    } finally {
+      await session.close();
+    }
+  }
+
+  /**
+   * 使用 UNWIND 批量创建关系：关系类型无法参数化，按类型分组后分别执行；
+   * 两端节点不存在的关系会被 MATCH 跳过，返回实际创建/更新的关系数
+   */
+  async createRelationsBatch(relations: KnowledgeRelationWrite[]): Promise<number> {
+    if (relations.length === 0) {
+      return 0;
+    }
+
+    const byType = new Map<string, Array<Record<string, unknown>>>();
+    for (const relation of relations) {
+      if (!RELATION_TYPE_PATTERN.test(relation.type)) {
+        throw new Error(`Invalid relation type: ${relation.type}`);
+      }
+      const rows = byType.get(relation.type) || [];
+      rows.push({
+        sourceId: relation.sourceId,
+        targetId: relation.targetId,
+        properties: relation.properties || {},
+      });
+      byType.set(relation.type, rows);
+    }
+
+    const session = this.getSession();
+    let written = 0;
+    try {
+      for (const [relationType, rows] of byType) {
+        const query = `
+          UNWIND $rows AS row
+          MATCH (source:KnowledgePoint {id: row.sourceId})
+          MATCH (target:KnowledgePoint {id: row.targetId})
+          MERGE (source)-[r:${relationType}]->(target)
+          ON CREATE SET r.createdAt = datetime()
+          SET r.updatedAt = datetime(), r += row.properties
+          RETURN count(r) AS written
+        `;
+
+        for (let start = 0; start < rows.length; start += NEO4J_BATCH_SIZE) {
+          const batch = rows.slice(start, start + NEO4J_BATCH_SIZE);
+          const result = await session.executeWrite((tx) => tx.run(query, { rows: batch }));
+          const count = result.records[0]?.get('written');
+          written += typeof count === 'number' ? count : Number(count?.toNumber?.() ?? 0);
+        }
+      }
+
+      logger.debug('Created relations in batch', { count: written });
+      return written;
+    } catch (error) {
+      logger.error('Failed to create relations in batch', { error, written });
+      throw error;
+    } finally {
+      await session.close();
+    }
+  }
+
   /**
    * 删除用户文档相关的所有节点和关系
    */
@@ -1,5 +1,5 @@
 import { StateGraph, END, START, Annotation } from '@langchain/langgraph';
-import { getNeo4jTool } from '../../../infrastructure/tools/neo4j';
+import { getNeo4jTool, type KnowledgePointWrite } from '../../../infrastructure/tools/neo4j';
 import { getDeepSeekClient } from '../../../infrastructure/clients/deepseek';
 import logger from '../../../shared/utils/logger';
 
@@ -9,6 +9,8 @@ import logger from '../../../shared/utils/logger';
 export interface BuildGraphRequest {
   documentId: string;
   userId: string;
+  /** 租户 ID，写入节点用于多租户隔离；旧版后端不传时归入 default */
+  tenantId?: string;
   title: string;
   content: string;
   fileType: string;
@@ -175,6 +177,7 @@ ${state.request.grade ? `年级：${state.request.grade}` : ''}
               properties: {
                 documentId: state.request.documentId,
                 userId: state.request.userId,
+                tenantId: state.request.tenantId || 'default',
                 subject: state.request.subject,
                 grade: state.request.grade,
                 difficulty,
@@ -286,38 +289,51 @@ async function insertToNeo4jNode(state: BuildGraphState): Promise<Partial<BuildG
   let insertedRelations = 0;
   
   try {
-    // 插入实体
-    for (const entity of state.entities) {
-      try {
-        await neo4jTool.createKnowledgePoint({
-          id: entity.id,
-          name: entity.name,
-          type: entity.type || 'KnowledgePoint',
-          description: entity.description,
-          difficulty: (entity.properties.difficulty as string) || 'medium',
-          grade: state.request.grade || '',
-          importance: (entity.properties.importance as number) ?? 0.5,
-          content: entity.description,
-          examples: [],
-          ...entity.properties,
-        });
-        insertedEntities++;
-      } catch (error) {
-        logger.warn('Failed to insert entity', { entity: entity.name, error });
+    const points: KnowledgePointWrite[] = state.entities.map((entity) => ({
+      id: entity.id,
+      name: entity.name,
+      type: entity.type || 'KnowledgePoint',
+      description: entity.description,
+      difficulty: (entity.properties.difficulty as string) || 'medium',
+      grade: state.request.grade || '',
+      importance: (entity.properties.importance as number) ?? 0.5,
+      content: entity.description,
+      examples: [],
+      ...entity.properties,
+    }));
+
+    // 优先 UNWIND 批量写入；批量失败时逐条重试，跳过个别异常数据
+    try {
+      insertedEntities = await neo4jTool.createKnowledgePointsBatch(points);
+    } catch (error) {
+      logger.warn('Batch insert entities failed, falling back to one by one', { error });
+      insertedEntities = 0;
+      for (const point of points) {
+        try {
+          await neo4jTool.createKnowledgePoint(point);
+          insertedEntities++;
+        } catch (itemError) {
+          logger.warn('Failed to insert entity', { entity: point.name, error: itemError });
+        }
       }
     }
     
-    // 插入关系
-    for (const relation of state.relations) {
-      try {
-        await neo4jTool.createRelation(
-          relation.sourceId,
-          relation.targetId,
-          relation.type
-        );
-        insertedRelations++;
-      } catch (error) {
-        logger.warn('Failed to insert relation', { relation, error });
+    try {
+      insertedRelations = await neo4jTool.createRelationsBatch(state.relations);
+    } catch (error) {
+      logger.warn('Batch insert relations failed, falling back to one by one', { error });
+      insertedRelations = 0;
+      for (const relation of state.relations) {
+        try {
+          await neo4jTool.createRelation(
+            relation.sourceId,
+            relation.targetId,
+            relation.type
+          );
+          insertedRelations++;
+        } catch (itemError) {
+          logger.warn('Failed to insert relation', { relation, error: itemError });
+        }
       }
     }
     
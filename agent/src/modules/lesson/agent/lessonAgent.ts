@@ -74,10 +74,13 @@ const SKILLS: AgentSkill[] = [
 
 const GenerateLessonToolInputSchema = z.object({
   subject: z.string().min(1),
+  subjects: z.array(z.string().min(1)).optional(),
   grade: z.string().min(1),
   topic: z.string().min(1),
   duration: z.number().int().positive(),
   style: z.string().optional(),
+  detailLevel: z.enum(['brief', 'standard', 'detailed']).optional(),
+  lessonType: z.enum(['new', 'review', 'experiment']).optional(),
   requirements: z.string().optional(),
   userId: z.string().optional(),
   context: z
@@ -6,9 +6,51 @@ import type {
   LessonObjectives,
   KnowledgeContext,
   GenerateLessonRequest,
+  LessonDetailLevel,
+  LessonType,
   TokenUsage,
 } from '../../../shared/types';
 
+/**
+ * 各详略级别的教学环节要求
+ */
+const DETAIL_LEVEL_REQUIREMENTS: Record<LessonDetailLevel, string> = {
+  brief: `这是一份简案，请设计3-4个教学环节，包括：导入、新授、练习与总结。
+每个环节只需简要列出教师活动和学生活动要点，设计意图一句话说明，全文约1500字。`,
+  standard: `请设计5-7个教学环节，包括：导入、新授、练习、总结等。
+每个环节需要详细描述教师活动、学生活动、教学内容和设计意图，全文约3000字。`,
+  detailed: `这是一份详案，请设计6-8个教学环节，包括：导入、新授、练习、总结等。
+每个环节需要详细描述教师活动、学生活动、教学内容和设计意图，并写出关键提问及预设的学生回答、环节之间的过渡语，
+在总结环节给出板书设计，全文约5000字。`,
+};
+
+/**
+ * 各课型的环节结构要求，与详略级别同时出现时环节结构以课型为准
+ */
+const LESSON_TYPE_REQUIREMENTS: Record<LessonType, string> = {
+  new: `课型：新授课。按“情境导入—新知探究—例题示范—巩固练习—课堂小结—布置作业”组织环节，
+新知探究环节占课时的主要部分，写清概念形成或规律发现的过程。`,
+  review: `课型：复习课。按“知识梳理—典型例题—易错辨析—分层训练—归纳总结”组织环节，不再重复讲授新知；
+知识梳理以思维导图或知识网络呈现已学内容之间的联系，分层训练设置基础、提高两个层次并写明常见错误及纠正方法。`,
+  experiment: `课型：实验课。按“实验导入—原理与器材—安全须知—分步操作—数据记录与分析—结论与反思”组织环节；
+安全须知单独成环节，列出器材、药品与操作中的安全风险及防护措施；
+操作步骤逐条编号，写明每一步的操作要点、注意事项与预期现象；设计实验记录表，并说明异常现象或数据偏差的分析方法。`,
+};
+
+/**
+ * 跨学科教案的整合要求，单学科时为空
+ */
+function interdisciplinaryRequirement(request: GenerateLessonRequest): string {
+  if (!request.subjects || request.subjects.length < 2) {
+    return '';
+  }
+  const subjects = request.subjects.join('、');
+  return `跨学科整合要求：
+- 这是一份融合${subjects}的跨学科教案，以真实问题或项目任务为主线，让各学科知识在同一任务中协同运用，避免简单拼接
+- 在相关环节的教学内容中标明所运用的学科知识
+- 至少设计一个需要综合运用多学科知识完成的探究或实践活动`;
+}
+
 /**
  * 教学内容生成 Skill 定义
  */
@@ -91,7 +133,7 @@ function buildSectionsPrompt(
   return `请为以下课程设计完整的教学过程：
 
 基本信息：
-- 学科：${request.subject}
+- 学科：${request.subjects && request.subjects.length > 1 ? request.subjects.join('、') : request.subject}
 - 年级：${request.grade}
 - 课题：${request.topic}
 - 课时：${request.duration}分钟
@@ -113,8 +155,10 @@ ${contextText}
 
 ${request.requirements ? `特殊要求：${request.requirements}` : ''}
 
-请设计5-7个教学环节，包括：导入、新授、练习、总结等。
-每个环节需要详细描述教师活动、学生活动、教学内容和设计意图。
+${interdisciplinaryRequirement(request)}
+
+${DETAIL_LEVEL_REQUIREMENTS[request.detailLevel ?? 'standard'] ?? DETAIL_LEVEL_REQUIREMENTS.standard}
+${LESSON_TYPE_REQUIREMENTS[request.lessonType ?? 'new'] ?? LESSON_TYPE_REQUIREMENTS.new}
 确保各环节时间之和等于${request.duration}分钟。`;
 }
 
@@ -5,15 +5,24 @@
 // 教案生成请求
 export interface GenerateLessonRequest {
   subject: string;
+  subjects?: string[]; // 跨学科教案的学科组合，首个为主学科，单学科时为空
   grade: string;
   topic: string;
   duration: number;
   style?: string;
+  detailLevel?: LessonDetailLevel; // 详略级别，默认 standard
+  lessonType?: LessonType; // 课型，默认 new
   requirements?: string;
   context?: KnowledgeContext[];
   userId?: string; // 用户ID，用于过滤个人知识库
 }
 
+// 教案详略级别：简案 / 标准 / 详案
+export type LessonDetailLevel = 'brief' | 'standard' | 'detailed';
+
+// 课型：新授课 / 复习课 / 实验课
+export type LessonType = 'new' | 'review' | 'experiment';
+
 // 知识上下文
 export interface KnowledgeContext {
   id: string;
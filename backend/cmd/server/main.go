@@ -16,6 +16,7 @@ import (
 	"lesson-plan/backend/pkg/database"
 	"lesson-plan/backend/pkg/jwt"
 	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/mailer"
 
 	"github.com/gin-gonic/gin"
 )
@@ -90,29 +91,73 @@ func main() {
 	knowledgeRepo := repository.NewKnowledgeRepository(neo4jDriver, &cfg.Database.Neo4j)
 	documentRepo := repository.NewDocumentRepository(db)
 	versionRepo := repository.NewVersionRepository(db)
+	lessonEditRepo := repository.NewLessonEditRepository(db)
+	annotationRepo := repository.NewAnnotationRepository(db)
+	shareRepo := repository.NewShareRepository(db)
+	followRepo := repository.NewFollowRepository(db)
+	presetRepo := repository.NewGenerationPresetRepository(db)
+	apiKeyRepo := repository.NewAPIKeyRepository(db)
+	notificationRepo := repository.NewNotificationRepository(db)
+	settingsRepo := repository.NewUserSettingsRepository(db)
+	userDataRepo := repository.NewUserDataRepository(db)
 
 	// 初始化Service
-	authService := service.NewAuthService(userRepo, jwtManager)
-	userService := service.NewUserService(userRepo, lessonRepo, favoriteRepo)
-	lessonService := service.NewLessonService(lessonRepo, favoriteRepo, likeRepo, versionRepo, &cfg.Agent)
-	commentService := service.NewCommentService(commentRepo, lessonRepo)
-	favoriteService := service.NewFavoriteService(favoriteRepo, lessonRepo)
+	tokenDenylist := service.NewTokenDenylist(cfg.JWT.RefreshExpiryDuration())
+	confirmStore := service.NewConfirmTokenStore()
+	mailSender := mailer.New(mailer.Config{
+		Host:     cfg.Mail.Host,
+		Port:     cfg.Mail.Port,
+		Username: cfg.Mail.Username,
+		Password: cfg.Mail.Password,
+		From:     cfg.Mail.From,
+	})
+	authService := service.NewAuthService(userRepo, jwtManager, tokenDenylist, service.NewEmailVerificationStore(), service.NewPasswordResetStore(), mailSender, cfg.Mail.VerifyURL, cfg.Mail.ResetPasswordURL)
+	userService := service.NewUserService(userRepo, lessonRepo, favoriteRepo, userDataRepo, jwtManager, tokenDenylist, mailSender, cfg.Mail.ConfirmURL, &cfg.Upload)
+	lessonService := service.NewLessonService(lessonRepo, favoriteRepo, likeRepo, versionRepo, lessonEditRepo, generationRepo, knowledgeRepo, jwtManager, confirmStore, &cfg.Agent, &cfg.Lesson, cfg.Upload.StoragePath)
+	notificationService := service.NewNotificationService(notificationRepo, userRepo, lessonRepo, commentRepo, mailSender, cfg.Notification.LessonURL)
+	commentService := service.NewCommentService(commentRepo, lessonRepo, notificationService)
+	annotationService := service.NewAnnotationService(annotationRepo, lessonRepo)
+	shareService := service.NewShareService(shareRepo, lessonRepo, lessonService)
+	favoriteService := service.NewFavoriteService(favoriteRepo, likeRepo, lessonRepo)
 	likeService := service.NewLikeService(likeRepo, lessonRepo)
-	generationService := service.NewGenerationService(generationRepo, lessonRepo, &cfg.Agent)
-	knowledgeService := service.NewKnowledgeService(knowledgeRepo, &cfg.Agent)
+	followService := service.NewFollowService(followRepo, userRepo)
+	promptTemplateService := service.NewPromptTemplateService("data/prompt_template.json")
+	presetService := service.NewGenerationPresetService(presetRepo)
+	settingsService := service.NewSettingsService(settingsRepo)
+	apiKeyService := service.NewAPIKeyService(apiKeyRepo, userRepo)
+	generationService := service.NewGenerationService(generationRepo, lessonRepo, promptTemplateService, service.NewContentModerator(&cfg.Moderation), knowledgeRepo, &cfg.Agent)
+	knowledgeService := service.NewKnowledgeService(knowledgeRepo, &cfg.Agent, &cfg.Knowledge)
 	documentService := service.NewDocumentService(documentRepo, &cfg.Agent)
 	templateService := service.NewTemplateService("data/lesson_templates.json")
 
 	// 初始化Handler
 	authHandler := handler.NewAuthHandler(authService, userService)
-	userHandler := handler.NewUserHandler(userService)
-	lessonHandler := handler.NewLessonHandler(lessonService, favoriteService, likeService, commentService)
+	userHandler := handler.NewUserHandler(userService, followService, notificationService, settingsService, &cfg.Upload)
+	lessonHandler := handler.NewLessonHandler(lessonService, favoriteService, likeService, commentService, knowledgeService, cfg.Upload.StoragePath, cfg.Lesson.KnowledgeLinkURL)
 	templateHandler := handler.NewTemplateHandler(templateService)
-	generationHandler := handler.NewGenerationHandler(generationService, knowledgeService)
-	knowledgeHandler := handler.NewKnowledgeHandler(documentService)
+	generationHandler := handler.NewGenerationHandler(generationService, knowledgeService, promptTemplateService, presetService, settingsService)
+	knowledgeHandler := handler.NewKnowledgeHandler(documentService, &cfg.Upload)
+	annotationHandler := handler.NewAnnotationHandler(annotationService)
+	shareHandler := handler.NewShareHandler(shareService, lessonHandler)
+	apiKeyHandler := handler.NewAPIKeyHandler(apiKeyService)
+	healthHandler := handler.NewHealthHandler(map[string]handler.DependencyCheck{
+		"postgres": func(ctx context.Context) error {
+			sqlDB, err := db.DB()
+			if err != nil {
+				return err
+			}
+			return sqlDB.PingContext(ctx)
+		},
+		"neo4j": func(ctx context.Context) error {
+			return neo4jDriver.VerifyConnectivity(ctx)
+		},
+		"redis": func(ctx context.Context) error {
+			return redisClient.Ping(ctx).Err()
+		},
+	}, 0)
 
 	// 初始化路由
-	router := handler.NewRouter(authHandler, userHandler, lessonHandler, templateHandler, generationHandler, knowledgeHandler, cfg, jwtManager)
+	router := handler.NewRouter(authHandler, userHandler, lessonHandler, templateHandler, generationHandler, knowledgeHandler, healthHandler, annotationHandler, shareHandler, apiKeyHandler, apiKeyService, tokenDenylist, cfg, jwtManager)
 
 	// 设置Gin模式
 	if cfg.App.Env == "production" {
@@ -140,12 +185,22 @@ func main() {
 
 	logger.Info(fmt.Sprintf("Server started on port %d", cfg.App.Port))
 
+	// 评论通知每日摘要
+	digestCtx, stopDigest := context.WithCancel(context.Background())
+	go notificationService.RunDigestScheduler(digestCtx, cfg.Notification.DigestHour)
+
+	// 长期不活跃教案自动归档
+	archiveCtx, stopArchive := context.WithCancel(context.Background())
+	go lessonService.RunArchiveScheduler(archiveCtx, cfg.Lesson.AutoArchiveDays, cfg.Lesson.AutoArchiveHour)
+
 	// 等待中断信号
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 	<-quit
 
 	logger.Info("Shutting down server...")
+	stopDigest()
+	stopArchive()
 
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
@@ -2,8 +2,10 @@ package config
 
 import (
 	"fmt"
+	"net"
 	"net/url"
 	"os"
+	"reflect"
 	"strings"
 	"time"
 
@@ -20,6 +22,12 @@ type Config struct {
 	CORS      CORSConfig      `mapstructure:"cors"`
 	RateLimit RateLimitConfig `mapstructure:"rate_limit"`
 	Upload    UploadConfig    `mapstructure:"upload"`
+	Lesson    LessonConfig    `mapstructure:"lesson"`
+	Mail      MailConfig      `mapstructure:"mail"`
+	Knowledge KnowledgeConfig `mapstructure:"knowledge"`
+
+	Notification NotificationConfig `mapstructure:"notification"`
+	Moderation   ModerationConfig   `mapstructure:"moderation"`
 }
 
 // AppConfig 应用基础配置
@@ -28,6 +36,25 @@ type AppConfig struct {
 	Env   string `mapstructure:"env"`
 	Port  int    `mapstructure:"port"`
 	Debug bool   `mapstructure:"debug"`
+	// DefaultTenant 匿名请求访问域名未在 TenantHosts 中配置时使用的租户，单校部署保持默认值即可
+	DefaultTenant string `mapstructure:"default_tenant"`
+	// TenantHosts 匿名请求的域名到租户映射，格式为 "host=tenant"，如 "a.school.edu=school-a"
+	TenantHosts []string `mapstructure:"tenant_hosts"`
+	// TrustedProxies 受信任的反向代理（CIDR 或单个 IP），只有来自这些地址的 X-Forwarded-For 才用于确定客户端 IP；为空时不信任任何代理
+	TrustedProxies []string `mapstructure:"trusted_proxies"`
+}
+
+// TenantHostMap 解析 TenantHosts，忽略格式不正确的条目
+func (c *AppConfig) TenantHostMap() map[string]string {
+	hosts := make(map[string]string, len(c.TenantHosts))
+	for _, entry := range c.TenantHosts {
+		host, tenantID, ok := strings.Cut(entry, "=")
+		if !ok {
+			continue
+		}
+		hosts[strings.TrimSpace(host)] = strings.TrimSpace(tenantID)
+	}
+	return hosts
 }
 
 // DatabaseConfig 数据库配置
@@ -110,6 +137,8 @@ type AgentConfig struct {
 	URL     string `mapstructure:"url"`
 	Timeout int    `mapstructure:"timeout"`
 	APIKey  string `mapstructure:"api_key"`
+	// EmbeddingCacheTTL 查询向量在 Redis 中的缓存时间（秒），0 表示不缓存
+	EmbeddingCacheTTL int `mapstructure:"embedding_cache_ttl"`
 }
 
 // TimeoutDuration 返回超时时间
@@ -117,6 +146,11 @@ func (c *AgentConfig) TimeoutDuration() time.Duration {
 	return time.Duration(c.Timeout) * time.Second
 }
 
+// EmbeddingCacheTTLDuration 返回查询向量缓存时间
+func (c *AgentConfig) EmbeddingCacheTTLDuration() time.Duration {
+	return time.Duration(c.EmbeddingCacheTTL) * time.Second
+}
+
 // LogConfig 日志配置
 type LogConfig struct {
 	Level      string `mapstructure:"level"`
@@ -143,6 +177,29 @@ type RateLimitConfig struct {
 	Enabled           bool `mapstructure:"enabled"`
 	RequestsPerSecond int  `mapstructure:"requests_per_second"`
 	Burst             int  `mapstructure:"burst"`
+
+	// LikesPerMinute/LikeBurst 点赞防刷限流，同一用户、同一设备分别计数，不受 Enabled 控制
+	LikesPerMinute int `mapstructure:"likes_per_minute"`
+	LikeBurst      int `mapstructure:"like_burst"`
+
+	// Whitelist/Blacklist IP 名单，支持 CIDR 与单个 IP：白名单豁免限流，黑名单直接返回 403，不受 Enabled 控制
+	Whitelist []string `mapstructure:"whitelist"`
+	Blacklist []string `mapstructure:"blacklist"`
+
+	// Routes 按路由组单独配置的限流，在全局限流之外叠加，同一用户（匿名按 IP）计数，受 Enabled 控制
+	Routes RouteRateLimitsConfig `mapstructure:"routes"`
+}
+
+// RouteRateLimitsConfig 各路由组的限流配置
+type RouteRateLimitsConfig struct {
+	Generate           RouteRateLimitConfig `mapstructure:"generate"`
+	KnowledgeDocuments RouteRateLimitConfig `mapstructure:"knowledge_documents"`
+}
+
+// RouteRateLimitConfig 单个路由组的限流参数，0 表示使用默认值
+type RouteRateLimitConfig struct {
+	RequestsPerMinute int `mapstructure:"requests_per_minute"`
+	Burst             int `mapstructure:"burst"`
 }
 
 // UploadConfig 上传配置
@@ -152,6 +209,85 @@ type UploadConfig struct {
 	StoragePath  string   `mapstructure:"storage_path"`
 }
 
+// LessonConfig 教案配置
+type LessonConfig struct {
+	// MaxVersions 每个教案保留的最近版本数，发布时的关键版本不计入且永不清理；0 表示不限制
+	MaxVersions int `mapstructure:"max_versions"`
+
+	// ComplianceRules 课标合规检查规则，为空时使用内置规则
+	ComplianceRules []ComplianceRule `mapstructure:"compliance_rules"`
+
+	// AutoArchiveDays 已发布教案连续多少天未更新且无点赞、收藏、评论时自动归档，0 表示不自动归档；
+	// AutoArchiveHour 每天执行归档的时刻（服务器本地时间 0-23 点）
+	AutoArchiveDays int `mapstructure:"auto_archive_days"`
+	AutoArchiveHour int `mapstructure:"auto_archive_hour"`
+
+	// KnowledgeLinkURL 前端知识图谱页地址，导出时知识点链接为 {url}?topic={名称}；为空时使用相对地址 /knowledge
+	KnowledgeLinkURL string `mapstructure:"knowledge_link_url"`
+}
+
+// ComplianceRule 课标合规规则：在指定字段中查找任一关键词，并可要求最少字数
+type ComplianceRule struct {
+	ID   string `mapstructure:"id"`
+	Name string `mapstructure:"name"`
+	// Fields 检查的教案字段：objectives、content、activities、assessment、resources，为空表示全部字段
+	Fields []string `mapstructure:"fields"`
+	// Keywords 命中任一即满足，为空时只检查字段有内容
+	Keywords  []string `mapstructure:"keywords"`
+	MinLength int      `mapstructure:"min_length"`
+	// Subjects 仅对这些学科生效，为空对所有学科生效
+	Subjects   []string `mapstructure:"subjects"`
+	Suggestion string   `mapstructure:"suggestion"`
+}
+
+// ComplianceRuleFields 合规规则可检查的教案字段
+var ComplianceRuleFields = []string{"objectives", "content", "activities", "assessment", "resources"}
+
+// MailConfig 邮件配置，host 为空时不发信，只把邮件内容写入日志
+type MailConfig struct {
+	Host     string `mapstructure:"host"`
+	Port     int    `mapstructure:"port"`
+	Username string `mapstructure:"username"`
+	Password string `mapstructure:"password"`
+	From     string `mapstructure:"from"`
+	// ConfirmURL 前端邮箱验证页地址，验证令牌以 token 查询参数追加
+	ConfirmURL string `mapstructure:"confirm_url"`
+	// VerifyURL 前端注册邮箱验证页地址，验证令牌以 token 查询参数追加
+	VerifyURL string `mapstructure:"verify_url"`
+	// ResetPasswordURL 前端重置密码页地址，重置令牌以 token 查询参数追加
+	ResetPasswordURL string `mapstructure:"reset_password_url"`
+}
+
+// KnowledgeConfig 知识检索配置
+type KnowledgeConfig struct {
+	// Synonyms 同义词组，查询包含组内某个词时以组内其他词扩展检索
+	Synonyms [][]string `mapstructure:"synonyms"`
+}
+
+// NotificationConfig 通知邮件配置
+type NotificationConfig struct {
+	// DigestHour 每日摘要的发送时刻（服务器本地时间 0-23 点）
+	DigestHour int `mapstructure:"digest_hour"`
+	// LessonURL 前端教案详情页地址前缀，邮件中以 /{id} 追加
+	LessonURL string `mapstructure:"lesson_url"`
+}
+
+// ModerationConfig 生成内容安全审核配置
+type ModerationConfig struct {
+	Enabled bool `mapstructure:"enabled"`
+	// BlockedKeywords 命中任一关键词（不区分大小写）即转人工复核
+	BlockedKeywords []string `mapstructure:"blocked_keywords"`
+	// APIURL 外部审核接口，为空时只按关键词审核；接口不可用时同样转人工复核
+	APIURL  string `mapstructure:"api_url"`
+	APIKey  string `mapstructure:"api_key"`
+	Timeout int    `mapstructure:"timeout"`
+}
+
+// TimeoutDuration 返回外部审核接口超时时间
+func (c *ModerationConfig) TimeoutDuration() time.Duration {
+	return time.Duration(c.Timeout) * time.Second
+}
+
 var cfg *Config
 
 // Load 加载配置
@@ -167,7 +303,15 @@ func Load(configPath string) (*Config, error) {
 		return nil, fmt.Errorf("failed to read config file: %w", err)
 	}
 
-	// 环境变量替换
+	// 绑定环境变量：LP_ 前缀 + 配置路径（如 LP_DATABASE_POSTGRES_HOST），需在占位符替换前完成
+	v.SetEnvPrefix(envPrefix)
+	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
+	v.AutomaticEnv()
+	if err := bindEnvKeys(v, reflect.TypeOf(Config{}), ""); err != nil {
+		return nil, fmt.Errorf("failed to bind env: %w", err)
+	}
+
+	// 环境变量替换（已被 LP_ 变量覆盖的键不再是占位符，自然跳过）
 	for _, key := range v.AllKeys() {
 		val := v.GetString(key)
 		if strings.HasPrefix(val, "${") && strings.Contains(val, "}") {
@@ -176,22 +320,71 @@ func Load(configPath string) (*Config, error) {
 		}
 	}
 
-	// 绑定环境变量
-	v.AutomaticEnv()
-	v.SetEnvKeyReplacer(strings.NewReplacer(".", "_"))
-
 	// 解析配置
 	cfg = &Config{}
 	if err := v.Unmarshal(cfg); err != nil {
 		return nil, fmt.Errorf("failed to unmarshal config: %w", err)
 	}
 
-	// 从环境变量覆盖配置
-	overrideFromEnv(cfg)
-
 	return cfg, nil
 }
 
+// envPrefix 配置项环境变量前缀
+const envPrefix = "LP"
+
+// legacyEnvAliases 兼容历史环境变量名，优先级低于 LP_ 前缀变量
+var legacyEnvAliases = map[string]string{
+	"database.postgres.host":     "DB_HOST",
+	"database.postgres.user":     "DB_USER",
+	"database.postgres.password": "DB_PASSWORD",
+	"database.postgres.name":     "DB_NAME",
+	"database.neo4j.uri":         "NEO4J_URI",
+	"database.neo4j.user":        "NEO4J_USER",
+	"database.neo4j.password":    "NEO4J_PASSWORD",
+	"database.redis.host":        "REDIS_HOST",
+	"database.redis.password":    "REDIS_PASSWORD",
+	"jwt.secret":                 "JWT_SECRET",
+	"agent.url":                  "AGENT_SERVICE_URL",
+}
+
+// envKey 返回配置路径对应的环境变量名
+func envKey(key string) string {
+	return envPrefix + "_" + strings.ToUpper(strings.ReplaceAll(key, ".", "_"))
+}
+
+// bindEnvKeys 按 mapstructure 标签遍历配置结构，为每个叶子字段绑定环境变量，
+// 使配置文件中未出现的字段也能通过环境变量设置，新增配置无需手工维护
+func bindEnvKeys(v *viper.Viper, t reflect.Type, parent string) error {
+	for i := 0; i < t.NumField(); i++ {
+		field := t.Field(i)
+		tag := field.Tag.Get("mapstructure")
+		if tag == "" || tag == "-" {
+			continue
+		}
+
+		key := tag
+		if parent != "" {
+			key = parent + "." + tag
+		}
+
+		if field.Type.Kind() == reflect.Struct {
+			if err := bindEnvKeys(v, field.Type, key); err != nil {
+				return err
+			}
+			continue
+		}
+
+		names := []string{key, envKey(key)}
+		if legacy, ok := legacyEnvAliases[key]; ok {
+			names = append(names, legacy)
+		}
+		if err := v.BindEnv(names...); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 // resolveEnvVar 解析环境变量格式 ${VAR:default}
 func resolveEnvVar(val string) string {
 	// 移除 ${ 和 }
@@ -213,52 +406,6 @@ func resolveEnvVar(val string) string {
 	return defaultVal
 }
 
-// overrideFromEnv 从环境变量覆盖配置
-func overrideFromEnv(cfg *Config) {
-	// 数据库配置
-	if host := os.Getenv("DB_HOST"); host != "" {
-		cfg.Database.Postgres.Host = host
-	}
-	if user := os.Getenv("DB_USER"); user != "" {
-		cfg.Database.Postgres.User = user
-	}
-	if password := os.Getenv("DB_PASSWORD"); password != "" {
-		cfg.Database.Postgres.Password = password
-	}
-	if name := os.Getenv("DB_NAME"); name != "" {
-		cfg.Database.Postgres.Name = name
-	}
-
-	// Neo4j配置
-	if uri := os.Getenv("NEO4J_URI"); uri != "" {
-		cfg.Database.Neo4j.URI = uri
-	}
-	if user := os.Getenv("NEO4J_USER"); user != "" {
-		cfg.Database.Neo4j.User = user
-	}
-	if password := os.Getenv("NEO4J_PASSWORD"); password != "" {
-		cfg.Database.Neo4j.Password = password
-	}
-
-	// Redis配置
-	if host := os.Getenv("REDIS_HOST"); host != "" {
-		cfg.Database.Redis.Host = host
-	}
-	if password := os.Getenv("REDIS_PASSWORD"); password != "" {
-		cfg.Database.Redis.Password = password
-	}
-
-	// JWT配置
-	if secret := os.Getenv("JWT_SECRET"); secret != "" {
-		cfg.JWT.Secret = secret
-	}
-
-	// Agent配置
-	if url := os.Getenv("AGENT_SERVICE_URL"); url != "" {
-		cfg.Agent.URL = url
-	}
-}
-
 // Get 获取配置实例
 func Get() *Config {
 	return cfg
@@ -337,6 +484,9 @@ func (c *Config) Validate() error {
 	if c.Agent.Timeout <= 0 {
 		errs = append(errs, "agent.timeout 必须大于 0")
 	}
+	if c.Agent.EmbeddingCacheTTL < 0 {
+		errs = append(errs, "agent.embedding_cache_ttl 不能为负数")
+	}
 
 	if c.RateLimit.Enabled {
 		if c.RateLimit.RequestsPerSecond <= 0 {
@@ -346,11 +496,62 @@ func (c *Config) Validate() error {
 			errs = append(errs, "rate_limit.burst 必须大于 0")
 		}
 	}
+	if c.RateLimit.LikesPerMinute < 0 || c.RateLimit.LikeBurst < 0 {
+		errs = append(errs, "rate_limit.likes_per_minute 与 rate_limit.like_burst 不能为负数")
+	}
+	for name, route := range map[string]RouteRateLimitConfig{
+		"generate":            c.RateLimit.Routes.Generate,
+		"knowledge_documents": c.RateLimit.Routes.KnowledgeDocuments,
+	} {
+		if route.RequestsPerMinute < 0 || route.Burst < 0 {
+			errs = append(errs, fmt.Sprintf("rate_limit.routes.%s 的 requests_per_minute 与 burst 不能为负数", name))
+		}
+	}
+	for _, entry := range c.App.TrustedProxies {
+		if !isValidIPOrCIDR(entry) {
+			errs = append(errs, fmt.Sprintf("app.trusted_proxies 条目无效: %s", entry))
+		}
+	}
+	for _, entry := range c.RateLimit.Whitelist {
+		if !isValidIPOrCIDR(entry) {
+			errs = append(errs, fmt.Sprintf("rate_limit.whitelist 条目无效: %s", entry))
+		}
+	}
+	for _, entry := range c.RateLimit.Blacklist {
+		if !isValidIPOrCIDR(entry) {
+			errs = append(errs, fmt.Sprintf("rate_limit.blacklist 条目无效: %s", entry))
+		}
+	}
+	if c.Moderation.Enabled {
+		if len(c.Moderation.BlockedKeywords) == 0 && strings.TrimSpace(c.Moderation.APIURL) == "" {
+			errs = append(errs, "moderation 启用时需配置 blocked_keywords 或 api_url")
+		}
+		if strings.TrimSpace(c.Moderation.APIURL) != "" && !isValidURL(c.Moderation.APIURL, "http", "https") {
+			errs = append(errs, "moderation.api_url 格式无效，需使用 http:// 或 https://")
+		}
+		if c.Moderation.Timeout < 0 {
+			errs = append(errs, "moderation.timeout 不能为负数")
+		}
+	}
+	if c.Lesson.AutoArchiveDays < 0 {
+		errs = append(errs, "lesson.auto_archive_days 不能为负数")
+	}
+	if c.Lesson.AutoArchiveHour < 0 || c.Lesson.AutoArchiveHour > 23 {
+		errs = append(errs, "lesson.auto_archive_hour 需在 0-23 之间")
+	}
+	if c.Notification.DigestHour < 0 || c.Notification.DigestHour > 23 {
+		errs = append(errs, "notification.digest_hour 需在 0-23 之间")
+	}
 
 	if c.Upload.MaxSize <= 0 {
 		errs = append(errs, "upload.max_size 必须大于 0")
 	}
 
+	if c.Lesson.MaxVersions < 0 {
+		errs = append(errs, "lesson.max_versions 不能为负数")
+	}
+	errs = append(errs, validateComplianceRules(c.Lesson.ComplianceRules)...)
+
 	if len(errs) > 0 {
 		return fmt.Errorf("配置校验失败:\n- %s", strings.Join(errs, "\n- "))
 	}
@@ -358,6 +559,37 @@ func (c *Config) Validate() error {
 	return nil
 }
 
+func validateComplianceRules(rules []ComplianceRule) []string {
+	var errs []string
+	seen := make(map[string]bool, len(rules))
+	for i, rule := range rules {
+		id := strings.TrimSpace(rule.ID)
+		if id == "" {
+			errs = append(errs, fmt.Sprintf("lesson.compliance_rules[%d].id 不能为空", i))
+		} else if seen[id] {
+			errs = append(errs, fmt.Sprintf("lesson.compliance_rules 规则 %s 重复", id))
+		}
+		seen[id] = true
+
+		for _, field := range rule.Fields {
+			valid := false
+			for _, allowed := range ComplianceRuleFields {
+				if field == allowed {
+					valid = true
+					break
+				}
+			}
+			if !valid {
+				errs = append(errs, fmt.Sprintf("lesson.compliance_rules 规则 %s 的字段 %s 无效", id, field))
+			}
+		}
+		if rule.MinLength < 0 {
+			errs = append(errs, fmt.Sprintf("lesson.compliance_rules 规则 %s 的 min_length 不能为负数", id))
+		}
+	}
+	return errs
+}
+
 func isValidURL(raw string, schemes ...string) bool {
 	parsed, err := url.Parse(raw)
 	if err != nil {
@@ -380,6 +612,16 @@ func isValidURL(raw string, schemes ...string) bool {
 	return false
 }
 
+// isValidIPOrCIDR 校验名单条目：CIDR 或单个 IP
+func isValidIPOrCIDR(entry string) bool {
+	entry = strings.TrimSpace(entry)
+	if strings.Contains(entry, "/") {
+		_, _, err := net.ParseCIDR(entry)
+		return err == nil
+	}
+	return net.ParseIP(entry) != nil
+}
+
 func looksLikePlaceholder(value string) bool {
 	lower := strings.ToLower(strings.TrimSpace(value))
 	return strings.Contains(lower, "change-in-production") ||
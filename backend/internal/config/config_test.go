@@ -0,0 +1,108 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const testConfigYAML = `
+app:
+  name: lesson-plan
+  port: 8080
+database:
+  postgres:
+    host: "${DB_HOST:localhost}"
+    port: 5432
+agent:
+  url: "http://agent:3000"
+  timeout: 60
+`
+
+func writeTestConfig(t *testing.T) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "config.yaml")
+	if err := os.WriteFile(path, []byte(testConfigYAML), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadEnvOverrides(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   map[string]string
+		check func(t *testing.T, cfg *Config)
+	}{
+		{
+			name: "file values without env",
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.App.Port != 8080 || cfg.Database.Postgres.Host != "localhost" || cfg.Agent.Timeout != 60 {
+					t.Errorf("got port=%d host=%q timeout=%d", cfg.App.Port, cfg.Database.Postgres.Host, cfg.Agent.Timeout)
+				}
+			},
+		},
+		{
+			name: "LP_ variable overrides a key in the file",
+			env:  map[string]string{"LP_APP_PORT": "9090", "LP_AGENT_URL": "http://other:3000"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.App.Port != 9090 || cfg.Agent.URL != "http://other:3000" {
+					t.Errorf("got port=%d url=%q", cfg.App.Port, cfg.Agent.URL)
+				}
+			},
+		},
+		{
+			name: "LP_ variable sets a key missing from the file",
+			env:  map[string]string{"LP_UPLOAD_MAX_SIZE": "1048576", "LP_LESSON_MAX_VERSIONS": "7"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Upload.MaxSize != 1<<20 || cfg.Lesson.MaxVersions != 7 {
+					t.Errorf("got max_size=%d max_versions=%d", cfg.Upload.MaxSize, cfg.Lesson.MaxVersions)
+				}
+			},
+		},
+		{
+			name: "legacy variable still works",
+			env:  map[string]string{"DB_HOST": "legacy-db"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.Postgres.Host != "legacy-db" {
+					t.Errorf("host = %q, want legacy-db", cfg.Database.Postgres.Host)
+				}
+			},
+		},
+		{
+			name: "LP_ variable wins over legacy variable",
+			env:  map[string]string{"DB_HOST": "legacy-db", "LP_DATABASE_POSTGRES_HOST": "lp-db"},
+			check: func(t *testing.T, cfg *Config) {
+				if cfg.Database.Postgres.Host != "lp-db" {
+					t.Errorf("host = %q, want lp-db", cfg.Database.Postgres.Host)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			for key, value := range tt.env {
+				t.Setenv(key, value)
+			}
+			cfg, err := Load(writeTestConfig(t))
+			if err != nil {
+				t.Fatalf("Load() error = %v", err)
+			}
+			tt.check(t, cfg)
+		})
+	}
+}
+
+func TestEnvKey(t *testing.T) {
+	tests := map[string]string{
+		"app.port":                "LP_APP_PORT",
+		"database.postgres.host":  "LP_DATABASE_POSTGRES_HOST",
+		"rate_limit.requests_per": "LP_RATE_LIMIT_REQUESTS_PER",
+	}
+	for key, want := range tests {
+		if got := envKey(key); got != want {
+			t.Errorf("envKey(%q) = %q, want %q", key, got, want)
+		}
+	}
+}
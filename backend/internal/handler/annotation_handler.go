@@ -0,0 +1,159 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// AnnotationHandler 教案批注处理器
+type AnnotationHandler struct {
+	annotationService service.AnnotationService
+}
+
+// NewAnnotationHandler 创建批注处理器
+func NewAnnotationHandler(annotationService service.AnnotationService) *AnnotationHandler {
+	return &AnnotationHandler{
+		annotationService: annotationService,
+	}
+}
+
+// parseIDs 解析当前用户、教案ID和可选的批注ID
+func (h *AnnotationHandler) parseIDs(c *gin.Context, withAnnotation bool) (userID, lessonID, annotationID uuid.UUID, ok bool) {
+	userIDStr, authed := middleware.GetCurrentUserID(c)
+	if !authed {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+	var err error
+	if userID, err = uuid.Parse(userIDStr); err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+	if lessonID, err = uuid.Parse(c.Param("id")); err != nil {
+		Error(c, http.StatusBadRequest, "无效的教案ID", nil)
+		return
+	}
+	if withAnnotation {
+		if annotationID, err = uuid.Parse(c.Param("annotationId")); err != nil {
+			Error(c, http.StatusBadRequest, "无效的批注ID", nil)
+			return
+		}
+	}
+	ok = true
+	return
+}
+
+// List 获取教案批注列表，可按 field、status 过滤
+func (h *AnnotationHandler) List(c *gin.Context) {
+	userID, lessonID, _, ok := h.parseIDs(c, false)
+	if !ok {
+		return
+	}
+
+	field := strings.TrimSpace(c.Query("field"))
+	if field != "" && !service.IsAnnotatableField(field) {
+		Error(c, http.StatusBadRequest, "不支持批注的字段", nil)
+		return
+	}
+	status := strings.TrimSpace(c.Query("status"))
+
+	annotations, err := h.annotationService.List(c.Request.Context(), lessonID, userID, field, status)
+	if err != nil {
+		h.handleError(c, err, "获取批注失败")
+		return
+	}
+
+	Success(c, annotations)
+}
+
+// Create 创建批注
+func (h *AnnotationHandler) Create(c *gin.Context) {
+	userID, lessonID, _, ok := h.parseIDs(c, false)
+	if !ok {
+		return
+	}
+
+	var req service.CreateAnnotationRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	annotation, err := h.annotationService.Create(c.Request.Context(), lessonID, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "创建批注失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Code:    0,
+		Message: "批注成功",
+		Data:    annotation,
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
+
+// UpdateStatus 标记批注为已处理/重新打开
+func (h *AnnotationHandler) UpdateStatus(c *gin.Context) {
+	userID, lessonID, annotationID, ok := h.parseIDs(c, true)
+	if !ok {
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+	if req.Status != model.AnnotationStatusOpen && req.Status != model.AnnotationStatusResolved {
+		Error(c, http.StatusBadRequest, "无效的批注状态，请使用 open 或 resolved", nil)
+		return
+	}
+
+	annotation, err := h.annotationService.UpdateStatus(c.Request.Context(), lessonID, annotationID, userID, req.Status)
+	if err != nil {
+		h.handleError(c, err, "更新批注失败")
+		return
+	}
+
+	Success(c, annotation)
+}
+
+// Delete 删除批注
+func (h *AnnotationHandler) Delete(c *gin.Context) {
+	userID, lessonID, annotationID, ok := h.parseIDs(c, true)
+	if !ok {
+		return
+	}
+
+	if err := h.annotationService.Delete(c.Request.Context(), lessonID, annotationID, userID); err != nil {
+		h.handleError(c, err, "删除批注失败")
+		return
+	}
+
+	SuccessWithMessage(c, "删除成功", nil)
+}
+
+func (h *AnnotationHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrLessonNotFound), errors.Is(err, service.ErrAnnotationNotFound):
+		Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrUnauthorized):
+		Error(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrInvalidAnnotation), errors.Is(err, service.ErrAnnotationQuoteMatch):
+		Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// APIKeyHandler API Key 管理处理器
+type APIKeyHandler struct {
+	apiKeyService service.APIKeyService
+}
+
+// NewAPIKeyHandler 创建 API Key 管理处理器
+func NewAPIKeyHandler(apiKeyService service.APIKeyService) *APIKeyHandler {
+	return &APIKeyHandler{apiKeyService: apiKeyService}
+}
+
+// List 当前用户的 API Key 列表（不含明文）
+func (h *APIKeyHandler) List(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	keys, err := h.apiKeyService.List(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取 API Key 失败", err.Error())
+		return
+	}
+
+	Success(c, keys)
+}
+
+// Create 创建 API Key，明文仅在本次响应中返回
+func (h *APIKeyHandler) Create(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req service.CreateAPIKeyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	key, err := h.apiKeyService.Create(c.Request.Context(), userUUID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyAPIKeys) {
+			Error(c, http.StatusBadRequest, "API Key 数量已达上限，请先吊销不用的 Key", nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "创建 API Key 失败", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Code:    0,
+		Message: "API Key 已创建，请立即保存，之后将无法再次查看",
+		Data:    key,
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
+
+// Revoke 吊销 API Key
+func (h *APIKeyHandler) Revoke(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	if err := h.apiKeyService.Revoke(c.Request.Context(), id, userUUID); err != nil {
+		if errors.Is(err, service.ErrAPIKeyNotFound) {
+			Error(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "吊销 API Key 失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "API Key 已吊销", nil)
+}
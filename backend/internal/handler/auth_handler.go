@@ -3,6 +3,7 @@ package handler
 import (
 	"errors"
 	"net/http"
+	"strings"
 
 	"lesson-plan/backend/internal/middleware"
 	"lesson-plan/backend/internal/service"
@@ -39,11 +40,15 @@ func (h *AuthHandler) Register(c *gin.Context) {
 			Error(c, http.StatusConflict, "用户已存在", nil)
 			return
 		}
+		if errors.Is(err, service.ErrWeakPassword) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
 		Error(c, http.StatusInternalServerError, "注册失败", err.Error())
 		return
 	}
 
-	Success(c, user.ToProfile())
+	SuccessWithMessage(c, "注册成功，请查收验证邮件激活账号", user.ToProfile())
 }
 
 // Login 登录
@@ -60,6 +65,10 @@ func (h *AuthHandler) Login(c *gin.Context) {
 			Error(c, http.StatusUnauthorized, "用户名/邮箱或密码错误", nil)
 			return
 		}
+		if errors.Is(err, service.ErrEmailNotVerified) {
+			Error(c, http.StatusForbidden, err.Error(), nil)
+			return
+		}
 		if errors.Is(err, service.ErrUserInactive) {
 			Error(c, http.StatusForbidden, "用户已被禁用", nil)
 			return
@@ -91,12 +100,41 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	Success(c, resp)
 }
 
-// Logout 登出
+// Logout 登出：当前访问令牌立即失效，请求体可选携带 refresh_token 一并作废
 func (h *AuthHandler) Logout(c *gin.Context) {
-	// 简单登出，客户端清除token即可
+	accessToken := bearerToken(c)
+	if accessToken == "" {
+		Error(c, http.StatusBadRequest, "仅登录令牌支持登出", nil)
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			Error(c, http.StatusBadRequest, "参数错误", err.Error())
+			return
+		}
+	}
+
+	if err := h.authService.Logout(c.Request.Context(), accessToken, req.RefreshToken); err != nil {
+		Error(c, http.StatusInternalServerError, "登出失败", err.Error())
+		return
+	}
+
 	SuccessWithMessage(c, "登出成功", nil)
 }
 
+// bearerToken 读取 Authorization 头中的 Bearer 令牌，不存在时返回空字符串
+func bearerToken(c *gin.Context) string {
+	fields := strings.Fields(c.GetHeader(middleware.AuthorizationHeaderKey))
+	if len(fields) < 2 || !strings.EqualFold(fields[0], middleware.AuthorizationTypeBearer) {
+		return ""
+	}
+	return fields[1]
+}
+
 // ChangePassword 修改密码
 func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -121,6 +159,10 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 			Error(c, http.StatusBadRequest, "旧密码错误", nil)
 			return
 		}
+		if errors.Is(err, service.ErrWeakPassword) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
 		Error(c, http.StatusInternalServerError, "修改密码失败", err.Error())
 		return
 	}
@@ -128,6 +170,114 @@ func (h *AuthHandler) ChangePassword(c *gin.Context) {
 	SuccessWithMessage(c, "密码修改成功", nil)
 }
 
+// ConfirmEmail 确认邮箱变更（验证链接落地页调用，无需登录）
+func (h *AuthHandler) ConfirmEmail(c *gin.Context) {
+	var req service.ConfirmEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	result, err := h.userService.ConfirmEmailChange(c.Request.Context(), req.Token)
+	if err != nil {
+		writeEmailChangeError(c, err)
+		return
+	}
+
+	SuccessWithMessage(c, "邮箱修改成功", result)
+}
+
+// VerifyEmail 注册邮箱验证（验证链接落地页调用，无需登录）
+func (h *AuthHandler) VerifyEmail(c *gin.Context) {
+	token := strings.TrimSpace(c.Query("token"))
+	if token == "" {
+		Error(c, http.StatusBadRequest, "缺少验证令牌", nil)
+		return
+	}
+
+	user, err := h.authService.VerifyEmail(c.Request.Context(), token)
+	if err != nil {
+		writeEmailVerificationError(c, err)
+		return
+	}
+
+	SuccessWithMessage(c, "邮箱验证成功，账号已激活", user.ToProfile())
+}
+
+// ResendVerification 重新发送注册验证邮件
+func (h *AuthHandler) ResendVerification(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	if err := h.authService.ResendVerification(c.Request.Context(), req.Email); err != nil {
+		writeEmailVerificationError(c, err)
+		return
+	}
+
+	SuccessWithMessage(c, "如果该邮箱已注册且尚未验证，验证邮件已发送", nil)
+}
+
+// ForgotPassword 发送重置密码邮件；无论邮箱是否注册都返回相同结果
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req struct {
+		Email string `json:"email" binding:"required,email"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	if err := h.authService.ForgotPassword(c.Request.Context(), req.Email); err != nil {
+		Error(c, http.StatusInternalServerError, "发送重置邮件失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "如果该邮箱已注册，重置密码邮件已发送", nil)
+}
+
+// ResetPassword 通过邮件中的一次性链接重置密码（无需登录）
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req service.ResetPasswordRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	if err := h.authService.ResetPassword(c.Request.Context(), req.Token, req.NewPassword); err != nil {
+		switch {
+		case errors.Is(err, service.ErrPasswordResetTokenInvalid), errors.Is(err, service.ErrWeakPassword):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrUserNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "重置密码失败", err.Error())
+		}
+		return
+	}
+
+	SuccessWithMessage(c, "密码已重置，请使用新密码登录", nil)
+}
+
+func writeEmailVerificationError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrEmailVerifyTokenInvalid), errors.Is(err, service.ErrEmailVerifyTokenExpired):
+		Error(c, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, service.ErrEmailAlreadyVerified):
+		Error(c, http.StatusConflict, err.Error(), nil)
+	case errors.Is(err, service.ErrVerificationResendTooSoon):
+		Error(c, http.StatusTooManyRequests, err.Error(), nil)
+	case errors.Is(err, service.ErrUserNotFound):
+		Error(c, http.StatusNotFound, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, "邮箱验证失败", err.Error())
+	}
+}
+
 // GetCurrentUser 获取当前用户信息
 func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
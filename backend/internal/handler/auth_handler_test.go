@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// memoryDenylist 内存中的令牌黑名单
+type memoryDenylist map[string]bool
+
+func (d memoryDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return d[jti], nil
+}
+
+func (d memoryDenylist) IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error) {
+	return false, nil
+}
+
+// logoutAuthService 登出时将访问令牌与刷新令牌写入内存黑名单
+type logoutAuthService struct {
+	service.AuthService
+	jwtManager *jwt.Manager
+	denylist   memoryDenylist
+}
+
+func (s *logoutAuthService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	for _, token := range []string{accessToken, refreshToken} {
+		if jti, _, err := s.jwtManager.ExtractTokenID(token); err == nil {
+			s.denylist[jti] = true
+		}
+	}
+	return nil
+}
+
+func TestLogoutRejectsSameToken(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	denylist := memoryDenylist{}
+	h := NewAuthHandler(&logoutAuthService{jwtManager: jwtManager, denylist: denylist}, nil)
+
+	r := gin.New()
+	auth := middleware.AuthMiddleware(jwtManager, nil, denylist)
+	r.POST("/logout", auth, h.Logout)
+	r.GET("/me", auth, func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	pair, err := jwtManager.GenerateTokenPair(uuid.NewString(), "teacher", "teacher@example.com", "teacher", "")
+	if err != nil {
+		t.Fatalf("GenerateTokenPair() error = %v", err)
+	}
+	access, refresh := pair.AccessToken, pair.RefreshToken
+	do := func(method, path, token, body string) int {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		req.Header.Set("Authorization", "Bearer "+token)
+		req.Header.Set("Content-Type", "application/json")
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+
+	if code := do(http.MethodGet, "/me", access, ""); code != http.StatusOK {
+		t.Fatalf("GET /me before logout = %d, want 200", code)
+	}
+	if code := do(http.MethodPost, "/logout", access, `{"refresh_token":"`+refresh+`"}`); code != http.StatusOK {
+		t.Fatalf("POST /logout = %d, want 200", code)
+	}
+	if code := do(http.MethodGet, "/me", access, ""); code != http.StatusUnauthorized {
+		t.Errorf("GET /me with logged out token = %d, want 401", code)
+	}
+	if code := do(http.MethodPost, "/logout", access, ""); code != http.StatusUnauthorized {
+		t.Errorf("POST /logout again = %d, want 401", code)
+	}
+	if jti, _, _ := jwtManager.ExtractTokenID(refresh); !denylist[jti] {
+		t.Error("refresh token from the request body was not revoked")
+	}
+}
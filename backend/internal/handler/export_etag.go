@@ -0,0 +1,32 @@
+package handler
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// exportETag 由渲染后的 Markdown 与导出选项计算 ETag。
+// 教案更新、回滚、发布等任何改变导出内容的操作都会改变哈希，无需在各写入路径上单独失效
+func exportETag(mdContent, format, layout string, toc bool) string {
+	sum := sha256.Sum256([]byte(fmt.Sprintf("%s|%s|%t|%s", format, layout, toc, mdContent)))
+	return `"` + hex.EncodeToString(sum[:16]) + `"`
+}
+
+// exportNotModified 写入 ETag，客户端缓存仍有效时返回 304 并跳过格式转换
+func exportNotModified(c *gin.Context, etag string) bool {
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "private, no-cache")
+	for _, candidate := range strings.Split(c.GetHeader("If-None-Match"), ",") {
+		candidate = strings.TrimPrefix(strings.TrimSpace(candidate), "W/")
+		if candidate == etag || candidate == "*" {
+			c.Status(http.StatusNotModified)
+			return true
+		}
+	}
+	return false
+}
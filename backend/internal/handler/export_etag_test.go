@@ -0,0 +1,79 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// exportLessonService 返回可在测试中修改的教案，模拟写入后再次读取
+type exportLessonService struct {
+	service.LessonService
+	lesson *model.LessonDetail
+}
+
+func (s *exportLessonService) GetByID(ctx context.Context, id uuid.UUID, currentUserID *uuid.UUID) (*model.LessonDetail, error) {
+	copied := *s.lesson
+	return &copied, nil
+}
+
+func TestExportETag(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lesson := formulaLesson()
+	lesson.ID = uuid.New()
+	svc := &exportLessonService{lesson: lesson}
+	h := &LessonHandler{lessonService: svc}
+	r := gin.New()
+	r.GET("/lessons/:id/export", h.Export)
+
+	export := func(t *testing.T, query, ifNoneMatch string) *httptest.ResponseRecorder {
+		t.Helper()
+		req := httptest.NewRequest(http.MethodGet, "/lessons/"+lesson.ID.String()+"/export"+query, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w
+	}
+
+	first := export(t, "", "")
+	etag := first.Header().Get("ETag")
+	if first.Code != http.StatusOK || etag == "" {
+		t.Fatalf("export = %d with ETag %q, want 200 with an ETag", first.Code, etag)
+	}
+
+	// 内容未变时复用缓存
+	if w := export(t, "", etag); w.Code != http.StatusNotModified || w.Body.Len() != 0 {
+		t.Errorf("export with current ETag = %d (%d bytes), want 304 without body", w.Code, w.Body.Len())
+	}
+	if w := export(t, "", `W/`+etag+`, "other"`); w.Code != http.StatusNotModified {
+		t.Errorf("export with weak ETag list = %d, want 304", w.Code)
+	}
+
+	// 导出选项不同则 ETag 不同
+	if w := export(t, "?toc=true", etag); w.Code != http.StatusOK || w.Header().Get("ETag") == etag {
+		t.Errorf("export with toc = %d with ETag %q, want 200 with a new ETag", w.Code, w.Header().Get("ETag"))
+	}
+
+	// 教案写入后旧 ETag 失效
+	svc.lesson.Title = "一元二次方程（修订）"
+	w := export(t, "", etag)
+	if w.Code != http.StatusOK {
+		t.Fatalf("export after update with stale ETag = %d, want 200", w.Code)
+	}
+	updated := w.Header().Get("ETag")
+	if updated == "" || updated == etag {
+		t.Errorf("ETag after update = %q, want different from %q", updated, etag)
+	}
+	if w := export(t, "", updated); w.Code != http.StatusNotModified {
+		t.Errorf("export with updated ETag = %d, want 304", w.Code)
+	}
+}
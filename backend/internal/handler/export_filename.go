@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"fmt"
+	"net/url"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// maxExportFilenameRunes 文件名主体最多保留的字符数（不含扩展名）
+	maxExportFilenameRunes = 80
+	// maxExportFilenameBytes 文件名主体的最大字节数，给扩展名和临时目录留余量，避免超过文件系统 255 字节限制
+	maxExportFilenameBytes = 200
+)
+
+// windowsReservedNames Windows 下不能作为文件名的设备名
+var windowsReservedNames = map[string]bool{
+	"CON": true, "PRN": true, "AUX": true, "NUL": true,
+	"COM1": true, "COM2": true, "COM3": true, "COM4": true, "COM5": true,
+	"COM6": true, "COM7": true, "COM8": true, "COM9": true,
+	"LPT1": true, "LPT2": true, "LPT3": true, "LPT4": true, "LPT5": true,
+	"LPT6": true, "LPT7": true, "LPT8": true, "LPT9": true,
+}
+
+// sanitizeFilename 清洗导出文件名主体：路径分隔符与各系统非法字符替换为下划线，
+// 去掉控制字符与 emoji，截断超长标题；清洗后为空时使用 fallback
+func sanitizeFilename(name, fallback string) string {
+	var sb strings.Builder
+	lastUnderscore := false
+	for _, r := range name {
+		switch {
+		case r == utf8.RuneError, unicode.IsControl(r), isEmojiRune(r):
+			continue
+		case strings.ContainsRune(`/\:*?"<>|`, r), unicode.IsSpace(r) && r != ' ':
+			r = '_'
+		}
+		if r == '_' {
+			if lastUnderscore {
+				continue
+			}
+			lastUnderscore = true
+		} else {
+			lastUnderscore = false
+		}
+		sb.WriteRune(r)
+	}
+
+	cleaned := truncateFilename(strings.Trim(sb.String(), " ._"))
+	// 截断后可能留下末尾的空格或点，Windows 不允许
+	cleaned = strings.TrimRight(cleaned, " .")
+	if cleaned == "" {
+		cleaned = fallback
+	}
+
+	base := cleaned
+	if idx := strings.IndexByte(base, '.'); idx >= 0 {
+		base = base[:idx]
+	}
+	if windowsReservedNames[strings.ToUpper(base)] {
+		cleaned = "_" + cleaned
+	}
+	return cleaned
+}
+
+// isEmojiRune 判断是否为 emoji 及其组合字符（变体选择符、零宽连接符、肤色修饰）
+func isEmojiRune(r rune) bool {
+	switch {
+	case r >= 0x1F000 && r <= 0x1FAFF:
+		return true
+	case r >= 0x2600 && r <= 0x27BF:
+		return true
+	case r >= 0xFE00 && r <= 0xFE0F, r == 0x200D, r == 0x20E3:
+		return true
+	case r >= 0xE0020 && r <= 0xE007F:
+		return true
+	}
+	return false
+}
+
+// truncateFilename 按字符数和字节数截断，保证不切断多字节字符
+func truncateFilename(name string) string {
+	runes := []rune(name)
+	if len(runes) > maxExportFilenameRunes {
+		runes = runes[:maxExportFilenameRunes]
+	}
+	for len(runes) > 0 && len(string(runes)) > maxExportFilenameBytes {
+		runes = runes[:len(runes)-1]
+	}
+	return string(runes)
+}
+
+// asciiFilename 为不支持 RFC 5987 的旧客户端生成纯 ASCII 文件名主体，去掉非 ASCII 字符后为空时使用 fallback
+func asciiFilename(name, fallback string) string {
+	var sb strings.Builder
+	for _, r := range name {
+		if r < 0x20 || r > 0x7E || r == '"' || r == '\\' || r == '%' {
+			continue
+		}
+		sb.WriteRune(r)
+	}
+	ascii := strings.Trim(sb.String(), " ._-")
+	if ascii == "" {
+		return fallback
+	}
+	return ascii
+}
+
+// setAttachmentHeader 写出附件的 Content-Disposition：filename 为 ASCII 回退名，filename* 为 UTF-8 编码的完整文件名
+func setAttachmentHeader(c *gin.Context, base, fallback, ext string) {
+	name := sanitizeFilename(base, fallback)
+	c.Header("Content-Disposition", fmt.Sprintf(
+		"attachment; filename=\"%s.%s\"; filename*=UTF-8''%s",
+		asciiFilename(name, fallback),
+		ext,
+		url.PathEscape(name+"."+ext),
+	))
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"unicode/utf8"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestSanitizeFilename(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+		want  string
+	}{
+		{name: "plain chinese", title: "勾股定理教案", want: "勾股定理教案"},
+		{name: "path separators", title: "../../etc/passwd", want: "etc_passwd"},
+		{name: "windows path", title: `C:\Users\教案`, want: "C_Users_教案"},
+		{name: "illegal characters", title: `分数<加法>:"乘法"|除法?*`, want: "分数_加法_乘法_除法"},
+		{name: "control characters dropped", title: "第一课\t第二课\n\x00结束", want: "第一课第二课结束"},
+		{name: "emoji removed", title: "🎉有理数✨复习👨‍👩‍👧", want: "有理数复习"},
+		{name: "trailing dots and spaces", title: "  期中复习. . ", want: "期中复习"},
+		{name: "empty falls back", title: "", want: "lesson-1"},
+		{name: "only illegal falls back", title: `/\:*?"<>|`, want: "lesson-1"},
+		{name: "only emoji falls back", title: "🎉🎉🎉", want: "lesson-1"},
+		{name: "reserved device name", title: "CON", want: "_CON"},
+		{name: "reserved device name with suffix", title: "nul.备份", want: "_nul.备份"},
+		{name: "reserved prefix is not reserved", title: "CONSOLE", want: "CONSOLE"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := sanitizeFilename(tt.title, "lesson-1"); got != tt.want {
+				t.Errorf("sanitizeFilename(%q) = %q, want %q", tt.title, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameTruncates(t *testing.T) {
+	tests := []struct {
+		name  string
+		title string
+	}{
+		{name: "long ascii", title: strings.Repeat("a", 300)},
+		{name: "long chinese", title: strings.Repeat("教", 300)},
+		{name: "long four-byte characters", title: strings.Repeat("𠀀", 100)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := sanitizeFilename(tt.title, "lesson-1")
+			if n := utf8.RuneCountInString(got); n > maxExportFilenameRunes {
+				t.Errorf("rune count = %d, want <= %d", n, maxExportFilenameRunes)
+			}
+			if len(got) > maxExportFilenameBytes {
+				t.Errorf("byte length = %d, want <= %d", len(got), maxExportFilenameBytes)
+			}
+			if !utf8.ValidString(got) || got == "" {
+				t.Errorf("truncated name %q is empty or cuts a multi-byte character", got)
+			}
+		})
+	}
+}
+
+func TestSanitizeFilenameFallbackAvoidsCollisions(t *testing.T) {
+	// 标题清洗后为空的教案使用各自 ID 作为文件名，不会互相覆盖
+	a := sanitizeFilename("???", "lesson-a1b2")
+	b := sanitizeFilename("***", "lesson-c3d4")
+	if a == b {
+		t.Errorf("fallback names collide: %q", a)
+	}
+}
+
+func TestAsciiFilename(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{name: "ascii kept", in: "Unit-3 Fractions", want: "Unit-3 Fractions"},
+		{name: "mixed drops non-ascii", in: "第3课 Fractions", want: "3 Fractions"},
+		{name: "quotes and percent dropped", in: `a"b\c%d`, want: "abcd"},
+		{name: "all chinese falls back", in: "勾股定理", want: "lesson-1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := asciiFilename(tt.in, "lesson-1"); got != tt.want {
+				t.Errorf("asciiFilename(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSetAttachmentHeader(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name         string
+		title        string
+		ext          string
+		wantFilename string
+		wantUTF8     string
+	}{
+		{name: "chinese title", title: "勾股定理", ext: "pdf", wantFilename: "lesson-1.pdf", wantUTF8: "勾股定理.pdf"},
+		{name: "mixed title", title: "Unit 1 有理数", ext: "docx", wantFilename: "Unit 1.docx", wantUTF8: "Unit 1 有理数.docx"},
+		{name: "quote and crlf cannot break the header", title: "a\"; filename=evil.exe\r\nX-Evil: 1", ext: "md", wantFilename: "a_; filename=evil.exeX-Evil_ 1.md", wantUTF8: "a_; filename=evil.exeX-Evil_ 1.md"},
+		{name: "empty title", title: "", ext: "md", wantFilename: "lesson-1.md", wantUTF8: "lesson-1.md"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			setAttachmentHeader(c, tt.title, "lesson-1", tt.ext)
+
+			header := w.Header().Get("Content-Disposition")
+			parts := strings.Split(header, "; filename*=UTF-8''")
+			if len(parts) != 2 || !strings.HasPrefix(parts[0], "attachment; filename=\"") {
+				t.Fatalf("Content-Disposition = %q, want filename and filename*", header)
+			}
+			if got := strings.TrimSuffix(strings.TrimPrefix(parts[0], "attachment; filename=\""), "\""); got != tt.wantFilename {
+				t.Errorf("filename = %q, want %q", got, tt.wantFilename)
+			}
+			decoded, err := url.PathUnescape(parts[1])
+			if err != nil || decoded != tt.wantUTF8 {
+				t.Errorf("filename* = %q (decoded %q, %v), want %q", parts[1], decoded, err, tt.wantUTF8)
+			}
+			if strings.ContainsAny(parts[1], " \"\r\n;") {
+				t.Errorf("filename* %q is not percent-encoded", parts[1])
+			}
+		})
+	}
+}
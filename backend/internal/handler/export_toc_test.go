@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubPandoc 在 PATH 中放入假的 pandoc：记录参数并创建 -o 指定的输出文件，返回参数记录文件路径
+func stubPandoc(t *testing.T) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	script := `#!/bin/sh
+printf '%s\n' "$@" > "` + argsFile + `"
+while [ $# -gt 0 ]; do
+	if [ "$1" = "-o" ]; then : > "$2"; fi
+	shift
+done
+`
+	if err := os.WriteFile(filepath.Join(dir, "pandoc"), []byte(script), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir+string(os.PathListSeparator)+os.Getenv("PATH"))
+	return argsFile
+}
+
+func TestConvertWithPandocTOC(t *testing.T) {
+	argsFile := stubPandoc(t)
+	h := &LessonHandler{uploadDir: t.TempDir()}
+	md := h.generateMarkdown(formulaLesson(), "standard", defaultExportSections)
+	if !strings.Contains(md, "\n## ") {
+		t.Fatalf("markdown has no level-2 headings for the table of contents:\n%s", md)
+	}
+
+	tests := []struct {
+		name    string
+		format  string
+		toc     bool
+		wantTOC bool
+	}{
+		{name: "pdf with toc", format: "pdf", toc: true, wantTOC: true},
+		{name: "pdf without toc", format: "pdf"},
+		{name: "docx ignores toc", format: "docx", toc: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			output, err := h.convertWithPandoc(md, "一元二次方程", tt.format, "standard", tt.toc)
+			if err != nil {
+				t.Fatalf("convertWithPandoc() error = %v", err)
+			}
+			t.Cleanup(func() { os.RemoveAll(filepath.Dir(output)) })
+			if filepath.Ext(output) != "."+tt.format {
+				t.Errorf("output = %s, want a .%s file", output, tt.format)
+			}
+
+			data, err := os.ReadFile(argsFile)
+			if err != nil {
+				t.Fatalf("read pandoc args: %v", err)
+			}
+			args := "\n" + string(data)
+			hasTOC := strings.Contains(args, "\n--toc\n")
+			if hasTOC != tt.wantTOC {
+				t.Errorf("pandoc args contain --toc = %v, want %v:%s", hasTOC, tt.wantTOC, args)
+			}
+			if tt.wantTOC && (!strings.Contains(args, "\n--toc-depth=2\n") || !strings.Contains(args, "\ntoc-title=目录\n")) {
+				t.Errorf("pandoc args = %s, want toc depth 2 titled 目录", args)
+			}
+		})
+	}
+}
@@ -1,9 +1,14 @@
 package handler
 
 import (
+	"context"
+	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
 	"strconv"
 	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/middleware"
 	"lesson-plan/backend/internal/model"
@@ -17,39 +22,93 @@ import (
 type GenerationHandler struct {
 	generationService service.GenerationService
 	knowledgeService  service.KnowledgeService
+	promptService     service.PromptTemplateService
+	presetService     service.GenerationPresetService
+	settingsService   service.SettingsService
+
+	// streamHeartbeat、streamIdleTimeout 流式生成的心跳间隔与上游空闲超时
+	streamHeartbeat   time.Duration
+	streamIdleTimeout time.Duration
 }
 
 // NewGenerationHandler 创建生成处理器
 func NewGenerationHandler(
 	generationService service.GenerationService,
 	knowledgeService service.KnowledgeService,
+	promptService service.PromptTemplateService,
+	presetService service.GenerationPresetService,
+	settingsService service.SettingsService,
 ) *GenerationHandler {
 	return &GenerationHandler{
 		generationService: generationService,
 		knowledgeService:  knowledgeService,
+		promptService:     promptService,
+		presetService:     presetService,
+		settingsService:   settingsService,
+		streamHeartbeat:   sseHeartbeatInterval,
+		streamIdleTimeout: sseIdleTimeout,
 	}
 }
 
-// Generate 生成教案
-func (h *GenerationHandler) Generate(c *gin.Context) {
+// bindGenerationRequest 解析生成请求，失败时已写入错误响应
+func (h *GenerationHandler) bindGenerationRequest(c *gin.Context) (uuid.UUID, *model.GenerationRequest, bool) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
 		Error(c, http.StatusUnauthorized, "未认证", nil)
-		return
+		return uuid.Nil, nil, false
 	}
 
 	var req model.GenerationRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		Error(c, http.StatusBadRequest, "参数错误", err.Error())
-		return
+		return uuid.Nil, nil, false
+	}
+	if req.Variants == 0 {
+		var query generationQuery
+		if !BindQuery(c, &query) {
+			return uuid.Nil, nil, false
+		}
+		if query.Variants != nil {
+			req.Variants = *query.Variants
+		}
 	}
 
 	userUUID, _ := uuid.Parse(userID)
+	if strings.TrimSpace(req.Subject) == "" || strings.TrimSpace(req.Grade) == "" {
+		// 未填写学科/年级时使用用户设置中的默认值；读取失败按未设置处理
+		if settings, err := h.settingsService.Get(c.Request.Context(), userUUID); err == nil {
+			if strings.TrimSpace(req.Subject) == "" {
+				req.Subject = settings.DefaultSubject
+			}
+			if strings.TrimSpace(req.Grade) == "" {
+				req.Grade = settings.DefaultGrade
+			}
+		}
+		if strings.TrimSpace(req.Subject) == "" || strings.TrimSpace(req.Grade) == "" {
+			Error(c, http.StatusBadRequest, "请填写学科和年级，或在个人设置中配置默认学科与年级", nil)
+			return uuid.Nil, nil, false
+		}
+	}
+	return userUUID, &req, true
+}
+
+// Generate 生成教案；请求中 async 为 true 时立即返回 202 与生成记录 ID，在后台生成
+func (h *GenerationHandler) Generate(c *gin.Context) {
+	userUUID, req, ok := h.bindGenerationRequest(c)
+	if !ok {
+		return
+	}
+
 	keyOverride := service.NewAPIKeyOverride(
 		c.GetHeader(service.HeaderGenerationAPIKey),
 		c.GetHeader(service.HeaderEmbeddingAPIKey),
 	)
-	resp, err := h.generationService.Generate(c.Request.Context(), userUUID, &req, keyOverride)
+	if req.Async {
+		h.startGeneration(c, userUUID, req, keyOverride)
+		return
+	}
+
+	resp, err := h.generationService.Generate(c.Request.Context(), userUUID, req, keyOverride)
 	if err != nil {
 		Error(c, http.StatusInternalServerError, "生成失败", err.Error())
 		return
@@ -58,23 +117,287 @@ func (h *GenerationHandler) Generate(c *gin.Context) {
 	Success(c, resp)
 }
 
-// GetGeneration 获取生成记录
+// GenerateStream 以 SSE 方式生成教案：先推送 started，多方案时每完成一个方案推送 variant_done，
+// 生成期间由 streamSSE 定时发送 :keepalive 心跳，完成后推送 result 或 error；
+// 心跳不算上游活动，Agent 停滞超过空闲超时或客户端断开时取消生成
+func (h *GenerationHandler) GenerateStream(c *gin.Context) {
+	userUUID, req, ok := h.bindGenerationRequest(c)
+	if !ok {
+		return
+	}
+
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+
+	ctx, cancel := context.WithCancel(c.Request.Context())
+	defer cancel()
+
+	events := make(chan sseEvent, 2)
+	// 多个方案排队调用 Agent，总耗时可能超过空闲超时；方案完成即算上游活动
+	generateCtx := service.WithVariantObserver(ctx, func(index, total int, variant model.GenerationResponse) {
+		select {
+		case events <- sseEvent{Event: "variant_done", Data: gin.H{
+			"index": index, "total": total, "id": variant.ID, "status": variant.Status, "style": variant.Style,
+		}}:
+		case <-ctx.Done():
+		}
+	})
+	go func() {
+		defer close(events)
+		events <- sseEvent{Event: "started", Data: gin.H{"topic": req.Topic}}
+
+		event := sseEvent{Event: "result"}
+		resp, err := h.generationService.Generate(generateCtx, userUUID, req, keyOverride)
+		if err != nil {
+			event = sseEvent{Event: "error", Data: gin.H{"message": "生成失败: " + err.Error()}}
+		} else {
+			event.Data = resp
+		}
+
+		select {
+		case events <- event:
+		case <-ctx.Done():
+		}
+	}()
+
+	_ = streamSSE(c, events, h.streamHeartbeat, h.streamIdleTimeout)
+}
+
+// StartGeneration 异步生成教案，等同于 POST /generate 且 async 为 true
+func (h *GenerationHandler) StartGeneration(c *gin.Context) {
+	userUUID, req, ok := h.bindGenerationRequest(c)
+	if !ok {
+		return
+	}
+
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+	h.startGeneration(c, userUUID, req, keyOverride)
+}
+
+// startGeneration 立即返回生成记录 ID，结果通过 GET /generate/history/:id 轮询或 GET /generate/:id/stream 订阅
+func (h *GenerationHandler) startGeneration(c *gin.Context, userUUID uuid.UUID, req *model.GenerationRequest, keyOverride service.APIKeyOverride) {
+	generation, err := h.generationService.StartGeneration(c.Request.Context(), userUUID, req, keyOverride)
+	if err != nil {
+		if errors.Is(err, service.ErrAsyncVariantsUnsupported) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "生成失败", err.Error())
+		return
+	}
+
+	c.JSON(http.StatusAccepted, Response{
+		Success: true,
+		Code:    0,
+		Message: "已开始生成",
+		Data:    gin.H{"id": generation.ID, "status": generation.Status},
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
+
+// StreamProgress 以 SSE 推送生成进度：每次状态变化推送 status 事件，到达终态（含生成结果）或客户端断开时结束
+func (h *GenerationHandler) StreamProgress(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	progress, unsubscribe, err := h.generationService.SubscribeProgress(c.Request.Context(), id, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrGenerationNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrGenerationForbidden):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "订阅生成进度失败", err.Error())
+		}
+		return
+	}
+	defer unsubscribe()
+
+	events := make(chan sseEvent)
+	go func() {
+		defer close(events)
+		for event := range progress {
+			select {
+			case events <- sseEvent{Event: "status", Data: event}:
+			case <-c.Request.Context().Done():
+				return
+			}
+		}
+	}()
+
+	// Agent 调用最长可达数分钟且期间没有事件，空闲超时放宽到生成超时之上
+	_ = streamSSE(c, events, sseHeartbeatInterval, generationProgressIdleTimeout)
+}
+
+// ListStyles 获取教学风格预设
+func (h *GenerationHandler) ListStyles(c *gin.Context) {
+	Success(c, service.GenerationStylePresets())
+}
+
+// ListPresets 获取当前用户的生成参数预设
+func (h *GenerationHandler) ListPresets(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	presets, err := h.presetService.List(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取预设失败", err.Error())
+		return
+	}
+
+	Success(c, presets)
+}
+
+// SavePreset 保存生成参数预设，同名预设覆盖
+func (h *GenerationHandler) SavePreset(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req service.SaveGenerationPresetRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	preset, created, err := h.presetService.Save(c.Request.Context(), userUUID, &req)
+	if err != nil {
+		if errors.Is(err, service.ErrTooManyGenerationPresets) {
+			Error(c, http.StatusBadRequest, "预设数量已达上限，请先删除不用的预设", nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "保存预设失败", err.Error())
+		return
+	}
+
+	if !created {
+		SuccessWithMessage(c, "预设已更新", preset)
+		return
+	}
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Code:    0,
+		Message: "预设已保存",
+		Data:    preset,
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
+
+// DeletePreset 删除生成参数预设
+func (h *GenerationHandler) DeletePreset(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	if err := h.presetService.Delete(c.Request.Context(), id, userUUID); err != nil {
+		if errors.Is(err, service.ErrGenerationPresetNotFound) {
+			Error(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "删除预设失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "预设已删除", nil)
+}
+
+// GetGeneration 获取生成记录，result 为解析后的结构化结果
 func (h *GenerationHandler) GetGeneration(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	generation, err := h.generationService.GetByID(c.Request.Context(), id)
+	userUUID, _ := uuid.Parse(userID)
+	generation, err := h.generationService.GetByID(c.Request.Context(), id, userUUID)
 	if err != nil {
-		Error(c, http.StatusNotFound, "记录不存在", nil)
+		switch {
+		case errors.Is(err, service.ErrGenerationNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrGenerationForbidden):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "获取生成记录失败", err.Error())
+		}
 		return
 	}
 
 	Success(c, generation)
 }
 
+// RetryGeneration 以原生成记录的参数重新生成，新记录关联原记录；原记录仍在生成中时返回 409
+func (h *GenerationHandler) RetryGeneration(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+	resp, err := h.generationService.Retry(c.Request.Context(), userUUID, id, keyOverride)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrGenerationNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrGenerationForbidden):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, service.ErrGenerationInProgress):
+			Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "重试生成失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, resp)
+}
+
 // ListGenerations 生成历史列表
 func (h *GenerationHandler) ListGenerations(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -95,6 +418,32 @@ func (h *GenerationHandler) ListGenerations(c *gin.Context) {
 	Paginated(c, generations, total, page, pageSize)
 }
 
+// SearchGenerations 按提示词搜索生成历史
+func (h *GenerationHandler) SearchGenerations(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	query := strings.TrimSpace(c.Query("q"))
+	if query == "" {
+		Error(c, http.StatusBadRequest, "请输入搜索关键词", nil)
+		return
+	}
+
+	page, pageSize := GetPagination(c)
+	userUUID, _ := uuid.Parse(userID)
+
+	generations, total, err := h.generationService.SearchHistory(c.Request.Context(), userUUID, query, page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "搜索失败", err.Error())
+		return
+	}
+
+	Paginated(c, generations, total, page, pageSize)
+}
+
 // GetStats 获取生成统计
 func (h *GenerationHandler) GetStats(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -113,6 +462,28 @@ func (h *GenerationHandler) GetStats(c *gin.Context) {
 	Success(c, stats)
 }
 
+// GetTimeseries 获取按天/周聚合的生成次数与 token 时间序列
+func (h *GenerationHandler) GetTimeseries(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	series, err := h.generationService.GetTimeseries(c.Request.Context(), userUUID, c.Query("granularity"), c.Query("range"))
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedGranularity) || errors.Is(err, service.ErrInvalidTimeseriesRange) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "获取统计失败", err.Error())
+		return
+	}
+
+	Success(c, series)
+}
+
 // GetLangSmithUsage 获取 LangSmith Token 使用情况
 func (h *GenerationHandler) GetLangSmithUsage(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -190,25 +561,308 @@ func (h *GenerationHandler) SearchKnowledge(c *gin.Context) {
 	Success(c, results)
 }
 
-// GetKnowledgeGraph 获取知识图谱
+// relationTypesHint 关系类型参数错误提示
+const relationTypesHint = "不支持的关系类型，可选 DEPENDS_ON、RELATES_TO、SIMILAR_TO、PART_OF"
+
+// queryRelationTypes 读取 relationTypes 参数，支持逗号分隔或重复传参
+func queryRelationTypes(c *gin.Context) []string {
+	var relationTypes []string
+	for _, value := range c.QueryArray("relationTypes") {
+		for _, relationType := range strings.Split(value, ",") {
+			if relationType = strings.TrimSpace(relationType); relationType != "" {
+				relationTypes = append(relationTypes, relationType)
+			}
+		}
+	}
+	return relationTypes
+}
+
+// asOfHint asOf 参数格式说明
+const asOfHint = "asOf 格式无效，请使用 2006-01-02 或 RFC3339 时间"
+
+// queryAsOf 读取 asOf 参数：日期表示当天结束时（服务器时区），也可传 RFC3339 时间；为空返回 nil
+func queryAsOf(c *gin.Context) (*time.Time, error) {
+	raw := strings.TrimSpace(c.Query("asOf"))
+	if raw == "" {
+		return nil, nil
+	}
+	if day, err := time.ParseInLocation("2006-01-02", raw, time.Local); err == nil {
+		endOfDay := day.AddDate(0, 0, 1).Add(-time.Nanosecond)
+		return &endOfDay, nil
+	}
+	t, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return nil, err
+	}
+	return &t, nil
+}
+
+// GetKnowledgeGraph 获取知识图谱，传 asOf 时返回该时间点的图谱快照
 func (h *GenerationHandler) GetKnowledgeGraph(c *gin.Context) {
-	subject := c.Query("subject")
-	grade := c.Query("grade")
-	topic := strings.TrimSpace(c.Query("topic"))
-	scope := strings.TrimSpace(c.Query("scope"))
-	limit := 50
-	if l, err := strconv.Atoi(c.Query("limit")); err == nil && l > 0 && l <= 500 {
-		limit = l
+	var query graphQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		Error(c, http.StatusBadRequest, asOfHint, nil)
+		return
 	}
 
 	// 获取当前用户ID，只展示用户自己的知识图谱
 	userIdStr, _ := middleware.GetCurrentUserID(c)
 
-	graph, err := h.knowledgeService.GetGraph(c.Request.Context(), subject, grade, topic, scope, userIdStr, limit)
+	graph, err := h.knowledgeService.GetGraph(c.Request.Context(), query.Subject, query.Grade, strings.TrimSpace(query.Topic), strings.TrimSpace(query.Scope), userIdStr, query.Limit, queryRelationTypes(c), asOf)
 	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedRelationType) {
+			Error(c, http.StatusBadRequest, relationTypesHint, err.Error())
+			return
+		}
 		Error(c, http.StatusInternalServerError, "获取图谱失败", err.Error())
 		return
 	}
 
 	Success(c, graph)
 }
+
+// GetKnowledgeGraphClusters 获取知识图谱聚类结果
+func (h *GenerationHandler) GetKnowledgeGraphClusters(c *gin.Context) {
+	var query graphClusterQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+
+	asOf, err := queryAsOf(c)
+	if err != nil {
+		Error(c, http.StatusBadRequest, asOfHint, nil)
+		return
+	}
+
+	userIdStr, _ := middleware.GetCurrentUserID(c)
+
+	result, err := h.knowledgeService.GetGraphClusters(c.Request.Context(), query.Subject, query.Grade, strings.TrimSpace(query.Topic), strings.TrimSpace(query.Scope), userIdStr, query.Limit, queryRelationTypes(c), asOf, strings.TrimSpace(query.Algorithm))
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedClusterAlgorithm) {
+			Error(c, http.StatusBadRequest, "不支持的聚类算法，请使用 components 或 label_propagation", nil)
+			return
+		}
+		if errors.Is(err, service.ErrUnsupportedRelationType) {
+			Error(c, http.StatusBadRequest, relationTypesHint, err.Error())
+			return
+		}
+		Error(c, http.StatusInternalServerError, "图谱聚类失败", err.Error())
+		return
+	}
+
+	Success(c, result)
+}
+
+// GetSimilarKnowledge 获取与指定知识点语义相似的知识点
+func (h *GenerationHandler) GetSimilarKnowledge(c *gin.Context) {
+	id := strings.TrimSpace(c.Param("id"))
+	var query similarKnowledgeQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+
+	userIdStr, _ := middleware.GetCurrentUserID(c)
+
+	nodes, err := h.knowledgeService.GetSimilar(c.Request.Context(), id, userIdStr, query.Limit)
+	if err != nil {
+		if errors.Is(err, service.ErrKnowledgeNodeNotFound) {
+			Error(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "相似知识点检索失败", err.Error())
+		return
+	}
+
+	Success(c, nodes)
+}
+
+// GetKnowledgePath 查询两个知识点之间的最短路径，两点不连通时返回 found=false
+func (h *GenerationHandler) GetKnowledgePath(c *gin.Context) {
+	var query knowledgePathQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+
+	userIdStr, _ := middleware.GetCurrentUserID(c)
+
+	path, err := h.knowledgeService.FindPath(c.Request.Context(), query.From, query.To, userIdStr, query.MaxDepth, queryRelationTypes(c))
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedRelationType):
+			Error(c, http.StatusBadRequest, relationTypesHint, err.Error())
+		case errors.Is(err, service.ErrKnowledgePathSameEndpoint):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrKnowledgePathEndpointNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "路径查询失败", err.Error())
+		}
+		return
+	}
+
+	if !path.Found {
+		SuccessWithMessage(c, fmt.Sprintf("两个知识点在 %d 步内没有关联路径", query.MaxDepth), path)
+		return
+	}
+	Success(c, path)
+}
+
+// ExportAnki 将知识点导出为 Anki 可导入的卡片文件
+func (h *GenerationHandler) ExportAnki(c *gin.Context) {
+	subject := c.Query("subject")
+	grade := c.Query("grade")
+	format := strings.ToLower(strings.TrimSpace(c.DefaultQuery("format", service.AnkiFormatCSV)))
+
+	userIdStr, _ := middleware.GetCurrentUserID(c)
+
+	export, err := h.knowledgeService.ExportAnki(c.Request.Context(), subject, grade, userIdStr, format)
+	if err != nil {
+		if errors.Is(err, service.ErrUnsupportedAnkiFormat) {
+			Error(c, http.StatusBadRequest, "不支持的导出格式，请使用 csv 或 tsv", nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "导出失败", err.Error())
+		return
+	}
+
+	ext := filepath.Ext(export.Filename)
+	setAttachmentHeader(c, strings.TrimSuffix(export.Filename, ext), "knowledge-cards", strings.TrimPrefix(ext, "."))
+	c.Header("X-Card-Count", strconv.Itoa(export.CardCount))
+	c.Data(http.StatusOK, export.ContentType, export.Content)
+}
+
+// UpdatePromptTemplateRequest 更新 prompt 模板请求
+type UpdatePromptTemplateRequest struct {
+	Content string `json:"content" binding:"required"`
+}
+
+// PreviewPromptTemplateRequest 预览 prompt 模板请求，Content 为空时预览当前模板，Request 为空时使用示例参数
+type PreviewPromptTemplateRequest struct {
+	Content string                   `json:"content"`
+	Request *model.GenerationRequest `json:"request" binding:"-"`
+}
+
+// ResolveReviewRequest 人工复核请求
+type ResolveReviewRequest struct {
+	Approved *bool `json:"approved" binding:"required"`
+}
+
+// ListPendingReviews 待人工复核的生成记录（管理员）
+func (h *GenerationHandler) ListPendingReviews(c *gin.Context) {
+	page, pageSize := GetPagination(c)
+
+	generations, total, err := h.generationService.ListPendingReviews(c.Request.Context(), page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取待复核列表失败", err.Error())
+		return
+	}
+
+	Paginated(c, generations, total, page, pageSize)
+}
+
+// ResolveReview 人工复核生成内容（管理员）：通过后用户可查看，驳回则记为失败
+func (h *GenerationHandler) ResolveReview(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	var req ResolveReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	if err := h.generationService.ResolveReview(c.Request.Context(), id, *req.Approved); err != nil {
+		if errors.Is(err, service.ErrGenerationNotInReview) {
+			Error(c, http.StatusNotFound, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "复核失败", err.Error())
+		return
+	}
+
+	message := "已通过复核"
+	if !*req.Approved {
+		message = "已驳回"
+	}
+	SuccessWithMessage(c, message, nil)
+}
+
+// GetPromptTemplate 获取当前生成 prompt 模板（管理员）
+func (h *GenerationHandler) GetPromptTemplate(c *gin.Context) {
+	Success(c, h.promptService.Get(c.Request.Context()))
+}
+
+// UpdatePromptTemplate 在线修改生成 prompt 模板（管理员），保存前校验模板可渲染
+func (h *GenerationHandler) UpdatePromptTemplate(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	var req UpdatePromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	tpl, err := h.promptService.Update(c.Request.Context(), req.Content, userUUID)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPromptTemplate) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "保存模板失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "模板已更新", tpl)
+}
+
+// ResetPromptTemplate 恢复内置 prompt 模板（管理员）
+func (h *GenerationHandler) ResetPromptTemplate(c *gin.Context) {
+	userID, _ := middleware.GetCurrentUserID(c)
+	userUUID, _ := uuid.Parse(userID)
+
+	tpl, err := h.promptService.Reset(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "恢复默认模板失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "已恢复默认模板", tpl)
+}
+
+// PreviewPromptTemplate 用示例请求渲染模板，不保存（管理员）
+func (h *GenerationHandler) PreviewPromptTemplate(c *gin.Context) {
+	var req PreviewPromptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	prompt, err := h.promptService.Preview(c.Request.Context(), req.Content, req.Request)
+	if err != nil {
+		if errors.Is(err, service.ErrInvalidPromptTemplate) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "预览失败", err.Error())
+		return
+	}
+
+	Success(c, gin.H{"prompt": prompt})
+}
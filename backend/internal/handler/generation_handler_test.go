@@ -0,0 +1,497 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestQueryAsOf(t *testing.T) {
+	tests := []struct {
+		name    string
+		query   string
+		want    *time.Time
+		wantErr bool
+	}{
+		{name: "absent", query: ""},
+		{name: "blank", query: "?asOf=%20"},
+		{
+			name:  "date means end of day",
+			query: "?asOf=2026-09-01",
+			want:  ptrTime(time.Date(2026, 9, 1, 23, 59, 59, 999999999, time.Local)),
+		},
+		{
+			name:  "rfc3339",
+			query: "?asOf=2026-09-01T08:30:00Z",
+			want:  ptrTime(time.Date(2026, 9, 1, 8, 30, 0, 0, time.UTC)),
+		},
+		{name: "malformed", query: "?asOf=09/01/2026", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			c, _ := gin.CreateTestContext(httptest.NewRecorder())
+			c.Request = httptest.NewRequest(http.MethodGet, "/knowledge/graph"+tt.query, nil)
+
+			got, err := queryAsOf(c)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("queryAsOf() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if (got == nil) != (tt.want == nil) || (got != nil && !got.Equal(*tt.want)) {
+				t.Errorf("queryAsOf() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func ptrTime(t time.Time) *time.Time { return &t }
+
+// recordingGenerationService 记录走的是同步还是异步生成，以及最近一次同步生成的请求
+type recordingGenerationService struct {
+	service.GenerationService
+	syncCalls, asyncCalls int
+	last                  *model.GenerationRequest
+}
+
+func (s *recordingGenerationService) Generate(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride service.APIKeyOverride) (*model.GenerationResponse, error) {
+	s.syncCalls++
+	s.last = req
+	return &model.GenerationResponse{ID: uuid.New(), Status: model.GenerationStatusCompleted, Title: req.Topic}, nil
+}
+
+func (s *recordingGenerationService) StartGeneration(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride service.APIKeyOverride) (*model.Generation, error) {
+	s.asyncCalls++
+	if req.Variants > 1 {
+		return nil, service.ErrAsyncVariantsUnsupported
+	}
+	return &model.Generation{ID: uuid.New(), UserID: userID, Status: model.GenerationStatusPending}, nil
+}
+
+func TestGenerateSyncAndAsync(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		wantAsync  bool
+		wantData   string
+	}{
+		{name: "sync by default", body: `{"subject":"数学","grade":"七年级","topic":"有理数"}`, wantStatus: http.StatusOK, wantData: "completed"},
+		{name: "async flag", body: `{"subject":"数学","grade":"七年级","topic":"有理数","async":true}`, wantStatus: http.StatusAccepted, wantAsync: true, wantData: "pending"},
+		{name: "async with variants", body: `{"subject":"数学","grade":"七年级","topic":"有理数","async":true,"variants":2}`, wantStatus: http.StatusBadRequest, wantAsync: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			svc := &recordingGenerationService{}
+			h := NewGenerationHandler(svc, nil, nil, nil, nil)
+			r := gin.New()
+			r.POST("/generate", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, h.Generate)
+
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if (svc.asyncCalls == 1) != tt.wantAsync || svc.syncCalls+svc.asyncCalls != 1 {
+				t.Errorf("calls = sync %d async %d, want async %v", svc.syncCalls, svc.asyncCalls, tt.wantAsync)
+			}
+			if tt.wantData == "" {
+				return
+			}
+			var resp struct {
+				Data struct {
+					ID     uuid.UUID `json:"id"`
+					Status string    `json:"status"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.ID == uuid.Nil || resp.Data.Status != tt.wantData {
+				t.Errorf("body = %s, want id and status %s", w.Body.String(), tt.wantData)
+			}
+		})
+	}
+}
+
+func TestGenerateVariantsQuery(t *testing.T) {
+	tests := []struct {
+		name         string
+		query        string
+		body         string
+		wantStatus   int
+		wantVariants int
+	}{
+		{name: "omitted", query: "", wantStatus: http.StatusOK},
+		{name: "in range", query: "?variants=2", wantStatus: http.StatusOK, wantVariants: 2},
+		{name: "body wins over query", query: "?variants=abc", body: `,"variants":3`, wantStatus: http.StatusOK, wantVariants: 3},
+		{name: "not a number", query: "?variants=abc", wantStatus: http.StatusBadRequest},
+		{name: "zero", query: "?variants=0", wantStatus: http.StatusBadRequest},
+		{name: "above range", query: "?variants=4", wantStatus: http.StatusBadRequest},
+		{name: "negative", query: "?variants=-1", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			svc := &recordingGenerationService{}
+			h := NewGenerationHandler(svc, nil, nil, nil, nil)
+			r := gin.New()
+			r.POST("/generate", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, h.Generate)
+
+			body := `{"subject":"数学","grade":"七年级","topic":"有理数"` + tt.body + `}`
+			req := httptest.NewRequest(http.MethodPost, "/generate"+tt.query, strings.NewReader(body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				// 参数错误时不调用生成服务
+				if svc.syncCalls != 0 {
+					t.Errorf("sync calls = %d, want none for an invalid variants", svc.syncCalls)
+				}
+				return
+			}
+			if svc.last == nil || svc.last.Variants != tt.wantVariants {
+				t.Errorf("request = %+v, want variants %d", svc.last, tt.wantVariants)
+			}
+		})
+	}
+}
+
+// detailGenerationService 按 ID 返回预置的生成记录详情，非生成者本人返回无权查看
+type detailGenerationService struct {
+	service.GenerationService
+	details map[uuid.UUID]*model.GenerationDetail
+}
+
+func (s *detailGenerationService) GetByID(ctx context.Context, id, userID uuid.UUID) (*model.GenerationDetail, error) {
+	detail, ok := s.details[id]
+	if !ok {
+		return nil, service.ErrGenerationNotFound
+	}
+	if detail.UserID != userID {
+		return nil, service.ErrGenerationForbidden
+	}
+	return detail, nil
+}
+
+func TestGetGenerationStructuredResult(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	id := uuid.New()
+	ownerID := uuid.New()
+	svc := &detailGenerationService{details: map[uuid.UUID]*model.GenerationDetail{
+		id: {
+			Generation: model.Generation{ID: id, UserID: ownerID, Status: model.GenerationStatusCompleted, Result: `{"title":"有理数的加法"}`},
+			Result:     &model.GenerationResponse{ID: id, Status: model.GenerationStatusCompleted, Title: "有理数的加法"},
+		},
+	}}
+	h := NewGenerationHandler(svc, nil, nil, nil, nil)
+
+	tests := []struct {
+		name       string
+		id         string
+		userID     uuid.UUID
+		wantStatus int
+	}{
+		{name: "found", id: id.String(), userID: ownerID, wantStatus: http.StatusOK},
+		{name: "invalid id", id: "not-a-uuid", userID: ownerID, wantStatus: http.StatusBadRequest},
+		{name: "missing", id: uuid.NewString(), userID: ownerID, wantStatus: http.StatusNotFound},
+		{name: "other user", id: id.String(), userID: uuid.New(), wantStatus: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.GET("/history/:id", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: tt.userID.String()})
+				c.Next()
+			}, h.GetGeneration)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/history/"+tt.id, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			// result 为结构化对象而非原始 JSON 字符串
+			var resp struct {
+				Data struct {
+					Status string `json:"status"`
+					Result struct {
+						ID    uuid.UUID `json:"id"`
+						Title string    `json:"title"`
+					} `json:"result"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body %s: %v", w.Body.String(), err)
+			}
+			if resp.Data.Status != model.GenerationStatusCompleted || resp.Data.Result.ID != id || resp.Data.Result.Title != "有理数的加法" {
+				t.Errorf("body = %s, want structured result", w.Body.String())
+			}
+		})
+	}
+}
+
+// similarKnowledgeService 记录相似检索的参数，id 为 missing 时返回节点不存在
+type similarKnowledgeService struct {
+	service.KnowledgeService
+	userID string
+	limit  int
+}
+
+func (s *similarKnowledgeService) GetSimilar(ctx context.Context, id, userId string, limit int) ([]model.SimilarKnowledgeNode, error) {
+	s.userID, s.limit = userId, limit
+	if id == "missing" {
+		return nil, service.ErrKnowledgeNodeNotFound
+	}
+	return []model.SimilarKnowledgeNode{
+		{KnowledgeNode: model.KnowledgeNode{ID: "k2", Label: "数轴"}, Score: 0.92, Connected: true},
+	}, nil
+}
+
+func TestGetSimilarKnowledge(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.NewString()
+
+	tests := []struct {
+		name       string
+		path       string
+		wantStatus int
+		wantLimit  int
+	}{
+		{name: "default limit", path: "/nodes/k1/similar", wantStatus: http.StatusOK, wantLimit: 10},
+		{name: "custom limit", path: "/nodes/k1/similar?limit=20", wantStatus: http.StatusOK, wantLimit: 20},
+		{name: "limit out of range", path: "/nodes/k1/similar?limit=51", wantStatus: http.StatusBadRequest},
+		{name: "missing node", path: "/nodes/missing/similar", wantStatus: http.StatusNotFound, wantLimit: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &similarKnowledgeService{}
+			h := NewGenerationHandler(nil, svc, nil, nil, nil)
+			r := gin.New()
+			r.GET("/nodes/:id/similar", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: userID})
+				c.Next()
+			}, h.GetSimilarKnowledge)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.path, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusBadRequest {
+				if svc.userID != "" {
+					t.Error("GetSimilar should not be called for an invalid limit")
+				}
+				return
+			}
+			if svc.userID != userID || svc.limit != tt.wantLimit {
+				t.Errorf("GetSimilar called with (%q, %d), want (%q, %d)", svc.userID, svc.limit, userID, tt.wantLimit)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data []struct {
+					ID        string  `json:"id"`
+					Score     float64 `json:"score"`
+					Connected bool    `json:"connected"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || len(resp.Data) != 1 ||
+				resp.Data[0].ID != "k2" || resp.Data[0].Score != 0.92 || !resp.Data[0].Connected {
+				t.Errorf("body = %s, want the similar node with score and connected flag", w.Body.String())
+			}
+		})
+	}
+}
+
+// pathKnowledgeService 记录路径查询参数，终点为 missing 时返回端点不存在，为 far 时两点不连通
+type pathKnowledgeService struct {
+	service.KnowledgeService
+	calls         int
+	maxDepth      int
+	relationTypes []string
+}
+
+func (s *pathKnowledgeService) FindPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (*model.KnowledgePath, error) {
+	s.calls++
+	s.maxDepth, s.relationTypes = maxDepth, relationTypes
+	for _, relationType := range relationTypes {
+		if relationType == "CAUSES" {
+			return nil, service.ErrUnsupportedRelationType
+		}
+	}
+	switch to {
+	case from:
+		return nil, service.ErrKnowledgePathSameEndpoint
+	case "missing":
+		return nil, service.ErrKnowledgePathEndpointNotFound
+	case "far":
+		return &model.KnowledgePath{Nodes: []model.KnowledgeNode{}, Edges: []model.KnowledgeEdge{}}, nil
+	}
+	return &model.KnowledgePath{
+		Found:  true,
+		Length: 1,
+		Nodes:  []model.KnowledgeNode{{ID: from, Label: "有理数"}, {ID: to, Label: "数轴"}},
+		Edges:  []model.KnowledgeEdge{{Source: from, Target: to, Type: "DEPENDS_ON", Weight: 1}},
+	}, nil
+}
+
+func TestGetKnowledgePath(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name          string
+		query         string
+		wantStatus    int
+		wantFound     bool
+		wantDepth     int
+		wantRelations string
+	}{
+		{name: "found with default depth", query: "from=k1&to=k2", wantStatus: http.StatusOK, wantFound: true, wantDepth: 6},
+		{name: "relation filter", query: "from=k1&to=k2&max_depth=3&relationTypes=DEPENDS_ON,PART_OF", wantStatus: http.StatusOK, wantFound: true, wantDepth: 3, wantRelations: "DEPENDS_ON,PART_OF"},
+		{name: "not connected", query: "from=k1&to=far&max_depth=2", wantStatus: http.StatusOK, wantDepth: 2},
+		{name: "missing endpoint", query: "from=k1&to=missing", wantStatus: http.StatusNotFound, wantDepth: 6},
+		{name: "same endpoint", query: "from=k1&to=k1", wantStatus: http.StatusBadRequest, wantDepth: 6},
+		{name: "unsupported relation", query: "from=k1&to=k2&relationTypes=CAUSES", wantStatus: http.StatusBadRequest, wantDepth: 6, wantRelations: "CAUSES"},
+		{name: "missing to", query: "from=k1", wantStatus: http.StatusBadRequest},
+		{name: "depth out of range", query: "from=k1&to=k2&max_depth=11", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &pathKnowledgeService{}
+			h := NewGenerationHandler(nil, svc, nil, nil, nil)
+			r := gin.New()
+			r.GET("/path", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, h.GetKnowledgePath)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/path?"+tt.query, nil))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantDepth == 0 {
+				if svc.calls != 0 {
+					t.Error("FindPath should not be called for invalid query parameters")
+				}
+				return
+			}
+			if svc.maxDepth != tt.wantDepth || strings.Join(svc.relationTypes, ",") != tt.wantRelations {
+				t.Errorf("FindPath called with (%d, %v), want (%d, %s)", svc.maxDepth, svc.relationTypes, tt.wantDepth, tt.wantRelations)
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Message string              `json:"message"`
+				Data    model.KnowledgePath `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			if resp.Data.Found != tt.wantFound || resp.Data.Nodes == nil || resp.Data.Edges == nil {
+				t.Errorf("data = %+v, want found %v with node and edge arrays", resp.Data, tt.wantFound)
+			}
+			if !tt.wantFound && !strings.Contains(resp.Message, "2 步内没有关联路径") {
+				t.Errorf("message = %q, want the depth in the not-connected notice", resp.Message)
+			}
+		})
+	}
+}
+
+// fixedSettingsService 返回预置的用户设置
+type fixedSettingsService struct {
+	service.SettingsService
+	settings *model.UserSettings
+	err      error
+}
+
+func (s *fixedSettingsService) Get(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error) {
+	return s.settings, s.err
+}
+
+func TestGenerateDefaultsFromSettings(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	defaults := &model.UserSettings{DefaultSubject: "数学", DefaultGrade: "七年级"}
+
+	tests := []struct {
+		name        string
+		body        string
+		settings    *fixedSettingsService
+		wantStatus  int
+		wantSubject string
+		wantGrade   string
+	}{
+		{name: "request values win", body: `{"subject":"物理","grade":"八年级","topic":"浮力"}`, settings: &fixedSettingsService{settings: defaults}, wantStatus: http.StatusOK, wantSubject: "物理", wantGrade: "八年级"},
+		{name: "both from settings", body: `{"topic":"有理数"}`, settings: &fixedSettingsService{settings: defaults}, wantStatus: http.StatusOK, wantSubject: "数学", wantGrade: "七年级"},
+		{name: "blank grade from settings", body: `{"subject":"语文","grade":" ","topic":"春"}`, settings: &fixedSettingsService{settings: defaults}, wantStatus: http.StatusOK, wantSubject: "语文", wantGrade: "七年级"},
+		{name: "no defaults configured", body: `{"topic":"有理数"}`, settings: &fixedSettingsService{settings: &model.UserSettings{DefaultSubject: "数学"}}, wantStatus: http.StatusBadRequest},
+		{name: "settings unavailable", body: `{"topic":"有理数"}`, settings: &fixedSettingsService{err: errors.New("db down")}, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &recordingGenerationService{}
+			h := NewGenerationHandler(svc, nil, nil, nil, tt.settings)
+			r := gin.New()
+			r.POST("/generate", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, h.Generate)
+
+			req := httptest.NewRequest(http.MethodPost, "/generate", strings.NewReader(tt.body))
+			req.Header.Set("Content-Type", "application/json")
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				if svc.syncCalls != 0 {
+					t.Error("Generate() called without subject and grade")
+				}
+				return
+			}
+			if svc.last.Subject != tt.wantSubject || svc.last.Grade != tt.wantGrade {
+				t.Errorf("request = (%q, %q), want (%q, %q)", svc.last.Subject, svc.last.Grade, tt.wantSubject, tt.wantGrade)
+			}
+		})
+	}
+}
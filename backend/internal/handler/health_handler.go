@@ -0,0 +1,143 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultHealthCacheTTL 依赖状态缓存时长，避免高频探测反复 ping 数据库
+	defaultHealthCacheTTL = 5 * time.Second
+	// healthCheckTimeout 单次依赖检查超时
+	healthCheckTimeout = 3 * time.Second
+)
+
+// DependencyCheck 依赖检查函数，返回 nil 表示可用
+type DependencyCheck func(ctx context.Context) error
+
+// DependencyStatus 单个依赖的检查结果
+type DependencyStatus struct {
+	Name      string `json:"name"`
+	Status    string `json:"status"`
+	Error     string `json:"error,omitempty"`
+	LatencyMs int64  `json:"latency_ms"`
+}
+
+// ReadinessReport 依赖状态汇总
+type ReadinessReport struct {
+	Status       string             `json:"status"`
+	CheckedAt    time.Time          `json:"checked_at"`
+	Cached       bool               `json:"cached"`
+	Dependencies []DependencyStatus `json:"dependencies"`
+}
+
+// HealthHandler 健康检查处理器
+type HealthHandler struct {
+	checks map[string]DependencyCheck
+	ttl    time.Duration
+	now    func() time.Time
+
+	mu       sync.Mutex
+	cached   *ReadinessReport
+	cachedAt time.Time
+}
+
+// NewHealthHandler 创建健康检查处理器，ttl <= 0 时使用默认缓存时长
+func NewHealthHandler(checks map[string]DependencyCheck, ttl time.Duration) *HealthHandler {
+	if ttl <= 0 {
+		ttl = defaultHealthCacheTTL
+	}
+	return &HealthHandler{
+		checks: checks,
+		ttl:    ttl,
+		now:    time.Now,
+	}
+}
+
+// Liveness 存活检查，只表示进程可响应，不访问任何依赖
+func (h *HealthHandler) Liveness(c *gin.Context) {
+	HealthCheck(c)
+}
+
+// Readiness 依赖状态检查，结果在 TTL 内复用
+func (h *HealthHandler) Readiness(c *gin.Context) {
+	report := h.report(c.Request.Context())
+
+	statusCode := http.StatusOK
+	if report.Status != "ok" {
+		statusCode = http.StatusServiceUnavailable
+	}
+	c.JSON(statusCode, report)
+}
+
+// report 返回依赖状态；缓存过期时由一个请求负责刷新，其余并发请求等待并复用结果
+func (h *HealthHandler) report(ctx context.Context) ReadinessReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.cached != nil && h.now().Sub(h.cachedAt) < h.ttl {
+		report := *h.cached
+		report.Cached = true
+		return report
+	}
+
+	// 检查结果会被所有调用方复用，不能因发起刷新的客户端断开而记为 down；
+	// 脱离请求取消后由 healthCheckTimeout 限定每项检查的耗时
+	report := h.runChecks(context.WithoutCancel(ctx))
+	h.cached = &report
+	h.cachedAt = h.now()
+	return report
+}
+
+func (h *HealthHandler) runChecks(ctx context.Context) ReadinessReport {
+	names := make([]string, 0, len(h.checks))
+	for name := range h.checks {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	statuses := make([]DependencyStatus, len(names))
+	var wg sync.WaitGroup
+	for i, name := range names {
+		wg.Add(1)
+		go func(i int, name string) {
+			defer wg.Done()
+
+			checkCtx, cancel := context.WithTimeout(ctx, healthCheckTimeout)
+			defer cancel()
+
+			start := time.Now()
+			err := h.checks[name](checkCtx)
+			status := DependencyStatus{
+				Name:      name,
+				Status:    "ok",
+				LatencyMs: time.Since(start).Milliseconds(),
+			}
+			if err != nil {
+				status.Status = "down"
+				status.Error = err.Error()
+			}
+			statuses[i] = status
+		}(i, name)
+	}
+	wg.Wait()
+
+	overall := "ok"
+	for _, status := range statuses {
+		if status.Status != "ok" {
+			overall = "degraded"
+			break
+		}
+	}
+
+	return ReadinessReport{
+		Status:       overall,
+		CheckedAt:    h.now(),
+		Dependencies: statuses,
+	}
+}
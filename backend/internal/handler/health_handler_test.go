@@ -0,0 +1,118 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+// countingCheck 记录调用次数的依赖检查
+type countingCheck struct {
+	calls int32
+	err   error
+}
+
+func (c *countingCheck) check(ctx context.Context) error {
+	atomic.AddInt32(&c.calls, 1)
+	if ctx.Err() != nil {
+		return ctx.Err()
+	}
+	return c.err
+}
+
+func (c *countingCheck) callCount() int {
+	return int(atomic.LoadInt32(&c.calls))
+}
+
+// newTestHealthRouter 注册存活与就绪检查路由
+func newTestHealthRouter(h *HealthHandler) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/healthz", h.Liveness)
+	r.GET("/readyz", h.Readiness)
+	return r
+}
+
+func getReadiness(t *testing.T, r http.Handler) (int, ReadinessReport) {
+	t.Helper()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/readyz", nil))
+	var report ReadinessReport
+	if err := json.Unmarshal(w.Body.Bytes(), &report); err != nil {
+		t.Fatalf("decode readiness: %v", err)
+	}
+	return w.Code, report
+}
+
+func TestReadinessCachesDependencyChecks(t *testing.T) {
+	db, redis := &countingCheck{}, &countingCheck{err: errors.New("connection refused")}
+	h := NewHealthHandler(map[string]DependencyCheck{"postgres": db.check, "redis": redis.check}, 5*time.Second)
+	now := time.Now()
+	h.now = func() time.Time { return now }
+	r := newTestHealthRouter(h)
+
+	tests := []struct {
+		name       string
+		advance    time.Duration
+		wantCalls  int
+		wantCached bool
+	}{
+		{name: "first probe pings dependencies", wantCalls: 1},
+		{name: "probe within ttl reuses result", advance: time.Second, wantCalls: 1, wantCached: true},
+		{name: "probe just before expiry is still cached", advance: 3900 * time.Millisecond, wantCalls: 1, wantCached: true},
+		{name: "probe after ttl pings again", advance: 200 * time.Millisecond, wantCalls: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			now = now.Add(tt.advance)
+			code, report := getReadiness(t, r)
+			if code != http.StatusServiceUnavailable || report.Status != "degraded" {
+				t.Errorf("readiness = %d %q, want 503 degraded", code, report.Status)
+			}
+			if report.Cached != tt.wantCached {
+				t.Errorf("cached = %v, want %v", report.Cached, tt.wantCached)
+			}
+			if db.callCount() != tt.wantCalls || redis.callCount() != tt.wantCalls {
+				t.Errorf("pings = postgres %d, redis %d, want %d each", db.callCount(), redis.callCount(), tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestLivenessDoesNotTouchDependencies(t *testing.T) {
+	db := &countingCheck{err: errors.New("down")}
+	r := newTestHealthRouter(NewHealthHandler(map[string]DependencyCheck{"postgres": db.check}, 0))
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("liveness status = %d, want 200", w.Code)
+		}
+	}
+	if db.callCount() != 0 {
+		t.Errorf("liveness pinged dependencies %d times, want 0", db.callCount())
+	}
+}
+
+func TestReadinessIgnoresCanceledRequest(t *testing.T) {
+	db := &countingCheck{}
+	h := NewHealthHandler(map[string]DependencyCheck{"postgres": db.check}, 0)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if report := h.report(ctx); report.Status != "ok" {
+		t.Errorf("status = %q, want ok even though the refreshing request was canceled", report.Status)
+	}
+	if report := h.report(context.Background()); !report.Cached || report.Status != "ok" {
+		t.Errorf("second report = %+v, want cached ok", report)
+	}
+}
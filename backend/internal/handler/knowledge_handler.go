@@ -1,14 +1,18 @@
 package handler
 
 import (
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"path/filepath"
+	"strconv"
 	"strings"
 
+	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/middleware"
 	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
 	"lesson-plan/backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -18,15 +22,26 @@ import (
 // KnowledgeHandler 知识库处理器
 type KnowledgeHandler struct {
 	documentService *service.DocumentService
+	uploadLimits    uploadLimits
 }
 
-// NewKnowledgeHandler 创建知识库处理器
-func NewKnowledgeHandler(documentService *service.DocumentService) *KnowledgeHandler {
+// NewKnowledgeHandler 创建知识库处理器。知识文档只使用上传配置中的大小与类型限制：
+// 文档上传后仅保存提取出的文本（数据库与知识图谱），不落盘原文件，因此不使用 storage_path
+func NewKnowledgeHandler(documentService *service.DocumentService, uploadCfg *config.UploadConfig) *KnowledgeHandler {
 	return &KnowledgeHandler{
 		documentService: documentService,
+		uploadLimits:    newUploadLimits(uploadCfg),
 	}
 }
 
+// documentTypes 知识文档支持的扩展名及对应 MIME 类型，同时需在 upload.allowed_types 中
+var documentTypes = map[string]string{
+	".txt":  "text/plain",
+	".md":   "text/markdown",
+	".pdf":  "application/pdf",
+	".docx": "application/vnd.openxmlformats-officedocument.wordprocessingml.document",
+}
+
 // UploadDocument 上传知识文档
 // POST /api/v1/knowledge/documents
 func (h *KnowledgeHandler) UploadDocument(c *gin.Context) {
@@ -42,33 +57,44 @@ func (h *KnowledgeHandler) UploadDocument(c *gin.Context) {
 		return
 	}
 
-	// 获取文件
-	file, header, err := c.Request.FormFile("file")
-	if err != nil {
-		Error(c, http.StatusBadRequest, "请选择要上传的文件", nil)
+	// 获取文件并按上传配置检查大小
+	header, ok := h.uploadLimits.formFile(c, "file")
+	if !ok {
 		return
 	}
-	defer file.Close()
 
-	// 验证文件类型
+	// 验证文件类型，不支持的类型与此前一样返回 400
 	ext := strings.ToLower(filepath.Ext(header.Filename))
-	if ext != ".txt" && ext != ".md" {
-		Error(c, http.StatusBadRequest, "仅支持 .txt 和 .md 格式文件", nil)
+	if mimeType, ok := documentTypes[ext]; !ok || !h.uploadLimits.allows(mimeType) {
+		Error(c, http.StatusBadRequest, service.ErrUnsupportedDocumentType.Error(), nil)
 		return
 	}
 
-	// 验证文件大小（最大 5MB）
-	if header.Size > 5*1024*1024 {
-		Error(c, http.StatusBadRequest, "文件大小不能超过 5MB", nil)
+	file, err := header.Open()
+	if err != nil {
+		Error(c, http.StatusBadRequest, "读取上传文件失败", nil)
 		return
 	}
+	defer file.Close()
 
-	// 读取文件内容
-	content, err := io.ReadAll(file)
+	// 读取文件内容，PDF/DOCX 先提取为纯文本
+	data, err := io.ReadAll(file)
 	if err != nil {
 		Error(c, http.StatusInternalServerError, "读取文件失败", nil)
 		return
 	}
+	content, err := service.ExtractDocumentText(c.Request.Context(), ext, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrUnsupportedDocumentType):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrDocumentExtractFailed), errors.Is(err, service.ErrDocumentEmpty):
+			Error(c, http.StatusUnprocessableEntity, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "读取文件失败", err.Error())
+		}
+		return
+	}
 
 	// 获取可选参数
 	title := c.PostForm("title")
@@ -78,36 +104,58 @@ func (h *KnowledgeHandler) UploadDocument(c *gin.Context) {
 	subject := c.PostForm("subject")
 	grade := c.PostForm("grade")
 
+	// 同内容文档已上传过时直接复用处理结果，force=true 可强制重新处理
+	if force, _ := strconv.ParseBool(c.PostForm("force")); !force {
+		existing, err := h.documentService.FindDuplicate(c.Request.Context(), userIDStr, content)
+		if err != nil {
+			Error(c, http.StatusInternalServerError, fmt.Sprintf("检测重复文档失败: %v", err), nil)
+			return
+		}
+		if existing != nil {
+			Success(c, gin.H{
+				"id":        existing.ID,
+				"title":     existing.Title,
+				"fileName":  existing.FileName,
+				"status":    existing.Status,
+				"duplicate": true,
+				"message":   "已存在相同内容的文档，已直接复用其处理结果",
+			})
+			return
+		}
+	}
+
 	// 创建文档记录
 	doc := &model.KnowledgeDocument{
 		UserID:   userID,
+		TenantID: middleware.GetCurrentTenantID(c),
 		Title:    title,
 		FileName: header.Filename,
 		FileType: strings.TrimPrefix(ext, "."),
 		FileSize: header.Size,
-		Content:  string(content),
+		Content:  content,
 		Subject:  subject,
 		Grade:    grade,
 		Status:   model.DocStatusPending,
 	}
 
 	// 保存文档并触发处理
-	if err := h.documentService.CreateDocument(doc); err != nil {
+	if err := h.documentService.CreateDocument(c.Request.Context(), doc); err != nil {
 		Error(c, http.StatusInternalServerError, fmt.Sprintf("保存文档失败: %v", err), nil)
 		return
 	}
 
 	Success(c, gin.H{
-		"id":       doc.ID,
-		"title":    doc.Title,
-		"fileName": doc.FileName,
-		"status":   doc.Status,
-		"message":  "文档已上传，正在后台处理中",
+		"id":        doc.ID,
+		"title":     doc.Title,
+		"fileName":  doc.FileName,
+		"status":    doc.Status,
+		"duplicate": false,
+		"message":   "文档已上传，正在后台处理中",
 	})
 }
 
 // ListDocuments 获取用户的知识文档列表
-// GET /api/v1/knowledge/documents
+// GET /api/v1/knowledge/documents?page=1&page_size=10&status=failed&subject=数学&grade=七年级
 func (h *KnowledgeHandler) ListDocuments(c *gin.Context) {
 	userIDStr, ok := middleware.GetCurrentUserID(c)
 	if !ok {
@@ -115,13 +163,24 @@ func (h *KnowledgeHandler) ListDocuments(c *gin.Context) {
 		return
 	}
 
-	docs, _, err := h.documentService.ListDocuments(userIDStr, 1, 100)
+	var query documentListQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+	page, pageSize := GetPagination(c)
+
+	docs, total, err := h.documentService.ListDocuments(c.Request.Context(), repository.DocumentFilter{
+		UserID:  userIDStr,
+		Status:  query.Status,
+		Subject: strings.TrimSpace(query.Subject),
+		Grade:   strings.TrimSpace(query.Grade),
+	}, page, pageSize)
 	if err != nil {
 		Error(c, http.StatusInternalServerError, fmt.Sprintf("获取文档列表失败: %v", err), nil)
 		return
 	}
 
-	Success(c, docs)
+	Paginated(c, docs, total, page, pageSize)
 }
 
 // GetDocument 获取文档详情
@@ -139,7 +198,7 @@ func (h *KnowledgeHandler) GetDocument(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.documentService.GetDocument(docID, userIDStr)
+	doc, err := h.documentService.GetDocument(c.Request.Context(), docID, userIDStr)
 	if err != nil {
 		Error(c, http.StatusNotFound, "文档不存在", nil)
 		return
@@ -163,7 +222,7 @@ func (h *KnowledgeHandler) DeleteDocument(c *gin.Context) {
 		return
 	}
 
-	if err := h.documentService.DeleteDocument(docID, userIDStr); err != nil {
+	if err := h.documentService.DeleteDocument(c.Request.Context(), docID, userIDStr); err != nil {
 		Error(c, http.StatusInternalServerError, fmt.Sprintf("删除文档失败: %v", err), nil)
 		return
 	}
@@ -171,6 +230,43 @@ func (h *KnowledgeHandler) DeleteDocument(c *gin.Context) {
 	Success(c, gin.H{"message": "文档已删除"})
 }
 
+// ReprocessDocument 重新处理文档，用于失败或需要重建图谱的文档
+// POST /api/v1/knowledge/documents/:id/reprocess
+func (h *KnowledgeHandler) ReprocessDocument(c *gin.Context) {
+	userIDStr, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未授权", nil)
+		return
+	}
+
+	docID := c.Param("id")
+	if _, err := uuid.Parse(docID); err != nil {
+		Error(c, http.StatusBadRequest, "无效的文档ID", nil)
+		return
+	}
+
+	doc, err := h.documentService.ReprocessDocument(c.Request.Context(), docID, userIDStr)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrDocumentNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrDocumentProcessing):
+			Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, fmt.Sprintf("重新处理文档失败: %v", err), nil)
+		}
+		return
+	}
+
+	Success(c, gin.H{
+		"id":       doc.ID,
+		"title":    doc.Title,
+		"fileName": doc.FileName,
+		"status":   doc.Status,
+		"message":  "文档已重新提交，正在后台处理中",
+	})
+}
+
 // GetDocumentStatus 获取文档处理状态
 // GET /api/v1/knowledge/documents/:id/status
 func (h *KnowledgeHandler) GetDocumentStatus(c *gin.Context) {
@@ -186,7 +282,7 @@ func (h *KnowledgeHandler) GetDocumentStatus(c *gin.Context) {
 		return
 	}
 
-	doc, err := h.documentService.GetDocumentStatus(docID, userIDStr)
+	doc, err := h.documentService.GetDocumentStatus(c.Request.Context(), docID, userIDStr)
 	if err != nil {
 		Error(c, http.StatusNotFound, "文档不存在", nil)
 		return
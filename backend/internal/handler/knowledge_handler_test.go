@@ -0,0 +1,296 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeDocumentRepo 内存中的知识文档仓库，后台处理协程会并发更新状态
+type fakeDocumentRepo struct {
+	repository.DocumentRepository
+
+	mu   sync.Mutex
+	docs map[uuid.UUID]*model.KnowledgeDocument
+}
+
+func newFakeDocumentRepo() *fakeDocumentRepo {
+	return &fakeDocumentRepo{docs: make(map[uuid.UUID]*model.KnowledgeDocument)}
+}
+
+func (r *fakeDocumentRepo) CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc.ID == uuid.Nil {
+		doc.ID = uuid.New()
+	}
+	doc.CreatedAt = time.Now()
+	stored := *doc
+	r.docs[doc.ID] = &stored
+	return nil
+}
+
+func (r *fakeDocumentRepo) FindByContentHash(ctx context.Context, userID string, contentHash string) (*model.KnowledgeDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, doc := range r.docs {
+		if doc.UserID.String() == userID && doc.ContentHash == contentHash && doc.Status != model.DocStatusFailed {
+			copied := *doc
+			return &copied, nil
+		}
+	}
+	return nil, nil
+}
+
+func (r *fakeDocumentRepo) UpdateDocumentStatus(ctx context.Context, docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc, ok := r.docs[docID]; ok {
+		doc.Status = status
+		doc.EntityCount = entityCount
+		doc.RelationCount = relCount
+		doc.ErrorMsg = errorMsg
+	}
+	return nil
+}
+
+func (r *fakeDocumentRepo) ListDocuments(ctx context.Context, filter repository.DocumentFilter, page, pageSize int) ([]model.KnowledgeDocument, int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var matched []model.KnowledgeDocument
+	for _, doc := range r.docs {
+		if doc.UserID.String() != filter.UserID ||
+			(filter.Status != "" && doc.Status != filter.Status) ||
+			(filter.Subject != "" && doc.Subject != filter.Subject) ||
+			(filter.Grade != "" && doc.Grade != filter.Grade) {
+			continue
+		}
+		matched = append(matched, *doc)
+	}
+	sort.Slice(matched, func(i, j int) bool { return matched[i].CreatedAt.After(matched[j].CreatedAt) })
+
+	offset := (page - 1) * pageSize
+	if offset >= len(matched) {
+		return []model.KnowledgeDocument{}, int64(len(matched)), nil
+	}
+	end := offset + pageSize
+	if end > len(matched) {
+		end = len(matched)
+	}
+	return matched[offset:end], int64(len(matched)), nil
+}
+
+// count 仓库中的文档数
+func (r *fakeDocumentRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.docs)
+}
+
+// newFakeGraphAgent 模拟 Agent 的 /api/build-graph 接口，总是构建成功
+func newFakeGraphAgent(t *testing.T) *httptest.Server {
+	t.Helper()
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`{"success":true,"entityCount":1,"relationCount":0}`))
+	}))
+	t.Cleanup(agent.Close)
+	return agent
+}
+
+// newTestKnowledgeRouter 注册知识文档上传与列表路由，请求头 X-Test-User 指定当前用户
+func newTestKnowledgeRouter(t *testing.T, repo repository.DocumentRepository, uploadCfg *config.UploadConfig) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	agent := newFakeGraphAgent(t)
+	h := NewKnowledgeHandler(service.NewDocumentService(repo, &config.AgentConfig{URL: agent.URL, Timeout: 5}), uploadCfg)
+
+	r := gin.New()
+	r.Use(func(c *gin.Context) {
+		if userID := c.GetHeader("X-Test-User"); userID != "" {
+			c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: userID})
+		}
+		c.Next()
+	})
+	r.POST("/knowledge/documents", h.UploadDocument)
+	r.GET("/knowledge/documents", h.ListDocuments)
+	return r
+}
+
+// uploadDocument 以 multipart 表单上传文档，返回状态码与响应中的 data
+func uploadDocument(t *testing.T, r http.Handler, userID uuid.UUID, fileName string, content []byte, fields map[string]string) (int, map[string]interface{}) {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile("file", fileName)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	_, _ = part.Write(content)
+	for k, v := range fields {
+		_ = form.WriteField(k, v)
+	}
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/knowledge/documents", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	req.Header.Set("X-Test-User", userID.String())
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	var resp struct {
+		Data map[string]interface{} `json:"data"`
+	}
+	_ = json.Unmarshal(w.Body.Bytes(), &resp)
+	return w.Code, resp.Data
+}
+
+func TestUploadDocumentDetectsDuplicates(t *testing.T) {
+	repo := newFakeDocumentRepo()
+	r := newTestKnowledgeRouter(t, repo, nil)
+	owner, other := uuid.New(), uuid.New()
+	content := []byte("有理数包括整数和分数。")
+
+	_, first := uploadDocument(t, r, owner, "有理数.txt", content, nil)
+	firstID, _ := first["id"].(string)
+	if firstID == "" {
+		t.Fatalf("first upload data = %v, want a document id", first)
+	}
+
+	tests := []struct {
+		name          string
+		userID        uuid.UUID
+		content       []byte
+		fields        map[string]string
+		wantDuplicate bool
+		wantSameID    bool
+	}{
+		{name: "same content reuses the existing document", userID: owner, content: content, wantDuplicate: true, wantSameID: true},
+		{name: "force re-uploads the same content", userID: owner, content: content, fields: map[string]string{"force": "true"}},
+		{name: "different content is a new document", userID: owner, content: []byte("分数的加减法。")},
+		{name: "other users do not share duplicates", userID: other, content: content},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := repo.count()
+			code, data := uploadDocument(t, r, tt.userID, "有理数.txt", tt.content, tt.fields)
+			if code != http.StatusOK {
+				t.Fatalf("status = %d, want 200", code)
+			}
+			if data["duplicate"] != tt.wantDuplicate {
+				t.Errorf("duplicate = %v, want %v", data["duplicate"], tt.wantDuplicate)
+			}
+			if (data["id"] == firstID) != tt.wantSameID {
+				t.Errorf("id = %v, same as first upload = %v", data["id"], tt.wantSameID)
+			}
+			wantCount := before + 1
+			if tt.wantDuplicate {
+				wantCount = before
+			}
+			if repo.count() != wantCount {
+				t.Errorf("documents = %d, want %d", repo.count(), wantCount)
+			}
+		})
+	}
+}
+
+func TestListDocumentsPagination(t *testing.T) {
+	userID, otherID := uuid.New(), uuid.New()
+	repo := newFakeDocumentRepo()
+	base := time.Now()
+	// 25 篇文档，每 5 篇有一篇失败；另一用户的文档不应出现
+	for i := 0; i < 25; i++ {
+		status := model.DocStatusCompleted
+		if i%5 == 0 {
+			status = model.DocStatusFailed
+		}
+		id := uuid.New()
+		repo.docs[id] = &model.KnowledgeDocument{ID: id, UserID: userID, Title: fmt.Sprintf("文档%02d", i), Subject: "数学", Status: status, CreatedAt: base.Add(time.Duration(i) * time.Minute)}
+	}
+	otherDoc := uuid.New()
+	repo.docs[otherDoc] = &model.KnowledgeDocument{ID: otherDoc, UserID: otherID, Title: "他人文档", Status: model.DocStatusFailed, CreatedAt: base}
+	r := newTestKnowledgeRouter(t, repo, nil)
+
+	tests := []struct {
+		name           string
+		query          string
+		wantStatus     int
+		wantItems      int
+		wantTotal      int64
+		wantPage       int
+		wantPageSize   int
+		wantTotalPages int
+		wantFirst      string
+	}{
+		{name: "default page", query: "", wantStatus: http.StatusOK, wantItems: 10, wantTotal: 25, wantPage: 1, wantPageSize: 10, wantTotalPages: 3, wantFirst: "文档24"},
+		{name: "last partial page", query: "page=3", wantStatus: http.StatusOK, wantItems: 5, wantTotal: 25, wantPage: 3, wantPageSize: 10, wantTotalPages: 3, wantFirst: "文档04"},
+		{name: "past the end", query: "page=4", wantStatus: http.StatusOK, wantItems: 0, wantTotal: 25, wantPage: 4, wantPageSize: 10, wantTotalPages: 3},
+		{name: "page below one", query: "page=0&page_size=5", wantStatus: http.StatusOK, wantItems: 5, wantTotal: 25, wantPage: 1, wantPageSize: 5, wantTotalPages: 5, wantFirst: "文档24"},
+		{name: "page size clamped", query: "page_size=500", wantStatus: http.StatusOK, wantItems: 25, wantTotal: 25, wantPage: 1, wantPageSize: 100, wantTotalPages: 1},
+		{name: "status filter", query: "status=failed", wantStatus: http.StatusOK, wantItems: 5, wantTotal: 5, wantPage: 1, wantPageSize: 10, wantTotalPages: 1, wantFirst: "文档20"},
+		{name: "subject trimmed", query: "subject=%20数学%20&page_size=1", wantStatus: http.StatusOK, wantItems: 1, wantTotal: 25, wantPage: 1, wantPageSize: 1, wantTotalPages: 25, wantFirst: "文档24"},
+		{name: "unknown status", query: "status=archived", wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/knowledge/documents?"+tt.query, nil)
+			req.Header.Set("X-Test-User", userID.String())
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus != http.StatusOK {
+				return
+			}
+
+			var resp struct {
+				Data struct {
+					Items      []model.KnowledgeDocument `json:"items"`
+					Total      int64                     `json:"total"`
+					Page       int                       `json:"page"`
+					PageSize   int                       `json:"page_size"`
+					TotalPages int                       `json:"total_pages"`
+				} `json:"data"`
+			}
+			if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil {
+				t.Fatalf("decode body: %v", err)
+			}
+			got := resp.Data
+			if len(got.Items) != tt.wantItems || got.Total != tt.wantTotal || got.Page != tt.wantPage ||
+				got.PageSize != tt.wantPageSize || got.TotalPages != tt.wantTotalPages {
+				t.Errorf("page = (%d items, total %d, page %d/%d, size %d), want (%d, %d, %d/%d, %d)",
+					len(got.Items), got.Total, got.Page, got.TotalPages, got.PageSize,
+					tt.wantItems, tt.wantTotal, tt.wantPage, tt.wantTotalPages, tt.wantPageSize)
+			}
+			if tt.wantFirst != "" && len(got.Items) > 0 && got.Items[0].Title != tt.wantFirst {
+				t.Errorf("first item = %s, want newest %s", got.Items[0].Title, tt.wantFirst)
+			}
+			for _, doc := range got.Items {
+				if doc.UserID != userID {
+					t.Errorf("item %s belongs to %s, want only the caller's documents", doc.Title, doc.UserID)
+				}
+			}
+		})
+	}
+}
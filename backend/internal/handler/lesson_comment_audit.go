@@ -0,0 +1,78 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// RestoreComment 恢复已删除的评论（评论作者或管理员）
+func (h *LessonHandler) RestoreComment(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的评论ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	comment, err := h.commentService.Restore(c.Request.Context(), commentID, userUUID, currentRole(c))
+	if err != nil {
+		handleCommentError(c, err, "恢复评论失败")
+		return
+	}
+
+	SuccessWithMessage(c, "恢复成功", comment)
+}
+
+// ListCommentAudits 管理员查看评论删除/恢复审计记录，可按 lesson_id 过滤
+func (h *LessonHandler) ListCommentAudits(c *gin.Context) {
+	var lessonID *uuid.UUID
+	if raw := c.Query("lesson_id"); raw != "" {
+		id, err := uuid.Parse(raw)
+		if err != nil {
+			Error(c, http.StatusBadRequest, "无效的教案ID", nil)
+			return
+		}
+		lessonID = &id
+	}
+
+	page, pageSize := GetPagination(c)
+	audits, total, err := h.commentService.ListAudits(c.Request.Context(), lessonID, page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取评论审计记录失败", err.Error())
+		return
+	}
+
+	Paginated(c, audits, total, page, pageSize)
+}
+
+// currentRole 当前用户角色，未登录时为空
+func currentRole(c *gin.Context) string {
+	claims, ok := middleware.GetCurrentClaims(c)
+	if !ok {
+		return ""
+	}
+	return claims.Role
+}
+
+func handleCommentError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrCommentNotFound), errors.Is(err, service.ErrLessonNotFound):
+		Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrUnauthorized):
+		Error(c, http.StatusForbidden, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
@@ -1,13 +1,17 @@
 package handler
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"net/http"
 	"net/url"
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"strings"
 
 	"lesson-plan/backend/internal/middleware"
@@ -19,12 +23,21 @@ import (
 	"github.com/google/uuid"
 )
 
+// confirmTokenHeader 危险操作二次确认令牌的请求头
+const confirmTokenHeader = "X-Confirm-Token"
+
 // LessonHandler 教案处理器
 type LessonHandler struct {
 	lessonService   service.LessonService
 	favoriteService service.FavoriteService
 	likeService     service.LikeService
 	commentService  service.CommentService
+	// uploadDir 上传文件目录，导出时从这里打包教案引用的本地图片
+	uploadDir string
+
+	// knowledgeService/knowledgeLinkURL 导出时为知识点注入跳转到知识图谱页的链接
+	knowledgeService service.KnowledgeService
+	knowledgeLinkURL string
 }
 
 type exportLayoutOption struct {
@@ -39,12 +52,18 @@ func NewLessonHandler(
 	favoriteService service.FavoriteService,
 	likeService service.LikeService,
 	commentService service.CommentService,
+	knowledgeService service.KnowledgeService,
+	uploadDir string,
+	knowledgeLinkURL string,
 ) *LessonHandler {
 	return &LessonHandler{
-		lessonService:   lessonService,
-		favoriteService: favoriteService,
-		likeService:     likeService,
-		commentService:  commentService,
+		lessonService:    lessonService,
+		favoriteService:  favoriteService,
+		likeService:      likeService,
+		commentService:   commentService,
+		uploadDir:        uploadDir,
+		knowledgeService: knowledgeService,
+		knowledgeLinkURL: knowledgeLinkURL,
 	}
 }
 
@@ -77,21 +96,39 @@ func isValidExportLayout(layout string) bool {
 	return false
 }
 
-// List 教案列表
+// List 教案列表，可按重复传参的 tag 筛选标签，tags_match=all 时须包含全部标签；
+// sort 支持 created_at/view_count/like_count/favorite_count/grade，order 为 asc/desc，默认按创建时间倒序，无效值返回 400
 func (h *LessonHandler) List(c *gin.Context) {
 	page, pageSize := GetPagination(c)
 
+	sortBy, sortOrder := c.Query("sort"), strings.ToLower(c.Query("order"))
+	if !repository.IsValidLessonSort(sortBy) {
+		Error(c, http.StatusBadRequest, "不支持的排序方式，请使用 created_at、view_count、like_count、favorite_count 或 grade", nil)
+		return
+	}
+	switch sortOrder {
+	case "", repository.SortOrderAsc, repository.SortOrderDesc:
+	default:
+		Error(c, http.StatusBadRequest, "不支持的排序方向，请使用 asc 或 desc", nil)
+		return
+	}
+
 	filter := repository.LessonFilter{
-		Subject: c.Query("subject"),
-		Grade:   c.Query("grade"),
-		Status:  c.Query("status"),
-		Keyword: c.Query("keyword"),
+		Subject:      c.Query("subject"),
+		Grade:        c.Query("grade"),
+		Status:       c.Query("status"),
+		Keyword:      c.Query("keyword"),
+		Tags:         queryTags(c),
+		TagsMatchAll: c.Query("tags_match") == "all",
+		SortBy:       sortBy,
+		SortOrder:    sortOrder,
 	}
 
-	// 只显示当前用户的教案
+	// 只显示当前用户的教案，并标记其点赞、收藏状态
 	if userID, ok := middleware.GetCurrentUserID(c); ok {
 		uid, _ := uuid.Parse(userID)
 		filter.UserID = &uid
+		filter.Viewer = &uid
 	}
 
 	lessons, total, err := h.lessonService.List(c.Request.Context(), filter, page, pageSize)
@@ -103,6 +140,19 @@ func (h *LessonHandler) List(c *gin.Context) {
 	Paginated(c, lessons, total, page, pageSize)
 }
 
+// queryTags 读取重复传参的 tag，去除空白与重复项
+func queryTags(c *gin.Context) []string {
+	var tags []string
+	seen := make(map[string]bool)
+	for _, tag := range c.QueryArray("tag") {
+		if tag = strings.TrimSpace(tag); tag != "" && !seen[tag] {
+			seen[tag] = true
+			tags = append(tags, tag)
+		}
+	}
+	return tags
+}
+
 // GetByID 获取教案详情
 func (h *LessonHandler) GetByID(c *gin.Context) {
 	id, err := uuid.Parse(c.Param("id"))
@@ -143,6 +193,10 @@ func (h *LessonHandler) Create(c *gin.Context) {
 	userUUID, _ := uuid.Parse(userID)
 	lesson, err := h.lessonService.Create(c.Request.Context(), userUUID, &req)
 	if err != nil {
+		if errors.Is(err, service.ErrInvalidGenerationSource) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
 		Error(c, http.StatusInternalServerError, "创建失败", err.Error())
 		return
 	}
@@ -154,6 +208,43 @@ func (h *LessonHandler) Create(c *gin.Context) {
 	})
 }
 
+// ImportFromURL 抓取网页正文导入为教案草稿
+func (h *LessonHandler) ImportFromURL(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req service.ImportLessonURLRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	lesson, err := h.lessonService.ImportFromURL(c.Request.Context(), userUUID, &req)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrImportURLInvalid),
+			errors.Is(err, service.ErrImportURLForbidden),
+			errors.Is(err, service.ErrImportNotHTML):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrImportNoContent):
+			Error(c, http.StatusUnprocessableEntity, err.Error(), nil)
+		default:
+			Error(c, http.StatusBadGateway, "导入失败", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Code:    0,
+		Message: "导入成功",
+		Data:    lesson,
+	})
+}
+
 // Update 更新教案
 func (h *LessonHandler) Update(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -177,7 +268,16 @@ func (h *LessonHandler) Update(c *gin.Context) {
 	userUUID, _ := uuid.Parse(userID)
 	lesson, err := h.lessonService.Update(c.Request.Context(), id, userUUID, &req)
 	if err != nil {
-		Error(c, http.StatusInternalServerError, "更新失败", err.Error())
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, service.ErrLessonTitleRequired):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "更新失败", err.Error())
+		}
 		return
 	}
 
@@ -186,6 +286,16 @@ func (h *LessonHandler) Update(c *gin.Context) {
 
 // Delete 删除教案
 func (h *LessonHandler) Delete(c *gin.Context) {
+	h.deleteWithConfirm(c, h.lessonService.Delete, "删除成功")
+}
+
+// Purge 永久清除教案，需携带 permanent=true 申请的确认令牌
+func (h *LessonHandler) Purge(c *gin.Context) {
+	h.deleteWithConfirm(c, h.lessonService.Purge, "已永久删除")
+}
+
+// deleteWithConfirm 读取二次确认令牌并执行删除类操作
+func (h *LessonHandler) deleteWithConfirm(c *gin.Context, del func(ctx context.Context, id, userID uuid.UUID, confirmToken string) error, message string) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
 		Error(c, http.StatusUnauthorized, "未认证", nil)
@@ -198,13 +308,62 @@ func (h *LessonHandler) Delete(c *gin.Context) {
 		return
 	}
 
+	// 确认令牌优先从请求头读取，也可通过 confirm_token 查询参数传入
+	confirmToken := strings.TrimSpace(c.GetHeader(confirmTokenHeader))
+	if confirmToken == "" {
+		confirmToken = strings.TrimSpace(c.Query("confirm_token"))
+	}
+
 	userUUID, _ := uuid.Parse(userID)
-	if err := h.lessonService.Delete(c.Request.Context(), id, userUUID); err != nil {
-		Error(c, http.StatusInternalServerError, "删除失败", err.Error())
+	if err := del(c.Request.Context(), id, userUUID, confirmToken); err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		case errors.Is(err, service.ErrConfirmTokenRequired):
+			ErrorWithCode(c, http.StatusPreconditionRequired, "CONFIRM_REQUIRED", err.Error(), nil)
+		case errors.Is(err, service.ErrConfirmTokenInvalid):
+			ErrorWithCode(c, http.StatusForbidden, "CONFIRM_INVALID", err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "删除失败", err.Error())
+		}
 		return
 	}
 
-	SuccessWithMessage(c, "删除成功", nil)
+	SuccessWithMessage(c, message, nil)
+}
+
+// ConfirmDelete 申请删除教案的二次确认令牌，permanent=true 时申请永久清除的令牌
+func (h *LessonHandler) ConfirmDelete(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	permanent := c.Query("permanent") == "true"
+	confirmation, err := h.lessonService.ConfirmDelete(c.Request.Context(), id, userUUID, permanent)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "生成确认令牌失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, confirmation)
 }
 
 // Publish 发布教案
@@ -223,13 +382,55 @@ func (h *LessonHandler) Publish(c *gin.Context) {
 
 	userUUID, _ := uuid.Parse(userID)
 	if err := h.lessonService.Publish(c.Request.Context(), id, userUUID); err != nil {
-		Error(c, http.StatusInternalServerError, "发布失败", err.Error())
+		var incomplete *service.LessonIncompleteError
+		switch {
+		case errors.As(err, &incomplete):
+			ErrorWithCode(c, http.StatusUnprocessableEntity, "LESSON_INCOMPLETE", err.Error(), gin.H{"missing": incomplete.Missing})
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权发布此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "发布失败", err.Error())
+		}
 		return
 	}
 
 	SuccessWithMessage(c, "发布成功", nil)
 }
 
+// Restore 恢复已归档的教案
+func (h *LessonHandler) Restore(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	if err := h.lessonService.Restore(c.Request.Context(), id, userUUID); err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权恢复此教案", nil)
+		case errors.Is(err, service.ErrLessonNotArchived):
+			Error(c, http.StatusConflict, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "恢复失败", err.Error())
+		}
+		return
+	}
+
+	SuccessWithMessage(c, "恢复成功", nil)
+}
+
 // MyLessons 我的教案
 func (h *LessonHandler) MyLessons(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -250,7 +451,13 @@ func (h *LessonHandler) MyLessons(c *gin.Context) {
 	Paginated(c, lessons, total, page, pageSize)
 }
 
-// AddFavorite 添加收藏
+// AddFavoriteRequest 收藏请求，请求体可省略
+type AddFavoriteRequest struct {
+	// Folder 收藏夹名称，不存在时自动创建；为空表示不分组
+	Folder string `json:"folder"`
+}
+
+// AddFavorite 添加收藏，可指定收藏夹；已收藏时移入指定收藏夹
 func (h *LessonHandler) AddFavorite(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
@@ -264,8 +471,20 @@ func (h *LessonHandler) AddFavorite(c *gin.Context) {
 		return
 	}
 
+	var req AddFavoriteRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			Error(c, http.StatusBadRequest, "参数错误", err.Error())
+			return
+		}
+	}
+
 	userUUID, _ := uuid.Parse(userID)
-	if err := h.favoriteService.Add(c.Request.Context(), userUUID, id); err != nil {
+	if err := h.favoriteService.Add(c.Request.Context(), userUUID, id, req.Folder); err != nil {
+		if errors.Is(err, service.ErrFavoriteFolderNameTooLong) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
 		Error(c, http.StatusInternalServerError, "收藏失败", err.Error())
 		return
 	}
@@ -296,7 +515,7 @@ func (h *LessonHandler) RemoveFavorite(c *gin.Context) {
 	SuccessWithMessage(c, "取消收藏成功", nil)
 }
 
-// MyFavorites 我的收藏
+// MyFavorites 我的收藏，folder 参数按收藏夹筛选
 func (h *LessonHandler) MyFavorites(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
@@ -307,7 +526,7 @@ func (h *LessonHandler) MyFavorites(c *gin.Context) {
 	page, pageSize := GetPagination(c)
 	userUUID, _ := uuid.Parse(userID)
 
-	lessons, total, err := h.favoriteService.List(c.Request.Context(), userUUID, page, pageSize)
+	lessons, total, err := h.favoriteService.List(c.Request.Context(), userUUID, c.Query("folder"), page, pageSize)
 	if err != nil {
 		Error(c, http.StatusInternalServerError, "获取列表失败", err.Error())
 		return
@@ -316,6 +535,24 @@ func (h *LessonHandler) MyFavorites(c *gin.Context) {
 	Paginated(c, lessons, total, page, pageSize)
 }
 
+// MyFavoriteFolders 我的收藏夹及各自的收藏数
+func (h *LessonHandler) MyFavoriteFolders(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	folders, err := h.favoriteService.ListFolders(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取收藏夹失败", err.Error())
+		return
+	}
+
+	Success(c, folders)
+}
+
 // Like 点赞
 func (h *LessonHandler) Like(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -370,11 +607,26 @@ func (h *LessonHandler) ListComments(c *gin.Context) {
 		return
 	}
 
+	sort := c.DefaultQuery("sort", repository.CommentSortNew)
+	switch sort {
+	case repository.CommentSortNew, repository.CommentSortOld, repository.CommentSortHot:
+	default:
+		Error(c, http.StatusBadRequest, "不支持的排序方式，请使用 hot、new 或 old", nil)
+		return
+	}
+
 	page, pageSize := GetPagination(c)
 
-	comments, total, err := h.commentService.List(c.Request.Context(), id, page, pageSize)
+	// 作者可查看未发布教案的评论
+	var viewerID *uuid.UUID
+	if userID, ok := middleware.GetCurrentUserID(c); ok {
+		uid, _ := uuid.Parse(userID)
+		viewerID = &uid
+	}
+
+	comments, total, err := h.commentService.List(c.Request.Context(), id, viewerID, sort, page, pageSize)
 	if err != nil {
-		Error(c, http.StatusInternalServerError, "获取评论失败", err.Error())
+		handleCommentError(c, err, "获取评论失败")
 		return
 	}
 
@@ -442,14 +694,57 @@ func (h *LessonHandler) DeleteComment(c *gin.Context) {
 	}
 
 	userUUID, _ := uuid.Parse(userID)
-	if err := h.commentService.Delete(c.Request.Context(), commentID, userUUID); err != nil {
-		Error(c, http.StatusInternalServerError, "删除评论失败", err.Error())
+	if err := h.commentService.Delete(c.Request.Context(), commentID, userUUID, currentRole(c)); err != nil {
+		handleCommentError(c, err, "删除评论失败")
 		return
 	}
 
 	SuccessWithMessage(c, "删除成功", nil)
 }
 
+// LikeComment 点赞评论
+func (h *LessonHandler) LikeComment(c *gin.Context) {
+	h.toggleCommentLike(c, true)
+}
+
+// UnlikeComment 取消评论点赞
+func (h *LessonHandler) UnlikeComment(c *gin.Context) {
+	h.toggleCommentLike(c, false)
+}
+
+func (h *LessonHandler) toggleCommentLike(c *gin.Context, like bool) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的教案ID", nil)
+		return
+	}
+	commentID, err := uuid.Parse(c.Param("commentId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的评论ID", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	var comment *model.Comment
+	if like {
+		comment, err = h.commentService.Like(c.Request.Context(), lessonID, commentID, userUUID)
+	} else {
+		comment, err = h.commentService.Unlike(c.Request.Context(), lessonID, commentID, userUUID)
+	}
+	if err != nil {
+		handleCommentError(c, err, "操作失败")
+		return
+	}
+
+	Success(c, comment)
+}
+
 // Search 搜索教案
 func (h *LessonHandler) Search(c *gin.Context) {
 	query := c.Query("q")
@@ -460,7 +755,14 @@ func (h *LessonHandler) Search(c *gin.Context) {
 
 	page, pageSize := GetPagination(c)
 
-	lessons, total, err := h.lessonService.Search(c.Request.Context(), query, page, pageSize)
+	// 登录用户额外能搜到自己未发布的教案
+	var viewerID *uuid.UUID
+	if userID, ok := middleware.GetCurrentUserID(c); ok {
+		uid, _ := uuid.Parse(userID)
+		viewerID = &uid
+	}
+
+	lessons, total, err := h.lessonService.Search(c.Request.Context(), query, viewerID, page, pageSize)
 	if err != nil {
 		Error(c, http.StatusInternalServerError, "搜索失败", err.Error())
 		return
@@ -553,135 +855,723 @@ func (h *LessonHandler) RollbackToVersion(c *gin.Context) {
 	Success(c, lesson)
 }
 
-// QualityReview 教案质量评分与自动审查。
-func (h *LessonHandler) QualityReview(c *gin.Context) {
+// LabelVersionRequest 版本命名请求，label 为空表示取消命名
+type LabelVersionRequest struct {
+	Label string `json:"label" binding:"max=50"`
+}
+
+// LabelVersion 给教案版本命名（如“公开课最终版”），命名的版本作为里程碑保留
+// POST /api/v1/lessons/:id/versions/:version/label
+func (h *LessonHandler) LabelVersion(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
 		Error(c, http.StatusUnauthorized, "未认证", nil)
 		return
 	}
 
-	lessonID, err := uuid.Parse(c.Param("id"))
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	userUUID, err := uuid.Parse(userID)
-	if err != nil {
-		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+	var version int
+	if _, err := fmt.Sscanf(c.Param("version"), "%d", &version); err != nil {
+		Error(c, http.StatusBadRequest, "无效的版本号", nil)
 		return
 	}
 
-	report, err := h.lessonService.ReviewQuality(c.Request.Context(), lessonID, userUUID)
+	var req LabelVersionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	v, err := h.lessonService.LabelVersion(c.Request.Context(), id, version, userUUID, req.Label)
 	if err != nil {
-		Error(c, http.StatusInternalServerError, "质量审查失败", err.Error())
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrVersionNotFound):
+			Error(c, http.StatusNotFound, "版本不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权修改此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "版本命名失败", err.Error())
+		}
 		return
 	}
 
-	Success(c, report)
+	Success(c, v)
 }
 
-// DiffVersions 比较两个版本的差异。
-func (h *LessonHandler) DiffVersions(c *gin.Context) {
+// ListEdits 获取教案编辑操作记录及撤销/重做状态
+func (h *LessonHandler) ListEdits(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
 	if !ok {
 		Error(c, http.StatusUnauthorized, "未认证", nil)
 		return
 	}
 
-	lessonID, err := uuid.Parse(c.Param("id"))
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	fromVersion := strings.TrimSpace(c.Query("from"))
-	toVersion := strings.TrimSpace(c.Query("to"))
-	if fromVersion == "" {
-		Error(c, http.StatusBadRequest, "缺少 from 版本号", nil)
+	userUUID, _ := uuid.Parse(userID)
+	history, err := h.lessonService.ListEdits(c.Request.Context(), id, userUUID)
+	if err != nil {
+		writeLessonEditError(c, err, "获取编辑记录失败")
 		return
 	}
-	if toVersion == "" {
-		toVersion = "current"
+
+	Success(c, history)
+}
+
+// UndoEdit 撤销最近一次编辑
+func (h *LessonHandler) UndoEdit(c *gin.Context) {
+	h.stepEdit(c, h.lessonService.UndoEdit, "撤销失败")
+}
+
+// RedoEdit 重做最近一次撤销的编辑
+func (h *LessonHandler) RedoEdit(c *gin.Context) {
+	h.stepEdit(c, h.lessonService.RedoEdit, "重做失败")
+}
+
+// stepEdit 撤销/重做共用的请求处理
+func (h *LessonHandler) stepEdit(
+	c *gin.Context,
+	step func(ctx context.Context, lessonID, userID uuid.UUID) (*service.LessonEditState, error),
+	failMessage string,
+) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
 	}
 
-	userUUID, err := uuid.Parse(userID)
+	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	diff, err := h.lessonService.CompareVersions(c.Request.Context(), lessonID, userUUID, fromVersion, toVersion)
+	userUUID, _ := uuid.Parse(userID)
+	state, err := step(c.Request.Context(), id, userUUID)
 	if err != nil {
-		Error(c, http.StatusBadRequest, "版本对比失败", err.Error())
+		writeLessonEditError(c, err, failMessage)
 		return
 	}
 
-	Success(c, diff)
+	Success(c, state)
 }
 
-// ExportLayouts 返回可用导出模板。
-func (h *LessonHandler) ExportLayouts(c *gin.Context) {
-	Success(c, availableExportLayouts())
+// writeLessonEditError 编辑记录相关错误映射
+func writeLessonEditError(c *gin.Context, err error, failMessage string) {
+	switch {
+	case errors.Is(err, service.ErrLessonNotFound):
+		Error(c, http.StatusNotFound, "教案不存在", nil)
+	case errors.Is(err, service.ErrUnauthorized):
+		Error(c, http.StatusForbidden, err.Error(), nil)
+	case errors.Is(err, service.ErrNothingToUndo), errors.Is(err, service.ErrNothingToRedo):
+		Error(c, http.StatusConflict, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, failMessage, err.Error())
+	}
 }
 
-// Export 导出教案
-func (h *LessonHandler) Export(c *gin.Context) {
+// AutoCover 根据学科与标题自动生成占位封面
+func (h *LessonHandler) AutoCover(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
 	id, err := uuid.Parse(c.Param("id"))
 	if err != nil {
 		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	format := c.Query("format")
-	if format == "" {
-		format = "md"
+	userUUID, _ := uuid.Parse(userID)
+	cover, err := h.lessonService.GenerateCover(c.Request.Context(), id, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "生成封面失败", err.Error())
+		}
+		return
 	}
-	layout := strings.TrimSpace(c.Query("layout"))
-	if layout == "" {
-		layout = "standard"
+
+	Success(c, cover)
+}
+
+// Cover 输出教案封面图片
+func (h *LessonHandler) Cover(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
 	}
 
-	// 验证格式
-	validFormats := map[string]bool{"md": true, "pdf": true, "docx": true}
-	if !validFormats[format] {
-		Error(c, http.StatusBadRequest, "不支持的格式，请使用 md、pdf 或 docx", nil)
+	coverURL, err := h.lessonService.GetCoverURL(c.Request.Context(), id)
+	if err != nil {
+		Error(c, http.StatusNotFound, err.Error(), nil)
 		return
 	}
-	if !isValidExportLayout(layout) {
-		Error(c, http.StatusBadRequest, "不支持的模板，请使用 standard、compact 或 research", nil)
+	path, ok := h.resolveUploadPath(coverURL)
+	if !ok {
+		Error(c, http.StatusNotFound, "封面文件不存在", nil)
 		return
 	}
 
-	var currentUserID *uuid.UUID
-	if userID, ok := middleware.GetCurrentUserID(c); ok {
-		uid, _ := uuid.Parse(userID)
-		currentUserID = &uid
+	// SVG 可内嵌脚本，禁止执行任何脚本与外部资源
+	c.Header("Content-Security-Policy", "default-src 'none'; style-src 'unsafe-inline'")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "no-cache")
+	c.File(path)
+}
+
+// QualityReview 教案质量评分与自动审查。
+func (h *LessonHandler) QualityReview(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
 	}
 
-	lesson, err := h.lessonService.GetByID(c.Request.Context(), id, currentUserID)
+	lessonID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		Error(c, http.StatusNotFound, "教案不存在", nil)
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	// 生成 Markdown 内容（模板化版式）
-	mdContent := h.generateMarkdown(lesson, layout)
-
-	// 如果是 md 格式，直接返回
-	if format == "md" {
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	report, err := h.lessonService.ReviewQuality(c.Request.Context(), lessonID, userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "质量审查失败", err.Error())
+		return
+	}
+
+	Success(c, report)
+}
+
+// ComplianceCheck 对照课标规则检查教案，返回缺失项
+func (h *LessonHandler) ComplianceCheck(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	report, err := h.lessonService.CheckCompliance(c.Request.Context(), lessonID, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权检查此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "合规检查失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, report)
+}
+
+// Readability 分析教案可读性并给出适合的年级建议
+func (h *LessonHandler) Readability(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	result, err := h.lessonService.AnalyzeReadability(c.Request.Context(), lessonID, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权查看此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "可读性分析失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, result)
+}
+
+// Translate 翻译教案，可另存为新教案。
+func (h *LessonHandler) Translate(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	var query translateQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+	target := strings.ToLower(strings.TrimSpace(query.Target))
+	if !service.IsSupportedTranslationTarget(target) {
+		Error(c, http.StatusBadRequest, "不支持的目标语言，请使用 en、zh 或 ja", nil)
+		return
+	}
+
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+	ctx := service.WithAPIKeyOverride(c.Request.Context(), keyOverride)
+
+	translation, err := h.lessonService.Translate(ctx, lessonID, userUUID, target, query.SaveAsNew)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权翻译此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "翻译失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, translation)
+}
+
+// StudentVersion 基于教案生成学生学案（去掉教师活动、保留学习任务），可另存为新教案。
+func (h *LessonHandler) StudentVersion(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	var query studentVersionQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+	mode := strings.ToLower(strings.TrimSpace(query.Mode))
+	if !service.IsSupportedStudentVersionMode(mode) {
+		Error(c, http.StatusBadRequest, "不支持的生成方式，请使用 auto、agent 或 rule", nil)
+		return
+	}
+
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+	ctx := service.WithAPIKeyOverride(c.Request.Context(), keyOverride)
+
+	version, err := h.lessonService.StudentVersion(ctx, lessonID, userUUID, mode, query.SaveAsNew)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权转换此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "学案生成失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, version)
+}
+
+// Beautify 规范化教案排版（标点、空行、列表），preview=true 时只返回排版结果不保存
+func (h *LessonHandler) Beautify(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	var query beautifyQuery
+	if !BindQuery(c, &query) {
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	result, err := h.lessonService.Beautify(c.Request.Context(), lessonID, userUUID, query.Preview)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权修改此教案", nil)
+		case errors.Is(err, service.ErrBeautifyUnsupported):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "排版失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, result)
+}
+
+// Glossary 提取教案中的专业术语并配上解释，优先使用知识库描述，其余由 Agent 补充。
+func (h *LessonHandler) Glossary(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	keyOverride := service.NewAPIKeyOverride(
+		c.GetHeader(service.HeaderGenerationAPIKey),
+		c.GetHeader(service.HeaderEmbeddingAPIKey),
+	)
+	ctx := service.WithAPIKeyOverride(c.Request.Context(), keyOverride)
+
+	glossary, err := h.lessonService.Glossary(ctx, lessonID, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权查看此教案", nil)
+		case errors.Is(err, service.ErrGlossaryUnavailable):
+			Error(c, http.StatusServiceUnavailable, "术语提取服务暂不可用，请稍后重试", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "术语提取失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, glossary)
+}
+
+// GetSourceGeneration 获取教案的来源生成记录
+func (h *LessonHandler) GetSourceGeneration(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	source, err := h.lessonService.GetSourceGeneration(c.Request.Context(), lessonID, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound), errors.Is(err, service.ErrGenerationSourceNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权查看此教案的生成记录", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "获取生成记录失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, source)
+}
+
+// ImageSuggestions 根据教案内容给出配图建议
+func (h *LessonHandler) ImageSuggestions(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	suggestions, err := h.lessonService.SuggestImages(c.Request.Context(), lessonID, userUUID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound):
+			Error(c, http.StatusNotFound, "教案不存在", nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, "无权查看此教案", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "生成配图建议失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, suggestions)
+}
+
+// DiffVersions 比较两个版本的差异。
+func (h *LessonHandler) DiffVersions(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	fromVersion := strings.TrimSpace(c.Query("from"))
+	toVersion := strings.TrimSpace(c.Query("to"))
+	if fromVersion == "" {
+		Error(c, http.StatusBadRequest, "缺少 from 版本号", nil)
+		return
+	}
+	if toVersion == "" {
+		toVersion = "current"
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	h.writeVersionDiff(c, lessonID, userUUID, fromVersion, toVersion)
+}
+
+// DiffVersionRange 对比两个版本：GET /lessons/:id/versions/:version/diff/:to，
+// 版本号可写作 3、v3，或 current 表示当前内容
+func (h *LessonHandler) DiffVersionRange(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	userUUID, err := uuid.Parse(userID)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return
+	}
+
+	h.writeVersionDiff(c, lessonID, userUUID, c.Param("version"), c.Param("to"))
+}
+
+// writeVersionDiff 计算版本差异并写入响应，教案或版本不存在返回 404，非作者返回 403
+func (h *LessonHandler) writeVersionDiff(c *gin.Context, lessonID, userID uuid.UUID, fromVersion, toVersion string) {
+	diff, err := h.lessonService.CompareVersions(c.Request.Context(), lessonID, userID, fromVersion, toVersion)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrLessonNotFound), errors.Is(err, service.ErrVersionNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		case errors.Is(err, service.ErrUnauthorized):
+			Error(c, http.StatusForbidden, err.Error(), nil)
+		default:
+			Error(c, http.StatusBadRequest, "版本对比失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, diff)
+}
+
+// ExportLayouts 返回可用导出模板。
+func (h *LessonHandler) ExportLayouts(c *gin.Context) {
+	Success(c, availableExportLayouts())
+}
+
+// Export 导出教案，toc=true 时 PDF 开头生成目录；knowledge_links=true 时把当前用户图谱中的知识点链接到图谱页
+func (h *LessonHandler) Export(c *gin.Context) {
+	id, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
+
+	query, sections, ok := bindExportQuery(c)
+	if !ok {
+		return
+	}
+
+	var currentUserID *uuid.UUID
+	userID, ok := middleware.GetCurrentUserID(c)
+	if ok {
+		uid, _ := uuid.Parse(userID)
+		currentUserID = &uid
+	}
+
+	lesson, err := h.lessonService.GetByID(c.Request.Context(), id, currentUserID)
+	if err != nil {
+		Error(c, http.StatusNotFound, "教案不存在", nil)
+		return
+	}
+
+	var knowledgeNames []string
+	if query.KnowledgeLinks {
+		knowledgeNames = h.knowledgeLinkNames(c.Request.Context(), lesson, userID)
+	}
+	h.writeExport(c, lesson, query.Format, query.Layout, sections, query.TOC, knowledgeNames)
+}
+
+// validateExportOptions 校验导出格式与模板，失败时已写入错误响应
+func validateExportOptions(c *gin.Context, format, layout string) bool {
+	validFormats := map[string]bool{"md": true, "pdf": true, "docx": true}
+	if !validFormats[format] {
+		Error(c, http.StatusBadRequest, "不支持的格式，请使用 md、pdf 或 docx", nil)
+		return false
+	}
+	if !isValidExportLayout(layout) {
+		Error(c, http.StatusBadRequest, "不支持的模板，请使用 standard、compact 或 research", nil)
+		return false
+	}
+	return true
+}
+
+// bindExportQuery 解析并校验导出参数（格式、模板、章节），失败时已写入错误响应
+func bindExportQuery(c *gin.Context) (*exportQuery, []string, bool) {
+	var query exportQuery
+	if !BindQuery(c, &query) {
+		return nil, nil, false
+	}
+	if query.Format = strings.TrimSpace(query.Format); query.Format == "" {
+		query.Format = "md"
+	}
+	if query.Layout = strings.TrimSpace(query.Layout); query.Layout == "" {
+		query.Layout = "standard"
+	}
+	if !validateExportOptions(c, query.Format, query.Layout) {
+		return nil, nil, false
+	}
+
+	sections, err := parseExportSections(query.Sections)
+	if err != nil {
+		Error(c, http.StatusBadRequest, err.Error(), nil)
+		return nil, nil, false
+	}
+	return &query, sections, true
+}
+
+// writeExport 按格式渲染教案并写出附件，toc 仅对 PDF 生效，knowledgeNames 非空时为其中的知识点注入链接
+func (h *LessonHandler) writeExport(c *gin.Context, lesson *model.LessonDetail, format, layout string, sections []string, toc bool, knowledgeNames []string) {
+	// 生成 Markdown 内容（模板化版式）
+	mdContent := h.generateMarkdown(lesson, layout, sections)
+	if len(knowledgeNames) > 0 {
+		mdContent = linkKnowledgePoints(mdContent, knowledgeNames, h.knowledgeLinkFor)
+	}
+	if exportNotModified(c, exportETag(mdContent, format, layout, toc)) {
+		return
+	}
+
+	// 如果是 md 格式，直接返回
+	if format == "md" {
 		c.Header("Content-Type", "text/markdown; charset=utf-8")
-		// 使用 RFC 5987 编码处理中文文件名
-		encodedFilename := url.PathEscape(lesson.Title + ".md")
-		c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", encodedFilename))
+		setAttachmentHeader(c, lesson.Title, lessonExportFallbackName(lesson), "md")
 		c.String(http.StatusOK, mdContent)
 		return
 	}
 
 	// 使用 pandoc 转换
-	outputFile, err := h.convertWithPandoc(mdContent, lesson.Title, format, layout)
+	outputFile, err := h.convertWithPandoc(mdContent, sanitizeFilename(lesson.Title, lessonExportFallbackName(lesson)), format, layout, toc)
 	if err != nil {
+		c.Writer.Header().Del("ETag")
 		Error(c, http.StatusInternalServerError, "转换失败: "+err.Error(), nil)
 		return
 	}
@@ -700,12 +1590,15 @@ func (h *LessonHandler) Export(c *gin.Context) {
 	}
 
 	c.Header("Content-Type", contentType)
-	// 使用 RFC 5987 编码处理中文文件名
-	encodedFilename := url.PathEscape(lesson.Title + "." + ext)
-	c.Header("Content-Disposition", fmt.Sprintf("attachment; filename*=UTF-8''%s", encodedFilename))
+	setAttachmentHeader(c, lesson.Title, lessonExportFallbackName(lesson), ext)
 	c.File(outputFile)
 }
 
+// lessonExportFallbackName 标题清洗后为空时使用的文件名
+func lessonExportFallbackName(lesson *model.LessonDetail) string {
+	return "lesson-" + lesson.ID.String()
+}
+
 func extractLessonText(raw string) string {
 	if raw == "" || raw == "{}" {
 		return ""
@@ -722,7 +1615,39 @@ func extractLessonText(raw string) string {
 }
 
 func sanitizeMarkdown(raw string) string {
+	return sanitizeMarkdownText(extractLessonText(raw))
+}
+
+// lessonFieldMarkdown 按教案的 content_type 把字段统一转为 Markdown：HTML 先交给 pandoc 转换，Markdown 直接使用
+func lessonFieldMarkdown(raw, contentType string) string {
+	if contentType != model.LessonContentTypeHTML {
+		return sanitizeMarkdown(raw)
+	}
 	value := extractLessonText(raw)
+	if value == "" {
+		return ""
+	}
+	return sanitizeMarkdownText(htmlToMarkdown(value))
+}
+
+var htmlTagPattern = regexp.MustCompile(`<[^>]*>`)
+
+// htmlToMarkdown 使用 pandoc 将富文本 HTML 转为 Markdown；pandoc 不可用时退化为去标签的纯文本
+func htmlToMarkdown(value string) string {
+	cmd := exec.Command("pandoc",
+		"--from", "html",
+		"--to", "markdown-raw_html-native_divs-native_spans-header_attributes+tex_math_dollars",
+		"--wrap", "none",
+	)
+	cmd.Stdin = strings.NewReader(value)
+	output, err := cmd.Output()
+	if err != nil {
+		return strings.TrimSpace(html.UnescapeString(htmlTagPattern.ReplaceAllString(value, "")))
+	}
+	return strings.TrimSpace(string(output))
+}
+
+func sanitizeMarkdownText(value string) string {
 	value = strings.ReplaceAll(value, "\n---\n", "\n\n---\n\n")
 
 	lines := strings.Split(value, "\n")
@@ -734,13 +1659,85 @@ func sanitizeMarkdown(raw string) string {
 	return strings.Join(lines, "\n")
 }
 
-// generateMarkdown 生成模板化 Markdown 内容。
-func (h *LessonHandler) generateMarkdown(lesson *model.LessonDetail, layout string) string {
-	objectives := sanitizeMarkdown(lesson.Objectives)
-	content := sanitizeMarkdown(lesson.Content)
-	activities := sanitizeMarkdown(lesson.Activities)
-	assessment := sanitizeMarkdown(lesson.Assessment)
-	resources := sanitizeMarkdown(lesson.Resources)
+// exportSectionTitles 各模板下章节标题；research 模板按导出顺序自动编号，空章节以占位文字代替
+var exportSectionTitles = map[string]map[string]string{
+	"standard": {
+		"objectives": "教学目标",
+		"content":    "教学内容",
+		"activities": "教学活动",
+		"assessment": "教学评价",
+		"resources":  "教学资源",
+	},
+	"compact": {
+		"objectives": "目标速览",
+		"content":    "内容主线",
+		"activities": "课堂执行步骤",
+		"assessment": "达成检测",
+		"resources":  "资源清单",
+	},
+	"research": {
+		"objectives": "教学目标",
+		"content":    "教学内容与重难点",
+		"activities": "教学活动设计",
+		"assessment": "评价方案",
+		"resources":  "资源与保障",
+	},
+}
+
+// researchSectionFallbacks research 模板中空章节的占位文字
+var researchSectionFallbacks = map[string]string{
+	"objectives": "待补充目标说明。",
+	"content":    "待补充内容说明。",
+	"activities": "待补充活动设计。",
+	"assessment": "待补充评价方案。",
+	"resources":  "待补充资源配置。",
+}
+
+// defaultExportSections 未指定 sections 时导出的章节及顺序
+var defaultExportSections = []string{"objectives", "content", "activities", "assessment", "resources"}
+
+var chineseOrdinals = []string{"一", "二", "三", "四", "五", "六", "七", "八", "九", "十"}
+
+// parseExportSections 解析 sections 参数（逗号分隔，决定导出章节及顺序），为空时导出全部章节
+func parseExportSections(raw string) ([]string, error) {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return defaultExportSections, nil
+	}
+
+	valid := exportSectionTitles["standard"]
+	seen := make(map[string]bool)
+	sections := make([]string, 0, len(defaultExportSections))
+	for _, item := range strings.Split(raw, ",") {
+		item = strings.ToLower(strings.TrimSpace(item))
+		if item == "" || seen[item] {
+			continue
+		}
+		if _, ok := valid[item]; !ok {
+			return nil, fmt.Errorf("不支持的章节: %s，可选 %s", item, strings.Join(defaultExportSections, "、"))
+		}
+		seen[item] = true
+		sections = append(sections, item)
+	}
+	if len(sections) == 0 {
+		return nil, errors.New("至少需要导出一个章节")
+	}
+	return sections, nil
+}
+
+// generateMarkdown 生成模板化 Markdown 内容，sections 决定导出哪些章节及其顺序。
+func (h *LessonHandler) generateMarkdown(lesson *model.LessonDetail, layout string, sections []string) string {
+	fields := map[string]string{
+		"objectives": lessonFieldMarkdown(lesson.Objectives, lesson.ContentType),
+		"content":    lessonFieldMarkdown(lesson.Content, lesson.ContentType),
+		"activities": lessonFieldMarkdown(lesson.Activities, lesson.ContentType),
+		"assessment": lessonFieldMarkdown(lesson.Assessment, lesson.ContentType),
+		"resources":  lessonFieldMarkdown(lesson.Resources, lesson.ContentType),
+	}
+	titles, ok := exportSectionTitles[layout]
+	if !ok {
+		titles = exportSectionTitles["standard"]
+	}
 
 	var sb strings.Builder
 
@@ -750,27 +1747,6 @@ func (h *LessonHandler) generateMarkdown(lesson *model.LessonDetail, layout stri
 		sb.WriteString("| 学科 | 年级 | 课时 |\n| --- | --- | --- |\n")
 		sb.WriteString(fmt.Sprintf("| %s | %s | %d 分钟 |\n\n", lesson.Subject, lesson.Grade, lesson.Duration))
 
-		if objectives != "" {
-			sb.WriteString("## 目标速览\n\n")
-			sb.WriteString(objectives + "\n\n")
-		}
-		if content != "" {
-			sb.WriteString("## 内容主线\n\n")
-			sb.WriteString(content + "\n\n")
-		}
-		if activities != "" {
-			sb.WriteString("## 课堂执行步骤\n\n")
-			sb.WriteString(activities + "\n\n")
-		}
-		if assessment != "" {
-			sb.WriteString("## 达成检测\n\n")
-			sb.WriteString(assessment + "\n\n")
-		}
-		if resources != "" {
-			sb.WriteString("## 资源清单\n\n")
-			sb.WriteString(resources + "\n\n")
-		}
-
 	case "research":
 		sb.WriteString(fmt.Sprintf("# %s（教研版）\n\n", lesson.Title))
 		sb.WriteString(fmt.Sprintf("**学科：** %s  \n", lesson.Subject))
@@ -778,55 +1754,28 @@ func (h *LessonHandler) generateMarkdown(lesson *model.LessonDetail, layout stri
 		sb.WriteString(fmt.Sprintf("**课时：** %d 分钟  \n", lesson.Duration))
 		sb.WriteString(fmt.Sprintf("**版本：** v%d  \n\n", lesson.Version))
 
-		sb.WriteString("## 一、教学目标\n\n")
-		sb.WriteString(orFallback(objectives, "待补充目标说明。"))
-		sb.WriteString("\n\n")
-
-		sb.WriteString("## 二、教学内容与重难点\n\n")
-		sb.WriteString(orFallback(content, "待补充内容说明。"))
-		sb.WriteString("\n\n")
-
-		sb.WriteString("## 三、教学活动设计\n\n")
-		sb.WriteString(orFallback(activities, "待补充活动设计。"))
-		sb.WriteString("\n\n")
-
-		sb.WriteString("## 四、评价方案\n\n")
-		sb.WriteString(orFallback(assessment, "待补充评价方案。"))
-		sb.WriteString("\n\n")
-
-		sb.WriteString("## 五、资源与保障\n\n")
-		sb.WriteString(orFallback(resources, "待补充资源配置。"))
-		sb.WriteString("\n\n")
+		for i, key := range sections {
+			sb.WriteString(fmt.Sprintf("## %s、%s\n\n", chineseOrdinals[i], titles[key]))
+			sb.WriteString(orFallback(fields[key], researchSectionFallbacks[key]))
+			sb.WriteString("\n\n")
+		}
 
-		sb.WriteString("## 六、教学设计说明（自动生成）\n\n")
+		sb.WriteString(fmt.Sprintf("## %s、教学设计说明（自动生成）\n\n", chineseOrdinals[len(sections)]))
 		sb.WriteString("- 建议围绕“目标-活动-评价”闭环进行同伴评审。\n")
 		sb.WriteString("- 建议在课堂后补充执行反馈与改进建议。\n\n")
+		return sb.String()
 
 	default:
 		sb.WriteString(fmt.Sprintf("# %s\n\n", lesson.Title))
 		sb.WriteString(fmt.Sprintf("**学科：** %s  \n", lesson.Subject))
 		sb.WriteString(fmt.Sprintf("**年级：** %s  \n", lesson.Grade))
 		sb.WriteString(fmt.Sprintf("**课时：** %d分钟  \n\n", lesson.Duration))
+	}
 
-		if objectives != "" {
-			sb.WriteString("## 教学目标\n\n")
-			sb.WriteString(objectives + "\n\n")
-		}
-		if content != "" {
-			sb.WriteString("## 教学内容\n\n")
-			sb.WriteString(content + "\n\n")
-		}
-		if activities != "" {
-			sb.WriteString("## 教学活动\n\n")
-			sb.WriteString(activities + "\n\n")
-		}
-		if assessment != "" {
-			sb.WriteString("## 教学评价\n\n")
-			sb.WriteString(assessment + "\n\n")
-		}
-		if resources != "" {
-			sb.WriteString("## 教学资源\n\n")
-			sb.WriteString(resources + "\n\n")
+	for _, key := range sections {
+		if value := fields[key]; value != "" {
+			sb.WriteString(fmt.Sprintf("## %s\n\n", titles[key]))
+			sb.WriteString(value + "\n\n")
 		}
 	}
 
@@ -840,14 +1789,17 @@ func orFallback(value, fallback string) string {
 	return value
 }
 
-// convertWithPandoc 使用 pandoc 转换文件
-func (h *LessonHandler) convertWithPandoc(mdContent, title, format, layout string) (string, error) {
+// convertWithPandoc 使用 pandoc 转换文件，title 需已经过 sanitizeFilename 清洗；toc 为 true 时 PDF 生成目录
+func (h *LessonHandler) convertWithPandoc(mdContent, title, format, layout string, toc bool) (string, error) {
 	// 创建临时目录
 	tmpDir, err := os.MkdirTemp("", "lesson-export-")
 	if err != nil {
 		return "", fmt.Errorf("创建临时目录失败: %v", err)
 	}
 
+	// 本地图片复制到临时目录，保证 pandoc/weasyprint 能找到
+	mdContent = h.localizeImages(mdContent, tmpDir)
+
 	// 写入 Markdown 文件
 	mdFile := filepath.Join(tmpDir, "lesson.md")
 	if err := os.WriteFile(mdFile, []byte(mdContent), 0644); err != nil {
@@ -859,9 +1811,10 @@ func (h *LessonHandler) convertWithPandoc(mdContent, title, format, layout strin
 	var outputFile string
 	var args []string
 
-	// 基础参数：禁用 YAML 元数据解析
+	// 基础参数：禁用 YAML 元数据解析，支持 $...$ 与 \(...\) 两种 LaTeX 公式写法
 	baseArgs := []string{
-		"--from", "markdown-yaml_metadata_block",
+		"--from", "markdown-yaml_metadata_block+tex_math_dollars+tex_math_single_backslash",
+		"--resource-path", tmpDir,
 		mdFile,
 	}
 
@@ -869,14 +1822,20 @@ func (h *LessonHandler) convertWithPandoc(mdContent, title, format, layout strin
 	case "pdf":
 		outputFile = filepath.Join(tmpDir, title+".pdf")
 		// 使用 weasyprint 以支持中文
+		// weasyprint 不执行脚本，公式需预先转为 MathML；docx 由 pandoc 原生转为 Word 公式
 		args = append(baseArgs,
 			"-o", outputFile,
 			"--pdf-engine=weasyprint",
+			"--mathml",
 		)
 		cssFile := filepath.Join("templates", "export", layout+".css")
 		if _, err := os.Stat(cssFile); err == nil {
 			args = append(args, "--css", cssFile)
 		}
+		if toc {
+			// 目录收录到二级标题（教案各部分），一级标题为教案名
+			args = append(args, "--toc", "--toc-depth=2", "--metadata", "toc-title=目录")
+		}
 	case "docx":
 		outputFile = filepath.Join(tmpDir, title+".docx")
 		args = append(baseArgs,
@@ -898,6 +1857,71 @@ func (h *LessonHandler) convertWithPandoc(mdContent, title, format, layout strin
 	return outputFile, nil
 }
 
+var markdownImagePattern = regexp.MustCompile(`!\[([^\]]*)\]\(\s*<?([^)\s>]+)>?(\s+"[^"]*")?\s*\)`)
+
+// localizeImages 将 Markdown 中引用的本地图片复制到导出目录并改写为绝对路径。
+// 远程图片（http/https/data）交给 pandoc 自行抓取；找不到或越出上传目录的图片替换为提示文字。
+func (h *LessonHandler) localizeImages(mdContent, tmpDir string) string {
+	imageDir := filepath.Join(tmpDir, "images")
+	index := 0
+
+	return markdownImagePattern.ReplaceAllStringFunc(mdContent, func(match string) string {
+		parts := markdownImagePattern.FindStringSubmatch(match)
+		alt, src, title := parts[1], parts[2], parts[3]
+
+		lower := strings.ToLower(src)
+		if strings.HasPrefix(lower, "http://") || strings.HasPrefix(lower, "https://") || strings.HasPrefix(lower, "data:") {
+			return match
+		}
+
+		localPath, ok := h.resolveUploadPath(src)
+		if !ok {
+			return fmt.Sprintf("*（图片缺失：%s）*", orFallback(alt, src))
+		}
+
+		if err := os.MkdirAll(imageDir, 0755); err != nil {
+			return match
+		}
+		index++
+		target := filepath.Join(imageDir, fmt.Sprintf("%d%s", index, strings.ToLower(filepath.Ext(localPath))))
+		data, err := os.ReadFile(localPath)
+		if err != nil || os.WriteFile(target, data, 0644) != nil {
+			return fmt.Sprintf("*（图片缺失：%s）*", orFallback(alt, src))
+		}
+
+		return fmt.Sprintf("![%s](%s%s)", alt, filepath.ToSlash(target), title)
+	})
+}
+
+// resolveUploadPath 将 /uploads/xxx 或相对路径映射到上传目录内的真实文件
+func (h *LessonHandler) resolveUploadPath(src string) (string, bool) {
+	if h.uploadDir == "" {
+		return "", false
+	}
+	if decoded, err := url.PathUnescape(src); err == nil {
+		src = decoded
+	}
+
+	rel := strings.TrimPrefix(filepath.ToSlash(src), "/")
+	rel = strings.TrimPrefix(rel, "uploads/")
+
+	root, err := filepath.Abs(h.uploadDir)
+	if err != nil {
+		return "", false
+	}
+	candidate := filepath.Join(root, filepath.FromSlash(rel))
+	// 防止 ../ 越出上传目录
+	if candidate != root && !strings.HasPrefix(candidate, root+string(filepath.Separator)) {
+		return "", false
+	}
+
+	info, err := os.Stat(candidate)
+	if err != nil || info.IsDir() {
+		return "", false
+	}
+	return candidate, true
+}
+
 // formatJSONContent 将 JSON 对象格式化为可读文本
 func formatJSONContent(data map[string]interface{}) string {
 	var sb strings.Builder
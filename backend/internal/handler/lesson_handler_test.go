@@ -0,0 +1,527 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// lessonText 按教案字段的存储格式包装文本
+func lessonText(text string) string {
+	data, _ := json.Marshal(map[string]string{"text": text})
+	return string(data)
+}
+
+// formulaLesson 含行内与独立公式及本地图片的理科教案
+func formulaLesson() *model.LessonDetail {
+	return &model.LessonDetail{
+		Title:      "一元二次方程",
+		Subject:    "数学",
+		Grade:      "九年级",
+		Duration:   45,
+		Objectives: lessonText(`掌握求根公式 $x = \frac{-b \pm \sqrt{b^2-4ac}}{2a}$`),
+		Content:    lessonText("判别式 \\(\\Delta = b^2 - 4ac\\)\n\n$$ax^2+bx+c=0$$\n\n![抛物线](/uploads/images/parabola.png)"),
+	}
+}
+
+func TestGenerateMarkdownKeepsFormulas(t *testing.T) {
+	h := &LessonHandler{}
+	lesson := formulaLesson()
+
+	for _, layout := range []string{"standard", "compact", "research"} {
+		t.Run(layout, func(t *testing.T) {
+			md := h.generateMarkdown(lesson, layout, defaultExportSections)
+			for _, want := range []string{
+				`$x = \frac{-b \pm \sqrt{b^2-4ac}}{2a}$`,
+				`\(\Delta = b^2 - 4ac\)`,
+				`$$ax^2+bx+c=0$$`,
+				`![抛物线](/uploads/images/parabola.png)`,
+			} {
+				if !strings.Contains(md, want) {
+					t.Errorf("markdown missing %q:\n%s", want, md)
+				}
+			}
+		})
+	}
+}
+
+func TestLocalizeImages(t *testing.T) {
+	uploadDir := t.TempDir()
+	if err := os.MkdirAll(filepath.Join(uploadDir, "images"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(uploadDir, "images", "parabola.png"), []byte("png"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	secret := filepath.Join(filepath.Dir(uploadDir), "secret.png")
+	_ = os.WriteFile(secret, []byte("secret"), 0644)
+	t.Cleanup(func() { os.Remove(secret) })
+
+	tests := []struct {
+		name       string
+		md         string
+		wantCopied bool
+		want       string
+	}{
+		{name: "uploads url is bundled", md: "![抛物线](/uploads/images/parabola.png)", wantCopied: true},
+		{name: "relative path with title is bundled", md: `![抛物线](images/parabola.png "图1")`, wantCopied: true, want: `"图1")`},
+		{name: "remote image is left to pandoc", md: "![远程](https://example.com/a.png)", want: "![远程](https://example.com/a.png)"},
+		{name: "data uri is kept", md: "![内嵌](data:image/png;base64,AAAA)", want: "![内嵌](data:image/png;base64,AAAA)"},
+		{name: "missing image becomes a note", md: "![坐标系](/uploads/images/missing.png)", want: "*（图片缺失：坐标系）*"},
+		{name: "path traversal is rejected", md: "![](../secret.png)", want: "*（图片缺失：../secret.png）*"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tmpDir := t.TempDir()
+			h := &LessonHandler{uploadDir: uploadDir}
+			got := h.localizeImages(tt.md, tmpDir)
+
+			copied := filepath.ToSlash(filepath.Join(tmpDir, "images", "1.png"))
+			if strings.Contains(got, copied) != tt.wantCopied {
+				t.Errorf("localizeImages() = %q, want copied to %s: %v", got, copied, tt.wantCopied)
+			}
+			if tt.wantCopied {
+				if data, err := os.ReadFile(copied); err != nil || string(data) != "png" {
+					t.Errorf("bundled image = %q, %v", data, err)
+				}
+			}
+			if tt.want != "" && !strings.Contains(got, tt.want) {
+				t.Errorf("localizeImages() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportFormulaLessonWithPandoc(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not installed")
+	}
+	h := &LessonHandler{uploadDir: t.TempDir()}
+	md := h.generateMarkdown(formulaLesson(), "standard", defaultExportSections)
+
+	output, err := h.convertWithPandoc(md, "一元二次方程", "docx", "standard", false)
+	if err != nil {
+		t.Fatalf("convertWithPandoc() error = %v", err)
+	}
+	t.Cleanup(func() { os.RemoveAll(filepath.Dir(output)) })
+	if info, err := os.Stat(output); err != nil || info.Size() == 0 {
+		t.Fatalf("exported docx missing or empty: %v", err)
+	}
+}
+
+// contentTypeLessons 同一份教案分别以 Markdown 与富文本 HTML 保存
+func contentTypeLessons() (markdown, richText *model.LessonDetail) {
+	markdown = &model.LessonDetail{
+		Title:       "分数的意义",
+		Subject:     "数学",
+		ContentType: model.LessonContentTypeMarkdown,
+		Objectives:  lessonText("理解**分数**的意义"),
+		Content:     lessonText("## 导入\n\n复习整数\n\n- 平均分\n- 单位一"),
+	}
+	richText = &model.LessonDetail{
+		Title:       "分数的意义",
+		Subject:     "数学",
+		ContentType: model.LessonContentTypeHTML,
+		Objectives:  lessonText("<p>理解<strong>分数</strong>的意义</p>"),
+		Content:     lessonText("<h2>导入</h2><p>复习整数</p><ul><li>平均分</li><li>单位一</li></ul>"),
+	}
+	return markdown, richText
+}
+
+func TestExportMarkdownAndHTMLLessonsAlike(t *testing.T) {
+	if _, err := exec.LookPath("pandoc"); err != nil {
+		t.Skip("pandoc not installed")
+	}
+	h := &LessonHandler{}
+	markdown, richText := contentTypeLessons()
+
+	for _, layout := range []string{"standard", "compact", "research"} {
+		t.Run(layout, func(t *testing.T) {
+			// pandoc 的列表缩进与换行风格可能不同，按词比较
+			want := strings.Fields(h.generateMarkdown(markdown, layout, defaultExportSections))
+			got := strings.Fields(h.generateMarkdown(richText, layout, defaultExportSections))
+			if strings.Join(got, " ") != strings.Join(want, " ") {
+				t.Errorf("html export = %q\nwant %q", got, want)
+			}
+		})
+	}
+}
+
+func TestLessonFieldMarkdown(t *testing.T) {
+	// PATH 中没有 pandoc 时 HTML 退化为纯文本
+	t.Setenv("PATH", t.TempDir())
+
+	tests := []struct {
+		name        string
+		raw         string
+		contentType string
+		want        string
+	}{
+		{name: "markdown is used as is", raw: lessonText("## 导入\n**重点**"), contentType: model.LessonContentTypeMarkdown, want: "## 导入\n**重点**"},
+		{name: "unknown type treated as markdown", raw: lessonText("<b>原样</b>"), contentType: "", want: "<b>原样</b>"},
+		{name: "html without pandoc drops tags", raw: lessonText("<p>a &lt; b&nbsp;且 <em>b</em> &gt; 0</p>"), contentType: model.LessonContentTypeHTML, want: "a < b 且 b > 0"},
+		{name: "empty html", raw: lessonText(""), contentType: model.LessonContentTypeHTML, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lessonFieldMarkdown(tt.raw, tt.contentType); got != tt.want {
+				t.Errorf("lessonFieldMarkdown() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseExportSections(t *testing.T) {
+	tests := []struct {
+		name    string
+		raw     string
+		want    []string
+		wantErr bool
+	}{
+		{name: "empty exports all", raw: "", want: defaultExportSections},
+		{name: "custom order kept", raw: "activities,objectives", want: []string{"activities", "objectives"}},
+		{name: "spaces case and duplicates", raw: " Content , content,,RESOURCES ", want: []string{"content", "resources"}},
+		{name: "unknown section", raw: "objectives,homework", wantErr: true},
+		{name: "only separators", raw: " , ,", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseExportSections(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseExportSections(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseExportSections(%q) = %v, want %v", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGenerateMarkdownCustomSections(t *testing.T) {
+	h := &LessonHandler{}
+	lesson := &model.LessonDetail{
+		Title:       "光合作用",
+		Subject:     "生物",
+		Grade:       "七年级",
+		Duration:    45,
+		Version:     3,
+		ContentType: model.LessonContentTypeMarkdown,
+		Objectives:  lessonText("目标正文"),
+		Content:     lessonText("内容正文"),
+		Activities:  lessonText("活动正文"),
+		Assessment:  lessonText("评价正文"),
+		Resources:   lessonText("资源正文"),
+	}
+	sections := []string{"activities", "objectives", "content"}
+
+	tests := []struct {
+		layout       string
+		wantHeadings []string
+		wantOmitted  []string
+	}{
+		{
+			layout:       "standard",
+			wantHeadings: []string{"## 教学活动", "## 教学目标", "## 教学内容"},
+			wantOmitted:  []string{"教学评价", "教学资源"},
+		},
+		{
+			layout:       "compact",
+			wantHeadings: []string{"## 课堂执行步骤", "## 目标速览", "## 内容主线"},
+			wantOmitted:  []string{"达成检测", "资源清单"},
+		},
+		{
+			// 教研版按导出顺序重新编号，说明章节接在最后
+			layout:       "research",
+			wantHeadings: []string{"## 一、教学活动设计", "## 二、教学目标", "## 三、教学内容与重难点", "## 四、教学设计说明（自动生成）"},
+			wantOmitted:  []string{"评价方案", "资源与保障"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.layout, func(t *testing.T) {
+			md := h.generateMarkdown(lesson, tt.layout, sections)
+
+			last := -1
+			for _, heading := range tt.wantHeadings {
+				idx := strings.Index(md, heading+"\n")
+				if idx < 0 {
+					t.Fatalf("markdown missing heading %q:\n%s", heading, md)
+				}
+				if idx < last {
+					t.Errorf("heading %q out of order:\n%s", heading, md)
+				}
+				last = idx
+			}
+			for _, omitted := range append(tt.wantOmitted, "评价正文", "资源正文") {
+				if strings.Contains(md, omitted) {
+					t.Errorf("markdown contains omitted section %q:\n%s", omitted, md)
+				}
+			}
+			// 章节正文紧随对应标题
+			if !strings.Contains(md, tt.wantHeadings[0]+"\n\n活动正文") {
+				t.Errorf("activities body not under its heading:\n%s", md)
+			}
+		})
+	}
+}
+
+func TestGenerateMarkdownSkipsEmptySelectedSection(t *testing.T) {
+	h := &LessonHandler{}
+	lesson := &model.LessonDetail{Title: "空章节", Objectives: lessonText("目标正文")}
+	sections := []string{"resources", "objectives"}
+
+	standard := h.generateMarkdown(lesson, "standard", sections)
+	if strings.Contains(standard, "## 教学资源") || !strings.Contains(standard, "## 教学目标") {
+		t.Errorf("standard export = %s, want empty section skipped", standard)
+	}
+
+	// 教研版保留选中的空章节并填入占位文字
+	research := h.generateMarkdown(lesson, "research", sections)
+	want := fmt.Sprintf("## 一、资源与保障\n\n%s", researchSectionFallbacks["resources"])
+	if !strings.Contains(research, want) {
+		t.Errorf("research export = %s, want %q", research, want)
+	}
+}
+
+func TestQueryTags(t *testing.T) {
+	tests := []struct {
+		query string
+		want  []string
+	}{
+		{query: "", want: nil},
+		{query: "tag=函数", want: []string{"函数"}},
+		{query: "tag=%20函数%20&tag=图像&tag=函数&tag=", want: []string{"函数", "图像"}},
+	}
+
+	for _, tt := range tests {
+		c, _ := gin.CreateTestContext(httptest.NewRecorder())
+		c.Request = httptest.NewRequest(http.MethodGet, "/lessons?"+tt.query, nil)
+		if got := queryTags(c); !reflect.DeepEqual(got, tt.want) {
+			t.Errorf("queryTags(%q) = %v, want %v", tt.query, got, tt.want)
+		}
+	}
+}
+
+// filterRecordingLessonService 记录 List 收到的筛选条件
+type filterRecordingLessonService struct {
+	service.LessonService
+	filter *repository.LessonFilter
+}
+
+func (s *filterRecordingLessonService) List(ctx context.Context, filter repository.LessonFilter, page, pageSize int) ([]model.LessonListItem, int64, error) {
+	s.filter = &filter
+	return []model.LessonListItem{}, 0, nil
+}
+
+func TestListLessonsSort(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		query     string
+		wantCode  int
+		wantSort  string
+		wantOrder string
+	}{
+		{name: "default", query: "", wantCode: http.StatusOK},
+		{name: "like count descending", query: "sort=like_count&order=desc", wantCode: http.StatusOK, wantSort: "like_count", wantOrder: "desc"},
+		{name: "order is case insensitive", query: "sort=view_count&order=ASC", wantCode: http.StatusOK, wantSort: "view_count", wantOrder: "asc"},
+		{name: "unknown sort field", query: "sort=title", wantCode: http.StatusBadRequest},
+		{name: "sort field is not sql", query: "sort=like_count%20DESC", wantCode: http.StatusBadRequest},
+		{name: "unknown order", query: "sort=like_count&order=up", wantCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &filterRecordingLessonService{}
+			r := gin.New()
+			r.GET("/lessons", (&LessonHandler{lessonService: svc}).List)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/lessons?"+tt.query, nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusOK {
+				if svc.filter != nil {
+					t.Error("List() called for an invalid sort")
+				}
+				return
+			}
+			if svc.filter.SortBy != tt.wantSort || svc.filter.SortOrder != tt.wantOrder {
+				t.Errorf("filter sort = (%q, %q), want (%q, %q)", svc.filter.SortBy, svc.filter.SortOrder, tt.wantSort, tt.wantOrder)
+			}
+		})
+	}
+}
+
+// restoreCommentService 按预置错误返回，并记录调用者角色
+type restoreCommentService struct {
+	service.CommentService
+	err  error
+	role string
+}
+
+func (s *restoreCommentService) Restore(ctx context.Context, id, userID uuid.UUID, role string) (*model.Comment, error) {
+	s.role = role
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Comment{ID: id}, nil
+}
+
+func TestRestoreComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name      string
+		commentID string
+		err       error
+		wantCode  int
+	}{
+		{name: "restored", commentID: uuid.NewString(), wantCode: http.StatusOK},
+		{name: "invalid id", commentID: "abc", wantCode: http.StatusBadRequest},
+		{name: "not deleted or missing", commentID: uuid.NewString(), err: service.ErrCommentNotFound, wantCode: http.StatusNotFound},
+		{name: "not author or admin", commentID: uuid.NewString(), err: service.ErrUnauthorized, wantCode: http.StatusForbidden},
+		{name: "storage failure", commentID: uuid.NewString(), err: errors.New("db down"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &restoreCommentService{err: tt.err}
+			r := gin.New()
+			r.POST("/lessons/:id/comments/:commentId/restore", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString(), Role: model.RoleAdmin})
+				c.Next()
+			}, (&LessonHandler{commentService: svc}).RestoreComment)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lessons/"+uuid.NewString()+"/comments/"+tt.commentID+"/restore", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusBadRequest && svc.role != model.RoleAdmin {
+				t.Errorf("Restore() role = %q, want %q from the token", svc.role, model.RoleAdmin)
+			}
+		})
+	}
+}
+
+// likeCommentService 按预置错误返回，并记录收到的教案 ID
+type likeCommentService struct {
+	service.CommentService
+	err      error
+	lessonID uuid.UUID
+}
+
+func (s *likeCommentService) Like(ctx context.Context, lessonID, id, userID uuid.UUID) (*model.Comment, error) {
+	s.lessonID = lessonID
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &model.Comment{ID: id, LessonID: lessonID, LikeCount: 1}, nil
+}
+
+func TestLikeComment(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lessonID := uuid.NewString()
+
+	tests := []struct {
+		name     string
+		lessonID string
+		err      error
+		wantCode int
+	}{
+		{name: "liked", lessonID: lessonID, wantCode: http.StatusOK},
+		{name: "invalid lesson id", lessonID: "abc", wantCode: http.StatusBadRequest},
+		{name: "comment of another lesson", lessonID: lessonID, err: service.ErrCommentNotFound, wantCode: http.StatusNotFound},
+		{name: "lesson missing", lessonID: lessonID, err: service.ErrLessonNotFound, wantCode: http.StatusNotFound},
+		{name: "lesson not visible", lessonID: lessonID, err: service.ErrUnauthorized, wantCode: http.StatusForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &likeCommentService{err: tt.err}
+			r := gin.New()
+			r.POST("/lessons/:id/comments/:commentId/like", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, (&LessonHandler{commentService: svc}).LikeComment)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lessons/"+tt.lessonID+"/comments/"+uuid.NewString()+"/like", nil))
+			if w.Code != tt.wantCode {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+			if tt.wantCode != http.StatusBadRequest && svc.lessonID.String() != tt.lessonID {
+				t.Errorf("Like() lesson = %s, want %s from the path", svc.lessonID, tt.lessonID)
+			}
+		})
+	}
+}
+
+// glossaryLessonService 按预置错误返回术语表
+type glossaryLessonService struct {
+	service.LessonService
+	err error
+}
+
+func (s *glossaryLessonService) Glossary(ctx context.Context, lessonID, userID uuid.UUID) (*service.LessonGlossary, error) {
+	if s.err != nil {
+		return nil, s.err
+	}
+	return &service.LessonGlossary{LessonID: lessonID, Terms: []service.GlossaryTerm{{Term: "数轴", Definition: "直线"}}}, nil
+}
+
+func TestGlossaryStatus(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name     string
+		err      error
+		wantCode int
+	}{
+		{name: "ok", wantCode: http.StatusOK},
+		{name: "missing lesson", err: service.ErrLessonNotFound, wantCode: http.StatusNotFound},
+		{name: "others draft", err: service.ErrUnauthorized, wantCode: http.StatusForbidden},
+		{name: "agent unavailable", err: service.ErrGlossaryUnavailable, wantCode: http.StatusServiceUnavailable},
+		{name: "unexpected", err: errors.New("boom"), wantCode: http.StatusInternalServerError},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := gin.New()
+			r.POST("/lessons/:id/glossary", func(c *gin.Context) {
+				c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+				c.Next()
+			}, (&LessonHandler{lessonService: &glossaryLessonService{err: tt.err}}).Glossary)
+
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/lessons/"+uuid.NewString()+"/glossary", nil))
+			if w.Code != tt.wantCode {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantCode, w.Body.String())
+			}
+		})
+	}
+}
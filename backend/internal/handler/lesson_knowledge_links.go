@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode/utf8"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+)
+
+const (
+	// knowledgeLinkGraphLimit 匹配知识点时最多读取的图谱节点数
+	knowledgeLinkGraphLimit = 500
+	// minKnowledgeLinkNameLength 知识点名称最少字符数，过短的名称容易误伤普通词语
+	minKnowledgeLinkNameLength = 2
+	// defaultKnowledgeLinkURL 未配置前端地址时使用的知识图谱页相对地址
+	defaultKnowledgeLinkURL = "/knowledge"
+)
+
+// knowledgeLinkProtectedPattern 行内不能注入链接的片段：行内代码、图片、已有链接与裸 URL
+var knowledgeLinkProtectedPattern = regexp.MustCompile("`[^`]*`|!?\\[[^\\]]*\\]\\([^)]*\\)|<[^>]+>|https?://\\S+")
+
+// knowledgeLinkNames 读取用户在教案学科下的知识图谱节点名称；读取失败时不注入链接，不影响导出
+func (h *LessonHandler) knowledgeLinkNames(ctx context.Context, lesson *model.LessonDetail, userID string) []string {
+	if h.knowledgeService == nil || userID == "" {
+		return nil
+	}
+	graph, err := h.knowledgeService.GetGraph(ctx, lesson.Subject, "", "", "", userID, knowledgeLinkGraphLimit, nil, nil)
+	if err != nil {
+		logger.Warn("Failed to load knowledge points for export links",
+			logger.String("lesson_id", lesson.ID.String()),
+			logger.Err(err),
+		)
+		return nil
+	}
+	names := make([]string, 0, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		names = append(names, node.Label)
+	}
+	return names
+}
+
+// knowledgeLinkFor 生成跳转到知识图谱页并定位该知识点的链接
+func (h *LessonHandler) knowledgeLinkFor(name string) string {
+	base := strings.TrimRight(h.knowledgeLinkURL, "/")
+	if base == "" {
+		base = defaultKnowledgeLinkURL
+	}
+	return base + "?topic=" + url.QueryEscape(name)
+}
+
+// linkKnowledgePoints 扫描 Markdown，把首次出现的知识点名称替换为锚链接。
+// 较长的名称优先匹配；标题、代码块、行内代码与已有链接中的文字保持不变。
+func linkKnowledgePoints(md string, names []string, linkFor func(name string) string) string {
+	pattern := knowledgeNamePattern(names)
+	if pattern == nil {
+		return md
+	}
+
+	linked := make(map[string]bool)
+	lines := strings.Split(md, "\n")
+	inFence := false
+	for i, line := range lines {
+		trimmed := strings.TrimSpace(line)
+		if strings.HasPrefix(trimmed, "```") || strings.HasPrefix(trimmed, "~~~") {
+			inFence = !inFence
+			continue
+		}
+		if inFence || strings.HasPrefix(trimmed, "#") {
+			continue
+		}
+		lines[i] = linkKnowledgeLine(line, pattern, linked, linkFor)
+	}
+	return strings.Join(lines, "\n")
+}
+
+// linkKnowledgeLine 只在受保护片段之外的文字中注入链接
+func linkKnowledgeLine(line string, pattern *regexp.Regexp, linked map[string]bool, linkFor func(name string) string) string {
+	var sb strings.Builder
+	last := 0
+	for _, loc := range knowledgeLinkProtectedPattern.FindAllStringIndex(line, -1) {
+		sb.WriteString(replaceKnowledgeNames(line[last:loc[0]], pattern, linked, linkFor))
+		sb.WriteString(line[loc[0]:loc[1]])
+		last = loc[1]
+	}
+	sb.WriteString(replaceKnowledgeNames(line[last:], pattern, linked, linkFor))
+	return sb.String()
+}
+
+func replaceKnowledgeNames(text string, pattern *regexp.Regexp, linked map[string]bool, linkFor func(name string) string) string {
+	return pattern.ReplaceAllStringFunc(text, func(name string) string {
+		if linked[name] {
+			return name
+		}
+		linked[name] = true
+		return "[" + name + "](" + linkFor(name) + ")"
+	})
+}
+
+// knowledgeNamePattern 把知识点名称编译为按长度降序排列的备选正则，没有可用名称时返回 nil
+func knowledgeNamePattern(names []string) *regexp.Regexp {
+	seen := make(map[string]bool)
+	candidates := make([]string, 0, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if utf8.RuneCountInString(name) < minKnowledgeLinkNameLength || strings.ContainsAny(name, "[]()\n") || seen[name] {
+			continue
+		}
+		seen[name] = true
+		candidates = append(candidates, name)
+	}
+	if len(candidates) == 0 {
+		return nil
+	}
+
+	sort.Slice(candidates, func(i, j int) bool {
+		li, lj := utf8.RuneCountInString(candidates[i]), utf8.RuneCountInString(candidates[j])
+		if li != lj {
+			return li > lj
+		}
+		return candidates[i] < candidates[j]
+	})
+	for i, name := range candidates {
+		candidates[i] = regexp.QuoteMeta(name)
+	}
+	return regexp.MustCompile(strings.Join(candidates, "|"))
+}
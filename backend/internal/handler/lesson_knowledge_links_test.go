@@ -0,0 +1,154 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+)
+
+// graphKnowledgeService 返回固定知识图谱，并记录查询的学科与用户
+type graphKnowledgeService struct {
+	service.KnowledgeService
+	labels  []string
+	err     error
+	subject string
+	userID  string
+}
+
+func (s *graphKnowledgeService) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error) {
+	s.subject, s.userID = subject, userId
+	if s.err != nil {
+		return nil, s.err
+	}
+	graph := &model.KnowledgeGraph{}
+	for _, label := range s.labels {
+		graph.Nodes = append(graph.Nodes, model.KnowledgeNode{Label: label})
+	}
+	return graph, nil
+}
+
+func TestLinkKnowledgePoints(t *testing.T) {
+	linkFor := func(name string) string { return "/k?" + name }
+
+	tests := []struct {
+		name  string
+		md    string
+		names []string
+		want  string
+	}{
+		{
+			name:  "first occurrence only",
+			md:    "有理数的加法基于有理数。",
+			names: []string{"有理数"},
+			want:  "[有理数](/k?有理数)的加法基于有理数。",
+		},
+		{
+			name:  "longer name wins",
+			md:    "学习有理数的加法",
+			names: []string{"有理数", "有理数的加法"},
+			want:  "学习[有理数的加法](/k?有理数的加法)",
+		},
+		{
+			name:  "headings and fenced code untouched",
+			md:    "## 有理数\n```\n有理数\n```\n复习有理数",
+			names: []string{"有理数"},
+			want:  "## 有理数\n```\n有理数\n```\n复习[有理数](/k?有理数)",
+		},
+		{
+			name:  "inline code images and links untouched",
+			md:    "`有理数` ![有理数](a.png) [有理数](b) 有理数",
+			names: []string{"有理数"},
+			want:  "`有理数` ![有理数](a.png) [有理数](b) [有理数](/k?有理数)",
+		},
+		{
+			name:  "short and unsafe names skipped",
+			md:    "数与[数轴]",
+			names: []string{"数", "[数轴]", " "},
+			want:  "数与[数轴]",
+		},
+		{
+			name: "no names",
+			md:   "有理数",
+			want: "有理数",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := linkKnowledgePoints(tt.md, tt.names, linkFor); got != tt.want {
+				t.Errorf("linkKnowledgePoints() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnowledgeLinkFor(t *testing.T) {
+	tests := []struct {
+		base string
+		want string
+	}{
+		{base: "", want: "/knowledge?topic=%E6%95%B0%E8%BD%B4+%E5%8E%9F%E7%82%B9"},
+		{base: "https://app.example.com/knowledge/", want: "https://app.example.com/knowledge?topic=%E6%95%B0%E8%BD%B4+%E5%8E%9F%E7%82%B9"},
+	}
+
+	for _, tt := range tests {
+		h := &LessonHandler{knowledgeLinkURL: tt.base}
+		if got := h.knowledgeLinkFor("数轴 原点"); got != tt.want {
+			t.Errorf("knowledgeLinkFor() with base %q = %q, want %q", tt.base, got, tt.want)
+		}
+	}
+}
+
+func TestKnowledgeLinkNames(t *testing.T) {
+	ctx := context.Background()
+	lesson := &model.LessonDetail{Subject: "数学"}
+
+	svc := &graphKnowledgeService{labels: []string{"有理数", "数轴"}}
+	h := &LessonHandler{knowledgeService: svc}
+	if got := h.knowledgeLinkNames(ctx, lesson, "u-1"); !reflect.DeepEqual(got, []string{"有理数", "数轴"}) {
+		t.Errorf("knowledgeLinkNames() = %v, want the graph labels", got)
+	}
+	if svc.subject != "数学" || svc.userID != "u-1" {
+		t.Errorf("GetGraph() called with (%q, %q), want the lesson subject and caller", svc.subject, svc.userID)
+	}
+
+	// 匿名导出、未配置图谱或读取失败时不注入链接
+	if got := h.knowledgeLinkNames(ctx, lesson, ""); got != nil {
+		t.Errorf("knowledgeLinkNames() anonymous = %v, want nil", got)
+	}
+	if got := (&LessonHandler{}).knowledgeLinkNames(ctx, lesson, "u-1"); got != nil {
+		t.Errorf("knowledgeLinkNames() without service = %v, want nil", got)
+	}
+	failing := &LessonHandler{knowledgeService: &graphKnowledgeService{err: errors.New("neo4j down")}}
+	if got := failing.knowledgeLinkNames(ctx, lesson, "u-1"); got != nil {
+		t.Errorf("knowledgeLinkNames() on error = %v, want nil", got)
+	}
+}
+
+func TestWriteExportMarkdownWithKnowledgeLinks(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	lesson := &model.LessonDetail{Title: "有理数", Subject: "数学", Content: lessonText("认识数轴与数轴上的点")}
+
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/export", nil)
+	(&LessonHandler{}).writeExport(c, lesson, "md", "standard", defaultExportSections, false, []string{"数轴"})
+
+	body := w.Body.String()
+	if w.Code != http.StatusOK || !strings.Contains(body, "认识[数轴](/knowledge?topic=%E6%95%B0%E8%BD%B4)与数轴上的点") {
+		t.Errorf("export = %d:\n%s\nwant the first 数轴 linked", w.Code, body)
+	}
+	if !strings.HasPrefix(body, "# 有理数") {
+		t.Errorf("export title changed:\n%s", body)
+	}
+}
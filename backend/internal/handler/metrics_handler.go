@@ -1,12 +1,24 @@
 package handler
 
 import (
+	"net/http"
+
 	"lesson-plan/backend/internal/observability"
+	"lesson-plan/backend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
 
-// Metrics 返回服务运行指标快照。
+// Metrics 以 Prometheus 文本格式输出服务运行指标，供抓取。
 func Metrics(c *gin.Context) {
+	c.Status(http.StatusOK)
+	c.Header("Content-Type", observability.PrometheusContentType)
+	if err := observability.WritePrometheus(c.Writer); err != nil {
+		logger.Warn("Write prometheus metrics failed", logger.Err(err))
+	}
+}
+
+// MetricsSnapshot 返回服务运行指标快照（JSON）。
+func MetricsSnapshot(c *gin.Context) {
 	Success(c, observability.SnapshotMetrics())
 }
@@ -0,0 +1,101 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/observability"
+
+	"github.com/gin-gonic/gin"
+)
+
+// sampleLine 指标样本行：名称、可选标签与数值
+var sampleLine = regexp.MustCompile(`^([a-zA-Z_:][a-zA-Z0-9_:]*)(\{.*\})? (\S+)$`)
+
+// scrapeMetrics 抓取 /metrics 并按行解析，返回 “名称+标签” 到数值的映射；
+// 同时校验每个样本所属的指标族已先声明 HELP 与 TYPE
+func scrapeMetrics(t *testing.T) map[string]float64 {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.GET("/metrics", Metrics)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("GET /metrics status = %d, want 200", w.Code)
+	}
+	if ct := w.Header().Get("Content-Type"); ct != observability.PrometheusContentType {
+		t.Errorf("Content-Type = %q, want %q", ct, observability.PrometheusContentType)
+	}
+
+	declared := make(map[string]string)
+	samples := make(map[string]float64)
+	scanner := bufio.NewScanner(w.Body)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "# TYPE ") {
+			fields := strings.Fields(line)
+			declared[fields[2]] = fields[3]
+			continue
+		}
+		if strings.HasPrefix(line, "# HELP ") || line == "" {
+			continue
+		}
+
+		m := sampleLine.FindStringSubmatch(line)
+		if m == nil {
+			t.Fatalf("malformed exposition line %q", line)
+		}
+		family := strings.TrimSuffix(strings.TrimSuffix(m[1], "_sum"), "_count")
+		if declared[m[1]] == "" && declared[family] != "summary" {
+			t.Errorf("sample %q has no preceding TYPE declaration", line)
+		}
+		value, err := strconv.ParseFloat(m[3], 64)
+		if err != nil {
+			t.Fatalf("sample %q value: %v", line, err)
+		}
+		samples[m[1]+m[2]] = value
+	}
+	return samples
+}
+
+func TestMetricsExposition(t *testing.T) {
+	route := "/api/v1/metrics-test/" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	observability.RecordHTTPRequest(http.MethodGet, route, http.StatusOK, 10*time.Millisecond)
+	observability.RecordHTTPRequest(http.MethodGet, route, http.StatusNotFound, 30*time.Millisecond)
+	observability.RecordDownstream("agent-test", `quote"op`, 0, 5*time.Millisecond)
+
+	samples := scrapeMetrics(t)
+
+	labels := `{method="GET",route="` + route + `"}`
+	want := map[string]float64{
+		"http_requests_total" + labels:                                                     2,
+		"http_request_errors_total" + labels:                                               1,
+		"http_request_duration_ms_sum" + labels:                                            40,
+		"http_request_duration_ms_count" + labels:                                          2,
+		`http_request_duration_ms{method="GET",route="` + route + `",quantile="0.95"}`:     30,
+		`downstream_requests_total{service="agent-test",operation="quote\"op"}`:            1,
+		`downstream_request_errors_total{service="agent-test",operation="quote\"op"}`:      1,
+		`downstream_request_duration_ms_count{service="agent-test",operation="quote\"op"}`: 1,
+	}
+	for key, wantValue := range want {
+		got, ok := samples[key]
+		if !ok {
+			t.Errorf("missing sample %s", key)
+			continue
+		}
+		if got != wantValue {
+			t.Errorf("%s = %v, want %v", key, got, wantValue)
+		}
+	}
+	if samples["process_uptime_seconds"] <= 0 {
+		t.Errorf("process_uptime_seconds = %v, want positive", samples["process_uptime_seconds"])
+	}
+}
@@ -0,0 +1,84 @@
+package handler
+
+import (
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// BindQuery 按结构体 tag 解析 query 参数并校验，失败时已写入 400 响应并返回 false。
+// form 指定参数名与缺省值（如 `form:"limit,default=50"`），binding 指定范围等校验（如 `binding:"min=1,max=500"`）；
+// 缺省值只在参数缺失时生效，参数存在但为空时按零值参与校验
+func BindQuery(c *gin.Context, dest interface{}) bool {
+	if err := c.ShouldBindQuery(dest); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return false
+	}
+	return true
+}
+
+// graphQuery 知识图谱查询参数
+type graphQuery struct {
+	Subject string `form:"subject"`
+	Grade   string `form:"grade"`
+	Topic   string `form:"topic"`
+	Scope   string `form:"scope"`
+	Limit   int    `form:"limit,default=50" binding:"min=1,max=500"`
+}
+
+// graphClusterQuery 知识图谱聚类查询参数
+type graphClusterQuery struct {
+	graphQuery
+	Algorithm string `form:"algorithm"`
+}
+
+// similarKnowledgeQuery 相似知识点查询参数
+type similarKnowledgeQuery struct {
+	Limit int `form:"limit,default=10" binding:"min=1,max=50"`
+}
+
+// documentListQuery 知识文档列表筛选参数，分页参数由 GetPagination 解析
+type documentListQuery struct {
+	Status  string `form:"status" binding:"omitempty,oneof=pending processing completed failed"`
+	Subject string `form:"subject"`
+	Grade   string `form:"grade"`
+}
+
+// knowledgePathQuery 知识点最短路径查询参数，from/to 为节点 id 或名称
+type knowledgePathQuery struct {
+	From     string `form:"from" binding:"required"`
+	To       string `form:"to" binding:"required"`
+	MaxDepth int    `form:"max_depth,default=6" binding:"min=1,max=10"`
+}
+
+// generationQuery 生成接口的 query 参数，请求体未指定方案数量时使用；
+// 用指针区分未传与 variants=0，显式传入的值必须在 1~3 之间
+type generationQuery struct {
+	Variants *int `form:"variants" binding:"omitempty,min=1,max=3"`
+}
+
+// translateQuery 教案翻译参数
+type translateQuery struct {
+	Target    string `form:"target,default=en"`
+	SaveAsNew bool   `form:"save_as_new"`
+}
+
+// studentVersionQuery 学案生成参数
+type studentVersionQuery struct {
+	Mode      string `form:"mode,default=auto"`
+	SaveAsNew bool   `form:"save_as_new"`
+}
+
+// beautifyQuery 教案排版参数，preview=true 时只返回排版结果不保存
+type beautifyQuery struct {
+	Preview bool `form:"preview"`
+}
+
+// exportQuery 教案导出参数，分享下载不支持 knowledge_links
+type exportQuery struct {
+	Format         string `form:"format,default=md"`
+	Layout         string `form:"layout,default=standard"`
+	Sections       string `form:"sections"`
+	TOC            bool   `form:"toc"`
+	KnowledgeLinks bool   `form:"knowledge_links"`
+}
@@ -0,0 +1,93 @@
+package handler
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gin-gonic/gin"
+)
+
+// bindTestQuery 用给定 query 串执行 BindQuery，返回绑定结果与响应
+func bindTestQuery(t *testing.T, rawQuery string, dest interface{}) (bool, *httptest.ResponseRecorder) {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	w := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(w)
+	c.Request = httptest.NewRequest(http.MethodGet, "/?"+rawQuery, nil)
+	return BindQuery(c, dest), w
+}
+
+func TestBindQueryDefaults(t *testing.T) {
+	var graph graphQuery
+	if ok, w := bindTestQuery(t, "subject=数学", &graph); !ok {
+		t.Fatalf("BindQuery() failed: %s", w.Body.String())
+	}
+	if graph.Limit != 50 || graph.Subject != "数学" {
+		t.Errorf("graphQuery = %+v, want default limit 50", graph)
+	}
+
+	var export exportQuery
+	if ok, w := bindTestQuery(t, "", &export); !ok {
+		t.Fatalf("BindQuery() failed: %s", w.Body.String())
+	}
+	if export.Format != "md" || export.Layout != "standard" || export.TOC || export.KnowledgeLinks {
+		t.Errorf("exportQuery = %+v, want md/standard without flags", export)
+	}
+
+	// 显式给出的值覆盖缺省值
+	export = exportQuery{}
+	if ok, w := bindTestQuery(t, "format=docx&toc=true&knowledge_links=1", &export); !ok {
+		t.Fatalf("BindQuery() failed: %s", w.Body.String())
+	}
+	if export.Format != "docx" || export.Layout != "standard" || !export.TOC || !export.KnowledgeLinks {
+		t.Errorf("exportQuery = %+v, want docx with toc and knowledge links", export)
+	}
+
+	// 嵌入结构体同样应用缺省值
+	var cluster graphClusterQuery
+	if ok, w := bindTestQuery(t, "algorithm=louvain", &cluster); !ok {
+		t.Fatalf("BindQuery() failed: %s", w.Body.String())
+	}
+	if cluster.Limit != 50 || cluster.Algorithm != "louvain" {
+		t.Errorf("graphClusterQuery = %+v, want default limit 50", cluster)
+	}
+}
+
+func TestBindQueryValidation(t *testing.T) {
+	tests := []struct {
+		name     string
+		rawQuery string
+		dest     func() interface{}
+		wantOK   bool
+	}{
+		{name: "limit lower bound", rawQuery: "limit=1", dest: func() interface{} { return &graphQuery{} }, wantOK: true},
+		{name: "limit upper bound", rawQuery: "limit=500", dest: func() interface{} { return &graphQuery{} }, wantOK: true},
+		{name: "limit below range", rawQuery: "limit=0", dest: func() interface{} { return &graphQuery{} }},
+		{name: "limit above range", rawQuery: "limit=501", dest: func() interface{} { return &graphQuery{} }},
+		{name: "limit not a number", rawQuery: "limit=abc", dest: func() interface{} { return &graphQuery{} }},
+		{name: "empty limit is zero", rawQuery: "limit=", dest: func() interface{} { return &graphQuery{} }},
+		{name: "similar limit above range", rawQuery: "limit=51", dest: func() interface{} { return &similarKnowledgeQuery{} }},
+		{name: "invalid bool flag", rawQuery: "preview=maybe", dest: func() interface{} { return &beautifyQuery{} }},
+		{name: "status in enum", rawQuery: "status=failed", dest: func() interface{} { return &documentListQuery{} }, wantOK: true},
+		{name: "status outside enum", rawQuery: "status=archived", dest: func() interface{} { return &documentListQuery{} }},
+		{name: "path endpoints required", rawQuery: "from=有理数", dest: func() interface{} { return &knowledgePathQuery{} }},
+		{name: "variants omitted", rawQuery: "", dest: func() interface{} { return &generationQuery{} }, wantOK: true},
+		{name: "variants in range", rawQuery: "variants=3", dest: func() interface{} { return &generationQuery{} }, wantOK: true},
+		{name: "variants above range", rawQuery: "variants=4", dest: func() interface{} { return &generationQuery{} }},
+		{name: "variants zero", rawQuery: "variants=0", dest: func() interface{} { return &generationQuery{} }},
+		{name: "variants not a number", rawQuery: "variants=abc", dest: func() interface{} { return &generationQuery{} }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ok, w := bindTestQuery(t, tt.rawQuery, tt.dest())
+			if ok != tt.wantOK {
+				t.Fatalf("BindQuery(%q) = %v, want %v: %s", tt.rawQuery, ok, tt.wantOK, w.Body.String())
+			}
+			if !ok && w.Code != http.StatusBadRequest {
+				t.Errorf("status = %d, want 400", w.Code)
+			}
+		})
+	}
+}
@@ -170,6 +170,12 @@ func defaultErrorCode(statusCode int) string {
 		return "NOT_FOUND"
 	case http.StatusConflict:
 		return "CONFLICT"
+	case http.StatusRequestEntityTooLarge:
+		return "PAYLOAD_TOO_LARGE"
+	case http.StatusUnsupportedMediaType:
+		return "UNSUPPORTED_MEDIA_TYPE"
+	case http.StatusUnprocessableEntity:
+		return "UNPROCESSABLE_ENTITY"
 	case http.StatusTooManyRequests:
 		return "RATE_LIMITED"
 	case http.StatusGatewayTimeout:
@@ -3,7 +3,10 @@ package handler
 import (
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
 	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,8 +19,18 @@ type Router struct {
 	templateHandler   *TemplateHandler
 	generationHandler *GenerationHandler
 	knowledgeHandler  *KnowledgeHandler
+	healthHandler     *HealthHandler
+	annotationHandler *AnnotationHandler
+	shareHandler      *ShareHandler
 	config            *config.Config
 	jwtManager        *jwt.Manager
+
+	apiKeyHandler *APIKeyHandler
+	// apiKeyAuth 认证中间件校验 X-API-Key 所用的服务
+	apiKeyAuth middleware.APIKeyAuthenticator
+
+	// tokenDenylist 已登出令牌黑名单，认证中间件据此拒绝已登出的令牌
+	tokenDenylist middleware.TokenDenylist
 }
 
 // NewRouter 创建路由管理器
@@ -28,6 +41,12 @@ func NewRouter(
 	templateHandler *TemplateHandler,
 	generationHandler *GenerationHandler,
 	knowledgeHandler *KnowledgeHandler,
+	healthHandler *HealthHandler,
+	annotationHandler *AnnotationHandler,
+	shareHandler *ShareHandler,
+	apiKeyHandler *APIKeyHandler,
+	apiKeyAuth middleware.APIKeyAuthenticator,
+	tokenDenylist middleware.TokenDenylist,
 	appConfig *config.Config,
 	jwtManager *jwt.Manager,
 ) *Router {
@@ -38,9 +57,54 @@ func NewRouter(
 		templateHandler:   templateHandler,
 		generationHandler: generationHandler,
 		knowledgeHandler:  knowledgeHandler,
+		healthHandler:     healthHandler,
+		annotationHandler: annotationHandler,
+		shareHandler:      shareHandler,
 		config:            appConfig,
 		jwtManager:        jwtManager,
+		apiKeyHandler:     apiKeyHandler,
+		apiKeyAuth:        apiKeyAuth,
+		tokenDenylist:     tokenDenylist,
+	}
+}
+
+// auth 必须认证：接受登录令牌或 X-API-Key
+func (r *Router) auth() gin.HandlerFunc {
+	return middleware.AuthMiddleware(r.jwtManager, r.apiKeyAuth, r.tokenDenylist)
+}
+
+// optionalAuth 可选认证
+func (r *Router) optionalAuth() gin.HandlerFunc {
+	return middleware.OptionalAuthMiddleware(r.jwtManager, r.apiKeyAuth, r.tokenDenylist)
+}
+
+// ipAccessLists 解析限流黑白名单；配置校验已拦截无效条目，这里解析失败时忽略对应名单
+func (r *Router) ipAccessLists(cfg config.RateLimitConfig) (*middleware.IPList, *middleware.IPList) {
+	whitelist, err := middleware.ParseIPList(cfg.Whitelist)
+	if err != nil {
+		logger.Warn("Invalid rate limit whitelist ignored", logger.Err(err))
+		whitelist = nil
+	}
+	blacklist, err := middleware.ParseIPList(cfg.Blacklist)
+	if err != nil {
+		logger.Warn("Invalid rate limit blacklist ignored", logger.Err(err))
+		blacklist = nil
+	}
+	return whitelist, blacklist
+}
+
+// routeRateLimit 路由组单独限流，需挂在认证之后以便按用户计数；全局限流关闭时不生效
+func (r *Router) routeRateLimit(enabled bool, route config.RouteRateLimitConfig, defaultPerMinute, defaultBurst int) gin.HandlerFunc {
+	if !enabled {
+		return func(c *gin.Context) { c.Next() }
+	}
+	if route.RequestsPerMinute <= 0 {
+		route.RequestsPerMinute = defaultPerMinute
 	}
+	if route.Burst <= 0 {
+		route.Burst = defaultBurst
+	}
+	return middleware.NewKeyedRateLimitMiddleware(float64(route.RequestsPerMinute)/60, route.Burst, middleware.UserRateLimitKey)
 }
 
 // Setup 配置路由
@@ -52,6 +116,17 @@ func (r *Router) Setup(engine *gin.Engine) {
 	if rateLimitConfig.Burst <= 0 {
 		rateLimitConfig.Burst = 200
 	}
+	if rateLimitConfig.LikesPerMinute <= 0 {
+		rateLimitConfig.LikesPerMinute = 20
+	}
+	if rateLimitConfig.LikeBurst <= 0 {
+		rateLimitConfig.LikeBurst = 10
+	}
+	likeRate := float64(rateLimitConfig.LikesPerMinute) / 60
+	likeGuard := middleware.AbuseGuardMiddleware("lesson_like",
+		middleware.NewKeyedRateLimiter(likeRate, rateLimitConfig.LikeBurst, middleware.UserRateLimitKey),
+		middleware.NewKeyedRateLimiter(likeRate, rateLimitConfig.LikeBurst, middleware.DeviceRateLimitKey),
+	)
 
 	defaultCORSConfig := middleware.DefaultCORSConfig()
 	corsConfig := middleware.CORSConfig{
@@ -80,17 +155,39 @@ func (r *Router) Setup(engine *gin.Engine) {
 	}
 	corsConfig.AllowCredentials = r.config.CORS.AllowCredentials
 
+	// 只采信受信任代理转发的 X-Forwarded-For，否则客户端可伪造来源 IP 绕过黑白名单与限流；
+	// 配置校验已拦截无效条目，这里设置失败时退回为不信任任何代理
+	if err := engine.SetTrustedProxies(r.config.App.TrustedProxies); err != nil {
+		logger.Warn("Invalid trusted proxies ignored", logger.Err(err))
+		_ = engine.SetTrustedProxies(nil)
+	}
+
 	// 中间件
 	engine.Use(middleware.LoggerMiddleware())
+	engine.Use(middleware.MetricsMiddleware())
 	engine.Use(middleware.RecoveryMiddleware())
 	engine.Use(middleware.CORSMiddleware(corsConfig))
+	engine.Use(middleware.TenantMiddleware(r.config.App.DefaultTenant, r.config.App.TenantHostMap()))
+	if whitelist, blacklist := r.ipAccessLists(rateLimitConfig); whitelist.Len() > 0 || blacklist.Len() > 0 {
+		engine.Use(middleware.IPAccessMiddleware(whitelist, blacklist))
+	}
 	if rateLimitConfig.Enabled {
 		engine.Use(middleware.NewRateLimitMiddleware(float64(rateLimitConfig.RequestsPerSecond), rateLimitConfig.Burst))
 	}
 
-	// 健康检查
-	engine.GET("/health", HealthCheck)
+	// 健康检查：/health 为 liveness，不访问依赖；/health/ready 检查依赖并短时缓存
+	engine.GET("/health", r.healthHandler.Liveness)
+	engine.GET("/health/ready", r.healthHandler.Readiness)
 	engine.GET("/metrics", Metrics)
+	engine.GET("/metrics/json", MetricsSnapshot)
+
+	// 用户头像：只开放 avatars 子目录，封面等其他上传文件仍经由各自接口访问
+	if r.config.Upload.StoragePath != "" {
+		avatars := engine.Group("/uploads/avatars", func(c *gin.Context) {
+			c.Header("X-Content-Type-Options", "nosniff")
+		})
+		avatars.Static("/", service.AvatarDir(r.config.Upload.StoragePath))
+	}
 
 	// API v1
 	v1 := engine.Group("/api/v1")
@@ -101,72 +198,160 @@ func (r *Router) Setup(engine *gin.Engine) {
 			auth.POST("/register", r.authHandler.Register)
 			auth.POST("/login", r.authHandler.Login)
 			auth.POST("/refresh", r.authHandler.RefreshToken)
-			auth.POST("/logout", middleware.AuthMiddleware(r.jwtManager), r.authHandler.Logout)
-			auth.POST("/change-password", middleware.AuthMiddleware(r.jwtManager), r.authHandler.ChangePassword)
-			auth.GET("/me", middleware.AuthMiddleware(r.jwtManager), r.authHandler.GetCurrentUser)
+			auth.POST("/confirm-email", r.authHandler.ConfirmEmail)
+			auth.GET("/verify", r.authHandler.VerifyEmail)
+			auth.POST("/resend-verification", r.authHandler.ResendVerification)
+			auth.POST("/forgot-password", r.authHandler.ForgotPassword)
+			auth.POST("/reset-password", r.authHandler.ResetPassword)
+			auth.POST("/logout", r.auth(), r.authHandler.Logout)
+			auth.POST("/change-password", r.auth(), middleware.DenyAPIKey(), r.authHandler.ChangePassword)
+			auth.GET("/me", r.auth(), r.authHandler.GetCurrentUser)
 		}
 
 		// 用户路由
 		users := v1.Group("/users")
-		users.Use(middleware.AuthMiddleware(r.jwtManager))
+		users.Use(r.auth())
 		{
 			users.GET("/profile", r.userHandler.GetProfile)
 			users.PUT("/profile", r.userHandler.UpdateProfile)
+			users.POST("/email/change", middleware.DenyAPIKey(), r.userHandler.RequestEmailChange)
 			users.POST("/avatar", r.userHandler.UploadAvatar)
+			users.GET("/notification-preference", r.userHandler.GetNotificationPreference)
+			users.PUT("/notification-preference", r.userHandler.UpdateNotificationPreference)
+			users.GET("/settings", r.userHandler.GetSettings)
+			users.GET("/me/data-export", middleware.DenyAPIKey(), r.userHandler.ExportData)
+			users.PUT("/settings", r.userHandler.UpdateSettings)
+			users.POST("/:id/follow", r.userHandler.Follow)
+			users.DELETE("/:id/follow", r.userHandler.Unfollow)
 		}
 
 		// 教案路由
 		lessons := v1.Group("/lessons")
 		{
-			lessons.GET("", middleware.OptionalAuthMiddleware(r.jwtManager), r.lessonHandler.List)
-			lessons.GET("/search", r.lessonHandler.Search)
-			lessons.GET("/:id", middleware.OptionalAuthMiddleware(r.jwtManager), r.lessonHandler.GetByID)
-			lessons.GET("/:id/comments", r.lessonHandler.ListComments)
-			lessons.GET("/export/layouts", middleware.OptionalAuthMiddleware(r.jwtManager), r.lessonHandler.ExportLayouts)
-			lessons.GET("/:id/export", middleware.OptionalAuthMiddleware(r.jwtManager), r.lessonHandler.Export)
+			lessons.GET("", r.optionalAuth(), r.lessonHandler.List)
+			lessons.GET("/search", r.optionalAuth(), r.lessonHandler.Search)
+			lessons.GET("/:id", r.optionalAuth(), r.lessonHandler.GetByID)
+			lessons.GET("/:id/comments", r.optionalAuth(), r.lessonHandler.ListComments)
+			lessons.GET("/export/layouts", r.optionalAuth(), r.lessonHandler.ExportLayouts)
+			lessons.GET("/:id/export", r.optionalAuth(), r.lessonHandler.Export)
+			lessons.GET("/:id/cover", r.lessonHandler.Cover)
 
 			// 需要认证的教案路由
 			lessonsAuth := lessons.Group("")
-			lessonsAuth.Use(middleware.AuthMiddleware(r.jwtManager))
+			lessonsAuth.Use(r.auth())
 			{
 				lessonsAuth.POST("", r.lessonHandler.Create)
+				lessonsAuth.POST("/import-url", r.lessonHandler.ImportFromURL)
 				lessonsAuth.PUT("/:id", r.lessonHandler.Update)
+				lessonsAuth.POST("/:id/confirm-delete", r.lessonHandler.ConfirmDelete)
+				lessonsAuth.DELETE("/:id/purge", r.lessonHandler.Purge)
 				lessonsAuth.DELETE("/:id", r.lessonHandler.Delete)
 				lessonsAuth.POST("/:id/publish", r.lessonHandler.Publish)
+				lessonsAuth.POST("/:id/restore", r.lessonHandler.Restore)
 				lessonsAuth.GET("/:id/versions", r.lessonHandler.ListVersions)
 				lessonsAuth.GET("/:id/versions/:version", r.lessonHandler.GetVersion)
 				lessonsAuth.GET("/:id/versions/diff", r.lessonHandler.DiffVersions)
+				lessonsAuth.GET("/:id/versions/:version/diff/:to", r.lessonHandler.DiffVersionRange)
 				lessonsAuth.POST("/:id/versions/:version/rollback", r.lessonHandler.RollbackToVersion)
+				lessonsAuth.POST("/:id/versions/:version/label", r.lessonHandler.LabelVersion)
+				lessonsAuth.GET("/:id/edits", r.lessonHandler.ListEdits)
+				lessonsAuth.POST("/:id/undo", r.lessonHandler.UndoEdit)
+				lessonsAuth.POST("/:id/redo", r.lessonHandler.RedoEdit)
+				lessonsAuth.POST("/:id/auto-cover", r.lessonHandler.AutoCover)
 				lessonsAuth.GET("/:id/quality-review", r.lessonHandler.QualityReview)
+				lessonsAuth.POST("/:id/compliance-check", r.lessonHandler.ComplianceCheck)
+				lessonsAuth.GET("/:id/readability", r.lessonHandler.Readability)
+				lessonsAuth.POST("/:id/translate", r.lessonHandler.Translate)
+				lessonsAuth.POST("/:id/student-version", r.lessonHandler.StudentVersion)
+				lessonsAuth.POST("/:id/glossary", r.lessonHandler.Glossary)
+				lessonsAuth.POST("/:id/beautify", r.lessonHandler.Beautify)
 				lessonsAuth.POST("/:id/favorite", r.lessonHandler.AddFavorite)
 				lessonsAuth.DELETE("/:id/favorite", r.lessonHandler.RemoveFavorite)
-				lessonsAuth.POST("/:id/like", r.lessonHandler.Like)
-				lessonsAuth.DELETE("/:id/like", r.lessonHandler.Unlike)
+				lessonsAuth.POST("/:id/like", likeGuard, r.lessonHandler.Like)
+				lessonsAuth.DELETE("/:id/like", likeGuard, r.lessonHandler.Unlike)
 				lessonsAuth.POST("/:id/comments", r.lessonHandler.CreateComment)
 				lessonsAuth.DELETE("/:id/comments/:commentId", r.lessonHandler.DeleteComment)
+				lessonsAuth.POST("/:id/comments/:commentId/restore", r.lessonHandler.RestoreComment)
+				lessonsAuth.POST("/:id/comments/:commentId/like", likeGuard, r.lessonHandler.LikeComment)
+				lessonsAuth.DELETE("/:id/comments/:commentId/like", likeGuard, r.lessonHandler.UnlikeComment)
+				lessonsAuth.GET("/:id/annotations", r.annotationHandler.List)
+				lessonsAuth.POST("/:id/annotations", r.annotationHandler.Create)
+				lessonsAuth.PATCH("/:id/annotations/:annotationId", r.annotationHandler.UpdateStatus)
+				lessonsAuth.DELETE("/:id/annotations/:annotationId", r.annotationHandler.Delete)
+				lessonsAuth.GET("/:id/generation", r.lessonHandler.GetSourceGeneration)
+				lessonsAuth.POST("/:id/image-suggestions", r.lessonHandler.ImageSuggestions)
+				lessonsAuth.POST("/:id/shares", r.shareHandler.Create)
+				lessonsAuth.GET("/:id/shares", r.shareHandler.List)
+				lessonsAuth.DELETE("/:id/shares/:shareId", r.shareHandler.Revoke)
 			}
 		}
 
 		// 我的教案
 		my := v1.Group("/my")
-		my.Use(middleware.AuthMiddleware(r.jwtManager))
+		my.Use(r.auth())
 		{
 			my.GET("/lessons", r.lessonHandler.MyLessons)
 			my.GET("/favorites", r.lessonHandler.MyFavorites)
+			my.GET("/favorites/folders", r.lessonHandler.MyFavoriteFolders)
+			my.GET("/feed", r.userHandler.Feed)
 		}
 
 		// 生成路由
 		generate := v1.Group("/generate")
-		generate.Use(middleware.AuthMiddleware(r.jwtManager))
+		generate.Use(r.auth(), r.routeRateLimit(rateLimitConfig.Enabled, rateLimitConfig.Routes.Generate, 30, 10))
 		{
-			generate.POST("", r.generationHandler.Generate)
-			generate.POST("/assistant/chat", r.generationHandler.AskAssistant)
+			// 调用 AI 的接口消耗额度，API Key 需额外具备 generate 权限
+			requireGenerate := middleware.RequireAPIKeyScope(model.APIKeyScopeGenerate)
+			generate.POST("", requireGenerate, r.generationHandler.Generate)
+			generate.POST("/stream", requireGenerate, r.generationHandler.GenerateStream)
+			generate.POST("/async", requireGenerate, r.generationHandler.StartGeneration)
+			generate.GET("/:id/stream", r.generationHandler.StreamProgress)
+			generate.POST("/assistant/chat", requireGenerate, r.generationHandler.AskAssistant)
 			generate.GET("/history", r.generationHandler.ListGenerations)
+			generate.GET("/history/search", r.generationHandler.SearchGenerations)
 			generate.GET("/history/:id", r.generationHandler.GetGeneration)
+			generate.POST("/history/:id/retry", requireGenerate, r.generationHandler.RetryGeneration)
 			generate.GET("/stats", r.generationHandler.GetStats)
+			generate.GET("/stats/timeseries", r.generationHandler.GetTimeseries)
+			generate.GET("/styles", r.generationHandler.ListStyles)
+			generate.GET("/presets", r.generationHandler.ListPresets)
+			generate.POST("/presets", r.generationHandler.SavePreset)
+			generate.DELETE("/presets/:id", r.generationHandler.DeletePreset)
 			generate.GET("/langsmith/usage", r.generationHandler.GetLangSmithUsage)
 		}
 
+		// API Key 管理（仅限登录令牌，API Key 不能管理自身）
+		apiKeys := v1.Group("/api-keys")
+		apiKeys.Use(r.auth(), middleware.DenyAPIKey())
+		{
+			apiKeys.GET("", r.apiKeyHandler.List)
+			apiKeys.POST("", r.apiKeyHandler.Create)
+			apiKeys.DELETE("/:id", r.apiKeyHandler.Revoke)
+		}
+
+		// 管理员路由
+		admin := v1.Group("/admin")
+		admin.Use(r.auth(), middleware.DenyAPIKey(), middleware.RoleMiddleware(model.RoleAdmin))
+		{
+			admin.GET("/prompt-template", r.generationHandler.GetPromptTemplate)
+			admin.PUT("/prompt-template", r.generationHandler.UpdatePromptTemplate)
+			admin.DELETE("/prompt-template", r.generationHandler.ResetPromptTemplate)
+			admin.POST("/prompt-template/preview", r.generationHandler.PreviewPromptTemplate)
+			admin.POST("/users/import", r.userHandler.ImportUsers)
+			admin.POST("/users/:id/ban", r.userHandler.BanUser)
+			admin.POST("/users/:id/unban", r.userHandler.UnbanUser)
+			admin.GET("/generations/review", r.generationHandler.ListPendingReviews)
+			admin.POST("/generations/:id/review", r.generationHandler.ResolveReview)
+			admin.GET("/comment-audits", r.lessonHandler.ListCommentAudits)
+		}
+
+		// 公开分享路由（免登录只读）
+		share := v1.Group("/share")
+		{
+			share.GET("/:token", r.shareHandler.View)
+			share.GET("/:token/export", r.shareHandler.Export)
+		}
+
 		// 知识图谱路由
 		knowledge := v1.Group("/knowledge")
 		{
@@ -174,32 +359,40 @@ func (r *Router) Setup(engine *gin.Engine) {
 
 			// 需要认证的知识图谱路由
 			knowledgeAuth := knowledge.Group("")
-			knowledgeAuth.Use(middleware.AuthMiddleware(r.jwtManager))
+			knowledgeAuth.Use(r.auth())
 			{
 				// 获取用户的知识图谱
 				knowledgeAuth.GET("/graph", r.generationHandler.GetKnowledgeGraph)
+				knowledgeAuth.GET("/graph/clusters", r.generationHandler.GetKnowledgeGraphClusters)
+				knowledgeAuth.GET("/nodes/:id/similar", r.generationHandler.GetSimilarKnowledge)
+				knowledgeAuth.GET("/path", r.generationHandler.GetKnowledgePath)
+				knowledgeAuth.GET("/export/anki", r.generationHandler.ExportAnki)
 			}
 
 			// 文档管理 (需要认证)
 			documents := knowledge.Group("/documents")
-			documents.Use(middleware.AuthMiddleware(r.jwtManager))
+			documents.Use(r.auth(), r.routeRateLimit(rateLimitConfig.Enabled, rateLimitConfig.Routes.KnowledgeDocuments, 60, 20))
 			{
 				documents.POST("", r.knowledgeHandler.UploadDocument)
 				documents.GET("", r.knowledgeHandler.ListDocuments)
 				documents.GET("/:id", r.knowledgeHandler.GetDocument)
 				documents.DELETE("/:id", r.knowledgeHandler.DeleteDocument)
 				documents.GET("/:id/status", r.knowledgeHandler.GetDocumentStatus)
+				documents.POST("/:id/reprocess", r.knowledgeHandler.ReprocessDocument)
 			}
 		}
 
 		// 教案模板库路由
 		templates := v1.Group("/templates")
-		templates.Use(middleware.AuthMiddleware(r.jwtManager))
+		templates.Use(r.auth())
 		{
 			templates.GET("", r.templateHandler.List)
+			templates.GET("/public", r.templateHandler.ListPublic)
 			templates.GET("/:id", r.templateHandler.Get)
 			templates.POST("", r.templateHandler.Create)
 			templates.POST("/:id/apply", r.templateHandler.Apply)
+			templates.POST("/:id/copy", r.templateHandler.Copy)
+			templates.PUT("/:id/visibility", r.templateHandler.SetVisibility)
 			templates.DELETE("/:id", r.templateHandler.Delete)
 		}
 	}
@@ -0,0 +1,205 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// fakeAPIKeyAuth 按明文查找预置的 API Key 认证结果
+type fakeAPIKeyAuth map[string]*model.APIKeyIdentity
+
+func (f fakeAPIKeyAuth) AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKeyIdentity, error) {
+	if identity, ok := f[rawKey]; ok {
+		return identity, nil
+	}
+	return nil, errors.New("invalid api key")
+}
+
+// newTestRouter 按完整路由表创建引擎；处理器均为空，只用于验证处理器之前的中间件
+func newTestRouter(t *testing.T, cfg *config.Config, apiKeys middleware.APIKeyAuthenticator) *gin.Engine {
+	t.Helper()
+	gin.SetMode(gin.TestMode)
+	if cfg == nil {
+		cfg = &config.Config{}
+	}
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	engine := gin.New()
+	NewRouter(nil, nil, nil, nil, nil, nil, nil, nil, nil, nil, apiKeys, nil, cfg, jwtManager).Setup(engine)
+	return engine
+}
+
+func TestAdminRoutesRejectAPIKeys(t *testing.T) {
+	apiKeys := fakeAPIKeyAuth{
+		"lpk_admin_rw": {KeyID: "k1", UserID: "admin-1", Role: model.RoleAdmin, Scopes: []string{model.APIKeyScopeRead, model.APIKeyScopeWrite}},
+		"lpk_admin_ro": {KeyID: "k2", UserID: "admin-1", Role: model.RoleAdmin, Scopes: []string{model.APIKeyScopeRead}},
+	}
+	r := newTestRouter(t, nil, apiKeys)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		key    string
+	}{
+		{name: "bulk import with read-write key", method: http.MethodPost, path: "/api/v1/admin/users/import", key: "lpk_admin_rw"},
+		{name: "ban user with read-write key", method: http.MethodPost, path: "/api/v1/admin/users/u-1/ban", key: "lpk_admin_rw"},
+		{name: "edit prompt template with read-write key", method: http.MethodPut, path: "/api/v1/admin/prompt-template", key: "lpk_admin_rw"},
+		{name: "read prompt template with read-only key", method: http.MethodGet, path: "/api/v1/admin/prompt-template", key: "lpk_admin_ro"},
+		{name: "list comment audits with read-only key", method: http.MethodGet, path: "/api/v1/admin/comment-audits", key: "lpk_admin_ro"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, tt.path, nil)
+			req.Header.Set(middleware.APIKeyHeader, tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusForbidden {
+				t.Errorf("status = %d, want %d: %s", w.Code, http.StatusForbidden, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestForwardedForOnlyTrustedFromProxies(t *testing.T) {
+	cfg := &config.Config{
+		App: config.AppConfig{TrustedProxies: []string{"10.0.0.0/8"}},
+		RateLimit: config.RateLimitConfig{
+			Enabled:           true,
+			RequestsPerSecond: 1,
+			Burst:             1,
+			Whitelist:         []string{"198.51.100.50"},
+			Blacklist:         []string{"203.0.113.7"},
+		},
+	}
+
+	tests := []struct {
+		name       string
+		remoteAddr string
+		forwarded  string
+		requests   int
+		wantStatus int
+	}{
+		{name: "blacklisted peer cannot hide behind a spoofed header", remoteAddr: "203.0.113.7:4000", forwarded: "198.51.100.1", requests: 1, wantStatus: http.StatusForbidden},
+		{name: "trusted proxy forwards a blacklisted client", remoteAddr: "10.0.0.2:4000", forwarded: "203.0.113.7", requests: 1, wantStatus: http.StatusForbidden},
+		{name: "trusted proxy forwards a normal client", remoteAddr: "10.0.0.2:4000", forwarded: "198.51.100.1", requests: 1, wantStatus: http.StatusUnauthorized},
+		{name: "untrusted peer cannot claim a whitelisted ip", remoteAddr: "192.0.2.9:4000", forwarded: "198.51.100.50", requests: 2, wantStatus: http.StatusTooManyRequests},
+		{name: "trusted proxy forwards a whitelisted client", remoteAddr: "10.0.0.3:4000", forwarded: "198.51.100.50", requests: 2, wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t, cfg, nil)
+			var w *httptest.ResponseRecorder
+			for i := 0; i < tt.requests; i++ {
+				req := httptest.NewRequest(http.MethodGet, "/api/v1/auth/me", nil)
+				req.RemoteAddr = tt.remoteAddr
+				req.Header.Set("X-Forwarded-For", tt.forwarded)
+				w = httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+			}
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+		})
+	}
+}
+
+func TestRouteRateLimits(t *testing.T) {
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	tokenFor := func(userID string) string {
+		token, _, err := jwtManager.GenerateAccessToken(userID, "teacher", userID+"@example.com", "user", "")
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		return token
+	}
+	// 全局限流足够宽松，只验证路由组叠加的限流
+	rateLimit := func(enabled bool, routes config.RouteRateLimitsConfig) *config.Config {
+		return &config.Config{RateLimit: config.RateLimitConfig{
+			Enabled: enabled, RequestsPerSecond: 1000, Burst: 1000, Routes: routes,
+		}}
+	}
+	strictGenerate := config.RouteRateLimitsConfig{Generate: config.RouteRateLimitConfig{RequestsPerMinute: 1, Burst: 2}}
+
+	type request struct {
+		user     string
+		path     string
+		limited  bool
+		requests int
+	}
+	tests := []struct {
+		name     string
+		cfg      *config.Config
+		requests []request
+	}{
+		{
+			name: "generate burst exhausted",
+			cfg:  rateLimit(true, strictGenerate),
+			requests: []request{
+				{user: "u1", path: "/api/v1/generate/styles", requests: 2},
+				{user: "u1", path: "/api/v1/generate/styles", requests: 1, limited: true},
+			},
+		},
+		{
+			name: "users are counted separately",
+			cfg:  rateLimit(true, strictGenerate),
+			requests: []request{
+				{user: "u1", path: "/api/v1/generate/styles", requests: 2},
+				{user: "u2", path: "/api/v1/generate/styles", requests: 2},
+				{user: "u1", path: "/api/v1/generate/styles", requests: 1, limited: true},
+			},
+		},
+		{
+			name: "other routes are not affected",
+			cfg:  rateLimit(true, strictGenerate),
+			requests: []request{
+				{user: "u1", path: "/api/v1/generate/styles", requests: 2},
+				{user: "u1", path: "/api/v1/knowledge/documents", requests: 5},
+			},
+		},
+		{
+			name: "documents default burst",
+			cfg:  rateLimit(true, config.RouteRateLimitsConfig{}),
+			requests: []request{
+				{user: "u1", path: "/api/v1/knowledge/documents", requests: 20},
+				{user: "u1", path: "/api/v1/knowledge/documents", requests: 1, limited: true},
+			},
+		},
+		{
+			name: "disabled with global rate limit",
+			cfg:  rateLimit(false, strictGenerate),
+			requests: []request{
+				{user: "u1", path: "/api/v1/generate/styles", requests: 5},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestRouter(t, tt.cfg, nil)
+			for _, req := range tt.requests {
+				for i := 0; i < req.requests; i++ {
+					httpReq := httptest.NewRequest(http.MethodGet, req.path, nil)
+					httpReq.Header.Set("Authorization", "Bearer "+tokenFor(req.user))
+					w := httptest.NewRecorder()
+					r.ServeHTTP(w, httpReq)
+					if limited := w.Code == http.StatusTooManyRequests; limited != req.limited {
+						t.Fatalf("%s %s #%d status = %d, want limited %v", req.user, req.path, i+1, w.Code, req.limited)
+					}
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/service"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// ShareHandler 教案分享处理器
+type ShareHandler struct {
+	shareService  service.ShareService
+	lessonHandler *LessonHandler
+}
+
+// NewShareHandler 创建分享处理器，导出复用教案处理器的渲染逻辑
+func NewShareHandler(shareService service.ShareService, lessonHandler *LessonHandler) *ShareHandler {
+	return &ShareHandler{
+		shareService:  shareService,
+		lessonHandler: lessonHandler,
+	}
+}
+
+// Create 为教案生成分享链接
+func (h *ShareHandler) Create(c *gin.Context) {
+	userID, lessonID, ok := h.parseOwnerRequest(c)
+	if !ok {
+		return
+	}
+
+	var req service.CreateShareRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			Error(c, http.StatusBadRequest, "参数错误", err.Error())
+			return
+		}
+	}
+
+	share, err := h.shareService.Create(c.Request.Context(), lessonID, userID, &req)
+	if err != nil {
+		h.handleError(c, err, "创建分享链接失败")
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Code:    0,
+		Message: "分享链接已生成",
+		Data:    share,
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
+
+// List 教案的分享链接列表
+func (h *ShareHandler) List(c *gin.Context) {
+	userID, lessonID, ok := h.parseOwnerRequest(c)
+	if !ok {
+		return
+	}
+
+	shares, err := h.shareService.List(c.Request.Context(), lessonID, userID)
+	if err != nil {
+		h.handleError(c, err, "获取分享链接失败")
+		return
+	}
+
+	Success(c, shares)
+}
+
+// Revoke 撤销分享链接
+func (h *ShareHandler) Revoke(c *gin.Context) {
+	userID, lessonID, ok := h.parseOwnerRequest(c)
+	if !ok {
+		return
+	}
+
+	shareID, err := uuid.Parse(c.Param("shareId"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的分享ID", nil)
+		return
+	}
+
+	if err := h.shareService.Revoke(c.Request.Context(), lessonID, shareID, userID); err != nil {
+		h.handleError(c, err, "撤销分享链接失败")
+		return
+	}
+
+	SuccessWithMessage(c, "分享链接已撤销", nil)
+}
+
+// View 免登录查看分享的教案（只读）
+func (h *ShareHandler) View(c *gin.Context) {
+	share, lesson, err := h.shareService.Resolve(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.handleError(c, err, "获取分享内容失败")
+		return
+	}
+
+	Success(c, gin.H{
+		"lesson":         lesson,
+		"allow_download": share.AllowDownload,
+		"expires_at":     share.ExpiresAt,
+	})
+}
+
+// Export 免登录下载分享的教案，需分享时允许下载
+func (h *ShareHandler) Export(c *gin.Context) {
+	query, sections, ok := bindExportQuery(c)
+	if !ok {
+		return
+	}
+
+	share, lesson, err := h.shareService.Resolve(c.Request.Context(), c.Param("token"))
+	if err != nil {
+		h.handleError(c, err, "获取分享内容失败")
+		return
+	}
+	if !share.AllowDownload {
+		Error(c, http.StatusForbidden, "该分享链接不允许下载", nil)
+		return
+	}
+
+	h.lessonHandler.writeExport(c, lesson, query.Format, query.Layout, sections, query.TOC, nil)
+}
+
+func (h *ShareHandler) parseOwnerRequest(c *gin.Context) (uuid.UUID, uuid.UUID, bool) {
+	userIDStr, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		Error(c, http.StatusUnauthorized, "无效的用户标识", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	lessonID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的教案ID", nil)
+		return uuid.Nil, uuid.Nil, false
+	}
+	return userID, lessonID, true
+}
+
+func (h *ShareHandler) handleError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, service.ErrShareNotFound), errors.Is(err, service.ErrLessonNotFound):
+		Error(c, http.StatusNotFound, err.Error(), nil)
+	case errors.Is(err, service.ErrShareExpired):
+		Error(c, http.StatusGone, err.Error(), nil)
+	case errors.Is(err, service.ErrUnauthorized):
+		Error(c, http.StatusForbidden, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, message, err.Error())
+	}
+}
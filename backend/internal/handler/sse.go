@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// sseHeartbeatInterval 无数据时向客户端发送 :keepalive 的间隔，防止代理断开空闲连接
+	sseHeartbeatInterval = 15 * time.Second
+	// sseIdleTimeout 上游持续无事件的最长等待时间，超过即关闭流
+	sseIdleTimeout = 3 * time.Minute
+	// generationProgressIdleTimeout 生成进度流的空闲超时，需大于 Agent 调用超时
+	generationProgressIdleTimeout = 15 * time.Minute
+)
+
+// sseEvent SSE 事件
+type sseEvent struct {
+	Event string
+	Data  interface{}
+}
+
+// errSSEIdleTimeout 上游空闲超时
+var errSSEIdleTimeout = fmt.Errorf("流式响应空闲超过 %s，已断开", sseIdleTimeout)
+
+// streamSSE 将 events 中的事件按 SSE 格式写给客户端，直到通道关闭。
+// 期间每 heartbeat 写一次 :keepalive 注释行；超过 idleTimeout 未收到新事件时
+// 发送 error 事件并返回 errSSEIdleTimeout；客户端断开时返回其 context 错误。
+// 调用方应在返回后取消上游生产者。
+func streamSSE(c *gin.Context, events <-chan sseEvent, heartbeat, idleTimeout time.Duration) error {
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+	c.Writer.Flush()
+
+	heartbeatTicker := time.NewTicker(heartbeat)
+	defer heartbeatTicker.Stop()
+	idleTimer := time.NewTimer(idleTimeout)
+	defer idleTimer.Stop()
+
+	for {
+		select {
+		case <-c.Request.Context().Done():
+			return c.Request.Context().Err()
+
+		case event, ok := <-events:
+			if !ok {
+				return nil
+			}
+			if err := writeSSEEvent(c, event); err != nil {
+				return err
+			}
+			if !idleTimer.Stop() {
+				<-idleTimer.C
+			}
+			idleTimer.Reset(idleTimeout)
+
+		case <-heartbeatTicker.C:
+			if _, err := fmt.Fprint(c.Writer, ":keepalive\n\n"); err != nil {
+				return err
+			}
+			c.Writer.Flush()
+
+		case <-idleTimer.C:
+			_ = writeSSEEvent(c, sseEvent{
+				Event: "error",
+				Data:  gin.H{"message": errSSEIdleTimeout.Error()},
+			})
+			return errSSEIdleTimeout
+		}
+	}
+}
+
+func writeSSEEvent(c *gin.Context, event sseEvent) error {
+	payload, err := json.Marshal(event.Data)
+	if err != nil {
+		return err
+	}
+	if event.Event != "" {
+		if _, err := fmt.Fprintf(c.Writer, "event: %s\n", event.Event); err != nil {
+			return err
+		}
+	}
+	if _, err := fmt.Fprintf(c.Writer, "data: %s\n\n", payload); err != nil {
+		return err
+	}
+	c.Writer.Flush()
+	return nil
+}
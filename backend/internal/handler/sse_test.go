@@ -0,0 +1,304 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func TestStreamSSE(t *testing.T) {
+	const (
+		heartbeat   = 10 * time.Millisecond
+		idleTimeout = 50 * time.Millisecond
+	)
+
+	tests := []struct {
+		name          string
+		produce       func(events chan<- sseEvent, cancel context.CancelFunc)
+		wantErr       error
+		wantContains  []string
+		wantKeepalive bool
+	}{
+		{
+			name: "events then close",
+			produce: func(events chan<- sseEvent, cancel context.CancelFunc) {
+				events <- sseEvent{Event: "started", Data: gin.H{"topic": "有理数"}}
+				events <- sseEvent{Event: "result", Data: gin.H{"title": "有理数"}}
+				close(events)
+			},
+			wantContains: []string{"event: started\ndata: {\"topic\":\"有理数\"}\n\n", "event: result\n"},
+		},
+		{
+			name: "stalled upstream hits idle timeout",
+			produce: func(events chan<- sseEvent, cancel context.CancelFunc) {
+				events <- sseEvent{Event: "started", Data: gin.H{}}
+			},
+			wantErr:       errSSEIdleTimeout,
+			wantContains:  []string{"event: started\n", "event: error\n"},
+			wantKeepalive: true,
+		},
+		{
+			name: "events keep resetting idle timer",
+			produce: func(events chan<- sseEvent, cancel context.CancelFunc) {
+				go func() {
+					for i := 0; i < 4; i++ {
+						events <- sseEvent{Event: "progress", Data: gin.H{"step": i}}
+						time.Sleep(idleTimeout / 2)
+					}
+					close(events)
+				}()
+			},
+			wantContains:  []string{`data: {"step":3}`},
+			wantKeepalive: true,
+		},
+		{
+			name: "client disconnect",
+			produce: func(events chan<- sseEvent, cancel context.CancelFunc) {
+				cancel()
+			},
+			wantErr: context.Canceled,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gin.SetMode(gin.TestMode)
+			w := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(w)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			c.Request = httptest.NewRequest(http.MethodGet, "/stream", nil).WithContext(ctx)
+
+			events := make(chan sseEvent, 4)
+			tt.produce(events, cancel)
+			err := streamSSE(c, events, heartbeat, idleTimeout)
+
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("streamSSE() error = %v, want %v", err, tt.wantErr)
+			}
+			if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+				t.Errorf("Content-Type = %q, want text/event-stream", got)
+			}
+			body := w.Body.String()
+			for _, want := range tt.wantContains {
+				if !strings.Contains(body, want) {
+					t.Errorf("body missing %q:\n%s", want, body)
+				}
+			}
+			if tt.wantKeepalive && !strings.Contains(body, ":keepalive\n\n") {
+				t.Errorf("body has no heartbeat:\n%s", body)
+			}
+			if tt.wantErr == nil && strings.Contains(body, "event: error") {
+				t.Errorf("unexpected idle timeout:\n%s", body)
+			}
+		})
+	}
+}
+
+// stalledGenerationService 模拟 Agent 停滞：Generate 一直阻塞到 context 取消
+type stalledGenerationService struct {
+	service.GenerationService
+	cancelled chan struct{}
+}
+
+func (s *stalledGenerationService) Generate(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride service.APIKeyOverride) (*model.GenerationResponse, error) {
+	<-ctx.Done()
+	close(s.cancelled)
+	return nil, ctx.Err()
+}
+
+func TestGenerateStreamClosesStalledAgent(t *testing.T) {
+	const (
+		heartbeat   = 10 * time.Millisecond
+		idleTimeout = 80 * time.Millisecond
+	)
+	gin.SetMode(gin.TestMode)
+	svc := &stalledGenerationService{cancelled: make(chan struct{})}
+	h := NewGenerationHandler(svc, nil, nil, nil, nil)
+	h.streamHeartbeat = heartbeat
+	h.streamIdleTimeout = idleTimeout
+
+	r := gin.New()
+	r.POST("/generate/stream", func(c *gin.Context) {
+		c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+		c.Next()
+	}, h.GenerateStream)
+
+	req := httptest.NewRequest(http.MethodPost, "/generate/stream",
+		strings.NewReader(`{"subject":"数学","grade":"七年级","topic":"有理数"}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+
+	done := make(chan struct{})
+	go func() {
+		r.ServeHTTP(w, req)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("stream did not close while the agent was stalled")
+	}
+
+	body := w.Body.String()
+	if !strings.Contains(body, "event: started\n") {
+		t.Errorf("body missing started event:\n%s", body)
+	}
+	if keepalives := strings.Count(body, ":keepalive\n\n"); keepalives < 2 {
+		t.Errorf("keepalive count = %d, want heartbeats while waiting:\n%s", keepalives, body)
+	}
+	if !strings.Contains(body, "event: error\n") || !strings.Contains(body, errSSEIdleTimeout.Error()) {
+		t.Errorf("body missing idle timeout event:\n%s", body)
+	}
+	if strings.Contains(body, "event: progress") {
+		t.Errorf("stalled agent produced progress events:\n%s", body)
+	}
+	select {
+	case <-svc.cancelled:
+	case <-time.After(time.Second):
+		t.Error("generation was not cancelled after idle timeout")
+	}
+}
+
+// slowVariantsGenerationService 模拟多方案排队生成：每个方案耗时 perVariant，完成时通知进度回调
+type slowVariantsGenerationService struct {
+	service.GenerationService
+	perVariant time.Duration
+}
+
+func (s *slowVariantsGenerationService) Generate(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride service.APIKeyOverride) (*model.GenerationResponse, error) {
+	resp := &model.GenerationResponse{Status: model.GenerationStatusCompleted, Title: "有理数的加法"}
+	for i := 0; i < req.Variants; i++ {
+		select {
+		case <-time.After(s.perVariant):
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+		variant := model.GenerationResponse{ID: uuid.New(), Status: model.GenerationStatusCompleted}
+		if observe := service.VariantObserverFromContext(ctx); observe != nil {
+			observe(i, req.Variants, variant)
+		}
+		resp.Variants = append(resp.Variants, variant)
+	}
+	return resp, nil
+}
+
+func TestGenerateStreamVariantsKeepStreamAlive(t *testing.T) {
+	const (
+		heartbeat   = 10 * time.Millisecond
+		idleTimeout = 80 * time.Millisecond
+	)
+	gin.SetMode(gin.TestMode)
+	// 单个方案在空闲超时内完成，但三个方案的总耗时超过空闲超时
+	h := NewGenerationHandler(&slowVariantsGenerationService{perVariant: 50 * time.Millisecond}, nil, nil, nil, nil)
+	h.streamHeartbeat = heartbeat
+	h.streamIdleTimeout = idleTimeout
+
+	r := gin.New()
+	r.POST("/generate/stream", func(c *gin.Context) {
+		c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+		c.Next()
+	}, h.GenerateStream)
+
+	req := httptest.NewRequest(http.MethodPost, "/generate/stream",
+		strings.NewReader(`{"subject":"数学","grade":"七年级","topic":"有理数","variants":3}`))
+	req.Header.Set("Content-Type", "application/json")
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, req)
+
+	body := w.Body.String()
+	if n := strings.Count(body, "event: variant_done\n"); n != 3 {
+		t.Errorf("variant_done events = %d, want 3:\n%s", n, body)
+	}
+	if !strings.Contains(body, `"index":2,`) || !strings.Contains(body, `"total":3`) {
+		t.Errorf("body missing variant progress:\n%s", body)
+	}
+	if strings.Contains(body, "event: error\n") {
+		t.Errorf("variants stream timed out:\n%s", body)
+	}
+	if !strings.Contains(body, "event: result\n") || strings.LastIndex(body, "event: variant_done") > strings.Index(body, "event: result") {
+		t.Errorf("body = %s, want result after every variant", body)
+	}
+}
+
+// progressGenerationService 按预设事件推送生成进度
+type progressGenerationService struct {
+	service.GenerationService
+	events       []service.GenerationProgressEvent
+	unsubscribed chan struct{}
+}
+
+func (s *progressGenerationService) SubscribeProgress(ctx context.Context, id, userID uuid.UUID) (<-chan service.GenerationProgressEvent, func(), error) {
+	if id == uuid.Nil {
+		return nil, nil, service.ErrGenerationNotFound
+	}
+	ch := make(chan service.GenerationProgressEvent, len(s.events))
+	for _, event := range s.events {
+		event.ID = id
+		ch <- event
+	}
+	close(ch)
+	return ch, func() { close(s.unsubscribed) }, nil
+}
+
+func TestStreamProgress(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	svc := &progressGenerationService{
+		events: []service.GenerationProgressEvent{
+			{Status: model.GenerationStatusProcessing},
+			{Status: model.GenerationStatusCompleted, Result: &model.GenerationResponse{Title: "有理数的加法"}},
+		},
+		unsubscribed: make(chan struct{}),
+	}
+	h := NewGenerationHandler(svc, nil, nil, nil, nil)
+
+	r := gin.New()
+	r.GET("/generate/:id/stream", func(c *gin.Context) {
+		c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: uuid.NewString()})
+		c.Next()
+	}, h.StreamProgress)
+
+	id := uuid.New()
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/generate/"+id.String()+"/stream", nil))
+
+	if got := w.Header().Get("Content-Type"); got != "text/event-stream" {
+		t.Fatalf("Content-Type = %q, want text/event-stream", got)
+	}
+	body := w.Body.String()
+	if n := strings.Count(body, "event: status\n"); n != 2 {
+		t.Fatalf("status events = %d, want 2:\n%s", n, body)
+	}
+	processing := strings.Index(body, `"status":"processing"`)
+	completed := strings.Index(body, `"status":"completed"`)
+	if processing < 0 || completed < processing || !strings.Contains(body, `"id":"`+id.String()+`"`) {
+		t.Errorf("body = %s, want processing then completed for %s", body, id)
+	}
+	if !strings.Contains(body, `"title":"有理数的加法"`) {
+		t.Errorf("terminal event missing result:\n%s", body)
+	}
+	select {
+	case <-svc.unsubscribed:
+	default:
+		t.Error("subscription was not released after the stream ended")
+	}
+
+	// 订阅失败时返回普通 JSON 错误
+	w = httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/generate/"+uuid.Nil.String()+"/stream", nil))
+	if w.Code != http.StatusNotFound {
+		t.Errorf("missing generation status = %d, want 404", w.Code)
+	}
+}
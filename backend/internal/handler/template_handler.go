@@ -38,7 +38,7 @@ func (h *TemplateHandler) resolveUserID(c *gin.Context) (uuid.UUID, bool) {
 	return uid, true
 }
 
-// List 模板列表（内置 + 当前用户私有模板）。
+// List 模板列表（内置 + 当前用户的模板）。
 func (h *TemplateHandler) List(c *gin.Context) {
 	userID, ok := h.resolveUserID(c)
 	if !ok {
@@ -152,3 +152,86 @@ func (h *TemplateHandler) Apply(c *gin.Context) {
 
 	Success(c, payload)
 }
+
+// SetTemplateVisibilityRequest 模板公开设置请求
+type SetTemplateVisibilityRequest struct {
+	Public *bool `json:"public" binding:"required"`
+}
+
+// ListPublic 模板市场（同租户内他人公开的模板）。
+func (h *TemplateHandler) ListPublic(c *gin.Context) {
+	userID, ok := h.resolveUserID(c)
+	if !ok {
+		return
+	}
+
+	templates, err := h.templateService.ListPublic(c.Request.Context(), userID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取公开模板失败", err.Error())
+		return
+	}
+
+	Success(c, templates)
+}
+
+// SetVisibility 公开或取消公开自己的模板。
+func (h *TemplateHandler) SetVisibility(c *gin.Context) {
+	userID, ok := h.resolveUserID(c)
+	if !ok {
+		return
+	}
+
+	var req SetTemplateVisibilityRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		ErrorWithCode(c, http.StatusBadRequest, "TEMPLATE_INVALID_REQUEST", "模板参数错误", err.Error())
+		return
+	}
+
+	template, err := h.templateService.SetPublic(c.Request.Context(), c.Param("id"), userID, *req.Public)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTemplateNotFound):
+			ErrorWithCode(c, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "模板不存在", nil)
+		case errors.Is(err, service.ErrTemplateForbidden):
+			ErrorWithCode(c, http.StatusForbidden, "TEMPLATE_FORBIDDEN", "只能修改自己的模板", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "更新模板失败", err.Error())
+		}
+		return
+	}
+
+	message := "模板已取消公开"
+	if template.Public {
+		message = "模板已公开"
+	}
+	SuccessWithMessage(c, message, template)
+}
+
+// Copy 复制模板到个人模板库。
+func (h *TemplateHandler) Copy(c *gin.Context) {
+	userID, ok := h.resolveUserID(c)
+	if !ok {
+		return
+	}
+
+	template, err := h.templateService.Copy(c.Request.Context(), c.Param("id"), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrTemplateNotFound):
+			ErrorWithCode(c, http.StatusNotFound, "TEMPLATE_NOT_FOUND", "模板不存在", nil)
+		case errors.Is(err, service.ErrTemplateForbidden):
+			ErrorWithCode(c, http.StatusForbidden, "TEMPLATE_FORBIDDEN", "无权复制该模板", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "复制模板失败", err.Error())
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, Response{
+		Success: true,
+		Code:    0,
+		Message: "模板已复制到我的模板",
+		Data:    template,
+		TraceID: middleware.TraceIDFromGin(c),
+	})
+}
@@ -0,0 +1,85 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"mime/multipart"
+	"net/http"
+	"strings"
+
+	"lesson-plan/backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// defaultUploadMaxSize 未配置 upload.max_size 时的单文件大小上限
+	defaultUploadMaxSize = 10 << 20
+	// multipartOverhead 请求体限制在文件上限之外为表单字段与分隔符预留的余量
+	multipartOverhead = 1 << 20
+)
+
+// uploadLimits 来自 UploadConfig 的上传限制
+type uploadLimits struct {
+	maxSize      int64
+	allowedTypes map[string]bool
+}
+
+// newUploadLimits 按配置构建上传限制，allowed_types 为空时不限制类型
+func newUploadLimits(cfg *config.UploadConfig) uploadLimits {
+	limits := uploadLimits{maxSize: defaultUploadMaxSize}
+	if cfg == nil {
+		return limits
+	}
+	if cfg.MaxSize > 0 {
+		limits.maxSize = cfg.MaxSize
+	}
+	if len(cfg.AllowedTypes) > 0 {
+		limits.allowedTypes = make(map[string]bool, len(cfg.AllowedTypes))
+		for _, t := range cfg.AllowedTypes {
+			limits.allowedTypes[strings.ToLower(strings.TrimSpace(t))] = true
+		}
+	}
+	return limits
+}
+
+// allows 判断 MIME 类型是否在允许列表中
+func (l uploadLimits) allows(mimeType string) bool {
+	return l.allowedTypes == nil || l.allowedTypes[strings.ToLower(mimeType)]
+}
+
+// formFile 读取上传文件并检查大小，请求体超限时不再继续读取；失败时已写入错误响应
+func (l uploadLimits) formFile(c *gin.Context, field string) (*multipart.FileHeader, bool) {
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, l.maxSize+multipartOverhead)
+	header, err := c.FormFile(field)
+	if err != nil {
+		var maxBytesErr *http.MaxBytesError
+		if errors.As(err, &maxBytesErr) {
+			l.abortTooLarge(c)
+		} else {
+			Error(c, http.StatusBadRequest, "请选择要上传的文件", nil)
+		}
+		return nil, false
+	}
+	if header.Size > l.maxSize {
+		l.abortTooLarge(c)
+		return nil, false
+	}
+	return header, true
+}
+
+// abortTooLarge 返回 413 并说明大小上限
+func (l uploadLimits) abortTooLarge(c *gin.Context) {
+	Error(c, http.StatusRequestEntityTooLarge, fmt.Sprintf("文件大小不能超过 %s", formatUploadSize(l.maxSize)), nil)
+}
+
+// formatUploadSize 把字节数格式化为 MB/KB
+func formatUploadSize(size int64) string {
+	if size >= 1<<20 && size%(1<<20) == 0 {
+		return fmt.Sprintf("%dMB", size>>20)
+	}
+	if size >= 1<<20 {
+		return fmt.Sprintf("%.1fMB", float64(size)/(1<<20))
+	}
+	return fmt.Sprintf("%dKB", (size+1023)>>10)
+}
@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"bytes"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+
+	"github.com/gin-gonic/gin"
+)
+
+// multipartFileRequest 构造只含一个文件字段的 multipart 请求
+func multipartFileRequest(t *testing.T, field, filename string, size int) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreateFormFile(field, filename)
+	if err != nil {
+		t.Fatalf("CreateFormFile() error = %v", err)
+	}
+	_, _ = part.Write(bytes.Repeat([]byte("a"), size))
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/upload", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadLimitsFormFile(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	limits := newUploadLimits(&config.UploadConfig{MaxSize: 1 << 20})
+	r := gin.New()
+	r.POST("/upload", func(c *gin.Context) {
+		if header, ok := limits.formFile(c, "file"); ok {
+			Success(c, gin.H{"size": header.Size})
+		}
+	})
+
+	tests := []struct {
+		name       string
+		field      string
+		size       int
+		wantStatus int
+	}{
+		{name: "at limit", field: "file", size: 1 << 20, wantStatus: http.StatusOK},
+		{name: "just over limit", field: "file", size: 1<<20 + 1, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "body over overhead", field: "file", size: 3 << 20, wantStatus: http.StatusRequestEntityTooLarge},
+		{name: "missing field", field: "other", size: 10, wantStatus: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, multipartFileRequest(t, tt.field, "notes.txt", tt.size))
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if tt.wantStatus == http.StatusRequestEntityTooLarge && !strings.Contains(w.Body.String(), "1MB") {
+				t.Errorf("body = %s, want the 1MB limit in the message", w.Body.String())
+			}
+		})
+	}
+}
+
+func TestNewUploadLimits(t *testing.T) {
+	if limits := newUploadLimits(nil); limits.maxSize != defaultUploadMaxSize || !limits.allows("application/zip") {
+		t.Errorf("newUploadLimits(nil) = %+v, want default size without type limits", limits)
+	}
+
+	limits := newUploadLimits(&config.UploadConfig{AllowedTypes: []string{" Text/Plain ", "image/png"}})
+	if limits.maxSize != defaultUploadMaxSize {
+		t.Errorf("maxSize = %d, want default when unset", limits.maxSize)
+	}
+	for mimeType, want := range map[string]bool{"text/plain": true, "IMAGE/PNG": true, "text/markdown": false} {
+		if got := limits.allows(mimeType); got != want {
+			t.Errorf("allows(%q) = %v, want %v", mimeType, got, want)
+		}
+	}
+}
+
+func TestFormatUploadSize(t *testing.T) {
+	tests := []struct {
+		size int64
+		want string
+	}{
+		{size: 5 << 20, want: "5MB"},
+		{size: 3 << 19, want: "1.5MB"},
+		{size: 512 << 10, want: "512KB"},
+		{size: 1000, want: "1KB"},
+	}
+	for _, tt := range tests {
+		if got := formatUploadSize(tt.size); got != tt.want {
+			t.Errorf("formatUploadSize(%d) = %q, want %q", tt.size, got, tt.want)
+		}
+	}
+}
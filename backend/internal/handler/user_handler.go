@@ -1,9 +1,15 @@
 package handler
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
+	"path/filepath"
+	"strings"
 
+	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/service"
 
 	"github.com/gin-gonic/gin"
@@ -12,16 +18,29 @@ import (
 
 // UserHandler 用户处理器
 type UserHandler struct {
-	userService service.UserService
+	userService         service.UserService
+	followService       service.FollowService
+	notificationService service.NotificationService
+	settingsService     service.SettingsService
+	uploadLimits        uploadLimits
 }
 
 // NewUserHandler 创建用户处理器
-func NewUserHandler(userService service.UserService) *UserHandler {
+func NewUserHandler(userService service.UserService, followService service.FollowService, notificationService service.NotificationService, settingsService service.SettingsService, uploadCfg *config.UploadConfig) *UserHandler {
 	return &UserHandler{
-		userService: userService,
+		userService:         userService,
+		followService:       followService,
+		notificationService: notificationService,
+		settingsService:     settingsService,
+		uploadLimits:        newUploadLimits(uploadCfg),
 	}
 }
 
+// NotificationPreferenceRequest 评论通知偏好请求
+type NotificationPreferenceRequest struct {
+	Mode string `json:"mode" binding:"required,oneof=realtime daily off"`
+}
+
 // GetProfile 获取用户资料
 func (h *UserHandler) GetProfile(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -61,9 +80,174 @@ func (h *UserHandler) UpdateProfile(c *gin.Context) {
 		return
 	}
 
+	// 邮箱变更走验证流程：资料先保存，新邮箱在确认链接被打开后才生效
+	if req.Email != "" && !strings.EqualFold(strings.TrimSpace(req.Email), user.Email) {
+		if _, err := h.userService.RequestEmailChange(c.Request.Context(), userUUID, req.Email); err != nil {
+			writeEmailChangeError(c, err)
+			return
+		}
+		SuccessWithMessage(c, "资料已更新，验证邮件已发送至新邮箱，确认后邮箱才会修改", user.ToProfile())
+		return
+	}
+
 	Success(c, user.ToProfile())
 }
 
+// RequestEmailChange 发起邮箱修改，向新邮箱发送验证链接
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req service.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	result, err := h.userService.RequestEmailChange(c.Request.Context(), userUUID, req.Email)
+	if err != nil {
+		writeEmailChangeError(c, err)
+		return
+	}
+
+	SuccessWithMessage(c, "验证邮件已发送，请前往新邮箱确认", result)
+}
+
+// writeEmailChangeError 邮箱变更错误映射
+func writeEmailChangeError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, service.ErrUserNotFound):
+		Error(c, http.StatusNotFound, "用户不存在", nil)
+	case errors.Is(err, service.ErrEmailUnchanged):
+		Error(c, http.StatusBadRequest, err.Error(), nil)
+	case errors.Is(err, service.ErrUserExists):
+		Error(c, http.StatusConflict, "该邮箱已被使用", nil)
+	case errors.Is(err, service.ErrEmailChangeTokenInvalid), errors.Is(err, service.ErrEmailChangeTokenExpired):
+		Error(c, http.StatusBadRequest, err.Error(), nil)
+	default:
+		Error(c, http.StatusInternalServerError, "邮箱修改失败", err.Error())
+	}
+}
+
+// GetNotificationPreference 获取评论通知偏好
+func (h *UserHandler) GetNotificationPreference(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	mode, err := h.notificationService.GetPreference(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusNotFound, "用户不存在", nil)
+		return
+	}
+
+	Success(c, gin.H{"mode": mode})
+}
+
+// UpdateNotificationPreference 修改评论通知偏好：实时、每日摘要或关闭
+func (h *UserHandler) UpdateNotificationPreference(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req NotificationPreferenceRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	if err := h.notificationService.UpdatePreference(c.Request.Context(), userUUID, req.Mode); err != nil {
+		if errors.Is(err, service.ErrInvalidNotifyMode) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "更新失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "通知偏好已更新", gin.H{"mode": req.Mode})
+}
+
+// ImportUsers 管理员上传 CSV 批量创建用户，返回逐行结果报告
+func (h *UserHandler) ImportUsers(c *gin.Context) {
+	file, header, err := c.Request.FormFile("file")
+	if err != nil {
+		Error(c, http.StatusBadRequest, "请选择要上传的 CSV 文件", nil)
+		return
+	}
+	defer file.Close()
+
+	if !strings.EqualFold(filepath.Ext(header.Filename), ".csv") {
+		Error(c, http.StatusBadRequest, "仅支持 .csv 格式文件", nil)
+		return
+	}
+	if header.Size > 2*1024*1024 {
+		Error(c, http.StatusBadRequest, "文件大小不能超过 2MB", nil)
+		return
+	}
+
+	report, err := h.userService.ImportUsers(c.Request.Context(), file)
+	if err != nil {
+		if errors.Is(err, service.ErrUserImportInvalid) {
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "导入失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, fmt.Sprintf("导入完成：新建 %d，跳过 %d，失败 %d", report.Created, report.Skipped, report.Failed), report)
+}
+
+// BanUser 管理员封禁用户，封禁后立即失去登录状态
+func (h *UserHandler) BanUser(c *gin.Context) {
+	h.setUserStatus(c, model.StatusBanned, "已封禁")
+}
+
+// UnbanUser 管理员解封用户
+func (h *UserHandler) UnbanUser(c *gin.Context) {
+	h.setUserStatus(c, model.StatusActive, "已解封")
+}
+
+func (h *UserHandler) setUserStatus(c *gin.Context, status, message string) {
+	adminID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的用户ID", nil)
+		return
+	}
+
+	adminUUID, _ := uuid.Parse(adminID)
+	if err := h.userService.SetStatus(c.Request.Context(), adminUUID, targetID, status); err != nil {
+		switch {
+		case errors.Is(err, service.ErrUserNotFound):
+			Error(c, http.StatusNotFound, "用户不存在", nil)
+		case errors.Is(err, service.ErrCannotChangeOwnStatus):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "修改用户状态失败", err.Error())
+		}
+		return
+	}
+
+	SuccessWithMessage(c, message, nil)
+}
+
 // UploadAvatar 上传头像
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
 	userID, ok := middleware.GetCurrentUserID(c)
@@ -72,23 +256,174 @@ func (h *UserHandler) UploadAvatar(c *gin.Context) {
 		return
 	}
 
-	file, err := c.FormFile("avatar")
+	file, ok := h.uploadLimits.formFile(c, "avatar")
+	if !ok {
+		return
+	}
+	src, err := file.Open()
+	if err != nil {
+		Error(c, http.StatusBadRequest, "读取上传文件失败", err.Error())
+		return
+	}
+	defer src.Close()
+
+	userUUID, _ := uuid.Parse(userID)
+	avatarURL, err := h.userService.UploadAvatar(c.Request.Context(), userUUID, src, file.Size)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrAvatarTooLarge):
+			Error(c, http.StatusRequestEntityTooLarge, err.Error(), nil)
+		case errors.Is(err, service.ErrAvatarTypeNotAllowed):
+			Error(c, http.StatusUnsupportedMediaType, err.Error(), nil)
+		case errors.Is(err, service.ErrUserNotFound):
+			Error(c, http.StatusNotFound, "用户不存在", nil)
+		default:
+			Error(c, http.StatusInternalServerError, "上传失败", err.Error())
+		}
+		return
+	}
+
+	Success(c, gin.H{"avatar_url": avatarURL})
+}
+
+// Follow 关注用户
+func (h *UserHandler) Follow(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
 	if err != nil {
-		Error(c, http.StatusBadRequest, "请上传文件", nil)
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
 		return
 	}
 
-	// 简化处理：实际应保存到对象存储
-	avatarURL := "/uploads/avatars/" + file.Filename
+	userUUID, _ := uuid.Parse(userID)
+	status, err := h.followService.Follow(c.Request.Context(), userUUID, targetID)
+	if err != nil {
+		switch {
+		case errors.Is(err, service.ErrCannotFollowSelf):
+			Error(c, http.StatusBadRequest, err.Error(), nil)
+		case errors.Is(err, service.ErrUserNotFound):
+			Error(c, http.StatusNotFound, err.Error(), nil)
+		default:
+			Error(c, http.StatusInternalServerError, "关注失败", err.Error())
+		}
+		return
+	}
+
+	SuccessWithMessage(c, "关注成功", status)
+}
+
+// Unfollow 取消关注
+func (h *UserHandler) Unfollow(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	targetID, err := uuid.Parse(c.Param("id"))
+	if err != nil {
+		Error(c, http.StatusBadRequest, "无效的ID", nil)
+		return
+	}
 
 	userUUID, _ := uuid.Parse(userID)
-	_, err = h.userService.UpdateProfile(c.Request.Context(), userUUID, &service.UpdateUserRequest{
-		AvatarURL: avatarURL,
-	})
+	status, err := h.followService.Unfollow(c.Request.Context(), userUUID, targetID)
 	if err != nil {
-		Error(c, http.StatusInternalServerError, "上传失败", err.Error())
+		Error(c, http.StatusInternalServerError, "取消关注失败", err.Error())
 		return
 	}
 
-	Success(c, gin.H{"avatar_url": avatarURL})
+	SuccessWithMessage(c, "已取消关注", status)
+}
+
+// Feed 关注动态：关注对象最新发布的教案
+func (h *UserHandler) Feed(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	page, pageSize := GetPagination(c)
+	userUUID, _ := uuid.Parse(userID)
+
+	lessons, total, err := h.followService.Feed(c.Request.Context(), userUUID, page, pageSize)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取动态失败", err.Error())
+		return
+	}
+
+	Paginated(c, lessons, total, page, pageSize)
+}
+
+// GetSettings 获取用户设置（主题、语言、默认学科/年级等），未保存过时返回默认值
+func (h *UserHandler) GetSettings(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	settings, err := h.settingsService.Get(c.Request.Context(), userUUID)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "获取设置失败", err.Error())
+		return
+	}
+
+	Success(c, settings)
+}
+
+// UpdateSettings 更新用户设置，只修改请求中提供的字段
+func (h *UserHandler) UpdateSettings(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	var req service.UpdateSettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		Error(c, http.StatusBadRequest, "参数错误", err.Error())
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	settings, err := h.settingsService.Update(c.Request.Context(), userUUID, &req)
+	if err != nil {
+		Error(c, http.StatusInternalServerError, "更新设置失败", err.Error())
+		return
+	}
+
+	SuccessWithMessage(c, "设置已保存", settings)
+}
+
+// ExportData 下载当前用户的全部个人数据（JSON 附件）
+// GET /api/v1/users/me/data-export
+func (h *UserHandler) ExportData(c *gin.Context) {
+	userID, ok := middleware.GetCurrentUserID(c)
+	if !ok {
+		Error(c, http.StatusUnauthorized, "未认证", nil)
+		return
+	}
+
+	userUUID, _ := uuid.Parse(userID)
+	data, err := h.userService.ExportData(c.Request.Context(), userUUID)
+	if err != nil {
+		if errors.Is(err, service.ErrUserNotFound) {
+			Error(c, http.StatusNotFound, "用户不存在", nil)
+			return
+		}
+		Error(c, http.StatusInternalServerError, "导出个人数据失败", err.Error())
+		return
+	}
+
+	setAttachmentHeader(c, "个人数据-"+data.Profile.Username+"-"+data.ExportedAt.Format("20060102"), "user-data", "json")
+	c.Header("Cache-Control", "no-store")
+	Success(c, data)
 }
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/internal/service"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// avatarUserRepo 内存中的用户仓库，只实现头像上传用到的方法
+type avatarUserRepo struct {
+	repository.UserRepository
+	users map[uuid.UUID]*model.User
+}
+
+func (r *avatarUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *user
+	return &copied, nil
+}
+
+func (r *avatarUserRepo) Update(ctx context.Context, user *model.User) error {
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+// pngHeader 最小的 PNG 文件头，足以让内容嗅探识别为 image/png
+var pngHeader = []byte("\x89PNG\r\n\x1a\n\x00\x00\x00\rIHDR\x00\x00\x00\x01\x00\x00\x00\x01\x08\x06\x00\x00\x00")
+
+// avatarUploadRequest 构造 multipart 头像上传请求，声明的 Content-Type 固定为 image/png
+func avatarUploadRequest(t *testing.T, filename string, content []byte) *http.Request {
+	t.Helper()
+	var body bytes.Buffer
+	form := multipart.NewWriter(&body)
+	part, err := form.CreatePart(map[string][]string{
+		"Content-Disposition": {`form-data; name="avatar"; filename="` + filename + `"`},
+		"Content-Type":        {"image/png"},
+	})
+	if err != nil {
+		t.Fatalf("CreatePart() error = %v", err)
+	}
+	_, _ = part.Write(content)
+	_ = form.Close()
+
+	req := httptest.NewRequest(http.MethodPost, "/avatar", &body)
+	req.Header.Set("Content-Type", form.FormDataContentType())
+	return req
+}
+
+func TestUploadAvatar(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	uploadDir := t.TempDir()
+	uploadCfg := &config.UploadConfig{StoragePath: uploadDir, MaxSize: 1 << 20}
+	user := &model.User{ID: uuid.New(), Username: "teacher"}
+	users := &avatarUserRepo{users: map[uuid.UUID]*model.User{user.ID: user}}
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	userService := service.NewUserService(users, nil, nil, nil, jwtManager, nil, nil, "", uploadCfg)
+	h := NewUserHandler(userService, nil, nil, nil, uploadCfg)
+
+	r := gin.New()
+	r.POST("/avatar", func(c *gin.Context) {
+		c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: user.ID.String()})
+		c.Next()
+	}, h.UploadAvatar)
+
+	upload := func(t *testing.T, filename string, content []byte) *httptest.ResponseRecorder {
+		t.Helper()
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, avatarUploadRequest(t, filename, content))
+		return w
+	}
+	avatarFiles := func(t *testing.T) []string {
+		t.Helper()
+		entries, _ := os.ReadDir(service.AvatarDir(uploadDir))
+		names := make([]string, 0, len(entries))
+		for _, entry := range entries {
+			names = append(names, entry.Name())
+		}
+		return names
+	}
+
+	var first string
+	t.Run("saved to disk", func(t *testing.T) {
+		w := upload(t, "me.png", pngHeader)
+		if w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		var resp struct {
+			Data struct {
+				AvatarURL string `json:"avatar_url"`
+			} `json:"data"`
+		}
+		_ = json.Unmarshal(w.Body.Bytes(), &resp)
+		first = resp.Data.AvatarURL
+
+		// 文件名由服务端生成，不沿用客户端文件名
+		name := strings.TrimPrefix(first, "/uploads/avatars/")
+		if name == first || name == "me.png" || filepath.Ext(name) != ".png" {
+			t.Fatalf("avatar_url = %q, want a generated .png name under /uploads/avatars/", first)
+		}
+		saved, err := os.ReadFile(filepath.Join(service.AvatarDir(uploadDir), name))
+		if err != nil || !bytes.Equal(saved, pngHeader) {
+			t.Errorf("saved file = %d bytes (err %v), want the uploaded content", len(saved), err)
+		}
+		if users.users[user.ID].AvatarURL != first {
+			t.Errorf("user avatar = %q, want %q", users.users[user.ID].AvatarURL, first)
+		}
+	})
+
+	t.Run("declared type not trusted", func(t *testing.T) {
+		w := upload(t, "fake.png", []byte("<html><script>alert(1)</script></html>"))
+		if w.Code != http.StatusUnsupportedMediaType {
+			t.Fatalf("status = %d, want 415: %s", w.Code, w.Body.String())
+		}
+		if files := avatarFiles(t); len(files) != 1 {
+			t.Errorf("avatar files = %v, want only the first upload", files)
+		}
+		if users.users[user.ID].AvatarURL != first {
+			t.Errorf("user avatar = %q, want unchanged %q", users.users[user.ID].AvatarURL, first)
+		}
+	})
+
+	t.Run("replacing removes old file", func(t *testing.T) {
+		if w := upload(t, "new.png", pngHeader); w.Code != http.StatusOK {
+			t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+		}
+		files := avatarFiles(t)
+		if len(files) != 1 || "/uploads/avatars/"+files[0] == first {
+			t.Errorf("avatar files = %v, want only the new avatar", files)
+		}
+	})
+}
+
+// exportUserService 记录导出请求的用户，返回带凭据字段的数据以检查序列化
+type exportUserService struct {
+	service.UserService
+	requested uuid.UUID
+}
+
+func (s *exportUserService) ExportData(ctx context.Context, id uuid.UUID) (*model.UserDataExport, error) {
+	s.requested = id
+	if id == uuid.Nil {
+		return nil, service.ErrUserNotFound
+	}
+	return &model.UserDataExport{
+		ExportedAt: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC),
+		Profile:    &model.User{ID: id, Username: "teacher", PasswordHash: "$2a$10$secret-hash"},
+		APIKeys:    []model.APIKey{{UserID: id, Name: "脚本", KeyHash: "secret-key-hash"}},
+		Lessons:    []model.Lesson{{UserID: id, Title: "有理数"}},
+	}, nil
+}
+
+func TestExportData(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	userID := uuid.New()
+	svc := &exportUserService{}
+	h := NewUserHandler(svc, nil, nil, nil, nil)
+	r := gin.New()
+	r.GET("/users/me/data-export", func(c *gin.Context) {
+		c.Set(middleware.AuthorizationPayloadKey, &jwt.Claims{UserID: userID.String()})
+		c.Next()
+	}, h.ExportData)
+
+	w := httptest.NewRecorder()
+	r.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/users/me/data-export?user_id="+uuid.NewString(), nil))
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200: %s", w.Code, w.Body.String())
+	}
+	// 只导出当前登录用户，忽略请求中指定的其他用户
+	if svc.requested != userID {
+		t.Errorf("ExportData called for %s, want caller %s", svc.requested, userID)
+	}
+	if cd := w.Header().Get("Content-Disposition"); !strings.HasPrefix(cd, "attachment;") || !strings.Contains(cd, "20261016.json") {
+		t.Errorf("Content-Disposition = %q, want a dated JSON attachment", cd)
+	}
+	if cc := w.Header().Get("Cache-Control"); cc != "no-store" {
+		t.Errorf("Cache-Control = %q, want no-store", cc)
+	}
+
+	body := w.Body.String()
+	for _, secret := range []string{"secret-hash", "secret-key-hash"} {
+		if strings.Contains(body, secret) {
+			t.Errorf("export body contains credential %q: %s", secret, body)
+		}
+	}
+	var resp struct {
+		Data struct {
+			Profile struct {
+				ID string `json:"id"`
+			} `json:"profile"`
+			Lessons []json.RawMessage `json:"lessons"`
+			APIKeys []json.RawMessage `json:"api_keys"`
+		} `json:"data"`
+	}
+	if err := json.Unmarshal(w.Body.Bytes(), &resp); err != nil || resp.Data.Profile.ID != userID.String() ||
+		len(resp.Data.Lessons) != 1 || len(resp.Data.APIKeys) != 1 {
+		t.Errorf("body = %s, want the caller's profile, lesson and API key metadata", body)
+	}
+}
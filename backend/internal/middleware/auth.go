@@ -1,9 +1,15 @@
 package middleware
 
 import (
+	"context"
+	"net/http"
 	"strings"
+	"time"
 
+	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,11 +18,70 @@ const (
 	AuthorizationHeaderKey  = "Authorization"
 	AuthorizationTypeBearer = "Bearer"
 	AuthorizationPayloadKey = "authorization_payload"
+
+	// APIKeyHeader 第三方集成使用的 API Key 请求头
+	APIKeyHeader     = "X-API-Key"
+	APIKeyPayloadKey = "api_key_payload"
 )
 
-// AuthMiddleware 认证中间件
-func AuthMiddleware(jwtManager *jwt.Manager) gin.HandlerFunc {
+// APIKeyAuthenticator 校验 API Key 明文并返回绑定的用户与权限范围
+type APIKeyAuthenticator interface {
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKeyIdentity, error)
+}
+
+// TokenDenylist 已登出令牌的黑名单，按 jti 查询；另按用户查询整体吊销（如封禁）
+type TokenDenylist interface {
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error)
+}
+
+// isTokenRevoked 令牌是否已登出或所属用户的令牌已被整体吊销；黑名单不可用时放行并记录日志，避免缓存故障导致全部请求无法认证
+func isTokenRevoked(c *gin.Context, denylist TokenDenylist, claims *jwt.Claims) bool {
+	if denylist == nil {
+		return false
+	}
+	ctx := c.Request.Context()
+
+	if claims.ID != "" {
+		revoked, err := denylist.IsRevoked(ctx, claims.ID)
+		if err != nil {
+			logger.Warn("Token denylist lookup failed", logger.String("trace_id", TraceIDFromGin(c)), logger.Err(err))
+		} else if revoked {
+			return true
+		}
+	}
+
+	// 不带签发时间的令牌按最早签发处理，用户被吊销时一并失效
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	revoked, err := denylist.IsUserRevoked(ctx, claims.UserID, issuedAt)
+	if err != nil {
+		logger.Warn("User token revocation lookup failed", logger.String("trace_id", TraceIDFromGin(c)), logger.Err(err))
+		return false
+	}
+	return revoked
+}
+
+// AuthMiddleware 认证中间件：支持 Bearer 登录令牌，apiKeys 不为空时也接受 X-API-Key；denylist 不为空时拒绝已登出的令牌
+func AuthMiddleware(jwtManager *jwt.Manager, apiKeys APIKeyAuthenticator, denylist TokenDenylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := strings.TrimSpace(c.GetHeader(APIKeyHeader)); rawKey != "" && apiKeys != nil {
+			identity, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), rawKey)
+			if err != nil {
+				abortWithError(c, 401, "AUTH_INVALID_API_KEY", "无效的 API Key", err.Error())
+				return
+			}
+			if scope := methodScope(c.Request.Method); !identity.HasScope(scope) {
+				abortWithError(c, 403, "AUTH_API_KEY_SCOPE", "API Key 缺少 "+scope+" 权限", nil)
+				return
+			}
+			bindAPIKeyIdentity(c, identity)
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader(AuthorizationHeaderKey)
 		if authHeader == "" {
 			abortWithError(c, 401, "AUTH_MISSING_HEADER", "缺少认证头", nil)
@@ -41,15 +106,29 @@ func AuthMiddleware(jwtManager *jwt.Manager) gin.HandlerFunc {
 			abortWithError(c, 401, "AUTH_INVALID_TOKEN", "无效的令牌", err.Error())
 			return
 		}
+		if isTokenRevoked(c, denylist, claims) {
+			abortWithError(c, 401, "AUTH_TOKEN_REVOKED", "令牌已失效，请重新登录", nil)
+			return
+		}
 
 		c.Set(AuthorizationPayloadKey, claims)
+		bindClaimsTenant(c, claims)
 		c.Next()
 	}
 }
 
-// OptionalAuthMiddleware 可选认证中间件
-func OptionalAuthMiddleware(jwtManager *jwt.Manager) gin.HandlerFunc {
+// OptionalAuthMiddleware 可选认证中间件，凭证无效时按匿名访问处理
+func OptionalAuthMiddleware(jwtManager *jwt.Manager, apiKeys APIKeyAuthenticator, denylist TokenDenylist) gin.HandlerFunc {
 	return func(c *gin.Context) {
+		if rawKey := strings.TrimSpace(c.GetHeader(APIKeyHeader)); rawKey != "" && apiKeys != nil {
+			identity, err := apiKeys.AuthenticateAPIKey(c.Request.Context(), rawKey)
+			if err == nil && identity.HasScope(methodScope(c.Request.Method)) {
+				bindAPIKeyIdentity(c, identity)
+			}
+			c.Next()
+			return
+		}
+
 		authHeader := c.GetHeader(AuthorizationHeaderKey)
 		if authHeader == "" {
 			c.Next()
@@ -70,16 +149,82 @@ func OptionalAuthMiddleware(jwtManager *jwt.Manager) gin.HandlerFunc {
 
 		accessToken := fields[1]
 		claims, err := jwtManager.ValidateToken(accessToken)
-		if err != nil {
+		if err != nil || isTokenRevoked(c, denylist, claims) {
 			c.Next()
 			return
 		}
 
 		c.Set(AuthorizationPayloadKey, claims)
+		bindClaimsTenant(c, claims)
 		c.Next()
 	}
 }
 
+// bindClaimsTenant 已认证请求以令牌中的租户为准；旧令牌不带租户时归入默认租户
+func bindClaimsTenant(c *gin.Context, claims *jwt.Claims) {
+	tenantID := claims.TenantID
+	if tenantID == "" {
+		tenantID = tenant.DefaultTenant
+	}
+	bindTenant(c, tenantID)
+}
+
+// bindAPIKeyIdentity 写入 API Key 认证结果：Claims 与登录令牌一致，下游处理器无需区分认证方式；租户以 Key 所属租户为准
+func bindAPIKeyIdentity(c *gin.Context, identity *model.APIKeyIdentity) {
+	claims := &jwt.Claims{
+		UserID:   identity.UserID,
+		Username: identity.Username,
+		Email:    identity.Email,
+		Role:     identity.Role,
+		TenantID: identity.TenantID,
+	}
+	c.Set(AuthorizationPayloadKey, claims)
+	c.Set(APIKeyPayloadKey, identity)
+	bindClaimsTenant(c, claims)
+}
+
+// methodScope 请求方法对应的 API Key 基础权限：只读请求需要 read，其余需要 write
+func methodScope(method string) string {
+	switch method {
+	case http.MethodGet, http.MethodHead, http.MethodOptions:
+		return model.APIKeyScopeRead
+	default:
+		return model.APIKeyScopeWrite
+	}
+}
+
+// RequireAPIKeyScope 通过 API Key 认证的请求还需具备指定权限，登录令牌不受限制
+func RequireAPIKeyScope(scope string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if identity, ok := GetAPIKeyIdentity(c); ok && !identity.HasScope(scope) {
+			abortWithError(c, 403, "AUTH_API_KEY_SCOPE", "API Key 缺少 "+scope+" 权限", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// DenyAPIKey 拒绝 API Key 访问，用于 API Key 管理、修改密码等只允许本人登录操作的接口
+func DenyAPIKey() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if _, ok := GetAPIKeyIdentity(c); ok {
+			abortWithError(c, 403, "AUTH_API_KEY_FORBIDDEN", "该接口不支持 API Key 访问，请登录后操作", nil)
+			return
+		}
+		c.Next()
+	}
+}
+
+// GetAPIKeyIdentity 获取 API Key 认证结果，登录令牌认证的请求返回 false
+func GetAPIKeyIdentity(c *gin.Context) (*model.APIKeyIdentity, bool) {
+	value, exists := c.Get(APIKeyPayloadKey)
+	if !exists {
+		return nil, false
+	}
+	identity, ok := value.(*model.APIKeyIdentity)
+	return identity, ok
+}
+
 // RoleMiddleware 角色中间件
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
@@ -40,6 +40,9 @@ func DefaultCORSConfig() CORSConfig {
 			"X-Requested-With",
 			"X-Trace-ID",
 			"X-Request-ID",
+			"X-Confirm-Token",
+			"X-API-Key",
+			"X-Device-ID",
 			"X-Generation-Api-Key",
 			"X-Embedding-Api-Key",
 		},
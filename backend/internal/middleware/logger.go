@@ -3,7 +3,6 @@ package middleware
 import (
 	"time"
 
-	"lesson-plan/backend/internal/observability"
 	"lesson-plan/backend/pkg/logger"
 
 	"github.com/gin-gonic/gin"
@@ -36,8 +35,6 @@ func LoggerMiddleware() gin.HandlerFunc {
 			route = c.Request.URL.Path
 		}
 
-		observability.RecordHTTPRequest(method, route, statusCode, latency)
-
 		// 根据状态码选择日志级别
 		logFunc := logger.Info
 		if statusCode >= 400 && statusCode < 500 {
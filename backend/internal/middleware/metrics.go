@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/observability"
+
+	"github.com/gin-gonic/gin"
+)
+
+// unmatchedRoute 未匹配到路由的请求统一归入该标签，避免按原始路径产生无界的指标维度
+const unmatchedRoute = "UNMATCHED"
+
+// metricsSkipPrefixes 指标抓取与健康检查请求频繁且无业务意义，不计入指标
+var metricsSkipPrefixes = []string{"/metrics", "/health"}
+
+// MetricsMiddleware 指标中间件：按路由模板（如 /api/v1/lessons/:id）记录请求量与延迟
+func MetricsMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		start := time.Now()
+
+		c.Next()
+
+		route := c.FullPath()
+		if skipMetrics(route) {
+			return
+		}
+		if route == "" {
+			route = unmatchedRoute
+		}
+		observability.RecordHTTPRequest(c.Request.Method, route, c.Writer.Status(), time.Since(start))
+	}
+}
+
+func skipMetrics(route string) bool {
+	for _, prefix := range metricsSkipPrefixes {
+		if route == prefix || strings.HasPrefix(route, prefix+"/") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,76 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/observability"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestMetricsMiddlewareRecordsRouteTemplates(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	// 指标为进程级全局状态，路由带上唯一前缀避免与其他测试相互影响
+	prefix := "/metrics-mw-" + strconv.FormatInt(time.Now().UnixNano(), 10)
+	r := gin.New()
+	r.Use(MetricsMiddleware())
+	r.GET(prefix+"/lessons/:id", func(c *gin.Context) {
+		if c.Param("id") == "missing" {
+			c.Status(http.StatusNotFound)
+			return
+		}
+		c.Status(http.StatusOK)
+	})
+	r.GET("/metrics", func(c *gin.Context) { c.Status(http.StatusOK) })
+	r.GET("/health", func(c *gin.Context) { c.Status(http.StatusOK) })
+
+	before := observability.SnapshotMetrics()
+	for _, path := range []string{
+		prefix + "/lessons/1", prefix + "/lessons/2", prefix + "/lessons/missing",
+		"/metrics", "/health", prefix + "/unknown",
+	} {
+		r.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	after := observability.SnapshotMetrics()
+
+	// 不同 id 归入同一路由模板
+	bucket := after.Routes["GET "+prefix+"/lessons/:id"]
+	if bucket.Count != 3 || bucket.ErrorCount != 1 {
+		t.Errorf("route bucket = %+v, want 3 requests with 1 error", bucket)
+	}
+	for _, key := range []string{"GET /metrics", "GET /health"} {
+		if after.Routes[key].Count != before.Routes[key].Count {
+			t.Errorf("%s counted = %d, want skipped", key, after.Routes[key].Count-before.Routes[key].Count)
+		}
+	}
+	if got := after.Routes["GET "+unmatchedRoute].Count - before.Routes["GET "+unmatchedRoute].Count; got != 1 {
+		t.Errorf("unmatched requests = %d, want 1 under %s", got, unmatchedRoute)
+	}
+	if got := after.Summary.TotalRequests - before.Summary.TotalRequests; got != 4 {
+		t.Errorf("total requests = %d, want 4 (metrics and health skipped)", got)
+	}
+}
+
+func TestSkipMetrics(t *testing.T) {
+	tests := []struct {
+		route string
+		want  bool
+	}{
+		{route: "/metrics", want: true},
+		{route: "/metrics/json", want: true},
+		{route: "/health", want: true},
+		{route: "/healthz", want: false},
+		{route: "/api/v1/metrics", want: false},
+		{route: "", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := skipMetrics(tt.route); got != tt.want {
+			t.Errorf("skipMetrics(%q) = %v, want %v", tt.route, got, tt.want)
+		}
+	}
+}
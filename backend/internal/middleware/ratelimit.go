@@ -2,12 +2,24 @@ package middleware
 
 import (
 	"net/http"
+	"strings"
 	"sync"
 	"time"
 
+	"lesson-plan/backend/pkg/logger"
+
 	"github.com/gin-gonic/gin"
 )
 
+const (
+	// DeviceIDHeader 客户端设备指纹请求头，未携带时按 IP 识别设备
+	DeviceIDHeader = "X-Device-ID"
+	// maxDeviceIDLength 设备指纹最大长度，超长部分截断
+	maxDeviceIDLength = 128
+	// maxKeyedLimiters 分桶数量超过该值时清理已回满的空闲桶，避免伪造键撑爆内存
+	maxKeyedLimiters = 10000
+)
+
 // RateLimiter 限流器接口
 type RateLimiter interface {
 	Allow(c *gin.Context) bool
@@ -87,10 +99,114 @@ func (l *IPRateLimiter) Allow(c *gin.Context) bool {
 	return limiter.Allow(c)
 }
 
-// RateLimitMiddleware 限流中间件
+// KeyedRateLimiter 按自定义键分桶的限流器，用于按用户、设备等粒度限流
+type KeyedRateLimiter struct {
+	limiters map[string]*TokenBucketLimiter
+	rate     float64
+	size     int
+	keyFunc  func(c *gin.Context) string
+	mu       sync.Mutex
+}
+
+// NewKeyedRateLimiter 创建按键分桶的限流器
+func NewKeyedRateLimiter(rate float64, bucketSize int, keyFunc func(c *gin.Context) string) *KeyedRateLimiter {
+	return &KeyedRateLimiter{
+		limiters: make(map[string]*TokenBucketLimiter),
+		rate:     rate,
+		size:     bucketSize,
+		keyFunc:  keyFunc,
+	}
+}
+
+// Allow 检查是否允许请求
+func (l *KeyedRateLimiter) Allow(c *gin.Context) bool {
+	key := l.keyFunc(c)
+
+	l.mu.Lock()
+	limiter, exists := l.limiters[key]
+	if !exists {
+		if len(l.limiters) >= maxKeyedLimiters {
+			l.pruneIdleLocked(time.Now())
+		}
+		limiter = NewTokenBucketLimiter(l.rate, l.size)
+		l.limiters[key] = limiter
+	}
+	l.mu.Unlock()
+
+	return limiter.Allow(c)
+}
+
+// pruneIdleLocked 删除令牌已回满的桶，这些键重新出现时与新建桶等价
+func (l *KeyedRateLimiter) pruneIdleLocked(now time.Time) {
+	for key, limiter := range l.limiters {
+		limiter.mu.Lock()
+		full := limiter.tokens+now.Sub(limiter.lastTime).Seconds()*limiter.rate >= float64(limiter.bucketSize)
+		limiter.mu.Unlock()
+		if full {
+			delete(l.limiters, key)
+		}
+	}
+}
+
+// UserRateLimitKey 已认证请求按用户分桶，匿名请求按 IP 分桶
+func UserRateLimitKey(c *gin.Context) string {
+	if userID, ok := GetCurrentUserID(c); ok {
+		return "user:" + userID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+// DeviceRateLimitKey 按设备指纹分桶，未携带指纹时按 IP 分桶
+func DeviceRateLimitKey(c *gin.Context) string {
+	if deviceID := requestDeviceID(c); deviceID != "" {
+		return "device:" + deviceID
+	}
+	return "ip:" + c.ClientIP()
+}
+
+func requestDeviceID(c *gin.Context) string {
+	deviceID := strings.TrimSpace(c.GetHeader(DeviceIDHeader))
+	if len(deviceID) > maxDeviceIDLength {
+		deviceID = deviceID[:maxDeviceIDLength]
+	}
+	return deviceID
+}
+
+// AbuseGuardMiddleware 防刷中间件：任一限流器拒绝即返回 429，并记录疑似刷量的请求特征；白名单 IP 不计数
+func AbuseGuardMiddleware(action string, limiters ...RateLimiter) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		if isRateLimitExempt(c) {
+			c.Next()
+			return
+		}
+		for _, limiter := range limiters {
+			if limiter.Allow(c) {
+				continue
+			}
+
+			userID, _ := GetCurrentUserID(c)
+			logger.Warn("Suspected abuse rate limited",
+				logger.String("trace_id", TraceIDFromGin(c)),
+				logger.String("action", action),
+				logger.String("user_id", userID),
+				logger.String("client_ip", c.ClientIP()),
+				logger.String("device_id", requestDeviceID(c)),
+				logger.String("path", c.Request.URL.Path),
+			)
+			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
+				"code":    429,
+				"message": "操作过于频繁，请稍后再试",
+			})
+			return
+		}
+		c.Next()
+	}
+}
+
+// RateLimitMiddleware 限流中间件，白名单 IP 不计数
 func RateLimitMiddleware(limiter RateLimiter) gin.HandlerFunc {
 	return func(c *gin.Context) {
-		if !limiter.Allow(c) {
+		if !isRateLimitExempt(c) && !limiter.Allow(c) {
 			c.AbortWithStatusJSON(http.StatusTooManyRequests, gin.H{
 				"code":    429,
 				"message": "请求过于频繁，请稍后再试",
@@ -106,3 +222,8 @@ func NewRateLimitMiddleware(rate float64, bucketSize int) gin.HandlerFunc {
 	limiter := NewIPRateLimiter(rate, bucketSize)
 	return RateLimitMiddleware(limiter)
 }
+
+// NewKeyedRateLimitMiddleware 创建按键分桶的限流中间件，用于给路由组单独挂载限流
+func NewKeyedRateLimitMiddleware(rate float64, bucketSize int, keyFunc func(c *gin.Context) string) gin.HandlerFunc {
+	return RateLimitMiddleware(NewKeyedRateLimiter(rate, bucketSize, keyFunc))
+}
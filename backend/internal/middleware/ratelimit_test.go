@@ -0,0 +1,132 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestLikeRouter 按路由中的点赞防刷配置挂载：同一用户、同一设备各自限流
+func newTestLikeRouter(jwtManager *jwt.Manager, burst int) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	rate := 1.0 / 60
+	guard := AbuseGuardMiddleware("lesson_like",
+		NewKeyedRateLimiter(rate, burst, UserRateLimitKey),
+		NewKeyedRateLimiter(rate, burst, DeviceRateLimitKey),
+	)
+	r.POST("/lessons/:id/like", AuthMiddleware(jwtManager, nil, nil), guard, func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	return r
+}
+
+func TestLikeAbuseGuard(t *testing.T) {
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	tokenFor := func(userID string) string {
+		token, _, err := jwtManager.GenerateAccessToken(userID, "teacher", userID+"@example.com", "user", "")
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		return token
+	}
+
+	type like struct {
+		user     string
+		device   string
+		ip       string
+		wantCode int
+	}
+	tests := []struct {
+		name  string
+		likes []like
+	}{
+		{
+			name: "fourth like within window is rejected",
+			likes: []like{
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "switching devices does not reset the user bucket",
+			likes: []like{
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d2", ip: "10.0.0.2", wantCode: http.StatusOK},
+				{user: "u1", device: "d3", ip: "10.0.0.3", wantCode: http.StatusOK},
+				{user: "u1", device: "d4", ip: "10.0.0.4", wantCode: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "switching accounts does not reset the device bucket",
+			likes: []like{
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u2", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u3", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u4", device: "d1", ip: "10.0.0.1", wantCode: http.StatusTooManyRequests},
+			},
+		},
+		{
+			name: "without fingerprint the device falls back to ip",
+			likes: []like{
+				{user: "u1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u2", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u3", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u4", ip: "10.0.0.1", wantCode: http.StatusTooManyRequests},
+				{user: "u5", ip: "10.0.0.2", wantCode: http.StatusOK},
+			},
+		},
+		{
+			name: "distinct users and devices are independent",
+			likes: []like{
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u1", device: "d1", ip: "10.0.0.1", wantCode: http.StatusOK},
+				{user: "u2", device: "d2", ip: "10.0.0.2", wantCode: http.StatusOK},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			r := newTestLikeRouter(jwtManager, 3)
+			for i, l := range tt.likes {
+				req := httptest.NewRequest(http.MethodPost, "/lessons/l-1/like", nil)
+				req.RemoteAddr = l.ip + ":12345"
+				req.Header.Set("Authorization", "Bearer "+tokenFor(l.user))
+				if l.device != "" {
+					req.Header.Set(DeviceIDHeader, l.device)
+				}
+				w := httptest.NewRecorder()
+				r.ServeHTTP(w, req)
+				if w.Code != l.wantCode {
+					t.Fatalf("like #%d (%s/%s) status = %d, want %d", i+1, l.user, l.device, w.Code, l.wantCode)
+				}
+			}
+		})
+	}
+}
+
+func TestLikeAbuseGuardSkipsExemptRequests(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(func(c *gin.Context) { c.Set(rateLimitExemptKey, true) })
+	r.POST("/like", AbuseGuardMiddleware("lesson_like", NewKeyedRateLimiter(1.0/60, 1, DeviceRateLimitKey)), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+
+	for i := 0; i < 3; i++ {
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(http.MethodPost, "/like", nil))
+		if w.Code != http.StatusOK {
+			t.Fatalf("exempt like #%d status = %d, want 200", i+1, w.Code)
+		}
+	}
+}
@@ -0,0 +1,75 @@
+package middleware
+
+import (
+	"net"
+	"regexp"
+	"strings"
+
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+const tenantIDKey = "tenant_id"
+
+var tenantIDPattern = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// TenantMiddleware 解析请求所属租户并写入 request context。
+// 匿名请求按访问域名在 hosts（主机名 → 租户）中查找租户，未匹配时使用默认租户；
+// 客户端不能通过请求头自选租户，否则匿名注册即可加入任意学校的租户。
+// 已认证请求随后由认证中间件以 JWT 或 API Key 所属租户覆盖。
+func TenantMiddleware(defaultTenant string, hosts map[string]string) gin.HandlerFunc {
+	if strings.TrimSpace(defaultTenant) == "" {
+		defaultTenant = tenant.DefaultTenant
+	}
+
+	tenantByHost := make(map[string]string, len(hosts))
+	for host, tenantID := range hosts {
+		host = normalizeHost(host)
+		tenantID = strings.TrimSpace(tenantID)
+		if host == "" || !tenantIDPattern.MatchString(tenantID) {
+			logger.Warn("Ignoring invalid tenant host mapping",
+				logger.String("host", host),
+				logger.String("tenant_id", tenantID),
+			)
+			continue
+		}
+		tenantByHost[host] = tenantID
+	}
+
+	return func(c *gin.Context) {
+		tenantID, ok := tenantByHost[normalizeHost(c.Request.Host)]
+		if !ok {
+			tenantID = defaultTenant
+		}
+
+		bindTenant(c, tenantID)
+		c.Next()
+	}
+}
+
+// normalizeHost 去掉端口并转为小写，便于按主机名匹配
+func normalizeHost(host string) string {
+	host = strings.TrimSpace(host)
+	if h, _, err := net.SplitHostPort(host); err == nil {
+		host = h
+	}
+	return strings.ToLower(strings.TrimSuffix(host, "."))
+}
+
+// bindTenant 绑定租户到 gin context 和 request context
+func bindTenant(c *gin.Context, tenantID string) {
+	c.Set(tenantIDKey, tenantID)
+	c.Request = c.Request.WithContext(tenant.WithTenant(c.Request.Context(), tenantID))
+}
+
+// GetCurrentTenantID 获取当前请求所属租户
+func GetCurrentTenantID(c *gin.Context) string {
+	if value, ok := c.Get(tenantIDKey); ok {
+		if tenantID, ok := value.(string); ok {
+			return tenantID
+		}
+	}
+	return tenant.FromContext(c.Request.Context())
+}
@@ -0,0 +1,89 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/gin-gonic/gin"
+)
+
+// newTestTenantRouter 返回请求最终所属租户（gin context 与 request context 各一份）
+func newTestTenantRouter(jwtManager *jwt.Manager) *gin.Engine {
+	gin.SetMode(gin.TestMode)
+	r := gin.New()
+	r.Use(TenantMiddleware("", map[string]string{
+		"School-A.example.com": "school-a",
+		"bad.example.com":      "bad tenant",
+	}))
+	echo := func(c *gin.Context) {
+		c.String(http.StatusOK, GetCurrentTenantID(c)+"|"+tenant.FromContext(c.Request.Context()))
+	}
+	r.GET("/public", echo)
+	r.GET("/private", AuthMiddleware(jwtManager, nil, nil), echo)
+	return r
+}
+
+func TestTenantResolution(t *testing.T) {
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	tokenFor := func(tenantID string) string {
+		token, _, err := jwtManager.GenerateAccessToken("user-1", "teacher", "t@example.com", "user", tenantID)
+		if err != nil {
+			t.Fatalf("GenerateAccessToken() error = %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name   string
+		path   string
+		host   string
+		header map[string]string
+		want   string
+	}{
+		{name: "unknown host uses default tenant", path: "/public", host: "lesson.example.com", want: "default|default"},
+		{name: "host mapping ignores case and port", path: "/public", host: "school-a.EXAMPLE.com:8080", want: "school-a|school-a"},
+		{name: "invalid mapping is ignored", path: "/public", host: "bad.example.com", want: "default|default"},
+		{
+			name:   "anonymous caller cannot pick a tenant by header",
+			path:   "/public",
+			host:   "lesson.example.com",
+			header: map[string]string{"X-Tenant-ID": "school-a"},
+			want:   "default|default",
+		},
+		{
+			name:   "token tenant wins over host",
+			path:   "/private",
+			host:   "school-a.example.com",
+			header: map[string]string{AuthorizationHeaderKey: "Bearer " + tokenFor("school-b")},
+			want:   "school-b|school-b",
+		},
+		{
+			name:   "legacy token without tenant falls back to default",
+			path:   "/private",
+			host:   "school-a.example.com",
+			header: map[string]string{AuthorizationHeaderKey: "Bearer " + tokenFor("")},
+			want:   "default|default",
+		},
+	}
+
+	r := newTestTenantRouter(jwtManager)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			req.Host = tt.host
+			for k, v := range tt.header {
+				req.Header.Set(k, v)
+			}
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != http.StatusOK || w.Body.String() != tt.want {
+				t.Errorf("got %d %q, want 200 %q", w.Code, w.Body.String(), tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package model
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// 年级标准值：1-12 对应一年级至十二年级（高三），0 表示无法识别。
+// Lesson.Grade 保留用户填写的原始写法用于展示，筛选与排序统一使用标准值。
+const (
+	GradeLevelUnknown = 0
+	GradeLevelMin     = 1
+	GradeLevelMax     = 12
+)
+
+var gradeLabels = [...]string{
+	"", "一年级", "二年级", "三年级", "四年级", "五年级", "六年级",
+	"七年级", "八年级", "九年级", "高一", "高二", "高三",
+}
+
+var (
+	// gradeTermSuffix 学期后缀：七年级上册、初一（下）、Grade 7 下学期
+	gradeTermSuffix = regexp.MustCompile(`(\(.*\)|（.*）|[上下](学期|册)?)$`)
+	// gradeChinese 七年级、7年级、初中一年级、小学三年级
+	gradeChinese = regexp.MustCompile(`^(小学|初中|高中)?([一二三四五六七八九十]+|\d{1,2})年级$`)
+	// gradeShort 初一、高三、小六，以及初1、高2
+	gradeShort = regexp.MustCompile(`^(小|初|高)([一二三四五六]|\d)$`)
+	// gradeEnglish grade7、g7、year7、7thgrade、7
+	gradeEnglish = regexp.MustCompile(`^(?:(?:grade|g|year|y)(\d{1,2})|(\d{1,2})(?:st|nd|rd|th)?(?:grade)?)$`)
+)
+
+// NormalizeGrade 将自由填写的年级归一化为标准值（1-12），无法识别时返回 GradeLevelUnknown。
+// 支持“七年级”“初一”“初中一年级”“高三”“Grade 7”“G7”“7th grade”等写法，忽略空白与上下册后缀。
+func NormalizeGrade(raw string) int {
+	s := compactGrade(raw)
+	if s == "" {
+		return GradeLevelUnknown
+	}
+	if trimmed := gradeTermSuffix.ReplaceAllString(s, ""); trimmed != "" {
+		s = trimmed
+	}
+
+	level := GradeLevelUnknown
+	if m := gradeChinese.FindStringSubmatch(s); m != nil {
+		level = parseGradeNumber(m[2])
+		switch m[1] {
+		case "初中":
+			// 初中一年级 = 七年级；“初中七年级”这类写法已是标准序号
+			if level >= 1 && level <= 3 {
+				level += 6
+			}
+		case "高中":
+			if level >= 1 && level <= 3 {
+				level += 9
+			} else if level < 10 {
+				level = GradeLevelUnknown
+			}
+		case "小学":
+			if level > 6 {
+				level = GradeLevelUnknown
+			}
+		}
+	} else if m := gradeShort.FindStringSubmatch(s); m != nil {
+		level = parseGradeNumber(m[2])
+		switch m[1] {
+		case "初":
+			level = shiftGrade(level, 3, 6)
+		case "高":
+			level = shiftGrade(level, 3, 9)
+		case "小":
+			level = shiftGrade(level, 6, 0)
+		}
+	} else if m := gradeEnglish.FindStringSubmatch(s); m != nil {
+		level = parseGradeNumber(firstGradeMatch(m[1], m[2]))
+	}
+
+	if level < GradeLevelMin || level > GradeLevelMax {
+		return GradeLevelUnknown
+	}
+	return level
+}
+
+// GradeLabel 标准值对应的规范名称，未知年级返回空字符串
+func GradeLabel(level int) string {
+	if level < GradeLevelMin || level > GradeLevelMax {
+		return ""
+	}
+	return gradeLabels[level]
+}
+
+// compactGrade 全角转半角、去空白并转小写
+func compactGrade(raw string) string {
+	var sb strings.Builder
+	for _, r := range raw {
+		switch {
+		case r == '　' || unicode.IsSpace(r):
+			continue
+		case r >= '！' && r <= '～':
+			r -= 0xFEE0
+		}
+		sb.WriteRune(unicode.ToLower(r))
+	}
+	return sb.String()
+}
+
+// shiftGrade 学段内序号转全学段序号，超出学段年限视为无法识别
+func shiftGrade(n, span, offset int) int {
+	if n < 1 || n > span {
+		return GradeLevelUnknown
+	}
+	return n + offset
+}
+
+func firstGradeMatch(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+	return ""
+}
+
+// parseGradeNumber 解析阿拉伯数字或一至十二的中文数字
+func parseGradeNumber(s string) int {
+	if n, err := strconv.Atoi(s); err == nil {
+		return n
+	}
+	digits := map[rune]int{'一': 1, '二': 2, '三': 3, '四': 4, '五': 5, '六': 6, '七': 7, '八': 8, '九': 9}
+	runes := []rune(s)
+	switch {
+	case len(runes) == 1 && runes[0] == '十':
+		return 10
+	case len(runes) == 1:
+		return digits[runes[0]]
+	case len(runes) == 2 && runes[0] == '十':
+		if d := digits[runes[1]]; d > 0 {
+			return 10 + d
+		}
+	}
+	return GradeLevelUnknown
+}
@@ -0,0 +1,102 @@
+package model
+
+import (
+	"sort"
+	"testing"
+)
+
+func TestNormalizeGrade(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want int
+	}{
+		{raw: "一年级", want: 1},
+		{raw: "小学三年级", want: 3},
+		{raw: "小六", want: 6},
+		{raw: "七年级", want: 7},
+		{raw: "7年级", want: 7},
+		{raw: "初一", want: 7},
+		{raw: "初1", want: 7},
+		{raw: "初中一年级", want: 7},
+		{raw: "初中八年级", want: 8},
+		{raw: "九年级下册", want: 9},
+		{raw: "初三（上）", want: 9},
+		{raw: "高一", want: 10},
+		{raw: "十年级", want: 10},
+		{raw: "高中二年级", want: 11},
+		{raw: "高三下学期", want: 12},
+		{raw: "Grade 7", want: 7},
+		{raw: "grade7", want: 7},
+		{raw: "G8", want: 8},
+		{raw: "Year 9", want: 9},
+		{raw: "7th grade", want: 7},
+		{raw: "1st", want: 1},
+		{raw: "１２", want: 12},
+		{raw: "  七 年 级 ", want: 7},
+		{raw: "", want: GradeLevelUnknown},
+		{raw: "学前班", want: GradeLevelUnknown},
+		{raw: "初四", want: GradeLevelUnknown},
+		{raw: "小学七年级", want: GradeLevelUnknown},
+		{raw: "高中五年级", want: GradeLevelUnknown},
+		{raw: "十三年级", want: GradeLevelUnknown},
+		{raw: "Grade 13", want: GradeLevelUnknown},
+		{raw: "0", want: GradeLevelUnknown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			if got := NormalizeGrade(tt.raw); got != tt.want {
+				t.Errorf("NormalizeGrade(%q) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGradeLabel(t *testing.T) {
+	tests := []struct {
+		level int
+		want  string
+	}{
+		{level: 1, want: "一年级"},
+		{level: 7, want: "七年级"},
+		{level: 10, want: "高一"},
+		{level: 12, want: "高三"},
+		{level: 0, want: ""},
+		{level: 13, want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := GradeLabel(tt.level); got != tt.want {
+			t.Errorf("GradeLabel(%d) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestGradeLevelSortOrder(t *testing.T) {
+	// 与列表的年级排序一致：按标准值升序，无法识别的排在最后，同一年级的不同写法相邻
+	raw := []string{"高一", "学前班", "Grade 7", "三年级", "初二", "七年级", "", "G12", "初一"}
+	sort.SliceStable(raw, func(i, j int) bool {
+		a, b := NormalizeGrade(raw[i]), NormalizeGrade(raw[j])
+		if (a == GradeLevelUnknown) != (b == GradeLevelUnknown) {
+			return b == GradeLevelUnknown
+		}
+		return a < b
+	})
+
+	want := []string{"三年级", "Grade 7", "七年级", "初一", "初二", "高一", "G12", "学前班", ""}
+	for i := range want {
+		if raw[i] != want[i] {
+			t.Fatalf("sorted = %q, want %q", raw, want)
+		}
+	}
+}
+
+func TestLessonBeforeSaveKeepsRawGrade(t *testing.T) {
+	lesson := &Lesson{Grade: "初一"}
+	if err := lesson.BeforeSave(nil); err != nil {
+		t.Fatalf("BeforeSave() error = %v", err)
+	}
+	if lesson.Grade != "初一" || lesson.GradeLevel != 7 {
+		t.Errorf("after BeforeSave grade = %q level = %d, want raw grade kept and level 7", lesson.Grade, lesson.GradeLevel)
+	}
+}
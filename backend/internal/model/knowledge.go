@@ -51,6 +51,8 @@ type KnowledgeGraph struct {
 	TypeCounts map[string]int  `json:"typeCounts"`
 	TotalNodes int             `json:"totalNodes"`
 	TotalEdges int             `json:"totalEdges"`
+	// AsOf 快照时间点，为空表示当前图谱
+	AsOf *time.Time `json:"asOf,omitempty"`
 }
 
 // KnowledgeNode 知识图谱节点
@@ -62,6 +64,35 @@ type KnowledgeNode struct {
 	Grade      string  `json:"grade"`
 	Difficulty string  `json:"difficulty"`
 	Importance float64 `json:"importance"`
+	Cluster    int     `json:"cluster,omitempty"`
+	// CreatedAt 节点创建时间，早期写入的节点为空
+	CreatedAt *time.Time `json:"createdAt,omitempty"`
+}
+
+// SimilarKnowledgeNode 语义相似的知识点
+type SimilarKnowledgeNode struct {
+	KnowledgeNode
+	// Score 向量相似度，越大越相似
+	Score float64 `json:"score"`
+	// Connected 是否与查询节点在图谱中直接相连
+	Connected bool `json:"connected"`
+}
+
+// KnowledgePath 两个知识点之间的最短路径，Found 为 false 时两点在限定跳数内不连通
+type KnowledgePath struct {
+	Found bool `json:"found"`
+	// Length 路径经过的关系数
+	Length int             `json:"length"`
+	Nodes  []KnowledgeNode `json:"nodes"`
+	Edges  []KnowledgeEdge `json:"edges"`
+}
+
+// KnowledgeCluster 知识图谱聚类簇
+type KnowledgeCluster struct {
+	ID      int      `json:"id"`
+	Label   string   `json:"label"`
+	Size    int      `json:"size"`
+	NodeIDs []string `json:"nodeIds"`
 }
 
 // KnowledgeEdge 知识图谱边
@@ -85,6 +116,7 @@ type KnowledgeSearchResult struct {
 type Generation struct {
 	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	TenantID    string     `gorm:"size:64;index;not null;default:'default'" json:"-"`
 	LessonID    *uuid.UUID `gorm:"type:uuid;index" json:"lesson_id,omitempty"`
 	Prompt      string     `gorm:"type:text;not null" json:"prompt"`
 	Parameters  string     `gorm:"type:jsonb" json:"parameters"`
@@ -95,6 +127,18 @@ type Generation struct {
 	ErrorMsg    string     `gorm:"type:text" json:"error_msg,omitempty"`
 	CreatedAt   time.Time  `json:"created_at"`
 	CompletedAt *time.Time `json:"completed_at,omitempty"`
+
+	// LessonVersion 由本次生成结果创建教案时教案的初始版本号，与 LessonID 一同写入
+	LessonVersion *int `json:"lesson_version,omitempty"`
+
+	// Events 生成各阶段事件（GenerationEvent 的 JSON 数组），用于分析耗时集中在哪个阶段
+	Events string `gorm:"type:jsonb;not null;default:'[]'" json:"events"`
+
+	// ReviewReason 内容审核未通过的原因，仅 review 状态有值
+	ReviewReason string `gorm:"type:text" json:"review_reason,omitempty"`
+
+	// RetryOf 由重试产生时指向被重试的原生成记录
+	RetryOf *uuid.UUID `gorm:"type:uuid;index" json:"retry_of,omitempty"`
 }
 
 // TableName 表名
@@ -102,24 +146,95 @@ func (Generation) TableName() string {
 	return "generations"
 }
 
+// GenerationPreset 生成参数预设（快捷配置），同一用户下名称唯一
+type GenerationPreset struct {
+	ID         uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_generation_presets_user_name;not null" json:"user_id"`
+	Name       string    `gorm:"size:50;uniqueIndex:idx_generation_presets_user_name;not null" json:"name"`
+	Subject    string    `gorm:"size:50" json:"subject"`
+	Grade      string    `gorm:"size:20" json:"grade"`
+	Duration   int       `gorm:"default:45" json:"duration"`
+	Style      string    `gorm:"size:50" json:"style"`
+	Difficulty string    `gorm:"size:20" json:"difficulty"`
+	CreatedAt  time.Time `json:"created_at"`
+	UpdatedAt  time.Time `json:"updated_at"`
+}
+
+// TableName 表名
+func (GenerationPreset) TableName() string {
+	return "generation_presets"
+}
+
 // 生成状态
 const (
 	GenerationStatusPending    = "pending"
 	GenerationStatusProcessing = "processing"
 	GenerationStatusCompleted  = "completed"
 	GenerationStatusFailed     = "failed"
+	// GenerationStatusFallback 仅用于响应：Agent 不可用，返回的是待填写的教案骨架
+	GenerationStatusFallback = "fallback"
+	// GenerationStatusReview 生成内容未通过安全审核，等待人工复核，复核前不向用户展示结果
+	GenerationStatusReview = "review"
+)
+
+// 生成阶段，按发生顺序排列
+const (
+	GenerationStageStarted   = "started"    // 收到生成请求
+	GenerationStageAgentCall = "agent_call" // 开始调用 Agent
+	GenerationStageAgentDone = "agent_done" // Agent 返回并完成响应解码
+	GenerationStageParsed    = "parsed"     // 生成结果解析完成
+	GenerationStageSaved     = "saved"      // 生成结果已保存
+	GenerationStageFailed    = "failed"     // 生成失败，Detail 为错误信息
+)
+
+// GenerationEvent 生成阶段事件，ElapsedMs 为距 started 的毫秒数
+type GenerationEvent struct {
+	Stage     string    `json:"stage"`
+	At        time.Time `json:"at"`
+	ElapsedMs int64     `json:"elapsed_ms"`
+	Detail    string    `json:"detail,omitempty"`
+}
+
+// 教案详略级别
+const (
+	GenerationDetailBrief    = "brief"
+	GenerationDetailStandard = "standard"
+	GenerationDetailDetailed = "detailed"
+)
+
+// 课型
+const (
+	GenerationLessonTypeNew        = "new"
+	GenerationLessonTypeReview     = "review"
+	GenerationLessonTypeExperiment = "experiment"
 )
 
 // GenerationRequest 生成请求
 type GenerationRequest struct {
-	Subject    string   `json:"subject" binding:"required"`
-	Grade      string   `json:"grade" binding:"required"`
+	// Subject 学科，跨学科教案可用“、”“+”等分隔多个学科，首个为主学科；未填写时使用用户设置中的默认学科
+	Subject string `json:"subject"`
+	// Subjects 可选：与 Subject 组合的其他学科，组合多个学科时生成跨学科整合教案
+	Subjects []string `json:"subjects" binding:"omitempty,max=3"`
+	// Grade 年级，未填写时使用用户设置中的默认年级
+	Grade      string   `json:"grade"`
 	Topic      string   `json:"topic" binding:"required"`
 	Duration   int      `json:"duration"`
 	Objectives []string `json:"objectives"`
 	Keywords   []string `json:"keywords"`
 	Style      string   `json:"style"`
 	Difficulty string   `json:"difficulty"`
+	// DetailLevel 详略级别（brief/standard/detailed），影响教学环节数量与篇幅，默认 standard
+	DetailLevel string `json:"detail_level" binding:"omitempty,oneof=brief standard detailed"`
+	// LessonType 课型（new 新授/review 复习/experiment 实验），决定教学环节结构，默认 new
+	LessonType string `json:"lesson_type" binding:"omitempty,oneof=new review experiment"`
+	// Variants 一次生成的方案数量（1~3），大于 1 时并发生成多个方案供对比选用
+	Variants int `json:"variants" binding:"omitempty,min=1,max=3"`
+	// Async 为 true 时立即返回生成记录 ID（202），在后台生成，进度见 GET /generate/:id/stream；不支持多方案
+	Async bool `json:"async"`
+	// VariantStyles 可选：为每个方案指定不同的教学风格，未指定的方案沿用 Style
+	VariantStyles []string `json:"variant_styles"`
+	// SaveAsDraft 为 true 时生成成功后自动保存为草稿教案；多方案生成时每个成功的方案各保存一份
+	SaveAsDraft bool `json:"save_as_draft"`
 }
 
 // GenerationResponse 生成响应
@@ -135,9 +250,30 @@ type GenerationResponse struct {
 	Activities      string    `json:"activities,omitempty"`
 	Assessment      string    `json:"assessment,omitempty"`
 	Resources       string    `json:"resources,omitempty"`
+	Tags            []string  `json:"tags,omitempty"`
 	TokenCount      int       `json:"token_count"`
 	DurationMs      int64     `json:"duration_ms"`
 	ErrorMessage    string    `json:"error_message,omitempty"`
+	Style           string    `json:"style,omitempty"`
+
+	// Variants 多方案生成时的全部方案（含失败方案），单方案生成时为空
+	Variants []GenerationResponse `json:"variants,omitempty"`
+
+	// Notice 给用户的提示，如降级模板说明
+	Notice string `json:"notice,omitempty"`
+
+	// Partial Agent 响应部分字段解析失败，只保留了其余内容；MissingFields 为失败的字段
+	Partial       bool     `json:"partial,omitempty"`
+	MissingFields []string `json:"missing_fields,omitempty"`
+
+	// LessonID 由生成结果自动保存的草稿教案（save_as_draft）
+	LessonID *uuid.UUID `json:"lesson_id,omitempty"`
+}
+
+// GenerationDetail 生成记录详情：Result 为解析后的结构化结果（与生成接口的响应一致），覆盖记录中的原始 JSON
+type GenerationDetail struct {
+	Generation
+	Result *GenerationResponse `json:"result"`
 }
 
 // ==================== 知识库文档模型 ====================
@@ -146,11 +282,13 @@ type GenerationResponse struct {
 type KnowledgeDocument struct {
 	ID            uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID        uuid.UUID `gorm:"type:uuid;not null;index;column:user_id" json:"userId"`
+	TenantID      string    `gorm:"type:varchar(64);not null;default:'default';index;column:tenant_id" json:"-"`
 	Title         string    `gorm:"type:varchar(255);not null" json:"title"`
 	FileName      string    `gorm:"type:varchar(255);not null;column:file_name" json:"fileName"`
-	FileType      string    `gorm:"type:varchar(50);not null;column:file_type" json:"fileType"` // txt, md
+	FileType      string    `gorm:"type:varchar(50);not null;column:file_type" json:"fileType"` // txt, md, pdf, docx
 	FileSize      int64     `gorm:"not null;column:file_size" json:"fileSize"`
 	Content       string    `gorm:"type:text" json:"content"`
+	ContentHash   string    `gorm:"type:varchar(64);index;column:content_hash" json:"contentHash,omitempty"`
 	Status        string    `gorm:"type:varchar(50);default:'pending'" json:"status"` // pending, processing, completed, failed
 	ErrorMsg      string    `gorm:"type:text;column:error_msg" json:"errorMsg,omitempty"`
 	EntityCount   int       `gorm:"default:0;column:entity_count" json:"entityCount"`
@@ -14,10 +14,17 @@ const (
 	LessonStatusArchived  = "archived"
 )
 
+// 教案正文格式：富文本编辑器产出 HTML，AI 生成与旧数据为 Markdown
+const (
+	LessonContentTypeMarkdown = "markdown"
+	LessonContentTypeHTML     = "html"
+)
+
 // Lesson 教案模型
 type Lesson struct {
 	ID            uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
 	UserID        uuid.UUID      `gorm:"type:uuid;index;not null" json:"user_id"`
+	TenantID      string         `gorm:"size:64;index;not null;default:'default'" json:"-"`
 	Title         string         `gorm:"size:200;not null" json:"title"`
 	Subject       string         `gorm:"size:50;not null;index" json:"subject"`
 	Grade         string         `gorm:"size:20;not null;index" json:"grade"`
@@ -29,12 +36,15 @@ type Lesson struct {
 	Resources     string         `gorm:"type:text" json:"resources"`
 	Status        string         `gorm:"size:20;default:'draft';index" json:"status"`
 	Tags          string         `gorm:"type:jsonb;default:'[]'" json:"tags"`
+	CoAuthors     string         `gorm:"type:jsonb;default:'[]'" json:"-"`
 	Version       int            `gorm:"default:1" json:"version"`
 	ViewCount     int            `gorm:"default:0" json:"view_count"`
 	LikeCount     int            `gorm:"default:0" json:"like_count"`
 	FavoriteCount int            `gorm:"default:0" json:"favorite_count"`
 	CommentCount  int            `gorm:"default:0" json:"comment_count"`
 	PublishedAt   *time.Time     `json:"published_at,omitempty"`
+	DraftContent  string         `gorm:"type:text" json:"-"`
+	DraftSavedAt  *time.Time     `json:"draft_saved_at,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	UpdatedAt     time.Time      `json:"updated_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
@@ -42,6 +52,18 @@ type Lesson struct {
 	// 关联
 	User     *User     `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Comments []Comment `gorm:"foreignKey:LessonID" json:"comments,omitempty"`
+
+	// ContentType 文本字段（目标、内容、活动、评价、资源）的格式，导出时据此转换
+	ContentType string `gorm:"size:20;not null;default:'markdown'" json:"content_type"`
+
+	// GradeLevel 年级标准值（见 NormalizeGrade），保存时由 Grade 计算，筛选与排序使用
+	GradeLevel int `gorm:"not null;default:0;index" json:"grade_level"`
+
+	// CoverURL 封面图地址（上传目录内的 /uploads/... 路径），为空表示没有封面
+	CoverURL string `gorm:"size:500" json:"cover_url"`
+
+	// ArchivedAt 长期无互动被自动归档的时间，作者恢复或手动改状态后清空
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 // TableName 表名
@@ -60,6 +82,12 @@ func (l *Lesson) BeforeCreate(tx *gorm.DB) error {
 	return nil
 }
 
+// BeforeSave 保存前钩子：Grade 保留原始写法，同步刷新标准值
+func (l *Lesson) BeforeSave(tx *gorm.DB) error {
+	l.GradeLevel = NormalizeGrade(l.Grade)
+	return nil
+}
+
 // LessonDetail 教案详情响应
 type LessonDetail struct {
 	ID            uuid.UUID  `json:"id"`
@@ -86,6 +114,24 @@ type LessonDetail struct {
 	AuthorAvatar  string     `json:"author_avatar"`
 	IsFavorited   bool       `json:"is_favorited"`
 	IsLiked       bool       `json:"is_liked"`
+	HasDraft      bool       `json:"has_draft"`
+	DraftSavedAt  *time.Time `json:"draft_saved_at,omitempty"`
+
+	// CoAuthors 联合署名（仅展示，不授予编辑权限）；Authors 为创建者加联合署名的完整署名列表
+	CoAuthors []string `json:"co_authors"`
+	Authors   []string `json:"authors"`
+
+	// ContentType 文本字段格式：markdown 或 html
+	ContentType string `json:"content_type"`
+
+	// GradeLevel 年级标准值，0 表示无法识别
+	GradeLevel int `json:"grade_level"`
+
+	// CoverURL 封面图地址，为空表示没有封面
+	CoverURL string `json:"cover_url"`
+
+	// ArchivedAt 自动归档时间，作者可据此恢复
+	ArchivedAt *time.Time `json:"archived_at,omitempty"`
 }
 
 // LessonVersion 教案版本历史
@@ -98,6 +144,11 @@ type LessonVersion struct {
 	CreatedBy     *uuid.UUID     `gorm:"type:uuid" json:"created_by,omitempty"`
 	CreatedAt     time.Time      `json:"created_at"`
 	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// IsKey 发布时的关键版本，超出保留数量时也不会被清理
+	IsKey bool `gorm:"column:is_key;not null;default:false" json:"is_key"`
+	// Label 教师给版本起的名称（如“公开课最终版”）；命名的版本作为里程碑，同样不会被清理
+	Label string `gorm:"size:50;not null;default:''" json:"label"`
 }
 
 // TableName 表名
@@ -120,6 +171,7 @@ type Comment struct {
 	UserID    uuid.UUID      `gorm:"type:uuid;index;not null" json:"user_id"`
 	ParentID  *uuid.UUID     `gorm:"type:uuid;index" json:"parent_id,omitempty"`
 	Content   string         `gorm:"type:text;not null" json:"content"`
+	LikeCount int            `gorm:"default:0" json:"like_count"`
 	CreatedAt time.Time      `json:"created_at"`
 	UpdatedAt time.Time      `json:"updated_at"`
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
@@ -134,6 +186,101 @@ func (Comment) TableName() string {
 	return "lesson_comments"
 }
 
+// CommentLike 评论点赞模型
+type CommentLike struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;index:idx_comment_like_user_comment,unique;not null" json:"user_id"`
+	CommentID uuid.UUID `gorm:"type:uuid;index:idx_comment_like_user_comment,unique;not null" json:"comment_id"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (CommentLike) TableName() string {
+	return "lesson_comment_likes"
+}
+
+// 评论审计操作
+const (
+	CommentAuditActionDelete  = "delete"
+	CommentAuditActionRestore = "restore"
+)
+
+// CommentAudit 评论删除/恢复审计记录，Content 保存操作时的评论内容快照
+type CommentAudit struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID  string    `gorm:"size:64;index;not null;default:'default'" json:"-"`
+	CommentID uuid.UUID `gorm:"type:uuid;index;not null" json:"comment_id"`
+	LessonID  uuid.UUID `gorm:"type:uuid;index;not null" json:"lesson_id"`
+	ActorID   uuid.UUID `gorm:"type:uuid;not null" json:"actor_id"`
+	ActorRole string    `gorm:"size:20;not null" json:"actor_role"`
+	Action    string    `gorm:"size:20;not null" json:"action"`
+	Content   string    `gorm:"type:text" json:"content"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (CommentAudit) TableName() string {
+	return "lesson_comment_audits"
+}
+
+// 批注状态
+const (
+	AnnotationStatusOpen     = "open"
+	AnnotationStatusResolved = "resolved"
+)
+
+// LessonAnnotation 教案批注，定位到某个字段内的一段文本，区别于整篇评论
+type LessonAnnotation struct {
+	ID          uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LessonID    uuid.UUID      `gorm:"type:uuid;index;not null" json:"lesson_id"`
+	UserID      uuid.UUID      `gorm:"type:uuid;index;not null" json:"user_id"`
+	Field       string         `gorm:"size:30;not null" json:"field"`
+	StartOffset int            `gorm:"not null;default:0" json:"start_offset"`
+	EndOffset   int            `gorm:"not null;default:0" json:"end_offset"`
+	Quote       string         `gorm:"type:text" json:"quote"`
+	Content     string         `gorm:"type:text;not null" json:"content"`
+	Suggestion  string         `gorm:"type:text" json:"suggestion,omitempty"`
+	Status      string         `gorm:"size:20;default:'open';index" json:"status"`
+	ResolvedBy  *uuid.UUID     `gorm:"type:uuid" json:"resolved_by,omitempty"`
+	ResolvedAt  *time.Time     `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time      `json:"created_at"`
+	UpdatedAt   time.Time      `json:"updated_at"`
+	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
+	// Outdated 教案修改后原文已找不到批注引用的片段
+	Outdated bool `gorm:"-" json:"outdated"`
+
+	// 关联
+	User *User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+// TableName 表名
+func (LessonAnnotation) TableName() string {
+	return "lesson_annotations"
+}
+
+// LessonShare 教案公开分享链接，持有 token 即可免登录只读访问
+type LessonShare struct {
+	ID            uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LessonID      uuid.UUID      `gorm:"type:uuid;index;not null" json:"lesson_id"`
+	UserID        uuid.UUID      `gorm:"type:uuid;index;not null" json:"user_id"`
+	Token         string         `gorm:"size:64;uniqueIndex;not null" json:"token"`
+	AllowDownload bool           `gorm:"default:false" json:"allow_download"`
+	ExpiresAt     *time.Time     `json:"expires_at,omitempty"`
+	ViewCount     int            `gorm:"default:0" json:"view_count"`
+	CreatedAt     time.Time      `json:"created_at"`
+	DeletedAt     gorm.DeletedAt `gorm:"index" json:"-"`
+}
+
+// TableName 表名
+func (LessonShare) TableName() string {
+	return "lesson_shares"
+}
+
+// IsExpired 分享是否已过期
+func (s *LessonShare) IsExpired(now time.Time) bool {
+	return s.ExpiresAt != nil && !now.Before(*s.ExpiresAt)
+}
+
 // Favorite 收藏模型
 type Favorite struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -141,6 +288,9 @@ type Favorite struct {
 	LessonID  uuid.UUID `gorm:"type:uuid;index:idx_favorite_user_lesson,unique;not null" json:"lesson_id"`
 	CreatedAt time.Time `json:"created_at"`
 
+	// FolderID 所属收藏夹，为空表示未分组
+	FolderID *uuid.UUID `gorm:"type:uuid;index" json:"folder_id,omitempty"`
+
 	// 关联
 	User   *User   `gorm:"foreignKey:UserID" json:"user,omitempty"`
 	Lesson *Lesson `gorm:"foreignKey:LessonID" json:"lesson,omitempty"`
@@ -151,6 +301,27 @@ func (Favorite) TableName() string {
 	return "lesson_favorites"
 }
 
+// FavoriteFolder 收藏夹，同一用户下名称唯一
+type FavoriteFolder struct {
+	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID    uuid.UUID `gorm:"type:uuid;uniqueIndex:idx_favorite_folders_user_name;not null" json:"user_id"`
+	Name      string    `gorm:"size:50;uniqueIndex:idx_favorite_folders_user_name;not null" json:"name"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (FavoriteFolder) TableName() string {
+	return "favorite_folders"
+}
+
+// FavoriteFolderSummary 收藏夹及其中的收藏数
+type FavoriteFolderSummary struct {
+	ID            uuid.UUID `json:"id"`
+	Name          string    `json:"name"`
+	FavoriteCount int64     `json:"favorite_count"`
+	CreatedAt     time.Time `json:"created_at"`
+}
+
 // Like 点赞模型
 type Like struct {
 	ID        uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
@@ -180,4 +351,44 @@ type LessonListItem struct {
 	PublishedAt   *time.Time `json:"published_at,omitempty"`
 	AuthorName    string     `json:"author_name"`
 	AuthorAvatar  string     `json:"author_avatar"`
+
+	// Authors 创建者加联合署名的完整署名列表
+	Authors []string `json:"authors"`
+
+	// GradeLevel 年级标准值，0 表示无法识别
+	GradeLevel int `json:"grade_level"`
+
+	// CoverURL 封面图地址，为空表示没有封面
+	CoverURL string `json:"cover_url"`
+
+	// IsLiked/IsFavorited 当前用户是否已点赞、收藏，匿名访问时均为 false
+	IsLiked     bool `json:"is_liked"`
+	IsFavorited bool `json:"is_favorited"`
+}
+
+// LessonEdit 教案编辑操作记录，构成撤销/重做栈：Before/After 为编辑前后的内容快照，
+// Undone 为 true 的记录处于可重做状态，新的编辑会丢弃全部可重做记录
+type LessonEdit struct {
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	LessonID uuid.UUID `gorm:"type:uuid;index;not null" json:"lesson_id"`
+	UserID   uuid.UUID `gorm:"type:uuid;not null" json:"user_id"`
+	Before   string    `gorm:"type:jsonb;not null" json:"-"`
+	After    string    `gorm:"type:jsonb;not null" json:"-"`
+	// Fields 本次编辑改动的字段名（JSON 数组）
+	Fields    string    `gorm:"type:jsonb;not null;default:'[]'" json:"fields"`
+	Undone    bool      `gorm:"not null;default:false" json:"undone"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (LessonEdit) TableName() string {
+	return "lesson_edits"
+}
+
+// BeforeCreate 创建前钩子
+func (e *LessonEdit) BeforeCreate(tx *gorm.DB) error {
+	if e.ID == uuid.Nil {
+		e.ID = uuid.New()
+	}
+	return nil
 }
@@ -0,0 +1,61 @@
+package model
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 通知邮件偏好
+const (
+	NotifyModeRealtime = "realtime" // 每条通知实时发送邮件
+	NotifyModeDaily    = "daily"    // 每日汇总为一封摘要邮件
+	NotifyModeOff      = "off"      // 不发送邮件
+)
+
+// 通知类型
+const (
+	NotificationTypeComment = "comment" // 教案收到评论
+	NotificationTypeReply   = "reply"   // 评论收到回复
+)
+
+// 通知邮件状态
+const (
+	NotificationEmailPending = "pending" // 待发送：每日摘要或实时发送失败后由摘要补发
+	NotificationEmailSent    = "sent"
+	NotificationEmailSkipped = "skipped" // 用户关闭了邮件通知
+)
+
+// Notification 站内通知，同时记录邮件发送状态，每日摘要据此聚合
+type Notification struct {
+	ID          uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID      uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	TenantID    string     `gorm:"size:64;index;not null;default:'default'" json:"-"`
+	Type        string     `gorm:"size:20;not null" json:"type"`
+	ActorID     uuid.UUID  `gorm:"type:uuid;not null" json:"actor_id"`
+	ActorName   string     `gorm:"size:100" json:"actor_name"`
+	LessonID    uuid.UUID  `gorm:"type:uuid;not null" json:"lesson_id"`
+	LessonTitle string     `gorm:"size:200" json:"lesson_title"`
+	CommentID   uuid.UUID  `gorm:"type:uuid;not null" json:"comment_id"`
+	Content     string     `gorm:"type:text" json:"content"`
+	EmailStatus string     `gorm:"size:20;index;not null;default:'pending'" json:"email_status"`
+	EmailedAt   *time.Time `json:"emailed_at,omitempty"`
+	CreatedAt   time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (Notification) TableName() string {
+	return "notifications"
+}
+
+// BeforeCreate 创建前钩子
+func (n *Notification) BeforeCreate(tx *gorm.DB) error {
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.EmailStatus == "" {
+		n.EmailStatus = NotificationEmailPending
+	}
+	return nil
+}
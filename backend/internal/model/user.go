@@ -23,18 +23,24 @@ const (
 
 // User 用户模型
 type User struct {
-	ID           uuid.UUID      `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	Username     string         `gorm:"uniqueIndex;size:50;not null" json:"username"`
-	Email        string         `gorm:"uniqueIndex;size:100;not null" json:"email"`
-	PasswordHash string         `gorm:"size:255;not null" json:"-"`
-	FullName     string         `gorm:"size:100" json:"full_name"`
-	AvatarURL    string         `gorm:"size:500" json:"avatar_url"`
-	Role         string         `gorm:"size:20;default:'teacher'" json:"role"`
-	Status       string         `gorm:"size:20;default:'active'" json:"status"`
-	LastLoginAt  *time.Time     `json:"last_login_at,omitempty"`
-	CreatedAt    time.Time      `json:"created_at"`
-	UpdatedAt    time.Time      `json:"updated_at"`
-	DeletedAt    gorm.DeletedAt `gorm:"index" json:"-"`
+	ID           uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	TenantID     string     `gorm:"size:64;index;not null;default:'default'" json:"tenant_id"`
+	Username     string     `gorm:"uniqueIndex;size:50;not null" json:"username"`
+	Email        string     `gorm:"uniqueIndex;size:100;not null" json:"email"`
+	PasswordHash string     `gorm:"size:255;not null" json:"-"`
+	FullName     string     `gorm:"size:100" json:"full_name"`
+	AvatarURL    string     `gorm:"size:500" json:"avatar_url"`
+	Role         string     `gorm:"size:20;default:'teacher'" json:"role"`
+	Status       string     `gorm:"size:20;default:'active'" json:"status"`
+	LastLoginAt  *time.Time `json:"last_login_at,omitempty"`
+	// EmailVerifiedAt 邮箱验证时间；自助注册的用户验证前为空且状态为 inactive
+	EmailVerifiedAt *time.Time     `json:"email_verified_at,omitempty"`
+	CreatedAt       time.Time      `json:"created_at"`
+	UpdatedAt       time.Time      `json:"updated_at"`
+	DeletedAt       gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// NotifyMode 评论通知邮件偏好：realtime 实时、daily 每日摘要、off 关闭
+	NotifyMode string `gorm:"size:20;not null;default:'realtime'" json:"notify_mode"`
 }
 
 // TableName 表名
@@ -53,9 +59,17 @@ func (u *User) BeforeCreate(tx *gorm.DB) error {
 	if u.Status == "" {
 		u.Status = StatusActive
 	}
+	if u.NotifyMode == "" {
+		u.NotifyMode = NotifyModeRealtime
+	}
 	return nil
 }
 
+// PendingEmailVerification 自助注册后尚未验证邮箱；与管理员停用的 inactive 账号区分
+func (u *User) PendingEmailVerification() bool {
+	return u.Status == StatusInactive && u.EmailVerifiedAt == nil
+}
+
 // UserProfile 用户资料响应
 type UserProfile struct {
 	ID            uuid.UUID  `json:"id"`
@@ -69,6 +83,7 @@ type UserProfile struct {
 	LastLoginAt   *time.Time `json:"last_login_at,omitempty"`
 	LessonCount   int64      `json:"lesson_count"`
 	FavoriteCount int64      `json:"favorite_count"`
+	NotifyMode    string     `json:"notify_mode"`
 }
 
 // ToProfile 转换为用户资料
@@ -83,16 +98,18 @@ func (u *User) ToProfile() *UserProfile {
 		Status:      u.Status,
 		CreatedAt:   u.CreatedAt,
 		LastLoginAt: u.LastLoginAt,
+		NotifyMode:  u.NotifyMode,
 	}
 }
 
 // UserSettings 用户设置
 type UserSettings struct {
-	ID             uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
-	UserID         uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
-	Theme          string    `gorm:"size:20;default:'light'" json:"theme"`
-	Language       string    `gorm:"size:10;default:'zh-CN'" json:"language"`
-	EmailNotify    bool      `gorm:"default:true" json:"email_notify"`
+	ID       uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID   uuid.UUID `gorm:"type:uuid;uniqueIndex;not null" json:"user_id"`
+	Theme    string    `gorm:"size:20;default:'light'" json:"theme"`
+	Language string    `gorm:"size:10;default:'zh-CN'" json:"language"`
+	// EmailNotify 列默认值为 TRUE；模型不声明 default，否则 GORM 插入时会把 false 当作零值替换为 true
+	EmailNotify    bool      `gorm:"not null" json:"email_notify"`
 	DefaultSubject string    `gorm:"size:50" json:"default_subject"`
 	DefaultGrade   string    `gorm:"size:20" json:"default_grade"`
 	CreatedAt      time.Time `json:"created_at"`
@@ -103,3 +120,104 @@ type UserSettings struct {
 func (UserSettings) TableName() string {
 	return "user_settings"
 }
+
+// UserFollow 用户关注关系
+type UserFollow struct {
+	ID          uuid.UUID `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	FollowerID  uuid.UUID `gorm:"type:uuid;index:idx_user_follows_pair,unique;not null" json:"follower_id"`
+	FollowingID uuid.UUID `gorm:"type:uuid;index:idx_user_follows_pair,unique;index;not null" json:"following_id"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+// TableName 表名
+func (UserFollow) TableName() string {
+	return "user_follows"
+}
+
+// API Key 权限范围
+const (
+	APIKeyScopeRead     = "read"     // 只读请求（GET/HEAD）
+	APIKeyScopeWrite    = "write"    // 创建、修改、删除
+	APIKeyScopeGenerate = "generate" // 调用 AI 生成，消耗额度
+)
+
+// APIKey 第三方集成使用的长期访问令牌，只保存哈希，明文仅在创建时返回一次
+type APIKey struct {
+	ID         uuid.UUID  `gorm:"type:uuid;primary_key;default:gen_random_uuid()" json:"id"`
+	UserID     uuid.UUID  `gorm:"type:uuid;index;not null" json:"user_id"`
+	TenantID   string     `gorm:"size:64;index;not null;default:'default'" json:"-"`
+	Name       string     `gorm:"size:100;not null" json:"name"`
+	Prefix     string     `gorm:"size:16;not null" json:"prefix"`
+	KeyHash    string     `gorm:"size:64;uniqueIndex;not null" json:"-"`
+	Scopes     string     `gorm:"type:jsonb;not null;default:'[]'" json:"-"`
+	LastUsedAt *time.Time `json:"last_used_at,omitempty"`
+	ExpiresAt  *time.Time `json:"expires_at,omitempty"`
+	RevokedAt  *time.Time `json:"revoked_at,omitempty"`
+	CreatedAt  time.Time  `json:"created_at"`
+}
+
+// TableName 表名
+func (APIKey) TableName() string {
+	return "api_keys"
+}
+
+// BeforeCreate 创建前钩子
+func (k *APIKey) BeforeCreate(tx *gorm.DB) error {
+	if k.ID == uuid.Nil {
+		k.ID = uuid.New()
+	}
+	return nil
+}
+
+// IsExpired API Key 是否已过期
+func (k *APIKey) IsExpired(now time.Time) bool {
+	return k.ExpiresAt != nil && !now.Before(*k.ExpiresAt)
+}
+
+// APIKeyIdentity API Key 认证结果：Key 绑定的用户与权限范围，租户取自 Key 本身
+type APIKeyIdentity struct {
+	KeyID    string
+	UserID   string
+	Username string
+	Email    string
+	Role     string
+	TenantID string
+	Scopes   []string
+}
+
+// HasScope 是否拥有指定权限范围
+func (i *APIKeyIdentity) HasScope(scope string) bool {
+	for _, item := range i.Scopes {
+		if item == scope {
+			return true
+		}
+	}
+	return false
+}
+
+// UserLoginHistory 登录记录；系统只保存最近一次登录时间，不保留逐次登录日志
+type UserLoginHistory struct {
+	LastLoginAt *time.Time `json:"last_login_at"`
+}
+
+// UserDataExport 用户个人数据导出，包含该用户在系统中产生的全部数据。
+// 密码哈希、API Key 哈希等凭据不在导出范围内（对应字段不序列化）
+type UserDataExport struct {
+	ExportedAt        time.Time           `json:"exported_at"`
+	Profile           *User               `json:"profile"`
+	Settings          *UserSettings       `json:"settings"`
+	LoginHistory      UserLoginHistory    `json:"login_history"`
+	Lessons           []Lesson            `json:"lessons"`
+	Comments          []Comment           `json:"comments"`
+	Annotations       []LessonAnnotation  `json:"annotations"`
+	Generations       []Generation        `json:"generations"`
+	GenerationPresets []GenerationPreset  `json:"generation_presets"`
+	Documents         []KnowledgeDocument `json:"documents"`
+	Favorites         []Favorite          `json:"favorites"`
+	FavoriteFolders   []FavoriteFolder    `json:"favorite_folders"`
+	Likes             []Like              `json:"likes"`
+	Following         []UserFollow        `json:"following"`
+	Shares            []LessonShare       `json:"shares"`
+	APIKeys           []APIKey            `json:"api_keys"`
+	Notifications     []Notification      `json:"notifications"`
+}
@@ -13,6 +13,9 @@ const (
 )
 
 type metricBucket struct {
+	// Labels 指标标签值：路由为 method、route，下游为 service、operation
+	Labels []string
+
 	Count          uint64
 	ErrorCount     uint64
 	TotalLatencyMs float64
@@ -163,7 +166,7 @@ func RecordHTTPRequest(method, route string, statusCode int, latency time.Durati
 	key := routeKey(method, route)
 	bucket := globalCollector.routes[key]
 	if bucket == nil {
-		bucket = &metricBucket{}
+		bucket = &metricBucket{Labels: []string{method, route}}
 		globalCollector.routes[key] = bucket
 	}
 	addSample(bucket, latencyMs, isError)
@@ -190,7 +193,7 @@ func RecordDownstream(service, operation string, statusCode int, latency time.Du
 	key := downstreamKey(service, operation)
 	bucket := globalCollector.downstreams[key]
 	if bucket == nil {
-		bucket = &metricBucket{}
+		bucket = &metricBucket{Labels: []string{service, operation}}
 		globalCollector.downstreams[key] = bucket
 	}
 	addSample(bucket, latencyMs, isError)
@@ -0,0 +1,120 @@
+package observability
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// PrometheusContentType Prometheus 文本格式（0.0.4）的响应类型
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// summaryQuantiles 延迟 summary 输出的分位数
+var summaryQuantiles = []int{95, 99}
+
+// WritePrometheus 以 Prometheus 文本格式输出当前指标。
+func WritePrometheus(w io.Writer) error {
+	globalCollector.mu.Lock()
+	uptime := time.Since(globalCollector.startedAt).Seconds()
+	routes := sortedBuckets(globalCollector.routes)
+	downstreams := sortedBuckets(globalCollector.downstreams)
+	globalCollector.mu.Unlock()
+
+	bw := bufio.NewWriter(w)
+
+	writeHeader(bw, "process_uptime_seconds", "gauge", "Seconds since the backend process started.")
+	fmt.Fprintf(bw, "process_uptime_seconds %s\n", formatFloat(uptime))
+
+	httpLabels := []string{"method", "route"}
+	writeHeader(bw, "http_requests_total", "counter", "Total HTTP requests by method and route template.")
+	for _, bucket := range routes {
+		fmt.Fprintf(bw, "http_requests_total%s %d\n", formatLabels(httpLabels, bucket.Labels), bucket.Count)
+	}
+	writeHeader(bw, "http_request_errors_total", "counter", "HTTP requests that returned a 4xx or 5xx status.")
+	for _, bucket := range routes {
+		fmt.Fprintf(bw, "http_request_errors_total%s %d\n", formatLabels(httpLabels, bucket.Labels), bucket.ErrorCount)
+	}
+	writeHeader(bw, "http_request_duration_ms", "summary", "HTTP request latency in milliseconds.")
+	for _, bucket := range routes {
+		writeSummary(bw, "http_request_duration_ms", httpLabels, bucket)
+	}
+
+	downstreamLabels := []string{"service", "operation"}
+	writeHeader(bw, "downstream_requests_total", "counter", "Total downstream calls by service and operation.")
+	for _, bucket := range downstreams {
+		fmt.Fprintf(bw, "downstream_requests_total%s %d\n", formatLabels(downstreamLabels, bucket.Labels), bucket.Count)
+	}
+	writeHeader(bw, "downstream_request_errors_total", "counter", "Downstream calls that failed or returned a 4xx or 5xx status.")
+	for _, bucket := range downstreams {
+		fmt.Fprintf(bw, "downstream_request_errors_total%s %d\n", formatLabels(downstreamLabels, bucket.Labels), bucket.ErrorCount)
+	}
+	writeHeader(bw, "downstream_request_duration_ms", "summary", "Downstream call latency in milliseconds.")
+	for _, bucket := range downstreams {
+		writeSummary(bw, "downstream_request_duration_ms", downstreamLabels, bucket)
+	}
+
+	return bw.Flush()
+}
+
+// sortedBuckets 复制桶并按标签排序，保证输出顺序稳定；调用方需持有锁
+func sortedBuckets(buckets map[string]*metricBucket) []metricBucket {
+	keys := make([]string, 0, len(buckets))
+	for key := range buckets {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	result := make([]metricBucket, 0, len(keys))
+	for _, key := range keys {
+		bucket := *buckets[key]
+		bucket.LatencySamples = append([]float64(nil), bucket.LatencySamples...)
+		result = append(result, bucket)
+	}
+	return result
+}
+
+func writeHeader(w io.Writer, name, metricType, help string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, help)
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, metricType)
+}
+
+// writeSummary 输出分位数、_sum 与 _count；分位数基于最近的延迟样本
+func writeSummary(w io.Writer, name string, labelNames []string, bucket metricBucket) {
+	quantileNames := append(append([]string(nil), labelNames...), "quantile")
+	for _, q := range summaryQuantiles {
+		values := append(append([]string(nil), bucket.Labels...), formatFloat(float64(q)/100))
+		fmt.Fprintf(w, "%s%s %s\n", name, formatLabels(quantileNames, values), formatFloat(percentile(bucket.LatencySamples, q)))
+	}
+	labels := formatLabels(labelNames, bucket.Labels)
+	fmt.Fprintf(w, "%s_sum%s %s\n", name, labels, formatFloat(bucket.TotalLatencyMs))
+	fmt.Fprintf(w, "%s_count%s %d\n", name, labels, bucket.Count)
+}
+
+func formatLabels(names, values []string) string {
+	if len(names) == 0 {
+		return ""
+	}
+	parts := make([]string, 0, len(names))
+	for i, name := range names {
+		value := ""
+		if i < len(values) {
+			value = values[i]
+		}
+		parts = append(parts, name+`="`+escapeLabelValue(value)+`"`)
+	}
+	return "{" + strings.Join(parts, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatFloat(value float64) string {
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
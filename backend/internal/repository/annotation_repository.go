@@ -0,0 +1,65 @@
+package repository
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// AnnotationRepository 批注仓库接口
+type AnnotationRepository interface {
+	Create(ctx context.Context, annotation *model.LessonAnnotation) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.LessonAnnotation, error)
+	Update(ctx context.Context, annotation *model.LessonAnnotation) error
+	Delete(ctx context.Context, id uuid.UUID) error
+	ListByLessonID(ctx context.Context, lessonID uuid.UUID, field, status string) ([]model.LessonAnnotation, error)
+}
+
+type annotationRepository struct {
+	db *gorm.DB
+}
+
+// NewAnnotationRepository 创建批注仓库
+func NewAnnotationRepository(db *gorm.DB) AnnotationRepository {
+	return &annotationRepository{db: db}
+}
+
+func (r *annotationRepository) Create(ctx context.Context, annotation *model.LessonAnnotation) error {
+	return r.db.WithContext(ctx).Create(annotation).Error
+}
+
+func (r *annotationRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.LessonAnnotation, error) {
+	var annotation model.LessonAnnotation
+	err := r.db.WithContext(ctx).Preload("User").Where("id = ?", id).First(&annotation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &annotation, nil
+}
+
+func (r *annotationRepository) Update(ctx context.Context, annotation *model.LessonAnnotation) error {
+	return r.db.WithContext(ctx).Omit("User").Save(annotation).Error
+}
+
+func (r *annotationRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.LessonAnnotation{}, "id = ?", id).Error
+}
+
+// ListByLessonID 按字段、位置顺序列出批注，field/status 为空时不过滤
+func (r *annotationRepository) ListByLessonID(ctx context.Context, lessonID uuid.UUID, field, status string) ([]model.LessonAnnotation, error) {
+	var annotations []model.LessonAnnotation
+
+	db := r.db.WithContext(ctx).Preload("User").Where("lesson_id = ?", lessonID)
+	if field != "" {
+		db = db.Where("field = ?", field)
+	}
+	if status != "" {
+		db = db.Where("status = ?", status)
+	}
+
+	err := db.Order("field ASC, start_offset ASC, created_at ASC").Find(&annotations).Error
+	return annotations, err
+}
@@ -0,0 +1,80 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// APIKeyRepository API Key 仓库接口
+type APIKeyRepository interface {
+	Create(ctx context.Context, key *model.APIKey) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error)
+	GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error)
+	CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	Revoke(ctx context.Context, id uuid.UUID, at time.Time) error
+	TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error
+}
+
+type apiKeyRepository struct {
+	db *gorm.DB
+}
+
+// NewAPIKeyRepository 创建 API Key 仓库
+func NewAPIKeyRepository(db *gorm.DB) APIKeyRepository {
+	return &apiKeyRepository{db: db}
+}
+
+func (r *apiKeyRepository) Create(ctx context.Context, key *model.APIKey) error {
+	return r.db.WithContext(ctx).Create(key).Error
+}
+
+func (r *apiKeyRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	var key model.APIKey
+	err := r.db.WithContext(ctx).Where("key_hash = ?", keyHash).First(&key).Error
+	if err != nil {
+		return nil, err
+	}
+	return &key, nil
+}
+
+func (r *apiKeyRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	var keys []model.APIKey
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("created_at DESC").Find(&keys).Error
+	return keys, err
+}
+
+// CountActiveByUserID 统计未吊销的 API Key 数量（含已过期）
+func (r *apiKeyRepository) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).Count(&count).Error
+	return count, err
+}
+
+// Revoke 吊销 API Key，保留记录便于审计
+func (r *apiKeyRepository) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).
+		Where("id = ? AND revoked_at IS NULL", id).
+		UpdateColumn("revoked_at", at).Error
+}
+
+func (r *apiKeyRepository) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.APIKey{}).Where("id = ?", id).
+		UpdateColumn("last_used_at", at).Error
+}
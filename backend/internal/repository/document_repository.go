@@ -1,6 +1,10 @@
 package repository
 
 import (
+	"context"
+	"errors"
+	"time"
+
 	"lesson-plan/backend/internal/model"
 
 	"github.com/google/uuid"
@@ -9,11 +13,22 @@ import (
 
 // DocumentRepository 知识文档仓库接口
 type DocumentRepository interface {
-	CreateDocument(doc *model.KnowledgeDocument) error
-	GetDocumentByID(docID string, userID string) (*model.KnowledgeDocument, error)
-	ListDocuments(userID string, page, pageSize int) ([]model.KnowledgeDocument, int64, error)
-	UpdateDocumentStatus(docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error
-	DeleteDocument(docID string, userID string) error
+	CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error
+	GetDocumentByID(ctx context.Context, docID string, userID string) (*model.KnowledgeDocument, error)
+	FindByContentHash(ctx context.Context, userID string, contentHash string) (*model.KnowledgeDocument, error)
+	ListDocuments(ctx context.Context, filter DocumentFilter, page, pageSize int) ([]model.KnowledgeDocument, int64, error)
+	UpdateDocumentStatus(ctx context.Context, docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error
+	DeleteDocument(ctx context.Context, docID string, userID string) error
+	// ResetForReprocess 把文档重置为待处理并清空处理结果；文档正在处理且 updated_at 晚于 staleBefore 时不重置，返回 false
+	ResetForReprocess(ctx context.Context, docID uuid.UUID, userID string, staleBefore time.Time) (bool, error)
+}
+
+// DocumentFilter 文档列表筛选条件，空字段表示不筛选
+type DocumentFilter struct {
+	UserID  string
+	Status  string
+	Subject string
+	Grade   string
 }
 
 // documentRepository 知识文档仓库实现
@@ -27,14 +42,14 @@ func NewDocumentRepository(db *gorm.DB) DocumentRepository {
 }
 
 // CreateDocument 创建文档
-func (r *documentRepository) CreateDocument(doc *model.KnowledgeDocument) error {
-	return r.db.Create(doc).Error
+func (r *documentRepository) CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error {
+	return r.db.WithContext(ctx).Create(doc).Error
 }
 
 // GetDocumentByID 根据ID和用户ID获取文档
-func (r *documentRepository) GetDocumentByID(docID string, userID string) (*model.KnowledgeDocument, error) {
+func (r *documentRepository) GetDocumentByID(ctx context.Context, docID string, userID string) (*model.KnowledgeDocument, error) {
 	var doc model.KnowledgeDocument
-	err := r.db.
+	err := r.db.WithContext(ctx).
 		Where("id = ? AND user_id = ?", docID, userID).
 		First(&doc).Error
 	if err != nil {
@@ -43,21 +58,47 @@ func (r *documentRepository) GetDocumentByID(docID string, userID string) (*mode
 	return &doc, nil
 }
 
-// ListDocuments 获取用户的文档列表
-func (r *documentRepository) ListDocuments(userID string, page, pageSize int) ([]model.KnowledgeDocument, int64, error) {
+// FindByContentHash 查找用户下内容相同且未失败的文档，不存在时返回 nil
+func (r *documentRepository) FindByContentHash(ctx context.Context, userID string, contentHash string) (*model.KnowledgeDocument, error) {
+	var doc model.KnowledgeDocument
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND content_hash = ? AND status <> ?", userID, contentHash, model.DocStatusFailed).
+		Order("created_at DESC").
+		First(&doc).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &doc, nil
+}
+
+// ListDocuments 按筛选条件分页获取用户的文档列表
+func (r *documentRepository) ListDocuments(ctx context.Context, filter DocumentFilter, page, pageSize int) ([]model.KnowledgeDocument, int64, error) {
 	var docs []model.KnowledgeDocument
 	var total int64
 
 	offset := (page - 1) * pageSize
 
+	query := r.db.WithContext(ctx).Model(&model.KnowledgeDocument{}).Where("user_id = ?", filter.UserID)
+	if filter.Status != "" {
+		query = query.Where("status = ?", filter.Status)
+	}
+	if filter.Subject != "" {
+		query = query.Where("subject = ?", filter.Subject)
+	}
+	if filter.Grade != "" {
+		query = query.Where("grade = ?", filter.Grade)
+	}
+
 	// 获取总数
-	if err := r.db.Model(&model.KnowledgeDocument{}).Where("user_id = ?", userID).Count(&total).Error; err != nil {
+	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// 获取分页数据
-	err := r.db.
-		Where("user_id = ?", userID).
+	err := query.
 		Order("created_at DESC").
 		Offset(offset).
 		Limit(pageSize).
@@ -67,22 +108,41 @@ func (r *documentRepository) ListDocuments(userID string, page, pageSize int) ([
 }
 
 // UpdateDocumentStatus 更新文档状态
-func (r *documentRepository) UpdateDocumentStatus(docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error {
+func (r *documentRepository) UpdateDocumentStatus(ctx context.Context, docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error {
 	updates := map[string]interface{}{
 		"status":         status,
 		"error_msg":      errorMsg,
 		"entity_count":   entityCount,
 		"relation_count": relCount,
 	}
-	return r.db.
+	return r.db.WithContext(ctx).
 		Model(&model.KnowledgeDocument{}).
 		Where("id = ?", docID).
 		Updates(updates).Error
 }
 
 // DeleteDocument 删除文档
-func (r *documentRepository) DeleteDocument(docID string, userID string) error {
-	return r.db.
+func (r *documentRepository) DeleteDocument(ctx context.Context, docID string, userID string) error {
+	return r.db.WithContext(ctx).
 		Where("id = ? AND user_id = ?", docID, userID).
 		Delete(&model.KnowledgeDocument{}).Error
 }
+
+// ResetForReprocess 条件更新保证同一文档不会被并发重复处理；超过 staleBefore 仍在处理中的文档视为处理进程已中断
+func (r *documentRepository) ResetForReprocess(ctx context.Context, docID uuid.UUID, userID string, staleBefore time.Time) (bool, error) {
+	updates := map[string]interface{}{
+		"status":         model.DocStatusPending,
+		"error_msg":      "",
+		"entity_count":   0,
+		"relation_count": 0,
+	}
+	result := r.db.WithContext(ctx).
+		Model(&model.KnowledgeDocument{}).
+		Where("id = ? AND user_id = ?", docID, userID).
+		Where("status <> ? OR updated_at < ?", model.DocStatusProcessing, staleBefore).
+		Updates(updates)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
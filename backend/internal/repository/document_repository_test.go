@@ -0,0 +1,135 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestResetForReprocessQuery(t *testing.T) {
+	var captured capturedSQL
+	db := newDryRunDB(t, &captured)
+	_ = db.Callback().Update().After("gorm:update").Register("test:capture", func(db *gorm.DB) {
+		captured.sql = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+		captured.vars = db.Statement.Vars
+	})
+	r := &documentRepository{db: db}
+
+	docID := uuid.New()
+	staleBefore := time.Now().Add(-10 * time.Minute)
+	if _, err := r.ResetForReprocess(context.Background(), docID, "user-1", staleBefore); err != nil {
+		t.Fatalf("ResetForReprocess() error = %v", err)
+	}
+
+	for _, want := range []string{
+		`UPDATE "knowledge_documents" SET`,
+		`"status"=$`,
+		`"error_msg"=$`,
+		`"entity_count"=$`,
+		`"relation_count"=$`,
+		`"updated_at"=$`,
+		`WHERE (id = $6 AND user_id = $7) AND (status <> $8 OR updated_at < $9)`,
+	} {
+		if !strings.Contains(captured.sql, want) {
+			t.Errorf("SQL = %s, want it to contain %q", captured.sql, want)
+		}
+	}
+
+	// 只有非处理中或处理已超时的文档才会被重置
+	var hasPending, hasProcessing, hasStaleBefore bool
+	for _, v := range captured.vars {
+		switch v := v.(type) {
+		case string:
+			hasPending = hasPending || v == model.DocStatusPending
+			hasProcessing = hasProcessing || v == model.DocStatusProcessing
+		case time.Time:
+			hasStaleBefore = hasStaleBefore || v.Equal(staleBefore)
+		}
+	}
+	if !hasPending || !hasProcessing || !hasStaleBefore {
+		t.Errorf("vars = %v, want pending status with processing/staleBefore guard", captured.vars)
+	}
+}
+
+func TestListDocumentsQuery(t *testing.T) {
+	tests := []struct {
+		name      string
+		filter    DocumentFilter
+		page      int
+		pageSize  int
+		wantWhere string
+		wantVars  []interface{}
+		wantPage  string
+	}{
+		{
+			name:      "first page without filters",
+			filter:    DocumentFilter{UserID: "user-1"},
+			page:      1,
+			pageSize:  10,
+			wantWhere: `WHERE user_id = $1`,
+			wantVars:  []interface{}{"user-1"},
+			wantPage:  `ORDER BY created_at DESC LIMIT 10`,
+		},
+		{
+			name:      "later page by status",
+			filter:    DocumentFilter{UserID: "user-1", Status: model.DocStatusFailed},
+			page:      3,
+			pageSize:  20,
+			wantWhere: `WHERE user_id = $1 AND status = $2`,
+			wantVars:  []interface{}{"user-1", model.DocStatusFailed},
+			wantPage:  `ORDER BY created_at DESC LIMIT 20 OFFSET 40`,
+		},
+		{
+			name:      "all filters",
+			filter:    DocumentFilter{UserID: "user-1", Status: model.DocStatusCompleted, Subject: "数学", Grade: "七年级"},
+			page:      2,
+			pageSize:  100,
+			wantWhere: `WHERE user_id = $1 AND status = $2 AND subject = $3 AND grade = $4`,
+			wantVars:  []interface{}{"user-1", model.DocStatusCompleted, "数学", "七年级"},
+			wantPage:  `ORDER BY created_at DESC LIMIT 100 OFFSET 100`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured capturedSQL
+			var countSQL string
+			db := newDryRunDB(t, &captured)
+			_ = db.Callback().Query().After("gorm:query").Before("test:capture").Register("test:count", func(db *gorm.DB) {
+				if _, isCount := db.Statement.Dest.(*int64); isCount {
+					countSQL = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+				}
+			})
+			r := NewDocumentRepository(db)
+
+			if _, _, err := r.ListDocuments(context.Background(), tt.filter, tt.page, tt.pageSize); err != nil {
+				t.Fatalf("ListDocuments() error = %v", err)
+			}
+
+			// 总数与分页数据使用同样的筛选条件
+			wantCount := `SELECT count(*) FROM "knowledge_documents" ` + tt.wantWhere
+			if countSQL != wantCount {
+				t.Errorf("count SQL = %s\nwant        %s", countSQL, wantCount)
+			}
+			wantSQL := `SELECT * FROM "knowledge_documents" ` + tt.wantWhere + ` ` + tt.wantPage
+			if captured.sql != wantSQL {
+				t.Errorf("SQL = %s\nwant  %s", captured.sql, wantSQL)
+			}
+			if len(captured.vars) != len(tt.wantVars) {
+				t.Fatalf("vars = %v, want %v", captured.vars, tt.wantVars)
+			}
+			for i, v := range tt.wantVars {
+				if captured.vars[i] != v {
+					t.Errorf("vars = %v, want %v", captured.vars, tt.wantVars)
+					break
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// FollowRepository 关注关系仓库接口
+type FollowRepository interface {
+	Create(ctx context.Context, follow *model.UserFollow) error
+	Delete(ctx context.Context, followerID, followingID uuid.UUID) error
+	Exists(ctx context.Context, followerID, followingID uuid.UUID) (bool, error)
+	CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error)
+	CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error)
+	ListFeed(ctx context.Context, followerID uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error)
+}
+
+type followRepository struct {
+	db *gorm.DB
+}
+
+// NewFollowRepository 创建关注关系仓库
+func NewFollowRepository(db *gorm.DB) FollowRepository {
+	return &followRepository{db: db}
+}
+
+func (r *followRepository) Create(ctx context.Context, follow *model.UserFollow) error {
+	return r.db.WithContext(ctx).Create(follow).Error
+}
+
+func (r *followRepository) Delete(ctx context.Context, followerID, followingID uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("follower_id = ? AND following_id = ?", followerID, followingID).
+		Delete(&model.UserFollow{}).Error
+}
+
+func (r *followRepository) Exists(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Where("follower_id = ? AND following_id = ?", followerID, followingID).Count(&count).Error
+	return count > 0, err
+}
+
+func (r *followRepository) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).Where("following_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *followRepository) CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.UserFollow{}).Where("follower_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+// ListFeed 关注对象已发布的教案，按发布时间倒序
+func (r *followRepository) ListFeed(ctx context.Context, followerID uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error) {
+	var lessons []model.Lesson
+	var total int64
+
+	following := r.db.WithContext(ctx).Model(&model.UserFollow{}).
+		Select("following_id").
+		Where("follower_id = ?", followerID)
+
+	db := r.db.WithContext(ctx).Model(&model.Lesson{}).Preload("User").
+		Where("user_id IN (?)", following).
+		Where("status = ?", model.LessonStatusPublished)
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("published_at DESC NULLS LAST").Order("created_at DESC").
+		Offset(offset).Limit(pageSize).Find(&lessons).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return lessons, total, nil
+}
@@ -0,0 +1,54 @@
+package repository
+
+import (
+	"context"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestListFeedQuery(t *testing.T) {
+	followerID := uuid.New()
+
+	tests := []struct {
+		name       string
+		page       int
+		pageSize   int
+		wantSQL    string
+		wantOffset string
+	}{
+		{
+			name:     "first page",
+			page:     1,
+			pageSize: 20,
+			wantSQL: `SELECT * FROM "lessons" WHERE user_id IN (SELECT "following_id" FROM "user_follows" WHERE follower_id = $1) ` +
+				`AND status = $2 AND "lessons"."deleted_at" IS NULL ORDER BY published_at DESC NULLS LAST,created_at DESC LIMIT 20`,
+		},
+		{
+			name:     "later page",
+			page:     3,
+			pageSize: 10,
+			wantSQL: `SELECT * FROM "lessons" WHERE user_id IN (SELECT "following_id" FROM "user_follows" WHERE follower_id = $1) ` +
+				`AND status = $2 AND "lessons"."deleted_at" IS NULL ORDER BY published_at DESC NULLS LAST,created_at DESC LIMIT 10 OFFSET 20`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured capturedSQL
+			r := NewFollowRepository(newDryRunDB(t, &captured))
+
+			if _, _, err := r.ListFeed(context.Background(), followerID, tt.page, tt.pageSize); err != nil {
+				t.Fatalf("ListFeed() error = %v", err)
+			}
+			if captured.sql != tt.wantSQL {
+				t.Errorf("SQL = %s\nwant  %s", captured.sql, tt.wantSQL)
+			}
+			if len(captured.vars) < 2 || captured.vars[0] != followerID || captured.vars[1] != model.LessonStatusPublished {
+				t.Errorf("vars = %v, want [%s %s]", captured.vars, followerID, model.LessonStatusPublished)
+			}
+		})
+	}
+}
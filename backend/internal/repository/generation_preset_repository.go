@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// GenerationPresetRepository 生成参数预设仓库接口
+type GenerationPresetRepository interface {
+	Create(ctx context.Context, preset *model.GenerationPreset) error
+	Update(ctx context.Context, preset *model.GenerationPreset) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.GenerationPreset, error)
+	GetByName(ctx context.Context, userID uuid.UUID, name string) (*model.GenerationPreset, error)
+	ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.GenerationPreset, error)
+	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+}
+
+type generationPresetRepository struct {
+	db *gorm.DB
+}
+
+// NewGenerationPresetRepository 创建生成参数预设仓库
+func NewGenerationPresetRepository(db *gorm.DB) GenerationPresetRepository {
+	return &generationPresetRepository{db: db}
+}
+
+func (r *generationPresetRepository) Create(ctx context.Context, preset *model.GenerationPreset) error {
+	return r.db.WithContext(ctx).Create(preset).Error
+}
+
+func (r *generationPresetRepository) Update(ctx context.Context, preset *model.GenerationPreset) error {
+	return r.db.WithContext(ctx).Save(preset).Error
+}
+
+func (r *generationPresetRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.GenerationPreset, error) {
+	var preset model.GenerationPreset
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&preset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+func (r *generationPresetRepository) GetByName(ctx context.Context, userID uuid.UUID, name string) (*model.GenerationPreset, error) {
+	var preset model.GenerationPreset
+	err := r.db.WithContext(ctx).Where("user_id = ? AND name = ?", userID, name).First(&preset).Error
+	if err != nil {
+		return nil, err
+	}
+	return &preset, nil
+}
+
+func (r *generationPresetRepository) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.GenerationPreset, error) {
+	var presets []model.GenerationPreset
+	err := r.db.WithContext(ctx).Where("user_id = ?", userID).
+		Order("updated_at DESC").Find(&presets).Error
+	return presets, err
+}
+
+func (r *generationPresetRepository) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var count int64
+	err := r.db.WithContext(ctx).Model(&model.GenerationPreset{}).Where("user_id = ?", userID).Count(&count).Error
+	return count, err
+}
+
+func (r *generationPresetRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Where("id = ?", id).Delete(&model.GenerationPreset{}).Error
+}
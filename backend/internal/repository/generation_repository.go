@@ -2,6 +2,8 @@ package repository
 
 import (
 	"context"
+	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/model"
 
@@ -13,11 +15,19 @@ import (
 type GenerationRepository interface {
 	Create(ctx context.Context, generation *model.Generation) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Generation, error)
+	GetByLessonID(ctx context.Context, lessonID uuid.UUID) (*model.Generation, error)
+	LinkLesson(ctx context.Context, id, lessonID uuid.UUID, lessonVersion int) error
 	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
-	UpdateResult(ctx context.Context, id uuid.UUID, result string, tokenCount int) error
-	UpdateError(ctx context.Context, id uuid.UUID, errorMsg string) error
+	UpdateResult(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64) error
+	UpdateError(ctx context.Context, id uuid.UUID, errorMsg string, durationMs int64) error
+	UpdateEvents(ctx context.Context, id uuid.UUID, events string) error
+	UpdateReview(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64, reason string) error
+	ListByStatus(ctx context.Context, status string, page, pageSize int) ([]model.Generation, int64, error)
+	ResolveReview(ctx context.Context, id uuid.UUID, status, errorMsg string) (bool, error)
 	ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Generation, int64, error)
+	SearchByUserID(ctx context.Context, userID uuid.UUID, query string, page, pageSize int) ([]model.Generation, int64, error)
 	GetStats(ctx context.Context, userID uuid.UUID) (*GenerationStats, error)
+	GetTimeseries(ctx context.Context, userID uuid.UUID, granularity string, since time.Time) ([]GenerationTimeBucket, error)
 }
 
 // GenerationStats 生成统计
@@ -31,6 +41,13 @@ type GenerationStats struct {
 	TotalLessons         int64   `json:"total_lessons"`
 }
 
+// GenerationTimeBucket 按时间段聚合的生成用量
+type GenerationTimeBucket struct {
+	BucketStart time.Time `json:"bucket_start"`
+	Count       int64     `json:"count"`
+	Tokens      int64     `json:"tokens"`
+}
+
 type generationRepository struct {
 	db *gorm.DB
 }
@@ -53,31 +70,112 @@ func (r *generationRepository) GetByID(ctx context.Context, id uuid.UUID) (*mode
 	return &generation, nil
 }
 
+// GetByLessonID 获取教案的来源生成记录，存在多条时取最早的一条
+func (r *generationRepository) GetByLessonID(ctx context.Context, lessonID uuid.UUID) (*model.Generation, error) {
+	var generation model.Generation
+	err := r.db.WithContext(ctx).Where("lesson_id = ?", lessonID).
+		Order("created_at ASC").First(&generation).Error
+	if err != nil {
+		return nil, err
+	}
+	return &generation, nil
+}
+
+// LinkLesson 将生成记录关联到由其创建的教案，已关联过的记录不会被覆盖
+func (r *generationRepository) LinkLesson(ctx context.Context, id, lessonID uuid.UUID, lessonVersion int) error {
+	result := r.db.WithContext(ctx).Model(&model.Generation{}).
+		Where("id = ? AND lesson_id IS NULL", id).
+		Updates(map[string]interface{}{
+			"lesson_id":      lessonID,
+			"lesson_version": lessonVersion,
+		})
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return gorm.ErrRecordNotFound
+	}
+	return nil
+}
+
 func (r *generationRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
 	return r.db.WithContext(ctx).Model(&model.Generation{}).Where("id = ?", id).
 		Update("status", status).Error
 }
 
-func (r *generationRepository) UpdateResult(ctx context.Context, id uuid.UUID, result string, tokenCount int) error {
+// UpdateResult 写入生成结果；durationMs 由调用方计时传入，不依赖数据库时钟
+func (r *generationRepository) UpdateResult(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64) error {
 	return r.db.WithContext(ctx).Model(&model.Generation{}).Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"result":      result,
 			"token_count": tokenCount, "completed_at": gorm.Expr("NOW()"),
-			"duration_ms": gorm.Expr("EXTRACT(EPOCH FROM (NOW() - created_at)) * 1000"),
+			"duration_ms": durationMs,
 			"status":      model.GenerationStatusCompleted,
 		}).Error
 }
 
-func (r *generationRepository) UpdateError(ctx context.Context, id uuid.UUID, errorMsg string) error {
+func (r *generationRepository) UpdateError(ctx context.Context, id uuid.UUID, errorMsg string, durationMs int64) error {
 	return r.db.WithContext(ctx).Model(&model.Generation{}).Where("id = ?", id).
 		Updates(map[string]interface{}{
 			"error_msg":    errorMsg,
 			"status":       model.GenerationStatusFailed,
 			"completed_at": gorm.Expr("NOW()"),
-			"duration_ms":  gorm.Expr("EXTRACT(EPOCH FROM (NOW() - created_at)) * 1000"),
+			"duration_ms":  durationMs,
+		}).Error
+}
+
+// UpdateEvents 写入生成阶段事件，events 为 JSON 数组
+func (r *generationRepository) UpdateEvents(ctx context.Context, id uuid.UUID, events string) error {
+	return r.db.WithContext(ctx).Model(&model.Generation{}).Where("id = ?", id).
+		Update("events", events).Error
+}
+
+// UpdateReview 写入未通过内容审核的生成结果，状态置为待人工复核
+func (r *generationRepository) UpdateReview(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64, reason string) error {
+	return r.db.WithContext(ctx).Model(&model.Generation{}).Where("id = ?", id).
+		Updates(map[string]interface{}{
+			"result":        result,
+			"token_count":   tokenCount,
+			"completed_at":  gorm.Expr("NOW()"),
+			"duration_ms":   durationMs,
+			"status":        model.GenerationStatusReview,
+			"review_reason": reason,
 		}).Error
 }
 
+// ListByStatus 按状态分页列出生成记录，最早的在前
+func (r *generationRepository) ListByStatus(ctx context.Context, status string, page, pageSize int) ([]model.Generation, int64, error) {
+	var generations []model.Generation
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.Generation{}).Where("status = ?", status)
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at ASC").Offset(offset).Limit(pageSize).Find(&generations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return generations, total, nil
+}
+
+// ResolveReview 结束人工复核；仅 review 状态的记录会被更新，返回是否更新
+func (r *generationRepository) ResolveReview(ctx context.Context, id uuid.UUID, status, errorMsg string) (bool, error) {
+	result := r.db.WithContext(ctx).Model(&model.Generation{}).
+		Where("id = ? AND status = ?", id, model.GenerationStatusReview).
+		Updates(map[string]interface{}{
+			"status":    status,
+			"error_msg": errorMsg,
+		})
+	if result.Error != nil {
+		return false, result.Error
+	}
+	return result.RowsAffected > 0, nil
+}
+
 func (r *generationRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Generation, int64, error) {
 	var generations []model.Generation
 	var total int64
@@ -96,6 +194,34 @@ func (r *generationRepository) ListByUserID(ctx context.Context, userID uuid.UUI
 	return generations, total, nil
 }
 
+// SearchByUserID 按提示词搜索用户的生成历史，空白分隔的多个关键词需同时命中；
+// prompt 上有 pg_trgm GIN 索引，中文关键词同样可走索引
+func (r *generationRepository) SearchByUserID(ctx context.Context, userID uuid.UUID, query string, page, pageSize int) ([]model.Generation, int64, error) {
+	var generations []model.Generation
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.Generation{}).Where("user_id = ?", userID)
+	for _, term := range strings.Fields(query) {
+		db = db.Where(`prompt ILIKE ? ESCAPE '\'`, "%"+escapeLikePattern(term)+"%")
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&generations).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return generations, total, nil
+}
+
+// escapeLikePattern 转义 LIKE 通配符，使关键词按字面匹配
+func escapeLikePattern(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
 func (r *generationRepository) GetStats(ctx context.Context, userID uuid.UUID) (*GenerationStats, error) {
 	var stats GenerationStats
 
@@ -123,3 +249,15 @@ func (r *generationRepository) GetStats(ctx context.Context, userID uuid.UUID) (
 
 	return &stats, nil
 }
+
+// GetTimeseries 按 date_trunc 粒度（day/week）聚合 since 之后的生成次数与 token，只返回有数据的时间段
+func (r *generationRepository) GetTimeseries(ctx context.Context, userID uuid.UUID, granularity string, since time.Time) ([]GenerationTimeBucket, error) {
+	var buckets []GenerationTimeBucket
+	err := r.db.WithContext(ctx).Model(&model.Generation{}).
+		Select("date_trunc(?, created_at) AS bucket_start, COUNT(*) AS count, COALESCE(SUM(token_count), 0) AS tokens", granularity).
+		Where("user_id = ? AND created_at >= ?", userID, since).
+		Group("bucket_start").
+		Order("bucket_start ASC").
+		Scan(&buckets).Error
+	return buckets, err
+}
@@ -0,0 +1,50 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"testing"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchByUserIDQuery(t *testing.T) {
+	var captured capturedSQL
+	repo := NewGenerationRepository(newDryRunDB(t, &captured))
+	userID := uuid.New()
+
+	if _, _, err := repo.SearchByUserID(context.Background(), userID, "光合作用  100%_达成", 2, 10); err != nil {
+		t.Fatalf("SearchByUserID() error = %v", err)
+	}
+	// 只查当前用户；每个关键词各一个 ILIKE 条件且需同时命中，通配符按字面匹配
+	wantSQL := `SELECT * FROM "generations" WHERE user_id = $1 AND prompt ILIKE $2 ESCAPE '\' AND prompt ILIKE $3 ESCAPE '\' ` +
+		`ORDER BY created_at DESC LIMIT 10 OFFSET 10`
+	if captured.sql != wantSQL {
+		t.Errorf("SearchByUserID() SQL =\n%s\nwant\n%s", captured.sql, wantSQL)
+	}
+	wantVars := []interface{}{userID, "%光合作用%", `%100\%\_达成%`}
+	if !reflect.DeepEqual(captured.vars, wantVars) {
+		t.Errorf("SearchByUserID() vars = %v, want %v", captured.vars, wantVars)
+	}
+}
+
+func TestEscapeLikePattern(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+	}{
+		{"光合作用", "光合作用"},
+		{"100%", `100\%`},
+		{"a_b", `a\_b`},
+		{`C:\path`, `C:\\path`},
+		{`\%_`, `\\\%\_`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			if got := escapeLikePattern(tt.in); got != tt.want {
+				t.Errorf("escapeLikePattern(%q) = %q, want %q", tt.in, got, tt.want)
+			}
+		})
+	}
+}
@@ -3,10 +3,13 @@ package repository
 import (
 	"context"
 	"fmt"
+	"regexp"
 	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/tenant"
 
 	"github.com/neo4j/neo4j-go-driver/v5/neo4j"
 )
@@ -17,11 +20,18 @@ type KnowledgeRepository interface {
 	GetByID(ctx context.Context, id string) (*model.Knowledge, error)
 	Update(ctx context.Context, knowledge *model.Knowledge) error
 	Delete(ctx context.Context, id string) error
-	Search(ctx context.Context, query string, limit int) ([]model.Knowledge, error)
+	Search(ctx context.Context, queries []string, limit int) ([]model.Knowledge, error)
 	SearchByEmbedding(ctx context.Context, embedding []float64, limit int) ([]model.Knowledge, error)
 	GetRelated(ctx context.Context, id string, limit int) ([]model.Knowledge, error)
 	CreateRelation(ctx context.Context, relation *model.KnowledgeRelation) error
-	GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int) (*model.KnowledgeGraph, error)
+	CreateBatch(ctx context.Context, knowledges []model.Knowledge) (int, error)
+	CreateRelationsBatch(ctx context.Context, relations []model.KnowledgeRelation) (int, error)
+	GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error)
+	ListKnowledgePoints(ctx context.Context, subject, grade, userId string, limit int) ([]model.Knowledge, error)
+	// FindSimilarNodes 以节点自身 embedding 做向量检索，found 为 false 表示节点不存在或尚无 embedding
+	FindSimilarNodes(ctx context.Context, id, userId string, limit int) (nodes []model.SimilarKnowledgeNode, found bool, err error)
+	// FindShortestPath 查询两个知识点之间的最短路径，found 为 false 表示起点或终点不存在
+	FindShortestPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (path *model.KnowledgePath, found bool, err error)
 }
 
 type knowledgeRepository struct {
@@ -60,6 +70,7 @@ func (r *knowledgeRepository) Create(ctx context.Context, knowledge *model.Knowl
 			description: $description,
 			keywords: $keywords,
 			embedding: $embedding,
+			tenantId: $tenantId,
 			created_at: datetime(),
 			updated_at: datetime()
 		})
@@ -76,6 +87,7 @@ func (r *knowledgeRepository) Create(ctx context.Context, knowledge *model.Knowl
 			"description": knowledge.Description,
 			"keywords":    knowledge.Keywords,
 			"embedding":   knowledge.Embedding,
+			"tenantId":    tenantOf(ctx),
 		})
 		return nil, err
 	})
@@ -158,21 +170,25 @@ func (r *knowledgeRepository) Delete(ctx context.Context, id string) error {
 	return err
 }
 
-func (r *knowledgeRepository) Search(ctx context.Context, query string, limit int) ([]model.Knowledge, error) {
+// Search 文本检索，名称或描述包含任一查询词即命中；名称命中首个查询词（原始查询）的排在前面
+func (r *knowledgeRepository) Search(ctx context.Context, queries []string, limit int) ([]model.Knowledge, error) {
 	session := r.session(ctx)
 	defer session.Close(ctx)
 
 	cypher := `
 		MATCH (k:Knowledge)
-		WHERE k.name CONTAINS $query OR k.description CONTAINS $query
+		WHERE any(q IN $queries WHERE k.name CONTAINS q OR k.description CONTAINS q)
+		  AND ($tenantId = '' OR COALESCE(k.tenantId, 'default') = $tenantId)
 		RETURN k
+		ORDER BY CASE WHEN k.name CONTAINS $queries[0] THEN 0 ELSE 1 END
 		LIMIT $limit
 	`
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		records, err := tx.Run(ctx, cypher, map[string]interface{}{
-			"query": query,
-			"limit": limit,
+			"queries":  queries,
+			"limit":    limit,
+			"tenantId": tenant.FromContext(ctx),
 		})
 		if err != nil {
 			return nil, err
@@ -202,6 +218,7 @@ func (r *knowledgeRepository) SearchByEmbedding(ctx context.Context, embedding [
 	cypher := `
 		CALL db.index.vector.queryNodes('knowledge_embedding', $limit, $embedding)
 		YIELD node, score
+		WHERE $tenantId = '' OR COALESCE(node.tenantId, 'default') = $tenantId
 		RETURN node, score
 		ORDER BY score DESC
 	`
@@ -210,6 +227,7 @@ func (r *knowledgeRepository) SearchByEmbedding(ctx context.Context, embedding [
 		records, err := tx.Run(ctx, cypher, map[string]interface{}{
 			"embedding": embedding,
 			"limit":     limit,
+			"tenantId":  tenant.FromContext(ctx),
 		})
 		if err != nil {
 			return nil, err
@@ -237,14 +255,16 @@ func (r *knowledgeRepository) GetRelated(ctx context.Context, id string, limit i
 
 	cypher := `
 		MATCH (k:Knowledge {id: $id})-[rel]-(related:Knowledge)
+		WHERE $tenantId = '' OR COALESCE(related.tenantId, 'default') = $tenantId
 		RETURN related, type(rel) as relType
 		LIMIT $limit
 	`
 
 	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
 		records, err := tx.Run(ctx, cypher, map[string]interface{}{
-			"id":    id,
-			"limit": limit,
+			"id":       id,
+			"limit":    limit,
+			"tenantId": tenant.FromContext(ctx),
 		})
 		if err != nil {
 			return nil, err
@@ -288,6 +308,202 @@ func (r *knowledgeRepository) CreateRelation(ctx context.Context, relation *mode
 	return err
 }
 
+// knowledgeBatchSize 单个 UNWIND 语句写入的最大条数，避免大文档一次事务过大
+const knowledgeBatchSize = 500
+
+// relationTypeIdentifier 关系类型只能拼接进 Cypher，限制为合法标识符防止注入
+var relationTypeIdentifier = regexp.MustCompile(`^[A-Za-z_][A-Za-z0-9_]*$`)
+
+// CreateBatch 使用 UNWIND 批量写入知识点，按 knowledgeBatchSize 分批、每批一个事务，返回成功写入的条数
+func (r *knowledgeRepository) CreateBatch(ctx context.Context, knowledges []model.Knowledge) (int, error) {
+	if len(knowledges) == 0 {
+		return 0, nil
+	}
+
+	session := r.session(ctx)
+	defer session.Close(ctx)
+
+	query := `
+		UNWIND $rows AS row
+		CREATE (k:Knowledge {
+			id: row.id,
+			name: row.name,
+			type: row.type,
+			subject: row.subject,
+			grade: row.grade,
+			description: row.description,
+			keywords: row.keywords,
+			embedding: row.embedding,
+			tenantId: $tenantId,
+			created_at: datetime(),
+			updated_at: datetime()
+		})
+	`
+
+	tenantID := tenantOf(ctx)
+	allRows := knowledgeRows(knowledges)
+	created := 0
+	for _, bounds := range batchBounds(len(allRows), knowledgeBatchSize) {
+		start := bounds[0]
+		rows := allRows[start:bounds[1]]
+
+		_, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+			_, err := tx.Run(ctx, query, map[string]interface{}{
+				"rows":     rows,
+				"tenantId": tenantID,
+			})
+			return nil, err
+		})
+		if err != nil {
+			return created, fmt.Errorf("批量写入知识点失败（第 %d 条起）: %w", start, err)
+		}
+		created += len(rows)
+	}
+
+	return created, nil
+}
+
+// CreateRelationsBatch 使用 UNWIND 批量写入关系。关系类型无法参数化，按类型分组后各自执行；
+// 两端节点不存在的关系会被 MATCH 跳过，返回值为实际创建的关系数
+func (r *knowledgeRepository) CreateRelationsBatch(ctx context.Context, relations []model.KnowledgeRelation) (int, error) {
+	if len(relations) == 0 {
+		return 0, nil
+	}
+
+	order, byType, err := relationRowsByType(relations)
+	if err != nil {
+		return 0, err
+	}
+
+	session := r.session(ctx)
+	defer session.Close(ctx)
+
+	created := 0
+	for _, relationType := range order {
+		cypher := fmt.Sprintf(`
+			UNWIND $rows AS row
+			MATCH (source:Knowledge {id: row.sourceId})
+			MATCH (target:Knowledge {id: row.targetId})
+			CREATE (source)-[:%s {weight: row.weight}]->(target)
+			RETURN count(*) AS created
+		`, relationType)
+
+		rows := byType[relationType]
+		for _, bounds := range batchBounds(len(rows), knowledgeBatchSize) {
+			batch := rows[bounds[0]:bounds[1]]
+			result, err := session.ExecuteWrite(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+				records, err := tx.Run(ctx, cypher, map[string]interface{}{"rows": batch})
+				if err != nil {
+					return nil, err
+				}
+				record, err := records.Single(ctx)
+				if err != nil {
+					return nil, err
+				}
+				count, _ := record.Get("created")
+				return count, nil
+			})
+			if err != nil {
+				return created, fmt.Errorf("批量写入 %s 关系失败: %w", relationType, err)
+			}
+			if count, ok := result.(int64); ok {
+				created += int(count)
+			}
+		}
+	}
+
+	return created, nil
+}
+
+// batchBounds 把 n 条记录按 size 切分为 [start, end) 区间
+func batchBounds(n, size int) [][2]int {
+	bounds := make([][2]int, 0, (n+size-1)/size)
+	for start := 0; start < n; start += size {
+		end := start + size
+		if end > n {
+			end = n
+		}
+		bounds = append(bounds, [2]int{start, end})
+	}
+	return bounds
+}
+
+// knowledgeRows 构造 CreateBatch 的 UNWIND 参数行
+func knowledgeRows(knowledges []model.Knowledge) []map[string]interface{} {
+	rows := make([]map[string]interface{}, 0, len(knowledges))
+	for _, knowledge := range knowledges {
+		rows = append(rows, map[string]interface{}{
+			"id":          knowledge.ID,
+			"name":        knowledge.Name,
+			"type":        knowledge.Type,
+			"subject":     knowledge.Subject,
+			"grade":       knowledge.Grade,
+			"description": knowledge.Description,
+			"keywords":    knowledge.Keywords,
+			"embedding":   knowledge.Embedding,
+		})
+	}
+	return rows
+}
+
+// relationRowsByType 按关系类型分组构造 UNWIND 参数行，类型按首次出现的顺序返回；含非法类型时整体拒绝
+func relationRowsByType(relations []model.KnowledgeRelation) ([]string, map[string][]map[string]interface{}, error) {
+	byType := make(map[string][]map[string]interface{})
+	order := make([]string, 0)
+	for _, relation := range relations {
+		if !relationTypeIdentifier.MatchString(relation.RelationType) {
+			return nil, nil, fmt.Errorf("非法的关系类型: %q", relation.RelationType)
+		}
+		if _, ok := byType[relation.RelationType]; !ok {
+			order = append(order, relation.RelationType)
+		}
+		byType[relation.RelationType] = append(byType[relation.RelationType], map[string]interface{}{
+			"sourceId": relation.SourceID,
+			"targetId": relation.TargetID,
+			"weight":   relation.Weight,
+		})
+	}
+	return order, byType, nil
+}
+
+// tenantOf 返回写入节点的租户，context 中未指定时归入默认租户
+func tenantOf(ctx context.Context) string {
+	if tenantID := tenant.FromContext(ctx); tenantID != "" {
+		return tenantID
+	}
+	return tenant.DefaultTenant
+}
+
+// tenantMatch 返回节点的租户过滤条件：$tenantId 为空（context 未指定租户）时不过滤，
+// 早期未写入 tenantId 的节点视为默认租户
+func tenantMatch(alias string) string {
+	return fmt.Sprintf("($tenantId = '' OR COALESCE(%s.tenantId, 'default') = $tenantId)", alias)
+}
+
+// KnowledgeRelationTypes 知识点之间支持的关系类型
+var KnowledgeRelationTypes = []string{"DEPENDS_ON", "RELATES_TO", "SIMILAR_TO", "PART_OF"}
+
+// relationTypePattern 生成 Cypher 关系类型匹配串（如 DEPENDS_ON|PART_OF）。
+// 关系类型无法参数化，只拼接白名单内的取值；为空或全部非法时匹配全部类型。
+func relationTypePattern(relationTypes []string) string {
+	allowed := make(map[string]bool, len(KnowledgeRelationTypes))
+	for _, relationType := range KnowledgeRelationTypes {
+		allowed[relationType] = true
+	}
+
+	selected := make([]string, 0, len(relationTypes))
+	for _, relationType := range relationTypes {
+		if allowed[relationType] {
+			selected = append(selected, relationType)
+			allowed[relationType] = false
+		}
+	}
+	if len(selected) == 0 {
+		selected = KnowledgeRelationTypes
+	}
+	return strings.Join(selected, "|")
+}
+
 func normalizeGraphScope(scope string) string {
 	switch strings.ToLower(strings.TrimSpace(scope)) {
 	case "matched", "one_hop", "two_hop":
@@ -346,60 +562,76 @@ func normalizeGraphNodeType(raw string) string {
 	return "KnowledgePoint"
 }
 
-func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int) (*model.KnowledgeGraph, error) {
+// createdAsOf 返回 asOf 快照过滤条件：未指定 $asOf 时不过滤；
+// 早期写入的节点和关系没有 createdAt，视为一直存在
+func createdAsOf(alias string) string {
+	return fmt.Sprintf("($asOf IS NULL OR %[1]s.createdAt IS NULL OR %[1]s.createdAt <= datetime($asOf))", alias)
+}
+
+// GetGraph 查询用户知识图谱，relationTypes 限定展示的边类型（扩展邻居时同样只沿这些关系），为空表示全部；
+// asOf 不为空时返回该时间点的快照：只包含此前创建的节点与关系
+func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error) {
 	session := r.session(ctx)
 	defer session.Close(ctx)
 
 	normalizedTopic := strings.TrimSpace(topic)
 	normalizedScope := normalizeGraphScope(scope)
+	relPattern := relationTypePattern(relationTypes)
 
 	params := map[string]interface{}{
-		"userId":  userId,
-		"subject": subject,
-		"grade":   grade,
-		"topic":   normalizedTopic,
-		"limit":   int64(limit),
+		"userId":   userId,
+		"tenantId": tenant.FromContext(ctx),
+		"subject":  subject,
+		"grade":    grade,
+		"topic":    normalizedTopic,
+		"limit":    int64(limit),
+		"asOf":     nil,
+	}
+	if asOf != nil {
+		params["asOf"] = asOf.Format(time.RFC3339Nano)
 	}
 
-	cypher := `
+	cypher := fmt.Sprintf(`
 		MATCH (k:KnowledgePoint)
-		WHERE k.userId = $userId
+		WHERE k.userId = $userId AND %s
 		  AND ($subject = '' OR k.subject = $subject OR k.subject IS NULL)
 		  AND ($grade = '' OR k.grade CONTAINS $grade OR k.grade IS NULL)
+		  AND %s
 		WITH k LIMIT $limit
-		OPTIONAL MATCH (k)-[rel:DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF]-(related:KnowledgePoint)
-		WHERE related.userId = $userId
+		OPTIONAL MATCH (k)-[rel:%s]-(related:KnowledgePoint)
+		WHERE related.userId = $userId AND %s AND %s AND %s
 		RETURN k, collect(DISTINCT {
 			source: k.id,
 			target: related.id,
 			type: type(rel),
 			weight: COALESCE(rel.strength, rel.similarity, 1.0)
 		}) as relations
-	`
+	`, tenantMatch("k"), createdAsOf("k"), relPattern, tenantMatch("related"), createdAsOf("related"), createdAsOf("rel"))
 
 	if normalizedTopic != "" {
 		if normalizedScope == "matched" {
-			cypher = `
+			cypher = fmt.Sprintf(`
 				MATCH (seed:KnowledgePoint)
-				WHERE seed.userId = $userId
+				WHERE seed.userId = $userId AND %s
 				  AND ($subject = '' OR seed.subject = $subject OR seed.subject IS NULL)
 				  AND ($grade = '' OR seed.grade CONTAINS $grade OR seed.grade IS NULL)
 				  AND (
 					toLower(COALESCE(seed.name, '')) CONTAINS toLower($topic)
 					OR any(kw IN COALESCE(seed.keywords, []) WHERE toLower(toString(kw)) CONTAINS toLower($topic))
 				  )
+				  AND %s
 				WITH seed LIMIT $limit
 				WITH collect(seed) AS nodes, collect(seed.id) AS nodeIDs
 				UNWIND nodes AS k
-				OPTIONAL MATCH (k)-[rel:DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF]-(related:KnowledgePoint)
-				WHERE related.id IN nodeIDs
+				OPTIONAL MATCH (k)-[rel:%s]-(related:KnowledgePoint)
+				WHERE related.id IN nodeIDs AND %s
 				RETURN k, collect(DISTINCT {
 					source: k.id,
 					target: related.id,
 					type: type(rel),
 					weight: COALESCE(rel.strength, rel.similarity, 1.0)
 				}) as relations
-			`
+			`, tenantMatch("seed"), createdAsOf("seed"), relPattern, createdAsOf("rel"))
 		} else {
 			depth := 1
 			if normalizedScope == "two_hop" {
@@ -408,34 +640,37 @@ func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topi
 
 			cypher = fmt.Sprintf(`
 				MATCH (seed:KnowledgePoint)
-				WHERE seed.userId = $userId
+				WHERE seed.userId = $userId AND %s
 				  AND ($subject = '' OR seed.subject = $subject OR seed.subject IS NULL)
 				  AND ($grade = '' OR seed.grade CONTAINS $grade OR seed.grade IS NULL)
 				  AND (
 					toLower(COALESCE(seed.name, '')) CONTAINS toLower($topic)
 					OR any(kw IN COALESCE(seed.keywords, []) WHERE toLower(toString(kw)) CONTAINS toLower($topic))
 				  )
+				  AND %s
 				WITH seed LIMIT $limit
 				WITH collect(seed) AS seeds
 				UNWIND seeds AS s
-				OPTIONAL MATCH (s)-[:DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF*1..%d]-(related:KnowledgePoint)
-				WHERE related.userId = $userId
+				OPTIONAL MATCH path = (s)-[:%s*1..%d]-(related:KnowledgePoint)
+				WHERE related.userId = $userId AND %s
 				  AND ($subject = '' OR related.subject = $subject OR related.subject IS NULL)
 				  AND ($grade = '' OR related.grade CONTAINS $grade OR related.grade IS NULL)
+				  AND all(n IN nodes(path) WHERE %s)
+				  AND all(r IN relationships(path) WHERE %s)
 				WITH seeds + collect(DISTINCT related) AS rawNodes
 				UNWIND rawNodes AS k
 				WITH DISTINCT k WHERE k IS NOT NULL
 				WITH collect(k) AS nodes, collect(k.id) AS nodeIDs
 				UNWIND nodes AS k
-				OPTIONAL MATCH (k)-[rel:DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF]-(related:KnowledgePoint)
-				WHERE related.id IN nodeIDs
+				OPTIONAL MATCH (k)-[rel:%s]-(related:KnowledgePoint)
+				WHERE related.id IN nodeIDs AND %s
 				RETURN k, collect(DISTINCT {
 					source: k.id,
 					target: related.id,
 					type: type(rel),
 					weight: COALESCE(rel.strength, rel.similarity, 1.0)
 				}) as relations
-			`, depth)
+			`, tenantMatch("seed"), createdAsOf("seed"), relPattern, depth, tenantMatch("related"), createdAsOf("n"), createdAsOf("r"), relPattern, createdAsOf("rel"))
 		}
 	}
 
@@ -459,10 +694,8 @@ func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topi
 			neo4jNode := node.(neo4j.Node)
 			props := neo4jNode.Props
 
-			nodeID := ""
-			if id, ok := props["id"].(string); ok {
-				nodeID = id
-			}
+			graphNode := knowledgeNodeFromProps(props, subject)
+			nodeID := graphNode.ID
 			if nodeID == "" {
 				continue
 			}
@@ -472,48 +705,8 @@ func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topi
 			}
 			nodeMap[nodeID] = true
 
-			nodeName := ""
-			if name, ok := props["name"].(string); ok {
-				nodeName = name
-			}
-
-			nodeGrade := ""
-			if g, ok := props["grade"].(string); ok {
-				nodeGrade = g
-			}
-
-			nodeType := "KnowledgePoint"
-			if t, ok := props["type"].(string); ok && t != "" {
-				nodeType = t
-			}
-			nodeType = normalizeGraphNodeType(nodeType)
-
-			nodeDifficulty := "medium"
-			if d, ok := props["difficulty"].(string); ok && d != "" {
-				nodeDifficulty = d
-			}
-
-			nodeImportance := 0.5
-			if imp, ok := props["importance"].(float64); ok {
-				nodeImportance = imp
-			}
-
-			nodeSubject := subject
-			if s, ok := props["subject"].(string); ok && s != "" {
-				nodeSubject = s
-			}
-
-			graph.Nodes = append(graph.Nodes, model.KnowledgeNode{
-				ID:         nodeID,
-				Label:      nodeName,
-				Type:       nodeType,
-				Subject:    nodeSubject,
-				Grade:      nodeGrade,
-				Difficulty: nodeDifficulty,
-				Importance: nodeImportance,
-			})
-
-			graph.TypeCounts[nodeType]++
+			graph.Nodes = append(graph.Nodes, graphNode)
+			graph.TypeCounts[graphNode.Type]++
 
 			relations, _ := records.Record().Get("relations")
 			if rels, ok := relations.([]interface{}); ok {
@@ -556,6 +749,7 @@ func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topi
 		graph.Edges = filteredEdges
 		graph.TotalNodes = len(graph.Nodes)
 		graph.TotalEdges = len(graph.Edges)
+		graph.AsOf = asOf
 
 		return graph, nil
 	})
@@ -567,6 +761,189 @@ func (r *knowledgeRepository) GetGraph(ctx context.Context, subject, grade, topi
 	return result.(*model.KnowledgeGraph), nil
 }
 
+// knowledgeNodeFromProps 将图谱节点属性转换为展示用节点，缺省字段取默认值，学科缺省时使用 fallbackSubject
+func knowledgeNodeFromProps(props map[string]interface{}, fallbackSubject string) model.KnowledgeNode {
+	node := model.KnowledgeNode{
+		Type:       "KnowledgePoint",
+		Subject:    fallbackSubject,
+		Difficulty: "medium",
+		Importance: 0.5,
+	}
+	if id, ok := props["id"].(string); ok {
+		node.ID = id
+	}
+	if name, ok := props["name"].(string); ok {
+		node.Label = name
+	}
+	if g, ok := props["grade"].(string); ok {
+		node.Grade = g
+	}
+	if t, ok := props["type"].(string); ok && t != "" {
+		node.Type = t
+	}
+	node.Type = normalizeGraphNodeType(node.Type)
+	if d, ok := props["difficulty"].(string); ok && d != "" {
+		node.Difficulty = d
+	}
+	if imp, ok := props["importance"].(float64); ok {
+		node.Importance = imp
+	}
+	if s, ok := props["subject"].(string); ok && s != "" {
+		node.Subject = s
+	}
+	if t, ok := props["createdAt"].(time.Time); ok {
+		node.CreatedAt = &t
+	}
+	return node
+}
+
+// ListKnowledgePoints 列出用户的知识点（含描述），按学科、名称排序
+func (r *knowledgeRepository) ListKnowledgePoints(ctx context.Context, subject, grade, userId string, limit int) ([]model.Knowledge, error) {
+	session := r.session(ctx)
+	defer session.Close(ctx)
+
+	cypher := `
+		MATCH (k:KnowledgePoint)
+		WHERE k.userId = $userId AND ` + tenantMatch("k") + `
+		  AND k.name IS NOT NULL
+		  AND ($subject = '' OR k.subject = $subject OR k.subject IS NULL)
+		  AND ($grade = '' OR k.grade CONTAINS $grade OR k.grade IS NULL)
+		RETURN k.id AS id, k.name AS name, k.type AS type, k.subject AS subject,
+		       k.grade AS grade, k.description AS description
+		ORDER BY COALESCE(k.subject, ''), k.name
+		LIMIT $limit
+	`
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		records, err := tx.Run(ctx, cypher, map[string]interface{}{
+			"userId":   userId,
+			"tenantId": tenant.FromContext(ctx),
+			"subject":  subject,
+			"grade":    grade,
+			"limit":    int64(limit),
+		})
+		if err != nil {
+			return nil, err
+		}
+
+		knowledges := []model.Knowledge{}
+		for records.Next(ctx) {
+			record := records.Record()
+			k := model.Knowledge{}
+			if v, ok := record.Get("id"); ok {
+				k.ID, _ = v.(string)
+			}
+			if v, ok := record.Get("name"); ok {
+				k.Name, _ = v.(string)
+			}
+			if v, ok := record.Get("type"); ok {
+				k.Type, _ = v.(string)
+			}
+			if v, ok := record.Get("subject"); ok {
+				k.Subject, _ = v.(string)
+			}
+			if v, ok := record.Get("grade"); ok {
+				k.Grade, _ = v.(string)
+			}
+			if v, ok := record.Get("description"); ok {
+				k.Description, _ = v.(string)
+			}
+			knowledges = append(knowledges, k)
+		}
+
+		return knowledges, records.Err()
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	return result.([]model.Knowledge), nil
+}
+
+// similarCandidateFactor 向量检索时多取的候选倍数，抵消按用户过滤与排除自身后的损耗
+const similarCandidateFactor = 3
+
+func (r *knowledgeRepository) FindSimilarNodes(ctx context.Context, id, userId string, limit int) ([]model.SimilarKnowledgeNode, bool, error) {
+	session := r.session(ctx)
+	defer session.Close(ctx)
+
+	sourceCypher := `
+		MATCH (k:KnowledgePoint {id: $id})
+		WHERE k.userId = $userId AND ` + tenantMatch("k") + ` AND k.embedding IS NOT NULL
+		RETURN k.id AS id
+		LIMIT 1
+	`
+	cypher := `
+		MATCH (k:KnowledgePoint {id: $id})
+		WHERE k.userId = $userId AND ` + tenantMatch("k") + ` AND k.embedding IS NOT NULL
+		CALL db.index.vector.queryNodes('knowledge_embedding', $candidates, k.embedding)
+		YIELD node, score
+		WHERE node.id <> k.id AND node.userId = $userId AND ` + tenantMatch("node") + ` AND node:KnowledgePoint
+		RETURN node, score, EXISTS((k)--(node)) AS connected
+		ORDER BY score DESC
+		LIMIT $limit
+	`
+
+	type similarResult struct {
+		nodes []model.SimilarKnowledgeNode
+		found bool
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"id":         id,
+			"userId":     userId,
+			"tenantId":   tenant.FromContext(ctx),
+			"limit":      int64(limit),
+			"candidates": int64(limit * similarCandidateFactor),
+		}
+
+		source, err := tx.Run(ctx, sourceCypher, params)
+		if err != nil {
+			return nil, err
+		}
+		if !source.Next(ctx) {
+			return similarResult{}, source.Err()
+		}
+
+		records, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+
+		nodes := []model.SimilarKnowledgeNode{}
+		for records.Next(ctx) {
+			record := records.Record()
+			value, ok := record.Get("node")
+			if !ok {
+				continue
+			}
+			node, ok := value.(neo4j.Node)
+			if !ok {
+				continue
+			}
+			similar := model.SimilarKnowledgeNode{KnowledgeNode: knowledgeNodeFromProps(node.Props, "")}
+			if v, ok := record.Get("score"); ok {
+				similar.Score, _ = v.(float64)
+			}
+			if v, ok := record.Get("connected"); ok {
+				similar.Connected, _ = v.(bool)
+			}
+			nodes = append(nodes, similar)
+		}
+
+		return similarResult{nodes: nodes, found: true}, records.Err()
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	res := result.(similarResult)
+	return res.nodes, res.found, nil
+}
+
 func (r *knowledgeRepository) nodeToKnowledge(node neo4j.Node) *model.Knowledge {
 	props := node.Props
 
@@ -604,3 +981,110 @@ func (r *knowledgeRepository) nodeToKnowledge(node neo4j.Node) *model.Knowledge
 
 	return k
 }
+
+// FindShortestPath 用 shortestPath 查询用户图谱中两个知识点之间的最短路径，from/to 可以是节点 id 或名称；
+// 关系方向不限，路径只经过该用户的节点与 relationTypes 指定的关系，最多 maxDepth 跳
+func (r *knowledgeRepository) FindShortestPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (*model.KnowledgePath, bool, error) {
+	session := r.session(ctx)
+	defer session.Close(ctx)
+
+	endpointsCypher := `
+		OPTIONAL MATCH (a:KnowledgePoint)
+		WHERE a.userId = $userId AND ` + tenantMatch("a") + ` AND (a.id = $from OR a.name = $from)
+		WITH collect(a) AS sources
+		OPTIONAL MATCH (b:KnowledgePoint)
+		WHERE b.userId = $userId AND ` + tenantMatch("b") + ` AND (b.id = $to OR b.name = $to)
+		RETURN size(sources) > 0 AND count(b) > 0 AS found
+	`
+	cypher := fmt.Sprintf(`
+		MATCH (a:KnowledgePoint), (b:KnowledgePoint)
+		WHERE a.userId = $userId AND %[3]s AND (a.id = $from OR a.name = $from)
+		  AND b.userId = $userId AND %[4]s AND (b.id = $to OR b.name = $to)
+		  AND a <> b
+		MATCH p = shortestPath((a)-[:%[1]s*..%[2]d]-(b))
+		WHERE all(n IN nodes(p) WHERE n.userId = $userId AND %[5]s)
+		RETURN nodes(p) AS pathNodes, [rel IN relationships(p) | {
+			source: startNode(rel).id,
+			target: endNode(rel).id,
+			type: type(rel),
+			weight: COALESCE(rel.strength, rel.similarity, 1.0)
+		}] AS pathEdges
+		ORDER BY length(p)
+		LIMIT 1
+	`, relationTypePattern(relationTypes), maxDepth, tenantMatch("a"), tenantMatch("b"), tenantMatch("n"))
+
+	type pathResult struct {
+		path  *model.KnowledgePath
+		found bool
+	}
+
+	result, err := session.ExecuteRead(ctx, func(tx neo4j.ManagedTransaction) (interface{}, error) {
+		params := map[string]interface{}{
+			"from":     from,
+			"to":       to,
+			"userId":   userId,
+			"tenantId": tenant.FromContext(ctx),
+		}
+
+		endpoints, err := tx.Run(ctx, endpointsCypher, params)
+		if err != nil {
+			return nil, err
+		}
+		if !endpoints.Next(ctx) {
+			return pathResult{}, endpoints.Err()
+		}
+		found, _ := endpoints.Record().Get("found")
+		if ok, _ := found.(bool); !ok {
+			return pathResult{}, nil
+		}
+
+		records, err := tx.Run(ctx, cypher, params)
+		if err != nil {
+			return nil, err
+		}
+
+		path := &model.KnowledgePath{
+			Nodes: []model.KnowledgeNode{},
+			Edges: []model.KnowledgeEdge{},
+		}
+		if records.Next(ctx) {
+			record := records.Record()
+			path.Found = true
+			if value, ok := record.Get("pathNodes"); ok {
+				items, _ := value.([]interface{})
+				for _, item := range items {
+					if node, ok := item.(neo4j.Node); ok {
+						path.Nodes = append(path.Nodes, knowledgeNodeFromProps(node.Props, ""))
+					}
+				}
+			}
+			if value, ok := record.Get("pathEdges"); ok {
+				items, _ := value.([]interface{})
+				for _, item := range items {
+					relMap, ok := item.(map[string]interface{})
+					if !ok {
+						continue
+					}
+					edge := model.KnowledgeEdge{Weight: 1.0}
+					edge.Source, _ = relMap["source"].(string)
+					edge.Target, _ = relMap["target"].(string)
+					edge.Type, _ = relMap["type"].(string)
+					if w, ok := relMap["weight"].(float64); ok {
+						edge.Weight = w
+					}
+					path.Edges = append(path.Edges, edge)
+				}
+			}
+			path.Length = len(path.Edges)
+		}
+
+		return pathResult{path: path, found: true}, records.Err()
+	})
+
+	if err != nil {
+		return nil, false, err
+	}
+
+	res := result.(pathResult)
+	return res.path, res.found, nil
+}
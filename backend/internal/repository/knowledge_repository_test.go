@@ -0,0 +1,153 @@
+package repository
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+)
+
+func TestRelationTypePattern(t *testing.T) {
+	tests := []struct {
+		name          string
+		relationTypes []string
+		want          string
+	}{
+		{name: "empty matches all", want: "DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF"},
+		{name: "single type", relationTypes: []string{"PART_OF"}, want: "PART_OF"},
+		{name: "keeps order and drops duplicates", relationTypes: []string{"SIMILAR_TO", "DEPENDS_ON", "SIMILAR_TO"}, want: "SIMILAR_TO|DEPENDS_ON"},
+		{name: "unknown types are never spliced", relationTypes: []string{"PART_OF]-() DETACH DELETE (n)//"}, want: "DEPENDS_ON|RELATES_TO|SIMILAR_TO|PART_OF"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := relationTypePattern(tt.relationTypes); got != tt.want {
+				t.Errorf("relationTypePattern(%q) = %q, want %q", tt.relationTypes, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBatchBounds(t *testing.T) {
+	tests := []struct {
+		name string
+		n    int
+		size int
+		want [][2]int
+	}{
+		{name: "empty", n: 0, size: 500, want: [][2]int{}},
+		{name: "smaller than one batch", n: 3, size: 500, want: [][2]int{{0, 3}}},
+		{name: "exactly one batch", n: 500, size: 500, want: [][2]int{{0, 500}}},
+		{name: "one over", n: 501, size: 500, want: [][2]int{{0, 500}, {500, 501}}},
+		{name: "several batches", n: 1200, size: 500, want: [][2]int{{0, 500}, {500, 1000}, {1000, 1200}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := batchBounds(tt.n, tt.size); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("batchBounds(%d, %d) = %v, want %v", tt.n, tt.size, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestKnowledgeRows(t *testing.T) {
+	knowledges := []model.Knowledge{
+		{ID: "k1", Name: "勾股定理", Type: "concept", Subject: "数学", Grade: "八年级", Description: "直角三角形三边关系", Keywords: []string{"直角"}, Embedding: []float64{0.1, 0.2}},
+		{ID: "k2", Name: "平方根"},
+	}
+
+	rows := knowledgeRows(knowledges)
+	if len(rows) != 2 {
+		t.Fatalf("knowledgeRows() len = %d, want 2", len(rows))
+	}
+	want := map[string]interface{}{
+		"id": "k1", "name": "勾股定理", "type": "concept", "subject": "数学", "grade": "八年级",
+		"description": "直角三角形三边关系", "keywords": []string{"直角"}, "embedding": []float64{0.1, 0.2},
+	}
+	if !reflect.DeepEqual(rows[0], want) {
+		t.Errorf("knowledgeRows()[0] = %v, want %v", rows[0], want)
+	}
+	if rows[1]["id"] != "k2" || rows[1]["name"] != "平方根" {
+		t.Errorf("knowledgeRows()[1] = %v", rows[1])
+	}
+}
+
+func TestRelationRowsByType(t *testing.T) {
+	relations := []model.KnowledgeRelation{
+		{SourceID: "a", TargetID: "b", RelationType: "PART_OF", Weight: 1},
+		{SourceID: "b", TargetID: "c", RelationType: "DEPENDS_ON", Weight: 0.5},
+		{SourceID: "c", TargetID: "d", RelationType: "PART_OF", Weight: 0.8},
+	}
+
+	order, byType, err := relationRowsByType(relations)
+	if err != nil {
+		t.Fatalf("relationRowsByType() error = %v", err)
+	}
+	if !reflect.DeepEqual(order, []string{"PART_OF", "DEPENDS_ON"}) {
+		t.Errorf("order = %v, want first-seen order", order)
+	}
+	wantPartOf := []map[string]interface{}{
+		{"sourceId": "a", "targetId": "b", "weight": 1.0},
+		{"sourceId": "c", "targetId": "d", "weight": 0.8},
+	}
+	if !reflect.DeepEqual(byType["PART_OF"], wantPartOf) {
+		t.Errorf("PART_OF rows = %v, want %v", byType["PART_OF"], wantPartOf)
+	}
+	if len(byType["DEPENDS_ON"]) != 1 {
+		t.Errorf("DEPENDS_ON rows = %v, want 1 row", byType["DEPENDS_ON"])
+	}
+
+	// 关系类型会拼进 Cypher，含非法字符时整批拒绝
+	for _, bad := range []string{"", "PART OF", "X]->() DETACH DELETE (n)//", "1ST"} {
+		_, _, err := relationRowsByType([]model.KnowledgeRelation{{SourceID: "a", TargetID: "b", RelationType: bad}})
+		if err == nil {
+			t.Errorf("relationRowsByType(%q) error = nil, want error", bad)
+		}
+	}
+}
+
+func TestCreatedAsOf(t *testing.T) {
+	want := "($asOf IS NULL OR rel.createdAt IS NULL OR rel.createdAt <= datetime($asOf))"
+	if got := createdAsOf("rel"); got != want {
+		t.Errorf("createdAsOf(rel) = %q, want %q", got, want)
+	}
+}
+
+func TestKnowledgeNodeFromProps(t *testing.T) {
+	createdAt := time.Date(2026, 9, 1, 8, 0, 0, 0, time.UTC)
+	tests := []struct {
+		name  string
+		props map[string]interface{}
+		want  model.KnowledgeNode
+	}{
+		{
+			name: "all fields",
+			props: map[string]interface{}{
+				"id": "k1", "name": "有理数", "grade": "七年级", "type": "Concept",
+				"difficulty": "hard", "importance": 0.9, "subject": "数学", "createdAt": createdAt,
+			},
+			want: model.KnowledgeNode{
+				ID: "k1", Label: "有理数", Type: "Concept", Subject: "数学", Grade: "七年级",
+				Difficulty: "hard", Importance: 0.9, CreatedAt: &createdAt,
+			},
+		},
+		{
+			// 缺省字段取默认值，学科回落到 fallback
+			name:  "defaults",
+			props: map[string]interface{}{"id": "k2", "type": "", "difficulty": "", "importance": "high"},
+			want: model.KnowledgeNode{
+				ID: "k2", Type: "KnowledgePoint", Subject: "物理", Difficulty: "medium", Importance: 0.5,
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := knowledgeNodeFromProps(tt.props, "物理"); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("knowledgeNodeFromProps() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,27 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/tenant"
+)
+
+func (r *lessonRepository) ArchiveInactive(ctx context.Context, cutoff time.Time) (int64, error) {
+	// 只改状态与归档时间，不更新 updated_at，保留最后一次编辑时间；
+	// 定时任务不带租户时归档所有租户的教案，带租户时只处理该租户
+	sql := `
+		UPDATE lessons SET status = ?, archived_at = ?
+		WHERE status = ? AND deleted_at IS NULL AND updated_at < ?
+			AND NOT EXISTS (SELECT 1 FROM lesson_likes WHERE lesson_id = lessons.id AND created_at >= ?)
+			AND NOT EXISTS (SELECT 1 FROM lesson_favorites WHERE lesson_id = lessons.id AND created_at >= ?)
+			AND NOT EXISTS (SELECT 1 FROM lesson_comments WHERE lesson_id = lessons.id AND created_at >= ? AND deleted_at IS NULL)`
+	args := []interface{}{model.LessonStatusArchived, time.Now(), model.LessonStatusPublished, cutoff, cutoff, cutoff, cutoff}
+	if tenantID := tenant.FromContext(ctx); tenantID != "" {
+		sql += " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	result := r.db.WithContext(ctx).Exec(sql, args...)
+	return result.RowsAffected, result.Error
+}
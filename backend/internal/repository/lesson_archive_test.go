@@ -0,0 +1,44 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+)
+
+func TestArchiveInactiveQuery(t *testing.T) {
+	var captured capturedSQL
+	r := &lessonRepository{db: newDryRunDB(t, &captured)}
+	cutoff := time.Now().AddDate(0, 0, -180)
+
+	if _, err := r.ArchiveInactive(context.Background(), cutoff); err != nil {
+		t.Fatalf("ArchiveInactive() error = %v", err)
+	}
+	for _, want := range []string{
+		"UPDATE lessons SET status = $1, archived_at = $2",
+		"WHERE status = $3 AND deleted_at IS NULL AND updated_at < $4",
+		"NOT EXISTS (SELECT 1 FROM lesson_likes WHERE lesson_id = lessons.id AND created_at >= $5)",
+		"NOT EXISTS (SELECT 1 FROM lesson_favorites WHERE lesson_id = lessons.id AND created_at >= $6)",
+		"NOT EXISTS (SELECT 1 FROM lesson_comments WHERE lesson_id = lessons.id AND created_at >= $7 AND deleted_at IS NULL)",
+	} {
+		if !strings.Contains(captured.sql, want) {
+			t.Errorf("SQL = %s, want it to contain %q", captured.sql, want)
+		}
+	}
+	// 不更新 updated_at，保留最后一次编辑时间
+	if strings.Contains(captured.sql, "updated_at =") {
+		t.Errorf("SQL = %s, want updated_at left unchanged", captured.sql)
+	}
+
+	if len(captured.vars) != 7 || captured.vars[0] != model.LessonStatusArchived || captured.vars[2] != model.LessonStatusPublished {
+		t.Fatalf("vars = %v, want archived/published statuses", captured.vars)
+	}
+	for _, i := range []int{3, 4, 5, 6} {
+		if got, ok := captured.vars[i].(time.Time); !ok || !got.Equal(cutoff) {
+			t.Errorf("vars[%d] = %v, want cutoff %v", i, captured.vars[i], cutoff)
+		}
+	}
+}
@@ -0,0 +1,132 @@
+package repository
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// LessonEditRepository 教案编辑操作记录仓库接口
+type LessonEditRepository interface {
+	Create(ctx context.Context, edit *model.LessonEdit) error
+	ListByLessonID(ctx context.Context, lessonID uuid.UUID, limit int) ([]model.LessonEdit, error)
+	LatestApplied(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error)
+	EarliestUndone(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error)
+	SetUndone(ctx context.Context, id uuid.UUID, undone bool) error
+	DiscardUndone(ctx context.Context, lessonID uuid.UUID) error
+	CountByState(ctx context.Context, lessonID uuid.UUID) (applied, undone int64, err error)
+	Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error)
+}
+
+type lessonEditRepository struct {
+	db *gorm.DB
+}
+
+// NewLessonEditRepository 创建教案编辑操作记录仓库
+func NewLessonEditRepository(db *gorm.DB) LessonEditRepository {
+	return &lessonEditRepository{db: db}
+}
+
+func (r *lessonEditRepository) Create(ctx context.Context, edit *model.LessonEdit) error {
+	return r.db.WithContext(ctx).Create(edit).Error
+}
+
+// ListByLessonID 按时间倒序列出最近的编辑记录
+func (r *lessonEditRepository) ListByLessonID(ctx context.Context, lessonID uuid.UUID, limit int) ([]model.LessonEdit, error) {
+	var edits []model.LessonEdit
+	err := r.db.WithContext(ctx).
+		Select("id", "lesson_id", "user_id", "fields", "undone", "created_at").
+		Where("lesson_id = ?", lessonID).
+		Order("created_at DESC").
+		Limit(limit).
+		Find(&edits).Error
+	return edits, err
+}
+
+// LatestApplied 栈顶：最近一次未撤销的编辑
+func (r *lessonEditRepository) LatestApplied(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error) {
+	var edit model.LessonEdit
+	err := r.db.WithContext(ctx).
+		Where("lesson_id = ? AND undone = ?", lessonID, false).
+		Order("created_at DESC").
+		First(&edit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &edit, nil
+}
+
+// EarliestUndone 下一个可重做的编辑：已撤销记录中最早的一条
+func (r *lessonEditRepository) EarliestUndone(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error) {
+	var edit model.LessonEdit
+	err := r.db.WithContext(ctx).
+		Where("lesson_id = ? AND undone = ?", lessonID, true).
+		Order("created_at ASC").
+		First(&edit).Error
+	if err != nil {
+		return nil, err
+	}
+	return &edit, nil
+}
+
+func (r *lessonEditRepository) SetUndone(ctx context.Context, id uuid.UUID, undone bool) error {
+	return r.db.WithContext(ctx).Model(&model.LessonEdit{}).
+		Where("id = ?", id).
+		Update("undone", undone).Error
+}
+
+// DiscardUndone 产生新编辑后，已撤销的记录不能再重做，直接删除
+func (r *lessonEditRepository) DiscardUndone(ctx context.Context, lessonID uuid.UUID) error {
+	return r.db.WithContext(ctx).
+		Where("lesson_id = ? AND undone = ?", lessonID, true).
+		Delete(&model.LessonEdit{}).Error
+}
+
+// CountByState 统计可撤销与可重做的记录数
+func (r *lessonEditRepository) CountByState(ctx context.Context, lessonID uuid.UUID) (int64, int64, error) {
+	var rows []struct {
+		Undone bool
+		Total  int64
+	}
+	err := r.db.WithContext(ctx).Model(&model.LessonEdit{}).
+		Select("undone, COUNT(*) AS total").
+		Where("lesson_id = ?", lessonID).
+		Group("undone").
+		Scan(&rows).Error
+	if err != nil {
+		return 0, 0, err
+	}
+
+	var applied, undone int64
+	for _, row := range rows {
+		if row.Undone {
+			undone = row.Total
+		} else {
+			applied = row.Total
+		}
+	}
+	return applied, undone, nil
+}
+
+// Prune 只保留最近 keep 条记录，返回清理条数
+func (r *lessonEditRepository) Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	recent := r.db.WithContext(ctx).
+		Model(&model.LessonEdit{}).
+		Select("id").
+		Where("lesson_id = ?", lessonID).
+		Order("created_at DESC").
+		Limit(keep)
+
+	result := r.db.WithContext(ctx).
+		Where("lesson_id = ?", lessonID).
+		Where("id NOT IN (?)", recent).
+		Delete(&model.LessonEdit{})
+	return result.RowsAffected, result.Error
+}
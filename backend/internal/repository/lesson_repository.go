@@ -2,24 +2,36 @@ package repository
 
 import (
 	"context"
+	"encoding/json"
+	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/tenant"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // LessonRepository 教案仓库接口
 type LessonRepository interface {
 	Create(ctx context.Context, lesson *model.Lesson) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Lesson, error)
+	// GetByIDUnscoped 按 ID 获取教案，包含已删除的教案
+	GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*model.Lesson, error)
 	Update(ctx context.Context, lesson *model.Lesson) error
 	Delete(ctx context.Context, id uuid.UUID) error
+	// Purge 永久删除教案记录，不可恢复
+	Purge(ctx context.Context, id uuid.UUID) error
 	List(ctx context.Context, filter LessonFilter, page, pageSize int) ([]model.Lesson, int64, error)
 	ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error)
 	IncrementViewCount(ctx context.Context, id uuid.UUID) error
+	UpdateCover(ctx context.Context, id uuid.UUID, coverURL string) error
 	UpdateCounts(ctx context.Context, id uuid.UUID) error
-	Search(ctx context.Context, query string, page, pageSize int) ([]model.Lesson, int64, error)
+	Search(ctx context.Context, query string, viewerID *uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error)
+	// ArchiveInactive 归档 cutoff 之后既未更新、也没有新点赞/收藏/评论的已发布教案，返回归档数量
+	ArchiveInactive(ctx context.Context, cutoff time.Time) (int64, error)
 }
 
 // LessonFilter 教案过滤器
@@ -29,6 +41,119 @@ type LessonFilter struct {
 	Status  string
 	UserID  *uuid.UUID
 	Keyword string
+
+	// Tags 按标签筛选（精确匹配），默认包含任一标签即可；TagsMatchAll 为 true 时须包含全部标签
+	Tags         []string
+	TagsMatchAll bool
+
+	// VisibleOnly 为 true 时只返回 Viewer 有权查看的教案，Viewer 为空表示匿名访问；Viewer 同时用于标记列表项的点赞、收藏状态
+	VisibleOnly bool
+	Viewer      *uuid.UUID
+
+	// SortBy 排序方式，默认按创建时间倒序；LessonSortGrade 按年级标准值升序，无法识别的年级排在最后
+	SortBy string
+
+	// SortOrder 排序方向 asc/desc，未设置时沿用各排序方式的默认方向
+	SortOrder string
+}
+
+// 教案列表排序方式
+const (
+	LessonSortCreated   = "created"
+	LessonSortGrade     = "grade"
+	LessonSortCreatedAt = "created_at"
+	LessonSortViews     = "view_count"
+	LessonSortLikes     = "like_count"
+	LessonSortFavorites = "favorite_count"
+)
+
+// 教案列表排序方向
+const (
+	SortOrderAsc  = "asc"
+	SortOrderDesc = "desc"
+)
+
+// lessonSortColumns 允许排序的列白名单，排序参数只能映射到这里的列名，避免拼接 SQL
+var lessonSortColumns = map[string]string{
+	LessonSortCreated:   "created_at",
+	LessonSortCreatedAt: "created_at",
+	LessonSortViews:     "view_count",
+	LessonSortLikes:     "like_count",
+	LessonSortFavorites: "favorite_count",
+}
+
+// IsValidLessonSort 排序方式是否受支持，空值表示默认排序
+func IsValidLessonSort(sortBy string) bool {
+	if sortBy == "" || sortBy == LessonSortGrade {
+		return true
+	}
+	_, ok := lessonSortColumns[sortBy]
+	return ok
+}
+
+// lessonOrderClause 将排序参数映射为 SQL 排序子句，未知排序方式按创建时间倒序，未知方向按默认方向。
+// 年级排序默认升序；其余默认倒序，并以创建时间倒序打破并列。
+func lessonOrderClause(sortBy, sortOrder string) string {
+	if sortBy == LessonSortGrade {
+		direction := "ASC"
+		if sortOrder == SortOrderDesc {
+			direction = "DESC"
+		}
+		return "grade_level = 0, grade_level " + direction + ", created_at DESC"
+	}
+
+	column, ok := lessonSortColumns[sortBy]
+	if !ok {
+		return "created_at DESC"
+	}
+	direction := "DESC"
+	if sortOrder == SortOrderAsc {
+		direction = "ASC"
+	}
+	if column == "created_at" {
+		return "created_at " + direction
+	}
+	return column + " " + direction + ", created_at DESC"
+}
+
+// gradeFilter 年级筛选：能归一化的按标准值匹配（“初一”与“七年级”视为同一年级），否则按原始写法精确匹配
+func gradeFilter(grade string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if level := model.NormalizeGrade(grade); level != model.GradeLevelUnknown {
+			return db.Where("grade_level = ?", level)
+		}
+		return db.Where("grade = ?", grade)
+	}
+}
+
+// tagsFilter 标签筛选，使用 jsonb 包含查询以命中 tags 上的 GIN 索引
+func tagsFilter(tags []string, matchAll bool) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if matchAll {
+			all, _ := json.Marshal(tags)
+			return db.Where("tags @> ?::jsonb", string(all))
+		}
+
+		conditions := make([]string, 0, len(tags))
+		args := make([]interface{}, 0, len(tags))
+		for _, tag := range tags {
+			single, _ := json.Marshal([]string{tag})
+			conditions = append(conditions, "tags @> ?::jsonb")
+			args = append(args, string(single))
+		}
+		return db.Where("("+strings.Join(conditions, " OR ")+")", args...)
+	}
+}
+
+// visibleToViewer 教案可见性条件：已发布的教案对所有人可见，作者可见自己的全部教案。
+// 新增可见范围（如组织内可见）时在此扩展，搜索与列表共用同一套规则。
+func visibleToViewer(viewer *uuid.UUID) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if viewer == nil {
+			return db.Where("status = ?", model.LessonStatusPublished)
+		}
+		return db.Where("(status = ? OR user_id = ?)", model.LessonStatusPublished, *viewer)
+	}
 }
 
 type lessonRepository struct {
@@ -57,10 +182,23 @@ func (r *lessonRepository) Update(ctx context.Context, lesson *model.Lesson) err
 	return r.db.WithContext(ctx).Save(lesson).Error
 }
 
+func (r *lessonRepository) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*model.Lesson, error) {
+	var lesson model.Lesson
+	err := r.db.WithContext(ctx).Unscoped().Where("id = ?", id).First(&lesson).Error
+	if err != nil {
+		return nil, err
+	}
+	return &lesson, nil
+}
+
 func (r *lessonRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Lesson{}, "id = ?", id).Error
 }
 
+func (r *lessonRepository) Purge(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Delete(&model.Lesson{}, "id = ?", id).Error
+}
+
 func (r *lessonRepository) List(ctx context.Context, filter LessonFilter, page, pageSize int) ([]model.Lesson, int64, error) {
 	var lessons []model.Lesson
 	var total int64
@@ -71,7 +209,7 @@ func (r *lessonRepository) List(ctx context.Context, filter LessonFilter, page,
 		db = db.Where("subject = ?", filter.Subject)
 	}
 	if filter.Grade != "" {
-		db = db.Where("grade = ?", filter.Grade)
+		db = db.Scopes(gradeFilter(filter.Grade))
 	}
 	if filter.Status != "" {
 		db = db.Where("status = ?", filter.Status)
@@ -80,7 +218,13 @@ func (r *lessonRepository) List(ctx context.Context, filter LessonFilter, page,
 		db = db.Where("user_id = ?", *filter.UserID)
 	}
 	if filter.Keyword != "" {
-		db = db.Where("title ILIKE ? OR content ILIKE ?", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
+		db = db.Where("(title ILIKE ? OR content ILIKE ?)", "%"+filter.Keyword+"%", "%"+filter.Keyword+"%")
+	}
+	if len(filter.Tags) > 0 {
+		db = db.Scopes(tagsFilter(filter.Tags, filter.TagsMatchAll))
+	}
+	if filter.VisibleOnly {
+		db = db.Scopes(visibleToViewer(filter.Viewer))
 	}
 
 	if err := db.Count(&total).Error; err != nil {
@@ -88,7 +232,7 @@ func (r *lessonRepository) List(ctx context.Context, filter LessonFilter, page,
 	}
 
 	offset := (page - 1) * pageSize
-	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&lessons).Error; err != nil {
+	if err := db.Order(lessonOrderClause(filter.SortBy, filter.SortOrder)).Offset(offset).Limit(pageSize).Find(&lessons).Error; err != nil {
 		return nil, 0, err
 	}
 
@@ -104,18 +248,37 @@ func (r *lessonRepository) IncrementViewCount(ctx context.Context, id uuid.UUID)
 		UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
 }
 
+// UpdateCover 只更新封面地址，不影响正文、版本号与更新时间
+func (r *lessonRepository) UpdateCover(ctx context.Context, id uuid.UUID, coverURL string) error {
+	return r.db.WithContext(ctx).Model(&model.Lesson{}).Where("id = ?", id).
+		UpdateColumn("cover_url", coverURL).Error
+}
+
+// UpdateCounts 原生 SQL 不经过租户回调，显式带上当前租户条件
 func (r *lessonRepository) UpdateCounts(ctx context.Context, id uuid.UUID) error {
-	return r.db.WithContext(ctx).Exec(`
+	sql := `
 		UPDATE lessons SET
 			like_count = (SELECT COUNT(*) FROM lesson_likes WHERE lesson_id = lessons.id),
 			favorite_count = (SELECT COUNT(*) FROM lesson_favorites WHERE lesson_id = lessons.id),
 			comment_count = (SELECT COUNT(*) FROM lesson_comments WHERE lesson_id = lessons.id AND deleted_at IS NULL)
-		WHERE id = ?
-	`, id).Error
+		WHERE id = ?`
+	args := []interface{}{id}
+	if tenantID := tenant.FromContext(ctx); tenantID != "" {
+		sql += " AND tenant_id = ?"
+		args = append(args, tenantID)
+	}
+	return r.db.WithContext(ctx).Exec(sql, args...).Error
 }
 
-func (r *lessonRepository) Search(ctx context.Context, query string, page, pageSize int) ([]model.Lesson, int64, error) {
-	return r.List(ctx, LessonFilter{Keyword: query, Status: model.LessonStatusPublished}, page, pageSize)
+// scopeTenant 显式追加当前请求的租户条件，用于拼接了原生 SQL 片段的查询，不单独依赖租户回调；
+// context 未指定租户时不过滤
+func scopeTenant(ctx context.Context) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if tenantID := tenant.FromContext(ctx); tenantID != "" {
+			return db.Where("lessons.tenant_id = ?", tenantID)
+		}
+		return db
+	}
 }
 
 // CommentRepository 评论仓库接口
@@ -123,7 +286,38 @@ type CommentRepository interface {
 	Create(ctx context.Context, comment *model.Comment) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.Comment, error)
 	Delete(ctx context.Context, id uuid.UUID) error
-	ListByLessonID(ctx context.Context, lessonID uuid.UUID, page, pageSize int) ([]model.Comment, int64, error)
+	ListByLessonID(ctx context.Context, lessonID uuid.UUID, sort string, page, pageSize int) ([]model.Comment, int64, error)
+	// GetDeletedByID 获取已软删除的评论
+	GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Comment, error)
+	// Restore 撤销评论的软删除
+	Restore(ctx context.Context, id uuid.UUID) error
+	// Like 点赞评论并重算点赞数，重复点赞不报错
+	Like(ctx context.Context, commentID, userID uuid.UUID) error
+	// Unlike 取消评论点赞并重算点赞数，未点赞时不报错
+	Unlike(ctx context.Context, commentID, userID uuid.UUID) error
+	CreateAudit(ctx context.Context, audit *model.CommentAudit) error
+	// ListAudits 按时间倒序列出评论审计记录，lessonID 非空时只列出该教案的记录
+	ListAudits(ctx context.Context, lessonID *uuid.UUID, page, pageSize int) ([]model.CommentAudit, int64, error)
+}
+
+// 评论排序方式
+const (
+	CommentSortNew = "new"
+	CommentSortOld = "old"
+	CommentSortHot = "hot"
+)
+
+// commentOrderClause 将排序参数映射为 SQL 排序子句，未知值按最新排序。
+// hot 按点赞数排序，点赞数相同时较新的评论在前。
+func commentOrderClause(sort string) string {
+	switch sort {
+	case CommentSortOld:
+		return "created_at ASC"
+	case CommentSortHot:
+		return "like_count DESC, created_at DESC"
+	default:
+		return "created_at DESC"
+	}
 }
 
 type commentRepository struct {
@@ -152,7 +346,7 @@ func (r *commentRepository) Delete(ctx context.Context, id uuid.UUID) error {
 	return r.db.WithContext(ctx).Delete(&model.Comment{}, "id = ?", id).Error
 }
 
-func (r *commentRepository) ListByLessonID(ctx context.Context, lessonID uuid.UUID, page, pageSize int) ([]model.Comment, int64, error) {
+func (r *commentRepository) ListByLessonID(ctx context.Context, lessonID uuid.UUID, sort string, page, pageSize int) ([]model.Comment, int64, error) {
 	var comments []model.Comment
 	var total int64
 
@@ -166,20 +360,97 @@ func (r *commentRepository) ListByLessonID(ctx context.Context, lessonID uuid.UU
 	}
 
 	offset := (page - 1) * pageSize
-	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&comments).Error; err != nil {
+	if err := db.Order(commentOrderClause(sort)).Offset(offset).Limit(pageSize).Find(&comments).Error; err != nil {
 		return nil, 0, err
 	}
 
 	return comments, total, nil
 }
 
+func (r *commentRepository) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+	var comment model.Comment
+	err := r.db.WithContext(ctx).Unscoped().
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		First(&comment).Error
+	if err != nil {
+		return nil, err
+	}
+	return &comment, nil
+}
+
+func (r *commentRepository) Restore(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Unscoped().Model(&model.Comment{}).
+		Where("id = ? AND deleted_at IS NOT NULL", id).
+		Update("deleted_at", nil).Error
+}
+
+func (r *commentRepository) Like(ctx context.Context, commentID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		like := model.CommentLike{CommentID: commentID, UserID: userID}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&like).Error; err != nil {
+			return err
+		}
+		return recountCommentLikes(tx, commentID)
+	})
+}
+
+func (r *commentRepository) Unlike(ctx context.Context, commentID, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("comment_id = ? AND user_id = ?", commentID, userID).
+			Delete(&model.CommentLike{}).Error; err != nil {
+			return err
+		}
+		return recountCommentLikes(tx, commentID)
+	})
+}
+
+// recountCommentLikes 按点赞记录重算评论点赞数，避免并发增减造成计数漂移
+func recountCommentLikes(tx *gorm.DB, commentID uuid.UUID) error {
+	return tx.Model(&model.Comment{}).Where("id = ?", commentID).
+		UpdateColumn("like_count", gorm.Expr("(SELECT COUNT(*) FROM lesson_comment_likes WHERE comment_id = ?)", commentID)).Error
+}
+
+func (r *commentRepository) CreateAudit(ctx context.Context, audit *model.CommentAudit) error {
+	return r.db.WithContext(ctx).Create(audit).Error
+}
+
+func (r *commentRepository) ListAudits(ctx context.Context, lessonID *uuid.UUID, page, pageSize int) ([]model.CommentAudit, int64, error) {
+	var audits []model.CommentAudit
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.CommentAudit{})
+	if lessonID != nil {
+		db = db.Where("lesson_id = ?", *lessonID)
+	}
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := db.Order("created_at DESC").Offset(offset).Limit(pageSize).Find(&audits).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return audits, total, nil
+}
+
 // FavoriteRepository 收藏仓库接口
 type FavoriteRepository interface {
 	Create(ctx context.Context, favorite *model.Favorite) error
 	Delete(ctx context.Context, userID, lessonID uuid.UUID) error
 	Exists(ctx context.Context, userID, lessonID uuid.UUID) (bool, error)
-	ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error)
+	// BatchExists 一次查询用户收藏了 lessonIDs 中的哪些教案
+	BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+	// ListByUserID 列出用户收藏，folderID 非空时只列出该收藏夹中的收藏
+	ListByUserID(ctx context.Context, userID uuid.UUID, folderID *uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error)
 	CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error)
+	// SetFolder 将已有收藏移入收藏夹，folderID 为 nil 时移出到未分组
+	SetFolder(ctx context.Context, userID, lessonID uuid.UUID, folderID *uuid.UUID) error
+	// GetOrCreateFolder 按名称获取收藏夹，不存在时创建
+	GetOrCreateFolder(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error)
+	GetFolderByName(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error)
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]model.FavoriteFolderSummary, error)
 }
 
 type favoriteRepository struct {
@@ -207,13 +478,20 @@ func (r *favoriteRepository) Exists(ctx context.Context, userID, lessonID uuid.U
 	return count > 0, err
 }
 
-func (r *favoriteRepository) ListByUserID(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
+func (r *favoriteRepository) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return batchLessonExists(ctx, r.db, &model.Favorite{}, userID, lessonIDs)
+}
+
+func (r *favoriteRepository) ListByUserID(ctx context.Context, userID uuid.UUID, folderID *uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
 	var favorites []model.Favorite
 	var total int64
 
 	db := r.db.WithContext(ctx).Model(&model.Favorite{}).
 		Preload("Lesson.User").
 		Where("user_id = ?", userID)
+	if folderID != nil {
+		db = db.Where("folder_id = ?", *folderID)
+	}
 
 	if err := db.Count(&total).Error; err != nil {
 		return nil, 0, err
@@ -233,11 +511,50 @@ func (r *favoriteRepository) CountByUserID(ctx context.Context, userID uuid.UUID
 	return count, err
 }
 
+func (r *favoriteRepository) SetFolder(ctx context.Context, userID, lessonID uuid.UUID, folderID *uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.Favorite{}).
+		Where("user_id = ? AND lesson_id = ?", userID, lessonID).
+		Update("folder_id", folderID).Error
+}
+
+// GetOrCreateFolder 并发创建同名收藏夹时由唯一索引兜底，冲突后读取已存在的记录
+func (r *favoriteRepository) GetOrCreateFolder(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error) {
+	folder := model.FavoriteFolder{UserID: userID, Name: name}
+	err := r.db.WithContext(ctx).Clauses(clause.OnConflict{DoNothing: true}).Create(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return r.GetFolderByName(ctx, userID, name)
+}
+
+func (r *favoriteRepository) GetFolderByName(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error) {
+	var folder model.FavoriteFolder
+	err := r.db.WithContext(ctx).Where("user_id = ? AND name = ?", userID, name).First(&folder).Error
+	if err != nil {
+		return nil, err
+	}
+	return &folder, nil
+}
+
+func (r *favoriteRepository) ListFolders(ctx context.Context, userID uuid.UUID) ([]model.FavoriteFolderSummary, error) {
+	var folders []model.FavoriteFolderSummary
+	err := r.db.WithContext(ctx).Table("favorite_folders AS f").
+		Select("f.id, f.name, f.created_at, COUNT(lf.id) AS favorite_count").
+		Joins("LEFT JOIN lesson_favorites lf ON lf.folder_id = f.id").
+		Where("f.user_id = ?", userID).
+		Group("f.id, f.name, f.created_at").
+		Order("f.created_at ASC").
+		Scan(&folders).Error
+	return folders, err
+}
+
 // LikeRepository 点赞仓库接口
 type LikeRepository interface {
 	Create(ctx context.Context, like *model.Like) error
 	Delete(ctx context.Context, userID, lessonID uuid.UUID) error
 	Exists(ctx context.Context, userID, lessonID uuid.UUID) (bool, error)
+	// BatchExists 一次查询用户点赞了 lessonIDs 中的哪些教案
+	BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error)
 }
 
 type likeRepository struct {
@@ -264,3 +581,26 @@ func (r *likeRepository) Exists(ctx context.Context, userID, lessonID uuid.UUID)
 		Where("user_id = ? AND lesson_id = ?", userID, lessonID).Count(&count).Error
 	return count > 0, err
 }
+
+func (r *likeRepository) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return batchLessonExists(ctx, r.db, &model.Like{}, userID, lessonIDs)
+}
+
+// batchLessonExists 在用户与教案的关联表（收藏、点赞）中一次查出 lessonIDs 里存在记录的教案
+func batchLessonExists(ctx context.Context, db *gorm.DB, table interface{}, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	exists := make(map[uuid.UUID]bool, len(lessonIDs))
+	if len(lessonIDs) == 0 {
+		return exists, nil
+	}
+
+	var found []uuid.UUID
+	if err := db.WithContext(ctx).Model(table).
+		Where("user_id = ? AND lesson_id IN ?", userID, lessonIDs).
+		Pluck("lesson_id", &found).Error; err != nil {
+		return nil, err
+	}
+	for _, id := range found {
+		exists[id] = true
+	}
+	return exists, nil
+}
@@ -0,0 +1,305 @@
+package repository
+
+import (
+	"context"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// capturedSQL 记录 dry-run 模式下生成的原生 SQL 及参数
+type capturedSQL struct {
+	sql  string
+	vars []interface{}
+}
+
+// newDryRunDB 只生成 SQL、不连接数据库的 GORM 实例，最后一条查询或 Exec/Raw 语句写入 captured
+func newDryRunDB(t *testing.T, captured *capturedSQL) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	if err := tenant.RegisterGORMCallbacks(db); err != nil {
+		t.Fatalf("RegisterGORMCallbacks() error = %v", err)
+	}
+	capture := func(db *gorm.DB) {
+		captured.sql = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+		captured.vars = db.Statement.Vars
+		// DryRun 下 GORM 不会清空 Count 的语句，手动清空以免随后的 Find 复用
+		if _, isCount := db.Statement.Dest.(*int64); isCount {
+			db.Statement.SQL.Reset()
+			db.Statement.Vars = nil
+		}
+	}
+	_ = db.Callback().Query().After("gorm:query").Register("test:capture", capture)
+	_ = db.Callback().Raw().After("gorm:raw").Register("test:capture", capture)
+	return db
+}
+
+func TestCommentOrderClause(t *testing.T) {
+	tests := []struct {
+		sort string
+		want string
+	}{
+		{CommentSortNew, "created_at DESC"},
+		{CommentSortOld, "created_at ASC"},
+		{CommentSortHot, "like_count DESC, created_at DESC"},
+		{"", "created_at DESC"},
+		{"unknown", "created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.sort, func(t *testing.T) {
+			if got := commentOrderClause(tt.sort); got != tt.want {
+				t.Errorf("commentOrderClause(%q) = %q, want %q", tt.sort, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRawSQLScopedToTenant(t *testing.T) {
+	lessonID := uuid.New()
+
+	tests := []struct {
+		name       string
+		tenantID   string
+		run        func(ctx context.Context, r *lessonRepository) error
+		wantSuffix string
+		wantLast   interface{}
+	}{
+		{
+			name:       "update counts within tenant",
+			tenantID:   "school-a",
+			run:        func(ctx context.Context, r *lessonRepository) error { return r.UpdateCounts(ctx, lessonID) },
+			wantSuffix: "WHERE id = $1 AND tenant_id = $2",
+			wantLast:   "school-a",
+		},
+		{
+			name:       "update counts without tenant",
+			run:        func(ctx context.Context, r *lessonRepository) error { return r.UpdateCounts(ctx, lessonID) },
+			wantSuffix: "WHERE id = $1",
+			wantLast:   lessonID,
+		},
+		{
+			name:     "archive within tenant",
+			tenantID: "school-a",
+			run: func(ctx context.Context, r *lessonRepository) error {
+				_, err := r.ArchiveInactive(ctx, time.Now())
+				return err
+			},
+			wantSuffix: "AND deleted_at IS NULL) AND tenant_id = $8",
+			wantLast:   "school-a",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured capturedSQL
+			r := &lessonRepository{db: newDryRunDB(t, &captured)}
+			ctx := tenant.WithTenant(context.Background(), tt.tenantID)
+
+			if err := tt.run(ctx, r); err != nil {
+				t.Fatalf("run error = %v", err)
+			}
+			if !strings.HasSuffix(captured.sql, tt.wantSuffix) {
+				t.Errorf("SQL = %s, want suffix %q", captured.sql, tt.wantSuffix)
+			}
+			if len(captured.vars) == 0 || captured.vars[len(captured.vars)-1] != tt.wantLast {
+				t.Errorf("vars = %v, want last %v", captured.vars, tt.wantLast)
+			}
+		})
+	}
+}
+
+func TestLessonOrderClauseGrade(t *testing.T) {
+	tests := []struct {
+		name  string
+		order string
+		want  string
+	}{
+		{name: "grade defaults to ascending", want: "grade_level = 0, grade_level ASC, created_at DESC"},
+		{name: "grade descending", order: SortOrderDesc, want: "grade_level = 0, grade_level DESC, created_at DESC"},
+	}
+
+	// grade_level = 0 在 PostgreSQL 中 false 排在 true 之前，无法识别的年级无论升降序都排在最后
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := lessonOrderClause(LessonSortGrade, tt.order); got != tt.want {
+				t.Errorf("lessonOrderClause(grade, %q) = %q, want %q", tt.order, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLessonOrderClause(t *testing.T) {
+	tests := []struct {
+		sort  string
+		order string
+		want  string
+	}{
+		{sort: "", want: "created_at DESC"},
+		{sort: LessonSortCreatedAt, order: SortOrderAsc, want: "created_at ASC"},
+		{sort: LessonSortLikes, order: SortOrderDesc, want: "like_count DESC, created_at DESC"},
+		{sort: LessonSortViews, order: SortOrderAsc, want: "view_count ASC, created_at DESC"},
+		{sort: LessonSortFavorites, want: "favorite_count DESC, created_at DESC"},
+		{sort: "title", order: SortOrderAsc, want: "created_at DESC"},
+	}
+
+	for _, tt := range tests {
+		if got := lessonOrderClause(tt.sort, tt.order); got != tt.want {
+			t.Errorf("lessonOrderClause(%q, %q) = %q, want %q", tt.sort, tt.order, got, tt.want)
+		}
+	}
+}
+
+func TestIsValidLessonSort(t *testing.T) {
+	for _, sort := range []string{"", LessonSortCreated, LessonSortCreatedAt, LessonSortGrade, LessonSortViews, LessonSortLikes, LessonSortFavorites} {
+		if !IsValidLessonSort(sort) {
+			t.Errorf("IsValidLessonSort(%q) = false, want true", sort)
+		}
+	}
+	for _, sort := range []string{"title", "LIKE_COUNT", "like_count DESC"} {
+		if IsValidLessonSort(sort) {
+			t.Errorf("IsValidLessonSort(%q) = true, want false", sort)
+		}
+	}
+}
+
+func TestGradeFilter(t *testing.T) {
+	tests := []struct {
+		grade    string
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{grade: "初一", wantSQL: "WHERE grade_level = $1", wantVars: []interface{}{7}},
+		{grade: "Grade 7", wantSQL: "WHERE grade_level = $1", wantVars: []interface{}{7}},
+		{grade: "学前班", wantSQL: "WHERE grade = $1", wantVars: []interface{}{"学前班"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.grade, func(t *testing.T) {
+			var captured capturedSQL
+			db := newDryRunDB(t, &captured)
+			var lessons []model.Lesson
+			db.Scopes(gradeFilter(tt.grade)).Find(&lessons)
+
+			if !strings.Contains(captured.sql, tt.wantSQL) {
+				t.Errorf("SQL = %s, want %q", captured.sql, tt.wantSQL)
+			}
+			if len(captured.vars) != len(tt.wantVars) || captured.vars[0] != tt.wantVars[0] {
+				t.Errorf("vars = %v, want %v", captured.vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestTagsFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		tags     []string
+		matchAll bool
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name:     "match any",
+			tags:     []string{"函数", "图像"},
+			wantSQL:  "WHERE ((tags @> $1::jsonb OR tags @> $2::jsonb))",
+			wantVars: []interface{}{`["函数"]`, `["图像"]`},
+		},
+		{
+			name:     "match all",
+			tags:     []string{"函数", "图像"},
+			matchAll: true,
+			wantSQL:  "WHERE tags @> $1::jsonb",
+			wantVars: []interface{}{`["函数","图像"]`},
+		},
+		{
+			name:     "single tag",
+			tags:     []string{`带"引号`},
+			wantSQL:  "WHERE (tags @> $1::jsonb)",
+			wantVars: []interface{}{`["带\"引号"]`},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured capturedSQL
+			db := newDryRunDB(t, &captured)
+			var lessons []model.Lesson
+			db.Scopes(tagsFilter(tt.tags, tt.matchAll)).Find(&lessons)
+
+			if !strings.Contains(captured.sql, tt.wantSQL) {
+				t.Errorf("SQL = %s, want %q", captured.sql, tt.wantSQL)
+			}
+			if !reflect.DeepEqual(captured.vars, tt.wantVars) {
+				t.Errorf("vars = %v, want %v", captured.vars, tt.wantVars)
+			}
+		})
+	}
+}
+
+func TestListFiltersByTags(t *testing.T) {
+	var captured capturedSQL
+	r := &lessonRepository{db: newDryRunDB(t, &captured)}
+
+	if _, _, err := r.List(context.Background(), LessonFilter{Subject: "数学"}, 1, 20); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if strings.Contains(captured.sql, "tags @>") {
+		t.Errorf("SQL = %s, want no tag condition without tags", captured.sql)
+	}
+
+	if _, _, err := r.List(context.Background(), LessonFilter{Subject: "数学", Tags: []string{"函数", "图像"}, TagsMatchAll: true}, 1, 20); err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	if !strings.Contains(captured.sql, "AND tags @> $2::jsonb") {
+		t.Errorf("SQL = %s, want the match-all tag condition", captured.sql)
+	}
+}
+
+func TestBatchLessonExistsQuery(t *testing.T) {
+	userID := uuid.New()
+	lessonIDs := []uuid.UUID{uuid.New(), uuid.New()}
+
+	var captured capturedSQL
+	db := newDryRunDB(t, &captured)
+	if _, err := batchLessonExists(context.Background(), db, &model.Like{}, userID, lessonIDs); err != nil {
+		t.Fatalf("batchLessonExists() error = %v", err)
+	}
+	want := `SELECT "lesson_id" FROM "lesson_likes" WHERE user_id = $1 AND lesson_id IN ($2,$3)`
+	if !strings.Contains(captured.sql, want) {
+		t.Errorf("SQL = %s, want %q", captured.sql, want)
+	}
+	if !reflect.DeepEqual(captured.vars, []interface{}{userID, lessonIDs[0], lessonIDs[1]}) {
+		t.Errorf("vars = %v, want user and lesson ids", captured.vars)
+	}
+
+	// 空列表不查询
+	captured = capturedSQL{}
+	exists, err := batchLessonExists(context.Background(), db, &model.Favorite{}, userID, nil)
+	if err != nil || len(exists) != 0 || captured.sql != "" {
+		t.Errorf("batchLessonExists(nil) = %v, %v with SQL %q, want empty without query", exists, err, captured.sql)
+	}
+}
+
+func TestGetDeletedCommentQuery(t *testing.T) {
+	var captured capturedSQL
+	r := &commentRepository{db: newDryRunDB(t, &captured)}
+
+	_, _ = r.GetDeletedByID(context.Background(), uuid.New())
+	// 须跳过软删除的默认过滤，只查已删除的评论
+	if !strings.Contains(captured.sql, "deleted_at IS NOT NULL") || strings.Contains(captured.sql, `"lesson_comments"."deleted_at" IS NULL`) {
+		t.Errorf("SQL = %s, want only soft-deleted comments", captured.sql)
+	}
+}
@@ -0,0 +1,89 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"unicode"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm/clause"
+)
+
+// lessons.search_vector 是标题（权重 A）与正文文本（权重 B）的 tsvector 生成列，使用 simple 配置：
+// 生成时在每个汉字两侧插入空格，使汉字逐字成词；查询时连续汉字用 <-> 组成短语，
+// 因此中文关键词按原文连续匹配，英文与数字按词前缀匹配。汉字范围须与迁移中的正则一致
+
+// isSearchHan 判断汉字（U+3400–U+9FFF、U+F900–U+FAFF），须与 search_vector 生成列中的正则范围一致
+func isSearchHan(r rune) bool {
+	return (r >= 0x3400 && r <= 0x9fff) || (r >= 0xf900 && r <= 0xfaff)
+}
+
+// buildLessonTSQuery 将用户输入转换为 to_tsquery('simple', ...) 的查询串：
+// 空白分隔的各关键词之间为 AND；关键词内连续汉字按短语匹配，其余字母数字词按前缀匹配。
+// 字母、数字与汉字以外的字符都视为分隔符，因此结果中不会出现用户输入的 tsquery 运算符。
+// 没有可检索的词时返回空串
+func buildLessonTSQuery(query string) string {
+	var terms []string
+	for _, field := range strings.Fields(query) {
+		var tokens []string
+		var word strings.Builder
+		flush := func() {
+			if word.Len() > 0 {
+				tokens = append(tokens, strings.ToLower(word.String())+":*")
+				word.Reset()
+			}
+		}
+		for _, r := range field {
+			switch {
+			case isSearchHan(r):
+				flush()
+				tokens = append(tokens, string(r))
+			case unicode.IsLetter(r) || unicode.IsDigit(r):
+				word.WriteRune(r)
+			default:
+				flush()
+			}
+		}
+		flush()
+
+		if len(tokens) > 0 {
+			terms = append(terms, "("+strings.Join(tokens, " <-> ")+")")
+		}
+	}
+	return strings.Join(terms, " & ")
+}
+
+// Search 全文检索，按相关度排序（标题命中高于仅正文命中），相关度相同按创建时间倒序。
+// 可见性过滤在查询中完成，分页总数不包含不可见教案；输入中没有可检索的词时退回关键词子串匹配
+func (r *lessonRepository) Search(ctx context.Context, query string, viewerID *uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error) {
+	tsQuery := buildLessonTSQuery(query)
+	if tsQuery == "" {
+		return r.List(ctx, LessonFilter{Keyword: query, VisibleOnly: true, Viewer: viewerID}, page, pageSize)
+	}
+
+	var lessons []model.Lesson
+	var total int64
+
+	db := r.db.WithContext(ctx).Model(&model.Lesson{}).Preload("User").
+		Where("search_vector @@ to_tsquery('simple', ?)", tsQuery).
+		Scopes(visibleToViewer(viewerID), scopeTenant(ctx))
+
+	if err := db.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	// Order 不接受 clause.OrderBy，带参数的排序表达式须经 Clauses 添加，否则会被忽略
+	offset := (page - 1) * pageSize
+	err := db.Clauses(clause.OrderBy{Expression: clause.Expr{
+		SQL:                "ts_rank(search_vector, to_tsquery('simple', ?)) DESC, created_at DESC",
+		Vars:               []interface{}{tsQuery},
+		WithoutParentheses: true,
+	}}).Offset(offset).Limit(pageSize).Find(&lessons).Error
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return lessons, total, nil
+}
@@ -0,0 +1,158 @@
+package repository
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestSearchVisibilityFilter(t *testing.T) {
+	authorID, otherID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name     string
+		query    string
+		viewer   *uuid.UUID
+		wantCond string
+		wantVars []interface{}
+	}{
+		{
+			name:     "anonymous sees published only",
+			query:    "函数",
+			wantCond: "AND status = $2 AND",
+			wantVars: []interface{}{model.LessonStatusPublished},
+		},
+		{
+			name:     "author also sees own drafts",
+			query:    "函数",
+			viewer:   &authorID,
+			wantCond: "AND ((status = $2 OR user_id = $3)) AND",
+			wantVars: []interface{}{model.LessonStatusPublished, authorID},
+		},
+		{
+			name:     "another user sees only their own drafts",
+			query:    "函数",
+			viewer:   &otherID,
+			wantCond: "AND ((status = $2 OR user_id = $3)) AND",
+			wantVars: []interface{}{model.LessonStatusPublished, otherID},
+		},
+		{
+			name:     "keyword fallback applies the same filter",
+			query:    "??",
+			viewer:   &authorID,
+			wantCond: "AND ((status = $3 OR user_id = $4)) AND",
+			wantVars: []interface{}{model.LessonStatusPublished, authorID},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var captured capturedSQL
+			r := &lessonRepository{db: newDryRunDB(t, &captured)}
+
+			if _, _, err := r.Search(context.Background(), tt.query, tt.viewer, 1, 20); err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			if !strings.Contains(captured.sql, tt.wantCond) {
+				t.Errorf("SQL = %s, want it to contain %q", captured.sql, tt.wantCond)
+			}
+			offset := strings.Count(strings.SplitN(captured.sql, tt.wantCond, 2)[0], "$")
+			for i, want := range tt.wantVars {
+				if offset+i >= len(captured.vars) || captured.vars[offset+i] != want {
+					t.Errorf("vars = %v, want %v from index %d", captured.vars, tt.wantVars, offset)
+					break
+				}
+			}
+		})
+	}
+}
+
+func TestBuildLessonTSQuery(t *testing.T) {
+	tests := []struct {
+		query string
+		want  string
+	}{
+		{query: "函数", want: "(函 <-> 数)"},
+		{query: "一次函数  图像", want: "(一 <-> 次 <-> 函 <-> 数) & (图 <-> 像)"},
+		{query: "Python3", want: "(python3:*)"},
+		{query: "勾股定理proof", want: "(勾 <-> 股 <-> 定 <-> 理 <-> proof:*)"},
+		{query: "a&b|!c", want: "(a:* <-> b:* <-> c:*)"},
+		{query: "?? ()", want: ""},
+	}
+
+	for _, tt := range tests {
+		if got := buildLessonTSQuery(tt.query); got != tt.want {
+			t.Errorf("buildLessonTSQuery(%q) = %q, want %q", tt.query, got, tt.want)
+		}
+	}
+}
+
+func TestSearchOrdersByRank(t *testing.T) {
+	var captured capturedSQL
+	r := &lessonRepository{db: newDryRunDB(t, &captured)}
+
+	if _, _, err := r.Search(context.Background(), "函数", nil, 2, 10); err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	for _, want := range []string{
+		"search_vector @@ to_tsquery('simple', $1)",
+		"ORDER BY ts_rank(search_vector, to_tsquery('simple', $3)) DESC, created_at DESC",
+		"LIMIT 10 OFFSET 10",
+	} {
+		if !strings.Contains(captured.sql, want) {
+			t.Errorf("SQL = %s, want it to contain %q", captured.sql, want)
+		}
+	}
+	if len(captured.vars) < 3 || captured.vars[0] != "(函 <-> 数)" || captured.vars[2] != "(函 <-> 数)" {
+		t.Errorf("vars = %v, want the tsquery for both match and rank", captured.vars)
+	}
+}
+
+// searchVectorWeight 匹配生成列中某一来源字段的 setweight(..., 'X')
+var searchVectorWeight = regexp.MustCompile(`setweight\(to_tsvector\('simple', regexp_replace\(coalesce\((\w+).*?'\(\[(.+?)\]\)'.*?'([A-D])'\)`)
+
+// ts_rank 默认权重 A=1.0、B=0.4，标题为 A、正文为 B 时仅标题命中的教案排在仅正文命中的教案之前。
+// 建表脚本与迁移须保持一致，汉字范围须与 isSearchHan 一致
+func TestSearchVectorWeightsTitleAboveBody(t *testing.T) {
+	root := filepath.Join("..", "..", "..", "database", "postgres")
+	migrations, _ := filepath.Glob(filepath.Join(root, "migrations", "*_alter_lessons_add_search_vector.sql"))
+	files := append([]string{filepath.Join(root, "init.sql")}, migrations...)
+	if len(files) != 2 {
+		t.Fatalf("schema files = %v, want init.sql and the search_vector migration", files)
+	}
+
+	for _, file := range files {
+		data, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatalf("read %s: %v", file, err)
+		}
+		weights := make(map[string]string)
+		for _, m := range searchVectorWeight.FindAllStringSubmatch(string(data), -1) {
+			weights[m[1]] = m[3]
+			if m[2] != `\u3400-\u9fff\uf900-\ufaff` {
+				t.Errorf("%s: han range for %s = %s, want the isSearchHan range", file, m[1], m[2])
+			}
+		}
+		if weights["title"] != "A" || weights["content"] != "B" {
+			t.Errorf("%s: weights = %v, want title A and content B", file, weights)
+		}
+	}
+
+	for _, r := range []rune{'\u3400', '函', '\u9fff', '\uf900', '\ufaff'} {
+		if !isSearchHan(r) {
+			t.Errorf("isSearchHan(%U) = false, want true", r)
+		}
+	}
+	for _, r := range []rune{'a', '1', '\u33ff', '\ua000', '，'} {
+		if isSearchHan(r) {
+			t.Errorf("isSearchHan(%U) = true, want false", r)
+		}
+	}
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// NotificationRepository 通知仓库接口
+type NotificationRepository interface {
+	Create(ctx context.Context, notification *model.Notification) error
+	ListDigestUserIDs(ctx context.Context) ([]uuid.UUID, error)
+	ListPendingByUser(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error)
+	UpdateEmailStatus(ctx context.Context, ids []uuid.UUID, fromStatus, toStatus string, at *time.Time) (int64, error)
+	SkipPendingByUser(ctx context.Context, userID uuid.UUID) error
+}
+
+type notificationRepository struct {
+	db *gorm.DB
+}
+
+// NewNotificationRepository 创建通知仓库
+func NewNotificationRepository(db *gorm.DB) NotificationRepository {
+	return &notificationRepository{db: db}
+}
+
+func (r *notificationRepository) Create(ctx context.Context, notification *model.Notification) error {
+	return r.db.WithContext(ctx).Create(notification).Error
+}
+
+// ListDigestUserIDs 有待发送通知且未关闭邮件通知的用户；实时发送失败的通知也在摘要中补发
+func (r *notificationRepository) ListDigestUserIDs(ctx context.Context) ([]uuid.UUID, error) {
+	var userIDs []uuid.UUID
+	err := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Joins("JOIN users ON users.id = notifications.user_id AND users.deleted_at IS NULL").
+		Where("notifications.email_status = ? AND users.notify_mode <> ?", model.NotificationEmailPending, model.NotifyModeOff).
+		Distinct().Pluck("notifications.user_id", &userIDs).Error
+	return userIDs, err
+}
+
+func (r *notificationRepository) ListPendingByUser(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error) {
+	var notifications []model.Notification
+	err := r.db.WithContext(ctx).
+		Where("user_id = ? AND email_status = ?", userID, model.NotificationEmailPending).
+		Order("created_at ASC").Limit(limit).Find(&notifications).Error
+	return notifications, err
+}
+
+// UpdateEmailStatus 按当前状态条件更新邮件状态，返回实际更新的行数；
+// 多实例同时发送摘要时以此认领，避免重复发送
+func (r *notificationRepository) UpdateEmailStatus(ctx context.Context, ids []uuid.UUID, fromStatus, toStatus string, at *time.Time) (int64, error) {
+	if len(ids) == 0 {
+		return 0, nil
+	}
+	result := r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("id IN ? AND email_status = ?", ids, fromStatus).
+		Updates(map[string]interface{}{
+			"email_status": toStatus,
+			"emailed_at":   at,
+		})
+	return result.RowsAffected, result.Error
+}
+
+// SkipPendingByUser 用户关闭邮件通知时，未发送的通知不再补发
+func (r *notificationRepository) SkipPendingByUser(ctx context.Context, userID uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.Notification{}).
+		Where("user_id = ? AND email_status = ?", userID, model.NotificationEmailPending).
+		Update("email_status", model.NotificationEmailSkipped).Error
+}
@@ -0,0 +1,70 @@
+package repository
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ShareRepository 分享链接仓库接口
+type ShareRepository interface {
+	Create(ctx context.Context, share *model.LessonShare) error
+	GetByID(ctx context.Context, id uuid.UUID) (*model.LessonShare, error)
+	GetByToken(ctx context.Context, token string) (*model.LessonShare, error)
+	ListByLessonID(ctx context.Context, lessonID uuid.UUID) ([]model.LessonShare, error)
+	Delete(ctx context.Context, id uuid.UUID) error
+	IncrementViewCount(ctx context.Context, id uuid.UUID) error
+}
+
+type shareRepository struct {
+	db *gorm.DB
+}
+
+// NewShareRepository 创建分享链接仓库
+func NewShareRepository(db *gorm.DB) ShareRepository {
+	return &shareRepository{db: db}
+}
+
+func (r *shareRepository) Create(ctx context.Context, share *model.LessonShare) error {
+	return r.db.WithContext(ctx).Create(share).Error
+}
+
+func (r *shareRepository) GetByID(ctx context.Context, id uuid.UUID) (*model.LessonShare, error) {
+	var share model.LessonShare
+	err := r.db.WithContext(ctx).Where("id = ?", id).First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) GetByToken(ctx context.Context, token string) (*model.LessonShare, error) {
+	var share model.LessonShare
+	err := r.db.WithContext(ctx).Where("token = ?", token).First(&share).Error
+	if err != nil {
+		return nil, err
+	}
+	return &share, nil
+}
+
+func (r *shareRepository) ListByLessonID(ctx context.Context, lessonID uuid.UUID) ([]model.LessonShare, error) {
+	var shares []model.LessonShare
+	err := r.db.WithContext(ctx).
+		Where("lesson_id = ?", lessonID).
+		Order("created_at DESC").
+		Find(&shares).Error
+	return shares, err
+}
+
+func (r *shareRepository) Delete(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Delete(&model.LessonShare{}, "id = ?", id).Error
+}
+
+func (r *shareRepository) IncrementViewCount(ctx context.Context, id uuid.UUID) error {
+	return r.db.WithContext(ctx).Model(&model.LessonShare{}).
+		Where("id = ?", id).
+		UpdateColumn("view_count", gorm.Expr("view_count + 1")).Error
+}
@@ -0,0 +1,73 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// UserDataRepository 用户个人数据仓库接口，用于个人数据导出
+type UserDataRepository interface {
+	// Export 读取用户的全部个人数据，用户不存在时返回 gorm.ErrRecordNotFound
+	Export(ctx context.Context, userID uuid.UUID) (*model.UserDataExport, error)
+}
+
+type userDataRepository struct {
+	db *gorm.DB
+}
+
+// NewUserDataRepository 创建用户个人数据仓库
+func NewUserDataRepository(db *gorm.DB) UserDataRepository {
+	return &userDataRepository{db: db}
+}
+
+func (r *userDataRepository) Export(ctx context.Context, userID uuid.UUID) (*model.UserDataExport, error) {
+	data := &model.UserDataExport{}
+	// 在同一个可重复读的只读事务里读取，避免导出期间的写入造成各部分数据不一致
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		var user model.User
+		if err := tx.First(&user, "id = ?", userID).Error; err != nil {
+			return err
+		}
+		data.Profile = &user
+		data.LoginHistory = model.UserLoginHistory{LastLoginAt: user.LastLoginAt}
+
+		var settings model.UserSettings
+		err := tx.Where("user_id = ?", userID).First(&settings).Error
+		switch {
+		case err == nil:
+			data.Settings = &settings
+		case !errors.Is(err, gorm.ErrRecordNotFound):
+			return err
+		}
+
+		for _, list := range []interface{}{
+			&data.Lessons,
+			&data.Comments,
+			&data.Annotations,
+			&data.Generations,
+			&data.GenerationPresets,
+			&data.Documents,
+			&data.Favorites,
+			&data.FavoriteFolders,
+			&data.Likes,
+			&data.Shares,
+			&data.APIKeys,
+			&data.Notifications,
+		} {
+			if err := tx.Where("user_id = ?", userID).Order("created_at").Find(list).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Where("follower_id = ?", userID).Order("created_at").Find(&data.Following).Error
+	}, &sql.TxOptions{Isolation: sql.LevelRepeatableRead, ReadOnly: true})
+	if err != nil {
+		return nil, err
+	}
+	return data, nil
+}
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"regexp"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// dryRunTxPool 不连接数据库的连接池，只支持开启事务，记录事务选项
+type dryRunTxPool struct {
+	gorm.ConnPool
+	opts *sql.TxOptions
+	tx   *dryRunTx
+}
+
+func (p *dryRunTxPool) BeginTx(ctx context.Context, opts *sql.TxOptions) (gorm.ConnPool, error) {
+	p.opts = opts
+	p.tx = &dryRunTx{}
+	return p.tx, nil
+}
+
+// dryRunTx dryRunTxPool 开启的事务，记录是否提交
+type dryRunTx struct {
+	gorm.ConnPool
+	committed bool
+}
+
+func (tx *dryRunTx) Commit() error {
+	tx.committed = true
+	return nil
+}
+
+func (tx *dryRunTx) Rollback() error { return nil }
+
+func TestExportUserDataQueries(t *testing.T) {
+	pool := &dryRunTxPool{}
+	db, err := gorm.Open(postgres.New(postgres.Config{Conn: pool}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	var statements []capturedSQL
+	_ = db.Callback().Query().After("gorm:query").Register("test:capture", func(db *gorm.DB) {
+		statements = append(statements, capturedSQL{
+			sql:  strings.Join(strings.Fields(db.Statement.SQL.String()), " "),
+			vars: db.Statement.Vars,
+		})
+	})
+
+	userID := uuid.New()
+	data, err := NewUserDataRepository(db).Export(context.Background(), userID)
+	if err != nil {
+		t.Fatalf("Export() error = %v", err)
+	}
+	if data.Profile == nil {
+		t.Error("Export() profile = nil")
+	}
+	if pool.opts == nil || !pool.opts.ReadOnly || pool.opts.Isolation != sql.LevelRepeatableRead || !pool.tx.committed {
+		t.Errorf("transaction options = %+v, want a committed read-only repeatable-read transaction", pool.opts)
+	}
+
+	// 每条查询都只按调用者筛选：用户表按 id，关注表按 follower_id，其余按 user_id
+	ownerFilter := regexp.MustCompile(`^SELECT \* FROM "(\w+)" WHERE (\w+) = \$1`)
+	tables := make(map[string]string)
+	for _, stmt := range statements {
+		m := ownerFilter.FindStringSubmatch(stmt.sql)
+		if m == nil || len(stmt.vars) == 0 || stmt.vars[0] != userID {
+			t.Errorf("query %s with vars %v is not scoped to the caller", stmt.sql, stmt.vars)
+			continue
+		}
+		if strings.Contains(strings.TrimPrefix(stmt.sql, m[0]), " OR ") {
+			t.Errorf("query %s widens the caller filter", stmt.sql)
+		}
+		tables[m[1]] = m[2]
+	}
+
+	want := map[string]string{
+		"users":               "id",
+		"user_settings":       "user_id",
+		"lessons":             "user_id",
+		"lesson_comments":     "user_id",
+		"lesson_annotations":  "user_id",
+		"generations":         "user_id",
+		"generation_presets":  "user_id",
+		"knowledge_documents": "user_id",
+		"lesson_favorites":    "user_id",
+		"favorite_folders":    "user_id",
+		"lesson_likes":        "user_id",
+		"lesson_shares":       "user_id",
+		"api_keys":            "user_id",
+		"notifications":       "user_id",
+		"user_follows":        "follower_id",
+	}
+	for table, column := range want {
+		if got, ok := tables[table]; !ok || got != column {
+			t.Errorf("table %s filtered by %q, want %q", table, got, column)
+		}
+	}
+	if len(tables) != len(want) {
+		t.Errorf("queried tables = %v, want exactly %d", tables, len(want))
+	}
+}
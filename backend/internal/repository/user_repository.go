@@ -2,11 +2,13 @@ package repository
 
 import (
 	"context"
+	"time"
 
 	"lesson-plan/backend/internal/model"
 
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 // UserRepository 用户仓库接口
@@ -20,6 +22,10 @@ type UserRepository interface {
 	ExistsByUsername(ctx context.Context, username string) (bool, error)
 	ExistsByEmail(ctx context.Context, email string) (bool, error)
 	UpdateLastLogin(ctx context.Context, id uuid.UUID) error
+	UpdateNotifyMode(ctx context.Context, id uuid.UUID, mode string) error
+	UpdateStatus(ctx context.Context, id uuid.UUID, status string) error
+	// MarkEmailVerified 记录邮箱验证时间并激活账号
+	MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error
 	List(ctx context.Context, page, pageSize int) ([]model.User, int64, error)
 }
 
@@ -106,6 +112,21 @@ func (r *userRepository) List(ctx context.Context, page, pageSize int) ([]model.
 	return users, total, nil
 }
 
+func (r *userRepository) UpdateNotifyMode(ctx context.Context, id uuid.UUID, mode string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("notify_mode", mode).Error
+}
+
+func (r *userRepository) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Update("status", status).Error
+}
+
+func (r *userRepository) MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	return r.db.WithContext(ctx).Model(&model.User{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":            model.StatusActive,
+		"email_verified_at": verifiedAt,
+	}).Error
+}
+
 // UserSettingsRepository 用户设置仓库接口
 type UserSettingsRepository interface {
 	GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error)
@@ -130,6 +151,16 @@ func (r *userSettingsRepository) GetByUserID(ctx context.Context, userID uuid.UU
 	return &settings, nil
 }
 
+// Upsert 按 user_id 插入或更新，冲突时覆盖可修改的列，保留原行的 ID 与创建时间
 func (r *userSettingsRepository) Upsert(ctx context.Context, settings *model.UserSettings) error {
-	return r.db.WithContext(ctx).Save(settings).Error
+	if settings.ID == uuid.Nil {
+		settings.ID = uuid.New()
+	}
+	return r.db.WithContext(ctx).
+		Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "user_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"theme", "language", "email_notify", "default_subject", "default_grade", "updated_at"}),
+		}).
+		Select("*").
+		Create(settings).Error
 }
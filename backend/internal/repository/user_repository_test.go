@@ -0,0 +1,59 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestUpsertSettingsQuery(t *testing.T) {
+	var captured capturedSQL
+	db := newDryRunDB(t, &captured)
+	_ = db.Callback().Create().After("gorm:create").Register("test:capture", func(db *gorm.DB) {
+		captured.sql = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+		captured.vars = db.Statement.Vars
+	})
+	r := &userSettingsRepository{db: db}
+
+	settings := &model.UserSettings{UserID: uuid.New(), Theme: "dark", Language: "zh-CN", EmailNotify: false}
+	if err := r.Upsert(context.Background(), settings); err != nil {
+		t.Fatalf("Upsert() error = %v", err)
+	}
+	if settings.ID == uuid.Nil {
+		t.Error("Upsert() left ID empty")
+	}
+
+	for _, want := range []string{
+		`INSERT INTO "user_settings"`,
+		`ON CONFLICT ("user_id") DO UPDATE SET`,
+		`"email_notify"="excluded"."email_notify"`,
+		`"default_subject"="excluded"."default_subject"`,
+		`"updated_at"="excluded"."updated_at"`,
+	} {
+		if !strings.Contains(captured.sql, want) {
+			t.Errorf("SQL = %s, want it to contain %q", captured.sql, want)
+		}
+	}
+	// 冲突时保留原行的 ID 与创建时间
+	for _, unwanted := range []string{`"id"="excluded"."id"`, `"created_at"="excluded"."created_at"`} {
+		if strings.Contains(captured.sql, unwanted) {
+			t.Errorf("SQL = %s, should not update %s", captured.sql, unwanted)
+		}
+	}
+
+	// email_notify=false 须显式写入，不能被列默认值 true 覆盖
+	found := false
+	for _, v := range captured.vars {
+		if b, ok := v.(bool); ok && !b {
+			found = true
+		}
+	}
+	if !found {
+		t.Errorf("vars = %v, want email_notify=false written explicitly", captured.vars)
+	}
+}
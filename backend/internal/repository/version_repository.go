@@ -14,6 +14,9 @@ type VersionRepository interface {
 	Create(ctx context.Context, version *model.LessonVersion) error
 	ListByLessonID(ctx context.Context, lessonID uuid.UUID) ([]model.LessonVersion, error)
 	GetByVersion(ctx context.Context, lessonID uuid.UUID, version int) (*model.LessonVersion, error)
+	Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error)
+	// SetLabel 设置版本名称，空串表示取消命名；版本不存在时返回 false
+	SetLabel(ctx context.Context, lessonID uuid.UUID, version int, label string) (bool, error)
 }
 
 type versionRepository struct {
@@ -48,3 +51,31 @@ func (r *versionRepository) GetByVersion(ctx context.Context, lessonID uuid.UUID
 	}
 	return &v, nil
 }
+
+// Prune 只保留最近 keep 个版本，关键版本（is_key）与命名版本不计入也不删除；直接物理删除以释放空间，返回清理条数
+func (r *versionRepository) Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+
+	recent := r.db.WithContext(ctx).
+		Model(&model.LessonVersion{}).
+		Select("version_number").
+		Where("lesson_id = ? AND is_key = ? AND label = ''", lessonID, false).
+		Order("version_number DESC").
+		Limit(keep)
+
+	result := r.db.WithContext(ctx).Unscoped().
+		Where("lesson_id = ? AND is_key = ? AND label = ''", lessonID, false).
+		Where("version_number NOT IN (?)", recent).
+		Delete(&model.LessonVersion{})
+	return result.RowsAffected, result.Error
+}
+
+func (r *versionRepository) SetLabel(ctx context.Context, lessonID uuid.UUID, version int, label string) (bool, error) {
+	result := r.db.WithContext(ctx).
+		Model(&model.LessonVersion{}).
+		Where("lesson_id = ? AND version_number = ?", lessonID, version).
+		Update("label", label)
+	return result.RowsAffected > 0, result.Error
+}
@@ -0,0 +1,64 @@
+package repository
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+func TestPruneQuery(t *testing.T) {
+	var captured capturedSQL
+	db := newDryRunDB(t, &captured)
+	_ = db.Callback().Delete().After("gorm:delete").Register("test:capture", func(db *gorm.DB) {
+		captured.sql = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+		captured.vars = db.Statement.Vars
+	})
+	repo := NewVersionRepository(db)
+	lessonID := uuid.New()
+
+	if _, err := repo.Prune(context.Background(), lessonID, 20); err != nil {
+		t.Fatalf("Prune() error = %v", err)
+	}
+	// 关键版本与命名版本既不参与计数也不会被删除，其余版本只保留最新的 20 个；物理删除不带 deleted_at 条件
+	wantSQL := `DELETE FROM "lesson_versions" WHERE (lesson_id = $1 AND is_key = $2 AND label = '') ` +
+		`AND version_number NOT IN (SELECT "version_number" FROM "lesson_versions" WHERE (lesson_id = $3 AND is_key = $4 AND label = '') ` +
+		`AND "lesson_versions"."deleted_at" IS NULL ORDER BY version_number DESC LIMIT 20)`
+	if captured.sql != wantSQL {
+		t.Errorf("Prune() SQL =\n%s\nwant\n%s", captured.sql, wantSQL)
+	}
+	if len(captured.vars) != 4 || captured.vars[0] != lessonID || captured.vars[1] != false || captured.vars[2] != lessonID || captured.vars[3] != false {
+		t.Errorf("Prune() vars = %v", captured.vars)
+	}
+
+	// keep <= 0 表示不限制，不发出删除语句
+	captured = capturedSQL{}
+	if n, err := repo.Prune(context.Background(), lessonID, 0); err != nil || n != 0 || captured.sql != "" {
+		t.Errorf("Prune(keep=0) = %d, %v, sql %q; want no-op", n, err, captured.sql)
+	}
+}
+
+func TestSetLabelQuery(t *testing.T) {
+	var captured capturedSQL
+	db := newDryRunDB(t, &captured)
+	_ = db.Callback().Update().After("gorm:update").Register("test:capture", func(db *gorm.DB) {
+		captured.sql = strings.Join(strings.Fields(db.Statement.SQL.String()), " ")
+		captured.vars = db.Statement.Vars
+	})
+	repo := NewVersionRepository(db)
+	lessonID := uuid.New()
+
+	if _, err := repo.SetLabel(context.Background(), lessonID, 3, "公开课最终版"); err != nil {
+		t.Fatalf("SetLabel() error = %v", err)
+	}
+	// 只改名称，不触碰版本内容
+	if !strings.HasPrefix(captured.sql, `UPDATE "lesson_versions" SET "label"=$1`) ||
+		!strings.Contains(captured.sql, "lesson_id = $") || !strings.Contains(captured.sql, "version_number = $") {
+		t.Errorf("SetLabel() SQL = %s", captured.sql)
+	}
+	if len(captured.vars) < 3 || captured.vars[0] != "公开课最终版" || captured.vars[1] != lessonID || captured.vars[2] != 3 {
+		t.Errorf("SetLabel() vars = %v", captured.vars)
+	}
+}
@@ -4,7 +4,9 @@ import "fmt"
 
 // AgentRequest Agent请求
 type AgentRequest struct {
-	Subject    string   `json:"subject"`
+	Subject string `json:"subject"`
+	// Subjects 跨学科教案的学科组合，首个与 Subject 相同；单学科时为空
+	Subjects   []string `json:"subjects,omitempty"`
 	Grade      string   `json:"grade"`
 	Topic      string   `json:"topic"`
 	Duration   int      `json:"duration"`
@@ -13,6 +15,11 @@ type AgentRequest struct {
 	Style      string   `json:"style"`
 	Difficulty string   `json:"difficulty"`
 	UserId     string   `json:"userId"`
+
+	// DetailLevel 详略级别 brief/standard/detailed
+	DetailLevel string `json:"detailLevel"`
+	// LessonType 课型 new/review/experiment
+	LessonType string `json:"lessonType"`
 }
 
 // AgentResponse Agent响应
@@ -21,6 +28,8 @@ type AgentResponse struct {
 	Data    *GeneratedLessonData `json:"data"`
 	Error   string               `json:"error,omitempty"`
 	Usage   *TokenUsage          `json:"usage,omitempty"`
+	// MissingFields 逐字段解析时失败的字段，非空表示 Data 只是部分结果
+	MissingFields []string `json:"-"`
 }
 
 // GeneratedLessonData 生成的教案数据
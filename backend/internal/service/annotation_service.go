@@ -0,0 +1,261 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrAnnotationNotFound   = errors.New("批注不存在")
+	ErrInvalidAnnotation    = errors.New("批注位置无效")
+	ErrAnnotationQuoteMatch = errors.New("批注引用的原文与教案内容不匹配")
+)
+
+// annotatableFields 可批注的教案字段
+var annotatableFields = map[string]bool{
+	"title":      true,
+	"objectives": true,
+	"content":    true,
+	"activities": true,
+	"assessment": true,
+	"resources":  true,
+}
+
+// CreateAnnotationRequest 创建批注请求，偏移量按字符（rune）计算，区间左闭右开
+type CreateAnnotationRequest struct {
+	Field       string `json:"field" binding:"required"`
+	StartOffset int    `json:"start_offset" binding:"min=0"`
+	EndOffset   int    `json:"end_offset" binding:"min=0"`
+	Quote       string `json:"quote"`
+	Content     string `json:"content" binding:"required,max=2000"`
+	Suggestion  string `json:"suggestion" binding:"max=5000"`
+}
+
+// AnnotationService 批注服务接口
+type AnnotationService interface {
+	Create(ctx context.Context, lessonID, userID uuid.UUID, req *CreateAnnotationRequest) (*model.LessonAnnotation, error)
+	List(ctx context.Context, lessonID, userID uuid.UUID, field, status string) ([]model.LessonAnnotation, error)
+	UpdateStatus(ctx context.Context, lessonID, id, userID uuid.UUID, status string) (*model.LessonAnnotation, error)
+	Delete(ctx context.Context, lessonID, id, userID uuid.UUID) error
+}
+
+// annotationService 批注服务实现
+type annotationService struct {
+	annotationRepo repository.AnnotationRepository
+	lessonRepo     repository.LessonRepository
+}
+
+// NewAnnotationService 创建批注服务
+func NewAnnotationService(annotationRepo repository.AnnotationRepository, lessonRepo repository.LessonRepository) AnnotationService {
+	return &annotationService{
+		annotationRepo: annotationRepo,
+		lessonRepo:     lessonRepo,
+	}
+}
+
+// IsAnnotatableField 判断字段是否支持批注
+func IsAnnotatableField(field string) bool {
+	return annotatableFields[field]
+}
+
+func (s *annotationService) Create(ctx context.Context, lessonID, userID uuid.UUID, req *CreateAnnotationRequest) (*model.LessonAnnotation, error) {
+	lesson, err := s.viewableLesson(ctx, lessonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if !IsAnnotatableField(req.Field) {
+		return nil, ErrInvalidAnnotation
+	}
+	content := strings.TrimSpace(req.Content)
+	if content == "" {
+		return nil, errors.New("批注内容不能为空")
+	}
+
+	text := []rune(annotationFieldText(lesson, req.Field))
+	start, end := req.StartOffset, req.EndOffset
+	if start > end || end > len(text) {
+		return nil, ErrInvalidAnnotation
+	}
+
+	// 未传引用时取偏移区间内的原文；传了则以引用为准校正偏移
+	quote := req.Quote
+	if quote == "" {
+		quote = string(text[start:end])
+	} else if string(text[start:end]) != quote {
+		newStart, ok := locateQuote(text, quote, start)
+		if !ok {
+			return nil, ErrAnnotationQuoteMatch
+		}
+		start, end = newStart, newStart+len([]rune(quote))
+	}
+
+	annotation := &model.LessonAnnotation{
+		LessonID:    lessonID,
+		UserID:      userID,
+		Field:       req.Field,
+		StartOffset: start,
+		EndOffset:   end,
+		Quote:       quote,
+		Content:     content,
+		Suggestion:  strings.TrimSpace(req.Suggestion),
+		Status:      model.AnnotationStatusOpen,
+	}
+
+	if err := s.annotationRepo.Create(ctx, annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func (s *annotationService) List(ctx context.Context, lessonID, userID uuid.UUID, field, status string) ([]model.LessonAnnotation, error) {
+	lesson, err := s.viewableLesson(ctx, lessonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	annotations, err := s.annotationRepo.ListByLessonID(ctx, lessonID, field, status)
+	if err != nil {
+		return nil, err
+	}
+
+	// 教案编辑后偏移可能漂移，按引用原文重新定位，找不到的标记为过期
+	texts := make(map[string][]rune)
+	for i := range annotations {
+		a := &annotations[i]
+		text, ok := texts[a.Field]
+		if !ok {
+			text = []rune(annotationFieldText(lesson, a.Field))
+			texts[a.Field] = text
+		}
+		if a.Quote == "" {
+			continue
+		}
+		if a.EndOffset <= len(text) && a.StartOffset <= a.EndOffset && string(text[a.StartOffset:a.EndOffset]) == a.Quote {
+			continue
+		}
+		if start, found := locateQuote(text, a.Quote, a.StartOffset); found {
+			a.StartOffset = start
+			a.EndOffset = start + len([]rune(a.Quote))
+		} else {
+			a.Outdated = true
+		}
+	}
+
+	return annotations, nil
+}
+
+func (s *annotationService) UpdateStatus(ctx context.Context, lessonID, id, userID uuid.UUID, status string) (*model.LessonAnnotation, error) {
+	if status != model.AnnotationStatusOpen && status != model.AnnotationStatusResolved {
+		return nil, errors.New("无效的批注状态")
+	}
+
+	annotation, lesson, err := s.annotationWithLesson(ctx, lessonID, id)
+	if err != nil {
+		return nil, err
+	}
+	// 教案作者和批注人都可以处理批注
+	if lesson.UserID != userID && annotation.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	annotation.Status = status
+	if status == model.AnnotationStatusResolved {
+		now := time.Now()
+		annotation.ResolvedBy = &userID
+		annotation.ResolvedAt = &now
+	} else {
+		annotation.ResolvedBy = nil
+		annotation.ResolvedAt = nil
+	}
+
+	if err := s.annotationRepo.Update(ctx, annotation); err != nil {
+		return nil, err
+	}
+	return annotation, nil
+}
+
+func (s *annotationService) Delete(ctx context.Context, lessonID, id, userID uuid.UUID) error {
+	annotation, lesson, err := s.annotationWithLesson(ctx, lessonID, id)
+	if err != nil {
+		return err
+	}
+	if lesson.UserID != userID && annotation.UserID != userID {
+		return ErrUnauthorized
+	}
+	return s.annotationRepo.Delete(ctx, id)
+}
+
+// viewableLesson 作者可批注任意状态的教案，其他人只能批注已发布的教案
+func (s *annotationService) viewableLesson(ctx context.Context, lessonID, userID uuid.UUID) (*model.Lesson, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+	return lesson, nil
+}
+
+func (s *annotationService) annotationWithLesson(ctx context.Context, lessonID, id uuid.UUID) (*model.LessonAnnotation, *model.Lesson, error) {
+	annotation, err := s.annotationRepo.GetByID(ctx, id)
+	if err != nil || annotation.LessonID != lessonID {
+		return nil, nil, ErrAnnotationNotFound
+	}
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, nil, ErrLessonNotFound
+	}
+	return annotation, lesson, nil
+}
+
+// annotationFieldText 返回批注所针对字段的纯文本（jsonb 字段取 text）
+func annotationFieldText(lesson *model.Lesson, field string) string {
+	switch field {
+	case "title":
+		return lesson.Title
+	case "objectives":
+		return normalizeLessonText(lesson.Objectives)
+	case "content":
+		return normalizeLessonText(lesson.Content)
+	case "activities":
+		return normalizeLessonText(lesson.Activities)
+	case "assessment":
+		return normalizeLessonText(lesson.Assessment)
+	case "resources":
+		return normalizeLessonText(lesson.Resources)
+	default:
+		return ""
+	}
+}
+
+// locateQuote 在文本中查找引用片段，存在多处时取离原偏移最近的一处
+func locateQuote(text []rune, quote string, near int) (int, bool) {
+	q := []rune(quote)
+	if len(q) == 0 || len(q) > len(text) {
+		return 0, false
+	}
+
+	best, bestDistance := -1, 0
+	for i := 0; i+len(q) <= len(text); i++ {
+		if string(text[i:i+len(q)]) != quote {
+			continue
+		}
+		distance := i - near
+		if distance < 0 {
+			distance = -distance
+		}
+		if best < 0 || distance < bestDistance {
+			best, bestDistance = i, distance
+		}
+	}
+	return best, best >= 0
+}
@@ -0,0 +1,207 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeAnnotationRepo 内存中的批注仓库，列表顺序与真实仓库一致
+type fakeAnnotationRepo struct {
+	repository.AnnotationRepository
+
+	annotations []model.LessonAnnotation
+}
+
+func (r *fakeAnnotationRepo) Create(ctx context.Context, annotation *model.LessonAnnotation) error {
+	if annotation.ID == uuid.Nil {
+		annotation.ID = uuid.New()
+	}
+	r.annotations = append(r.annotations, *annotation)
+	return nil
+}
+
+func (r *fakeAnnotationRepo) ListByLessonID(ctx context.Context, lessonID uuid.UUID, field, status string) ([]model.LessonAnnotation, error) {
+	var list []model.LessonAnnotation
+	for _, a := range r.annotations {
+		if a.LessonID == lessonID && (field == "" || a.Field == field) && (status == "" || a.Status == status) {
+			list = append(list, a)
+		}
+	}
+	sort.SliceStable(list, func(i, j int) bool {
+		if list[i].Field != list[j].Field {
+			return list[i].Field < list[j].Field
+		}
+		return list[i].StartOffset < list[j].StartOffset
+	})
+	return list, nil
+}
+
+func TestCreateAnnotationLocatesQuote(t *testing.T) {
+	authorID, reviewerID := uuid.New(), uuid.New()
+	// content 的纯文本为「先复习整数，再学习分数，最后比较整数与分数」
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	lesson.Content = wrapLessonText("先复习整数，再学习分数，最后比较整数与分数")
+	draft := publishableLesson(authorID, model.LessonStatusDraft)
+
+	tests := []struct {
+		name      string
+		lesson    *model.Lesson
+		userID    uuid.UUID
+		req       CreateAnnotationRequest
+		wantErr   error
+		wantStart int
+		wantEnd   int
+		wantQuote string
+	}{
+		{
+			name:      "quote taken from offsets",
+			lesson:    lesson,
+			userID:    reviewerID,
+			req:       CreateAnnotationRequest{Field: "content", StartOffset: 3, EndOffset: 5, Content: "补充负整数"},
+			wantStart: 3, wantEnd: 5, wantQuote: "整数",
+		},
+		{
+			name:      "stale offsets corrected to nearest quote",
+			lesson:    lesson,
+			userID:    reviewerID,
+			req:       CreateAnnotationRequest{Field: "content", StartOffset: 15, EndOffset: 17, Quote: "整数", Content: "举例说明"},
+			wantStart: 16, wantEnd: 18, wantQuote: "整数",
+		},
+		{
+			name:    "quote not in field",
+			lesson:  lesson,
+			userID:  reviewerID,
+			req:     CreateAnnotationRequest{Field: "content", StartOffset: 0, EndOffset: 2, Quote: "小数", Content: "?"},
+			wantErr: ErrAnnotationQuoteMatch,
+		},
+		{
+			name:    "offset past end of field",
+			lesson:  lesson,
+			userID:  reviewerID,
+			req:     CreateAnnotationRequest{Field: "content", StartOffset: 0, EndOffset: 100, Content: "?"},
+			wantErr: ErrInvalidAnnotation,
+		},
+		{
+			name:    "field not annotatable",
+			lesson:  lesson,
+			userID:  reviewerID,
+			req:     CreateAnnotationRequest{Field: "tags", Content: "?"},
+			wantErr: ErrInvalidAnnotation,
+		},
+		{
+			name:    "reviewer cannot annotate a draft",
+			lesson:  draft,
+			userID:  reviewerID,
+			req:     CreateAnnotationRequest{Field: "title", StartOffset: 0, EndOffset: 1, Content: "?"},
+			wantErr: ErrUnauthorized,
+		},
+		{
+			name:      "author annotates own draft",
+			lesson:    draft,
+			userID:    authorID,
+			req:       CreateAnnotationRequest{Field: "title", StartOffset: 0, EndOffset: 3, Content: "标题再具体些"},
+			wantStart: 0, wantEnd: 3, wantQuote: "正式版",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakeAnnotationRepo{}
+			lessonRepo := newFakeLessonRepo(tt.lesson)
+			svc := NewAnnotationService(repo, lessonRepo)
+
+			got, err := svc.Create(context.Background(), tt.lesson.ID, tt.userID, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.annotations) != 0 {
+					t.Errorf("annotations stored = %d, want 0", len(repo.annotations))
+				}
+				return
+			}
+			if got.StartOffset != tt.wantStart || got.EndOffset != tt.wantEnd || got.Quote != tt.wantQuote {
+				t.Errorf("anchor = [%d,%d) %q, want [%d,%d) %q",
+					got.StartOffset, got.EndOffset, got.Quote, tt.wantStart, tt.wantEnd, tt.wantQuote)
+			}
+			if got.Status != model.AnnotationStatusOpen {
+				t.Errorf("status = %q, want open", got.Status)
+			}
+		})
+	}
+}
+
+func TestListAnnotationsRelocatesAfterEdit(t *testing.T) {
+	ctx := context.Background()
+	authorID, reviewerID := uuid.New(), uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	lesson.Content = wrapLessonText("先复习整数，再学习分数")
+	lessonRepo := newFakeLessonRepo(lesson)
+	repo := &fakeAnnotationRepo{}
+	svc := NewAnnotationService(repo, lessonRepo)
+
+	for _, req := range []CreateAnnotationRequest{
+		{Field: "content", StartOffset: 9, EndOffset: 11, Content: "分数概念"},
+		{Field: "content", StartOffset: 3, EndOffset: 5, Content: "整数范围"},
+		{Field: "title", StartOffset: 0, EndOffset: 2, Content: "标题"},
+	} {
+		if _, err := svc.Create(ctx, lesson.ID, reviewerID, &req); err != nil {
+			t.Fatalf("Create() error = %v", err)
+		}
+	}
+
+	// 作者在开头插入文字并删掉「分数」
+	edited, _ := lessonRepo.GetByID(ctx, lesson.ID)
+	edited.Content = wrapLessonText("课前导入：先复习整数，再学习小数")
+	_ = lessonRepo.Update(ctx, edited)
+
+	tests := []struct {
+		name  string
+		field string
+		want  []model.LessonAnnotation
+	}{
+		{
+			name:  "content annotations in position order",
+			field: "content",
+			want: []model.LessonAnnotation{
+				{Quote: "整数", StartOffset: 8, EndOffset: 10},
+				{Quote: "分数", StartOffset: 9, EndOffset: 11, Outdated: true},
+			},
+		},
+		{
+			name:  "all fields",
+			field: "",
+			want: []model.LessonAnnotation{
+				{Quote: "整数", StartOffset: 8, EndOffset: 10},
+				{Quote: "分数", StartOffset: 9, EndOffset: 11, Outdated: true},
+				{Quote: "正式", StartOffset: 0, EndOffset: 2},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := svc.List(ctx, lesson.ID, reviewerID, tt.field, "")
+			if err != nil {
+				t.Fatalf("List() error = %v", err)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("len = %d, want %d", len(got), len(tt.want))
+			}
+			for i, want := range tt.want {
+				a := got[i]
+				if a.Quote != want.Quote || a.StartOffset != want.StartOffset || a.EndOffset != want.EndOffset || a.Outdated != want.Outdated {
+					t.Errorf("[%d] = %q [%d,%d) outdated=%v, want %q [%d,%d) outdated=%v", i,
+						a.Quote, a.StartOffset, a.EndOffset, a.Outdated, want.Quote, want.StartOffset, want.EndOffset, want.Outdated)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// apiKeyPrefix API Key 明文前缀，便于识别与密钥扫描
+	apiKeyPrefix = "lpk_"
+	// apiKeyDisplayLength 列表中展示的明文前缀长度
+	apiKeyDisplayLength = 12
+	// maxAPIKeysPerUser 每个用户未吊销的 API Key 上限
+	maxAPIKeysPerUser = 20
+	// apiKeyTouchInterval 最近使用时间的刷新间隔，避免每个请求都写库
+	apiKeyTouchInterval = time.Minute
+)
+
+var (
+	ErrAPIKeyNotFound = errors.New("API Key 不存在")
+	ErrAPIKeyInvalid  = errors.New("API Key 无效")
+	ErrAPIKeyRevoked  = errors.New("API Key 已吊销")
+	ErrAPIKeyExpired  = errors.New("API Key 已过期")
+	ErrTooManyAPIKeys = errors.New("API Key 数量已达上限")
+)
+
+// CreateAPIKeyRequest 创建 API Key 请求，Scopes 为空时只授予只读权限，ExpiresInDays 为 0 表示永不过期
+type CreateAPIKeyRequest struct {
+	Name          string   `json:"name" binding:"required,max=100"`
+	Scopes        []string `json:"scopes" binding:"omitempty,dive,oneof=read write generate"`
+	ExpiresInDays int      `json:"expires_in_days" binding:"min=0,max=3650"`
+}
+
+// APIKeyInfo API Key 展示信息，不含明文
+type APIKeyInfo struct {
+	model.APIKey
+	Scopes []string `json:"scopes"`
+	Active bool     `json:"active"`
+}
+
+// CreatedAPIKey 新建的 API Key，Key 为明文，仅此一次返回
+type CreatedAPIKey struct {
+	APIKeyInfo
+	Key string `json:"key"`
+}
+
+// APIKeyService API Key 服务接口
+type APIKeyService interface {
+	Create(ctx context.Context, userID uuid.UUID, req *CreateAPIKeyRequest) (*CreatedAPIKey, error)
+	List(ctx context.Context, userID uuid.UUID) ([]APIKeyInfo, error)
+	Revoke(ctx context.Context, id, userID uuid.UUID) error
+	AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKeyIdentity, error)
+}
+
+// apiKeyService API Key 服务实现
+type apiKeyService struct {
+	apiKeyRepo repository.APIKeyRepository
+	userRepo   repository.UserRepository
+	now        func() time.Time
+}
+
+// NewAPIKeyService 创建 API Key 服务
+func NewAPIKeyService(apiKeyRepo repository.APIKeyRepository, userRepo repository.UserRepository) APIKeyService {
+	return &apiKeyService{
+		apiKeyRepo: apiKeyRepo,
+		userRepo:   userRepo,
+		now:        time.Now,
+	}
+}
+
+func (s *apiKeyService) Create(ctx context.Context, userID uuid.UUID, req *CreateAPIKeyRequest) (*CreatedAPIKey, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, errors.New("API Key 名称不能为空")
+	}
+
+	count, err := s.apiKeyRepo.CountActiveByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if count >= maxAPIKeysPerUser {
+		return nil, ErrTooManyAPIKeys
+	}
+
+	rawKey, err := newAPIKey()
+	if err != nil {
+		return nil, err
+	}
+	scopesJSON, _ := json.Marshal(normalizeAPIKeyScopes(req.Scopes))
+
+	key := &model.APIKey{
+		UserID:  userID,
+		Name:    name,
+		Prefix:  rawKey[:apiKeyDisplayLength],
+		KeyHash: hashAPIKey(rawKey),
+		Scopes:  string(scopesJSON),
+	}
+	if req.ExpiresInDays > 0 {
+		expiresAt := s.now().AddDate(0, 0, req.ExpiresInDays)
+		key.ExpiresAt = &expiresAt
+	}
+
+	if err := s.apiKeyRepo.Create(ctx, key); err != nil {
+		return nil, err
+	}
+	return &CreatedAPIKey{APIKeyInfo: s.toInfo(*key), Key: rawKey}, nil
+}
+
+func (s *apiKeyService) List(ctx context.Context, userID uuid.UUID) ([]APIKeyInfo, error) {
+	keys, err := s.apiKeyRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	items := make([]APIKeyInfo, 0, len(keys))
+	for _, key := range keys {
+		items = append(items, s.toInfo(key))
+	}
+	return items, nil
+}
+
+// Revoke 吊销 API Key，立即生效；重复吊销视为成功
+func (s *apiKeyService) Revoke(ctx context.Context, id, userID uuid.UUID) error {
+	key, err := s.apiKeyRepo.GetByID(ctx, id)
+	if err != nil || key.UserID != userID {
+		return ErrAPIKeyNotFound
+	}
+	if key.RevokedAt != nil {
+		return nil
+	}
+	return s.apiKeyRepo.Revoke(ctx, id, s.now())
+}
+
+// AuthenticateAPIKey 校验 API Key 明文：Key 全局唯一，按哈希跨租户查找，租户取自 Key 本身
+func (s *apiKeyService) AuthenticateAPIKey(ctx context.Context, rawKey string) (*model.APIKeyIdentity, error) {
+	if !strings.HasPrefix(rawKey, apiKeyPrefix) {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	globalCtx := tenant.WithoutTenant(ctx)
+	key, err := s.apiKeyRepo.GetByHash(globalCtx, hashAPIKey(rawKey))
+	if err != nil {
+		return nil, ErrAPIKeyInvalid
+	}
+	now := s.now()
+	if key.RevokedAt != nil {
+		return nil, ErrAPIKeyRevoked
+	}
+	if key.IsExpired(now) {
+		return nil, ErrAPIKeyExpired
+	}
+
+	user, err := s.userRepo.GetByID(globalCtx, key.UserID)
+	if err != nil || user.Status != model.StatusActive {
+		return nil, ErrAPIKeyInvalid
+	}
+
+	if key.LastUsedAt == nil || now.Sub(*key.LastUsedAt) >= apiKeyTouchInterval {
+		_ = s.apiKeyRepo.TouchLastUsed(globalCtx, key.ID, now)
+	}
+
+	return &model.APIKeyIdentity{
+		KeyID:    key.ID.String(),
+		UserID:   user.ID.String(),
+		Username: user.Username,
+		Email:    user.Email,
+		Role:     user.Role,
+		TenantID: key.TenantID,
+		Scopes:   decodeAPIKeyScopes(key.Scopes),
+	}, nil
+}
+
+func (s *apiKeyService) toInfo(key model.APIKey) APIKeyInfo {
+	return APIKeyInfo{
+		APIKey: key,
+		Scopes: decodeAPIKeyScopes(key.Scopes),
+		Active: key.RevokedAt == nil && !key.IsExpired(s.now()),
+	}
+}
+
+// normalizeAPIKeyScopes 去重并保持固定顺序，未指定时只授予只读权限
+func normalizeAPIKeyScopes(scopes []string) []string {
+	requested := make(map[string]bool, len(scopes))
+	for _, scope := range scopes {
+		requested[strings.TrimSpace(scope)] = true
+	}
+
+	result := make([]string, 0, 3)
+	for _, scope := range []string{model.APIKeyScopeRead, model.APIKeyScopeWrite, model.APIKeyScopeGenerate} {
+		if requested[scope] {
+			result = append(result, scope)
+		}
+	}
+	if len(result) == 0 {
+		result = append(result, model.APIKeyScopeRead)
+	}
+	return result
+}
+
+func decodeAPIKeyScopes(raw string) []string {
+	scopes := []string{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &scopes)
+	}
+	return scopes
+}
+
+// newAPIKey 生成 API Key 明文：固定前缀 + 32 字节随机数
+func newAPIKey() (string, error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return apiKeyPrefix + base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// hashAPIKey Key 本身是高熵随机数，SHA-256 即可安全存储并支持按哈希直接查找
+func hashAPIKey(rawKey string) string {
+	sum := sha256.Sum256([]byte(rawKey))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,287 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+// fakeAPIKeyRepo 内存中的 API Key 仓库
+type fakeAPIKeyRepo struct {
+	repository.APIKeyRepository
+
+	mu   sync.Mutex
+	keys map[uuid.UUID]*model.APIKey
+}
+
+func newFakeAPIKeyRepo() *fakeAPIKeyRepo {
+	return &fakeAPIKeyRepo{keys: make(map[uuid.UUID]*model.APIKey)}
+}
+
+func (r *fakeAPIKeyRepo) Create(ctx context.Context, key *model.APIKey) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key.ID == uuid.Nil {
+		key.ID = uuid.New()
+	}
+	stored := *key
+	r.keys[key.ID] = &stored
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	key, ok := r.keys[id]
+	if !ok {
+		return nil, errRecordNotFound
+	}
+	copied := *key
+	return &copied, nil
+}
+
+func (r *fakeAPIKeyRepo) GetByHash(ctx context.Context, keyHash string) (*model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, key := range r.keys {
+		if key.KeyHash == keyHash {
+			copied := *key
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakeAPIKeyRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.APIKey, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var keys []model.APIKey
+	for _, key := range r.keys {
+		if key.UserID == userID {
+			keys = append(keys, *key)
+		}
+	}
+	return keys, nil
+}
+
+func (r *fakeAPIKeyRepo) CountActiveByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	keys, _ := r.ListByUserID(ctx, userID)
+	var count int64
+	for _, key := range keys {
+		if key.RevokedAt == nil {
+			count++
+		}
+	}
+	return count, nil
+}
+
+func (r *fakeAPIKeyRepo) Revoke(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.keys[id]; ok {
+		key.RevokedAt = &at
+	}
+	return nil
+}
+
+func (r *fakeAPIKeyRepo) TouchLastUsed(ctx context.Context, id uuid.UUID, at time.Time) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if key, ok := r.keys[id]; ok {
+		key.LastUsedAt = &at
+	}
+	return nil
+}
+
+func TestCreateAPIKeyReturnsPlaintextOnceAndStoresHash(t *testing.T) {
+	ctx := context.Background()
+	user := activeUser(t, "teacher@example.com", "Passw0rd!")
+	keys := newFakeAPIKeyRepo()
+	svc := NewAPIKeyService(keys, newFakeUserRepo(user))
+
+	created, err := svc.Create(ctx, user.ID, &CreateAPIKeyRequest{Name: "  集成  ", Scopes: []string{"write", "read", "write"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if !strings.HasPrefix(created.Key, apiKeyPrefix) || len(created.Key) < 40 {
+		t.Fatalf("Key = %q, want prefixed high-entropy plaintext", created.Key)
+	}
+	if created.Name != "集成" || strings.Join(created.Scopes, ",") != "read,write" {
+		t.Errorf("created = name %q scopes %v", created.Name, created.Scopes)
+	}
+
+	stored, _ := keys.GetByID(ctx, created.ID)
+	if stored.KeyHash != hashAPIKey(created.Key) || stored.KeyHash == created.Key {
+		t.Errorf("stored KeyHash = %q, want sha256 of the plaintext", stored.KeyHash)
+	}
+	if stored.Prefix != created.Key[:apiKeyDisplayLength] {
+		t.Errorf("stored Prefix = %q, want display prefix only", stored.Prefix)
+	}
+	storedJSON, _ := json.Marshal(stored)
+	if strings.Contains(string(storedJSON), created.Key) || strings.Contains(stored.Scopes+stored.Name, created.Key) {
+		t.Error("plaintext key persisted")
+	}
+
+	// 列表只返回展示信息，不再包含明文与哈希
+	list, err := svc.List(ctx, user.ID)
+	if err != nil || len(list) != 1 {
+		t.Fatalf("List() = %v, %v", list, err)
+	}
+	listJSON, _ := json.Marshal(list)
+	if strings.Contains(string(listJSON), created.Key) || strings.Contains(string(listJSON), stored.KeyHash) {
+		t.Errorf("List() leaks key material: %s", listJSON)
+	}
+}
+
+func TestAuthenticateAPIKey(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string
+		wantErr error
+	}{
+		{
+			name: "valid key",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				return created.Key
+			},
+		},
+		{
+			name: "revoked key",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				if err := svc.Revoke(ctx, created.ID, created.UserID); err != nil {
+					t.Fatalf("Revoke() error = %v", err)
+				}
+				return created.Key
+			},
+			wantErr: ErrAPIKeyRevoked,
+		},
+		{
+			name: "expired key",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				past := time.Now().Add(-time.Minute)
+				keys.keys[created.ID].ExpiresAt = &past
+				return created.Key
+			},
+			wantErr: ErrAPIKeyExpired,
+		},
+		{
+			name: "owner banned",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				users.users[created.UserID].Status = model.StatusBanned
+				return created.Key
+			},
+			wantErr: ErrAPIKeyInvalid,
+		},
+		{
+			name: "unknown key",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				return apiKeyPrefix + "does-not-exist"
+			},
+			wantErr: ErrAPIKeyInvalid,
+		},
+		{
+			name: "hash instead of plaintext",
+			prepare: func(t *testing.T, svc APIKeyService, keys *fakeAPIKeyRepo, users *fakeUserRepo, created *CreatedAPIKey) string {
+				return keys.keys[created.ID].KeyHash
+			},
+			wantErr: ErrAPIKeyInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := activeUser(t, "teacher@example.com", "Passw0rd!")
+			users := newFakeUserRepo(user)
+			keys := newFakeAPIKeyRepo()
+			svc := NewAPIKeyService(keys, users)
+			created, err := svc.Create(ctx, user.ID, &CreateAPIKeyRequest{Name: "ci"})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			identity, err := svc.AuthenticateAPIKey(ctx, tt.prepare(t, svc, keys, users, created))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("AuthenticateAPIKey() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if identity.UserID != user.ID.String() || identity.KeyID != created.ID.String() || !identity.HasScope(model.APIKeyScopeRead) {
+				t.Errorf("identity = %+v", identity)
+			}
+			if keys.keys[created.ID].LastUsedAt == nil {
+				t.Error("LastUsedAt not recorded")
+			}
+		})
+	}
+}
+
+func TestAPIKeyThroughAuthMiddleware(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	user := activeUser(t, "teacher@example.com", "Passw0rd!")
+	svc := NewAPIKeyService(newFakeAPIKeyRepo(), newFakeUserRepo(user))
+
+	readOnly, _ := svc.Create(ctx, user.ID, &CreateAPIKeyRequest{Name: "只读"})
+	readWrite, _ := svc.Create(ctx, user.ID, &CreateAPIKeyRequest{Name: "读写", Scopes: []string{"read", "write"}})
+	revoked, _ := svc.Create(ctx, user.ID, &CreateAPIKeyRequest{Name: "已吊销", Scopes: []string{"read", "write"}})
+	if err := svc.Revoke(ctx, revoked.ID, user.ID); err != nil {
+		t.Fatalf("Revoke() error = %v", err)
+	}
+
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	r := gin.New()
+	api := r.Group("/api", middleware.AuthMiddleware(jwtManager, svc, nil))
+	currentUser := func(status int) gin.HandlerFunc {
+		return func(c *gin.Context) {
+			userID, _ := middleware.GetCurrentUserID(c)
+			c.String(status, userID)
+		}
+	}
+	api.GET("/lessons", currentUser(http.StatusOK))
+	api.POST("/lessons", currentUser(http.StatusCreated))
+
+	tests := []struct {
+		name       string
+		method     string
+		key        string
+		wantStatus int
+	}{
+		{name: "valid key reads", method: http.MethodGet, key: readOnly.Key, wantStatus: http.StatusOK},
+		{name: "read-only key cannot write", method: http.MethodPost, key: readOnly.Key, wantStatus: http.StatusForbidden},
+		{name: "read-write key writes", method: http.MethodPost, key: readWrite.Key, wantStatus: http.StatusCreated},
+		{name: "revoked key", method: http.MethodGet, key: revoked.Key, wantStatus: http.StatusUnauthorized},
+		{name: "garbage key", method: http.MethodGet, key: "lpk_garbage", wantStatus: http.StatusUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(tt.method, "/api/lessons", nil)
+			req.Header.Set(middleware.APIKeyHeader, tt.key)
+			w := httptest.NewRecorder()
+			r.ServeHTTP(w, req)
+			if w.Code != tt.wantStatus {
+				t.Fatalf("status = %d, want %d: %s", w.Code, tt.wantStatus, w.Body.String())
+			}
+			if w.Code < 300 && w.Body.String() != user.ID.String() {
+				t.Errorf("authenticated user = %q, want %s", w.Body.String(), user.ID)
+			}
+		})
+	}
+}
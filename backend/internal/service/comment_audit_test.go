@@ -0,0 +1,153 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// auditingCommentRepo 支持软删除与审计记录的评论仓库
+type auditingCommentRepo struct {
+	*fakeCommentRepo
+
+	deleted  map[uuid.UUID]bool
+	audits   []model.CommentAudit
+	auditErr error
+}
+
+func newAuditingCommentRepo(comments ...*model.Comment) *auditingCommentRepo {
+	return &auditingCommentRepo{fakeCommentRepo: newFakeCommentRepo(comments...), deleted: make(map[uuid.UUID]bool)}
+}
+
+func (r *auditingCommentRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+	if r.deleted[id] {
+		return nil, errRecordNotFound
+	}
+	return r.fakeCommentRepo.GetByID(ctx, id)
+}
+
+func (r *auditingCommentRepo) GetDeletedByID(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+	if !r.deleted[id] {
+		return nil, errRecordNotFound
+	}
+	return r.fakeCommentRepo.GetByID(ctx, id)
+}
+
+func (r *auditingCommentRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.deleted[id] = true
+	return nil
+}
+
+func (r *auditingCommentRepo) Restore(ctx context.Context, id uuid.UUID) error {
+	delete(r.deleted, id)
+	return nil
+}
+
+func (r *auditingCommentRepo) CreateAudit(ctx context.Context, audit *model.CommentAudit) error {
+	if r.auditErr != nil {
+		return r.auditErr
+	}
+	r.audits = append(r.audits, *audit)
+	return nil
+}
+
+func TestDeleteAndRestoreComment(t *testing.T) {
+	ctx := context.Background()
+	authorID, adminID, otherID := uuid.New(), uuid.New(), uuid.New()
+
+	tests := []struct {
+		name    string
+		actorID uuid.UUID
+		role    string
+		wantErr error
+	}{
+		{name: "author", actorID: authorID, role: model.RoleTeacher},
+		{name: "admin", actorID: adminID, role: model.RoleAdmin},
+		{name: "other user", actorID: otherID, role: model.RoleTeacher, wantErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comment := &model.Comment{ID: uuid.New(), LessonID: uuid.New(), UserID: authorID, Content: "讲得很清楚"}
+			repo := newAuditingCommentRepo(comment)
+			lessons := &recountingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}
+			svc := NewCommentService(repo, lessons, nil)
+
+			if err := svc.Delete(ctx, comment.ID, tt.actorID, tt.role); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Delete() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.deleted[comment.ID] || len(repo.audits) != 0 {
+					t.Errorf("unauthorized delete changed state: deleted %v, %d audits", repo.deleted[comment.ID], len(repo.audits))
+				}
+				return
+			}
+
+			restored, err := svc.Restore(ctx, comment.ID, tt.actorID, tt.role)
+			if err != nil {
+				t.Fatalf("Restore() error = %v", err)
+			}
+			if restored.ID != comment.ID || repo.deleted[comment.ID] {
+				t.Errorf("Restore() = %+v, want the comment back", restored)
+			}
+			if lessons.recounts != 2 {
+				t.Errorf("lesson recounted %d times, want 2", lessons.recounts)
+			}
+
+			// 删除与恢复各记录一条审计，保存操作者与评论内容快照
+			if len(repo.audits) != 2 {
+				t.Fatalf("audits = %+v, want delete and restore", repo.audits)
+			}
+			for i, action := range []string{model.CommentAuditActionDelete, model.CommentAuditActionRestore} {
+				audit := repo.audits[i]
+				if audit.Action != action || audit.CommentID != comment.ID || audit.LessonID != comment.LessonID ||
+					audit.ActorID != tt.actorID || audit.ActorRole != tt.role || audit.Content != comment.Content {
+					t.Errorf("audit[%d] = %+v, want %s by %s", i, audit, action, tt.role)
+				}
+			}
+		})
+	}
+}
+
+func TestRestoreCommentErrors(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	live := &model.Comment{ID: uuid.New(), LessonID: uuid.New(), UserID: authorID}
+	deleted := &model.Comment{ID: uuid.New(), LessonID: uuid.New(), UserID: authorID}
+	repo := newAuditingCommentRepo(live, deleted)
+	repo.deleted[deleted.ID] = true
+	svc := NewCommentService(repo, &recountingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}, nil)
+
+	// 未删除或不存在的评论无法恢复
+	for _, id := range []uuid.UUID{live.ID, uuid.New()} {
+		if _, err := svc.Restore(ctx, id, authorID, model.RoleTeacher); !errors.Is(err, ErrCommentNotFound) {
+			t.Errorf("Restore(%s) error = %v, want ErrCommentNotFound", id, err)
+		}
+	}
+	if _, err := svc.Restore(ctx, deleted.ID, uuid.New(), model.RoleTeacher); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Restore() by other user error = %v, want ErrUnauthorized", err)
+	}
+	if !repo.deleted[deleted.ID] || len(repo.audits) != 0 {
+		t.Errorf("failed restores changed state: deleted %v, %d audits", repo.deleted[deleted.ID], len(repo.audits))
+	}
+}
+
+func TestDeleteCommentAuditFailureKeepsDeletion(t *testing.T) {
+	ctx := context.Background()
+	comment := &model.Comment{ID: uuid.New(), LessonID: uuid.New(), UserID: uuid.New()}
+	repo := newAuditingCommentRepo(comment)
+	repo.auditErr = errors.New("insert failed")
+	svc := NewCommentService(repo, &recountingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}, nil)
+
+	// 审计写入失败只记录日志，删除照常生效
+	if err := svc.Delete(ctx, comment.ID, comment.UserID, model.RoleTeacher); err != nil {
+		t.Fatalf("Delete() error = %v, want nil", err)
+	}
+	if !repo.deleted[comment.ID] {
+		t.Error("comment not deleted after audit failure")
+	}
+}
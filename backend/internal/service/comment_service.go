@@ -7,6 +7,7 @@ import (
 
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/logger"
 
 	"github.com/google/uuid"
 )
@@ -14,21 +15,32 @@ import (
 // CommentService 评论服务接口
 type CommentService interface {
 	Create(ctx context.Context, userID, lessonID uuid.UUID, content string, parentID *uuid.UUID) (*model.Comment, error)
-	Delete(ctx context.Context, id, userID uuid.UUID) error
-	List(ctx context.Context, lessonID uuid.UUID, page, pageSize int) ([]model.Comment, int64, error)
+	// Delete 软删除评论，评论作者或管理员可操作，并记录审计
+	Delete(ctx context.Context, id, userID uuid.UUID, role string) error
+	// Restore 恢复已删除的评论，评论作者或管理员可操作，并记录审计
+	Restore(ctx context.Context, id, userID uuid.UUID, role string) (*model.Comment, error)
+	// Like 点赞评论，重复点赞幂等；评论须属于 lessonID 且该教案对用户可见
+	Like(ctx context.Context, lessonID, id, userID uuid.UUID) (*model.Comment, error)
+	// Unlike 取消评论点赞，未点赞时幂等；校验同 Like
+	Unlike(ctx context.Context, lessonID, id, userID uuid.UUID) (*model.Comment, error)
+	// List 教案的评论列表，viewerID 为空表示未登录，只能查看已发布教案的评论
+	List(ctx context.Context, lessonID uuid.UUID, viewerID *uuid.UUID, sort string, page, pageSize int) ([]model.Comment, int64, error)
+	ListAudits(ctx context.Context, lessonID *uuid.UUID, page, pageSize int) ([]model.CommentAudit, int64, error)
 }
 
 // commentService 评论服务实现
 type commentService struct {
 	commentRepo repository.CommentRepository
 	lessonRepo  repository.LessonRepository
+	notifier    NotificationService
 }
 
 // NewCommentService 创建评论服务
-func NewCommentService(commentRepo repository.CommentRepository, lessonRepo repository.LessonRepository) CommentService {
+func NewCommentService(commentRepo repository.CommentRepository, lessonRepo repository.LessonRepository, notifier NotificationService) CommentService {
 	return &commentService{
 		commentRepo: commentRepo,
 		lessonRepo:  lessonRepo,
+		notifier:    notifier,
 	}
 }
 
@@ -50,16 +62,17 @@ func (s *commentService) Create(ctx context.Context, userID, lessonID uuid.UUID,
 	}
 
 	_ = s.lessonRepo.UpdateCounts(ctx, lessonID)
+	s.notifier.NotifyComment(ctx, comment)
 	return comment, nil
 }
 
-func (s *commentService) Delete(ctx context.Context, id, userID uuid.UUID) error {
+func (s *commentService) Delete(ctx context.Context, id, userID uuid.UUID, role string) error {
 	comment, err := s.commentRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrCommentNotFound
 	}
 
-	if comment.UserID != userID {
+	if !canModerateComment(comment, userID, role) {
 		return ErrUnauthorized
 	}
 
@@ -67,10 +80,102 @@ func (s *commentService) Delete(ctx context.Context, id, userID uuid.UUID) error
 		return err
 	}
 
+	s.audit(ctx, comment, userID, role, model.CommentAuditActionDelete)
 	_ = s.lessonRepo.UpdateCounts(ctx, comment.LessonID)
 	return nil
 }
 
-func (s *commentService) List(ctx context.Context, lessonID uuid.UUID, page, pageSize int) ([]model.Comment, int64, error) {
-	return s.commentRepo.ListByLessonID(ctx, lessonID, page, pageSize)
+func (s *commentService) Restore(ctx context.Context, id, userID uuid.UUID, role string) (*model.Comment, error) {
+	comment, err := s.commentRepo.GetDeletedByID(ctx, id)
+	if err != nil {
+		return nil, ErrCommentNotFound
+	}
+
+	if !canModerateComment(comment, userID, role) {
+		return nil, ErrUnauthorized
+	}
+
+	if err := s.commentRepo.Restore(ctx, id); err != nil {
+		return nil, err
+	}
+
+	s.audit(ctx, comment, userID, role, model.CommentAuditActionRestore)
+	_ = s.lessonRepo.UpdateCounts(ctx, comment.LessonID)
+	return s.commentRepo.GetByID(ctx, id)
+}
+
+func (s *commentService) Like(ctx context.Context, lessonID, id, userID uuid.UUID) (*model.Comment, error) {
+	if err := s.checkLessonComment(ctx, lessonID, id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Like(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetByID(ctx, id)
+}
+
+func (s *commentService) Unlike(ctx context.Context, lessonID, id, userID uuid.UUID) (*model.Comment, error) {
+	if err := s.checkLessonComment(ctx, lessonID, id, userID); err != nil {
+		return nil, err
+	}
+	if err := s.commentRepo.Unlike(ctx, id, userID); err != nil {
+		return nil, err
+	}
+	return s.commentRepo.GetByID(ctx, id)
+}
+
+// checkLessonComment 评论须属于路径中的教案（否则视为不存在），且该教案对用户可见
+func (s *commentService) checkLessonComment(ctx context.Context, lessonID, id, userID uuid.UUID) error {
+	comment, err := s.commentRepo.GetByID(ctx, id)
+	if err != nil || comment.LessonID != lessonID {
+		return ErrCommentNotFound
+	}
+	return s.checkLessonVisible(ctx, lessonID, &userID)
+}
+
+// checkLessonVisible 作者可查看任意状态教案的评论，其他人（含未登录）只能查看已发布教案的评论
+func (s *commentService) checkLessonVisible(ctx context.Context, lessonID uuid.UUID, viewerID *uuid.UUID) error {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return ErrLessonNotFound
+	}
+	if lesson.Status != model.LessonStatusPublished && (viewerID == nil || *viewerID != lesson.UserID) {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+func (s *commentService) ListAudits(ctx context.Context, lessonID *uuid.UUID, page, pageSize int) ([]model.CommentAudit, int64, error) {
+	return s.commentRepo.ListAudits(ctx, lessonID, page, pageSize)
+}
+
+// canModerateComment 评论作者与管理员可以删除或恢复评论
+func canModerateComment(comment *model.Comment, userID uuid.UUID, role string) bool {
+	return comment.UserID == userID || role == model.RoleAdmin
+}
+
+// audit 记录评论删除/恢复审计；审计写入失败只记录日志，不回滚已完成的操作
+func (s *commentService) audit(ctx context.Context, comment *model.Comment, actorID uuid.UUID, role, action string) {
+	err := s.commentRepo.CreateAudit(ctx, &model.CommentAudit{
+		CommentID: comment.ID,
+		LessonID:  comment.LessonID,
+		ActorID:   actorID,
+		ActorRole: role,
+		Action:    action,
+		Content:   comment.Content,
+	})
+	if err != nil {
+		logger.Error("Failed to write comment audit",
+			logger.String("comment_id", comment.ID.String()),
+			logger.String("action", action),
+			logger.Err(err),
+		)
+	}
+}
+
+func (s *commentService) List(ctx context.Context, lessonID uuid.UUID, viewerID *uuid.UUID, sort string, page, pageSize int) ([]model.Comment, int64, error) {
+	if err := s.checkLessonVisible(ctx, lessonID, viewerID); err != nil {
+		return nil, 0, err
+	}
+	return s.commentRepo.ListByLessonID(ctx, lessonID, sort, page, pageSize)
 }
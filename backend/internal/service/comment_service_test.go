@@ -0,0 +1,197 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeCommentRepo 内存中的评论仓库，点赞记录按 (评论, 用户) 去重
+type fakeCommentRepo struct {
+	repository.CommentRepository
+
+	comments map[uuid.UUID]*model.Comment
+	likes    map[[2]uuid.UUID]bool
+}
+
+func newFakeCommentRepo(comments ...*model.Comment) *fakeCommentRepo {
+	r := &fakeCommentRepo{comments: make(map[uuid.UUID]*model.Comment), likes: make(map[[2]uuid.UUID]bool)}
+	for _, c := range comments {
+		r.comments[c.ID] = c
+	}
+	return r
+}
+
+func (r *fakeCommentRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Comment, error) {
+	c, ok := r.comments[id]
+	if !ok {
+		return nil, errors.New("record not found")
+	}
+	copied := *c
+	return &copied, nil
+}
+
+func (r *fakeCommentRepo) ListByLessonID(ctx context.Context, lessonID uuid.UUID, sort string, page, pageSize int) ([]model.Comment, int64, error) {
+	var list []model.Comment
+	for _, c := range r.comments {
+		if c.LessonID == lessonID {
+			list = append(list, *c)
+		}
+	}
+	return list, int64(len(list)), nil
+}
+
+func (r *fakeCommentRepo) recount(commentID uuid.UUID) {
+	count := 0
+	for key := range r.likes {
+		if key[0] == commentID {
+			count++
+		}
+	}
+	r.comments[commentID].LikeCount = count
+}
+
+func (r *fakeCommentRepo) Like(ctx context.Context, commentID, userID uuid.UUID) error {
+	r.likes[[2]uuid.UUID{commentID, userID}] = true
+	r.recount(commentID)
+	return nil
+}
+
+func (r *fakeCommentRepo) Unlike(ctx context.Context, commentID, userID uuid.UUID) error {
+	delete(r.likes, [2]uuid.UUID{commentID, userID})
+	r.recount(commentID)
+	return nil
+}
+
+func TestCommentLikeIsIdempotent(t *testing.T) {
+	commentID := uuid.New()
+	lesson := &model.Lesson{ID: uuid.New(), UserID: uuid.New(), Status: model.LessonStatusPublished}
+	alice, bob := uuid.New(), uuid.New()
+
+	type step struct {
+		like   bool
+		userID uuid.UUID
+	}
+	tests := []struct {
+		name      string
+		steps     []step
+		wantCount int
+	}{
+		{"like once", []step{{true, alice}}, 1},
+		{"repeated like counts once", []step{{true, alice}, {true, alice}}, 1},
+		{"likes from different users", []step{{true, alice}, {true, bob}}, 2},
+		{"unlike without like stays at zero", []step{{false, alice}}, 0},
+		{"like then unlike", []step{{true, alice}, {true, bob}, {false, alice}}, 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeCommentRepo(&model.Comment{ID: commentID, LessonID: lesson.ID})
+			svc := NewCommentService(repo, newFakeLessonRepo(lesson), nil)
+
+			var got *model.Comment
+			var err error
+			for _, s := range tt.steps {
+				if s.like {
+					got, err = svc.Like(context.Background(), lesson.ID, commentID, s.userID)
+				} else {
+					got, err = svc.Unlike(context.Background(), lesson.ID, commentID, s.userID)
+				}
+				if err != nil {
+					t.Fatalf("unexpected error: %v", err)
+				}
+			}
+			if got.LikeCount != tt.wantCount {
+				t.Errorf("like_count = %d, want %d", got.LikeCount, tt.wantCount)
+			}
+		})
+	}
+}
+
+func TestCommentLikeUnknownComment(t *testing.T) {
+	svc := NewCommentService(newFakeCommentRepo(), newFakeLessonRepo(), nil)
+	if _, err := svc.Like(context.Background(), uuid.New(), uuid.New(), uuid.New()); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Like() error = %v, want ErrCommentNotFound", err)
+	}
+	if _, err := svc.Unlike(context.Background(), uuid.New(), uuid.New(), uuid.New()); !errors.Is(err, ErrCommentNotFound) {
+		t.Errorf("Unlike() error = %v, want ErrCommentNotFound", err)
+	}
+}
+
+func TestCommentLikeChecksLesson(t *testing.T) {
+	authorID, readerID := uuid.New(), uuid.New()
+	published := &model.Lesson{ID: uuid.New(), UserID: authorID, Status: model.LessonStatusPublished}
+	draft := &model.Lesson{ID: uuid.New(), UserID: authorID, Status: model.LessonStatusDraft}
+	onPublished := &model.Comment{ID: uuid.New(), LessonID: published.ID}
+	onDraft := &model.Comment{ID: uuid.New(), LessonID: draft.ID}
+
+	tests := []struct {
+		name     string
+		lessonID uuid.UUID
+		comment  uuid.UUID
+		userID   uuid.UUID
+		wantErr  error
+	}{
+		{name: "comment of the lesson", lessonID: published.ID, comment: onPublished.ID, userID: readerID},
+		{name: "comment of another lesson", lessonID: published.ID, comment: onDraft.ID, userID: readerID, wantErr: ErrCommentNotFound},
+		{name: "unpublished lesson by reader", lessonID: draft.ID, comment: onDraft.ID, userID: readerID, wantErr: ErrUnauthorized},
+		{name: "unpublished lesson by author", lessonID: draft.ID, comment: onDraft.ID, userID: authorID},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments := newFakeCommentRepo(onPublished, onDraft)
+			svc := NewCommentService(comments, newFakeLessonRepo(published, draft), nil)
+
+			_, err := svc.Like(context.Background(), tt.lessonID, tt.comment, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Like() error = %v, want %v", err, tt.wantErr)
+			}
+			if liked := comments.likes[[2]uuid.UUID{tt.comment, tt.userID}]; liked != (tt.wantErr == nil) {
+				t.Errorf("liked = %v, want %v", liked, tt.wantErr == nil)
+			}
+			if _, err := svc.Unlike(context.Background(), tt.lessonID, tt.comment, tt.userID); !errors.Is(err, tt.wantErr) {
+				t.Errorf("Unlike() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestListCommentsChecksLessonVisibility(t *testing.T) {
+	authorID, readerID := uuid.New(), uuid.New()
+	published := &model.Lesson{ID: uuid.New(), UserID: authorID, Status: model.LessonStatusPublished}
+	draft := &model.Lesson{ID: uuid.New(), UserID: authorID, Status: model.LessonStatusDraft}
+	svc := NewCommentService(
+		newFakeCommentRepo(&model.Comment{ID: uuid.New(), LessonID: published.ID}, &model.Comment{ID: uuid.New(), LessonID: draft.ID}),
+		newFakeLessonRepo(published, draft), nil)
+
+	tests := []struct {
+		name     string
+		lessonID uuid.UUID
+		viewerID *uuid.UUID
+		wantErr  error
+	}{
+		{name: "published lesson anonymously", lessonID: published.ID},
+		{name: "unpublished lesson anonymously", lessonID: draft.ID, wantErr: ErrUnauthorized},
+		{name: "unpublished lesson by reader", lessonID: draft.ID, viewerID: &readerID, wantErr: ErrUnauthorized},
+		{name: "unpublished lesson by author", lessonID: draft.ID, viewerID: &authorID},
+		{name: "missing lesson", lessonID: uuid.New(), wantErr: ErrLessonNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			comments, total, err := svc.List(context.Background(), tt.lessonID, tt.viewerID, "", 1, 20)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("List() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && (total != 1 || len(comments) != 1) {
+				t.Errorf("List() = %d comments (total %d), want 1", len(comments), total)
+			}
+		})
+	}
+}
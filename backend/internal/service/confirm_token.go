@@ -0,0 +1,34 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"lesson-plan/backend/pkg/database"
+)
+
+// confirmTokenUsedKeyPrefix 已使用的二次确认令牌的 Redis 键前缀，键名为前缀加 jti
+const confirmTokenUsedKeyPrefix = "confirm:used:"
+
+// ConfirmTokenStore 二次确认令牌使用记录，保证每个令牌只能使用一次
+type ConfirmTokenStore interface {
+	// Consume 标记令牌已使用，令牌此前已被使用时返回 false
+	Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error)
+}
+
+// redisConfirmTokenStore 基于 Redis 的令牌使用记录，多实例部署时共享
+type redisConfirmTokenStore struct{}
+
+// NewConfirmTokenStore 创建二次确认令牌使用记录，需先初始化 Redis
+func NewConfirmTokenStore() ConfirmTokenStore {
+	return &redisConfirmTokenStore{}
+}
+
+// Consume 以 SETNX 原子地占用 jti，记录保留到令牌原本的过期时间为止
+func (s *redisConfirmTokenStore) Consume(ctx context.Context, jti string, expiresAt time.Time) (bool, error) {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return false, nil
+	}
+	return database.SetNX(ctx, confirmTokenUsedKeyPrefix+jti, true, ttl)
+}
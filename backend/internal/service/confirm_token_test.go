@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/database"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/alicebob/miniredis/v2"
+	"github.com/google/uuid"
+)
+
+// newTestRedis 启动内存 Redis 并作为全局 Redis 连接，测试结束时关闭
+func newTestRedis(t *testing.T) *miniredis.Miniredis {
+	t.Helper()
+	mr := miniredis.RunT(t)
+	port, _ := strconv.Atoi(mr.Port())
+	if _, err := database.InitRedis(&config.RedisConfig{Host: mr.Host(), Port: port}); err != nil {
+		t.Fatalf("InitRedis() error = %v", err)
+	}
+	t.Cleanup(func() { _ = database.CloseRedis() })
+	return mr
+}
+
+func TestRedisConfirmTokenStore(t *testing.T) {
+	ctx := context.Background()
+	mr := newTestRedis(t)
+	store := NewConfirmTokenStore()
+
+	if fresh, err := store.Consume(ctx, "jti-1", time.Now().Add(time.Minute)); err != nil || !fresh {
+		t.Fatalf("first Consume() = %v, %v, want true", fresh, err)
+	}
+	if fresh, _ := store.Consume(ctx, "jti-1", time.Now().Add(time.Minute)); fresh {
+		t.Error("replayed token consumed twice")
+	}
+	if fresh, _ := store.Consume(ctx, "jti-2", time.Now().Add(-time.Second)); fresh {
+		t.Error("expired token consumed")
+	}
+	if ttl := mr.TTL(confirmTokenUsedKeyPrefix + "jti-1"); ttl <= 0 || ttl > time.Minute {
+		t.Errorf("used record ttl = %v, want until token expiry", ttl)
+	}
+}
+
+func TestDeleteLessonRequiresConfirmToken(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	authorID, otherID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		permanent bool
+		token     func(lessonID uuid.UUID) string
+		replay    bool
+		wantErr   error
+	}{
+		{
+			name:    "missing token",
+			token:   func(uuid.UUID) string { return "" },
+			wantErr: ErrConfirmTokenRequired,
+		},
+		{
+			name: "expired token",
+			token: func(id uuid.UUID) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(authorID.String(), confirmActionDeleteLesson, id.String(), -time.Second)
+				return token
+			},
+			wantErr: ErrConfirmTokenInvalid,
+		},
+		{
+			name: "token for another lesson",
+			token: func(uuid.UUID) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(authorID.String(), confirmActionDeleteLesson, uuid.NewString(), time.Minute)
+				return token
+			},
+			wantErr: ErrConfirmTokenInvalid,
+		},
+		{
+			name: "token issued to another user",
+			token: func(id uuid.UUID) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(otherID.String(), confirmActionDeleteLesson, id.String(), time.Minute)
+				return token
+			},
+			wantErr: ErrConfirmTokenInvalid,
+		},
+		{
+			name:      "delete token cannot purge",
+			permanent: true,
+			token: func(id uuid.UUID) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(authorID.String(), confirmActionDeleteLesson, id.String(), time.Minute)
+				return token
+			},
+			wantErr: ErrConfirmTokenInvalid,
+		},
+		{
+			name:    "garbage token",
+			token:   func(uuid.UUID) string { return "not-a-token" },
+			wantErr: ErrConfirmTokenInvalid,
+		},
+		{name: "confirmed delete"},
+		{name: "confirmed purge", permanent: true},
+		{name: "replayed token", replay: true, wantErr: ErrConfirmTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := publishableLesson(authorID, model.LessonStatusDraft)
+			repo := newFakeLessonRepo(lesson)
+			svc := NewLessonService(repo, &fakeFavoriteRepo{}, &fakeLikeRepo{}, nil, nil, nil, nil, jwtManager, NewConfirmTokenStore(), nil, nil, "")
+
+			token := ""
+			if tt.token != nil {
+				token = tt.token(lesson.ID)
+			} else {
+				confirmation, err := svc.ConfirmDelete(ctx, lesson.ID, authorID, tt.permanent)
+				if err != nil {
+					t.Fatalf("ConfirmDelete() error = %v", err)
+				}
+				token = confirmation.Token
+			}
+			if tt.replay {
+				if err := svc.Delete(ctx, lesson.ID, authorID, token); err != nil {
+					t.Fatalf("first Delete() error = %v", err)
+				}
+				// 从回收站恢复后再次使用同一令牌
+				delete(repo.deleted, lesson.ID)
+			}
+
+			remove := svc.Delete
+			if tt.permanent {
+				remove = svc.Purge
+			}
+			if err := remove(ctx, lesson.ID, authorID, token); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			_, err := repo.GetByIDUnscoped(ctx, lesson.ID)
+			exists := err == nil
+			if wantExists := tt.wantErr != nil || !tt.permanent; exists != wantExists {
+				t.Errorf("lesson exists = %v, want %v", exists, wantExists)
+			}
+			if !tt.permanent && (repo.deleted[lesson.ID] != (tt.wantErr == nil)) {
+				t.Errorf("soft deleted = %v, want %v", repo.deleted[lesson.ID], tt.wantErr == nil)
+			}
+		})
+	}
+}
+
+func TestConfirmDeleteOwnerOnly(t *testing.T) {
+	ctx := context.Background()
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	svc := NewLessonService(newFakeLessonRepo(lesson), &fakeFavoriteRepo{}, &fakeLikeRepo{}, nil, nil, nil, nil, jwtManager, nil, nil, nil, "")
+
+	if _, err := svc.ConfirmDelete(ctx, lesson.ID, uuid.New(), false); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("ConfirmDelete() by other user error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.ConfirmDelete(ctx, uuid.New(), authorID, false); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("ConfirmDelete() on missing lesson error = %v, want ErrLessonNotFound", err)
+	}
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"time"
+	"unicode/utf8"
+)
+
+// documentExtractTimeout 单个文档文本提取的超时时间
+const documentExtractTimeout = 60 * time.Second
+
+var (
+	ErrUnsupportedDocumentType = errors.New("仅支持 .txt、.md、.pdf、.docx 格式文件")
+	ErrDocumentExtractFailed   = errors.New("无法从文件中提取文本")
+	ErrDocumentEmpty           = errors.New("文件中没有可识别的文字内容")
+)
+
+// ExtractDocumentText 按扩展名把上传文件转为纯文本：txt/md 直接使用，
+// pdf 调用 pdftotext，docx 调用 pandoc；扫描件等提取不到文字的文件返回 ErrDocumentEmpty
+func ExtractDocumentText(ctx context.Context, ext string, data []byte) (string, error) {
+	var text string
+	switch strings.ToLower(ext) {
+	case ".txt", ".md":
+		if !utf8.Valid(data) {
+			return "", fmt.Errorf("%w：文本文件需为 UTF-8 编码", ErrDocumentExtractFailed)
+		}
+		text = string(data)
+	case ".pdf", ".docx":
+		extracted, err := extractWithTool(ctx, strings.ToLower(ext), data)
+		if err != nil {
+			return "", err
+		}
+		text = extracted
+	default:
+		return "", ErrUnsupportedDocumentType
+	}
+
+	text = strings.TrimSpace(strings.ReplaceAll(text, "\r\n", "\n"))
+	if text == "" {
+		return "", ErrDocumentEmpty
+	}
+	return text, nil
+}
+
+// extractWithTool 把文件写入临时目录后调用外部工具提取文本，工具缺失或转换失败时返回 ErrDocumentExtractFailed
+func extractWithTool(ctx context.Context, ext string, data []byte) (string, error) {
+	tmpDir, err := os.MkdirTemp("", "knowledge-doc-*")
+	if err != nil {
+		return "", fmt.Errorf("创建临时目录失败: %w", err)
+	}
+	defer os.RemoveAll(tmpDir)
+
+	input := filepath.Join(tmpDir, "input"+ext)
+	if err := os.WriteFile(input, data, 0600); err != nil {
+		return "", fmt.Errorf("写入临时文件失败: %w", err)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, documentExtractTimeout)
+	defer cancel()
+
+	var cmd *exec.Cmd
+	switch ext {
+	case ".pdf":
+		// -layout 保留版面顺序，输出到 stdout
+		cmd = exec.CommandContext(ctx, "pdftotext", "-layout", "-enc", "UTF-8", input, "-")
+	case ".docx":
+		cmd = exec.CommandContext(ctx, "pandoc", "--from", "docx", "--to", "plain", "--wrap", "none", input)
+	}
+
+	var stderr bytes.Buffer
+	cmd.Stderr = &stderr
+	output, err := cmd.Output()
+	if err != nil {
+		if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+			return "", fmt.Errorf("%w：处理超时", ErrDocumentExtractFailed)
+		}
+		if errors.Is(err, exec.ErrNotFound) {
+			return "", fmt.Errorf("%w：服务器未安装 %s", ErrDocumentExtractFailed, cmd.Args[0])
+		}
+		detail := strings.TrimSpace(stderr.String())
+		if detail == "" {
+			detail = err.Error()
+		}
+		return "", fmt.Errorf("%w：%s", ErrDocumentExtractFailed, detail)
+	}
+	return string(output), nil
+}
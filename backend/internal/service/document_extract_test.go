@@ -0,0 +1,148 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// stubExtractTool 在只含该工具的 PATH 中放入假的 name 脚本：先把参数逐行记录到返回的文件，再执行 script
+func stubExtractTool(t *testing.T, name, script string) string {
+	t.Helper()
+	dir := t.TempDir()
+	argsFile := filepath.Join(dir, "args")
+	content := "#!/bin/sh\nprintf '%s\\n' \"$@\" > \"" + argsFile + "\"\n" + script
+	if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv("PATH", dir)
+	return argsFile
+}
+
+func TestExtractDocumentTextPlain(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		data    string
+		want    string
+		wantErr error
+	}{
+		{name: "txt", ext: ".txt", data: "有理数的加法", want: "有理数的加法"},
+		{name: "md with crlf", ext: ".MD", data: "\r\n# 有理数\r\n\r\n正文\r\n", want: "# 有理数\n\n正文"},
+		{name: "not utf-8", ext: ".txt", data: "\xd3\xd0\xc0\xed\xca\xfd", wantErr: ErrDocumentExtractFailed},
+		{name: "blank", ext: ".md", data: " \r\n\t", wantErr: ErrDocumentEmpty},
+		{name: "unsupported", ext: ".doc", data: "旧版 Word", wantErr: ErrUnsupportedDocumentType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ExtractDocumentText(context.Background(), tt.ext, []byte(tt.data))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExtractDocumentText() error = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ExtractDocumentText() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+// TestExtractDocumentTextFixtures 用真实工具解析样例文件，未安装 pdftotext/pandoc 时跳过
+func TestExtractDocumentTextFixtures(t *testing.T) {
+	tests := []struct {
+		file string
+		tool string
+		want []string
+	}{
+		{file: "sample.pdf", tool: "pdftotext", want: []string{"Rational Numbers", "number line"}},
+		{file: "sample.docx", tool: "pandoc", want: []string{"有理数的加法", "同号两数相加"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			if _, err := exec.LookPath(tt.tool); err != nil {
+				t.Skipf("%s not installed", tt.tool)
+			}
+			data, err := os.ReadFile(filepath.Join("testdata", tt.file))
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			text, err := ExtractDocumentText(context.Background(), filepath.Ext(tt.file), data)
+			if err != nil {
+				t.Fatalf("ExtractDocumentText(%s) error = %v", tt.file, err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(text, want) {
+					t.Errorf("ExtractDocumentText(%s) = %q, want it to contain %q", tt.file, text, want)
+				}
+			}
+		})
+	}
+}
+
+func TestExtractDocumentTextTools(t *testing.T) {
+	fixture := func(t *testing.T, name string) []byte {
+		t.Helper()
+		data, err := os.ReadFile(filepath.Join("testdata", name))
+		if err != nil {
+			t.Fatal(err)
+		}
+		return data
+	}
+
+	t.Run("pdf arguments", func(t *testing.T) {
+		argsFile := stubExtractTool(t, "pdftotext", `printf '第一章\r\n有理数\r\n'`)
+		text, err := ExtractDocumentText(context.Background(), ".PDF", fixture(t, "sample.pdf"))
+		if err != nil {
+			t.Fatalf("ExtractDocumentText() error = %v", err)
+		}
+		if text != "第一章\n有理数" {
+			t.Errorf("ExtractDocumentText() = %q, want normalized tool output", text)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if !strings.HasPrefix(string(args), "-layout\n-enc\nUTF-8\n") || !strings.HasSuffix(string(args), ".pdf\n-\n") {
+			t.Errorf("pdftotext args = %q, want layout mode writing UTF-8 to stdout", args)
+		}
+	})
+
+	t.Run("docx arguments", func(t *testing.T) {
+		argsFile := stubExtractTool(t, "pandoc", `printf '有理数的加法\n'`)
+		if _, err := ExtractDocumentText(context.Background(), ".docx", fixture(t, "sample.docx")); err != nil {
+			t.Fatalf("ExtractDocumentText() error = %v", err)
+		}
+		args, _ := os.ReadFile(argsFile)
+		if !strings.HasPrefix(string(args), "--from\ndocx\n--to\nplain\n--wrap\nnone\n") {
+			t.Errorf("pandoc args = %q, want docx to unwrapped plain text", args)
+		}
+	})
+
+	t.Run("tool failure", func(t *testing.T) {
+		stubExtractTool(t, "pdftotext", `printf 'Syntax Error: Couldn'"'"'t find trailer dictionary\n' >&2
+exit 1
+`)
+		_, err := ExtractDocumentText(context.Background(), ".pdf", []byte("not a pdf"))
+		if !errors.Is(err, ErrDocumentExtractFailed) || !strings.Contains(err.Error(), "trailer dictionary") {
+			t.Errorf("ExtractDocumentText() error = %v, want ErrDocumentExtractFailed with tool stderr", err)
+		}
+	})
+
+	t.Run("scanned document", func(t *testing.T) {
+		stubExtractTool(t, "pdftotext", `printf '\f\n'`)
+		if _, err := ExtractDocumentText(context.Background(), ".pdf", fixture(t, "sample.pdf")); !errors.Is(err, ErrDocumentEmpty) {
+			t.Errorf("ExtractDocumentText() error = %v, want ErrDocumentEmpty", err)
+		}
+	})
+
+	t.Run("tool missing", func(t *testing.T) {
+		t.Setenv("PATH", t.TempDir())
+		_, err := ExtractDocumentText(context.Background(), ".docx", fixture(t, "sample.docx"))
+		if !errors.Is(err, ErrDocumentExtractFailed) || !strings.Contains(err.Error(), "pandoc") {
+			t.Errorf("ExtractDocumentText() error = %v, want ErrDocumentExtractFailed naming pandoc", err)
+		}
+	})
+}
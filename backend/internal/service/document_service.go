@@ -2,7 +2,10 @@ package service
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
 	"time"
@@ -11,6 +14,17 @@ import (
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
 	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
+
+	"gorm.io/gorm"
+)
+
+// documentProcessTimeout 单个文档构建知识图谱的超时时间，处理中状态超过该时间视为已中断
+const documentProcessTimeout = 10 * time.Minute
+
+var (
+	ErrDocumentNotFound   = errors.New("文档不存在")
+	ErrDocumentProcessing = errors.New("文档正在处理中，请稍后再试")
 )
 
 // DocumentService 文档服务
@@ -29,33 +43,83 @@ func NewDocumentService(documentRepo repository.DocumentRepository, agentConfig
 	}
 }
 
+// ContentHash 计算文档内容哈希
+func ContentHash(content string) string {
+	sum := sha256.Sum256([]byte(content))
+	return hex.EncodeToString(sum[:])
+}
+
+// FindDuplicate 查找用户已上传的同内容文档（失败的文档不算），用于跳过重复处理
+func (s *DocumentService) FindDuplicate(ctx context.Context, userID string, content string) (*model.KnowledgeDocument, error) {
+	return s.documentRepo.FindByContentHash(ctx, userID, ContentHash(content))
+}
+
 // CreateDocument 创建文档记录
-func (s *DocumentService) CreateDocument(doc *model.KnowledgeDocument) error {
-	err := s.documentRepo.CreateDocument(doc)
+func (s *DocumentService) CreateDocument(ctx context.Context, doc *model.KnowledgeDocument) error {
+	if doc.ContentHash == "" {
+		doc.ContentHash = ContentHash(doc.Content)
+	}
+
+	err := s.documentRepo.CreateDocument(ctx, doc)
 	if err != nil {
 		return err
 	}
 
-	// 异步处理文档（带 recover 和超时保护）
+	s.processAsync(doc, false)
+	return nil
+}
+
+// ReprocessDocument 重新处理用户的文档（如 Agent 不可用导致失败），清空上次的处理结果与图谱节点后重建；
+// 正在处理中的文档返回 ErrDocumentProcessing
+func (s *DocumentService) ReprocessDocument(ctx context.Context, id string, userID string) (*model.KnowledgeDocument, error) {
+	doc, err := s.documentRepo.GetDocumentByID(ctx, id, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrDocumentNotFound
+		}
+		return nil, err
+	}
+
+	reset, err := s.documentRepo.ResetForReprocess(ctx, doc.ID, userID, time.Now().Add(-documentProcessTimeout))
+	if err != nil {
+		return nil, err
+	}
+	if !reset {
+		return nil, ErrDocumentProcessing
+	}
+
+	doc.Status = model.DocStatusPending
+	doc.ErrorMsg = ""
+	doc.EntityCount = 0
+	doc.RelationCount = 0
+	s.processAsync(doc, true)
+	return doc, nil
+}
+
+// processAsync 异步处理文档（带 recover 和超时保护），clearNodes 为 true 时先删除上次处理写入的图谱节点。
+// 处理不随请求取消，但仍限定在文档所属租户内更新状态
+func (s *DocumentService) processAsync(doc *model.KnowledgeDocument, clearNodes bool) {
+	tenantCtx := tenant.WithTenant(context.Background(), doc.TenantID)
 	go func() {
 		defer func() {
 			if r := recover(); r != nil {
 				logger.Error(fmt.Sprintf("panic in processDocument for doc %s: %v", doc.ID, r))
-				s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, "内部错误: 处理过程异常")
+				s.documentRepo.UpdateDocumentStatus(tenantCtx, doc.ID, model.DocStatusFailed, 0, 0, "内部错误: 处理过程异常")
 			}
 		}()
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Minute)
+		ctx, cancel := context.WithTimeout(tenantCtx, documentProcessTimeout)
 		defer cancel()
+		if clearNodes {
+			s.deleteDocumentNodes(ctx, doc.ID.String())
+		}
 		s.processDocument(ctx, doc)
 	}()
-
-	return nil
 }
 
 // processDocument 处理文档，调用Agent构建知识图谱
 func (s *DocumentService) processDocument(ctx context.Context, doc *model.KnowledgeDocument) {
 	// 更新状态为处理中
-	if err := s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusProcessing, 0, 0, ""); err != nil {
+	if err := s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusProcessing, 0, 0, ""); err != nil {
 		logger.Error("Failed to update document status: " + err.Error())
 		return
 	}
@@ -64,6 +128,7 @@ func (s *DocumentService) processDocument(ctx context.Context, doc *model.Knowle
 	reqBody := map[string]interface{}{
 		"documentId": doc.ID,
 		"userId":     doc.UserID,
+		"tenantId":   doc.TenantID,
 		"content":    doc.Content,
 		"title":      doc.Title,
 		"subject":    doc.Subject,
@@ -72,7 +137,7 @@ func (s *DocumentService) processDocument(ctx context.Context, doc *model.Knowle
 
 	jsonData, err := json.Marshal(reqBody)
 	if err != nil {
-		s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, "JSON编码错误")
+		s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusFailed, 0, 0, "JSON编码错误")
 		return
 	}
 
@@ -91,13 +156,13 @@ func (s *DocumentService) processDocument(ctx context.Context, doc *model.Knowle
 	)
 	if err != nil {
 		logger.Error("Failed to call agent: " + err.Error())
-		s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, "Agent服务调用失败: "+err.Error())
+		s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusFailed, 0, 0, "Agent服务调用失败: "+err.Error())
 		return
 	}
 
 	if statusCode != http.StatusOK {
 		logger.Error("Agent returned error: " + string(body))
-		s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, "Agent处理失败")
+		s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusFailed, 0, 0, "Agent处理失败")
 		return
 	}
 
@@ -110,34 +175,34 @@ func (s *DocumentService) processDocument(ctx context.Context, doc *model.Knowle
 	}
 
 	if err := json.Unmarshal(body, &result); err != nil {
-		s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, "响应解析失败")
+		s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusFailed, 0, 0, "响应解析失败")
 		return
 	}
 
 	if !result.Success {
-		s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusFailed, 0, 0, result.Message)
+		s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusFailed, 0, 0, result.Message)
 		return
 	}
 
 	// 更新为完成状态
-	s.documentRepo.UpdateDocumentStatus(doc.ID, model.DocStatusCompleted, result.EntityCount, result.RelCount, "")
+	s.documentRepo.UpdateDocumentStatus(ctx, doc.ID, model.DocStatusCompleted, result.EntityCount, result.RelCount, "")
 	logger.Info(fmt.Sprintf("Document %s processed: %d entities, %d relations", doc.ID, result.EntityCount, result.RelCount))
 }
 
 // GetDocument 获取文档
-func (s *DocumentService) GetDocument(id string, userID string) (*model.KnowledgeDocument, error) {
-	return s.documentRepo.GetDocumentByID(id, userID)
+func (s *DocumentService) GetDocument(ctx context.Context, id string, userID string) (*model.KnowledgeDocument, error) {
+	return s.documentRepo.GetDocumentByID(ctx, id, userID)
 }
 
-// ListDocuments 获取文档列表
-func (s *DocumentService) ListDocuments(userID string, page, pageSize int) ([]model.KnowledgeDocument, int64, error) {
-	return s.documentRepo.ListDocuments(userID, page, pageSize)
+// ListDocuments 按筛选条件分页获取文档列表
+func (s *DocumentService) ListDocuments(ctx context.Context, filter repository.DocumentFilter, page, pageSize int) ([]model.KnowledgeDocument, int64, error) {
+	return s.documentRepo.ListDocuments(ctx, filter, page, pageSize)
 }
 
 // DeleteDocument 删除文档
-func (s *DocumentService) DeleteDocument(id string, userID string) error {
+func (s *DocumentService) DeleteDocument(ctx context.Context, id string, userID string) error {
 	// 先获取文档确认权限
-	doc, err := s.documentRepo.GetDocumentByID(id, userID)
+	doc, err := s.documentRepo.GetDocumentByID(ctx, id, userID)
 	if err != nil {
 		return err
 	}
@@ -158,7 +223,7 @@ func (s *DocumentService) DeleteDocument(id string, userID string) error {
 	}()
 
 	// 删除数据库记录
-	return s.documentRepo.DeleteDocument(id, userID)
+	return s.documentRepo.DeleteDocument(ctx, id, userID)
 }
 
 // deleteDocumentNodes 删除Neo4j中的文档节点
@@ -191,6 +256,6 @@ func (s *DocumentService) deleteDocumentNodes(ctx context.Context, documentID st
 }
 
 // GetDocumentStatus 获取文档状态
-func (s *DocumentService) GetDocumentStatus(id string, userID string) (*model.KnowledgeDocument, error) {
-	return s.documentRepo.GetDocumentByID(id, userID)
+func (s *DocumentService) GetDocumentStatus(ctx context.Context, id string, userID string) (*model.KnowledgeDocument, error) {
+	return s.documentRepo.GetDocumentByID(ctx, id, userID)
 }
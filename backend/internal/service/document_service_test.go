@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeDocumentRepo 内存中的知识文档仓库，ResetForReprocess 与真实条件更新一致
+type fakeDocumentRepo struct {
+	repository.DocumentRepository
+	mu   sync.Mutex
+	docs map[uuid.UUID]*model.KnowledgeDocument
+}
+
+func newFakeDocumentRepo(docs ...*model.KnowledgeDocument) *fakeDocumentRepo {
+	r := &fakeDocumentRepo{docs: make(map[uuid.UUID]*model.KnowledgeDocument)}
+	for _, doc := range docs {
+		r.docs[doc.ID] = doc
+	}
+	return r
+}
+
+func (r *fakeDocumentRepo) GetDocumentByID(ctx context.Context, docID string, userID string) (*model.KnowledgeDocument, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	id, _ := uuid.Parse(docID)
+	doc, ok := r.docs[id]
+	if !ok || doc.UserID.String() != userID {
+		return nil, gorm.ErrRecordNotFound
+	}
+	copied := *doc
+	return &copied, nil
+}
+
+func (r *fakeDocumentRepo) UpdateDocumentStatus(ctx context.Context, docID uuid.UUID, status string, entityCount, relCount int, errorMsg string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if doc, ok := r.docs[docID]; ok {
+		doc.Status, doc.EntityCount, doc.RelationCount, doc.ErrorMsg = status, entityCount, relCount, errorMsg
+		doc.UpdatedAt = time.Now()
+	}
+	return nil
+}
+
+func (r *fakeDocumentRepo) ResetForReprocess(ctx context.Context, docID uuid.UUID, userID string, staleBefore time.Time) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	doc, ok := r.docs[docID]
+	if !ok || doc.UserID.String() != userID || (doc.Status == model.DocStatusProcessing && !doc.UpdatedAt.Before(staleBefore)) {
+		return false, nil
+	}
+	doc.Status, doc.EntityCount, doc.RelationCount, doc.ErrorMsg = model.DocStatusPending, 0, 0, ""
+	doc.UpdatedAt = time.Now()
+	return true, nil
+}
+
+// status 返回文档当前状态
+func (r *fakeDocumentRepo) status(id uuid.UUID) model.KnowledgeDocument {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return *r.docs[id]
+}
+
+// graphAgent 模拟 Agent 的建图与删节点接口，按调用顺序记录路径
+type graphAgent struct {
+	*httptest.Server
+	mu    sync.Mutex
+	calls []string
+}
+
+func newGraphAgent(t *testing.T) *graphAgent {
+	t.Helper()
+	agent := &graphAgent{}
+	agent.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		agent.mu.Lock()
+		agent.calls = append(agent.calls, r.URL.Path)
+		agent.mu.Unlock()
+		if r.URL.Path == "/api/build-graph" {
+			_ = json.NewEncoder(w).Encode(map[string]interface{}{"success": true, "entityCount": 12, "relationCount": 8})
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	t.Cleanup(agent.Close)
+	return agent
+}
+
+func (a *graphAgent) recorded() []string {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]string(nil), a.calls...)
+}
+
+// waitForDocumentStatus 等待后台处理把文档更新为 status
+func waitForDocumentStatus(t *testing.T, repo *fakeDocumentRepo, id uuid.UUID, status string) model.KnowledgeDocument {
+	t.Helper()
+	deadline := time.Now().Add(5 * time.Second)
+	for {
+		doc := repo.status(id)
+		if doc.Status == status {
+			return doc
+		}
+		if time.Now().After(deadline) {
+			t.Fatalf("document status = %s, want %s", doc.Status, status)
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+}
+
+func TestReprocessDocument(t *testing.T) {
+	owner := uuid.New()
+	document := func(status string, updatedAt time.Time) *model.KnowledgeDocument {
+		return &model.KnowledgeDocument{
+			ID: uuid.New(), UserID: owner, TenantID: "default", Title: "有理数", Content: "有理数的加法",
+			Status: status, ErrorMsg: "Agent服务调用失败", UpdatedAt: updatedAt,
+		}
+	}
+
+	t.Run("failed document rebuilt", func(t *testing.T) {
+		agent := newGraphAgent(t)
+		doc := document(model.DocStatusFailed, time.Now())
+		repo := newFakeDocumentRepo(doc)
+		svc := NewDocumentService(repo, &config.AgentConfig{URL: agent.URL, Timeout: 5})
+
+		got, err := svc.ReprocessDocument(context.Background(), doc.ID.String(), owner.String())
+		if err != nil {
+			t.Fatalf("ReprocessDocument() error = %v", err)
+		}
+		if got.Status != model.DocStatusPending || got.ErrorMsg != "" {
+			t.Errorf("ReprocessDocument() = (%s, %q), want pending with the error cleared", got.Status, got.ErrorMsg)
+		}
+
+		done := waitForDocumentStatus(t, repo, doc.ID, model.DocStatusCompleted)
+		if done.EntityCount != 12 || done.RelationCount != 8 || done.ErrorMsg != "" {
+			t.Errorf("document = (%d entities, %d relations, %q), want rebuilt counts", done.EntityCount, done.RelationCount, done.ErrorMsg)
+		}
+		// 先删除上次写入的图谱节点再重建
+		if calls := agent.recorded(); len(calls) != 2 || calls[0] != "/api/delete-document-nodes" || calls[1] != "/api/build-graph" {
+			t.Errorf("agent calls = %v, want delete nodes then build graph", calls)
+		}
+	})
+
+	t.Run("stale processing document", func(t *testing.T) {
+		agent := newGraphAgent(t)
+		doc := document(model.DocStatusProcessing, time.Now().Add(-documentProcessTimeout-time.Minute))
+		repo := newFakeDocumentRepo(doc)
+		svc := NewDocumentService(repo, &config.AgentConfig{URL: agent.URL, Timeout: 5})
+
+		if _, err := svc.ReprocessDocument(context.Background(), doc.ID.String(), owner.String()); err != nil {
+			t.Fatalf("ReprocessDocument() error = %v", err)
+		}
+		waitForDocumentStatus(t, repo, doc.ID, model.DocStatusCompleted)
+	})
+
+	t.Run("in-flight document rejected", func(t *testing.T) {
+		agent := newGraphAgent(t)
+		doc := document(model.DocStatusProcessing, time.Now().Add(-time.Minute))
+		repo := newFakeDocumentRepo(doc)
+		svc := NewDocumentService(repo, &config.AgentConfig{URL: agent.URL, Timeout: 5})
+
+		if _, err := svc.ReprocessDocument(context.Background(), doc.ID.String(), owner.String()); !errors.Is(err, ErrDocumentProcessing) {
+			t.Fatalf("ReprocessDocument() error = %v, want ErrDocumentProcessing", err)
+		}
+		time.Sleep(50 * time.Millisecond)
+		if calls := agent.recorded(); len(calls) != 0 {
+			t.Errorf("agent calls = %v, want none for an in-flight document", calls)
+		}
+		if stored := repo.status(doc.ID); stored.Status != model.DocStatusProcessing {
+			t.Errorf("status = %s, want still processing", stored.Status)
+		}
+	})
+
+	t.Run("other user's document", func(t *testing.T) {
+		doc := document(model.DocStatusFailed, time.Now())
+		svc := NewDocumentService(newFakeDocumentRepo(doc), &config.AgentConfig{URL: "http://127.0.0.1:0"})
+
+		for _, id := range []string{doc.ID.String(), uuid.NewString()} {
+			if _, err := svc.ReprocessDocument(context.Background(), id, uuid.NewString()); !errors.Is(err, ErrDocumentNotFound) {
+				t.Errorf("ReprocessDocument(%s) error = %v, want ErrDocumentNotFound", id, err)
+			}
+		}
+	})
+}
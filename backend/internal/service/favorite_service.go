@@ -2,6 +2,9 @@ package service
 
 import (
 	"context"
+	"errors"
+	"strings"
+	"unicode/utf8"
 
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
@@ -9,37 +12,57 @@ import (
 	"github.com/google/uuid"
 )
 
+// maxFavoriteFolderNameLength 收藏夹名称最大长度（字符数）
+const maxFavoriteFolderNameLength = 50
+
+var ErrFavoriteFolderNameTooLong = errors.New("收藏夹名称不能超过50个字符")
+
 // FavoriteService 收藏服务接口
 type FavoriteService interface {
-	Add(ctx context.Context, userID, lessonID uuid.UUID) error
+	// Add 收藏教案并放入 folder 收藏夹（不存在时创建），folder 为空表示未分组；已收藏时移入该收藏夹
+	Add(ctx context.Context, userID, lessonID uuid.UUID, folder string) error
 	Remove(ctx context.Context, userID, lessonID uuid.UUID) error
-	List(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error)
+	// List 列出收藏，folder 非空时只列出该收藏夹中的教案
+	List(ctx context.Context, userID uuid.UUID, folder string, page, pageSize int) ([]model.LessonListItem, int64, error)
+	ListFolders(ctx context.Context, userID uuid.UUID) ([]model.FavoriteFolderSummary, error)
 	IsFavorited(ctx context.Context, userID, lessonID uuid.UUID) (bool, error)
 }
 
 // favoriteService 收藏服务实现
 type favoriteService struct {
 	favoriteRepo repository.FavoriteRepository
+	likeRepo     repository.LikeRepository
 	lessonRepo   repository.LessonRepository
 }
 
 // NewFavoriteService 创建收藏服务
-func NewFavoriteService(favoriteRepo repository.FavoriteRepository, lessonRepo repository.LessonRepository) FavoriteService {
+func NewFavoriteService(favoriteRepo repository.FavoriteRepository, likeRepo repository.LikeRepository, lessonRepo repository.LessonRepository) FavoriteService {
 	return &favoriteService{
 		favoriteRepo: favoriteRepo,
+		likeRepo:     likeRepo,
 		lessonRepo:   lessonRepo,
 	}
 }
 
-func (s *favoriteService) Add(ctx context.Context, userID, lessonID uuid.UUID) error {
+func (s *favoriteService) Add(ctx context.Context, userID, lessonID uuid.UUID, folder string) error {
+	folderID, err := s.resolveFolder(ctx, userID, folder)
+	if err != nil {
+		return err
+	}
+
 	exists, _ := s.favoriteRepo.Exists(ctx, userID, lessonID)
 	if exists {
-		return nil
+		// 未指定收藏夹时保持原分组，重复收藏不会把教案移出收藏夹
+		if folderID == nil {
+			return nil
+		}
+		return s.favoriteRepo.SetFolder(ctx, userID, lessonID, folderID)
 	}
 
 	favorite := &model.Favorite{
 		UserID:   userID,
 		LessonID: lessonID,
+		FolderID: folderID,
 	}
 
 	if err := s.favoriteRepo.Create(ctx, favorite); err != nil {
@@ -50,7 +73,16 @@ func (s *favoriteService) Add(ctx context.Context, userID, lessonID uuid.UUID) e
 	return nil
 }
 
+// Remove 取消操作幂等：记录不存在时直接返回成功，不触发计数重算
 func (s *favoriteService) Remove(ctx context.Context, userID, lessonID uuid.UUID) error {
+	exists, err := s.favoriteRepo.Exists(ctx, userID, lessonID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
 	if err := s.favoriteRepo.Delete(ctx, userID, lessonID); err != nil {
 		return err
 	}
@@ -59,8 +91,35 @@ func (s *favoriteService) Remove(ctx context.Context, userID, lessonID uuid.UUID
 	return nil
 }
 
-func (s *favoriteService) List(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error) {
-	favorites, total, err := s.favoriteRepo.ListByUserID(ctx, userID, page, pageSize)
+// resolveFolder 按名称取得收藏夹 ID，不存在时创建；名称为空返回 nil
+func (s *favoriteService) resolveFolder(ctx context.Context, userID uuid.UUID, name string) (*uuid.UUID, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return nil, nil
+	}
+	if utf8.RuneCountInString(name) > maxFavoriteFolderNameLength {
+		return nil, ErrFavoriteFolderNameTooLong
+	}
+
+	folder, err := s.favoriteRepo.GetOrCreateFolder(ctx, userID, name)
+	if err != nil {
+		return nil, err
+	}
+	return &folder.ID, nil
+}
+
+func (s *favoriteService) List(ctx context.Context, userID uuid.UUID, folder string, page, pageSize int) ([]model.LessonListItem, int64, error) {
+	var folderID *uuid.UUID
+	if folder = strings.TrimSpace(folder); folder != "" {
+		f, err := s.favoriteRepo.GetFolderByName(ctx, userID, folder)
+		if err != nil {
+			// 收藏夹不存在时视为空收藏夹
+			return []model.LessonListItem{}, 0, nil
+		}
+		folderID = &f.ID
+	}
+
+	favorites, total, err := s.favoriteRepo.ListByUserID(ctx, userID, folderID, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -68,33 +127,25 @@ func (s *favoriteService) List(ctx context.Context, userID uuid.UUID, page, page
 	items := make([]model.LessonListItem, 0, len(favorites))
 	for _, f := range favorites {
 		if f.Lesson != nil {
-			item := model.LessonListItem{
-				ID:            f.Lesson.ID,
-				Title:         f.Lesson.Title,
-				Subject:       f.Lesson.Subject,
-				Grade:         f.Lesson.Grade,
-				Duration:      f.Lesson.Duration,
-				Status:        f.Lesson.Status,
-				ViewCount:     f.Lesson.ViewCount,
-				LikeCount:     f.Lesson.LikeCount,
-				FavoriteCount: f.Lesson.FavoriteCount,
-				CreatedAt:     f.Lesson.CreatedAt,
-				PublishedAt:   f.Lesson.PublishedAt,
-			}
-			if f.Lesson.User != nil {
-				item.AuthorName = f.Lesson.User.FullName
-				if item.AuthorName == "" {
-					item.AuthorName = f.Lesson.User.Username
-				}
-				item.AuthorAvatar = f.Lesson.User.AvatarURL
-			}
-			items = append(items, item)
+			items = append(items, lessonListItem(*f.Lesson))
 		}
 	}
+	markLessonInteractions(ctx, s.favoriteRepo, s.likeRepo, &userID, items)
 
 	return items, total, nil
 }
 
+func (s *favoriteService) ListFolders(ctx context.Context, userID uuid.UUID) ([]model.FavoriteFolderSummary, error) {
+	folders, err := s.favoriteRepo.ListFolders(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if folders == nil {
+		folders = []model.FavoriteFolderSummary{}
+	}
+	return folders, nil
+}
+
 func (s *favoriteService) IsFavorited(ctx context.Context, userID, lessonID uuid.UUID) (bool, error) {
 	return s.favoriteRepo.Exists(ctx, userID, lessonID)
 }
@@ -0,0 +1,266 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// recountingLessonRepo 记录 UpdateCounts 调用次数
+type recountingLessonRepo struct {
+	*fakeLessonRepo
+	recounts int
+}
+
+func (r *recountingLessonRepo) UpdateCounts(ctx context.Context, id uuid.UUID) error {
+	r.recounts++
+	return nil
+}
+
+// deletingFavoriteRepo 记录 Delete 调用并移除收藏标记
+type deletingFavoriteRepo struct {
+	*fakeFavoriteRepo
+	deletes int
+}
+
+func (r *deletingFavoriteRepo) Delete(ctx context.Context, userID, lessonID uuid.UUID) error {
+	r.deletes++
+	delete(r.marks, [2]uuid.UUID{userID, lessonID})
+	return nil
+}
+
+// deletingLikeRepo 记录 Delete 调用并移除点赞标记
+type deletingLikeRepo struct {
+	*fakeLikeRepo
+	deletes int
+}
+
+func (r *deletingLikeRepo) Delete(ctx context.Context, userID, lessonID uuid.UUID) error {
+	r.deletes++
+	delete(r.marks, [2]uuid.UUID{userID, lessonID})
+	return nil
+}
+
+func TestRemoveIsIdempotent(t *testing.T) {
+	userID, lessonID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name         string
+		marked       bool
+		wantDeletes  int
+		wantRecounts int
+	}{
+		{name: "existing mark is removed and recounted", marked: true, wantDeletes: 1, wantRecounts: 1},
+		{name: "missing mark is a no-op", marked: false},
+	}
+
+	for _, tt := range tests {
+		marks := func() fakeMarks {
+			m := fakeMarks{}
+			if tt.marked {
+				m[[2]uuid.UUID{userID, lessonID}] = true
+			}
+			return m
+		}
+
+		t.Run("favorite/"+tt.name, func(t *testing.T) {
+			lessonRepo := &recountingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}
+			favoriteRepo := &deletingFavoriteRepo{fakeFavoriteRepo: &fakeFavoriteRepo{marks: marks()}}
+			svc := NewFavoriteService(favoriteRepo, &fakeLikeRepo{}, lessonRepo)
+
+			if err := svc.Remove(context.Background(), userID, lessonID); err != nil {
+				t.Fatalf("Remove() error = %v", err)
+			}
+			if favoriteRepo.deletes != tt.wantDeletes || lessonRepo.recounts != tt.wantRecounts {
+				t.Errorf("deletes = %d, recounts = %d, want %d, %d", favoriteRepo.deletes, lessonRepo.recounts, tt.wantDeletes, tt.wantRecounts)
+			}
+		})
+
+		t.Run("like/"+tt.name, func(t *testing.T) {
+			lessonRepo := &recountingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}
+			likeRepo := &deletingLikeRepo{fakeLikeRepo: &fakeLikeRepo{marks: marks()}}
+			svc := NewLikeService(likeRepo, lessonRepo)
+
+			if err := svc.Unlike(context.Background(), userID, lessonID); err != nil {
+				t.Fatalf("Unlike() error = %v", err)
+			}
+			if likeRepo.deletes != tt.wantDeletes || lessonRepo.recounts != tt.wantRecounts {
+				t.Errorf("deletes = %d, recounts = %d, want %d, %d", likeRepo.deletes, lessonRepo.recounts, tt.wantDeletes, tt.wantRecounts)
+			}
+		})
+	}
+}
+
+// folderFavoriteRepo 支持收藏夹的内存收藏仓库，folderOf 记录每条收藏所在的收藏夹
+type folderFavoriteRepo struct {
+	*fakeFavoriteRepo
+	lessons  *fakeLessonRepo
+	folders  []model.FavoriteFolder
+	folderOf map[[2]uuid.UUID]*uuid.UUID
+}
+
+func newFolderFavoriteRepo(lessons *fakeLessonRepo) *folderFavoriteRepo {
+	return &folderFavoriteRepo{
+		fakeFavoriteRepo: &fakeFavoriteRepo{marks: fakeMarks{}},
+		lessons:          lessons,
+		folderOf:         make(map[[2]uuid.UUID]*uuid.UUID),
+	}
+}
+
+func (r *folderFavoriteRepo) Create(ctx context.Context, favorite *model.Favorite) error {
+	key := [2]uuid.UUID{favorite.UserID, favorite.LessonID}
+	r.marks[key] = true
+	r.folderOf[key] = favorite.FolderID
+	return nil
+}
+
+func (r *folderFavoriteRepo) SetFolder(ctx context.Context, userID, lessonID uuid.UUID, folderID *uuid.UUID) error {
+	r.folderOf[[2]uuid.UUID{userID, lessonID}] = folderID
+	return nil
+}
+
+func (r *folderFavoriteRepo) GetFolderByName(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error) {
+	for i := range r.folders {
+		if r.folders[i].UserID == userID && r.folders[i].Name == name {
+			return &r.folders[i], nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *folderFavoriteRepo) GetOrCreateFolder(ctx context.Context, userID uuid.UUID, name string) (*model.FavoriteFolder, error) {
+	if folder, err := r.GetFolderByName(ctx, userID, name); err == nil {
+		return folder, nil
+	}
+	r.folders = append(r.folders, model.FavoriteFolder{ID: uuid.New(), UserID: userID, Name: name})
+	return &r.folders[len(r.folders)-1], nil
+}
+
+func (r *folderFavoriteRepo) ListByUserID(ctx context.Context, userID uuid.UUID, folderID *uuid.UUID, page, pageSize int) ([]model.Favorite, int64, error) {
+	var favorites []model.Favorite
+	for key, id := range r.folderOf {
+		if key[0] != userID || (folderID != nil && (id == nil || *id != *folderID)) {
+			continue
+		}
+		favorites = append(favorites, model.Favorite{UserID: userID, LessonID: key[1], FolderID: id, Lesson: r.lessons.lessons[key[1]]})
+	}
+	return favorites, int64(len(favorites)), nil
+}
+
+func (r *folderFavoriteRepo) ListFolders(ctx context.Context, userID uuid.UUID) ([]model.FavoriteFolderSummary, error) {
+	var summaries []model.FavoriteFolderSummary
+	for _, folder := range r.folders {
+		if folder.UserID != userID {
+			continue
+		}
+		summary := model.FavoriteFolderSummary{ID: folder.ID, Name: folder.Name}
+		for _, id := range r.folderOf {
+			if id != nil && *id == folder.ID {
+				summary.FavoriteCount++
+			}
+		}
+		summaries = append(summaries, summary)
+	}
+	return summaries, nil
+}
+
+func TestAddFavoriteToFolder(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := &model.Lesson{UserID: uuid.New(), Title: "有理数的加法"}
+	lessons := newFakeLessonRepo(lesson)
+	repo := newFolderFavoriteRepo(lessons)
+	svc := NewFavoriteService(repo, &fakeLikeRepo{}, &recountingLessonRepo{fakeLessonRepo: lessons})
+	key := [2]uuid.UUID{userID, lesson.ID}
+
+	// 名称去除首尾空白后按需创建收藏夹
+	if err := svc.Add(ctx, userID, lesson.ID, "  备课 "); err != nil {
+		t.Fatalf("Add() error = %v", err)
+	}
+	if len(repo.folders) != 1 || repo.folders[0].Name != "备课" {
+		t.Fatalf("folders = %+v, want one folder named 备课", repo.folders)
+	}
+	prep := repo.folders[0].ID
+	if got := repo.folderOf[key]; got == nil || *got != prep {
+		t.Errorf("favorite folder = %v, want %s", got, prep)
+	}
+
+	// 重复收藏且未指定收藏夹时保持原分组
+	if err := svc.Add(ctx, userID, lesson.ID, ""); err != nil {
+		t.Fatalf("Add() without folder error = %v", err)
+	}
+	if got := repo.folderOf[key]; got == nil || *got != prep {
+		t.Errorf("favorite folder after re-add = %v, want kept in %s", got, prep)
+	}
+
+	// 重复收藏并指定其他收藏夹时移入该收藏夹
+	if err := svc.Add(ctx, userID, lesson.ID, "公开课"); err != nil {
+		t.Fatalf("Add() to another folder error = %v", err)
+	}
+	if got := repo.folderOf[key]; got == nil || *got != repo.folders[1].ID {
+		t.Errorf("favorite folder after move = %v, want %s", got, repo.folders[1].ID)
+	}
+
+	if err := svc.Add(ctx, userID, lesson.ID, strings.Repeat("夹", maxFavoriteFolderNameLength+1)); !errors.Is(err, ErrFavoriteFolderNameTooLong) {
+		t.Errorf("Add() with long folder name error = %v, want ErrFavoriteFolderNameTooLong", err)
+	}
+	if len(repo.folders) != 2 {
+		t.Errorf("folders = %d, want the rejected name not created", len(repo.folders))
+	}
+}
+
+func TestListFavoritesByFolder(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	grouped := &model.Lesson{UserID: uuid.New(), Title: "已分组"}
+	loose := &model.Lesson{UserID: uuid.New(), Title: "未分组"}
+	lessons := newFakeLessonRepo(grouped, loose)
+	repo := newFolderFavoriteRepo(lessons)
+	svc := NewFavoriteService(repo, &fakeLikeRepo{}, &recountingLessonRepo{fakeLessonRepo: lessons})
+
+	folders, err := svc.ListFolders(ctx, userID)
+	if err != nil || folders == nil || len(folders) != 0 {
+		t.Errorf("ListFolders() with no folders = %#v, %v, want empty slice", folders, err)
+	}
+
+	_ = svc.Add(ctx, userID, grouped.ID, "备课")
+	_ = svc.Add(ctx, userID, loose.ID, "")
+
+	tests := []struct {
+		folder     string
+		wantTitles []string
+	}{
+		{folder: "备课", wantTitles: []string{"已分组"}},
+		{folder: " 备课 ", wantTitles: []string{"已分组"}},
+		{folder: "不存在", wantTitles: nil},
+	}
+	for _, tt := range tests {
+		items, total, err := svc.List(ctx, userID, tt.folder, 1, 20)
+		if err != nil {
+			t.Fatalf("List(%q) error = %v", tt.folder, err)
+		}
+		if items == nil || int(total) != len(tt.wantTitles) || len(items) != len(tt.wantTitles) {
+			t.Errorf("List(%q) = %d items (total %d), want %d", tt.folder, len(items), total, len(tt.wantTitles))
+			continue
+		}
+		for i, item := range items {
+			if item.Title != tt.wantTitles[i] || !item.IsFavorited {
+				t.Errorf("List(%q)[%d] = (%q, favorited %v), want %q favorited", tt.folder, i, item.Title, item.IsFavorited, tt.wantTitles[i])
+			}
+		}
+	}
+
+	if items, total, _ := svc.List(ctx, userID, "", 1, 20); total != 2 || len(items) != 2 {
+		t.Errorf("List() without folder = %d items (total %d), want all 2", len(items), total)
+	}
+
+	folders, _ = svc.ListFolders(ctx, userID)
+	if len(folders) != 1 || folders[0].Name != "备课" || folders[0].FavoriteCount != 1 {
+		t.Errorf("ListFolders() = %+v, want 备课 with 1 favorite", folders)
+	}
+}
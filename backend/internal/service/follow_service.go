@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// ErrCannotFollowSelf 不能关注自己
+var ErrCannotFollowSelf = errors.New("不能关注自己")
+
+// FollowStatus 关注状态
+type FollowStatus struct {
+	UserID         uuid.UUID `json:"user_id"`
+	Following      bool      `json:"following"`
+	FollowerCount  int64     `json:"follower_count"`
+	FollowingCount int64     `json:"following_count"`
+}
+
+// FollowService 关注服务接口
+type FollowService interface {
+	Follow(ctx context.Context, followerID, followingID uuid.UUID) (*FollowStatus, error)
+	Unfollow(ctx context.Context, followerID, followingID uuid.UUID) (*FollowStatus, error)
+	Feed(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error)
+}
+
+// followService 关注服务实现
+type followService struct {
+	followRepo repository.FollowRepository
+	userRepo   repository.UserRepository
+}
+
+// NewFollowService 创建关注服务
+func NewFollowService(followRepo repository.FollowRepository, userRepo repository.UserRepository) FollowService {
+	return &followService{
+		followRepo: followRepo,
+		userRepo:   userRepo,
+	}
+}
+
+func (s *followService) Follow(ctx context.Context, followerID, followingID uuid.UUID) (*FollowStatus, error) {
+	if followerID == followingID {
+		return nil, ErrCannotFollowSelf
+	}
+	if _, err := s.userRepo.GetByID(ctx, followingID); err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	exists, err := s.followRepo.Exists(ctx, followerID, followingID)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		follow := &model.UserFollow{
+			FollowerID:  followerID,
+			FollowingID: followingID,
+		}
+		if err := s.followRepo.Create(ctx, follow); err != nil {
+			return nil, err
+		}
+	}
+
+	return s.status(ctx, followingID, true)
+}
+
+// Unfollow 取消关注，未关注时同样返回成功
+func (s *followService) Unfollow(ctx context.Context, followerID, followingID uuid.UUID) (*FollowStatus, error) {
+	if err := s.followRepo.Delete(ctx, followerID, followingID); err != nil {
+		return nil, err
+	}
+	return s.status(ctx, followingID, false)
+}
+
+func (s *followService) Feed(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error) {
+	lessons, total, err := s.followRepo.ListFeed(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	items := make([]model.LessonListItem, len(lessons))
+	for i, l := range lessons {
+		items[i] = lessonListItem(l)
+	}
+
+	return items, total, nil
+}
+
+func (s *followService) status(ctx context.Context, userID uuid.UUID, following bool) (*FollowStatus, error) {
+	followers, err := s.followRepo.CountFollowers(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	followings, err := s.followRepo.CountFollowing(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &FollowStatus{
+		UserID:         userID,
+		Following:      following,
+		FollowerCount:  followers,
+		FollowingCount: followings,
+	}, nil
+}
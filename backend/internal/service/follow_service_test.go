@@ -0,0 +1,247 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeUserRepo 内存中的用户仓库
+type fakeUserRepo struct {
+	repository.UserRepository
+
+	users map[uuid.UUID]*model.User
+}
+
+func newFakeUserRepo(users ...*model.User) *fakeUserRepo {
+	r := &fakeUserRepo{users: make(map[uuid.UUID]*model.User)}
+	for _, u := range users {
+		if u.ID == uuid.Nil {
+			u.ID = uuid.New()
+		}
+		stored := *u
+		r.users[u.ID] = &stored
+	}
+	return r
+}
+
+func (r *fakeUserRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.User, error) {
+	user, ok := r.users[id]
+	if !ok {
+		return nil, errRecordNotFound
+	}
+	copied := *user
+	return &copied, nil
+}
+
+// fakeFollowRepo 内存中的关注关系仓库，ListFeed 与真实查询一致：关注对象已发布的教案，按发布时间倒序
+type fakeFollowRepo struct {
+	repository.FollowRepository
+
+	follows map[[2]uuid.UUID]bool
+	lessons []model.Lesson
+}
+
+func newFakeFollowRepo(lessons ...model.Lesson) *fakeFollowRepo {
+	return &fakeFollowRepo{follows: make(map[[2]uuid.UUID]bool), lessons: lessons}
+}
+
+func (r *fakeFollowRepo) Create(ctx context.Context, follow *model.UserFollow) error {
+	r.follows[[2]uuid.UUID{follow.FollowerID, follow.FollowingID}] = true
+	return nil
+}
+
+func (r *fakeFollowRepo) Delete(ctx context.Context, followerID, followingID uuid.UUID) error {
+	delete(r.follows, [2]uuid.UUID{followerID, followingID})
+	return nil
+}
+
+func (r *fakeFollowRepo) Exists(ctx context.Context, followerID, followingID uuid.UUID) (bool, error) {
+	return r.follows[[2]uuid.UUID{followerID, followingID}], nil
+}
+
+func (r *fakeFollowRepo) CountFollowers(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var n int64
+	for key := range r.follows {
+		if key[1] == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeFollowRepo) CountFollowing(ctx context.Context, userID uuid.UUID) (int64, error) {
+	var n int64
+	for key := range r.follows {
+		if key[0] == userID {
+			n++
+		}
+	}
+	return n, nil
+}
+
+func (r *fakeFollowRepo) ListFeed(ctx context.Context, followerID uuid.UUID, page, pageSize int) ([]model.Lesson, int64, error) {
+	var feed []model.Lesson
+	for _, l := range r.lessons {
+		if r.follows[[2]uuid.UUID{followerID, l.UserID}] && l.Status == model.LessonStatusPublished {
+			feed = append(feed, l)
+		}
+	}
+	for i := 1; i < len(feed); i++ {
+		for j := i; j > 0 && feed[j].PublishedAt.After(*feed[j-1].PublishedAt); j-- {
+			feed[j], feed[j-1] = feed[j-1], feed[j]
+		}
+	}
+	total := int64(len(feed))
+	start := (page - 1) * pageSize
+	if start > len(feed) {
+		start = len(feed)
+	}
+	end := start + pageSize
+	if end > len(feed) {
+		end = len(feed)
+	}
+	return feed[start:end], total, nil
+}
+
+func TestFollow(t *testing.T) {
+	ctx := context.Background()
+	me, teacher := &model.User{Username: "me"}, &model.User{Username: "teacher"}
+	userRepo := newFakeUserRepo(me, teacher)
+	me, teacher = userRepo.users[me.ID], userRepo.users[teacher.ID]
+
+	tests := []struct {
+		name          string
+		action        func(svc FollowService) (*FollowStatus, error)
+		wantErr       error
+		wantFollowing bool
+		wantFollowers int64
+	}{
+		{
+			name: "follow",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				return svc.Follow(ctx, me.ID, teacher.ID)
+			},
+			wantFollowing: true, wantFollowers: 1,
+		},
+		{
+			name: "follow twice is idempotent",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				_, _ = svc.Follow(ctx, me.ID, teacher.ID)
+				return svc.Follow(ctx, me.ID, teacher.ID)
+			},
+			wantFollowing: true, wantFollowers: 1,
+		},
+		{
+			name: "unfollow",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				_, _ = svc.Follow(ctx, me.ID, teacher.ID)
+				return svc.Unfollow(ctx, me.ID, teacher.ID)
+			},
+			wantFollowers: 0,
+		},
+		{
+			name: "unfollow without following succeeds",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				return svc.Unfollow(ctx, me.ID, teacher.ID)
+			},
+			wantFollowers: 0,
+		},
+		{
+			name: "cannot follow self",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				return svc.Follow(ctx, me.ID, me.ID)
+			},
+			wantErr: ErrCannotFollowSelf,
+		},
+		{
+			name: "cannot follow unknown user",
+			action: func(svc FollowService) (*FollowStatus, error) {
+				return svc.Follow(ctx, me.ID, uuid.New())
+			},
+			wantErr: ErrUserNotFound,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeFollowRepo()
+			svc := NewFollowService(repo, userRepo)
+
+			status, err := tt.action(svc)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(repo.follows) != 0 {
+					t.Errorf("follows = %d, want none", len(repo.follows))
+				}
+				return
+			}
+			if status.UserID != teacher.ID || status.Following != tt.wantFollowing || status.FollowerCount != tt.wantFollowers {
+				t.Errorf("status = %+v, want following=%v followers=%d", status, tt.wantFollowing, tt.wantFollowers)
+			}
+		})
+	}
+}
+
+func TestFollowFeed(t *testing.T) {
+	ctx := context.Background()
+	me, followed, stranger := uuid.New(), uuid.New(), uuid.New()
+	userRepo := newFakeUserRepo(&model.User{ID: me}, &model.User{ID: followed}, &model.User{ID: stranger})
+	base := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+	lesson := func(title string, author uuid.UUID, status string, day int) model.Lesson {
+		published := base.AddDate(0, 0, day)
+		return model.Lesson{ID: uuid.New(), UserID: author, Title: title, Status: status, PublishedAt: &published}
+	}
+	repo := newFakeFollowRepo(
+		lesson("旧教案", followed, model.LessonStatusPublished, 1),
+		lesson("新教案", followed, model.LessonStatusPublished, 3),
+		lesson("草稿", followed, model.LessonStatusDraft, 4),
+		lesson("陌生人的教案", stranger, model.LessonStatusPublished, 5),
+	)
+	svc := NewFollowService(repo, userRepo)
+
+	if items, total, _ := svc.Feed(ctx, me, 1, 10); total != 0 || len(items) != 0 {
+		t.Fatalf("feed before following = %d items, want empty", total)
+	}
+	if _, err := svc.Follow(ctx, me, followed); err != nil {
+		t.Fatalf("Follow() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		page       int
+		pageSize   int
+		wantTitles []string
+	}{
+		{name: "newest published first", page: 1, pageSize: 10, wantTitles: []string{"新教案", "旧教案"}},
+		{name: "paged", page: 2, pageSize: 1, wantTitles: []string{"旧教案"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			items, total, err := svc.Feed(ctx, me, tt.page, tt.pageSize)
+			if err != nil {
+				t.Fatalf("Feed() error = %v", err)
+			}
+			if total != 2 {
+				t.Errorf("total = %d, want 2", total)
+			}
+			if len(items) != len(tt.wantTitles) {
+				t.Fatalf("items = %d, want %d", len(items), len(tt.wantTitles))
+			}
+			for i, title := range tt.wantTitles {
+				if items[i].Title != title {
+					t.Errorf("items[%d] = %q, want %q", i, items[i].Title, title)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,45 @@
+package service
+
+import (
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// detailLevelPreset 教案详略级别说明
+type detailLevelPreset struct {
+	Name string
+	// Requirement 写入 prompt 的篇幅要求
+	Requirement string
+}
+
+// detailLevelPresets 详略级别，Key 与 GenerationRequest.DetailLevel 取值一致
+var detailLevelPresets = map[string]detailLevelPreset{
+	model.GenerationDetailBrief: {
+		Name:        "简案",
+		Requirement: "3~4 个教学环节，每个环节只列教师活动与学生活动要点，全文约 1500 字",
+	},
+	model.GenerationDetailStandard: {
+		Name:        "标准",
+		Requirement: "5~7 个教学环节，写明教师活动、学生活动与设计意图，全文约 3000 字",
+	},
+	model.GenerationDetailDetailed: {
+		Name:        "详案",
+		Requirement: "6~8 个教学环节，写出关键提问与预设学生回答、过渡语和板书设计，全文约 5000 字",
+	},
+}
+
+// normalizeDetailLevel 未指定或无法识别时按标准详略处理
+func normalizeDetailLevel(level string) string {
+	level = strings.ToLower(strings.TrimSpace(level))
+	if _, ok := detailLevelPresets[level]; ok {
+		return level
+	}
+	return model.GenerationDetailStandard
+}
+
+// describeDetailLevel 详略级别的展示名与篇幅要求，用于 prompt
+func describeDetailLevel(level string) string {
+	preset := detailLevelPresets[normalizeDetailLevel(level)]
+	return preset.Name + "（" + preset.Requirement + "）"
+}
@@ -0,0 +1,56 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeDetailLevel(t *testing.T) {
+	tests := []struct {
+		level string
+		want  string
+	}{
+		{level: "brief", want: model.GenerationDetailBrief},
+		{level: " Detailed ", want: model.GenerationDetailDetailed},
+		{level: "standard", want: model.GenerationDetailStandard},
+		{level: "", want: model.GenerationDetailStandard},
+		{level: "verbose", want: model.GenerationDetailStandard},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeDetailLevel(tt.level); got != tt.want {
+			t.Errorf("normalizeDetailLevel(%q) = %q, want %q", tt.level, got, tt.want)
+		}
+	}
+}
+
+func TestDetailLevelInPromptAndAgentRequest(t *testing.T) {
+	svc := &generationService{}
+
+	tests := []struct {
+		level      string
+		wantPrompt string
+		wantAgent  string
+	}{
+		{level: "brief", wantPrompt: "详略程度：简案（3~4 个教学环节", wantAgent: model.GenerationDetailBrief},
+		{level: "detailed", wantPrompt: "详略程度：详案（6~8 个教学环节", wantAgent: model.GenerationDetailDetailed},
+		{level: "", wantPrompt: "详略程度：标准（5~7 个教学环节", wantAgent: model.GenerationDetailStandard},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantAgent, func(t *testing.T) {
+			req := &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数", DetailLevel: tt.level}
+
+			if prompt := svc.buildPrompt(req); !strings.Contains(prompt, tt.wantPrompt) {
+				t.Errorf("prompt missing %q:\n%s", tt.wantPrompt, prompt)
+			}
+			if got := newAgentRequest(uuid.New(), req).DetailLevel; got != tt.wantAgent {
+				t.Errorf("agent detail level = %q, want %q", got, tt.wantAgent)
+			}
+		})
+	}
+}
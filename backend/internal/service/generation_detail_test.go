@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestGetByIDStructuredResult(t *testing.T) {
+	ctx := context.Background()
+	lessonID := uuid.New()
+	data, _ := json.Marshal(GeneratedLessonData{
+		Title:           "有理数的加法",
+		KeyPoints:       []string{"同号相加"},
+		TeachingMethods: []string{"讲授法"},
+	})
+	params, _ := json.Marshal(model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数的加法"})
+
+	tests := []struct {
+		name       string
+		generation *model.Generation
+		check      func(t *testing.T, result *model.GenerationResponse)
+	}{
+		{
+			name: "completed",
+			generation: &model.Generation{
+				Status: model.GenerationStatusCompleted, Result: string(data), Parameters: string(params),
+				TokenCount: 120, DurationMs: 900, LessonID: &lessonID,
+			},
+			check: func(t *testing.T, result *model.GenerationResponse) {
+				if result.Title != "有理数的加法" || result.KeyPoints == "" || result.TokenCount != 120 ||
+					result.DurationMs != 900 || result.LessonID == nil || *result.LessonID != lessonID {
+					t.Errorf("result = %+v, want parsed lesson with stats and lesson id", result)
+				}
+				if tags := strings.Join(result.Tags, ","); !strings.Contains(tags, "数学") || !strings.Contains(tags, "讲授法") {
+					t.Errorf("tags = %v, want tags from params and result", result.Tags)
+				}
+			},
+		},
+		{
+			// 无法解析的结果仅返回 ID 与状态
+			name:       "corrupt result",
+			generation: &model.Generation{Status: model.GenerationStatusCompleted, Result: "{not json"},
+			check: func(t *testing.T, result *model.GenerationResponse) {
+				if result.Status != model.GenerationStatusCompleted || result.Title != "" || result.Content != "" {
+					t.Errorf("result = %+v, want only id and status", result)
+				}
+			},
+		},
+		{
+			name:       "failed",
+			generation: &model.Generation{Status: model.GenerationStatusFailed, ErrorMsg: "Agent 超时"},
+			check: func(t *testing.T, result *model.GenerationResponse) {
+				if result.Status != model.GenerationStatusFailed || result.ErrorMessage != "Agent 超时" {
+					t.Errorf("result = %+v, want failed with error message", result)
+				}
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, "http://127.0.0.1:0", repo, nil)
+			userID := uuid.New()
+			tt.generation.UserID = userID
+			_ = repo.Create(ctx, tt.generation)
+
+			detail, err := svc.GetByID(ctx, tt.generation.ID, userID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if detail.Result == nil || detail.Result.ID != tt.generation.ID {
+				t.Fatalf("detail.Result = %+v, want result for %s", detail.Result, tt.generation.ID)
+			}
+			tt.check(t, detail.Result)
+		})
+	}
+}
+
+func TestGetByIDOwnership(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeGenerationRepo()
+	svc := newTestGenerationService(t, "http://127.0.0.1:0", repo, nil)
+	ownerID := uuid.New()
+	generation := &model.Generation{UserID: ownerID, Status: model.GenerationStatusCompleted, Result: `{"title":"有理数的加法"}`}
+	_ = repo.Create(ctx, generation)
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "owner", id: generation.ID, userID: ownerID},
+		{name: "other user", id: generation.ID, userID: uuid.New(), wantErr: ErrGenerationForbidden},
+		{name: "missing", id: uuid.New(), userID: ownerID, wantErr: ErrGenerationNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			detail, err := svc.GetByID(ctx, tt.id, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetByID() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil && detail != nil {
+				t.Errorf("GetByID() = %+v, want no record for %s", detail, tt.name)
+			}
+		})
+	}
+}
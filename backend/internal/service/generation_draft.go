@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strconv"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// saveDraftLesson 将生成结果保存为草稿教案并关联生成记录，成功时写入 resp.LessonID。
+// 保存失败不影响生成结果，仅在响应中提示用户手动保存
+func (s *generationService) saveDraftLesson(ctx context.Context, generationID, userID uuid.UUID, req *model.GenerationRequest, resp *model.GenerationResponse) {
+	lesson := draftLessonFromGeneration(userID, req, resp)
+	if err := s.lessonRepo.Create(ctx, lesson); err != nil {
+		logger.Warn("Failed to save generated lesson as draft",
+			logger.String("generation_id", generationID.String()),
+			logger.Err(err),
+		)
+		resp.Notice = "生成成功，但自动保存草稿失败，请手动保存"
+		return
+	}
+
+	_ = s.generationRepo.LinkLesson(ctx, generationID, lesson.ID, lesson.Version)
+	resp.LessonID = &lesson.ID
+}
+
+// draftLessonFromGeneration 由生成响应构造草稿教案，字段映射与手动保存生成结果一致
+func draftLessonFromGeneration(userID uuid.UUID, req *model.GenerationRequest, resp *model.GenerationResponse) *model.Lesson {
+	title := resp.Title
+	if title == "" {
+		title = req.Topic
+	}
+	if len([]rune(title)) > 200 {
+		title = string([]rune(title)[:200])
+	}
+
+	duration := req.Duration
+	if duration <= 0 {
+		duration = defaultLessonDuration
+	}
+
+	tags := resp.Tags
+	if tags == nil {
+		tags = []string{}
+	}
+	tagsJSON, _ := json.Marshal(tags)
+
+	return &model.Lesson{
+		UserID:      userID,
+		Title:       title,
+		Subject:     primarySubject(req),
+		Grade:       req.Grade,
+		Duration:    duration,
+		Objectives:  fmt.Sprintf(`{"text": %s}`, strconv.Quote(resp.Objectives)),
+		Content:     fmt.Sprintf(`{"text": %s}`, strconv.Quote(resp.Content)),
+		Activities:  resp.Activities,
+		Assessment:  resp.Assessment,
+		Resources:   resp.Resources,
+		Tags:        string(tagsJSON),
+		Status:      model.LessonStatusDraft,
+		ContentType: model.LessonContentTypeMarkdown,
+	}
+}
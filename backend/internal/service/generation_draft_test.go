@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// failingCreateLessonRepo 保存教案总是失败
+type failingCreateLessonRepo struct {
+	*fakeLessonRepo
+}
+
+func (r *failingCreateLessonRepo) Create(ctx context.Context, lesson *model.Lesson) error {
+	return errors.New("db down")
+}
+
+func TestGenerateSaveAsDraft(t *testing.T) {
+	agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+		return http.StatusOK, agentLesson("有理数的加法", 100)
+	})
+	req := func(save bool) *model.GenerationRequest {
+		return &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数", Duration: 40, SaveAsDraft: save}
+	}
+
+	t.Run("saved and linked", func(t *testing.T) {
+		ctx := context.Background()
+		userID := uuid.New()
+		genRepo := newFakeGenerationRepo()
+		lessons := newFakeLessonRepo()
+		svc := newTestGenerationService(t, agent.URL, genRepo, lessons)
+
+		resp, err := svc.Generate(ctx, userID, req(true), APIKeyOverride{})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if resp.LessonID == nil {
+			t.Fatalf("response lesson_id = nil, want the saved draft")
+		}
+		lesson, ok := lessons.lessons[*resp.LessonID]
+		if !ok {
+			t.Fatalf("lesson %s not saved", *resp.LessonID)
+		}
+		if lesson.UserID != userID || lesson.Status != model.LessonStatusDraft || lesson.Title != "有理数的加法" ||
+			lesson.Subject != "数学" || lesson.Duration != 40 {
+			t.Errorf("lesson = %+v, want caller's draft built from the result", lesson)
+		}
+
+		// 生成记录关联到草稿及其版本，查看详情时也能拿到 lesson_id
+		stored, _ := genRepo.GetByID(ctx, resp.ID)
+		if stored.LessonID == nil || *stored.LessonID != lesson.ID || stored.LessonVersion == nil || *stored.LessonVersion != lesson.Version {
+			t.Errorf("generation link = (%v, %v), want lesson %s v%d", stored.LessonID, stored.LessonVersion, lesson.ID, lesson.Version)
+		}
+		if detail, _ := svc.GetByID(ctx, resp.ID, userID); detail.Result == nil || detail.Result.LessonID == nil || *detail.Result.LessonID != lesson.ID {
+			t.Errorf("detail result = %+v, want lesson_id %s", detail.Result, lesson.ID)
+		}
+	})
+
+	t.Run("not requested", func(t *testing.T) {
+		lessons := newFakeLessonRepo()
+		svc := newTestGenerationService(t, agent.URL, newFakeGenerationRepo(), lessons)
+
+		resp, err := svc.Generate(context.Background(), uuid.New(), req(false), APIKeyOverride{})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if resp.LessonID != nil || len(lessons.lessons) != 0 {
+			t.Errorf("lesson_id = %v with %d lessons, want nothing saved", resp.LessonID, len(lessons.lessons))
+		}
+	})
+
+	t.Run("save failure keeps result", func(t *testing.T) {
+		genRepo := newFakeGenerationRepo()
+		svc := newTestGenerationService(t, agent.URL, genRepo, &failingCreateLessonRepo{fakeLessonRepo: newFakeLessonRepo()})
+
+		resp, err := svc.Generate(context.Background(), uuid.New(), req(true), APIKeyOverride{})
+		if err != nil {
+			t.Fatalf("Generate() error = %v", err)
+		}
+		if resp.Status != model.GenerationStatusCompleted || resp.Title == "" || resp.LessonID != nil || resp.Notice == "" {
+			t.Errorf("response = %+v, want completed result with a manual-save notice", resp)
+		}
+		if stored, _ := genRepo.GetByID(context.Background(), resp.ID); stored.LessonID != nil {
+			t.Errorf("generation linked to %v, want no link", stored.LessonID)
+		}
+	})
+}
+
+func TestDraftLessonFromGeneration(t *testing.T) {
+	userID := uuid.New()
+	lesson := draftLessonFromGeneration(userID, &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数"},
+		&model.GenerationResponse{Content: "正文"})
+
+	if lesson.Title != "有理数" || lesson.Duration != defaultLessonDuration || lesson.Tags != "[]" {
+		t.Errorf("lesson = (%q, %d min, tags %s), want topic title, default duration and empty tags", lesson.Title, lesson.Duration, lesson.Tags)
+	}
+	if lesson.Content != `{"text": "正文"}` || lesson.Status != model.LessonStatusDraft || lesson.UserID != userID {
+		t.Errorf("lesson = (%s, %s), want wrapped content as a draft", lesson.Content, lesson.Status)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"encoding/json"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// maxGenerationEventDetail 事件附带信息的最大长度（字符），错误信息过长时截断
+const maxGenerationEventDetail = 500
+
+// generationTimeline 记录单次生成各阶段的时间点，生成结束后随记录一并落库
+type generationTimeline struct {
+	start  time.Time
+	events []model.GenerationEvent
+}
+
+// newGenerationTimeline 创建阶段记录并写入 started 事件
+func newGenerationTimeline() *generationTimeline {
+	t := &generationTimeline{start: time.Now()}
+	t.events = append(t.events, model.GenerationEvent{
+		Stage: model.GenerationStageStarted,
+		At:    t.start,
+	})
+	return t
+}
+
+// Record 记录阶段事件，耗时由进程内单调时钟计算
+func (t *generationTimeline) Record(stage, detail string) {
+	now := time.Now()
+	if runes := []rune(detail); len(runes) > maxGenerationEventDetail {
+		detail = string(runes[:maxGenerationEventDetail])
+	}
+	t.events = append(t.events, model.GenerationEvent{
+		Stage:     stage,
+		At:        now,
+		ElapsedMs: now.Sub(t.start).Milliseconds(),
+		Detail:    detail,
+	})
+}
+
+// JSON 返回事件列表的 JSON 数组
+func (t *generationTimeline) JSON() string {
+	data, err := json.Marshal(t.events)
+	if err != nil {
+		return "[]"
+	}
+	return string(data)
+}
@@ -0,0 +1,104 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// decodeEvents 解析落库的阶段事件
+func decodeEvents(t *testing.T, raw string) []model.GenerationEvent {
+	t.Helper()
+	var events []model.GenerationEvent
+	if err := json.Unmarshal([]byte(raw), &events); err != nil {
+		t.Fatalf("decode events %q: %v", raw, err)
+	}
+	return events
+}
+
+func TestGenerationTimelineRecord(t *testing.T) {
+	timeline := newGenerationTimeline()
+	time.Sleep(2 * time.Millisecond)
+	timeline.Record(model.GenerationStageAgentCall, "")
+	timeline.Record(model.GenerationStageFailed, strings.Repeat("错", maxGenerationEventDetail+10))
+
+	events := decodeEvents(t, timeline.JSON())
+	if len(events) != 3 || events[0].Stage != model.GenerationStageStarted || events[0].ElapsedMs != 0 {
+		t.Fatalf("events = %+v, want started first with zero elapsed", events)
+	}
+	if events[1].ElapsedMs < 2 || events[2].ElapsedMs < events[1].ElapsedMs {
+		t.Errorf("elapsed = %d, %d, want monotonic from start", events[1].ElapsedMs, events[2].ElapsedMs)
+	}
+	if got := len([]rune(events[2].Detail)); got != maxGenerationEventDetail {
+		t.Errorf("detail length = %d, want truncated to %d", got, maxGenerationEventDetail)
+	}
+	if events[1].At.Before(events[0].At) {
+		t.Errorf("event times out of order: %v before %v", events[1].At, events[0].At)
+	}
+}
+
+func TestGenerateRecordsStageEvents(t *testing.T) {
+	tests := []struct {
+		name       string
+		respond    func(req *AgentRequest) (int, *AgentResponse)
+		wantStages []string
+		wantDetail string
+	}{
+		{
+			name: "success",
+			respond: func(req *AgentRequest) (int, *AgentResponse) {
+				return http.StatusOK, agentLesson("有理数的加法", 100)
+			},
+			wantStages: []string{
+				model.GenerationStageStarted, model.GenerationStageAgentCall, model.GenerationStageAgentDone,
+				model.GenerationStageParsed, model.GenerationStageSaved,
+			},
+		},
+		{
+			name: "agent error",
+			respond: func(req *AgentRequest) (int, *AgentResponse) {
+				return http.StatusBadRequest, &AgentResponse{Error: "topic too vague"}
+			},
+			wantStages: []string{model.GenerationStageStarted, model.GenerationStageAgentCall, model.GenerationStageFailed},
+			wantDetail: "topic too vague",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, newFakeAgent(t, tt.respond).URL, repo, nil)
+
+			resp, err := svc.Generate(context.Background(), uuid.New(), &model.GenerationRequest{
+				Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Duration: 45,
+			}, APIKeyOverride{})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+
+			stored, _ := repo.GetByID(context.Background(), resp.ID)
+			events := decodeEvents(t, stored.Events)
+			var stages []string
+			for i, event := range events {
+				stages = append(stages, event.Stage)
+				if i > 0 && event.ElapsedMs < events[i-1].ElapsedMs {
+					t.Errorf("stage %s elapsed %d before previous %d", event.Stage, event.ElapsedMs, events[i-1].ElapsedMs)
+				}
+			}
+			if !reflect.DeepEqual(stages, tt.wantStages) {
+				t.Fatalf("stages = %v, want %v", stages, tt.wantStages)
+			}
+			if last := events[len(events)-1]; !strings.Contains(last.Detail, tt.wantDetail) {
+				t.Errorf("last event detail = %q, want it to contain %q", last.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrAgentUnavailable Agent 无法连接或持续返回 5xx/429，区别于参数错误等业务失败
+var ErrAgentUnavailable = errors.New("AI 服务暂不可用")
+
+// GenerationFallbackNotice 降级模板的提示语
+const GenerationFallbackNotice = "AI 不可用，已生成模板，请手动填写各环节内容"
+
+const (
+	// fallbackPlaceholder 骨架中待教师填写的占位文字
+	fallbackPlaceholder = "（待填写）"
+	// fallbackDefaultDuration 请求未指定课时长度时的默认分钟数
+	fallbackDefaultDuration = 45
+)
+
+// fallbackSections 骨架教学环节及其课时占比（百分比），最后一个环节吸收取整误差
+var fallbackSections = []struct {
+	title   string
+	percent int
+}{
+	{"导入新课", 10},
+	{"新课讲授", 45},
+	{"练习巩固", 30},
+	{"课堂小结", 15},
+}
+
+// shouldFallback 仅在 Agent 不可用且调用方仍在等待结果时降级；客户端已断开时无需再生成模板
+func shouldFallback(ctx context.Context, err error) bool {
+	return ctx.Err() == nil && errors.Is(err, ErrAgentUnavailable)
+}
+
+// fallbackGeneration 基于请求参数生成空白教案骨架，结构与 Agent 返回一致，便于前端直接填写后保存
+func fallbackGeneration(id uuid.UUID, req *model.GenerationRequest, durationMs int64) *model.GenerationResponse {
+	data := buildFallbackLesson(req)
+	resp := generationResponseFromData(id, data)
+	resp.Tags = generateLessonTags(req, data)
+	resp.Status = model.GenerationStatusFallback
+	resp.Style = req.Style
+	resp.DurationMs = durationMs
+	resp.Notice = GenerationFallbackNotice
+	return resp
+}
+
+func buildFallbackLesson(req *model.GenerationRequest) *GeneratedLessonData {
+	topic := strings.TrimSpace(req.Topic)
+	duration := req.Duration
+	if duration <= 0 {
+		duration = fallbackDefaultDuration
+	}
+
+	knowledge := fallbackPlaceholder
+	if goals := nonEmptyStrings(req.Objectives); len(goals) > 0 {
+		knowledge = strings.Join(goals, "\n")
+	}
+
+	keyPoints := []string{fallbackPlaceholder}
+	if keywords := nonEmptyStrings(req.Keywords); len(keywords) > 0 {
+		keyPoints = keywords
+	}
+
+	sections := make([]LessonSection, 0, len(fallbackSections))
+	remaining := duration
+	for i, item := range fallbackSections {
+		minutes := duration * item.percent / 100
+		if i == len(fallbackSections)-1 {
+			minutes = remaining
+		}
+		remaining -= minutes
+		sections = append(sections, LessonSection{
+			Title:           item.title,
+			Duration:        minutes,
+			Content:         fallbackPlaceholder,
+			TeacherActivity: fallbackPlaceholder,
+			StudentActivity: fallbackPlaceholder,
+		})
+	}
+
+	return &GeneratedLessonData{
+		Title: fmt.Sprintf("%s（%s%s）", topic, strings.TrimSpace(req.Grade), describeSubjects(req)),
+		Objectives: LessonObjectives{
+			Knowledge: knowledge,
+			Process:   fallbackPlaceholder,
+			Emotion:   fallbackPlaceholder,
+		},
+		KeyPoints:       keyPoints,
+		DifficultPoints: []string{fallbackPlaceholder},
+		TeachingMethods: []string{fallbackPlaceholder},
+		Content: LessonContent{
+			Sections:  sections,
+			Materials: []string{fallbackPlaceholder},
+			Homework:  fallbackPlaceholder,
+		},
+		Evaluation: fallbackPlaceholder,
+	}
+}
+
+func nonEmptyStrings(items []string) []string {
+	result := make([]string, 0, len(items))
+	for _, item := range items {
+		if item = strings.TrimSpace(item); item != "" {
+			result = append(result, item)
+		}
+	}
+	return result
+}
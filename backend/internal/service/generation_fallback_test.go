@@ -0,0 +1,163 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateFallsBackToSkeletonWhenAgentUnavailable(t *testing.T) {
+	tests := []struct {
+		name       string
+		agentURL   func(t *testing.T) string
+		variants   int
+		wantStatus string
+	}{
+		{
+			name: "agent keeps returning 503",
+			agentURL: func(t *testing.T) string {
+				return newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+					return http.StatusServiceUnavailable, &AgentResponse{Error: "overloaded"}
+				}).URL
+			},
+			wantStatus: model.GenerationStatusFallback,
+		},
+		{
+			name: "agent unreachable",
+			agentURL: func(t *testing.T) string {
+				agent := newFakeAgent(t, nil)
+				agent.Close()
+				return agent.URL
+			},
+			wantStatus: model.GenerationStatusFallback,
+		},
+		{
+			name: "all variants unavailable",
+			agentURL: func(t *testing.T) string {
+				return newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+					return http.StatusBadGateway, &AgentResponse{Error: "bad gateway"}
+				}).URL
+			},
+			variants:   2,
+			wantStatus: model.GenerationStatusFallback,
+		},
+		{
+			name: "business error is not degraded",
+			agentURL: func(t *testing.T) string {
+				return newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+					return http.StatusBadRequest, &AgentResponse{Error: "topic too vague"}
+				}).URL
+			},
+			wantStatus: model.GenerationStatusFailed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, tt.agentURL(t), repo, nil)
+
+			resp, err := svc.Generate(context.Background(), uuid.New(), &model.GenerationRequest{
+				Subject:    "数学",
+				Grade:      "七年级",
+				Topic:      "有理数的加法",
+				Duration:   40,
+				Objectives: []string{"掌握有理数加法法则"},
+				Variants:   tt.variants,
+			}, APIKeyOverride{})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Fatalf("Status = %q, want %q (error %q)", resp.Status, tt.wantStatus, resp.ErrorMessage)
+			}
+			if tt.wantStatus != model.GenerationStatusFallback {
+				if resp.Notice != "" {
+					t.Errorf("Notice = %q, want none for non-degraded failure", resp.Notice)
+				}
+				return
+			}
+
+			if resp.Notice != GenerationFallbackNotice {
+				t.Errorf("Notice = %q, want %q", resp.Notice, GenerationFallbackNotice)
+			}
+			if resp.Title != "有理数的加法（七年级数学）" {
+				t.Errorf("Title = %q", resp.Title)
+			}
+			if !strings.Contains(resp.Objectives, "掌握有理数加法法则") {
+				t.Errorf("Objectives = %q, want request objectives kept", resp.Objectives)
+			}
+			for _, section := range fallbackSections {
+				if !strings.Contains(resp.Content, section.title) {
+					t.Errorf("Content missing section %q:\n%s", section.title, resp.Content)
+				}
+			}
+			// 骨架不消耗 token，生成记录仍记为失败
+			if resp.TokenCount != 0 {
+				t.Errorf("TokenCount = %d, want 0", resp.TokenCount)
+			}
+			stored, _ := repo.GetByID(context.Background(), resp.ID)
+			if stored == nil || stored.Status != model.GenerationStatusFailed {
+				t.Errorf("stored generation = %+v, want failed record", stored)
+			}
+		})
+	}
+}
+
+func TestBuildFallbackLessonSplitsDuration(t *testing.T) {
+	tests := []struct {
+		duration int
+		want     []int
+	}{
+		{duration: 45, want: []int{4, 20, 13, 8}},
+		{duration: 40, want: []int{4, 18, 12, 6}},
+		{duration: 0, want: []int{4, 20, 13, 8}},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.duration), func(t *testing.T) {
+			data := buildFallbackLesson(&model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数", Duration: tt.duration})
+			total := 0
+			for i, section := range data.Content.Sections {
+				if section.Duration != tt.want[i] {
+					t.Errorf("section %d duration = %d, want %d", i, section.Duration, tt.want[i])
+				}
+				total += section.Duration
+			}
+			// 最后一个环节吸收取整误差，总时长与课时一致
+			wantTotal := tt.duration
+			if wantTotal <= 0 {
+				wantTotal = fallbackDefaultDuration
+			}
+			if total != wantTotal {
+				t.Errorf("total duration = %d, want %d", total, wantTotal)
+			}
+			if data.KeyPoints[0] != fallbackPlaceholder {
+				t.Errorf("KeyPoints = %v, want placeholder", data.KeyPoints)
+			}
+		})
+	}
+}
+
+func TestShouldFallback(t *testing.T) {
+	cancelled, cancel := context.WithCancel(context.Background())
+	cancel()
+	unavailable := fmt.Errorf("%w: agent returned error: 503", ErrAgentUnavailable)
+
+	if !shouldFallback(context.Background(), unavailable) {
+		t.Error("shouldFallback(unavailable) = false, want true")
+	}
+	if shouldFallback(cancelled, unavailable) {
+		t.Error("shouldFallback() after client disconnect = true, want false")
+	}
+	if shouldFallback(context.Background(), errors.New("agent returned error: 400")) {
+		t.Error("shouldFallback(business error) = true, want false")
+	}
+}
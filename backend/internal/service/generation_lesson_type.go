@@ -0,0 +1,51 @@
+package service
+
+import (
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// lessonTypePreset 课型说明
+type lessonTypePreset struct {
+	Name string
+	// Structure 写入 prompt 的环节结构要求
+	Structure []string
+}
+
+// lessonTypePresets 课型，Key 与 GenerationRequest.LessonType 取值一致
+var lessonTypePresets = map[string]lessonTypePreset{
+	model.GenerationLessonTypeNew: {
+		Name: "新授课",
+		Structure: []string{
+			"按“情境导入—新知探究—例题示范—巩固练习—课堂小结—布置作业”组织教学环节",
+			"新知探究环节占课时的主要部分，写清概念形成或规律发现的过程",
+		},
+	},
+	model.GenerationLessonTypeReview: {
+		Name: "复习课",
+		Structure: []string{
+			"按“知识梳理—典型例题—易错辨析—分层训练—归纳总结”组织教学环节，不再重复讲授新知",
+			"知识梳理环节以思维导图或知识网络呈现已学内容之间的联系",
+			"分层训练设置基础、提高两个层次的题目，并写明常见错误及纠正方法",
+		},
+	},
+	model.GenerationLessonTypeExperiment: {
+		Name: "实验课",
+		Structure: []string{
+			"按“实验导入—原理与器材—安全须知—分步操作—数据记录与分析—结论与反思”组织教学环节",
+			"单独设置安全须知环节，列出器材、药品与操作中的安全风险及防护措施",
+			"操作步骤逐条编号，写明每一步的操作要点、注意事项与预期现象",
+			"设计实验记录表，并说明异常现象或数据偏差的分析方法",
+		},
+	},
+}
+
+// normalizeLessonType 未指定或无法识别时按新授课处理
+func normalizeLessonType(lessonType string) string {
+	lessonType = strings.ToLower(strings.TrimSpace(lessonType))
+	if _, ok := lessonTypePresets[lessonType]; ok {
+		return lessonType
+	}
+	return model.GenerationLessonTypeNew
+}
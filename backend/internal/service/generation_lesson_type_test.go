@@ -0,0 +1,77 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestNormalizeLessonType(t *testing.T) {
+	tests := []struct {
+		lessonType string
+		want       string
+	}{
+		{lessonType: "review", want: model.GenerationLessonTypeReview},
+		{lessonType: " Experiment ", want: model.GenerationLessonTypeExperiment},
+		{lessonType: "new", want: model.GenerationLessonTypeNew},
+		{lessonType: "", want: model.GenerationLessonTypeNew},
+		{lessonType: "lab", want: model.GenerationLessonTypeNew},
+	}
+
+	for _, tt := range tests {
+		if got := normalizeLessonType(tt.lessonType); got != tt.want {
+			t.Errorf("normalizeLessonType(%q) = %q, want %q", tt.lessonType, got, tt.want)
+		}
+	}
+}
+
+func TestLessonTypeInPromptAndAgentRequest(t *testing.T) {
+	svc := &generationService{}
+
+	tests := []struct {
+		lessonType  string
+		wantPrompt  []string
+		wantMissing string
+		wantAgent   string
+	}{
+		{
+			lessonType:  "",
+			wantPrompt:  []string{"课型：新授课", "新授课环节结构：\n- 按“情境导入—新知探究"},
+			wantMissing: "安全须知",
+			wantAgent:   model.GenerationLessonTypeNew,
+		},
+		{
+			lessonType:  "review",
+			wantPrompt:  []string{"课型：复习课", "知识梳理—典型例题—易错辨析", "分层训练设置基础、提高两个层次"},
+			wantMissing: "新知探究",
+			wantAgent:   model.GenerationLessonTypeReview,
+		},
+		{
+			lessonType: "experiment",
+			wantPrompt: []string{"课型：实验课", "单独设置安全须知环节", "操作步骤逐条编号", "设计实验记录表"},
+			wantAgent:  model.GenerationLessonTypeExperiment,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wantAgent, func(t *testing.T) {
+			req := &model.GenerationRequest{Subject: "化学", Grade: "九年级", Topic: "氧气的制取", LessonType: tt.lessonType}
+
+			prompt := svc.buildPrompt(req)
+			for _, want := range tt.wantPrompt {
+				if !strings.Contains(prompt, want) {
+					t.Errorf("prompt missing %q:\n%s", want, prompt)
+				}
+			}
+			if tt.wantMissing != "" && strings.Contains(prompt, tt.wantMissing) {
+				t.Errorf("prompt contains %q from another lesson type:\n%s", tt.wantMissing, prompt)
+			}
+			if got := newAgentRequest(uuid.New(), req).LessonType; got != tt.wantAgent {
+				t.Errorf("agent lesson type = %q, want %q", got, tt.wantAgent)
+			}
+		})
+	}
+}
@@ -0,0 +1,116 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+)
+
+// knowledgeFlagTTL 用户有无个人知识点的缓存时长。新增知识点后最长这么久内仍按缓存结果判断能否跨用户合并，
+// 与文档解析、知识点入库本身的耗时相当
+const knowledgeFlagTTL = 30 * time.Second
+
+// knowledgeFlagCache 按用户短时缓存有无个人知识点，避免每次生成都查询一次 Neo4j；零值可直接使用
+type knowledgeFlagCache struct {
+	mu    sync.Mutex
+	flags map[uuid.UUID]knowledgeFlag
+}
+
+type knowledgeFlag struct {
+	has       bool
+	expiresAt time.Time
+}
+
+func (c *knowledgeFlagCache) get(userID uuid.UUID, now time.Time) (has, ok bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	flag, ok := c.flags[userID]
+	if !ok || !now.Before(flag.expiresAt) {
+		return false, false
+	}
+	return flag.has, true
+}
+
+func (c *knowledgeFlagCache) set(userID uuid.UUID, has bool, now time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.flags == nil {
+		c.flags = make(map[uuid.UUID]knowledgeFlag)
+	}
+	// 写入时顺带清理过期项，缓存大小不超过 TTL 内活跃的用户数
+	for id, flag := range c.flags {
+		if !now.Before(flag.expiresAt) {
+			delete(c.flags, id)
+		}
+	}
+	c.flags[userID] = knowledgeFlag{has: has, expiresAt: now.Add(knowledgeFlagTTL)}
+}
+
+// generationMergeKey 计算合并键：Agent 请求体、租户与自带 API Key 完全一致的请求才会合并。
+// 自带 Key 参与计算，保证每次调用都使用发起者自己的 Key 计费。
+func generationMergeKey(ctx context.Context, agentReq *AgentRequest, keyOverride APIKeyOverride) string {
+	body, _ := json.Marshal(agentReq)
+	h := sha256.New()
+	h.Write(body)
+	h.Write([]byte{0})
+	h.Write([]byte(tenant.FromContext(ctx)))
+	h.Write([]byte{0})
+	h.Write([]byte(keyOverride.GenerationAPIKey))
+	h.Write([]byte{0})
+	h.Write([]byte(keyOverride.EmbeddingAPIKey))
+	return hex.EncodeToString(h.Sum(nil))
+}
+
+// mergeAcrossUsers 用户的请求能否与其他用户合并。Agent 按 userId 只检索本人的个人知识库：
+// 没有个人知识点的用户检索结果都为空，相同参数生成的内容与用户无关，可以共享一次调用；
+// 有个人知识点时结果包含本人资料，只合并本人的重复提交（连点、多标签页），避免知识库内容串号。
+// 查询结果按用户缓存 knowledgeFlagTTL；查询失败时按有知识点处理且不缓存
+func (s *generationService) mergeAcrossUsers(ctx context.Context, userID uuid.UUID) bool {
+	if s.knowledgeRepo == nil {
+		return false
+	}
+	now := time.Now()
+	if has, ok := s.knowledgeFlags.get(userID, now); ok {
+		return !has
+	}
+	points, err := s.knowledgeRepo.ListKnowledgePoints(ctx, "", "", userID.String(), 1)
+	if err != nil {
+		return false
+	}
+	s.knowledgeFlags.set(userID, len(points) > 0, now)
+	return len(points) == 0
+}
+
+// callAgentShared 通过 singleflight 合并并发中的相同生成请求，只调用一次 Agent；
+// 生成记录与 token 仍由各请求在 generateOne 中分别落库。
+// 共享调用不随单个请求取消（由 Agent HTTP 超时兜底），各请求只在自己的 context 结束时放弃等待。
+func (s *generationService) callAgentShared(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*AgentResponse, error) {
+	keyReq := newAgentRequest(userID, req)
+	if s.mergeAcrossUsers(ctx, userID) {
+		keyReq.UserId = ""
+	}
+	key := generationMergeKey(ctx, keyReq, keyOverride)
+	sharedCtx := context.WithoutCancel(ctx)
+
+	ch := s.inflight.DoChan(key, func() (interface{}, error) {
+		return s.callAgent(sharedCtx, userID, req, keyOverride)
+	})
+
+	select {
+	case result := <-ch:
+		if result.Err != nil {
+			return nil, result.Err
+		}
+		return result.Val.(*AgentResponse), nil
+	case <-ctx.Done():
+		return nil, ctx.Err()
+	}
+}
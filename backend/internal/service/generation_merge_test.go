@@ -0,0 +1,187 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeKnowledgeOwners 按用户区分有无个人知识点的知识仓库，并记录查询次数
+type fakeKnowledgeOwners struct {
+	repository.KnowledgeRepository
+
+	owners  map[string]bool
+	mu      sync.Mutex
+	queries int
+}
+
+func (r *fakeKnowledgeOwners) ListKnowledgePoints(ctx context.Context, subject, grade, userId string, limit int) ([]model.Knowledge, error) {
+	r.mu.Lock()
+	r.queries++
+	r.mu.Unlock()
+	if r.owners[userId] {
+		return []model.Knowledge{{ID: "k-" + userId, Name: "个人知识点"}}, nil
+	}
+	return []model.Knowledge{}, nil
+}
+
+func TestConcurrentIdenticalGenerationsShareAgentCall(t *testing.T) {
+	const concurrency = 5
+	sameUser := uuid.New()
+
+	tests := []struct {
+		name string
+		// users 第 i 个请求的发起用户
+		users     func(i int) uuid.UUID
+		owners    func(users []uuid.UUID) map[string]bool
+		keys      func(i int) APIKeyOverride
+		wantCalls int
+	}{
+		{
+			name:      "same user",
+			users:     func(int) uuid.UUID { return sameUser },
+			wantCalls: 1,
+		},
+		{
+			name:      "users without personal knowledge",
+			users:     func(int) uuid.UUID { return uuid.New() },
+			wantCalls: 1,
+		},
+		{
+			name:  "users with personal knowledge are not merged",
+			users: func(int) uuid.UUID { return uuid.New() },
+			owners: func(users []uuid.UUID) map[string]bool {
+				owners := make(map[string]bool)
+				for _, u := range users {
+					owners[u.String()] = true
+				}
+				return owners
+			},
+			wantCalls: concurrency,
+		},
+		{
+			// 有个人知识点的用户单独调用 Agent，其余用户共享一次调用
+			name:  "user with personal knowledge gets own call",
+			users: func(int) uuid.UUID { return uuid.New() },
+			owners: func(users []uuid.UUID) map[string]bool {
+				return map[string]bool{users[len(users)-1].String(): true}
+			},
+			wantCalls: 2,
+		},
+		{
+			name:      "different own api keys are not merged",
+			users:     func(int) uuid.UUID { return sameUser },
+			keys:      func(i int) APIKeyOverride { return NewAPIKeyOverride("sk-user-"+string(rune('a'+i)), "") },
+			wantCalls: concurrency,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			release := make(chan struct{})
+			agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+				<-release
+				return http.StatusOK, agentLesson("有理数", 10)
+			})
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+			users := make([]uuid.UUID, concurrency)
+			for i := range users {
+				users[i] = tt.users(i)
+			}
+			owners := map[string]bool{}
+			if tt.owners != nil {
+				owners = tt.owners(users)
+			}
+			svc.knowledgeRepo = &fakeKnowledgeOwners{owners: owners}
+
+			var wg sync.WaitGroup
+			responses := make([]*model.GenerationResponse, concurrency)
+			for i := 0; i < concurrency; i++ {
+				wg.Add(1)
+				go func(i int) {
+					defer wg.Done()
+					var keys APIKeyOverride
+					if tt.keys != nil {
+						keys = tt.keys(i)
+					}
+					resp, err := svc.Generate(context.Background(), users[i], &model.GenerationRequest{
+						Subject: "数学", Grade: "七年级", Topic: "有理数",
+					}, keys)
+					if err != nil {
+						t.Errorf("Generate() error = %v", err)
+						return
+					}
+					responses[i] = resp
+				}(i)
+			}
+
+			// 等第一个请求到达 Agent 后留出时间让其余请求加入，再放行
+			deadline := time.Now().Add(2 * time.Second)
+			for agent.callCount() == 0 && time.Now().Before(deadline) {
+				time.Sleep(5 * time.Millisecond)
+			}
+			time.Sleep(100 * time.Millisecond)
+			close(release)
+			wg.Wait()
+
+			if got := agent.callCount(); got != tt.wantCalls {
+				t.Errorf("agent calls = %d, want %d", got, tt.wantCalls)
+			}
+			// 共享调用时每个请求仍各自落库记账
+			if got := repo.count(); got != concurrency {
+				t.Errorf("generation records = %d, want %d", got, concurrency)
+			}
+			for i, resp := range responses {
+				if resp == nil {
+					continue
+				}
+				if resp.Status != model.GenerationStatusCompleted || resp.TokenCount != 10 {
+					t.Errorf("response %d = status %q tokens %d, want completed with 10 tokens", i, resp.Status, resp.TokenCount)
+				}
+				stored, _ := repo.GetByID(context.Background(), resp.ID)
+				if stored == nil || stored.UserID != users[i] || stored.TokenCount != 10 {
+					t.Errorf("record %d not booked to its own user: %+v", i, stored)
+				}
+			}
+		})
+	}
+}
+
+func TestMergeAcrossUsersCachesKnowledgeFlag(t *testing.T) {
+	ctx := context.Background()
+	plain, owner := uuid.New(), uuid.New()
+	knowledge := &fakeKnowledgeOwners{owners: map[string]bool{owner.String(): true}}
+	svc := newTestGenerationService(t, "http://127.0.0.1:0", newFakeGenerationRepo(), nil)
+	svc.knowledgeRepo = knowledge
+
+	for i := 0; i < 3; i++ {
+		if !svc.mergeAcrossUsers(ctx, plain) {
+			t.Fatalf("mergeAcrossUsers(plain) = false, want true without personal knowledge")
+		}
+		if svc.mergeAcrossUsers(ctx, owner) {
+			t.Fatalf("mergeAcrossUsers(owner) = true, want false with personal knowledge")
+		}
+	}
+	if knowledge.queries != 2 {
+		t.Errorf("knowledge queries = %d, want one per user while cached", knowledge.queries)
+	}
+
+	// 缓存过期后重新查询，期间新增的知识点生效
+	svc.knowledgeFlags.set(plain, false, time.Now().Add(-knowledgeFlagTTL))
+	knowledge.owners[plain.String()] = true
+	if svc.mergeAcrossUsers(ctx, plain) {
+		t.Error("mergeAcrossUsers(plain) after expiry = true, want the new knowledge to be seen")
+	}
+	if knowledge.queries != 3 {
+		t.Errorf("knowledge queries = %d, want a new query after expiry", knowledge.queries)
+	}
+}
@@ -0,0 +1,203 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/observability"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxModerationResponseBytes 外部审核接口响应体上限
+	maxModerationResponseBytes = 1 << 20
+	// reviewRejectedMessage 人工复核驳回后写入生成记录的错误信息
+	reviewRejectedMessage = "内容未通过人工复核"
+)
+
+var ErrGenerationNotInReview = errors.New("生成记录不存在或不在待复核状态")
+
+// ModerationResult 内容审核结果
+type ModerationResult struct {
+	Passed bool   `json:"passed"`
+	Reason string `json:"reason,omitempty"`
+}
+
+// ContentModerator 生成内容审核器
+type ContentModerator interface {
+	Review(ctx context.Context, text string) (*ModerationResult, error)
+}
+
+// contentModerator 先按关键词规则审核，通过后再调用外部接口（如已配置）
+type contentModerator struct {
+	keywords   []string
+	apiURL     string
+	apiKey     string
+	httpClient *http.Client
+}
+
+// NewContentModerator 创建内容审核器，未启用时返回 nil
+func NewContentModerator(cfg *config.ModerationConfig) ContentModerator {
+	if cfg == nil || !cfg.Enabled {
+		return nil
+	}
+
+	keywords := make([]string, 0, len(cfg.BlockedKeywords))
+	for _, keyword := range cfg.BlockedKeywords {
+		if keyword = strings.TrimSpace(keyword); keyword != "" {
+			keywords = append(keywords, keyword)
+		}
+	}
+
+	timeout := cfg.TimeoutDuration()
+	if timeout <= 0 {
+		timeout = 10 * time.Second
+	}
+	return &contentModerator{
+		keywords:   keywords,
+		apiURL:     strings.TrimSpace(cfg.APIURL),
+		apiKey:     cfg.APIKey,
+		httpClient: &http.Client{Timeout: timeout},
+	}
+}
+
+func (m *contentModerator) Review(ctx context.Context, text string) (*ModerationResult, error) {
+	lower := strings.ToLower(text)
+	for _, keyword := range m.keywords {
+		if strings.Contains(lower, strings.ToLower(keyword)) {
+			return &ModerationResult{Passed: false, Reason: "命中敏感词：" + keyword}, nil
+		}
+	}
+
+	if m.apiURL == "" {
+		return &ModerationResult{Passed: true}, nil
+	}
+	return m.reviewRemote(ctx, text)
+}
+
+// reviewRemote 调用外部审核接口：POST {"text": ...}，返回 {"passed": bool, "reason": string}
+func (m *contentModerator) reviewRemote(ctx context.Context, text string) (*ModerationResult, error) {
+	body, _ := json.Marshal(map[string]string{"text": text})
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, m.apiURL, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", "application/json")
+	if m.apiKey != "" {
+		req.Header.Set("Authorization", "Bearer "+m.apiKey)
+	}
+	if traceID := middleware.TraceIDFromContext(ctx); traceID != "" {
+		req.Header.Set(middleware.TraceIDHeader, traceID)
+	}
+
+	start := time.Now()
+	resp, err := m.httpClient.Do(req)
+	if err != nil {
+		observability.RecordDownstream("moderation", "review", 0, time.Since(start))
+		return nil, err
+	}
+	defer resp.Body.Close()
+	observability.RecordDownstream("moderation", "review", resp.StatusCode, time.Since(start))
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("审核接口返回状态码 %d", resp.StatusCode)
+	}
+
+	var result ModerationResult
+	if err := json.NewDecoder(io.LimitReader(resp.Body, maxModerationResponseBytes)).Decode(&result); err != nil {
+		return nil, fmt.Errorf("解析审核结果失败: %w", err)
+	}
+	if !result.Passed && strings.TrimSpace(result.Reason) == "" {
+		result.Reason = "外部审核未通过"
+	}
+	return &result, nil
+}
+
+// moderate 审核生成结果，返回不通过的原因；审核服务不可用时同样转人工复核
+func (s *generationService) moderate(ctx context.Context, generationID uuid.UUID, resp *model.GenerationResponse) (string, bool) {
+	if s.moderator == nil {
+		return "", false
+	}
+
+	result, err := s.moderator.Review(ctx, moderationText(resp))
+	if err != nil {
+		logger.Warn("Content moderation unavailable, generation held for review",
+			logger.String("generation_id", generationID.String()),
+			logger.Err(err),
+		)
+		return "审核服务不可用，待人工复核", true
+	}
+	if result.Passed {
+		return "", false
+	}
+	return result.Reason, true
+}
+
+// moderationText 拼接生成结果中所有展示给用户的文本
+func moderationText(resp *model.GenerationResponse) string {
+	parts := []string{
+		resp.Title, resp.Objectives, resp.KeyPoints, resp.DifficultPoints, resp.TeachingMethods,
+		resp.Content, resp.Activities, resp.Assessment, resp.Resources,
+	}
+	parts = append(parts, resp.Tags...)
+	return strings.Join(parts, "\n")
+}
+
+// reviewGenerationResponse 待复核的生成响应，不含生成内容
+func reviewGenerationResponse(id uuid.UUID, tokenCount int, durationMs int64) *model.GenerationResponse {
+	return &model.GenerationResponse{
+		ID:         id,
+		Status:     model.GenerationStatusReview,
+		TokenCount: tokenCount,
+		DurationMs: durationMs,
+		Notice:     "生成内容需人工复核，复核通过后可在生成历史中查看",
+	}
+}
+
+// hideUnreviewedResult 待复核的生成记录不向用户展示结果，审核原因（如命中的敏感词）也只对管理员可见
+func hideUnreviewedResult(generation *model.Generation) {
+	if generation != nil && generation.Status == model.GenerationStatusReview {
+		generation.Result = ""
+		generation.ReviewReason = ""
+	}
+}
+
+func hideUnreviewedResults(generations []model.Generation) {
+	for i := range generations {
+		hideUnreviewedResult(&generations[i])
+	}
+}
+
+// ListPendingReviews 列出待人工复核的生成记录（管理员），含生成结果与审核原因
+func (s *generationService) ListPendingReviews(ctx context.Context, page, pageSize int) ([]model.Generation, int64, error) {
+	return s.generationRepo.ListByStatus(ctx, model.GenerationStatusReview, page, pageSize)
+}
+
+// ResolveReview 人工复核（管理员）：通过后生成记录转为已完成，用户可查看并据此创建教案；驳回则记为失败
+func (s *generationService) ResolveReview(ctx context.Context, id uuid.UUID, approved bool) error {
+	status, errorMsg := model.GenerationStatusCompleted, ""
+	if !approved {
+		status, errorMsg = model.GenerationStatusFailed, reviewRejectedMessage
+	}
+
+	updated, err := s.generationRepo.ResolveReview(ctx, id, status, errorMsg)
+	if err != nil {
+		return err
+	}
+	if !updated {
+		return ErrGenerationNotInReview
+	}
+	return nil
+}
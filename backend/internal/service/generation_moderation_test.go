@@ -0,0 +1,208 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (r *fakeGenerationRepo) ResolveReview(ctx context.Context, id uuid.UUID, status, errorMsg string) (bool, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	generation, ok := r.generations[id]
+	if !ok || generation.Status != model.GenerationStatusReview {
+		return false, nil
+	}
+	generation.Status = status
+	generation.ErrorMsg = errorMsg
+	return true, nil
+}
+
+// newModerationServer 模拟外部审核接口，respond 按待审文本决定返回
+func newModerationServer(t *testing.T, respond func(text string) (int, string)) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		if r.Header.Get("Authorization") != "Bearer moderation-key" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		status, body := respond(req.Text)
+		w.WriteHeader(status)
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestNewContentModeratorDisabled(t *testing.T) {
+	if m := NewContentModerator(nil); m != nil {
+		t.Errorf("NewContentModerator(nil) = %v, want nil", m)
+	}
+	if m := NewContentModerator(&config.ModerationConfig{BlockedKeywords: []string{"违禁"}}); m != nil {
+		t.Errorf("NewContentModerator(disabled) = %v, want nil", m)
+	}
+}
+
+func TestContentModeratorReview(t *testing.T) {
+	server := newModerationServer(t, func(text string) (int, string) {
+		switch {
+		case strings.Contains(text, "远程拦截"):
+			return http.StatusOK, `{"passed": false, "reason": "政治敏感"}`
+		case strings.Contains(text, "无原因"):
+			return http.StatusOK, `{"passed": false}`
+		case strings.Contains(text, "故障"):
+			return http.StatusInternalServerError, ""
+		default:
+			return http.StatusOK, `{"passed": true}`
+		}
+	})
+
+	tests := []struct {
+		name       string
+		apiURL     string
+		text       string
+		wantPassed bool
+		wantReason string
+		wantErr    bool
+	}{
+		{name: "clean text without api", text: "有理数的加法", wantPassed: true},
+		{name: "keyword ignores case", text: "含有 BadWord 的内容", wantReason: "命中敏感词：badword"},
+		{name: "keyword checked before api", apiURL: server.URL, text: "badword 远程拦截", wantReason: "命中敏感词：badword"},
+		{name: "api passes", apiURL: server.URL, text: "有理数的加法", wantPassed: true},
+		{name: "api rejects", apiURL: server.URL, text: "远程拦截", wantReason: "政治敏感"},
+		{name: "api rejects without reason", apiURL: server.URL, text: "无原因", wantReason: "外部审核未通过"},
+		{name: "api failure", apiURL: server.URL, text: "故障", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewContentModerator(&config.ModerationConfig{
+				Enabled:         true,
+				BlockedKeywords: []string{" badword ", ""},
+				APIURL:          tt.apiURL,
+				APIKey:          "moderation-key",
+			})
+
+			result, err := m.Review(context.Background(), tt.text)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Review() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if result.Passed != tt.wantPassed || result.Reason != tt.wantReason {
+				t.Errorf("Review() = %+v, want passed %v reason %q", result, tt.wantPassed, tt.wantReason)
+			}
+		})
+	}
+}
+
+func TestGenerateHeldForReview(t *testing.T) {
+	agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+		return http.StatusOK, agentLesson("含违禁词的教案", 100)
+	})
+
+	tests := []struct {
+		name       string
+		moderator  *config.ModerationConfig
+		wantReason string
+	}{
+		{
+			name:       "keyword hit",
+			moderator:  &config.ModerationConfig{Enabled: true, BlockedKeywords: []string{"违禁词"}},
+			wantReason: "命中敏感词：违禁词",
+		},
+		{
+			name: "moderation unavailable",
+			moderator: &config.ModerationConfig{
+				Enabled: true, APIURL: newModerationServer(t, func(string) (int, string) { return http.StatusBadGateway, "" }).URL,
+				APIKey: "moderation-key",
+			},
+			wantReason: "审核服务不可用，待人工复核",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			userID := uuid.New()
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, agent.URL, repo, nil)
+			svc.moderator = NewContentModerator(tt.moderator)
+
+			resp, err := svc.Generate(ctx, userID, &model.GenerationRequest{
+				Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Duration: 45,
+			}, APIKeyOverride{})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if resp.Status != model.GenerationStatusReview || resp.Title != "" || resp.Content != "" || resp.Notice == "" {
+				t.Errorf("response = %+v, want held for review without content", resp)
+			}
+
+			// 结果照常落库供管理员复核
+			stored, _ := repo.GetByID(ctx, resp.ID)
+			if stored.Status != model.GenerationStatusReview || stored.Result == "" || stored.ReviewReason != tt.wantReason {
+				t.Errorf("stored = (%s, reason %q, result %d bytes), want review with reason %q",
+					stored.Status, stored.ReviewReason, len(stored.Result), tt.wantReason)
+			}
+
+			// 用户查看时不返回内容和审核原因
+			visible, err := svc.GetByID(ctx, resp.ID, userID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if visible.Generation.Result != "" || visible.ReviewReason != "" || visible.Result.Title != "" {
+				t.Errorf("user view = (result %q, reason %q), want both hidden", visible.Generation.Result, visible.ReviewReason)
+			}
+		})
+	}
+}
+
+func TestResolveReview(t *testing.T) {
+	ctx := context.Background()
+	repo := newFakeGenerationRepo()
+	svc := newTestGenerationService(t, "http://127.0.0.1:0", repo, nil)
+	userID := uuid.New()
+	held := func() uuid.UUID {
+		generation := &model.Generation{UserID: userID, Status: model.GenerationStatusReview, Result: `{"title":"待复核"}`}
+		_ = repo.Create(ctx, generation)
+		return generation.ID
+	}
+
+	approved := held()
+	if err := svc.ResolveReview(ctx, approved, true); err != nil {
+		t.Fatalf("ResolveReview(approve) error = %v", err)
+	}
+	if visible, _ := svc.GetByID(ctx, approved, userID); visible.Status != model.GenerationStatusCompleted || visible.Result.Title != "待复核" {
+		t.Errorf("approved = (%s, %+v), want completed with result visible", visible.Status, visible.Result)
+	}
+
+	rejected := held()
+	if err := svc.ResolveReview(ctx, rejected, false); err != nil {
+		t.Fatalf("ResolveReview(reject) error = %v", err)
+	}
+	if stored, _ := repo.GetByID(ctx, rejected); stored.Status != model.GenerationStatusFailed || stored.ErrorMsg != reviewRejectedMessage {
+		t.Errorf("rejected = (%s, %q), want failed with rejection message", stored.Status, stored.ErrorMsg)
+	}
+
+	// 已处理或不存在的记录不能再次复核
+	for _, id := range []uuid.UUID{approved, uuid.New()} {
+		if err := svc.ResolveReview(ctx, id, false); !errors.Is(err, ErrGenerationNotInReview) {
+			t.Errorf("ResolveReview(%s) error = %v, want ErrGenerationNotInReview", id, err)
+		}
+	}
+}
@@ -0,0 +1,124 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// errNoUsableLessonData 响应中没有任何可用的教案字段，无法保留部分结果
+var errNoUsableLessonData = errors.New("agent response has no usable lesson fields")
+
+// generationFieldLabels 结构化教案字段对应的中文名称，用于提示缺失内容
+var generationFieldLabels = map[string]string{
+	"title":           "标题",
+	"objectives":      "教学目标",
+	"keyPoints":       "教学重点",
+	"difficultPoints": "教学难点",
+	"teachingMethods": "教学方法",
+	"sections":        "教学过程",
+	"materials":       "教学资源",
+	"homework":        "课后作业",
+	"evaluation":      "教学评价",
+	"reflection":      "教学反思",
+}
+
+// partialGenerationNotice 部分结果的提示语，列出解析失败的字段
+func partialGenerationNotice(missing []string) string {
+	labels := make([]string, 0, len(missing))
+	for _, field := range missing {
+		if label, ok := generationFieldLabels[field]; ok {
+			labels = append(labels, label)
+		} else {
+			labels = append(labels, field)
+		}
+	}
+	return fmt.Sprintf("部分内容解析失败，已保留其余内容，请补充：%s", strings.Join(labels, "、"))
+}
+
+// decodePartialAgentResponse 整体解析失败时逐字段解析 Agent 响应：
+// 类型不符的字段置空并记入 MissingFields，教学环节逐条解析、只丢弃出错的环节；
+// 外层不是合法 JSON 或没有任何可用字段时返回错误
+func decodePartialAgentResponse(body []byte) (*AgentResponse, error) {
+	var envelope map[string]json.RawMessage
+	if err := json.Unmarshal(body, &envelope); err != nil {
+		return nil, err
+	}
+
+	var success bool
+	if err := json.Unmarshal(envelope["success"], &success); err != nil || !success {
+		return nil, errors.New("agent response is not successful")
+	}
+
+	var fields map[string]json.RawMessage
+	if err := json.Unmarshal(envelope["data"], &fields); err != nil || fields == nil {
+		return nil, errors.New("agent response data is not an object")
+	}
+
+	resp := &AgentResponse{Success: true, Data: &GeneratedLessonData{}}
+	var usage TokenUsage
+	if err := json.Unmarshal(envelope["usage"], &usage); err == nil {
+		resp.Usage = &usage
+	}
+
+	data := resp.Data
+	decode := func(raw map[string]json.RawMessage, key string, dest interface{}) {
+		value, ok := raw[key]
+		if !ok || string(value) == "null" {
+			return
+		}
+		if err := json.Unmarshal(value, dest); err != nil {
+			resp.MissingFields = append(resp.MissingFields, key)
+		}
+	}
+	decode(fields, "title", &data.Title)
+	decode(fields, "objectives", &data.Objectives)
+	decode(fields, "keyPoints", &data.KeyPoints)
+	decode(fields, "difficultPoints", &data.DifficultPoints)
+	decode(fields, "teachingMethods", &data.TeachingMethods)
+	decode(fields, "evaluation", &data.Evaluation)
+	decode(fields, "reflection", &data.Reflection)
+
+	var content map[string]json.RawMessage
+	if raw, ok := fields["content"]; ok && string(raw) != "null" {
+		if err := json.Unmarshal(raw, &content); err != nil {
+			resp.MissingFields = append(resp.MissingFields, "sections", "materials", "homework")
+		}
+	}
+	if content != nil {
+		data.Content.Sections = decodePartialSections(content["sections"], resp)
+		decode(content, "materials", &data.Content.Materials)
+		decode(content, "homework", &data.Content.Homework)
+	}
+
+	if strings.TrimSpace(data.Title) == "" && len(data.Content.Sections) == 0 {
+		return nil, errNoUsableLessonData
+	}
+	return resp, nil
+}
+
+// decodePartialSections 逐条解析教学环节，出错的环节被跳过并记为 sections 缺失
+func decodePartialSections(raw json.RawMessage, resp *AgentResponse) []LessonSection {
+	if len(raw) == 0 || string(raw) == "null" {
+		return nil
+	}
+	var items []json.RawMessage
+	if err := json.Unmarshal(raw, &items); err != nil {
+		resp.MissingFields = append(resp.MissingFields, "sections")
+		return nil
+	}
+
+	sections := make([]LessonSection, 0, len(items))
+	for _, item := range items {
+		var section LessonSection
+		if err := json.Unmarshal(item, &section); err != nil {
+			continue
+		}
+		sections = append(sections, section)
+	}
+	if len(sections) < len(items) {
+		resp.MissingFields = append(resp.MissingFields, "sections")
+	}
+	return sections
+}
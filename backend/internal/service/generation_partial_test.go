@@ -0,0 +1,132 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// newRawAgent Agent 原样返回 body，用于构造字段类型不符的响应
+func newRawAgent(t *testing.T, body string) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(body))
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestDecodePartialAgentResponse(t *testing.T) {
+	tests := []struct {
+		name         string
+		body         string
+		wantErr      bool
+		wantTitle    string
+		wantSections []string
+		wantMissing  []string
+		check        func(t *testing.T, data *GeneratedLessonData)
+	}{
+		{
+			name: "one field with wrong type",
+			body: `{"success":true,"usage":{"totalTokens":42},"data":{"title":"有理数","keyPoints":"不是数组","evaluation":"课堂提问",
+				"content":{"sections":[{"title":"导入","content":"复习"}],"homework":"练习一"}}}`,
+			wantTitle:    "有理数",
+			wantSections: []string{"导入"},
+			wantMissing:  []string{"keyPoints"},
+			check: func(t *testing.T, data *GeneratedLessonData) {
+				if data.Evaluation != "课堂提问" || data.Content.Homework != "练习一" || data.KeyPoints != nil {
+					t.Errorf("data = %+v, want other fields kept and keyPoints empty", data)
+				}
+			},
+		},
+		{
+			name: "bad section skipped",
+			body: `{"success":true,"data":{"title":"有理数","content":{"sections":[
+				{"title":"导入","content":"复习"},{"title":"新授","duration":"十分钟"},{"title":"小结","content":"回顾"}]}}}`,
+			wantTitle:    "有理数",
+			wantSections: []string{"导入", "小结"},
+			wantMissing:  []string{"sections"},
+		},
+		{
+			name:         "content not an object",
+			body:         `{"success":true,"data":{"title":"有理数","objectives":{"knowledge":"加法法则"},"content":"全文"}}`,
+			wantTitle:    "有理数",
+			wantMissing:  []string{"sections", "materials", "homework"},
+			wantSections: []string{},
+			check: func(t *testing.T, data *GeneratedLessonData) {
+				if data.Objectives.Knowledge != "加法法则" {
+					t.Errorf("objectives = %+v, want kept", data.Objectives)
+				}
+			},
+		},
+		{name: "not json", body: `<html>`, wantErr: true},
+		{name: "not successful", body: `{"success":false,"data":{"title":"有理数"}}`, wantErr: true},
+		{name: "data not an object", body: `{"success":true,"data":[1]}`, wantErr: true},
+		{name: "nothing usable", body: `{"success":true,"data":{"title":1,"content":{"sections":"无"}}}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := decodePartialAgentResponse([]byte(tt.body))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("decodePartialAgentResponse() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			sections := []string{}
+			for _, s := range resp.Data.Content.Sections {
+				sections = append(sections, s.Title)
+			}
+			if resp.Data.Title != tt.wantTitle || !reflect.DeepEqual(sections, tt.wantSections) {
+				t.Errorf("data = (%q, %q), want (%q, %q)", resp.Data.Title, sections, tt.wantTitle, tt.wantSections)
+			}
+			if !reflect.DeepEqual(resp.MissingFields, tt.wantMissing) {
+				t.Errorf("MissingFields = %q, want %q", resp.MissingFields, tt.wantMissing)
+			}
+			if tt.check != nil {
+				tt.check(t, resp.Data)
+			}
+		})
+	}
+}
+
+func TestGenerateKeepsPartialResult(t *testing.T) {
+	ctx := context.Background()
+	agent := newRawAgent(t, `{"success":true,"usage":{"totalTokens":42},"data":{"title":"有理数的加法","keyPoints":"同号相加",
+		"evaluation":"课堂提问","content":{"sections":[{"title":"导入","content":"复习旧知"}]}}}`)
+	repo := newFakeGenerationRepo()
+	svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+	userID := uuid.New()
+	resp, err := svc.Generate(ctx, userID, &model.GenerationRequest{
+		Subject: "数学", Grade: "七年级", Topic: "有理数的加法",
+	}, APIKeyOverride{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if resp.Status != model.GenerationStatusCompleted || resp.Title != "有理数的加法" ||
+		!strings.Contains(resp.Content, "复习旧知") || resp.Assessment != "课堂提问" || resp.TokenCount != 42 {
+		t.Errorf("response = %+v, want the parsed fields kept", resp)
+	}
+	if !resp.Partial || !reflect.DeepEqual(resp.MissingFields, []string{"keyPoints"}) || !strings.Contains(resp.Notice, "教学重点") {
+		t.Errorf("partial = (%v, %q, %q), want keyPoints reported as missing", resp.Partial, resp.MissingFields, resp.Notice)
+	}
+
+	// 部分结果照常落库，历史详情可以看到保留的内容
+	detail, err := svc.GetByID(ctx, resp.ID, userID)
+	if err != nil {
+		t.Fatalf("GetByID() error = %v", err)
+	}
+	if detail.Status != model.GenerationStatusCompleted || detail.Result.Title != "有理数的加法" {
+		t.Errorf("stored = (%s, %q), want completed with the partial result", detail.Status, detail.Result.Title)
+	}
+}
@@ -0,0 +1,107 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// maxGenerationPresets 每个用户最多保存的生成参数预设数量
+const maxGenerationPresets = 20
+
+var (
+	ErrGenerationPresetNotFound = errors.New("预设不存在")
+	ErrTooManyGenerationPresets = errors.New("预设数量已达上限")
+)
+
+// SaveGenerationPresetRequest 保存生成参数预设请求
+type SaveGenerationPresetRequest struct {
+	Name       string `json:"name" binding:"required,max=50"`
+	Subject    string `json:"subject" binding:"max=50"`
+	Grade      string `json:"grade" binding:"max=20"`
+	Duration   int    `json:"duration" binding:"omitempty,min=10,max=240"`
+	Style      string `json:"style" binding:"max=50"`
+	Difficulty string `json:"difficulty" binding:"max=20"`
+}
+
+// GenerationPresetService 生成参数预设服务接口
+type GenerationPresetService interface {
+	Save(ctx context.Context, userID uuid.UUID, req *SaveGenerationPresetRequest) (*model.GenerationPreset, bool, error)
+	List(ctx context.Context, userID uuid.UUID) ([]model.GenerationPreset, error)
+	Delete(ctx context.Context, id, userID uuid.UUID) error
+}
+
+// generationPresetService 生成参数预设服务实现
+type generationPresetService struct {
+	presetRepo repository.GenerationPresetRepository
+}
+
+// NewGenerationPresetService 创建生成参数预设服务
+func NewGenerationPresetService(presetRepo repository.GenerationPresetRepository) GenerationPresetService {
+	return &generationPresetService{presetRepo: presetRepo}
+}
+
+// Save 按名称保存预设：同名预设直接覆盖，返回值 created 表示是否新建
+func (s *generationPresetService) Save(ctx context.Context, userID uuid.UUID, req *SaveGenerationPresetRequest) (*model.GenerationPreset, bool, error) {
+	name := strings.TrimSpace(req.Name)
+	if name == "" {
+		return nil, false, errors.New("预设名称不能为空")
+	}
+
+	preset, err := s.presetRepo.GetByName(ctx, userID, name)
+	created := err != nil
+	if created {
+		count, err := s.presetRepo.CountByUserID(ctx, userID)
+		if err != nil {
+			return nil, false, err
+		}
+		if count >= maxGenerationPresets {
+			return nil, false, ErrTooManyGenerationPresets
+		}
+		preset = &model.GenerationPreset{UserID: userID, Name: name}
+	}
+
+	preset.Subject = strings.TrimSpace(req.Subject)
+	preset.Grade = strings.TrimSpace(req.Grade)
+	preset.Duration = req.Duration
+	if preset.Duration <= 0 {
+		preset.Duration = 45
+	}
+	preset.Style = strings.TrimSpace(req.Style)
+	preset.Difficulty = strings.TrimSpace(req.Difficulty)
+
+	if created {
+		err = s.presetRepo.Create(ctx, preset)
+	} else {
+		err = s.presetRepo.Update(ctx, preset)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+	return preset, created, nil
+}
+
+func (s *generationPresetService) List(ctx context.Context, userID uuid.UUID) ([]model.GenerationPreset, error) {
+	presets, err := s.presetRepo.ListByUserID(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if presets == nil {
+		presets = []model.GenerationPreset{}
+	}
+	return presets, nil
+}
+
+// Delete 删除预设，他人的预设按不存在处理
+func (s *generationPresetService) Delete(ctx context.Context, id, userID uuid.UUID) error {
+	preset, err := s.presetRepo.GetByID(ctx, id)
+	if err != nil || preset.UserID != userID {
+		return ErrGenerationPresetNotFound
+	}
+	return s.presetRepo.Delete(ctx, id)
+}
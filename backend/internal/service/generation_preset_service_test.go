@@ -0,0 +1,185 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// fakePresetRepo 内存中的生成参数预设仓库
+type fakePresetRepo struct {
+	presets []*model.GenerationPreset
+}
+
+func (r *fakePresetRepo) Create(ctx context.Context, preset *model.GenerationPreset) error {
+	preset.ID = uuid.New()
+	stored := *preset
+	r.presets = append(r.presets, &stored)
+	return nil
+}
+
+func (r *fakePresetRepo) Update(ctx context.Context, preset *model.GenerationPreset) error {
+	for i, p := range r.presets {
+		if p.ID == preset.ID {
+			stored := *preset
+			r.presets[i] = &stored
+		}
+	}
+	return nil
+}
+
+func (r *fakePresetRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.GenerationPreset, error) {
+	for _, p := range r.presets {
+		if p.ID == id {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakePresetRepo) GetByName(ctx context.Context, userID uuid.UUID, name string) (*model.GenerationPreset, error) {
+	for _, p := range r.presets {
+		if p.UserID == userID && p.Name == name {
+			copied := *p
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakePresetRepo) ListByUserID(ctx context.Context, userID uuid.UUID) ([]model.GenerationPreset, error) {
+	var list []model.GenerationPreset
+	for _, p := range r.presets {
+		if p.UserID == userID {
+			list = append(list, *p)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakePresetRepo) CountByUserID(ctx context.Context, userID uuid.UUID) (int64, error) {
+	list, _ := r.ListByUserID(ctx, userID)
+	return int64(len(list)), nil
+}
+
+func (r *fakePresetRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	for i, p := range r.presets {
+		if p.ID == id {
+			r.presets = append(r.presets[:i], r.presets[i+1:]...)
+			break
+		}
+	}
+	return nil
+}
+
+func TestSaveGenerationPreset(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	tests := []struct {
+		name         string
+		existing     int
+		req          SaveGenerationPresetRequest
+		wantErr      error
+		wantCreated  bool
+		wantCount    int
+		wantDuration int
+	}{
+		{
+			name:         "new preset",
+			req:          SaveGenerationPresetRequest{Name: "七年级数学", Subject: "数学", Grade: "七年级", Duration: 40, Style: "interactive"},
+			wantCreated:  true,
+			wantCount:    1,
+			wantDuration: 40,
+		},
+		{
+			name:         "missing duration defaults to 45",
+			req:          SaveGenerationPresetRequest{Name: "语文阅读", Subject: "语文"},
+			wantCreated:  true,
+			wantCount:    1,
+			wantDuration: 45,
+		},
+		{
+			name:         "same name overwrites",
+			existing:     1,
+			req:          SaveGenerationPresetRequest{Name: " 预设0 ", Subject: "物理", Duration: 90},
+			wantCount:    1,
+			wantDuration: 90,
+		},
+		{
+			name:      "limit reached",
+			existing:  maxGenerationPresets,
+			req:       SaveGenerationPresetRequest{Name: "多出来的预设"},
+			wantErr:   ErrTooManyGenerationPresets,
+			wantCount: maxGenerationPresets,
+		},
+		{
+			name:         "overwrite allowed at limit",
+			existing:     maxGenerationPresets,
+			req:          SaveGenerationPresetRequest{Name: "预设3", Subject: "化学", Duration: 45},
+			wantCount:    maxGenerationPresets,
+			wantDuration: 45,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &fakePresetRepo{}
+			for i := 0; i < tt.existing; i++ {
+				_ = repo.Create(ctx, &model.GenerationPreset{UserID: userID, Name: fmt.Sprintf("预设%d", i), Subject: "数学", Duration: 45})
+			}
+			svc := NewGenerationPresetService(repo)
+
+			preset, created, err := svc.Save(ctx, userID, &tt.req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Save() error = %v, want %v", err, tt.wantErr)
+			}
+			if list, _ := svc.List(ctx, userID); len(list) != tt.wantCount {
+				t.Errorf("presets = %d, want %d", len(list), tt.wantCount)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if created != tt.wantCreated {
+				t.Errorf("created = %v, want %v", created, tt.wantCreated)
+			}
+			stored, err := repo.GetByID(ctx, preset.ID)
+			if err != nil {
+				t.Fatalf("saved preset not found: %v", err)
+			}
+			if stored.Subject != tt.req.Subject || stored.Style != tt.req.Style || stored.Duration != tt.wantDuration {
+				t.Errorf("stored = %+v, want subject %q style %q duration %d", stored, tt.req.Subject, tt.req.Style, tt.wantDuration)
+			}
+		})
+	}
+}
+
+func TestGenerationPresetsPerUser(t *testing.T) {
+	ctx := context.Background()
+	ownerID, otherID := uuid.New(), uuid.New()
+	svc := NewGenerationPresetService(&fakePresetRepo{})
+
+	preset, _, err := svc.Save(ctx, ownerID, &SaveGenerationPresetRequest{Name: "常用", Subject: "数学"})
+	if err != nil {
+		t.Fatalf("Save() error = %v", err)
+	}
+
+	if list, _ := svc.List(ctx, otherID); list == nil || len(list) != 0 {
+		t.Errorf("other user presets = %v, want empty list", list)
+	}
+	if err := svc.Delete(ctx, preset.ID, otherID); !errors.Is(err, ErrGenerationPresetNotFound) {
+		t.Errorf("Delete() by other user error = %v, want ErrGenerationPresetNotFound", err)
+	}
+	if err := svc.Delete(ctx, preset.ID, ownerID); err != nil {
+		t.Fatalf("Delete() error = %v", err)
+	}
+	if list, _ := svc.List(ctx, ownerID); len(list) != 0 {
+		t.Errorf("presets after delete = %d, want 0", len(list))
+	}
+}
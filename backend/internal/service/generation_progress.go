@@ -0,0 +1,203 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// progressSubscriberBuffer 每个订阅者的事件缓冲；单次生成只有两三个状态事件，正常不会写满
+	progressSubscriberBuffer = 8
+	// asyncGenerationTimeout 后台生成的最长执行时间，超时后记为失败
+	asyncGenerationTimeout = 10 * time.Minute
+)
+
+var (
+	ErrGenerationNotFound       = errors.New("生成记录不存在")
+	ErrGenerationForbidden      = errors.New("无权查看此生成记录")
+	ErrAsyncVariantsUnsupported = errors.New("异步生成暂不支持多方案，请使用同步生成")
+)
+
+// GenerationProgressEvent 生成进度事件：pending → processing → completed/failed/review/fallback
+type GenerationProgressEvent struct {
+	ID     uuid.UUID `json:"id"`
+	Status string    `json:"status"`
+	// Result 终态事件附带的生成响应
+	Result *model.GenerationResponse `json:"result,omitempty"`
+	// Error 生成过程出错（非 Agent 失败，如落库失败）时的错误信息
+	Error string `json:"error,omitempty"`
+}
+
+// Terminal 是否为终态事件，收到后订阅结束
+func (e GenerationProgressEvent) Terminal() bool {
+	switch e.Status {
+	case model.GenerationStatusPending, model.GenerationStatusProcessing:
+		return false
+	default:
+		return true
+	}
+}
+
+// generationBroker 进程内按生成 ID 分发进度事件。多实例部署时只有执行生成的实例能推送中间状态，
+// 其他实例的订阅者通过 SubscribeProgress 从数据库读取终态
+type generationBroker struct {
+	mu   sync.Mutex
+	subs map[uuid.UUID]map[chan GenerationProgressEvent]struct{}
+}
+
+func newGenerationBroker() *generationBroker {
+	return &generationBroker{subs: make(map[uuid.UUID]map[chan GenerationProgressEvent]struct{})}
+}
+
+// subscribe 订阅生成进度，返回的 unsubscribe 可重复调用
+func (b *generationBroker) subscribe(id uuid.UUID) (chan GenerationProgressEvent, func()) {
+	ch := make(chan GenerationProgressEvent, progressSubscriberBuffer)
+
+	b.mu.Lock()
+	if b.subs[id] == nil {
+		b.subs[id] = make(map[chan GenerationProgressEvent]struct{})
+	}
+	b.subs[id][ch] = struct{}{}
+	b.mu.Unlock()
+
+	return ch, func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if _, ok := b.subs[id][ch]; ok {
+			delete(b.subs[id], ch)
+			if len(b.subs[id]) == 0 {
+				delete(b.subs, id)
+			}
+			close(ch)
+		}
+	}
+}
+
+// publish 推送事件；终态事件推送后关闭并移除全部订阅
+func (b *generationBroker) publish(event GenerationProgressEvent) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	subs := b.subs[event.ID]
+	for ch := range subs {
+		select {
+		case ch <- event:
+		default:
+			// 缓冲已满说明订阅者不再消费，丢弃事件，不阻塞生成
+		}
+	}
+	if event.Terminal() {
+		for ch := range subs {
+			close(ch)
+		}
+		delete(b.subs, event.ID)
+	}
+}
+
+// publishProgress 推送生成进度
+func (s *generationService) publishProgress(id uuid.UUID, status string, result *model.GenerationResponse) {
+	s.progress.publish(GenerationProgressEvent{ID: id, Status: status, Result: result})
+}
+
+// StartGeneration 创建生成记录并在后台执行，立即返回记录，调用方轮询生成记录或通过 SubscribeProgress 获取进度。
+// 后台生成不随请求取消，但保留请求上下文中的租户、追踪等信息，最长执行 asyncGenerationTimeout
+func (s *generationService) StartGeneration(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*model.Generation, error) {
+	if req.Variants > 1 {
+		return nil, ErrAsyncVariantsUnsupported
+	}
+
+	generation, timeline, err := s.createGeneration(ctx, userID, req, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	bgCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), asyncGenerationTimeout)
+	go func() {
+		defer cancel()
+		defer func() {
+			if r := recover(); r != nil {
+				logger.Error(fmt.Sprintf("panic in async generation %s: %v", generation.ID, r))
+				msg := "内部错误: 生成过程异常"
+				_ = s.generationRepo.UpdateError(bgCtx, generation.ID, msg, 0)
+				s.progress.publish(GenerationProgressEvent{ID: generation.ID, Status: model.GenerationStatusFailed, Error: msg})
+			}
+		}()
+		_, _ = s.runGeneration(bgCtx, generation, timeline, userID, req, keyOverride, true)
+	}()
+	return generation, nil
+}
+
+// SubscribeProgress 订阅生成进度（仅生成者本人）。先订阅再读取当前状态，避免漏掉两者之间的事件：
+// 已处于终态时直接返回只含终态事件的通道；否则先推送当前状态，后续事件由生成过程推送
+func (s *generationService) SubscribeProgress(ctx context.Context, id, userID uuid.UUID) (<-chan GenerationProgressEvent, func(), error) {
+	ch, unsubscribe := s.progress.subscribe(id)
+
+	generation, err := s.generationRepo.GetByID(ctx, id)
+	if err != nil {
+		unsubscribe()
+		return nil, nil, ErrGenerationNotFound
+	}
+	if generation.UserID != userID {
+		unsubscribe()
+		return nil, nil, ErrGenerationForbidden
+	}
+
+	current := GenerationProgressEvent{ID: id, Status: generation.Status}
+	if current.Terminal() {
+		unsubscribe()
+		current.Result = generationResponseFromRecord(generation)
+		done := make(chan GenerationProgressEvent, 1)
+		done <- current
+		close(done)
+		return done, func() {}, nil
+	}
+
+	// 当前状态插到队首：订阅后到读库前若已有事件进入缓冲，以缓冲中的为准
+	out := make(chan GenerationProgressEvent, progressSubscriberBuffer+1)
+	out <- current
+	go func() {
+		defer close(out)
+		for event := range ch {
+			out <- event
+		}
+	}()
+	return out, unsubscribe, nil
+}
+
+// generationResponseFromRecord 由已落库的生成记录还原生成响应，供生成结束后才订阅的客户端使用
+func generationResponseFromRecord(generation *model.Generation) *model.GenerationResponse {
+	switch generation.Status {
+	case model.GenerationStatusCompleted:
+		var data GeneratedLessonData
+		if err := json.Unmarshal([]byte(generation.Result), &data); err != nil {
+			return &model.GenerationResponse{ID: generation.ID, Status: generation.Status}
+		}
+		resp := generationResponseFromData(generation.ID, &data)
+		var req model.GenerationRequest
+		if json.Unmarshal([]byte(generation.Parameters), &req) == nil {
+			resp.Tags = generateLessonTags(&req, &data)
+		}
+		resp.TokenCount = generation.TokenCount
+		resp.DurationMs = generation.DurationMs
+		resp.LessonID = generation.LessonID
+		return resp
+	case model.GenerationStatusReview:
+		return reviewGenerationResponse(generation.ID, generation.TokenCount, generation.DurationMs)
+	default:
+		return &model.GenerationResponse{
+			ID:           generation.ID,
+			Status:       generation.Status,
+			DurationMs:   generation.DurationMs,
+			ErrorMessage: generation.ErrorMsg,
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// collectProgress 读取进度事件直到通道关闭
+func collectProgress(t *testing.T, ch <-chan GenerationProgressEvent) []GenerationProgressEvent {
+	t.Helper()
+	var events []GenerationProgressEvent
+	timeout := time.After(5 * time.Second)
+	for {
+		select {
+		case event, ok := <-ch:
+			if !ok {
+				return events
+			}
+			events = append(events, event)
+		case <-timeout:
+			t.Fatalf("progress stream not closed, got %+v", events)
+		}
+	}
+}
+
+func TestSubscribeProgressObservesStatusChanges(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	called, release := make(chan struct{}), make(chan struct{})
+	agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+		close(called)
+		<-release
+		return http.StatusOK, agentLesson("有理数的加法", 100)
+	})
+	repo := newFakeGenerationRepo()
+	svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+	generation, err := svc.StartGeneration(ctx, userID, &model.GenerationRequest{
+		Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Duration: 45,
+	}, APIKeyOverride{})
+	if err != nil {
+		t.Fatalf("StartGeneration() error = %v", err)
+	}
+	<-called
+
+	if _, _, err := svc.SubscribeProgress(ctx, generation.ID, uuid.New()); !errors.Is(err, ErrGenerationForbidden) {
+		t.Errorf("SubscribeProgress() by other user error = %v, want ErrGenerationForbidden", err)
+	}
+	progress, unsubscribe, err := svc.SubscribeProgress(ctx, generation.ID, userID)
+	if err != nil {
+		t.Fatalf("SubscribeProgress() error = %v", err)
+	}
+	defer unsubscribe()
+	close(release)
+
+	// 订阅时先收到当前状态，生成结束后收到带结果的终态并关闭
+	events := collectProgress(t, progress)
+	if len(events) != 2 || events[0].Status != model.GenerationStatusProcessing || events[1].Status != model.GenerationStatusCompleted {
+		t.Fatalf("events = %+v, want processing then completed", events)
+	}
+	if events[1].Result == nil || events[1].Result.Title != "有理数的加法" {
+		t.Errorf("terminal result = %+v, want the generated lesson", events[1].Result)
+	}
+
+	// 生成结束后订阅直接得到终态
+	late, _, err := svc.SubscribeProgress(ctx, generation.ID, userID)
+	if err != nil {
+		t.Fatalf("late SubscribeProgress() error = %v", err)
+	}
+	events = collectProgress(t, late)
+	if len(events) != 1 || events[0].Status != model.GenerationStatusCompleted || events[0].Result.Title != "有理数的加法" {
+		t.Errorf("late events = %+v, want only the stored terminal state", events)
+	}
+}
+
+func TestGenerationBrokerPublish(t *testing.T) {
+	broker := newGenerationBroker()
+	id := uuid.New()
+	first, _ := broker.subscribe(id)
+	second, unsubscribe := broker.subscribe(id)
+	other, _ := broker.subscribe(uuid.New())
+
+	unsubscribe()
+	unsubscribe()
+	broker.publish(GenerationProgressEvent{ID: id, Status: model.GenerationStatusProcessing})
+	broker.publish(GenerationProgressEvent{ID: id, Status: model.GenerationStatusFailed, Error: "agent down"})
+
+	if events := collectProgress(t, first); len(events) != 2 || events[1].Error != "agent down" {
+		t.Errorf("events = %+v, want processing then failed", events)
+	}
+	if _, ok := <-second; ok {
+		t.Error("unsubscribed channel received an event")
+	}
+	select {
+	case event := <-other:
+		t.Errorf("other generation received %+v", event)
+	default:
+	}
+}
+
+func TestStartGeneration(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	req := &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Duration: 45}
+
+	t.Run("returns pending record and finishes in background", func(t *testing.T) {
+		release := make(chan struct{})
+		agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+			<-release
+			return http.StatusBadRequest, &AgentResponse{Error: "topic too vague"}
+		})
+		repo := newFakeGenerationRepo()
+		svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+		reqCtx, cancel := context.WithCancel(ctx)
+		generation, err := svc.StartGeneration(reqCtx, userID, req, APIKeyOverride{})
+		if err != nil {
+			t.Fatalf("StartGeneration() error = %v", err)
+		}
+		if generation.Status != model.GenerationStatusPending || generation.UserID != userID {
+			t.Errorf("generation = (%s, %s), want pending for the caller", generation.Status, generation.UserID)
+		}
+		progress, unsubscribe, err := svc.SubscribeProgress(ctx, generation.ID, userID)
+		if err != nil {
+			t.Fatalf("SubscribeProgress() error = %v", err)
+		}
+		defer unsubscribe()
+
+		// 请求结束不取消后台生成
+		cancel()
+		close(release)
+		events := collectProgress(t, progress)
+		if last := events[len(events)-1]; last.Status != model.GenerationStatusFailed {
+			t.Fatalf("events = %+v, want to end failed", events)
+		}
+		stored, _ := repo.GetByID(ctx, generation.ID)
+		if stored.Status != model.GenerationStatusFailed || stored.ErrorMsg == "" {
+			t.Errorf("stored = (%s, %q), want failure recorded", stored.Status, stored.ErrorMsg)
+		}
+	})
+
+	t.Run("variants unsupported", func(t *testing.T) {
+		repo := newFakeGenerationRepo()
+		svc := newTestGenerationService(t, "http://127.0.0.1:0", repo, nil)
+		multi := *req
+		multi.Variants = 2
+		if _, err := svc.StartGeneration(ctx, userID, &multi, APIKeyOverride{}); !errors.Is(err, ErrAsyncVariantsUnsupported) {
+			t.Errorf("StartGeneration() error = %v, want ErrAsyncVariantsUnsupported", err)
+		}
+		if repo.count() != 0 {
+			t.Errorf("generations = %d, want none created", repo.count())
+		}
+	})
+}
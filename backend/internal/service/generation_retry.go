@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var ErrGenerationInProgress = errors.New("生成仍在进行中，请等待结束后再重试")
+
+// Retry 以原生成记录保存的参数重新生成（仅生成者本人），新记录通过 RetryOf 关联原记录。
+// 原记录仍在生成中时返回 ErrGenerationInProgress
+func (s *generationService) Retry(ctx context.Context, userID, generationID uuid.UUID, keyOverride APIKeyOverride) (*model.GenerationResponse, error) {
+	original, err := s.generationRepo.GetByID(ctx, generationID)
+	if err != nil {
+		return nil, ErrGenerationNotFound
+	}
+	if original.UserID != userID {
+		return nil, ErrGenerationForbidden
+	}
+	if original.Status == model.GenerationStatusPending || original.Status == model.GenerationStatusProcessing {
+		return nil, ErrGenerationInProgress
+	}
+
+	var req model.GenerationRequest
+	if err := json.Unmarshal([]byte(original.Parameters), &req); err != nil {
+		return nil, fmt.Errorf("解析原生成参数失败: %w", err)
+	}
+	// 重试始终同步执行单个方案；多方案生成时每个方案各自落库，参数中 variants 已为 1
+	req.Async = false
+	req.Variants = 1
+	req.VariantStyles = nil
+
+	generation, timeline, err := s.createGeneration(ctx, userID, &req, &original.ID)
+	if err != nil {
+		return nil, err
+	}
+	return s.runGeneration(ctx, generation, timeline, userID, &req, keyOverride, true)
+}
@@ -0,0 +1,79 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestRetryGeneration(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	var topics []string
+	agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+		topics = append(topics, req.Topic)
+		return http.StatusOK, agentLesson("有理数的加法", 100)
+	})
+	repo := newFakeGenerationRepo()
+	svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+	stored := func(status string) *model.Generation {
+		params, _ := json.Marshal(model.GenerationRequest{
+			Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Duration: 45, Async: true, Variants: 3,
+		})
+		generation := &model.Generation{UserID: userID, Status: status, Parameters: string(params)}
+		_ = repo.Create(ctx, generation)
+		return generation
+	}
+
+	failed := stored(model.GenerationStatusFailed)
+	resp, err := svc.Retry(ctx, userID, failed.ID, APIKeyOverride{})
+	if err != nil {
+		t.Fatalf("Retry() error = %v", err)
+	}
+	if resp.Status != model.GenerationStatusCompleted || resp.ID == failed.ID || resp.Title != "有理数的加法" {
+		t.Errorf("response = %+v, want a new completed generation", resp)
+	}
+	retried, _ := repo.GetByID(ctx, resp.ID)
+	if retried.RetryOf == nil || *retried.RetryOf != failed.ID {
+		t.Errorf("RetryOf = %v, want %s", retried.RetryOf, failed.ID)
+	}
+	// 重试以同步单方案执行，原记录保持不变
+	var params model.GenerationRequest
+	_ = json.Unmarshal([]byte(retried.Parameters), &params)
+	if params.Async || params.Variants != 1 || params.Topic != "有理数的加法" || len(topics) != 1 {
+		t.Errorf("retry params = %+v after %d agent calls, want sync single variant of the original", params, len(topics))
+	}
+	if original, _ := repo.GetByID(ctx, failed.ID); original.Status != model.GenerationStatusFailed {
+		t.Errorf("original status = %s, want unchanged", original.Status)
+	}
+
+	tests := []struct {
+		name    string
+		id      uuid.UUID
+		userID  uuid.UUID
+		wantErr error
+	}{
+		{name: "processing", id: stored(model.GenerationStatusProcessing).ID, userID: userID, wantErr: ErrGenerationInProgress},
+		{name: "pending", id: stored(model.GenerationStatusPending).ID, userID: userID, wantErr: ErrGenerationInProgress},
+		{name: "other user", id: failed.ID, userID: uuid.New(), wantErr: ErrGenerationForbidden},
+		{name: "missing", id: uuid.New(), userID: userID, wantErr: ErrGenerationNotFound},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			before := repo.count()
+			if _, err := svc.Retry(ctx, tt.userID, tt.id, APIKeyOverride{}); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Retry() error = %v, want %v", err, tt.wantErr)
+			}
+			if repo.count() != before {
+				t.Errorf("generations = %d, want no new record", repo.count())
+			}
+		})
+	}
+}
@@ -6,47 +6,219 @@ import (
 	"fmt"
 	"net/http"
 	"strings"
+	"sync"
+	"time"
 
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/logger"
 
 	"github.com/google/uuid"
+	"golang.org/x/sync/singleflight"
+)
+
+const (
+	// maxGenerationVariants 单次请求允许生成的方案上限
+	maxGenerationVariants = 3
+	// variantConcurrency 多方案生成时同时调用 Agent 的并发上限
+	variantConcurrency = 2
 )
 
 // GenerationService 生成服务接口
 type GenerationService interface {
 	Generate(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*model.GenerationResponse, error)
-	GetByID(ctx context.Context, id uuid.UUID) (*model.Generation, error)
+	GetByID(ctx context.Context, id, userID uuid.UUID) (*model.GenerationDetail, error)
 	ListByUser(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Generation, int64, error)
+	SearchHistory(ctx context.Context, userID uuid.UUID, query string, page, pageSize int) ([]model.Generation, int64, error)
 	GetStats(ctx context.Context, userID uuid.UUID) (*repository.GenerationStats, error)
+	GetTimeseries(ctx context.Context, userID uuid.UUID, granularity, rangeParam string) (*GenerationTimeseries, error)
 	GetLangSmithUsage(ctx context.Context, userID uuid.UUID, page, pageSize int) (*LangSmithUsagePayload, error)
 	AskAssistant(ctx context.Context, userID uuid.UUID, req *AssistantChatRequest, keyOverride APIKeyOverride) (*AssistantChatPayload, error)
+	ListPendingReviews(ctx context.Context, page, pageSize int) ([]model.Generation, int64, error)
+	ResolveReview(ctx context.Context, id uuid.UUID, approved bool) error
+	StartGeneration(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*model.Generation, error)
+	SubscribeProgress(ctx context.Context, id, userID uuid.UUID) (<-chan GenerationProgressEvent, func(), error)
+	Retry(ctx context.Context, userID, generationID uuid.UUID, keyOverride APIKeyOverride) (*model.GenerationResponse, error)
 }
 
 // generationService 生成服务实现
 type generationService struct {
 	generationRepo repository.GenerationRepository
 	lessonRepo     repository.LessonRepository
+	prompts        PromptTemplateService
+	moderator      ContentModerator
+	// knowledgeRepo 判断用户有无个人知识点，决定相同请求能否跨用户合并，见 mergeAcrossUsers；为 nil 时只合并本人的请求
+	knowledgeRepo repository.KnowledgeRepository
+	// knowledgeFlags 短时缓存用户有无个人知识点，见 mergeAcrossUsers
+	knowledgeFlags knowledgeFlagCache
 	cfg            *config.AgentConfig
 	httpClient     *http.Client
+	// inflight 合并并发中的相同生成请求，见 callAgentShared
+	inflight singleflight.Group
+	// progress 按生成 ID 推送进度，见 SubscribeProgress
+	progress *generationBroker
 }
 
 // NewGenerationService 创建生成服务
 func NewGenerationService(
 	generationRepo repository.GenerationRepository,
 	lessonRepo repository.LessonRepository,
+	prompts PromptTemplateService,
+	moderator ContentModerator,
+	knowledgeRepo repository.KnowledgeRepository,
 	cfg *config.AgentConfig,
 ) GenerationService {
 	return &generationService{
 		generationRepo: generationRepo,
 		lessonRepo:     lessonRepo,
+		prompts:        prompts,
+		moderator:      moderator,
+		knowledgeRepo:  knowledgeRepo,
+		progress:       newGenerationBroker(),
 		cfg:            cfg,
 		httpClient:     newAgentHTTPClient(cfg),
 	}
 }
 
 func (s *generationService) Generate(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*model.GenerationResponse, error) {
+	if req.Variants > 1 {
+		return s.generateVariants(ctx, userID, req, keyOverride)
+	}
+	return s.generateOne(ctx, userID, req, keyOverride, true)
+}
+
+// variantObserverKey 多方案进度回调在 context 中的键
+type variantObserverKey struct{}
+
+// VariantDoneFunc 多方案生成中单个方案结束（成功或失败）时的回调，index 从 0 开始；可能被并发调用
+type VariantDoneFunc func(index, total int, variant model.GenerationResponse)
+
+// WithVariantObserver 登记多方案生成的进度回调，供流式接口逐个推送已完成的方案
+func WithVariantObserver(ctx context.Context, fn VariantDoneFunc) context.Context {
+	return context.WithValue(ctx, variantObserverKey{}, fn)
+}
+
+// VariantObserverFromContext 取出登记的多方案进度回调，未登记时返回 nil
+func VariantObserverFromContext(ctx context.Context) VariantDoneFunc {
+	fn, _ := ctx.Value(variantObserverKey{}).(VariantDoneFunc)
+	return fn
+}
+
+func notifyVariantDone(ctx context.Context, index, total int, variant *model.GenerationResponse) {
+	if fn := VariantObserverFromContext(ctx); fn != nil {
+		fn(index, total, *variant)
+	}
+}
+
+// generateVariants 并发生成多个方案。单个方案失败不影响其他方案，
+// 只要有一个方案成功即整体视为成功，全部失败时返回失败状态。
+func (s *generationService) generateVariants(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*model.GenerationResponse, error) {
+	count := req.Variants
+	if count > maxGenerationVariants {
+		count = maxGenerationVariants
+	}
+
+	variants := make([]model.GenerationResponse, count)
+	sem := make(chan struct{}, variantConcurrency)
+	var wg sync.WaitGroup
+
+	for i := 0; i < count; i++ {
+		variantReq := *req
+		variantReq.Variants = 1
+		variantReq.VariantStyles = nil
+		if i < len(req.VariantStyles) && strings.TrimSpace(req.VariantStyles[i]) != "" {
+			variantReq.Style = strings.TrimSpace(req.VariantStyles[i])
+		}
+
+		wg.Add(1)
+		go func(index int, variantReq model.GenerationRequest) {
+			defer wg.Done()
+			defer notifyVariantDone(ctx, index, count, &variants[index])
+			defer func() {
+				if r := recover(); r != nil {
+					variants[index] = model.GenerationResponse{
+						Status:       model.GenerationStatusFailed,
+						Style:        variantReq.Style,
+						ErrorMessage: fmt.Sprintf("方案生成异常: %v", r),
+					}
+				}
+			}()
+
+			select {
+			case sem <- struct{}{}:
+				defer func() { <-sem }()
+			case <-ctx.Done():
+				variants[index] = model.GenerationResponse{
+					Status:       model.GenerationStatusFailed,
+					Style:        variantReq.Style,
+					ErrorMessage: ctx.Err().Error(),
+				}
+				return
+			}
+
+			// 未指定风格的多个方案参数完全相同，合并后会得到同一份结果，因此方案生成不参与合并
+			resp, err := s.generateOne(ctx, userID, &variantReq, keyOverride, false)
+			if err != nil {
+				variants[index] = model.GenerationResponse{
+					Status:       model.GenerationStatusFailed,
+					Style:        variantReq.Style,
+					ErrorMessage: err.Error(),
+				}
+				return
+			}
+			resp.Style = variantReq.Style
+			variants[index] = *resp
+		}(i, variantReq)
+	}
+	wg.Wait()
+
+	var primary *model.GenerationResponse
+	totalTokens := 0
+	for i := range variants {
+		totalTokens += variants[i].TokenCount
+		if primary == nil && variants[i].Status == model.GenerationStatusCompleted {
+			primary = &variants[i]
+		}
+	}
+
+	if primary == nil {
+		// Agent 不可用时各方案都是同一份骨架，返回第一份即可；
+		// 没有可用方案但有待复核方案时，提示等待复核而不是生成失败
+		for _, status := range []string{model.GenerationStatusFallback, model.GenerationStatusReview} {
+			for i := range variants {
+				if variants[i].Status == status {
+					result := variants[i]
+					return &result, nil
+				}
+			}
+		}
+		return &model.GenerationResponse{
+			ID:           variants[0].ID,
+			Status:       model.GenerationStatusFailed,
+			ErrorMessage: "所有方案均生成失败",
+			Variants:     variants,
+		}, nil
+	}
+
+	result := *primary
+	result.TokenCount = totalTokens
+	result.Variants = variants
+	return &result, nil
+}
+
+// generateOne 生成单个方案并各自落库记账；merge 为 true 时与并发中的相同请求共享一次 Agent 调用
+func (s *generationService) generateOne(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride, merge bool) (*model.GenerationResponse, error) {
+	generation, timeline, err := s.createGeneration(ctx, userID, req, nil)
+	if err != nil {
+		return nil, err
+	}
+	return s.runGeneration(ctx, generation, timeline, userID, req, keyOverride, merge)
+}
+
+// createGeneration 创建 pending 状态的生成记录，retryOf 为被重试的原记录（非重试时为 nil）
+func (s *generationService) createGeneration(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, retryOf *uuid.UUID) (*model.Generation, *generationTimeline, error) {
+	timeline := newGenerationTimeline()
 	prompt := s.buildPrompt(req)
 	paramsJSON, _ := json.Marshal(req)
 
@@ -55,23 +227,59 @@ func (s *generationService) Generate(ctx context.Context, userID uuid.UUID, req
 		Prompt:     prompt,
 		Parameters: string(paramsJSON),
 		Status:     model.GenerationStatusPending,
+		Events:     timeline.JSON(),
+		RetryOf:    retryOf,
 	}
 
 	if err := s.generationRepo.Create(ctx, generation); err != nil {
+		return nil, nil, err
+	}
+	return generation, timeline, nil
+}
+
+// runGeneration 执行生成并向订阅者推送进度，结束时推送终态事件
+func (s *generationService) runGeneration(ctx context.Context, generation *model.Generation, timeline *generationTimeline, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride, merge bool) (*model.GenerationResponse, error) {
+	resp, err := s.executeGeneration(ctx, generation, timeline, userID, req, keyOverride, merge)
+	if err != nil {
+		s.progress.publish(GenerationProgressEvent{ID: generation.ID, Status: model.GenerationStatusFailed, Error: err.Error()})
 		return nil, err
 	}
+	s.publishProgress(generation.ID, resp.Status, resp)
+	return resp, nil
+}
 
+func (s *generationService) executeGeneration(ctx context.Context, generation *model.Generation, timeline *generationTimeline, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride, merge bool) (*model.GenerationResponse, error) {
 	_ = s.generationRepo.UpdateStatus(ctx, generation.ID, model.GenerationStatusProcessing)
-
-	agentResp, err := s.callAgent(ctx, userID, req, keyOverride)
+	s.publishProgress(generation.ID, model.GenerationStatusProcessing, nil)
+
+	// 耗时以进入 processing 后调用 Agent 为起点，由进程内单调时钟计算
+	timeline.Record(model.GenerationStageAgentCall, "")
+	startedAt := time.Now()
+	var agentResp *AgentResponse
+	var err error
+	if merge {
+		agentResp, err = s.callAgentShared(ctx, userID, req, keyOverride)
+	} else {
+		agentResp, err = s.callAgent(ctx, userID, req, keyOverride)
+	}
+	durationMs := time.Since(startedAt).Milliseconds()
 	if err != nil {
-		_ = s.generationRepo.UpdateError(ctx, generation.ID, err.Error())
+		_ = s.generationRepo.UpdateError(ctx, generation.ID, err.Error(), durationMs)
+		timeline.Record(model.GenerationStageFailed, err.Error())
+		_ = s.generationRepo.UpdateEvents(ctx, generation.ID, timeline.JSON())
+		// 生成记录仍记为失败，用户拿到可手填的骨架模板
+		if shouldFallback(ctx, err) {
+			return fallbackGeneration(generation.ID, req, durationMs), nil
+		}
 		return &model.GenerationResponse{
 			ID:           generation.ID,
 			Status:       model.GenerationStatusFailed,
+			DurationMs:   durationMs,
 			ErrorMessage: err.Error(),
 		}, nil
 	}
+	timeline.Record(model.GenerationStageAgentDone, "")
+
 	tokenCount := 0
 	if agentResp.Usage != nil {
 		tokenCount = agentResp.Usage.TotalTokens
@@ -81,47 +289,100 @@ func (s *generationService) Generate(ctx context.Context, userID uuid.UUID, req
 	}
 
 	resultJSON, _ := json.Marshal(agentResp.Data)
-	if err := s.generationRepo.UpdateResult(ctx, generation.ID, string(resultJSON), tokenCount); err != nil {
+	resp := generationResponseFromData(generation.ID, agentResp.Data)
+	resp.Tags = generateLessonTags(req, agentResp.Data)
+	resp.TokenCount = tokenCount
+	resp.DurationMs = durationMs
+	if len(agentResp.MissingFields) > 0 {
+		resp.Partial = true
+		resp.MissingFields = agentResp.MissingFields
+		resp.Notice = partialGenerationNotice(agentResp.MissingFields)
+		timeline.Record(model.GenerationStageParsed, resp.Notice)
+	} else {
+		timeline.Record(model.GenerationStageParsed, "")
+	}
+
+	// 未通过内容审核：结果照常落库供人工复核，响应中不返回内容
+	if reason, flagged := s.moderate(ctx, generation.ID, resp); flagged {
+		if err := s.generationRepo.UpdateReview(ctx, generation.ID, string(resultJSON), tokenCount, durationMs, reason); err != nil {
+			return nil, err
+		}
+		timeline.Record(model.GenerationStageSaved, "待人工复核")
+		_ = s.generationRepo.UpdateEvents(ctx, generation.ID, timeline.JSON())
+		return reviewGenerationResponse(generation.ID, tokenCount, durationMs), nil
+	}
+
+	if err := s.generationRepo.UpdateResult(ctx, generation.ID, string(resultJSON), tokenCount, durationMs); err != nil {
 		return nil, err
 	}
+	timeline.Record(model.GenerationStageSaved, "")
+	_ = s.generationRepo.UpdateEvents(ctx, generation.ID, timeline.JSON())
 
-	var objectives, keyPoints, difficultPoints, teachingMethods, content, activities, assessment, resources string
-	if agentResp.Data != nil {
-		objectives = FormatObjectives(agentResp.Data.Objectives)
-		keyPoints = FormatStringList(agentResp.Data.KeyPoints)
-		difficultPoints = FormatStringList(agentResp.Data.DifficultPoints)
-		teachingMethods = FormatStringList(agentResp.Data.TeachingMethods)
-		content = FormatSections(agentResp.Data.Content.Sections)
-		activities = FormatActivities(agentResp.Data.Content.Sections)
-		assessment = agentResp.Data.Evaluation
-		if agentResp.Data.Content.Homework != "" {
-			assessment += "\n\n## 课后作业\n" + agentResp.Data.Content.Homework
-		}
-		resources = FormatMaterials(agentResp.Data.Content.Materials)
-	}
-
-	return &model.GenerationResponse{
-		ID:              generation.ID,
-		Status:          model.GenerationStatusCompleted,
-		Title:           agentResp.Data.Title,
-		Objectives:      objectives,
-		KeyPoints:       keyPoints,
-		DifficultPoints: difficultPoints,
-		TeachingMethods: teachingMethods,
-		Content:         content,
-		Activities:      activities,
-		Assessment:      assessment,
-		Resources:       resources,
-		TokenCount:      tokenCount,
-	}, nil
+	if req.SaveAsDraft {
+		s.saveDraftLesson(ctx, generation.ID, userID, req, resp)
+	}
+
+	return resp, nil
 }
 
-func (s *generationService) GetByID(ctx context.Context, id uuid.UUID) (*model.Generation, error) {
-	return s.generationRepo.GetByID(ctx, id)
+// generationResponseFromData 将结构化教案转换为生成响应的文本字段
+func generationResponseFromData(id uuid.UUID, data *GeneratedLessonData) *model.GenerationResponse {
+	resp := &model.GenerationResponse{
+		ID:     id,
+		Status: model.GenerationStatusCompleted,
+	}
+	if data == nil {
+		return resp
+	}
+
+	resp.Title = data.Title
+	resp.Objectives = FormatObjectives(data.Objectives)
+	resp.KeyPoints = FormatStringList(data.KeyPoints)
+	resp.DifficultPoints = FormatStringList(data.DifficultPoints)
+	resp.TeachingMethods = FormatStringList(data.TeachingMethods)
+	resp.Content = FormatSections(data.Content.Sections)
+	resp.Activities = FormatActivities(data.Content.Sections)
+	resp.Assessment = data.Evaluation
+	if data.Content.Homework != "" {
+		resp.Assessment += "\n\n## 课后作业\n" + data.Content.Homework
+	}
+	resp.Resources = FormatMaterials(data.Content.Materials)
+	return resp
+}
+
+// GetByID 获取生成记录详情（仅生成者本人）
+func (s *generationService) GetByID(ctx context.Context, id, userID uuid.UUID) (*model.GenerationDetail, error) {
+	generation, err := s.generationRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrGenerationNotFound
+	}
+	if generation.UserID != userID {
+		return nil, ErrGenerationForbidden
+	}
+	hideUnreviewedResult(generation)
+	return &model.GenerationDetail{
+		Generation: *generation,
+		Result:     generationResponseFromRecord(generation),
+	}, nil
 }
 
 func (s *generationService) ListByUser(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.Generation, int64, error) {
-	return s.generationRepo.ListByUserID(ctx, userID, page, pageSize)
+	generations, total, err := s.generationRepo.ListByUserID(ctx, userID, page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	hideUnreviewedResults(generations)
+	return generations, total, nil
+}
+
+// SearchHistory 按提示词关键词搜索生成历史
+func (s *generationService) SearchHistory(ctx context.Context, userID uuid.UUID, query string, page, pageSize int) ([]model.Generation, int64, error) {
+	generations, total, err := s.generationRepo.SearchByUserID(ctx, userID, strings.TrimSpace(query), page, pageSize)
+	if err != nil {
+		return nil, 0, err
+	}
+	hideUnreviewedResults(generations)
+	return generations, total, nil
 }
 
 func (s *generationService) GetStats(ctx context.Context, userID uuid.UUID) (*repository.GenerationStats, error) {
@@ -249,51 +510,42 @@ func (s *generationService) AskAssistant(ctx context.Context, userID uuid.UUID,
 	}, nil
 }
 
+// buildPrompt 按当前 prompt 模板渲染；自定义模板渲染失败时回退内置模板，保证生成不中断
 func (s *generationService) buildPrompt(req *model.GenerationRequest) string {
-	prompt := fmt.Sprintf(`请生成一份%s学科%s年级的教案，主题是：%s。
-
-要求：
-- 课时时长：%d分钟
-- 难度：%s
-- 教学风格：%s
-`,
-		req.Subject,
-		req.Grade,
-		req.Topic,
-		req.Duration,
-		req.Difficulty,
-		req.Style,
-	)
-
-	if len(req.Objectives) > 0 {
-		prompt += "\n教学目标：\n"
-		for _, obj := range req.Objectives {
-			prompt += fmt.Sprintf("- %s\n", obj)
-		}
-	}
-
-	if len(req.Keywords) > 0 {
-		prompt += "\n关键知识点：\n"
-		for _, kw := range req.Keywords {
-			prompt += fmt.Sprintf("- %s\n", kw)
+	if s.prompts != nil {
+		if prompt, err := s.prompts.Render(req); err == nil {
+			return prompt
 		}
 	}
 
+	prompt, _ := executePromptTemplate(defaultPromptTmpl, req)
 	return prompt
 }
 
-func (s *generationService) callAgent(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*AgentResponse, error) {
-	agentReq := &AgentRequest{
-		Subject:    req.Subject,
-		Grade:      req.Grade,
-		Topic:      req.Topic,
-		Duration:   req.Duration,
-		Objectives: req.Objectives,
-		Keywords:   req.Keywords,
-		Style:      req.Style,
-		Difficulty: req.Difficulty,
-		UserId:     userID.String(),
+// newAgentRequest 构造发给 Agent 的生成请求
+func newAgentRequest(userID uuid.UUID, req *model.GenerationRequest) *AgentRequest {
+	var subjects []string
+	if all := generationSubjects(req); len(all) > 1 {
+		subjects = all
+	}
+	return &AgentRequest{
+		Subject:     primarySubject(req),
+		Subjects:    subjects,
+		Grade:       req.Grade,
+		Topic:       req.Topic,
+		Duration:    req.Duration,
+		Objectives:  req.Objectives,
+		Keywords:    req.Keywords,
+		Style:       describeStyle(req.Style),
+		Difficulty:  req.Difficulty,
+		DetailLevel: normalizeDetailLevel(req.DetailLevel),
+		LessonType:  normalizeLessonType(req.LessonType),
+		UserId:      userID.String(),
 	}
+}
+
+func (s *generationService) callAgent(ctx context.Context, userID uuid.UUID, req *model.GenerationRequest, keyOverride APIKeyOverride) (*AgentResponse, error) {
+	agentReq := newAgentRequest(userID, req)
 
 	body, err := json.Marshal(agentReq)
 	if err != nil {
@@ -316,16 +568,31 @@ func (s *generationService) callAgent(ctx context.Context, userID uuid.UUID, req
 
 	statusCode, respBody, err := doAgentRequestWithRetry(ctx, s.httpClient, http.MethodPost, url, body, headers, "generate")
 	if err != nil {
-		return nil, fmt.Errorf("call agent failed: %w", err)
+		if ctx.Err() != nil {
+			return nil, fmt.Errorf("call agent failed: %w", err)
+		}
+		return nil, fmt.Errorf("%w: call agent failed: %w", ErrAgentUnavailable, err)
 	}
 
+	if retryableStatusCode(statusCode) {
+		return nil, fmt.Errorf("%w: agent returned error: %d - %s", ErrAgentUnavailable, statusCode, string(respBody))
+	}
 	if statusCode != http.StatusOK {
 		return nil, fmt.Errorf("agent returned error: %d - %s", statusCode, string(respBody))
 	}
 
 	var agentResp AgentResponse
 	if err := json.Unmarshal(respBody, &agentResp); err != nil {
-		return nil, fmt.Errorf("unmarshal response failed: %w", err)
+		// 个别字段类型不符时保留其余已解析的内容，避免整份教案被丢弃
+		partial, partialErr := decodePartialAgentResponse(respBody)
+		if partialErr != nil {
+			return nil, fmt.Errorf("unmarshal response failed: %w", err)
+		}
+		logger.Warn("Agent response partially parsed",
+			logger.String("missing_fields", strings.Join(partial.MissingFields, ",")),
+			logger.Err(err),
+		)
+		return partial, nil
 	}
 
 	if !agentResp.Success {
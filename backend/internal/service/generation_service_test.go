@@ -0,0 +1,345 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeGenerationRepo 内存中的生成记录仓库，只实现生成流程用到的方法
+type fakeGenerationRepo struct {
+	repository.GenerationRepository
+
+	mu          sync.Mutex
+	generations map[uuid.UUID]*model.Generation
+}
+
+func newFakeGenerationRepo() *fakeGenerationRepo {
+	return &fakeGenerationRepo{generations: make(map[uuid.UUID]*model.Generation)}
+}
+
+func (r *fakeGenerationRepo) Create(ctx context.Context, generation *model.Generation) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation.ID == uuid.Nil {
+		generation.ID = uuid.New()
+	}
+	stored := *generation
+	r.generations[generation.ID] = &stored
+	return nil
+}
+
+func (r *fakeGenerationRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Generation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	generation, ok := r.generations[id]
+	if !ok {
+		return nil, ErrGenerationNotFound
+	}
+	copied := *generation
+	return &copied, nil
+}
+
+func (r *fakeGenerationRepo) GetByLessonID(ctx context.Context, lessonID uuid.UUID) (*model.Generation, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, generation := range r.generations {
+		if generation.LessonID != nil && *generation.LessonID == lessonID {
+			copied := *generation
+			return &copied, nil
+		}
+	}
+	return nil, ErrGenerationNotFound
+}
+
+func (r *fakeGenerationRepo) update(id uuid.UUID, fn func(g *model.Generation)) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if generation, ok := r.generations[id]; ok {
+		fn(generation)
+	}
+	return nil
+}
+
+func (r *fakeGenerationRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	return r.update(id, func(g *model.Generation) { g.Status = status })
+}
+
+func (r *fakeGenerationRepo) UpdateResult(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64) error {
+	return r.update(id, func(g *model.Generation) {
+		g.Status = model.GenerationStatusCompleted
+		g.Result = result
+		g.TokenCount = tokenCount
+		g.DurationMs = durationMs
+	})
+}
+
+func (r *fakeGenerationRepo) UpdateError(ctx context.Context, id uuid.UUID, errorMsg string, durationMs int64) error {
+	return r.update(id, func(g *model.Generation) {
+		g.Status = model.GenerationStatusFailed
+		g.ErrorMsg = errorMsg
+		g.DurationMs = durationMs
+	})
+}
+
+func (r *fakeGenerationRepo) UpdateEvents(ctx context.Context, id uuid.UUID, events string) error {
+	return r.update(id, func(g *model.Generation) { g.Events = events })
+}
+
+func (r *fakeGenerationRepo) UpdateReview(ctx context.Context, id uuid.UUID, result string, tokenCount int, durationMs int64, reason string) error {
+	return r.update(id, func(g *model.Generation) {
+		g.Status = model.GenerationStatusReview
+		g.Result = result
+		g.TokenCount = tokenCount
+		g.DurationMs = durationMs
+		g.ReviewReason = reason
+	})
+}
+
+func (r *fakeGenerationRepo) LinkLesson(ctx context.Context, id, lessonID uuid.UUID, lessonVersion int) error {
+	return r.update(id, func(g *model.Generation) {
+		g.LessonID = &lessonID
+		g.LessonVersion = &lessonVersion
+	})
+}
+
+// count 仓库中的生成记录数
+func (r *fakeGenerationRepo) count() int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return len(r.generations)
+}
+
+// fakeAgent 模拟 Agent 的 /api/generate 接口，respond 按请求决定返回内容
+type fakeAgent struct {
+	*httptest.Server
+	calls int32
+}
+
+func newFakeAgent(t *testing.T, respond func(req *AgentRequest) (int, *AgentResponse)) *fakeAgent {
+	t.Helper()
+	agent := &fakeAgent{}
+	agent.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(&agent.calls, 1)
+		var req AgentRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		status, resp := respond(&req)
+		w.Header().Set("Content-Type", "application/json")
+		w.WriteHeader(status)
+		_ = json.NewEncoder(w).Encode(resp)
+	}))
+	t.Cleanup(agent.Close)
+	return agent
+}
+
+func (a *fakeAgent) callCount() int {
+	return int(atomic.LoadInt32(&a.calls))
+}
+
+// agentLesson 生成成功时 Agent 返回的教案
+func agentLesson(title string, tokens int) *AgentResponse {
+	return &AgentResponse{
+		Success: true,
+		Data: &GeneratedLessonData{
+			Title:     title,
+			KeyPoints: []string{"重点"},
+			Content: LessonContent{
+				Sections: []LessonSection{{Title: "导入", Content: "复习旧知"}},
+			},
+			Evaluation: "课堂提问",
+		},
+		Usage: &TokenUsage{TotalTokens: tokens},
+	}
+}
+
+func newTestGenerationService(t *testing.T, agentURL string, generationRepo repository.GenerationRepository, lessonRepo repository.LessonRepository) *generationService {
+	t.Helper()
+	return NewGenerationService(generationRepo, lessonRepo, nil, nil, nil, &config.AgentConfig{URL: agentURL, Timeout: 5}).(*generationService)
+}
+
+func TestGenerateVariants(t *testing.T) {
+	tests := []struct {
+		name          string
+		variants      int
+		styles        []string
+		failStyles    map[string]bool
+		wantStatus    string
+		wantVariants  int
+		wantTokens    int
+		wantStyles    []string
+		wantSucceeded int
+	}{
+		{
+			name:          "single variant skips fan-out",
+			variants:      1,
+			wantStatus:    model.GenerationStatusCompleted,
+			wantVariants:  0,
+			wantTokens:    10,
+			wantSucceeded: 1,
+		},
+		{
+			name:          "each variant gets its own style",
+			variants:      3,
+			styles:        []string{"风格甲", "风格乙", "风格丙"},
+			wantStatus:    model.GenerationStatusCompleted,
+			wantVariants:  3,
+			wantTokens:    30,
+			wantStyles:    []string{"风格甲", "风格乙", "风格丙"},
+			wantSucceeded: 3,
+		},
+		{
+			name:          "one failed variant does not fail the request",
+			variants:      2,
+			styles:        []string{"风格甲", "风格乙"},
+			failStyles:    map[string]bool{"风格甲": true},
+			wantStatus:    model.GenerationStatusCompleted,
+			wantVariants:  2,
+			wantTokens:    10,
+			wantStyles:    []string{"风格甲", "风格乙"},
+			wantSucceeded: 1,
+		},
+		{
+			name:          "all variants failed",
+			variants:      2,
+			styles:        []string{"风格甲", "风格乙"},
+			failStyles:    map[string]bool{"风格甲": true, "风格乙": true},
+			wantStatus:    model.GenerationStatusFailed,
+			wantVariants:  2,
+			wantTokens:    0,
+			wantSucceeded: 0,
+		},
+		{
+			name:          "variant count is capped",
+			variants:      5,
+			wantStatus:    model.GenerationStatusCompleted,
+			wantVariants:  maxGenerationVariants,
+			wantTokens:    10 * maxGenerationVariants,
+			wantSucceeded: maxGenerationVariants,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+				if tt.failStyles[req.Style] {
+					return http.StatusBadRequest, &AgentResponse{Error: "bad request"}
+				}
+				return http.StatusOK, agentLesson("方案-"+req.Style, 10)
+			})
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, agent.URL, repo, nil)
+
+			// 每个方案结束时回调一次，失败的方案同样计入
+			var mu sync.Mutex
+			done := make(map[int]string)
+			ctx := WithVariantObserver(context.Background(), func(index, total int, variant model.GenerationResponse) {
+				mu.Lock()
+				defer mu.Unlock()
+				if total != tt.wantVariants {
+					t.Errorf("observer total = %d, want %d", total, tt.wantVariants)
+				}
+				done[index] = variant.Status
+			})
+
+			resp, err := svc.Generate(ctx, uuid.New(), &model.GenerationRequest{
+				Subject: "数学", Grade: "七年级", Topic: "有理数",
+				Variants: tt.variants, VariantStyles: tt.styles,
+			}, APIKeyOverride{})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Errorf("status = %q, want %q", resp.Status, tt.wantStatus)
+			}
+			if len(resp.Variants) != tt.wantVariants {
+				t.Fatalf("len(variants) = %d, want %d", len(resp.Variants), tt.wantVariants)
+			}
+			if len(done) != tt.wantVariants {
+				t.Errorf("observed variants = %v, want %d", done, tt.wantVariants)
+			}
+			for i, v := range resp.Variants {
+				if done[i] != v.Status {
+					t.Errorf("observed variants[%d] = %q, want %q", i, done[i], v.Status)
+				}
+			}
+			if resp.TokenCount != tt.wantTokens {
+				t.Errorf("token count = %d, want %d", resp.TokenCount, tt.wantTokens)
+			}
+			for i, style := range tt.wantStyles {
+				if resp.Variants[i].Style != style {
+					t.Errorf("variants[%d].style = %q, want %q", i, resp.Variants[i].Style, style)
+				}
+			}
+			succeeded := 0
+			for _, v := range resp.Variants {
+				if v.Status == model.GenerationStatusCompleted {
+					succeeded++
+				}
+			}
+			if tt.wantVariants == 0 && resp.Status == model.GenerationStatusCompleted {
+				succeeded = 1
+			}
+			if succeeded != tt.wantSucceeded {
+				t.Errorf("succeeded variants = %d, want %d", succeeded, tt.wantSucceeded)
+			}
+			if wantRecords := max(tt.wantVariants, 1); repo.count() != wantRecords {
+				t.Errorf("generation records = %d, want %d", repo.count(), wantRecords)
+			}
+			if resp.Status == model.GenerationStatusCompleted && !strings.HasPrefix(resp.Title, "方案-") {
+				t.Errorf("title = %q, want the primary variant's title", resp.Title)
+			}
+		})
+	}
+}
+
+func TestGenerationDurationMeasuredAroundAgentCall(t *testing.T) {
+	const agentDelay = 60 * time.Millisecond
+
+	tests := []struct {
+		name       string
+		status     int
+		resp       *AgentResponse
+		wantStatus string
+	}{
+		{name: "completed", status: http.StatusOK, resp: agentLesson("有理数", 10), wantStatus: model.GenerationStatusCompleted},
+		{name: "failed", status: http.StatusBadRequest, resp: &AgentResponse{Error: "bad request"}, wantStatus: model.GenerationStatusFailed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+				time.Sleep(agentDelay)
+				return tt.status, tt.resp
+			})
+			repo := newFakeGenerationRepo()
+			svc := newTestGenerationService(t, agent.URL, repo, newFakeLessonRepo())
+
+			resp, err := svc.Generate(context.Background(), uuid.New(), &model.GenerationRequest{Subject: "数学", Topic: "有理数"}, APIKeyOverride{})
+			if err != nil {
+				t.Fatalf("Generate() error = %v", err)
+			}
+			if resp.Status != tt.wantStatus {
+				t.Fatalf("status = %s, want %s", resp.Status, tt.wantStatus)
+			}
+			if resp.DurationMs < agentDelay.Milliseconds() || resp.DurationMs > (5*time.Second).Milliseconds() {
+				t.Errorf("duration_ms = %d, want about %d", resp.DurationMs, agentDelay.Milliseconds())
+			}
+			stored, _ := repo.GetByID(context.Background(), resp.ID)
+			if stored.DurationMs != resp.DurationMs {
+				t.Errorf("stored duration_ms = %d, want %d", stored.DurationMs, resp.DurationMs)
+			}
+		})
+	}
+}
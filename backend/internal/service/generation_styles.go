@@ -0,0 +1,87 @@
+package service
+
+import (
+	"strings"
+)
+
+// StylePreset 教学风格预设
+type StylePreset struct {
+	Key          string   `json:"key"`
+	Name         string   `json:"name"`
+	Description  string   `json:"description"`
+	Instructions []string `json:"instructions"`
+}
+
+// stylePresets 预设教学风格，Key 与前端及模板库中的 style 取值保持一致
+var stylePresets = []StylePreset{
+	{
+		Key:         "lecture",
+		Name:        "讲授型",
+		Description: "教师系统讲解为主，结构清晰，适合概念与原理教学。",
+		Instructions: []string{
+			"按“复习导入-新知讲解-例题示范-巩固练习-课堂小结”组织流程",
+			"讲解环节拆分为若干知识块，每块配一个示例或板书要点",
+			"穿插 2~3 个检查理解的提问，避免长时间单向灌输",
+		},
+	},
+	{
+		Key:         "interactive",
+		Name:        "探究型",
+		Description: "以问题驱动学生自主探究，强调观察、猜想与验证。",
+		Instructions: []string{
+			"以真实情境或认知冲突提出驱动问题",
+			"设计“猜想-实验/观察-讨论-归纳”的探究环节，明确小组分工",
+			"教师以追问和提示为主，结论由学生归纳后再统一提炼",
+		},
+	},
+	{
+		Key:         "flipped",
+		Name:        "翻转课堂",
+		Description: "课前自学、课上深化，课堂时间用于答疑与高阶任务。",
+		Instructions: []string{
+			"给出课前自学任务单（微课/阅读材料及自测题）",
+			"课上先用前测或提问诊断自学效果，再针对共性问题答疑",
+			"主要课堂时间用于合作解决进阶任务与成果展示",
+		},
+	},
+	{
+		Key:         "project",
+		Name:        "项目式",
+		Description: "围绕一个真实项目产出成果，整合知识与能力。",
+		Instructions: []string{
+			"明确项目任务与最终产出物（作品、报告或方案）",
+			"将课时拆分为项目启动、任务实施、成果展示与评价等阶段",
+			"提供过程性评价量规，兼顾小组协作与个人贡献",
+		},
+	},
+}
+
+// GenerationStylePresets 返回全部教学风格预设
+func GenerationStylePresets() []StylePreset {
+	presets := make([]StylePreset, len(stylePresets))
+	copy(presets, stylePresets)
+	return presets
+}
+
+// findStylePreset 按 Key 或中文名称匹配预设，自由文本返回 nil
+func findStylePreset(style string) *StylePreset {
+	style = strings.TrimSpace(style)
+	if style == "" {
+		return nil
+	}
+	for i := range stylePresets {
+		if strings.EqualFold(stylePresets[i].Key, style) || stylePresets[i].Name == style {
+			return &stylePresets[i]
+		}
+	}
+	return nil
+}
+
+// describeStyle 将预设展开为单行描述供 Agent 使用，自由文本原样返回
+func describeStyle(style string) string {
+	preset := findStylePreset(style)
+	if preset == nil {
+		return strings.TrimSpace(style)
+	}
+	return preset.Name + "（" + strings.Join(preset.Instructions, "；") + "）"
+}
@@ -0,0 +1,68 @@
+package service
+
+import (
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestDescribeStyle(t *testing.T) {
+	tests := []struct {
+		style      string
+		wantPrefix string
+		wantExact  bool
+	}{
+		{style: "lecture", wantPrefix: "讲授型（按“复习导入"},
+		{style: "Interactive", wantPrefix: "探究型（"},
+		{style: "翻转课堂", wantPrefix: "翻转课堂（给出课前自学任务单"},
+		{style: "  project ", wantPrefix: "项目式（"},
+		{style: " 情景教学 ", wantPrefix: "情景教学", wantExact: true},
+		{style: "", wantPrefix: "", wantExact: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.style, func(t *testing.T) {
+			got := describeStyle(tt.style)
+			if tt.wantExact && got != tt.wantPrefix {
+				t.Errorf("describeStyle(%q) = %q, want %q", tt.style, got, tt.wantPrefix)
+			}
+			if !strings.HasPrefix(got, tt.wantPrefix) {
+				t.Errorf("describeStyle(%q) = %q, want prefix %q", tt.style, got, tt.wantPrefix)
+			}
+		})
+	}
+}
+
+func TestGenerationStylePresetsReturnsCopy(t *testing.T) {
+	presets := GenerationStylePresets()
+	if len(presets) != len(stylePresets) {
+		t.Fatalf("len = %d, want %d", len(presets), len(stylePresets))
+	}
+	presets[0].Name = "changed"
+	if stylePresets[0].Name == "changed" {
+		t.Error("modifying the returned presets changed the built-in presets")
+	}
+}
+
+func TestStylePresetExpandsIntoPromptAndAgentRequest(t *testing.T) {
+	svc := &generationService{}
+	req := &model.GenerationRequest{Subject: "物理", Grade: "八年级", Topic: "浮力", Style: "interactive"}
+
+	prompt := svc.buildPrompt(req)
+	if !strings.Contains(prompt, "教学风格：探究型") {
+		t.Errorf("prompt does not name the preset:\n%s", prompt)
+	}
+	for _, instruction := range findStylePreset("interactive").Instructions {
+		if !strings.Contains(prompt, instruction) {
+			t.Errorf("prompt is missing instruction %q", instruction)
+		}
+	}
+
+	agentReq := newAgentRequest(uuid.New(), req)
+	if !strings.HasPrefix(agentReq.Style, "探究型（") {
+		t.Errorf("agent style = %q, want the expanded preset", agentReq.Style)
+	}
+}
@@ -0,0 +1,53 @@
+package service
+
+import (
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// maxGenerationSubjects 跨学科教案最多组合的学科数
+const maxGenerationSubjects = 4
+
+// subjectSeparators Subject 中用于分隔多个学科的字符，如“数学+物理”“数学、科学”
+const subjectSeparators = "、,，+/；;"
+
+// generationSubjects 汇总请求中的学科组合：Subject 可用分隔符写多个学科，Subjects 追加其余学科；
+// 去除空白与重复后按出现顺序返回，首个为主学科，最多 maxGenerationSubjects 个
+func generationSubjects(req *model.GenerationRequest) []string {
+	fields := strings.FieldsFunc(req.Subject, func(r rune) bool {
+		return strings.ContainsRune(subjectSeparators, r)
+	})
+	fields = append(fields, req.Subjects...)
+
+	subjects := make([]string, 0, len(fields))
+	seen := make(map[string]bool)
+	for _, subject := range fields {
+		subject = strings.TrimSpace(subject)
+		if subject == "" || seen[subject] {
+			continue
+		}
+		seen[subject] = true
+		subjects = append(subjects, subject)
+		if len(subjects) == maxGenerationSubjects {
+			break
+		}
+	}
+	return subjects
+}
+
+// primarySubject 主学科，用于知识检索与保存教案；未识别出学科时原样返回 Subject
+func primarySubject(req *model.GenerationRequest) string {
+	if subjects := generationSubjects(req); len(subjects) > 0 {
+		return subjects[0]
+	}
+	return strings.TrimSpace(req.Subject)
+}
+
+// describeSubjects 学科组合展示名，如“数学、物理”
+func describeSubjects(req *model.GenerationRequest) string {
+	if subjects := generationSubjects(req); len(subjects) > 0 {
+		return strings.Join(subjects, "、")
+	}
+	return strings.TrimSpace(req.Subject)
+}
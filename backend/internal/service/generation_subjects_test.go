@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerationSubjects(t *testing.T) {
+	tests := []struct {
+		name        string
+		subject     string
+		subjects    []string
+		want        []string
+		wantPrimary string
+		wantDisplay string
+	}{
+		{name: "single subject", subject: " 数学 ", want: []string{"数学"}, wantPrimary: "数学", wantDisplay: "数学"},
+		{name: "separators in subject", subject: "数学+物理、化学", want: []string{"数学", "物理", "化学"}, wantPrimary: "数学", wantDisplay: "数学、物理、化学"},
+		{name: "subjects appended", subject: "数学", subjects: []string{"物理", " 信息技术 "}, want: []string{"数学", "物理", "信息技术"}, wantPrimary: "数学", wantDisplay: "数学、物理、信息技术"},
+		{name: "blanks and duplicates dropped", subject: "数学,，物理", subjects: []string{"数学", "", "物理"}, want: []string{"数学", "物理"}, wantPrimary: "数学", wantDisplay: "数学、物理"},
+		{name: "capped at max", subject: "语文/数学;英语；物理", subjects: []string{"化学"}, want: []string{"语文", "数学", "英语", "物理"}, wantPrimary: "语文", wantDisplay: "语文、数学、英语、物理"},
+		{name: "only separators", subject: "+、", want: []string{}, wantPrimary: "+、", wantDisplay: "+、"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := &model.GenerationRequest{Subject: tt.subject, Subjects: tt.subjects}
+			if got := generationSubjects(req); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generationSubjects() = %q, want %q", got, tt.want)
+			}
+			if got := primarySubject(req); got != tt.wantPrimary {
+				t.Errorf("primarySubject() = %q, want %q", got, tt.wantPrimary)
+			}
+			if got := describeSubjects(req); got != tt.wantDisplay {
+				t.Errorf("describeSubjects() = %q, want %q", got, tt.wantDisplay)
+			}
+		})
+	}
+}
+
+func TestInterdisciplinaryPromptAndAgentRequest(t *testing.T) {
+	svc := &generationService{}
+
+	t.Run("interdisciplinary", func(t *testing.T) {
+		req := &model.GenerationRequest{Subject: "数学", Subjects: []string{"物理"}, Grade: "八年级", Topic: "测量校园旗杆高度"}
+
+		prompt := svc.buildPrompt(req)
+		for _, want := range []string{"融合数学、物理的八年级", "跨学科整合要求", "写明数学的核心概念", "写明物理的核心概念"} {
+			if !strings.Contains(prompt, want) {
+				t.Errorf("prompt missing %q:\n%s", want, prompt)
+			}
+		}
+
+		agentReq := newAgentRequest(uuid.New(), req)
+		if agentReq.Subject != "数学" || !reflect.DeepEqual(agentReq.Subjects, []string{"数学", "物理"}) {
+			t.Errorf("agent request subjects = (%q, %q), want 数学 with [数学 物理]", agentReq.Subject, agentReq.Subjects)
+		}
+		if title := buildFallbackLesson(req).Title; !strings.Contains(title, "数学、物理") {
+			t.Errorf("fallback title = %q, want the subject combination", title)
+		}
+	})
+
+	t.Run("single subject", func(t *testing.T) {
+		req := &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数"}
+
+		prompt := svc.buildPrompt(req)
+		if !strings.Contains(prompt, "请生成一份数学学科七年级") || strings.Contains(prompt, "跨学科") {
+			t.Errorf("single subject prompt should not mention interdisciplinary:\n%s", prompt)
+		}
+		if agentReq := newAgentRequest(uuid.New(), req); agentReq.Subjects != nil {
+			t.Errorf("agent request subjects = %q, want omitted", agentReq.Subjects)
+		}
+	})
+}
+
+func TestGenerateInterdisciplinary(t *testing.T) {
+	var received *AgentRequest
+	agent := newFakeAgent(t, func(req *AgentRequest) (int, *AgentResponse) {
+		received = req
+		return http.StatusOK, agentLesson("测量校园旗杆高度", 100)
+	})
+	lessons := newFakeLessonRepo()
+	svc := newTestGenerationService(t, agent.URL, newFakeGenerationRepo(), lessons)
+
+	resp, err := svc.Generate(context.Background(), uuid.New(), &model.GenerationRequest{
+		Subject: "数学+物理", Grade: "八年级", Topic: "测量校园旗杆高度", SaveAsDraft: true,
+	}, APIKeyOverride{})
+	if err != nil {
+		t.Fatalf("Generate() error = %v", err)
+	}
+	if received == nil || received.Subject != "数学" || !reflect.DeepEqual(received.Subjects, []string{"数学", "物理"}) {
+		t.Fatalf("agent request = %+v, want interdisciplinary subjects", received)
+	}
+
+	// 草稿按主学科保存，便于按学科检索
+	if resp.LessonID == nil || lessons.lessons[*resp.LessonID].Subject != "数学" {
+		t.Errorf("draft lesson = %v, want saved under the primary subject", resp.LessonID)
+	}
+}
@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// GranularityDay 按天聚合
+	GranularityDay = "day"
+	// GranularityWeek 按周聚合（周一为起点，与 PostgreSQL date_trunc('week') 一致）
+	GranularityWeek = "week"
+
+	defaultTimeseriesRange = "30d"
+	// maxTimeseriesDays 时间序列最多回溯的天数
+	maxTimeseriesDays = 366
+)
+
+var (
+	ErrUnsupportedGranularity = errors.New("不支持的统计粒度，可选 day、week")
+	ErrInvalidTimeseriesRange = errors.New("统计范围格式错误，例如 30d、12w，最长 366 天")
+)
+
+// GenerationTimeseries 生成用量时间序列
+type GenerationTimeseries struct {
+	Granularity string                            `json:"granularity"`
+	Range       string                            `json:"range"`
+	Since       time.Time                         `json:"since"`
+	Points      []repository.GenerationTimeBucket `json:"points"`
+}
+
+// parseTimeseriesRange 解析 "30d"、"12w" 形式的统计范围，返回天数
+func parseTimeseriesRange(raw string) (int, error) {
+	raw = strings.ToLower(strings.TrimSpace(raw))
+	if raw == "" {
+		raw = defaultTimeseriesRange
+	}
+	if len(raw) < 2 {
+		return 0, ErrInvalidTimeseriesRange
+	}
+
+	n, err := strconv.Atoi(raw[:len(raw)-1])
+	if err != nil || n <= 0 {
+		return 0, ErrInvalidTimeseriesRange
+	}
+
+	days := 0
+	switch raw[len(raw)-1] {
+	case 'd':
+		days = n
+	case 'w':
+		days = n * 7
+	default:
+		return 0, ErrInvalidTimeseriesRange
+	}
+	if days > maxTimeseriesDays {
+		return 0, ErrInvalidTimeseriesRange
+	}
+	return days, nil
+}
+
+// truncateToBucket 把时间截断到所在时间段的起点
+func truncateToBucket(t time.Time, granularity string) time.Time {
+	day := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	if granularity != GranularityWeek {
+		return day
+	}
+	// time.Weekday 以周日为 0，换算成距离周一的天数
+	offset := (int(day.Weekday()) + 6) % 7
+	return day.AddDate(0, 0, -offset)
+}
+
+// fillTimeseries 按粒度补齐 [start, end] 之间没有数据的时间段，保证前端折线连续
+func fillTimeseries(buckets []repository.GenerationTimeBucket, start, end time.Time, granularity string) []repository.GenerationTimeBucket {
+	step := 1
+	if granularity == GranularityWeek {
+		step = 7
+	}
+
+	byStart := make(map[string]repository.GenerationTimeBucket, len(buckets))
+	for _, b := range buckets {
+		key := truncateToBucket(b.BucketStart.In(start.Location()), granularity).Format("2006-01-02")
+		if existing, ok := byStart[key]; ok {
+			b.Count += existing.Count
+			b.Tokens += existing.Tokens
+		}
+		byStart[key] = b
+	}
+
+	points := make([]repository.GenerationTimeBucket, 0)
+	for cur := truncateToBucket(start, granularity); !cur.After(end); cur = cur.AddDate(0, 0, step) {
+		b := byStart[cur.Format("2006-01-02")]
+		points = append(points, repository.GenerationTimeBucket{
+			BucketStart: cur,
+			Count:       b.Count,
+			Tokens:      b.Tokens,
+		})
+	}
+	return points
+}
+
+func (s *generationService) GetTimeseries(ctx context.Context, userID uuid.UUID, granularity, rangeParam string) (*GenerationTimeseries, error) {
+	granularity = strings.ToLower(strings.TrimSpace(granularity))
+	if granularity == "" {
+		granularity = GranularityDay
+	}
+	if granularity != GranularityDay && granularity != GranularityWeek {
+		return nil, ErrUnsupportedGranularity
+	}
+
+	days, err := parseTimeseriesRange(rangeParam)
+	if err != nil {
+		return nil, err
+	}
+	if rangeParam == "" {
+		rangeParam = defaultTimeseriesRange
+	}
+
+	now := time.Now()
+	since := truncateToBucket(now.AddDate(0, 0, -(days-1)), granularity)
+
+	buckets, err := s.generationRepo.GetTimeseries(ctx, userID, granularity, since)
+	if err != nil {
+		return nil, err
+	}
+
+	return &GenerationTimeseries{
+		Granularity: granularity,
+		Range:       strings.ToLower(strings.TrimSpace(rangeParam)),
+		Since:       since,
+		Points:      fillTimeseries(buckets, since, now, granularity),
+	}, nil
+}
@@ -0,0 +1,167 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// timeseriesRecordingRepo 返回固定的聚合结果并记录查询参数
+type timeseriesRecordingRepo struct {
+	fakeGenerationRepo
+
+	buckets     []repository.GenerationTimeBucket
+	granularity string
+	since       time.Time
+}
+
+func (r *timeseriesRecordingRepo) GetTimeseries(ctx context.Context, userID uuid.UUID, granularity string, since time.Time) ([]repository.GenerationTimeBucket, error) {
+	r.granularity, r.since = granularity, since
+	return r.buckets, nil
+}
+
+func TestParseTimeseriesRange(t *testing.T) {
+	tests := []struct {
+		raw     string
+		want    int
+		wantErr bool
+	}{
+		{raw: "", want: 30},
+		{raw: "7d", want: 7},
+		{raw: " 12W ", want: 84},
+		{raw: "366d", want: 366},
+		{raw: "367d", wantErr: true},
+		{raw: "53w", wantErr: true},
+		{raw: "0d", wantErr: true},
+		{raw: "-3d", wantErr: true},
+		{raw: "3m", wantErr: true},
+		{raw: "d", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.raw, func(t *testing.T) {
+			got, err := parseTimeseriesRange(tt.raw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("parseTimeseriesRange(%q) error = %v, wantErr %v", tt.raw, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("parseTimeseriesRange(%q) = %d, want %d", tt.raw, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFillTimeseries(t *testing.T) {
+	day := func(d, hour int) time.Time { return time.Date(2026, 3, d, hour, 0, 0, 0, time.UTC) }
+
+	tests := []struct {
+		name        string
+		granularity string
+		start, end  time.Time
+		buckets     []repository.GenerationTimeBucket
+		wantStarts  []time.Time
+		wantCounts  []int64
+		wantTokens  []int64
+	}{
+		{
+			name:        "daily gaps filled with zero",
+			granularity: GranularityDay,
+			start:       day(1, 0),
+			end:         day(4, 12),
+			buckets: []repository.GenerationTimeBucket{
+				{BucketStart: day(2, 0), Count: 2, Tokens: 300},
+				{BucketStart: day(4, 0), Count: 1, Tokens: 50},
+			},
+			wantStarts: []time.Time{day(1, 0), day(2, 0), day(3, 0), day(4, 0)},
+			wantCounts: []int64{0, 2, 0, 1},
+			wantTokens: []int64{0, 300, 0, 50},
+		},
+		{
+			name:        "weekly buckets start on monday",
+			granularity: GranularityWeek,
+			start:       day(4, 0),
+			end:         day(17, 9),
+			buckets: []repository.GenerationTimeBucket{
+				{BucketStart: day(2, 0), Count: 1, Tokens: 10},
+				{BucketStart: day(5, 0), Count: 2, Tokens: 20},
+				{BucketStart: day(16, 0), Count: 4, Tokens: 40},
+			},
+			wantStarts: []time.Time{day(2, 0), day(9, 0), day(16, 0)},
+			wantCounts: []int64{3, 0, 4},
+			wantTokens: []int64{30, 0, 40},
+		},
+		{
+			name:        "empty range still has one point",
+			granularity: GranularityDay,
+			start:       day(1, 8),
+			end:         day(1, 20),
+			wantStarts:  []time.Time{day(1, 0)},
+			wantCounts:  []int64{0},
+			wantTokens:  []int64{0},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			points := fillTimeseries(tt.buckets, tt.start, tt.end, tt.granularity)
+			if len(points) != len(tt.wantStarts) {
+				t.Fatalf("points = %d, want %d", len(points), len(tt.wantStarts))
+			}
+			for i, p := range points {
+				if !p.BucketStart.Equal(tt.wantStarts[i]) || p.Count != tt.wantCounts[i] || p.Tokens != tt.wantTokens[i] {
+					t.Errorf("points[%d] = %s %d/%d, want %s %d/%d", i, p.BucketStart.Format(time.DateOnly), p.Count, p.Tokens,
+						tt.wantStarts[i].Format(time.DateOnly), tt.wantCounts[i], tt.wantTokens[i])
+				}
+			}
+		})
+	}
+}
+
+func TestGetTimeseries(t *testing.T) {
+	tests := []struct {
+		name        string
+		granularity string
+		rangeParam  string
+		wantErr     error
+		wantGran    string
+		wantRange   string
+		wantPoints  int
+	}{
+		{name: "defaults to 30 days", wantGran: GranularityDay, wantRange: "30d", wantPoints: 30},
+		{name: "weekly", granularity: "WEEK", rangeParam: "4w", wantGran: GranularityWeek, wantRange: "4w"},
+		{name: "unsupported granularity", granularity: "month", wantErr: ErrUnsupportedGranularity},
+		{name: "range too long", rangeParam: "400d", wantErr: ErrInvalidTimeseriesRange},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &timeseriesRecordingRepo{}
+			svc := newTestGenerationService(t, "", repo, nil)
+
+			series, err := svc.GetTimeseries(context.Background(), uuid.New(), tt.granularity, tt.rangeParam)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetTimeseries() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if series.Granularity != tt.wantGran || series.Range != tt.wantRange || repo.granularity != tt.wantGran {
+				t.Errorf("series = %s/%s, repo granularity %s, want %s/%s", series.Granularity, series.Range, repo.granularity, tt.wantGran, tt.wantRange)
+			}
+			if !repo.since.Equal(series.Since) || !series.Since.Equal(truncateToBucket(series.Since, tt.wantGran)) {
+				t.Errorf("since = %v (repo %v), want a bucket start", series.Since, repo.since)
+			}
+			if tt.wantPoints > 0 && len(series.Points) != tt.wantPoints {
+				t.Errorf("points = %d, want %d", len(series.Points), tt.wantPoints)
+			}
+			if tt.wantGran == GranularityWeek && (len(series.Points) < 4 || len(series.Points) > 5) {
+				t.Errorf("weekly points = %d, want 4 or 5", len(series.Points))
+			}
+		})
+	}
+}
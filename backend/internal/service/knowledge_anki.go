@@ -0,0 +1,139 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"errors"
+	"html"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// Anki 导出格式
+const (
+	AnkiFormatCSV = "csv"
+	AnkiFormatTSV = "tsv"
+)
+
+// ankiExportLimit 单次导出的知识点上限
+const ankiExportLimit = 5000
+
+// ErrUnsupportedAnkiFormat 不支持的导出格式
+var ErrUnsupportedAnkiFormat = errors.New("不支持的导出格式")
+
+// AnkiExport Anki 卡片导出结果
+type AnkiExport struct {
+	Filename    string
+	ContentType string
+	Content     []byte
+	CardCount   int
+}
+
+func (s *knowledgeService) ExportAnki(ctx context.Context, subject, grade, userId, format string) (*AnkiExport, error) {
+	if format == "" {
+		format = AnkiFormatCSV
+	}
+	if format != AnkiFormatCSV && format != AnkiFormatTSV {
+		return nil, ErrUnsupportedAnkiFormat
+	}
+
+	points, err := s.knowledgeRepo.ListKnowledgePoints(ctx, subject, grade, userId, ankiExportLimit)
+	if err != nil {
+		return nil, err
+	}
+
+	deck := "知识点"
+	if subject != "" {
+		deck += "::" + subject
+	}
+	if grade != "" {
+		deck += "::" + grade
+	}
+
+	content, count, err := buildAnkiDeck(points, deck, format)
+	if err != nil {
+		return nil, err
+	}
+
+	contentType := "text/csv; charset=utf-8"
+	if format == AnkiFormatTSV {
+		contentType = "text/tab-separated-values; charset=utf-8"
+	}
+
+	return &AnkiExport{
+		Filename:    strings.ReplaceAll(deck, "::", "-") + "." + format,
+		ContentType: contentType,
+		Content:     content,
+		CardCount:   count,
+	}, nil
+}
+
+// buildAnkiDeck 生成 Anki 文本导入文件：正面为知识点名称，背面为描述，第三列为标签。
+// 文件头使用 Anki 2.1.54+ 支持的 #separator/#html/#deck 指令，导入时无需手动选择分隔符和牌组；
+// 字段按 HTML 转义，换行转为 <br>。没有描述的知识点不生成卡片。
+func buildAnkiDeck(points []model.Knowledge, deck, format string) ([]byte, int, error) {
+	var buf bytes.Buffer
+	separator := "Comma"
+	comma := ','
+	if format == AnkiFormatTSV {
+		separator = "Tab"
+		comma = '\t'
+	}
+	buf.WriteString("#separator:" + separator + "\n")
+	buf.WriteString("#html:true\n")
+	buf.WriteString("#deck:" + deck + "\n")
+	buf.WriteString("#tags column:3\n")
+
+	writer := csv.NewWriter(&buf)
+	writer.Comma = comma
+
+	seen := make(map[string]bool, len(points))
+	count := 0
+	for _, point := range points {
+		front := strings.TrimSpace(point.Name)
+		back := strings.TrimSpace(point.Description)
+		if front == "" || back == "" {
+			continue
+		}
+		// 同名同描述的知识点（如多份文档抽取出的重复节点）只导出一次
+		key := front + "\x00" + back
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+
+		if err := writer.Write([]string{
+			ankiHTML(front),
+			ankiHTML(back),
+			ankiTags(point.Subject, point.Grade),
+		}); err != nil {
+			return nil, 0, err
+		}
+		count++
+	}
+
+	writer.Flush()
+	if err := writer.Error(); err != nil {
+		return nil, 0, err
+	}
+	return buf.Bytes(), count, nil
+}
+
+func ankiHTML(text string) string {
+	text = strings.ReplaceAll(text, "\r\n", "\n")
+	return strings.ReplaceAll(html.EscapeString(text), "\n", "<br>")
+}
+
+// ankiTags Anki 标签以空格分隔，标签内的空白替换为下划线
+func ankiTags(values ...string) string {
+	tags := make([]string, 0, len(values))
+	for _, value := range values {
+		value = strings.Join(strings.Fields(value), "_")
+		if value != "" {
+			tags = append(tags, value)
+		}
+	}
+	return strings.Join(tags, " ")
+}
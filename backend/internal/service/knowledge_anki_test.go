@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"encoding/csv"
+	"errors"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+)
+
+// fakeKnowledgeRepo 内存中的知识点仓库，只实现用到的查询
+type fakeKnowledgeRepo struct {
+	repository.KnowledgeRepository
+
+	points []model.Knowledge
+}
+
+func (r *fakeKnowledgeRepo) ListKnowledgePoints(ctx context.Context, subject, grade, userId string, limit int) ([]model.Knowledge, error) {
+	var list []model.Knowledge
+	for _, p := range r.points {
+		if (subject == "" || p.Subject == subject) && (grade == "" || p.Grade == grade) && len(list) < limit {
+			list = append(list, p)
+		}
+	}
+	return list, nil
+}
+
+func newTestKnowledgeService(repo repository.KnowledgeRepository) *knowledgeService {
+	return NewKnowledgeService(repo, &config.AgentConfig{}, nil).(*knowledgeService)
+}
+
+// parseAnkiFile 拆出 # 开头的文件头与卡片行
+func parseAnkiFile(t *testing.T, content []byte, comma rune) ([]string, [][]string) {
+	t.Helper()
+	var headers []string
+	var body strings.Builder
+	for _, line := range strings.SplitAfter(string(content), "\n") {
+		if strings.HasPrefix(line, "#") {
+			headers = append(headers, strings.TrimSpace(line))
+		} else {
+			body.WriteString(line)
+		}
+	}
+	reader := csv.NewReader(strings.NewReader(body.String()))
+	reader.Comma = comma
+	rows, err := reader.ReadAll()
+	if err != nil {
+		t.Fatalf("parse cards: %v", err)
+	}
+	return headers, rows
+}
+
+func TestExportAnki(t *testing.T) {
+	repo := &fakeKnowledgeRepo{points: []model.Knowledge{
+		{Name: "有理数", Description: "整数和分数的统称", Subject: "数学", Grade: "七年级"},
+		{Name: "绝对值", Description: "数轴上的点到原点的距离\n记作 |a|", Subject: "数学", Grade: "七年级"},
+		{Name: "不等式", Description: "a < b, 且 b > 0", Subject: "数学", Grade: "七年级"},
+		{Name: "有理数", Description: "整数和分数的统称", Subject: "数学", Grade: "七年级"},
+		{Name: "数轴", Subject: "数学", Grade: "七年级"},
+		{Name: "光合作用", Description: "植物利用光能合成有机物", Subject: "生物", Grade: "初中 二年级"},
+	}}
+	svc := newTestKnowledgeService(repo)
+
+	tests := []struct {
+		name         string
+		subject      string
+		grade        string
+		format       string
+		wantErr      error
+		wantFilename string
+		wantType     string
+		wantComma    rune
+		wantHeaders  []string
+		wantCards    [][]string
+	}{
+		{
+			name:         "csv by default",
+			subject:      "数学",
+			grade:        "七年级",
+			wantFilename: "知识点-数学-七年级.csv",
+			wantType:     "text/csv; charset=utf-8",
+			wantComma:    ',',
+			wantHeaders:  []string{"#separator:Comma", "#html:true", "#deck:知识点::数学::七年级", "#tags column:3"},
+			wantCards: [][]string{
+				{"有理数", "整数和分数的统称", "数学 七年级"},
+				{"绝对值", "数轴上的点到原点的距离<br>记作 |a|", "数学 七年级"},
+				{"不等式", "a &lt; b, 且 b &gt; 0", "数学 七年级"},
+			},
+		},
+		{
+			name:         "tsv with tag whitespace replaced",
+			subject:      "生物",
+			format:       AnkiFormatTSV,
+			wantFilename: "知识点-生物.tsv",
+			wantType:     "text/tab-separated-values; charset=utf-8",
+			wantComma:    '\t',
+			wantHeaders:  []string{"#separator:Tab", "#html:true", "#deck:知识点::生物", "#tags column:3"},
+			wantCards:    [][]string{{"光合作用", "植物利用光能合成有机物", "生物 初中_二年级"}},
+		},
+		{name: "unknown format", format: "apkg", wantErr: ErrUnsupportedAnkiFormat},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			export, err := svc.ExportAnki(context.Background(), tt.subject, tt.grade, "", tt.format)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ExportAnki() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if export.Filename != tt.wantFilename || export.ContentType != tt.wantType {
+				t.Errorf("file = %q (%s), want %q (%s)", export.Filename, export.ContentType, tt.wantFilename, tt.wantType)
+			}
+			headers, cards := parseAnkiFile(t, export.Content, tt.wantComma)
+			if strings.Join(headers, "|") != strings.Join(tt.wantHeaders, "|") {
+				t.Errorf("headers = %q, want %q", headers, tt.wantHeaders)
+			}
+			if export.CardCount != len(tt.wantCards) || len(cards) != len(tt.wantCards) {
+				t.Fatalf("cards = %d (count %d), want %d", len(cards), export.CardCount, len(tt.wantCards))
+			}
+			for i, want := range tt.wantCards {
+				if strings.Join(cards[i], "|") != strings.Join(want, "|") {
+					t.Errorf("card[%d] = %q, want %q", i, cards[i], want)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,205 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// 图谱聚类算法
+const (
+	ClusterAlgorithmComponents = "components"
+	ClusterAlgorithmLabelProp  = "label_propagation"
+)
+
+// labelPropagationMaxRounds 标签传播最大迭代轮数
+const labelPropagationMaxRounds = 20
+
+// ErrUnsupportedClusterAlgorithm 不支持的聚类算法
+var ErrUnsupportedClusterAlgorithm = errors.New("不支持的聚类算法")
+
+// KnowledgeGraphClusters 知识图谱聚类结果
+type KnowledgeGraphClusters struct {
+	*model.KnowledgeGraph
+	Algorithm string                   `json:"algorithm"`
+	Clusters  []model.KnowledgeCluster `json:"clusters"`
+}
+
+func (s *knowledgeService) GetGraphClusters(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time, algorithm string) (*KnowledgeGraphClusters, error) {
+	if algorithm == "" {
+		algorithm = ClusterAlgorithmLabelProp
+	}
+	if algorithm != ClusterAlgorithmComponents && algorithm != ClusterAlgorithmLabelProp {
+		return nil, ErrUnsupportedClusterAlgorithm
+	}
+
+	graph, err := s.GetGraph(ctx, subject, grade, topic, scope, userId, limit, relationTypes, asOf)
+	if err != nil {
+		return nil, err
+	}
+
+	return &KnowledgeGraphClusters{
+		KnowledgeGraph: graph,
+		Algorithm:      algorithm,
+		Clusters:       clusterKnowledgeGraph(graph, algorithm),
+	}, nil
+}
+
+// clusterKnowledgeGraph 在子图上聚类，写回每个节点的 Cluster 并返回簇摘要。
+// 节点按 ID 排序后处理、平局取最小标签，保证同一子图多次调用结果一致；
+// 簇编号从 1 开始，按规模降序、再按簇内最小节点 ID 升序分配。
+func clusterKnowledgeGraph(graph *model.KnowledgeGraph, algorithm string) []model.KnowledgeCluster {
+	if graph == nil || len(graph.Nodes) == 0 {
+		return []model.KnowledgeCluster{}
+	}
+
+	ids := make([]string, 0, len(graph.Nodes))
+	known := make(map[string]bool, len(graph.Nodes))
+	for _, node := range graph.Nodes {
+		if known[node.ID] {
+			continue
+		}
+		known[node.ID] = true
+		ids = append(ids, node.ID)
+	}
+	sort.Strings(ids)
+
+	// 无向邻接表，权重缺省为 1
+	adjacency := make(map[string]map[string]float64, len(ids))
+	for _, id := range ids {
+		adjacency[id] = map[string]float64{}
+	}
+	for _, edge := range graph.Edges {
+		if edge.Source == edge.Target || !known[edge.Source] || !known[edge.Target] {
+			continue
+		}
+		weight := edge.Weight
+		if weight <= 0 {
+			weight = 1
+		}
+		adjacency[edge.Source][edge.Target] += weight
+		adjacency[edge.Target][edge.Source] += weight
+	}
+
+	var labels map[string]string
+	if algorithm == ClusterAlgorithmComponents {
+		labels = connectedComponents(ids, adjacency)
+	} else {
+		labels = labelPropagation(ids, adjacency)
+	}
+
+	members := make(map[string][]string)
+	for _, id := range ids {
+		members[labels[id]] = append(members[labels[id]], id)
+	}
+	groups := make([][]string, 0, len(members))
+	for _, group := range members {
+		groups = append(groups, group)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		if len(groups[i]) != len(groups[j]) {
+			return len(groups[i]) > len(groups[j])
+		}
+		return groups[i][0] < groups[j][0]
+	})
+
+	nodeIndex := make(map[string]int, len(graph.Nodes))
+	for i, node := range graph.Nodes {
+		nodeIndex[node.ID] = i
+	}
+
+	clusters := make([]model.KnowledgeCluster, 0, len(groups))
+	clusterOf := make(map[string]int, len(ids))
+	for i, group := range groups {
+		cluster := model.KnowledgeCluster{
+			ID:      i + 1,
+			Size:    len(group),
+			NodeIDs: group,
+		}
+		// 以簇内最重要的节点作为簇名
+		bestImportance := -1.0
+		for _, id := range group {
+			clusterOf[id] = cluster.ID
+			node := graph.Nodes[nodeIndex[id]]
+			if node.Importance > bestImportance {
+				bestImportance = node.Importance
+				cluster.Label = node.Label
+			}
+		}
+		clusters = append(clusters, cluster)
+	}
+
+	for i := range graph.Nodes {
+		graph.Nodes[i].Cluster = clusterOf[graph.Nodes[i].ID]
+	}
+
+	return clusters
+}
+
+// connectedComponents 连通分量，以分量内最小节点 ID 作为标签
+func connectedComponents(ids []string, adjacency map[string]map[string]float64) map[string]string {
+	labels := make(map[string]string, len(ids))
+	for _, root := range ids {
+		if _, visited := labels[root]; visited {
+			continue
+		}
+		labels[root] = root
+		queue := []string{root}
+		for len(queue) > 0 {
+			current := queue[0]
+			queue = queue[1:]
+			for neighbor := range adjacency[current] {
+				if _, visited := labels[neighbor]; visited {
+					continue
+				}
+				labels[neighbor] = root
+				queue = append(queue, neighbor)
+			}
+		}
+	}
+	return labels
+}
+
+// labelPropagation 异步标签传播，节点采用邻居中权重最高的标签
+func labelPropagation(ids []string, adjacency map[string]map[string]float64) map[string]string {
+	labels := make(map[string]string, len(ids))
+	for _, id := range ids {
+		labels[id] = id
+	}
+
+	for round := 0; round < labelPropagationMaxRounds; round++ {
+		changed := false
+		for _, id := range ids {
+			if len(adjacency[id]) == 0 {
+				continue
+			}
+
+			scores := make(map[string]float64)
+			for neighbor, weight := range adjacency[id] {
+				scores[labels[neighbor]] += weight
+			}
+
+			best := labels[id]
+			bestScore := scores[best]
+			for label, score := range scores {
+				if score > bestScore || (score == bestScore && label < best) {
+					best = label
+					bestScore = score
+				}
+			}
+
+			if best != labels[id] {
+				labels[id] = best
+				changed = true
+			}
+		}
+		if !changed {
+			break
+		}
+	}
+
+	return labels
+}
@@ -0,0 +1,117 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// twoTriangles 两个三角形子图，由一条弱关系相连
+func twoTriangles() *model.KnowledgeGraph {
+	return &model.KnowledgeGraph{
+		Nodes: []model.KnowledgeNode{
+			{ID: "a", Label: "有理数", Importance: 0.9},
+			{ID: "b", Label: "数轴", Importance: 0.5},
+			{ID: "c", Label: "相反数", Importance: 0.4},
+			{ID: "d", Label: "方程", Importance: 0.3},
+			{ID: "e", Label: "一元一次方程", Importance: 0.8},
+			{ID: "f", Label: "移项", Importance: 0.2},
+		},
+		Edges: []model.KnowledgeEdge{
+			{Source: "a", Target: "b"}, {Source: "b", Target: "c"}, {Source: "a", Target: "c"},
+			{Source: "d", Target: "e"}, {Source: "e", Target: "f"}, {Source: "d", Target: "f"},
+			{Source: "c", Target: "d", Weight: 0.1},
+		},
+	}
+}
+
+func TestClusterKnowledgeGraph(t *testing.T) {
+	tests := []struct {
+		name       string
+		graph      *model.KnowledgeGraph
+		algorithm  string
+		wantSizes  []int
+		wantLabels []string
+		wantNodes  map[string]int
+	}{
+		{
+			name:      "empty graph",
+			graph:     &model.KnowledgeGraph{},
+			algorithm: ClusterAlgorithmLabelProp,
+		},
+		{
+			name:       "components merge weakly linked groups",
+			graph:      twoTriangles(),
+			algorithm:  ClusterAlgorithmComponents,
+			wantSizes:  []int{6},
+			wantLabels: []string{"有理数"},
+			wantNodes:  map[string]int{"a": 1, "f": 1},
+		},
+		{
+			name:       "label propagation splits weakly linked groups",
+			graph:      twoTriangles(),
+			algorithm:  ClusterAlgorithmLabelProp,
+			wantSizes:  []int{3, 3},
+			wantLabels: []string{"有理数", "一元一次方程"},
+			wantNodes:  map[string]int{"a": 1, "c": 1, "d": 2, "f": 2},
+		},
+		{
+			name: "isolated nodes form their own clusters",
+			graph: &model.KnowledgeGraph{
+				Nodes: []model.KnowledgeNode{{ID: "x", Label: "X"}, {ID: "y", Label: "Y"}, {ID: "z", Label: "Z"}},
+				Edges: []model.KnowledgeEdge{{Source: "y", Target: "z"}, {Source: "x", Target: "x"}, {Source: "x", Target: "missing"}},
+			},
+			algorithm:  ClusterAlgorithmComponents,
+			wantSizes:  []int{2, 1},
+			wantLabels: []string{"Y", "X"},
+			wantNodes:  map[string]int{"x": 2, "y": 1, "z": 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			clusters := clusterKnowledgeGraph(tt.graph, tt.algorithm)
+
+			var sizes []int
+			var labels []string
+			for i, c := range clusters {
+				if c.ID != i+1 {
+					t.Errorf("clusters[%d].ID = %d, want %d", i, c.ID, i+1)
+				}
+				sizes = append(sizes, c.Size)
+				labels = append(labels, c.Label)
+			}
+			if !reflect.DeepEqual(sizes, tt.wantSizes) {
+				t.Errorf("sizes = %v, want %v", sizes, tt.wantSizes)
+			}
+			if !reflect.DeepEqual(labels, tt.wantLabels) {
+				t.Errorf("labels = %v, want %v", labels, tt.wantLabels)
+			}
+			for _, node := range tt.graph.Nodes {
+				if want, ok := tt.wantNodes[node.ID]; ok && node.Cluster != want {
+					t.Errorf("node %s cluster = %d, want %d", node.ID, node.Cluster, want)
+				}
+			}
+		})
+	}
+}
+
+func TestClusterKnowledgeGraphIsDeterministic(t *testing.T) {
+	first := clusterKnowledgeGraph(twoTriangles(), ClusterAlgorithmLabelProp)
+	for i := 0; i < 20; i++ {
+		if got := clusterKnowledgeGraph(twoTriangles(), ClusterAlgorithmLabelProp); !reflect.DeepEqual(got, first) {
+			t.Fatalf("run %d = %+v, want %+v", i, got, first)
+		}
+	}
+}
+
+func TestGetGraphClustersRejectsUnknownAlgorithm(t *testing.T) {
+	svc := &knowledgeService{}
+	_, err := svc.GetGraphClusters(context.Background(), "", "", "", "", "", 0, nil, nil, "kmeans")
+	if !errors.Is(err, ErrUnsupportedClusterAlgorithm) {
+		t.Errorf("error = %v, want ErrUnsupportedClusterAlgorithm", err)
+	}
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"time"
+
+	"lesson-plan/backend/pkg/database"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// embeddingCacheKeyPrefix 查询向量缓存的 Redis 键前缀，键名为前缀加文本的 SHA-256
+const embeddingCacheKeyPrefix = "embedding:"
+
+// embeddingCacheable 是否使用查询向量缓存：需配置缓存时间且 Redis 已初始化；
+// 请求携带用户自己的 embedding API Key 时可能使用不同的模型，向量不能与默认模型的缓存混用
+func (s *knowledgeService) embeddingCacheable(ctx context.Context) bool {
+	if s.cfg == nil || s.cfg.EmbeddingCacheTTL <= 0 || database.GetRedis() == nil {
+		return false
+	}
+	return APIKeyOverrideFromContext(ctx).EmbeddingAPIKey == ""
+}
+
+func embeddingCacheKey(text string) string {
+	sum := sha256.Sum256([]byte(text))
+	return embeddingCacheKeyPrefix + hex.EncodeToString(sum[:])
+}
+
+// getCachedEmbedding 读取缓存的查询向量；缓存读取失败只记录日志，由调用方回退到请求 Agent
+func getCachedEmbedding(ctx context.Context, text string) ([]float64, bool) {
+	var embedding []float64
+	if err := database.Get(ctx, embeddingCacheKey(text), &embedding); err != nil {
+		if !errors.Is(err, redis.Nil) {
+			logger.Warn("Failed to read embedding cache", logger.Err(err))
+		}
+		return nil, false
+	}
+	return embedding, len(embedding) > 0
+}
+
+// setCachedEmbedding 缓存查询向量，写入失败不影响本次请求
+func setCachedEmbedding(ctx context.Context, text string, embedding []float64, ttl time.Duration) {
+	if err := database.Set(ctx, embeddingCacheKey(text), embedding, ttl); err != nil {
+		logger.Warn("Failed to write embedding cache", logger.Err(err))
+	}
+}
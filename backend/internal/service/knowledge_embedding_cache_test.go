@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/config"
+)
+
+// embeddingAgent 模拟 Agent 的向量接口并统计请求次数
+func embeddingAgent(t *testing.T, hits *int32) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		atomic.AddInt32(hits, 1)
+		var req struct {
+			Text string `json:"text"`
+		}
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{"embedding": []float64{float64(len(req.Text)), 0.5}})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestGetEmbeddingCache(t *testing.T) {
+	mr := newTestRedis(t)
+
+	tests := []struct {
+		name     string
+		ttl      int
+		ctx      context.Context
+		texts    []string
+		wantHits int32
+	}{
+		{name: "identical query cached", ttl: 60, ctx: context.Background(), texts: []string{"有理数", "有理数"}, wantHits: 1},
+		{name: "different query", ttl: 60, ctx: context.Background(), texts: []string{"有理数", "无理数"}, wantHits: 2},
+		{name: "cache disabled", ttl: 0, ctx: context.Background(), texts: []string{"有理数", "有理数"}, wantHits: 2},
+		{
+			name:     "user embedding key",
+			ttl:      60,
+			ctx:      WithAPIKeyOverride(context.Background(), APIKeyOverride{EmbeddingAPIKey: "sk-user"}),
+			texts:    []string{"有理数", "有理数"},
+			wantHits: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr.FlushAll()
+			var hits int32
+			agent := embeddingAgent(t, &hits)
+			svc := NewKnowledgeService(nil, &config.AgentConfig{URL: agent.URL, Timeout: 5, EmbeddingCacheTTL: tt.ttl}, nil)
+
+			for _, text := range tt.texts {
+				embedding, err := svc.GetEmbedding(tt.ctx, text)
+				if err != nil {
+					t.Fatalf("GetEmbedding(%q) error = %v", text, err)
+				}
+				// 命中缓存时返回与 Agent 相同的向量
+				if len(embedding) != 2 || embedding[0] != float64(len(text)) || embedding[1] != 0.5 {
+					t.Errorf("GetEmbedding(%q) = %v, want the agent embedding", text, embedding)
+				}
+			}
+			if got := atomic.LoadInt32(&hits); got != tt.wantHits {
+				t.Errorf("agent hits = %d, want %d", got, tt.wantHits)
+			}
+		})
+	}
+
+	t.Run("entry expires with ttl", func(t *testing.T) {
+		mr.FlushAll()
+		var hits int32
+		agent := embeddingAgent(t, &hits)
+		svc := NewKnowledgeService(nil, &config.AgentConfig{URL: agent.URL, Timeout: 5, EmbeddingCacheTTL: 60}, nil)
+
+		if _, err := svc.GetEmbedding(context.Background(), "有理数"); err != nil {
+			t.Fatalf("GetEmbedding() error = %v", err)
+		}
+		if ttl := mr.TTL(embeddingCacheKey("有理数")); ttl != time.Minute {
+			t.Errorf("cache ttl = %v, want %v", ttl, time.Minute)
+		}
+		mr.FastForward(time.Minute + time.Second)
+		if _, err := svc.GetEmbedding(context.Background(), "有理数"); err != nil {
+			t.Fatalf("GetEmbedding() error = %v", err)
+		}
+		if got := atomic.LoadInt32(&hits); got != 2 {
+			t.Errorf("agent hits = %d, want 2 after the entry expired", got)
+		}
+	})
+}
@@ -3,19 +3,66 @@ package service
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"net/http"
+	"sort"
+	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
 )
 
+// ErrUnsupportedRelationType 不支持的知识点关系类型
+var ErrUnsupportedRelationType = errors.New("不支持的关系类型")
+
+// ErrKnowledgeNodeNotFound 知识点不存在或尚未生成 embedding
+var ErrKnowledgeNodeNotFound = errors.New("知识点不存在或尚未生成向量")
+
+// ErrKnowledgePathEndpointNotFound 路径查询的起点或终点不存在
+var ErrKnowledgePathEndpointNotFound = errors.New("起点或终点知识点不存在")
+
+// ErrKnowledgePathSameEndpoint 路径查询的起点与终点相同
+var ErrKnowledgePathSameEndpoint = errors.New("起点与终点不能相同")
+
+// NormalizeRelationTypes 规范化关系类型过滤条件：大小写不敏感、去重，
+// 出现白名单外的取值时返回 ErrUnsupportedRelationType；为空表示不过滤
+func NormalizeRelationTypes(relationTypes []string) ([]string, error) {
+	normalized := make([]string, 0, len(relationTypes))
+	seen := make(map[string]bool, len(relationTypes))
+	for _, raw := range relationTypes {
+		relationType := strings.ToUpper(strings.TrimSpace(raw))
+		if relationType == "" || seen[relationType] {
+			continue
+		}
+		supported := false
+		for _, known := range repository.KnowledgeRelationTypes {
+			if relationType == known {
+				supported = true
+				break
+			}
+		}
+		if !supported {
+			return nil, fmt.Errorf("%w: %s", ErrUnsupportedRelationType, raw)
+		}
+		seen[relationType] = true
+		normalized = append(normalized, relationType)
+	}
+	return normalized, nil
+}
+
 // KnowledgeService 知识服务接口
 type KnowledgeService interface {
 	Search(ctx context.Context, query string, limit int) ([]model.KnowledgeSearchResult, error)
-	GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int) (*model.KnowledgeGraph, error)
+	GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error)
+	GetGraphClusters(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time, algorithm string) (*KnowledgeGraphClusters, error)
+	ExportAnki(ctx context.Context, subject, grade, userId, format string) (*AnkiExport, error)
 	GetEmbedding(ctx context.Context, text string) ([]float64, error)
+	GetSimilar(ctx context.Context, id, userId string, limit int) ([]model.SimilarKnowledgeNode, error)
+	// FindPath 查询两个知识点之间的最短路径，不连通时返回 Found=false 的空路径
+	FindPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (*model.KnowledgePath, error)
 }
 
 // knowledgeService 知识服务实现
@@ -23,26 +70,36 @@ type knowledgeService struct {
 	knowledgeRepo repository.KnowledgeRepository
 	cfg           *config.AgentConfig
 	httpClient    *http.Client
+	synonyms      *synonymExpander
 }
 
 // NewKnowledgeService 创建知识服务
 func NewKnowledgeService(
 	knowledgeRepo repository.KnowledgeRepository,
 	cfg *config.AgentConfig,
+	knowledgeCfg *config.KnowledgeConfig,
 ) KnowledgeService {
+	var synonyms [][]string
+	if knowledgeCfg != nil {
+		synonyms = knowledgeCfg.Synonyms
+	}
 	return &knowledgeService{
 		knowledgeRepo: knowledgeRepo,
 		cfg:           cfg,
 		httpClient:    newAgentHTTPClient(cfg),
+		synonyms:      newSynonymExpander(synonyms),
 	}
 }
 
 func (s *knowledgeService) Search(ctx context.Context, query string, limit int) ([]model.KnowledgeSearchResult, error) {
+	// 同义词扩展：如“水的三态”同时检索“固液气”
+	queries := s.synonyms.Expand(query)
+
 	// 获取查询的embedding
 	embedding, err := s.GetEmbedding(ctx, query)
 	if err != nil {
 		// 如果embedding失败，回退到文本搜索
-		knowledges, err := s.knowledgeRepo.Search(ctx, query, limit)
+		knowledges, err := s.knowledgeRepo.Search(ctx, queries, limit)
 		if err != nil {
 			return nil, err
 		}
@@ -77,14 +134,96 @@ func (s *knowledgeService) Search(ctx context.Context, query string, limit int)
 		}
 	}
 
+	if len(queries) > 1 {
+		results = s.mergeSynonymResults(ctx, results, queries[1:], limit)
+	}
 	return results, nil
 }
 
-func (s *knowledgeService) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int) (*model.KnowledgeGraph, error) {
-	return s.knowledgeRepo.GetGraph(ctx, subject, grade, topic, scope, userId, limit)
+// mergeSynonymResults 向量检索对词表中的专有说法不敏感，补充同义词的文本检索结果后按相关度截取
+func (s *knowledgeService) mergeSynonymResults(ctx context.Context, results []model.KnowledgeSearchResult, synonymQueries []string, limit int) []model.KnowledgeSearchResult {
+	knowledges, err := s.knowledgeRepo.Search(ctx, synonymQueries, limit)
+	if err != nil || len(knowledges) == 0 {
+		return results
+	}
+
+	seen := make(map[string]bool, len(results))
+	for _, item := range results {
+		seen[item.ID] = true
+	}
+	for _, k := range knowledges {
+		if seen[k.ID] {
+			continue
+		}
+		seen[k.ID] = true
+		results = append(results, model.KnowledgeSearchResult{
+			ID:             k.ID,
+			Name:           k.Name,
+			Content:        k.Description,
+			RelevanceScore: synonymScore,
+			Source:         "synonym_search",
+		})
+	}
+
+	sort.SliceStable(results, func(i, j int) bool {
+		return results[i].RelevanceScore > results[j].RelevanceScore
+	})
+	if limit > 0 && len(results) > limit {
+		results = results[:limit]
+	}
+	return results
+}
+
+// GetGraph 查询知识图谱，asOf 不为空时返回该时间点的快照
+func (s *knowledgeService) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error) {
+	relationTypes, err := NormalizeRelationTypes(relationTypes)
+	if err != nil {
+		return nil, err
+	}
+	return s.knowledgeRepo.GetGraph(ctx, subject, grade, topic, scope, userId, limit, relationTypes, asOf)
+}
+
+// GetSimilar 按节点 embedding 检索语义相似的知识点，结果不限于图谱中直接相连的节点
+func (s *knowledgeService) GetSimilar(ctx context.Context, id, userId string, limit int) ([]model.SimilarKnowledgeNode, error) {
+	nodes, found, err := s.knowledgeRepo.FindSimilarNodes(ctx, id, userId, limit)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrKnowledgeNodeNotFound
+	}
+	return nodes, nil
+}
+
+// FindPath 查询两个知识点之间的最短路径，from/to 为节点 id 或名称
+func (s *knowledgeService) FindPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (*model.KnowledgePath, error) {
+	from, to = strings.TrimSpace(from), strings.TrimSpace(to)
+	if from == to {
+		return nil, ErrKnowledgePathSameEndpoint
+	}
+	relationTypes, err := NormalizeRelationTypes(relationTypes)
+	if err != nil {
+		return nil, err
+	}
+
+	path, found, err := s.knowledgeRepo.FindShortestPath(ctx, from, to, userId, maxDepth, relationTypes)
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrKnowledgePathEndpointNotFound
+	}
+	return path, nil
 }
 
 func (s *knowledgeService) GetEmbedding(ctx context.Context, text string) ([]float64, error) {
+	cacheable := s.embeddingCacheable(ctx)
+	if cacheable {
+		if embedding, ok := getCachedEmbedding(ctx, text); ok {
+			return embedding, nil
+		}
+	}
+
 	reqBody := map[string]interface{}{
 		"text": text,
 	}
@@ -124,5 +263,8 @@ func (s *knowledgeService) GetEmbedding(ctx context.Context, text string) ([]flo
 		return nil, err
 	}
 
+	if cacheable && len(result.Embedding) > 0 {
+		setCachedEmbedding(ctx, text, result.Embedding, s.cfg.EmbeddingCacheTTLDuration())
+	}
 	return result.Embedding, nil
 }
@@ -0,0 +1,217 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+)
+
+// graphRecordingRepo 记录 GetGraph 收到的关系类型与快照时间
+type graphRecordingRepo struct {
+	fakeKnowledgeRepo
+
+	calls         int
+	relationTypes []string
+	asOf          *time.Time
+}
+
+func (r *graphRecordingRepo) GetGraph(ctx context.Context, subject, grade, topic, scope, userId string, limit int, relationTypes []string, asOf *time.Time) (*model.KnowledgeGraph, error) {
+	r.calls++
+	r.relationTypes = relationTypes
+	r.asOf = asOf
+	return &model.KnowledgeGraph{AsOf: asOf}, nil
+}
+
+func TestGetGraphRelationTypes(t *testing.T) {
+	tests := []struct {
+		name          string
+		relationTypes []string
+		want          []string
+		wantErr       error
+	}{
+		{name: "no filter", want: []string{}},
+		{name: "case insensitive and deduplicated", relationTypes: []string{" part_of", "PART_OF", "depends_on", ""}, want: []string{"PART_OF", "DEPENDS_ON"}},
+		{name: "unsupported type", relationTypes: []string{"RELATES_TO", "TEACHES"}, wantErr: ErrUnsupportedRelationType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := &graphRecordingRepo{}
+			svc := newTestKnowledgeService(repo)
+
+			_, err := svc.GetGraph(context.Background(), "数学", "", "", "", "user-1", 50, tt.relationTypes, nil)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetGraph() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.calls != 0 {
+					t.Errorf("repository queried %d times, want 0", repo.calls)
+				}
+				return
+			}
+			if strings.Join(repo.relationTypes, ",") != strings.Join(tt.want, ",") {
+				t.Errorf("relation types = %q, want %q", repo.relationTypes, tt.want)
+			}
+		})
+	}
+}
+
+func TestGetGraphPassesAsOf(t *testing.T) {
+	repo := &graphRecordingRepo{}
+	svc := newTestKnowledgeService(repo)
+	asOf := time.Date(2026, 9, 1, 23, 59, 59, 0, time.UTC)
+
+	graph, err := svc.GetGraph(context.Background(), "数学", "", "", "", "user-1", 50, nil, &asOf)
+	if err != nil {
+		t.Fatalf("GetGraph() error = %v", err)
+	}
+	if repo.asOf == nil || !repo.asOf.Equal(asOf) || graph.AsOf == nil {
+		t.Errorf("asOf = %v, graph.AsOf = %v, want %v passed through", repo.asOf, graph.AsOf, asOf)
+	}
+
+	// 聚类基于同一时间点的快照
+	if _, err := svc.GetGraphClusters(context.Background(), "数学", "", "", "", "user-1", 50, nil, &asOf, ClusterAlgorithmComponents); err != nil {
+		t.Fatalf("GetGraphClusters() error = %v", err)
+	}
+	if repo.calls != 2 || repo.asOf == nil || !repo.asOf.Equal(asOf) {
+		t.Errorf("clusters asOf = %v, want %v", repo.asOf, asOf)
+	}
+
+	if _, err := svc.GetGraph(context.Background(), "数学", "", "", "", "user-1", 50, nil, nil); err != nil || repo.asOf != nil {
+		t.Errorf("GetGraph() without asOf passed %v, %v", repo.asOf, err)
+	}
+}
+
+// similarRecordingRepo 记录相似检索参数，按 found 决定节点是否存在
+type similarRecordingRepo struct {
+	fakeKnowledgeRepo
+
+	found  bool
+	nodes  []model.SimilarKnowledgeNode
+	err    error
+	id     string
+	userID string
+	limit  int
+}
+
+func (r *similarRecordingRepo) FindSimilarNodes(ctx context.Context, id, userId string, limit int) ([]model.SimilarKnowledgeNode, bool, error) {
+	r.id, r.userID, r.limit = id, userId, limit
+	return r.nodes, r.found, r.err
+}
+
+func TestGetSimilar(t *testing.T) {
+	repoErr := errors.New("neo4j unavailable")
+	tests := []struct {
+		name      string
+		repo      *similarRecordingRepo
+		wantCount int
+		wantErr   error
+	}{
+		{
+			name: "found",
+			repo: &similarRecordingRepo{found: true, nodes: []model.SimilarKnowledgeNode{
+				{KnowledgeNode: model.KnowledgeNode{ID: "k2", Label: "数轴"}, Score: 0.92, Connected: true},
+				{KnowledgeNode: model.KnowledgeNode{ID: "k3", Label: "绝对值"}, Score: 0.81},
+			}},
+			wantCount: 2,
+		},
+		{name: "found without neighbours", repo: &similarRecordingRepo{found: true, nodes: []model.SimilarKnowledgeNode{}}},
+		{name: "missing node or embedding", repo: &similarRecordingRepo{}, wantErr: ErrKnowledgeNodeNotFound},
+		{name: "repository error", repo: &similarRecordingRepo{err: repoErr}, wantErr: repoErr},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestKnowledgeService(tt.repo)
+			nodes, err := svc.GetSimilar(context.Background(), "k1", "user-1", 5)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSimilar() error = %v, want %v", err, tt.wantErr)
+			}
+			if len(nodes) != tt.wantCount {
+				t.Errorf("GetSimilar() returned %d nodes, want %d", len(nodes), tt.wantCount)
+			}
+			if tt.repo.id != "k1" || tt.repo.userID != "user-1" || tt.repo.limit != 5 {
+				t.Errorf("repository called with (%q, %q, %d), want (k1, user-1, 5)", tt.repo.id, tt.repo.userID, tt.repo.limit)
+			}
+		})
+	}
+}
+
+// pathRecordingRepo 记录最短路径查询参数，按 found 决定端点是否存在
+type pathRecordingRepo struct {
+	fakeKnowledgeRepo
+
+	found         bool
+	path          *model.KnowledgePath
+	err           error
+	calls         int
+	from, to      string
+	maxDepth      int
+	relationTypes []string
+}
+
+func (r *pathRecordingRepo) FindShortestPath(ctx context.Context, from, to, userId string, maxDepth int, relationTypes []string) (*model.KnowledgePath, bool, error) {
+	r.calls++
+	r.from, r.to, r.maxDepth, r.relationTypes = from, to, maxDepth, relationTypes
+	return r.path, r.found, r.err
+}
+
+func TestFindPath(t *testing.T) {
+	repoErr := errors.New("neo4j unavailable")
+	connected := &model.KnowledgePath{
+		Found:  true,
+		Length: 1,
+		Nodes:  []model.KnowledgeNode{{ID: "k1", Label: "有理数"}, {ID: "k2", Label: "数轴"}},
+		Edges:  []model.KnowledgeEdge{{Source: "k1", Target: "k2", Type: "DEPENDS_ON", Weight: 1}},
+	}
+
+	tests := []struct {
+		name          string
+		from, to      string
+		relationTypes []string
+		repo          *pathRecordingRepo
+		wantErr       error
+		wantFound     bool
+		wantRelations []string
+	}{
+		{name: "connected", from: " 有理数 ", to: "k2", repo: &pathRecordingRepo{found: true, path: connected}, wantFound: true, wantRelations: []string{}},
+		{name: "not connected", from: "k1", to: "k9", repo: &pathRecordingRepo{found: true, path: &model.KnowledgePath{}}, wantRelations: []string{}},
+		{name: "relation filter normalized", from: "k1", to: "k2", relationTypes: []string{"depends_on", "DEPENDS_ON", "part_of"},
+			repo: &pathRecordingRepo{found: true, path: connected}, wantFound: true, wantRelations: []string{"DEPENDS_ON", "PART_OF"}},
+		{name: "missing endpoint", from: "k1", to: "missing", repo: &pathRecordingRepo{}, wantErr: ErrKnowledgePathEndpointNotFound},
+		{name: "repository error", from: "k1", to: "k2", repo: &pathRecordingRepo{err: repoErr}, wantErr: repoErr},
+		{name: "same endpoint", from: "k1", to: " k1", repo: &pathRecordingRepo{}, wantErr: ErrKnowledgePathSameEndpoint},
+		{name: "unsupported relation", from: "k1", to: "k2", relationTypes: []string{"CAUSES"}, repo: &pathRecordingRepo{}, wantErr: ErrUnsupportedRelationType},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestKnowledgeService(tt.repo)
+			path, err := svc.FindPath(context.Background(), tt.from, tt.to, "user-1", 4, tt.relationTypes)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("FindPath() error = %v, want %v", err, tt.wantErr)
+			}
+			if errors.Is(tt.wantErr, ErrKnowledgePathSameEndpoint) || errors.Is(tt.wantErr, ErrUnsupportedRelationType) {
+				if tt.repo.calls != 0 {
+					t.Error("repository queried for an invalid request")
+				}
+				return
+			}
+			if tt.repo.from != strings.TrimSpace(tt.from) || tt.repo.to != tt.to || tt.repo.maxDepth != 4 ||
+				strings.Join(tt.repo.relationTypes, ",") != strings.Join(tt.wantRelations, ",") {
+				t.Errorf("repository called with (%q, %q, %d, %v), want trimmed endpoints, depth 4 and %v",
+					tt.repo.from, tt.repo.to, tt.repo.maxDepth, tt.repo.relationTypes, tt.wantRelations)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if path.Found != tt.wantFound || (tt.wantFound && path.Length != len(path.Edges)) {
+				t.Errorf("FindPath() = %+v, want found %v", path, tt.wantFound)
+			}
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package service
+
+import (
+	"sort"
+	"strings"
+)
+
+// maxQueryExpansions 同义词扩展后的查询数量上限（含原查询），避免词表过大时检索条件膨胀
+const maxQueryExpansions = 8
+
+// synonymScore 仅由同义词扩展命中的结果的相关度，排在向量检索的前两名之后
+const synonymScore = 0.85
+
+// synonymExpander 基于可配词表的查询同义词扩展
+type synonymExpander struct {
+	groups [][]string
+}
+
+// newSynonymExpander 创建同义词扩展器，忽略空词和少于两个词的词组；
+// 组内词按长度降序排列，优先匹配更长的词，如“水的三态”先于“三态”
+func newSynonymExpander(groups [][]string) *synonymExpander {
+	expander := &synonymExpander{}
+	for _, group := range groups {
+		seen := make(map[string]bool, len(group))
+		terms := make([]string, 0, len(group))
+		for _, term := range group {
+			term = strings.TrimSpace(term)
+			if term == "" || seen[term] {
+				continue
+			}
+			seen[term] = true
+			terms = append(terms, term)
+		}
+		if len(terms) < 2 {
+			continue
+		}
+		sort.SliceStable(terms, func(i, j int) bool {
+			return len([]rune(terms[i])) > len([]rune(terms[j]))
+		})
+		expander.groups = append(expander.groups, terms)
+	}
+	return expander
+}
+
+// Expand 返回原查询及同义词替换后的查询，原查询始终在首位。
+// 查询包含词组中的某个词时，依次替换为组内其他词，如“水的三态”扩展出“固液气”“物态变化”
+func (e *synonymExpander) Expand(query string) []string {
+	query = strings.TrimSpace(query)
+	queries := []string{query}
+	if query == "" {
+		return queries
+	}
+
+	seen := map[string]bool{query: true}
+	for _, group := range e.groups {
+		for _, term := range group {
+			if !strings.Contains(query, term) {
+				continue
+			}
+			for _, synonym := range group {
+				if synonym == term {
+					continue
+				}
+				expanded := strings.ReplaceAll(query, term, synonym)
+				if seen[expanded] {
+					continue
+				}
+				seen[expanded] = true
+				queries = append(queries, expanded)
+				if len(queries) >= maxQueryExpansions {
+					return queries
+				}
+			}
+			break
+		}
+	}
+	return queries
+}
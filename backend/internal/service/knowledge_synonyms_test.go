@@ -0,0 +1,170 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+)
+
+var testSynonyms = [][]string{
+	{"水的三态", "固液气", "物态变化"},
+	{"光合作用", "光合"},
+	{" ", "孤词"},
+}
+
+func TestSynonymExpanderExpand(t *testing.T) {
+	expander := newSynonymExpander(testSynonyms)
+
+	tests := []struct {
+		name  string
+		query string
+		want  []string
+	}{
+		{name: "term expands to the other group members", query: "水的三态", want: []string{"水的三态", "物态变化", "固液气"}},
+		{name: "any member expands", query: "固液气", want: []string{"固液气", "水的三态", "物态变化"}},
+		{name: "term inside a longer query", query: "水的三态实验", want: []string{"水的三态实验", "物态变化实验", "固液气实验"}},
+		{name: "longer term matched before its substring", query: "光合作用", want: []string{"光合作用", "光合"}},
+		{name: "no synonym", query: "勾股定理", want: []string{"勾股定理"}},
+		{name: "single-term group ignored", query: "孤词", want: []string{"孤词"}},
+		{name: "whitespace trimmed", query: "  光合 ", want: []string{"光合", "光合作用"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expander.Expand(tt.query); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Expand(%q) = %v, want %v", tt.query, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSynonymExpanderCapsExpansions(t *testing.T) {
+	group := []string{"甲"}
+	for i := 0; i < maxQueryExpansions+5; i++ {
+		group = append(group, "乙"+strings.Repeat("丙", i+1))
+	}
+	if got := newSynonymExpander([][]string{group}).Expand("甲"); len(got) != maxQueryExpansions || got[0] != "甲" {
+		t.Errorf("Expand() = %d queries starting with %q, want %d starting with the original", len(got), got[0], maxQueryExpansions)
+	}
+}
+
+// synonymSearchRepo 名称或描述包含任一查询词即命中，模拟仓库的文本检索
+type synonymSearchRepo struct {
+	fakeKnowledgeRepo
+
+	vectorHits []model.Knowledge
+	queries    [][]string
+}
+
+func (r *synonymSearchRepo) Search(ctx context.Context, queries []string, limit int) ([]model.Knowledge, error) {
+	r.queries = append(r.queries, queries)
+	var list []model.Knowledge
+	for _, p := range r.points {
+		for _, q := range queries {
+			if strings.Contains(p.Name, q) || strings.Contains(p.Description, q) {
+				list = append(list, p)
+				break
+			}
+		}
+	}
+	return list, nil
+}
+
+func (r *synonymSearchRepo) SearchByEmbedding(ctx context.Context, embedding []float64, limit int) ([]model.Knowledge, error) {
+	return r.vectorHits, nil
+}
+
+func TestSearchExpandsSynonyms(t *testing.T) {
+	points := []model.Knowledge{
+		{ID: "k1", Name: "固液气的相互转化", Description: "熔化、凝固、汽化"},
+		{ID: "k2", Name: "勾股定理", Description: "直角三角形三边关系"},
+	}
+
+	tests := []struct {
+		name        string
+		embedStatus int
+		vectorHits  []model.Knowledge
+		wantIDs     []string
+		wantSource  string
+	}{
+		{
+			name:        "text search falls back with synonyms",
+			embedStatus: http.StatusBadRequest,
+			wantIDs:     []string{"k1"},
+			wantSource:  "text_search",
+		},
+		{
+			name:        "vector hit not duplicated by synonym search",
+			embedStatus: http.StatusOK,
+			vectorHits:  []model.Knowledge{{ID: "v1", Name: "温度"}, {ID: "k1", Name: "固液气的相互转化"}},
+			wantIDs:     []string{"v1", "k1"},
+			wantSource:  "vector_search",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.embedStatus)
+				_, _ = w.Write([]byte(`{"embedding":[0.1,0.2]}`))
+			}))
+			defer agent.Close()
+
+			repo := &synonymSearchRepo{fakeKnowledgeRepo: fakeKnowledgeRepo{points: points}, vectorHits: tt.vectorHits}
+			svc := NewKnowledgeService(repo, &config.AgentConfig{URL: agent.URL}, &config.KnowledgeConfig{Synonyms: testSynonyms})
+
+			results, err := svc.Search(context.Background(), "水的三态", 10)
+			if err != nil {
+				t.Fatalf("Search() error = %v", err)
+			}
+			var ids []string
+			for _, r := range results {
+				ids = append(ids, r.ID)
+			}
+			if !reflect.DeepEqual(ids, tt.wantIDs) {
+				t.Fatalf("Search() ids = %v, want %v", ids, tt.wantIDs)
+			}
+			if results[len(results)-1].Source != tt.wantSource {
+				t.Errorf("k1 source = %q, want %q", results[len(results)-1].Source, tt.wantSource)
+			}
+			if len(repo.queries) != 1 || !reflect.DeepEqual(repo.queries[0][len(repo.queries[0])-2:], []string{"物态变化", "固液气"}) {
+				t.Errorf("text search queries = %v, want synonyms included", repo.queries)
+			}
+		})
+	}
+}
+
+func TestSearchAddsSynonymOnlyHits(t *testing.T) {
+	agent := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`{"embedding":[0.1,0.2]}`))
+	}))
+	defer agent.Close()
+
+	repo := &synonymSearchRepo{
+		fakeKnowledgeRepo: fakeKnowledgeRepo{points: []model.Knowledge{{ID: "k1", Name: "固液气的相互转化"}}},
+		vectorHits:        []model.Knowledge{{ID: "v1"}, {ID: "v2"}, {ID: "v3"}},
+	}
+	svc := NewKnowledgeService(repo, &config.AgentConfig{URL: agent.URL}, &config.KnowledgeConfig{Synonyms: testSynonyms})
+
+	results, err := svc.Search(context.Background(), "水的三态", 3)
+	if err != nil {
+		t.Fatalf("Search() error = %v", err)
+	}
+	// 同义词命中排在向量检索前两名之后，超出 limit 的向量结果被截掉
+	var ids []string
+	for _, r := range results {
+		ids = append(ids, r.ID)
+	}
+	if !reflect.DeepEqual(ids, []string{"v1", "v2", "k1"}) {
+		t.Fatalf("Search() ids = %v, want [v1 v2 k1]", ids)
+	}
+	if results[2].Source != "synonym_search" || results[2].RelevanceScore != synonymScore {
+		t.Errorf("synonym hit = %+v", results[2])
+	}
+}
@@ -0,0 +1,69 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// ErrLessonNotArchived 只有已归档的教案可以恢复
+var ErrLessonNotArchived = errors.New("教案未归档，无需恢复")
+
+// Restore 作者恢复已归档的教案：曾发布过的恢复为已发布，否则恢复为草稿。
+// 保存会刷新更新时间，恢复后的教案重新计算不活跃期限
+func (s *lessonService) Restore(ctx context.Context, lessonID, userID uuid.UUID) error {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return ErrUnauthorized
+	}
+	if lesson.Status != model.LessonStatusArchived {
+		return ErrLessonNotArchived
+	}
+
+	lesson.Status = model.LessonStatusDraft
+	if lesson.PublishedAt != nil {
+		lesson.Status = model.LessonStatusPublished
+	}
+	lesson.ArchivedAt = nil
+	return s.lessonRepo.Update(ctx, lesson)
+}
+
+// ArchiveInactive 归档最近 inactiveDays 天内未更新且没有点赞、收藏、评论的已发布教案
+func (s *lessonService) ArchiveInactive(ctx context.Context, inactiveDays int) (int64, error) {
+	cutoff := time.Now().AddDate(0, 0, -inactiveDays)
+	return s.lessonRepo.ArchiveInactive(ctx, cutoff)
+}
+
+// RunArchiveScheduler 每天在 hour 点归档长期不活跃的教案，阻塞直到 ctx 取消；inactiveDays 不大于 0 时直接返回
+func (s *lessonService) RunArchiveScheduler(ctx context.Context, inactiveDays, hour int) {
+	if inactiveDays <= 0 {
+		return
+	}
+	for {
+		timer := time.NewTimer(time.Until(nextDigestTime(time.Now(), hour)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		archived, err := s.ArchiveInactive(ctx, inactiveDays)
+		if err != nil {
+			logger.Error("Lesson auto archive run failed", logger.String("error", err.Error()))
+			continue
+		}
+		logger.Info("Inactive lessons archived",
+			logger.Int("inactive_days", inactiveDays),
+			logger.Int("archived", int(archived)),
+		)
+	}
+}
@@ -0,0 +1,166 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// archivingLessonRepo 记录 ArchiveInactive 收到的截止时间
+type archivingLessonRepo struct {
+	*fakeLessonRepo
+	cutoffs []time.Time
+}
+
+func (r *archivingLessonRepo) ArchiveInactive(ctx context.Context, cutoff time.Time) (int64, error) {
+	r.cutoffs = append(r.cutoffs, cutoff)
+	return 2, nil
+}
+
+func TestRestoreArchivedLesson(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	archivedAt := time.Now().Add(-time.Hour)
+	publishedAt := time.Now().AddDate(0, -6, 0)
+
+	tests := []struct {
+		name       string
+		lesson     *model.Lesson
+		caller     uuid.UUID
+		wantErr    error
+		wantStatus string
+	}{
+		{
+			name:       "previously published",
+			lesson:     &model.Lesson{UserID: userID, Status: model.LessonStatusArchived, ArchivedAt: &archivedAt, PublishedAt: &publishedAt},
+			caller:     userID,
+			wantStatus: model.LessonStatusPublished,
+		},
+		{
+			name:       "never published",
+			lesson:     &model.Lesson{UserID: userID, Status: model.LessonStatusArchived, ArchivedAt: &archivedAt},
+			caller:     userID,
+			wantStatus: model.LessonStatusDraft,
+		},
+		{
+			name:       "not archived",
+			lesson:     &model.Lesson{UserID: userID, Status: model.LessonStatusPublished, PublishedAt: &publishedAt},
+			caller:     userID,
+			wantErr:    ErrLessonNotArchived,
+			wantStatus: model.LessonStatusPublished,
+		},
+		{
+			name:       "other user",
+			lesson:     &model.Lesson{UserID: userID, Status: model.LessonStatusArchived, ArchivedAt: &archivedAt},
+			caller:     uuid.New(),
+			wantErr:    ErrUnauthorized,
+			wantStatus: model.LessonStatusArchived,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLessonRepo(tt.lesson)
+			svc := newTestLessonService(repo, nil)
+
+			err := svc.Restore(ctx, tt.lesson.ID, tt.caller)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Restore() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := repo.GetByID(ctx, tt.lesson.ID)
+			if stored.Status != tt.wantStatus {
+				t.Errorf("status = %s, want %s", stored.Status, tt.wantStatus)
+			}
+			if tt.wantErr == nil && stored.ArchivedAt != nil {
+				t.Errorf("archived_at = %v, want cleared", stored.ArchivedAt)
+			}
+		})
+	}
+
+	svc := newTestLessonService(newFakeLessonRepo(), nil)
+	if err := svc.Restore(ctx, uuid.New(), userID); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("Restore() on missing lesson error = %v, want ErrLessonNotFound", err)
+	}
+}
+
+func TestUpdateClearsArchivedAt(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	archivedAt := time.Now()
+	lesson := &model.Lesson{UserID: userID, Title: "旧标题", Status: model.LessonStatusArchived, ArchivedAt: &archivedAt}
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, nil)
+
+	// 仍为归档状态的编辑保留归档时间
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Title: strPtr("新标题")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stored, _ := repo.GetByID(ctx, lesson.ID); stored.ArchivedAt == nil {
+		t.Error("archived_at cleared while still archived")
+	}
+
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Status: model.LessonStatusDraft}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if stored, _ := repo.GetByID(ctx, lesson.ID); stored.ArchivedAt != nil || stored.Status != model.LessonStatusDraft {
+		t.Errorf("stored = (%s, archived_at %v), want draft without archived_at", stored.Status, stored.ArchivedAt)
+	}
+}
+
+func TestArchiveInactive(t *testing.T) {
+	repo := &archivingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}
+	svc := newTestLessonService(repo, nil)
+
+	before := time.Now()
+	archived, err := svc.ArchiveInactive(context.Background(), 180)
+	if err != nil || archived != 2 {
+		t.Fatalf("ArchiveInactive() = %d, %v, want 2", archived, err)
+	}
+	want := before.AddDate(0, 0, -180)
+	if len(repo.cutoffs) != 1 || repo.cutoffs[0].Before(want) || repo.cutoffs[0].Sub(want) > time.Minute {
+		t.Errorf("cutoffs = %v, want about %v", repo.cutoffs, want)
+	}
+}
+
+func TestRunArchiveSchedulerStops(t *testing.T) {
+	repo := &archivingLessonRepo{fakeLessonRepo: newFakeLessonRepo()}
+	svc := newTestLessonService(repo, nil)
+
+	tests := []struct {
+		name         string
+		inactiveDays int
+		cancel       bool
+	}{
+		{name: "disabled", inactiveDays: 0},
+		{name: "context canceled", inactiveDays: 180, cancel: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx, cancel := context.WithCancel(context.Background())
+			if tt.cancel {
+				cancel()
+			}
+			defer cancel()
+
+			done := make(chan struct{})
+			go func() {
+				svc.RunArchiveScheduler(ctx, tt.inactiveDays, 3)
+				close(done)
+			}()
+			select {
+			case <-done:
+			case <-time.After(time.Second):
+				t.Fatal("RunArchiveScheduler() did not return")
+			}
+			if len(repo.cutoffs) != 0 {
+				t.Errorf("archived %d times before the scheduled hour", len(repo.cutoffs))
+			}
+		})
+	}
+}
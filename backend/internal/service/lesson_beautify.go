@@ -0,0 +1,218 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"regexp"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrBeautifyUnsupported 富文本教案的排版由编辑器负责，不做文本级规范化
+var ErrBeautifyUnsupported = errors.New("富文本教案暂不支持自动排版")
+
+// LessonBeautify 教案排版结果，Fields 只包含排版后有变化的字段（字段名 -> 排版后的文本）
+type LessonBeautify struct {
+	LessonID uuid.UUID         `json:"lesson_id"`
+	Changed  bool              `json:"changed"`
+	Fields   map[string]string `json:"fields"`
+	// Applied 为 true 表示已保存到教案（生成新版本，已发布教案进入草稿）；预览时为 false
+	Applied bool `json:"applied"`
+}
+
+var (
+	// halfWidthPunctAfterHan 汉字后（可隔空白）的半角标点，转为对应的全角标点并去掉中间的空白
+	halfWidthPunctAfterHan = regexp.MustCompile(`(\p{Han})[ \t]*([,;:?!])`)
+	// periodAfterHan 汉字后的半角句点，仅在句末（行尾、空白或汉字前）时转为句号，避免误伤小数、网址
+	periodAfterHan = regexp.MustCompile(`(\p{Han})[ \t]*\.(\s|$|\p{Han})`)
+	// parenWithHan 内容含汉字的半角括号转为全角括号
+	parenWithHan = regexp.MustCompile(`\(([^()\n]*\p{Han}[^()\n]*)\)`)
+	// spaceBeforeFullPunct / spaceAfterFullPunct 全角标点前后多余的空白
+	spaceBeforeFullPunct = regexp.MustCompile(`[ \t\x{3000}]+([，。；：？！、）」』”])`)
+	spaceAfterFullPunct  = regexp.MustCompile(`([，。；：？！、（「『“])[ \t\x{3000}]+`)
+	// bulletLine 各种项目符号开头的行，统一为 Markdown 的 "- "；* 和 + 需后跟空白，避免误伤 **加粗**
+	bulletLine = regexp.MustCompile(`^([ \t\x{3000}]*)(?:[•·●○▪■◆◇▶►]\s*|[-*+]\s+)(\S.*)$`)
+	// orderedLine 有序列表行（1. / 1、 / 1） / (1) / （1）），统一为 "1. "
+	orderedLine    = regexp.MustCompile(`^([ \t\x{3000}]*)(?:(\d{1,3})[.．、)）]|[(（](\d{1,3})[)）])[ \t\x{3000}]*(\S.*)$`)
+	fullWidthPunct = map[string]string{
+		",": "，", ";": "；", ":": "：", "?": "？", "!": "！",
+	}
+)
+
+// Beautify 规范化教案排版：统一中文标点与全角字母数字、清理多余空行与行尾空白、统一列表符号与缩进。
+// preview 为 true 时只返回排版结果；否则通过 Update 保存，与手动编辑一样生成版本与编辑记录
+func (s *lessonService) Beautify(ctx context.Context, lessonID, userID uuid.UUID, preview bool) (*LessonBeautify, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	if lesson.ContentType == model.LessonContentTypeHTML {
+		return nil, ErrBeautifyUnsupported
+	}
+
+	result := &LessonBeautify{LessonID: lesson.ID, Fields: map[string]string{}}
+	req := &UpdateLessonRequest{}
+	for _, field := range []struct {
+		name   string
+		raw    string
+		target **string
+	}{
+		{"objectives", lesson.Objectives, &req.Objectives},
+		{"content", lesson.Content, &req.Content},
+		{"activities", lesson.Activities, &req.Activities},
+		{"assessment", lesson.Assessment, &req.Assessment},
+		{"resources", lesson.Resources, &req.Resources},
+	} {
+		text, ok := lessonPlainText(field.raw)
+		if !ok {
+			continue
+		}
+		beautified := beautifyLessonText(text)
+		if beautified == text {
+			continue
+		}
+		value := beautified
+		*field.target = &value
+		result.Fields[field.name] = beautified
+	}
+
+	result.Changed = len(result.Fields) > 0
+	if preview || !result.Changed {
+		return result, nil
+	}
+	if _, err := s.Update(ctx, lessonID, userID, req); err != nil {
+		return nil, err
+	}
+	result.Applied = true
+	return result, nil
+}
+
+// lessonPlainText 取出字段中的纯文本：{"text": ...} 包装的取 text，普通文本原样返回；
+// 结构化 JSON（如环节数组）不是排版对象，返回 false
+func lessonPlainText(raw string) (string, bool) {
+	trimmed := strings.TrimSpace(raw)
+	if trimmed == "" {
+		return "", false
+	}
+	if strings.HasPrefix(trimmed, "{") || strings.HasPrefix(trimmed, "[") {
+		var value interface{}
+		if err := json.Unmarshal([]byte(trimmed), &value); err == nil {
+			obj, isObject := value.(map[string]interface{})
+			if !isObject {
+				return "", false
+			}
+			text, isText := obj["text"].(string)
+			if !isText || len(obj) != 1 {
+				return "", false
+			}
+			return text, true
+		}
+	}
+	return raw, true
+}
+
+// beautifyLessonText 逐行规范化文本，代码块原样保留；连续空行合并为一行，首尾空行去掉
+func beautifyLessonText(text string) string {
+	lines := strings.Split(strings.ReplaceAll(strings.ReplaceAll(text, "\r\n", "\n"), "\r", "\n"), "\n")
+	out := make([]string, 0, len(lines))
+	inCode := false
+	blank := 0
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inCode = !inCode
+			blank = 0
+			out = append(out, strings.TrimRight(line, " \t"))
+			continue
+		}
+		if inCode {
+			out = append(out, line)
+			continue
+		}
+
+		line = beautifyLine(line)
+		if line == "" {
+			blank++
+			if blank > 1 || len(out) == 0 {
+				continue
+			}
+		} else {
+			blank = 0
+		}
+		out = append(out, line)
+	}
+	return strings.TrimRight(strings.Join(out, "\n"), "\n")
+}
+
+// beautifyLine 规范化单行：全角字母数字转半角、中文语境标点转全角、统一列表符号
+func beautifyLine(line string) string {
+	line = strings.TrimRight(toHalfWidthAlnum(line), " \t\u3000")
+	if strings.TrimSpace(line) == "" {
+		return ""
+	}
+
+	if m := orderedLine.FindStringSubmatch(line); m != nil {
+		number := m[2] + m[3]
+		body := m[4]
+		// “1.5 米”这类小数不是列表
+		if !(strings.HasPrefix(line[len(m[1]):], number+".") && body[0] >= '0' && body[0] <= '9') {
+			line = listIndent(m[1]) + number + ". " + body
+		}
+	} else if m := bulletLine.FindStringSubmatch(line); m != nil {
+		line = listIndent(m[1]) + "- " + m[2]
+	}
+
+	line = halfWidthPunctAfterHan.ReplaceAllStringFunc(line, func(match string) string {
+		m := halfWidthPunctAfterHan.FindStringSubmatch(match)
+		return m[1] + fullWidthPunct[m[2]]
+	})
+	line = replaceAllRepeated(periodAfterHan, line, "${1}。${2}")
+	line = parenWithHan.ReplaceAllString(line, "（${1}）")
+	line = spaceBeforeFullPunct.ReplaceAllString(line, "${1}")
+	// 保留行首缩进，只清理标点后面的空白
+	indent := len(line) - len(strings.TrimLeft(line, " \t"))
+	return line[:indent] + spaceAfterFullPunct.ReplaceAllString(line[indent:], "${1}")
+}
+
+// replaceAllRepeated 相邻匹配共用分隔字符时一次替换不完整，重复替换直到不再变化
+func replaceAllRepeated(pattern *regexp.Regexp, s, repl string) string {
+	for {
+		next := pattern.ReplaceAllString(s, repl)
+		if next == s {
+			return s
+		}
+		s = next
+	}
+}
+
+// listIndent 列表缩进统一为每级两个空格：制表符与全角空格各算一级
+func listIndent(indent string) string {
+	level := 0
+	spaces := 0
+	for _, r := range indent {
+		switch r {
+		case '\t', '　':
+			level++
+		default:
+			spaces++
+		}
+	}
+	level += spaces / 2
+	return strings.Repeat("  ", level)
+}
+
+// toHalfWidthAlnum 全角字母、数字转为半角，全角标点保持不变
+func toHalfWidthAlnum(s string) string {
+	return strings.Map(func(r rune) rune {
+		if (r >= '０' && r <= '９') || (r >= 'Ａ' && r <= 'Ｚ') || (r >= 'ａ' && r <= 'ｚ') {
+			return r - 0xFEE0
+		}
+		return r
+	}, s)
+}
@@ -0,0 +1,180 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestBeautifyLine(t *testing.T) {
+	tests := []struct {
+		name string
+		line string
+		want string
+	}{
+		{name: "punctuation after han", line: "同学们,请思考:为什么?", want: "同学们，请思考：为什么？"},
+		{name: "exclamation", line: "结束!", want: "结束！"},
+		{name: "sentence period", line: "本节课结束.下节课再见", want: "本节课结束。下节课再见"},
+		{name: "decimal kept", line: "圆周率约为3.14", want: "圆周率约为3.14"},
+		{name: "url kept", line: "访问 example.com 获取资料", want: "访问 example.com 获取资料"},
+		{name: "english kept", line: "Hello, world.", want: "Hello, world."},
+		{name: "han parentheses", line: "引入(课件展示)新课", want: "引入（课件展示）新课"},
+		{name: "latin parentheses kept", line: "使用 (PPT) 展示", want: "使用 (PPT) 展示"},
+		{name: "spaces around full punct", line: "学生 ， 回答 。", want: "学生，回答。"},
+		{name: "full-width alnum", line: "ＡＢＣ１２３，全角字母", want: "ABC123，全角字母"},
+		{name: "trailing spaces", line: "行尾空白 \t　", want: "行尾空白"},
+		{name: "blank", line: " \t ", want: ""},
+		{name: "dot bullet", line: "• 要点一", want: "- 要点一"},
+		{name: "star bullet", line: "* 要点二", want: "- 要点二"},
+		{name: "bold kept", line: "**加粗**内容", want: "**加粗**内容"},
+		{name: "tab indented bullet", line: "\t- 子要点", want: "  - 子要点"},
+		{name: "full-width indented bullet", line: "　　● 要点", want: "    - 要点"},
+		{name: "ordered with dun", line: "1、导入", want: "1. 导入"},
+		{name: "ordered in parentheses", line: "（2）探究", want: "2. 探究"},
+		{name: "ordered with paren", line: "3) 练习", want: "3. 练习"},
+		{name: "ordered already normal", line: "4. 小结", want: "4. 小结"},
+		{name: "decimal not a list", line: "1.5 米的绳子", want: "1.5 米的绳子"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := beautifyLine(tt.line); got != tt.want {
+				t.Errorf("beautifyLine(%q) = %q, want %q", tt.line, got, tt.want)
+			}
+			// 排版结果再次排版不再变化
+			if again := beautifyLine(tt.want); again != tt.want {
+				t.Errorf("beautifyLine(%q) = %q, want it unchanged", tt.want, again)
+			}
+		})
+	}
+}
+
+func TestBeautifyLessonText(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{name: "blank lines collapsed", text: "\n\n第一段\r\n\r\n\r\n第二段\n\n", want: "第一段\n\n第二段"},
+		{name: "code block kept", text: "示例:\n```  \n  x = 1 ,y = 2.  \n\n\n```\n结束.", want: "示例：\n```\n  x = 1 ,y = 2.  \n\n\n```\n结束。"},
+		{name: "mixed lists", text: "步骤:\n1) 导入\n• 观察\n\t* 记录", want: "步骤：\n1. 导入\n- 观察\n  - 记录"},
+		{name: "already clean", text: "目标：理解有理数。\n\n- 要点", want: "目标：理解有理数。\n\n- 要点"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := beautifyLessonText(tt.text); got != tt.want {
+				t.Errorf("beautifyLessonText(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestLessonPlainText(t *testing.T) {
+	tests := []struct {
+		raw    string
+		want   string
+		wantOK bool
+	}{
+		{raw: `{"text": "理解有理数"}`, want: "理解有理数", wantOK: true},
+		{raw: "普通文本", want: "普通文本", wantOK: true},
+		{raw: "{未闭合的括号", want: "{未闭合的括号", wantOK: true},
+		{raw: `{"text": "a", "steps": []}`},
+		{raw: `[{"name": "导入"}]`},
+		{raw: "  "},
+	}
+
+	for _, tt := range tests {
+		got, ok := lessonPlainText(tt.raw)
+		if got != tt.want || ok != tt.wantOK {
+			t.Errorf("lessonPlainText(%q) = (%q, %v), want (%q, %v)", tt.raw, got, ok, tt.want, tt.wantOK)
+		}
+	}
+}
+
+func TestBeautify(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	newLesson := func() *model.Lesson {
+		lesson := publishableLesson(userID, model.LessonStatusDraft)
+		lesson.Content = wrapLessonText("导入:\n\n\n1、复习旧知")
+		lesson.Activities = `[{"name": "小组讨论"}]`
+		return lesson
+	}
+
+	t.Run("preview leaves lesson unchanged", func(t *testing.T) {
+		lesson := newLesson()
+		repo := newFakeLessonRepo(lesson)
+		svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+		result, err := svc.Beautify(ctx, lesson.ID, userID, true)
+		if err != nil {
+			t.Fatalf("Beautify() error = %v", err)
+		}
+		if !result.Changed || result.Applied || result.Fields["content"] != "导入：\n\n1. 复习旧知" {
+			t.Errorf("Beautify() = %+v, want changed content preview", result)
+		}
+		// 已规范的字段与结构化字段不出现在结果中
+		for _, field := range []string{"objectives", "activities"} {
+			if _, ok := result.Fields[field]; ok {
+				t.Errorf("Fields contains %s, want only changed plain-text fields", field)
+			}
+		}
+		if stored := repo.lessons[lesson.ID]; stored.Content != wrapLessonText("导入:\n\n\n1、复习旧知") {
+			t.Errorf("stored content = %s, want unchanged after preview", stored.Content)
+		}
+	})
+
+	t.Run("apply saves through update", func(t *testing.T) {
+		lesson := newLesson()
+		repo := newFakeLessonRepo(lesson)
+		svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+		result, err := svc.Beautify(ctx, lesson.ID, userID, false)
+		if err != nil {
+			t.Fatalf("Beautify() error = %v", err)
+		}
+		if !result.Applied {
+			t.Errorf("Beautify() = %+v, want applied", result)
+		}
+		stored := repo.lessons[lesson.ID]
+		if stored.Content != wrapLessonText("导入：\n\n1. 复习旧知") || stored.Activities != lesson.Activities {
+			t.Errorf("stored = (%s, %s), want beautified content and untouched activities", stored.Content, stored.Activities)
+		}
+	})
+
+	t.Run("nothing to change", func(t *testing.T) {
+		lesson := publishableLesson(userID, model.LessonStatusDraft)
+		repo := newFakeLessonRepo(lesson)
+		svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+		result, err := svc.Beautify(ctx, lesson.ID, userID, false)
+		if err != nil {
+			t.Fatalf("Beautify() error = %v", err)
+		}
+		if result.Changed || result.Applied || len(result.Fields) != 0 {
+			t.Errorf("Beautify() = %+v, want no changes and nothing saved", result)
+		}
+	})
+
+	t.Run("rejected", func(t *testing.T) {
+		html := newLesson()
+		html.ContentType = model.LessonContentTypeHTML
+		lesson := newLesson()
+		svc := newTestLessonService(newFakeLessonRepo(html, lesson), &fakeVersionRepo{})
+
+		if _, err := svc.Beautify(ctx, html.ID, userID, true); !errors.Is(err, ErrBeautifyUnsupported) {
+			t.Errorf("Beautify(html) error = %v, want ErrBeautifyUnsupported", err)
+		}
+		if _, err := svc.Beautify(ctx, lesson.ID, uuid.New(), true); !errors.Is(err, ErrUnauthorized) {
+			t.Errorf("Beautify() by other user error = %v, want ErrUnauthorized", err)
+		}
+		if _, err := svc.Beautify(ctx, uuid.New(), userID, true); !errors.Is(err, ErrLessonNotFound) {
+			t.Errorf("Beautify(missing) error = %v, want ErrLessonNotFound", err)
+		}
+	})
+}
@@ -0,0 +1,50 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+)
+
+// normalizeCoAuthors 去除空白与重复署名，保持传入顺序
+func normalizeCoAuthors(names []string) []string {
+	result := make([]string, 0, len(names))
+	seen := make(map[string]bool, len(names))
+	for _, name := range names {
+		name = strings.TrimSpace(name)
+		if name == "" || seen[name] {
+			continue
+		}
+		seen[name] = true
+		result = append(result, name)
+	}
+	return result
+}
+
+// encodeCoAuthors 序列化联合署名，写入 jsonb 列
+func encodeCoAuthors(names []string) string {
+	data, _ := json.Marshal(normalizeCoAuthors(names))
+	return string(data)
+}
+
+// decodeCoAuthors 解析联合署名，空值或格式错误时返回空列表
+func decodeCoAuthors(raw string) []string {
+	names := []string{}
+	if raw != "" {
+		_ = json.Unmarshal([]byte(raw), &names)
+	}
+	return names
+}
+
+// lessonAuthors 署名列表：创建者在前，联合署名依次在后，与创建者同名的署名只保留一次
+func lessonAuthors(authorName string, coAuthors []string) []string {
+	authors := make([]string, 0, len(coAuthors)+1)
+	if authorName != "" {
+		authors = append(authors, authorName)
+	}
+	for _, name := range coAuthors {
+		if name != authorName {
+			authors = append(authors, name)
+		}
+	}
+	return authors
+}
@@ -0,0 +1,90 @@
+package service
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestLessonCoAuthors(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	author := &model.User{ID: authorID, Username: "zhang", FullName: "张老师"}
+	empty := []string{}
+
+	tests := []struct {
+		name        string
+		create      []string
+		update      *[]string
+		wantCo      []string
+		wantAuthors []string
+	}{
+		{
+			name:        "no co-authors",
+			wantCo:      []string{},
+			wantAuthors: []string{"张老师"},
+		},
+		{
+			name:        "blanks and duplicates are dropped",
+			create:      []string{" 李老师 ", "", "王老师", "李老师"},
+			wantCo:      []string{"李老师", "王老师"},
+			wantAuthors: []string{"张老师", "李老师", "王老师"},
+		},
+		{
+			name:        "creator listed once",
+			create:      []string{"王老师", "张老师"},
+			wantCo:      []string{"王老师", "张老师"},
+			wantAuthors: []string{"张老师", "王老师"},
+		},
+		{
+			name:        "update without co-authors keeps them",
+			create:      []string{"李老师"},
+			update:      nil,
+			wantCo:      []string{"李老师"},
+			wantAuthors: []string{"张老师", "李老师"},
+		},
+		{
+			name:        "update with empty list clears them",
+			create:      []string{"李老师"},
+			update:      &empty,
+			wantCo:      []string{},
+			wantAuthors: []string{"张老师"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			repo := newFakeLessonRepo()
+			svc := newTestLessonService(repo, nil)
+
+			lesson, err := svc.Create(ctx, authorID, &CreateLessonRequest{Title: "联合备课", Subject: "数学", Grade: "七年级", CoAuthors: tt.create})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if tt.update != nil {
+				if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{CoAuthors: tt.update}); err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+			}
+			repo.lessons[lesson.ID].User = author
+
+			detail, err := svc.GetByID(ctx, lesson.ID, nil)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if strings.Join(detail.CoAuthors, ",") != strings.Join(tt.wantCo, ",") || detail.CoAuthors == nil {
+				t.Errorf("co_authors = %q, want %q", detail.CoAuthors, tt.wantCo)
+			}
+			if strings.Join(detail.Authors, ",") != strings.Join(tt.wantAuthors, ",") {
+				t.Errorf("detail authors = %q, want %q", detail.Authors, tt.wantAuthors)
+			}
+			if item := lessonListItem(*repo.lessons[lesson.ID]); strings.Join(item.Authors, ",") != strings.Join(tt.wantAuthors, ",") {
+				t.Errorf("list authors = %q, want %q", item.Authors, tt.wantAuthors)
+			}
+		})
+	}
+}
@@ -0,0 +1,169 @@
+package service
+
+import (
+	"context"
+	"strings"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ComplianceItem 单条课标规则的检查结果
+type ComplianceItem struct {
+	RuleID     string   `json:"rule_id"`
+	Name       string   `json:"name"`
+	Passed     bool     `json:"passed"`
+	Fields     []string `json:"fields"`
+	Reason     string   `json:"reason,omitempty"`
+	Suggestion string   `json:"suggestion,omitempty"`
+}
+
+// LessonComplianceReport 教案课标合规检查结果，Missing 为未满足的规则
+type LessonComplianceReport struct {
+	LessonID    uuid.UUID        `json:"lesson_id"`
+	Compliant   bool             `json:"compliant"`
+	TotalRules  int              `json:"total_rules"`
+	PassedRules int              `json:"passed_rules"`
+	Missing     []ComplianceItem `json:"missing"`
+	Items       []ComplianceItem `json:"items"`
+}
+
+// defaultComplianceRules 未配置 lesson.compliance_rules 时使用的内置规则
+var defaultComplianceRules = []config.ComplianceRule{
+	{
+		ID: "objective_knowledge", Name: "教学目标包含知识与技能维度",
+		Fields: []string{"objectives"}, Keywords: []string{"知识与技能", "知识目标", "知识与能力"},
+		Suggestion: "在教学目标中写明学生应掌握的知识与技能。",
+	},
+	{
+		ID: "objective_process", Name: "教学目标包含过程与方法维度",
+		Fields: []string{"objectives"}, Keywords: []string{"过程与方法", "过程目标", "方法目标"},
+		Suggestion: "在教学目标中写明学生经历的学习过程与掌握的方法。",
+	},
+	{
+		ID: "objective_emotion", Name: "教学目标包含情感态度价值观维度",
+		Fields: []string{"objectives"}, Keywords: []string{"情感态度", "价值观", "情感目标"},
+		Suggestion: "在教学目标中补充情感态度与价值观目标。",
+	},
+	{
+		ID: "key_difficult_points", Name: "明确教学重难点",
+		Fields: []string{"objectives", "content"}, Keywords: []string{"重点", "难点"},
+		Suggestion: "单独列出本课的教学重点与教学难点。",
+	},
+	{
+		ID: "teaching_process", Name: "包含完整教学过程",
+		Fields: []string{"content", "activities"}, MinLength: 50,
+		Suggestion: "补充导入、新授、练习、小结等教学环节。",
+	},
+	{
+		ID: "assessment", Name: "包含教学评价环节",
+		Fields: []string{"assessment"}, Keywords: []string{"评价", "检测", "反馈", "达成"}, MinLength: 10,
+		Suggestion: "补充与教学目标对应的评价方式和评价标准。",
+	},
+	{
+		ID: "homework", Name: "布置课后作业",
+		Fields: []string{"assessment", "content", "activities"}, Keywords: []string{"作业"},
+		Suggestion: "补充分层的课后作业或拓展任务。",
+	},
+	{
+		ID: "resources", Name: "列出教学资源",
+		Fields:     []string{"resources"},
+		Suggestion: "列出课件、教具、学习单等教学资源。",
+	},
+}
+
+// CheckCompliance 对照课标规则检查教案，返回缺失项；已发布教案所有人可查，草稿仅作者可查
+func (s *lessonService) CheckCompliance(ctx context.Context, lessonID, userID uuid.UUID) (*LessonComplianceReport, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+
+	rules := s.complianceRules
+	if len(rules) == 0 {
+		rules = defaultComplianceRules
+	}
+	return checkLessonCompliance(lesson, rules), nil
+}
+
+func checkLessonCompliance(lesson *model.Lesson, rules []config.ComplianceRule) *LessonComplianceReport {
+	fieldTexts := map[string]string{
+		"objectives": normalizeLessonText(lesson.Objectives),
+		"content":    normalizeLessonText(lesson.Content),
+		"activities": normalizeLessonText(lesson.Activities),
+		"assessment": normalizeLessonText(lesson.Assessment),
+		"resources":  normalizeLessonText(lesson.Resources),
+	}
+
+	report := &LessonComplianceReport{
+		LessonID: lesson.ID,
+		Missing:  make([]ComplianceItem, 0),
+		Items:    make([]ComplianceItem, 0, len(rules)),
+	}
+	for _, rule := range rules {
+		if !complianceRuleApplies(rule, lesson.Subject) {
+			continue
+		}
+
+		fields := rule.Fields
+		if len(fields) == 0 {
+			fields = config.ComplianceRuleFields
+		}
+		parts := make([]string, 0, len(fields))
+		for _, field := range fields {
+			if text := fieldTexts[field]; text != "" {
+				parts = append(parts, text)
+			}
+		}
+		item := ComplianceItem{
+			RuleID:     rule.ID,
+			Name:       firstNonEmpty(rule.Name, rule.ID),
+			Fields:     fields,
+			Suggestion: rule.Suggestion,
+		}
+		item.Reason = complianceFailure(strings.Join(parts, "\n"), rule)
+		item.Passed = item.Reason == ""
+
+		report.Items = append(report.Items, item)
+		report.TotalRules++
+		if item.Passed {
+			report.PassedRules++
+		} else {
+			report.Missing = append(report.Missing, item)
+		}
+	}
+	report.Compliant = len(report.Missing) == 0
+	return report
+}
+
+// complianceFailure 返回规则未满足的原因，满足时返回空字符串
+func complianceFailure(text string, rule config.ComplianceRule) string {
+	if strings.TrimSpace(text) == "" {
+		return "相关内容为空"
+	}
+	if rule.MinLength > 0 && len([]rune(text)) < rule.MinLength {
+		return "相关内容过于简略"
+	}
+	if len(rule.Keywords) > 0 && !containsAnyKeyword(text, rule.Keywords) {
+		return "未找到相关表述：" + strings.Join(rule.Keywords, " / ")
+	}
+	return ""
+}
+
+func complianceRuleApplies(rule config.ComplianceRule, subject string) bool {
+	if len(rule.Subjects) == 0 {
+		return true
+	}
+	subject = strings.TrimSpace(subject)
+	for _, item := range rule.Subjects {
+		if strings.EqualFold(strings.TrimSpace(item), subject) {
+			return true
+		}
+	}
+	return false
+}
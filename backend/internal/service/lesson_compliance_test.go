@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// compliantLesson 满足全部内置课标规则的教案
+func compliantLesson(userID uuid.UUID) *model.Lesson {
+	return &model.Lesson{
+		UserID:  userID,
+		Title:   "有理数的加法",
+		Subject: "数学",
+		Grade:   "七年级",
+		Objectives: wrapLessonText("知识与技能：掌握有理数加法法则。\n过程与方法：经历从具体情境抽象法则的过程。\n" +
+			"情感态度与价值观：体会分类讨论思想。\n教学重点：加法法则；教学难点：异号两数相加。"),
+		Content: wrapLessonText("一、情境导入：用温度变化引出正负数相加。二、新课讲授：分同号、异号、与零相加三种情况归纳法则。" +
+			"三、练习巩固：完成课本例题。四、课堂小结：学生复述法则。"),
+		Activities: "小组讨论温度变化问题",
+		Assessment: "课堂评价：通过随堂检测反馈法则掌握情况。课后作业：习题 1.3 第 1-4 题。",
+		Resources:  "课件、温度计教具",
+		Status:     model.LessonStatusDraft,
+	}
+}
+
+func TestCheckLessonComplianceDefaultRules(t *testing.T) {
+	tests := []struct {
+		name        string
+		modify      func(l *model.Lesson)
+		wantMissing []string
+	}{
+		{name: "complete lesson", modify: func(l *model.Lesson) {}},
+		{
+			name: "objectives lack emotion dimension",
+			modify: func(l *model.Lesson) {
+				l.Objectives = wrapLessonText("知识与技能：掌握法则。过程与方法：归纳法则。重点：法则；难点：异号相加。")
+			},
+			wantMissing: []string{"objective_emotion"},
+		},
+		{
+			name: "no assessment and no resources",
+			modify: func(l *model.Lesson) {
+				l.Assessment = ""
+				l.Resources = ""
+			},
+			wantMissing: []string{"assessment", "homework", "resources"},
+		},
+		{
+			name: "teaching process too short",
+			modify: func(l *model.Lesson) {
+				l.Content = wrapLessonText("讲授法则")
+				l.Activities = ""
+			},
+			wantMissing: []string{"teaching_process"},
+		},
+		{
+			name: "empty lesson misses every rule",
+			modify: func(l *model.Lesson) {
+				*l = model.Lesson{Subject: "数学"}
+			},
+			wantMissing: []string{"objective_knowledge", "objective_process", "objective_emotion", "key_difficult_points", "teaching_process", "assessment", "homework", "resources"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := compliantLesson(uuid.New())
+			tt.modify(lesson)
+
+			report := checkLessonCompliance(lesson, defaultComplianceRules)
+			var missing []string
+			for _, item := range report.Missing {
+				missing = append(missing, item.RuleID)
+				if item.Reason == "" || item.Suggestion == "" {
+					t.Errorf("missing item %s has no reason or suggestion", item.RuleID)
+				}
+			}
+			if !reflect.DeepEqual(missing, tt.wantMissing) {
+				t.Errorf("missing = %v, want %v", missing, tt.wantMissing)
+			}
+			if report.Compliant != (len(tt.wantMissing) == 0) {
+				t.Errorf("Compliant = %v with %d missing", report.Compliant, len(tt.wantMissing))
+			}
+			if report.TotalRules != len(defaultComplianceRules) || report.PassedRules != report.TotalRules-len(tt.wantMissing) {
+				t.Errorf("rules total = %d passed = %d", report.TotalRules, report.PassedRules)
+			}
+		})
+	}
+}
+
+func TestCheckLessonComplianceCustomRules(t *testing.T) {
+	rules := []config.ComplianceRule{
+		{ID: "experiment", Name: "包含实验环节", Fields: []string{"activities"}, Keywords: []string{"实验"}, Subjects: []string{"物理", "化学"}},
+		{ID: "long_assessment", Fields: []string{"assessment"}, MinLength: 200},
+		{ID: "any_field", Keywords: []string{"温度计"}},
+	}
+
+	lesson := compliantLesson(uuid.New())
+	report := checkLessonCompliance(lesson, rules)
+
+	// 学科不符的规则不计入；未指定字段时检查全部字段；未命名时以 ID 作为名称
+	if report.TotalRules != 2 {
+		t.Fatalf("TotalRules = %d, want 2 (subject-scoped rule skipped)", report.TotalRules)
+	}
+	if len(report.Missing) != 1 || report.Missing[0].RuleID != "long_assessment" || report.Missing[0].Name != "long_assessment" {
+		t.Errorf("Missing = %+v, want only long_assessment", report.Missing)
+	}
+	if report.Missing[0].Reason != "相关内容过于简略" {
+		t.Errorf("Reason = %q", report.Missing[0].Reason)
+	}
+
+	lesson.Subject = "物理"
+	report = checkLessonCompliance(lesson, rules)
+	if report.TotalRules != 3 || report.Missing[0].RuleID != "experiment" {
+		t.Errorf("physics report = %+v, want experiment rule applied and missing", report)
+	}
+}
+
+func TestCheckCompliancePermissions(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	draft := compliantLesson(ownerID)
+	published := compliantLesson(ownerID)
+	published.Status = model.LessonStatusPublished
+	svc := newTestLessonService(newFakeLessonRepo(draft, published), nil)
+
+	if _, err := svc.CheckCompliance(ctx, draft.ID, ownerID); err != nil {
+		t.Errorf("CheckCompliance() by owner error = %v", err)
+	}
+	if _, err := svc.CheckCompliance(ctx, draft.ID, uuid.New()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("CheckCompliance() on others' draft error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.CheckCompliance(ctx, published.ID, uuid.New()); err != nil {
+		t.Errorf("CheckCompliance() on published lesson error = %v", err)
+	}
+	if _, err := svc.CheckCompliance(ctx, uuid.New(), ownerID); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("CheckCompliance() on missing lesson error = %v, want ErrLessonNotFound", err)
+	}
+}
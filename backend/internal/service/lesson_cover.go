@@ -0,0 +1,258 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"html"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf8"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// 封面尺寸为 16:9，与列表卡片的展示比例一致
+	coverWidth  = 1200
+	coverHeight = 675
+	// coverPadding 文字区域左右边距
+	coverPadding = 96
+	// coverFormat 封面格式：SVG 由浏览器按系统中文字体渲染文字，服务端不需要内置字体
+	coverFormat = "svg"
+	// coverFontFamily 优先使用常见的中文无衬线字体
+	coverFontFamily = "'PingFang SC','Microsoft YaHei','Noto Sans CJK SC',sans-serif"
+	// coverTitleMaxLines 标题最多显示行数，超出部分以省略号结尾
+	coverTitleMaxLines = 3
+)
+
+var ErrCoverNotFound = errors.New("教案还没有封面")
+
+// coverPalette 封面配色：背景渐变起止色与角标强调色
+type coverPalette struct {
+	From   string `json:"from"`
+	To     string `json:"to"`
+	Accent string `json:"accent"`
+}
+
+// subjectPalettes 学科配色，按顺序匹配学科名中的关键字
+var subjectPalettes = []struct {
+	Subject string
+	Palette coverPalette
+}{
+	{"语文", coverPalette{From: "#C0392B", To: "#E67E22", Accent: "#FDEBD0"}},
+	{"数学", coverPalette{From: "#1F4E9E", To: "#3A8DDE", Accent: "#D6EAF8"}},
+	{"英语", coverPalette{From: "#6C3483", To: "#A569BD", Accent: "#EBDEF0"}},
+	{"物理", coverPalette{From: "#1A237E", To: "#3949AB", Accent: "#C5CAE9"}},
+	{"化学", coverPalette{From: "#00695C", To: "#26A69A", Accent: "#B2DFDB"}},
+	{"生物", coverPalette{From: "#2E7D32", To: "#66BB6A", Accent: "#DCEDC8"}},
+	{"地理", coverPalette{From: "#006064", To: "#0097A7", Accent: "#B2EBF2"}},
+	{"历史", coverPalette{From: "#5D4037", To: "#8D6E63", Accent: "#EFEBE9"}},
+	{"政治", coverPalette{From: "#B71C1C", To: "#E53935", Accent: "#FFCDD2"}},
+	{"道德与法治", coverPalette{From: "#B71C1C", To: "#E53935", Accent: "#FFCDD2"}},
+	{"音乐", coverPalette{From: "#AD1457", To: "#EC407A", Accent: "#F8BBD0"}},
+	{"美术", coverPalette{From: "#E65100", To: "#FFA726", Accent: "#FFE0B2"}},
+	{"体育", coverPalette{From: "#33691E", To: "#7CB342", Accent: "#DCEDC8"}},
+	{"科学", coverPalette{From: "#0D47A1", To: "#26C6DA", Accent: "#B3E5FC"}},
+}
+
+// fallbackPalettes 未配置学科按名称散列选取，同一学科的封面颜色保持稳定
+var fallbackPalettes = []coverPalette{
+	{From: "#37474F", To: "#607D8B", Accent: "#CFD8DC"},
+	{From: "#283593", To: "#5C6BC0", Accent: "#C5CAE9"},
+	{From: "#4E342E", To: "#A1887F", Accent: "#D7CCC8"},
+	{From: "#00838F", To: "#4DD0E1", Accent: "#B2EBF2"},
+}
+
+// LessonCover 自动生成的封面
+type LessonCover struct {
+	LessonID uuid.UUID    `json:"lesson_id"`
+	URL      string       `json:"url"`
+	Format   string       `json:"format"`
+	Width    int          `json:"width"`
+	Height   int          `json:"height"`
+	Palette  coverPalette `json:"palette"`
+}
+
+// GenerateCover 根据学科与标题生成占位封面并设为教案封面（仅作者），重复调用会覆盖旧封面
+func (s *lessonService) GenerateCover(ctx context.Context, lessonID, userID uuid.UUID) (*LessonCover, error) {
+	if s.uploadDir == "" {
+		return nil, errors.New("未配置上传目录，无法保存封面")
+	}
+
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	palette := subjectPalette(lesson.Subject)
+	data := renderLessonCover(lesson, palette)
+
+	dir := filepath.Join(s.uploadDir, "covers")
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return nil, fmt.Errorf("创建封面目录失败: %w", err)
+	}
+	name := lesson.ID.String() + "." + coverFormat
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0644); err != nil {
+		return nil, fmt.Errorf("保存封面失败: %w", err)
+	}
+
+	coverURL := "/uploads/covers/" + name
+	if err := s.lessonRepo.UpdateCover(ctx, lesson.ID, coverURL); err != nil {
+		return nil, err
+	}
+
+	return &LessonCover{
+		LessonID: lesson.ID,
+		URL:      coverURL,
+		Format:   coverFormat,
+		Width:    coverWidth,
+		Height:   coverHeight,
+		Palette:  palette,
+	}, nil
+}
+
+// GetCoverURL 获取教案封面地址
+func (s *lessonService) GetCoverURL(ctx context.Context, lessonID uuid.UUID) (string, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return "", ErrLessonNotFound
+	}
+	if lesson.CoverURL == "" {
+		return "", ErrCoverNotFound
+	}
+	return lesson.CoverURL, nil
+}
+
+// subjectPalette 按学科取配色，学科名包含关键字即可匹配，如“高中数学”
+func subjectPalette(subject string) coverPalette {
+	subject = strings.TrimSpace(subject)
+	for _, item := range subjectPalettes {
+		if strings.Contains(subject, item.Subject) {
+			return item.Palette
+		}
+	}
+
+	h := fnv.New32a()
+	_, _ = h.Write([]byte(subject))
+	return fallbackPalettes[h.Sum32()%uint32(len(fallbackPalettes))]
+}
+
+// renderLessonCover 渲染 SVG 封面：渐变背景、装饰圆、学科角标、标题与年级课时
+func renderLessonCover(lesson *model.Lesson, palette coverPalette) []byte {
+	subject := strings.TrimSpace(lesson.Subject)
+	if subject == "" {
+		subject = "教案"
+	}
+
+	// 两行放不下时缩小字号，最多三行
+	fontSize := 72
+	lines := wrapCoverTitle(lesson.Title, float64(coverWidth-2*coverPadding)/float64(fontSize), coverTitleMaxLines)
+	if len(lines) > 2 {
+		fontSize = 60
+		lines = wrapCoverTitle(lesson.Title, float64(coverWidth-2*coverPadding)/float64(fontSize), coverTitleMaxLines)
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, `<svg xmlns="http://www.w3.org/2000/svg" width="%d" height="%d" viewBox="0 0 %d %d">`,
+		coverWidth, coverHeight, coverWidth, coverHeight)
+	fmt.Fprintf(&sb, `<defs><linearGradient id="bg" x1="0" y1="0" x2="1" y2="1">`+
+		`<stop offset="0" stop-color="%s"/><stop offset="1" stop-color="%s"/></linearGradient></defs>`,
+		palette.From, palette.To)
+	fmt.Fprintf(&sb, `<rect width="%d" height="%d" fill="url(#bg)"/>`, coverWidth, coverHeight)
+	sb.WriteString(`<circle cx="1080" cy="110" r="220" fill="#FFFFFF" fill-opacity="0.08"/>`)
+	sb.WriteString(`<circle cx="1150" cy="610" r="150" fill="#FFFFFF" fill-opacity="0.06"/>`)
+
+	// 学科角标，宽度按文字估算
+	badgeWidth := int(textUnits(subject)*28) + 48
+	fmt.Fprintf(&sb, `<rect x="%d" y="88" width="%d" height="52" rx="26" fill="%s"/>`, coverPadding, badgeWidth, palette.Accent)
+	fmt.Fprintf(&sb, `<text x="%d" y="124" font-family="%s" font-size="28" font-weight="bold" fill="%s">%s</text>`,
+		coverPadding+24, coverFontFamily, palette.From, html.EscapeString(subject))
+
+	// 标题垂直居中于角标与底部信息之间
+	lineHeight := fontSize * 5 / 4
+	top := (coverHeight-len(lines)*lineHeight)/2 + fontSize
+	for i, line := range lines {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="%s" font-size="%d" font-weight="bold" fill="#FFFFFF">%s</text>`,
+			coverPadding, top+i*lineHeight, coverFontFamily, fontSize, html.EscapeString(line))
+	}
+
+	if footer := coverFooter(lesson); footer != "" {
+		fmt.Fprintf(&sb, `<text x="%d" y="%d" font-family="%s" font-size="30" fill="#FFFFFF" fill-opacity="0.85">%s</text>`,
+			coverPadding, coverHeight-80, coverFontFamily, html.EscapeString(footer))
+	}
+	sb.WriteString(`</svg>`)
+	return []byte(sb.String())
+}
+
+// coverFooter 底部信息：年级与课时
+func coverFooter(lesson *model.Lesson) string {
+	parts := make([]string, 0, 2)
+	if grade := strings.TrimSpace(lesson.Grade); grade != "" {
+		parts = append(parts, grade)
+	}
+	if lesson.Duration > 0 {
+		parts = append(parts, fmt.Sprintf("%d 分钟", lesson.Duration))
+	}
+	return strings.Join(parts, " · ")
+}
+
+// wrapCoverTitle 按估算宽度折行，每行不超过 maxUnits 个全角字宽，超出 maxLines 时末行以省略号结尾
+func wrapCoverTitle(title string, maxUnits float64, maxLines int) []string {
+	title = strings.Join(strings.Fields(title), " ")
+	if title == "" {
+		return []string{"未命名教案"}
+	}
+
+	var lines []string
+	var current strings.Builder
+	units := 0.0
+	for _, r := range title {
+		w := runeUnits(r)
+		if units+w > maxUnits && current.Len() > 0 {
+			lines = append(lines, strings.TrimSpace(current.String()))
+			current.Reset()
+			units = 0
+		}
+		current.WriteRune(r)
+		units += w
+	}
+	if current.Len() > 0 {
+		lines = append(lines, strings.TrimSpace(current.String()))
+	}
+
+	if len(lines) > maxLines {
+		lines = lines[:maxLines]
+		last := []rune(lines[maxLines-1])
+		// 给省略号腾出一个字宽
+		if len(last) > 1 {
+			last = last[:len(last)-1]
+		}
+		lines[maxLines-1] = string(last) + "…"
+	}
+	return lines
+}
+
+// textUnits 文本的估算宽度（全角字宽为 1）
+func textUnits(s string) float64 {
+	total := 0.0
+	for _, r := range s {
+		total += runeUnits(r)
+	}
+	return total
+}
+
+// runeUnits 单个字符的估算宽度：ASCII 约为半个全角字宽
+func runeUnits(r rune) float64 {
+	if r < utf8.RuneSelf {
+		return 0.55
+	}
+	return 1
+}
@@ -0,0 +1,127 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (r *fakeLessonRepo) UpdateCover(ctx context.Context, id uuid.UUID, coverURL string) error {
+	lesson, ok := r.lessons[id]
+	if !ok {
+		return errRecordNotFound
+	}
+	lesson.CoverURL = coverURL
+	return nil
+}
+
+func TestSubjectPalette(t *testing.T) {
+	math := subjectPalettes[1].Palette
+	if got := subjectPalette("数学"); got != math {
+		t.Errorf("subjectPalette(数学) = %+v, want %+v", got, math)
+	}
+	if got := subjectPalette(" 高中数学 "); got != math {
+		t.Errorf("subjectPalette(高中数学) = %+v, want keyword match %+v", got, math)
+	}
+
+	// 未配置学科的配色稳定且来自备选配色
+	first := subjectPalette("信息技术")
+	if second := subjectPalette("信息技术"); first != second {
+		t.Errorf("fallback palette not stable: %+v vs %+v", first, second)
+	}
+	found := false
+	for _, p := range fallbackPalettes {
+		found = found || p == first
+	}
+	if !found {
+		t.Errorf("subjectPalette(信息技术) = %+v, want one of the fallback palettes", first)
+	}
+}
+
+func TestWrapCoverTitle(t *testing.T) {
+	tests := []struct {
+		name     string
+		title    string
+		maxUnits float64
+		want     []string
+	}{
+		{name: "fits one line", title: "有理数的加法", maxUnits: 10, want: []string{"有理数的加法"}},
+		{name: "wraps by width", title: "有理数的加法与减法", maxUnits: 5, want: []string{"有理数的加", "法与减法"}},
+		{name: "ascii is half width", title: "Unit 1 Hello", maxUnits: 5, want: []string{"Unit 1 He", "llo"}},
+		{name: "extra lines end with ellipsis", title: "一二三四五六七八九十", maxUnits: 3, want: []string{"一二三", "四五六", "七八…"}},
+		{name: "blank title", title: "  ", maxUnits: 10, want: []string{"未命名教案"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := wrapCoverTitle(tt.title, tt.maxUnits, 3); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("wrapCoverTitle() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRenderLessonCover(t *testing.T) {
+	palette := subjectPalette("数学")
+	svg := string(renderLessonCover(&model.Lesson{
+		Title: "<有理数> & 数轴", Subject: "数学", Grade: "七年级", Duration: 45,
+	}, palette))
+
+	for _, want := range []string{
+		`width="1200" height="675"`,
+		`stop-color="` + palette.From + `"`,
+		"&lt;有理数&gt; &amp; 数轴",
+		"七年级 · 45 分钟",
+	} {
+		if !strings.Contains(svg, want) {
+			t.Errorf("cover missing %q:\n%s", want, svg)
+		}
+	}
+	if !strings.HasPrefix(svg, "<svg") || !strings.HasSuffix(svg, "</svg>") {
+		t.Errorf("cover is not a complete svg document")
+	}
+}
+
+func TestGenerateCover(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := &model.Lesson{UserID: userID, Title: "有理数的加法", Subject: "数学", Grade: "七年级"}
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, nil)
+
+	if _, err := svc.GetCoverURL(ctx, lesson.ID); !errors.Is(err, ErrCoverNotFound) {
+		t.Errorf("GetCoverURL() before generate error = %v, want ErrCoverNotFound", err)
+	}
+	if _, err := svc.GenerateCover(ctx, lesson.ID, userID); err == nil {
+		t.Error("GenerateCover() without upload dir should fail")
+	}
+
+	svc.uploadDir = t.TempDir()
+	if _, err := svc.GenerateCover(ctx, lesson.ID, uuid.New()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("GenerateCover() by other user error = %v, want ErrUnauthorized", err)
+	}
+
+	cover, err := svc.GenerateCover(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("GenerateCover() error = %v", err)
+	}
+	wantURL := "/uploads/covers/" + lesson.ID.String() + ".svg"
+	if cover.URL != wantURL || cover.Format != "svg" || cover.Palette != subjectPalette("数学") {
+		t.Errorf("cover = %+v, want svg at %s", cover, wantURL)
+	}
+	data, err := os.ReadFile(filepath.Join(svc.uploadDir, "covers", lesson.ID.String()+".svg"))
+	if err != nil || !strings.Contains(string(data), "有理数的加法") {
+		t.Errorf("cover file = %q, %v, want the rendered title", data, err)
+	}
+	if got, err := svc.GetCoverURL(ctx, lesson.ID); err != nil || got != wantURL {
+		t.Errorf("GetCoverURL() = %q, %v, want %q", got, err, wantURL)
+	}
+}
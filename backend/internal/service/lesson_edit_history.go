@@ -0,0 +1,233 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"sort"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// maxLessonEdits 每个教案保留的编辑操作记录数，即最多可连续撤销的步数
+const maxLessonEdits = 50
+
+var (
+	ErrNothingToUndo = errors.New("没有可撤销的编辑")
+	ErrNothingToRedo = errors.New("没有可重做的编辑")
+)
+
+// LessonEditState 撤销/重做后的教案及编辑栈状态；已发布教案返回草稿视图
+type LessonEditState struct {
+	Lesson  *model.Lesson `json:"lesson"`
+	CanUndo bool          `json:"can_undo"`
+	CanRedo bool          `json:"can_redo"`
+}
+
+// LessonEditHistory 编辑操作记录列表
+type LessonEditHistory struct {
+	Edits   []model.LessonEdit `json:"edits"`
+	CanUndo bool               `json:"can_undo"`
+	CanRedo bool               `json:"can_redo"`
+}
+
+// ListEdits 列出教案最近的编辑操作记录（仅作者）
+func (s *lessonService) ListEdits(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditHistory, error) {
+	if _, err := s.getEditableLesson(ctx, lessonID, userID); err != nil {
+		return nil, err
+	}
+
+	edits, err := s.editRepo.ListByLessonID(ctx, lessonID, maxLessonEdits)
+	if err != nil {
+		return nil, err
+	}
+	history := &LessonEditHistory{Edits: edits}
+	history.CanUndo, history.CanRedo = s.editStackState(ctx, lessonID)
+	return history, nil
+}
+
+// UndoEdit 撤销最近一次编辑，恢复到编辑前的内容
+func (s *lessonService) UndoEdit(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditState, error) {
+	lesson, err := s.getEditableLesson(ctx, lessonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	edit, err := s.editRepo.LatestApplied(ctx, lessonID)
+	if err != nil {
+		return nil, ErrNothingToUndo
+	}
+	restored, err := s.restoreEditSnapshot(ctx, lesson, userID, edit.Before, "撤销前快照")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.editRepo.SetUndone(ctx, edit.ID, true); err != nil {
+		return nil, err
+	}
+
+	state := &LessonEditState{Lesson: restored}
+	state.CanUndo, state.CanRedo = s.editStackState(ctx, lessonID)
+	return state, nil
+}
+
+// RedoEdit 重做最近一次撤销的编辑
+func (s *lessonService) RedoEdit(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditState, error) {
+	lesson, err := s.getEditableLesson(ctx, lessonID, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	edit, err := s.editRepo.EarliestUndone(ctx, lessonID)
+	if err != nil {
+		return nil, ErrNothingToRedo
+	}
+	restored, err := s.restoreEditSnapshot(ctx, lesson, userID, edit.After, "重做前快照")
+	if err != nil {
+		return nil, err
+	}
+	if err := s.editRepo.SetUndone(ctx, edit.ID, false); err != nil {
+		return nil, err
+	}
+
+	state := &LessonEditState{Lesson: restored}
+	state.CanUndo, state.CanRedo = s.editStackState(ctx, lessonID)
+	return state, nil
+}
+
+func (s *lessonService) getEditableLesson(ctx context.Context, lessonID, userID uuid.UUID) (*model.Lesson, error) {
+	if s.editRepo == nil {
+		return nil, errors.New("编辑记录功能未启用")
+	}
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	return lesson, nil
+}
+
+func (s *lessonService) editStackState(ctx context.Context, lessonID uuid.UUID) (bool, bool) {
+	applied, undone, err := s.editRepo.CountByState(ctx, lessonID)
+	if err != nil {
+		return false, false
+	}
+	return applied > 0, undone > 0
+}
+
+// restoreEditSnapshot 将教案内容恢复为快照，状态保持不变：
+// 已发布教案写入草稿（与正式版一致时清除草稿），其他教案直接覆盖正文并保存版本快照
+func (s *lessonService) restoreEditSnapshot(ctx context.Context, lesson *model.Lesson, userID uuid.UUID, snapshot, summary string) (*model.Lesson, error) {
+	status := lesson.Status
+
+	if status == model.LessonStatusPublished {
+		draft := *lesson
+		if err := applyLessonSnapshot(&draft, snapshot); err != nil {
+			return nil, fmt.Errorf("解析编辑快照失败: %w", err)
+		}
+		draft.Status = status
+
+		draftContent, err := buildLessonSnapshot(&draft)
+		if err != nil {
+			return nil, fmt.Errorf("生成草稿失败: %w", err)
+		}
+		publishedContent, err := buildLessonSnapshot(lesson)
+		if err != nil {
+			return nil, fmt.Errorf("生成草稿失败: %w", err)
+		}
+
+		if draftContent == publishedContent {
+			lesson.DraftContent = ""
+			lesson.DraftSavedAt = nil
+		} else {
+			now := time.Now()
+			lesson.DraftContent = draftContent
+			lesson.DraftSavedAt = &now
+		}
+		if err := s.lessonRepo.Update(ctx, lesson); err != nil {
+			return nil, err
+		}
+		draft.DraftContent = lesson.DraftContent
+		draft.DraftSavedAt = lesson.DraftSavedAt
+		return &draft, nil
+	}
+
+	if err := s.snapshotVersion(ctx, lesson, userID, summary); err != nil {
+		return nil, err
+	}
+
+	if err := applyLessonSnapshot(lesson, snapshot); err != nil {
+		return nil, fmt.Errorf("解析编辑快照失败: %w", err)
+	}
+	lesson.Status = status
+	lesson.Version++
+	if err := s.lessonRepo.Update(ctx, lesson); err != nil {
+		return nil, err
+	}
+	return lesson, nil
+}
+
+// editableSnapshot 教案当前可编辑内容的快照：已发布教案有草稿时以草稿为准
+func editableSnapshot(lesson *model.Lesson) (string, error) {
+	view := *lesson
+	if lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(&view, lesson.DraftContent); err != nil {
+			return "", err
+		}
+	}
+	return buildLessonSnapshot(&view)
+}
+
+// recordEdit 记录一次编辑操作入栈，并丢弃可重做的记录；内容无变化时不记录。
+// 编辑已经保存，记录失败只影响撤销，不影响本次编辑
+func (s *lessonService) recordEdit(ctx context.Context, lessonID, userID uuid.UUID, before, after string) {
+	if s.editRepo == nil || before == "" || after == "" {
+		return
+	}
+	fields := changedSnapshotFields(before, after)
+	if len(fields) == 0 {
+		return
+	}
+	fieldsJSON, _ := json.Marshal(fields)
+
+	if err := s.editRepo.DiscardUndone(ctx, lessonID); err != nil {
+		return
+	}
+	edit := &model.LessonEdit{
+		LessonID: lessonID,
+		UserID:   userID,
+		Before:   before,
+		After:    after,
+		Fields:   string(fieldsJSON),
+	}
+	if err := s.editRepo.Create(ctx, edit); err != nil {
+		return
+	}
+	_, _ = s.editRepo.Prune(ctx, lessonID, maxLessonEdits)
+}
+
+// changedSnapshotFields 比较两个快照，返回内容有变化的字段名（不含状态）
+func changedSnapshotFields(before, after string) []string {
+	var a, b map[string]interface{}
+	if json.Unmarshal([]byte(before), &a) != nil || json.Unmarshal([]byte(after), &b) != nil {
+		return nil
+	}
+
+	fields := make([]string, 0)
+	for key, value := range b {
+		if key == "status" {
+			continue
+		}
+		if !reflect.DeepEqual(a[key], value) {
+			fields = append(fields, key)
+		}
+	}
+	sort.Strings(fields)
+	return fields
+}
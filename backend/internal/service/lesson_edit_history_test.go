@@ -0,0 +1,246 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"sort"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeLessonEditRepo 内存中的编辑记录仓库，按写入顺序递增 CreatedAt
+type fakeLessonEditRepo struct {
+	repository.LessonEditRepository
+
+	edits []model.LessonEdit
+	clock time.Time
+}
+
+func (r *fakeLessonEditRepo) Create(ctx context.Context, edit *model.LessonEdit) error {
+	if edit.ID == uuid.Nil {
+		edit.ID = uuid.New()
+	}
+	r.clock = r.clock.Add(time.Second)
+	edit.CreatedAt = r.clock
+	r.edits = append(r.edits, *edit)
+	return nil
+}
+
+func (r *fakeLessonEditRepo) ListByLessonID(ctx context.Context, lessonID uuid.UUID, limit int) ([]model.LessonEdit, error) {
+	var list []model.LessonEdit
+	for _, e := range r.edits {
+		if e.LessonID == lessonID {
+			list = append(list, e)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].CreatedAt.After(list[j].CreatedAt) })
+	if len(list) > limit {
+		list = list[:limit]
+	}
+	return list, nil
+}
+
+func (r *fakeLessonEditRepo) LatestApplied(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error) {
+	for i := len(r.edits) - 1; i >= 0; i-- {
+		if e := r.edits[i]; e.LessonID == lessonID && !e.Undone {
+			return &e, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakeLessonEditRepo) EarliestUndone(ctx context.Context, lessonID uuid.UUID) (*model.LessonEdit, error) {
+	for _, e := range r.edits {
+		if e.LessonID == lessonID && e.Undone {
+			return &e, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakeLessonEditRepo) SetUndone(ctx context.Context, id uuid.UUID, undone bool) error {
+	for i := range r.edits {
+		if r.edits[i].ID == id {
+			r.edits[i].Undone = undone
+		}
+	}
+	return nil
+}
+
+func (r *fakeLessonEditRepo) DiscardUndone(ctx context.Context, lessonID uuid.UUID) error {
+	kept := r.edits[:0]
+	for _, e := range r.edits {
+		if e.LessonID == lessonID && e.Undone {
+			continue
+		}
+		kept = append(kept, e)
+	}
+	r.edits = kept
+	return nil
+}
+
+func (r *fakeLessonEditRepo) CountByState(ctx context.Context, lessonID uuid.UUID) (int64, int64, error) {
+	var applied, undone int64
+	for _, e := range r.edits {
+		if e.LessonID != lessonID {
+			continue
+		}
+		if e.Undone {
+			undone++
+		} else {
+			applied++
+		}
+	}
+	return applied, undone, nil
+}
+
+func (r *fakeLessonEditRepo) Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error) {
+	var count int
+	for _, e := range r.edits {
+		if e.LessonID == lessonID {
+			count++
+		}
+	}
+	var removed int64
+	kept := r.edits[:0]
+	for _, e := range r.edits {
+		if e.LessonID == lessonID && count > keep {
+			count--
+			removed++
+			continue
+		}
+		kept = append(kept, e)
+	}
+	r.edits = kept
+	return removed, nil
+}
+
+// newTestLessonServiceWithEdits 创建启用撤销/重做的教案服务
+func newTestLessonServiceWithEdits(lessonRepo repository.LessonRepository, editRepo repository.LessonEditRepository) *lessonService {
+	return NewLessonService(lessonRepo, &fakeFavoriteRepo{}, &fakeLikeRepo{}, &fakeVersionRepo{}, editRepo, nil, nil, nil, nil, nil, nil, "").(*lessonService)
+}
+
+// editState 断言编辑栈状态
+func editState(t *testing.T, state *LessonEditState, wantTitle string, canUndo, canRedo bool) {
+	t.Helper()
+	if state.Lesson.Title != wantTitle || state.CanUndo != canUndo || state.CanRedo != canRedo {
+		t.Errorf("state = (%q, undo %v, redo %v), want (%q, undo %v, redo %v)",
+			state.Lesson.Title, state.CanUndo, state.CanRedo, wantTitle, canUndo, canRedo)
+	}
+}
+
+func TestUndoRedoEdit(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := publishableLesson(userID, model.LessonStatusDraft)
+	lessons := newFakeLessonRepo(lesson)
+	edits := &fakeLessonEditRepo{}
+	svc := newTestLessonServiceWithEdits(lessons, edits)
+
+	for _, title := range []string{"第一次修改", "第二次修改"} {
+		if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Title: strPtr(title)}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+	// 状态不变的保存不入栈
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Title: strPtr("第二次修改")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if len(edits.edits) != 2 || edits.edits[1].Fields != `["title"]` {
+		t.Fatalf("edits = %+v, want two title edits", edits.edits)
+	}
+
+	state, err := svc.UndoEdit(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoEdit() error = %v", err)
+	}
+	editState(t, state, "第一次修改", true, true)
+
+	state, err = svc.UndoEdit(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoEdit() error = %v", err)
+	}
+	editState(t, state, "正式版标题", false, true)
+	if _, err := svc.UndoEdit(ctx, lesson.ID, userID); !errors.Is(err, ErrNothingToUndo) {
+		t.Errorf("UndoEdit() on empty stack error = %v, want ErrNothingToUndo", err)
+	}
+
+	// 重做按撤销的逆序重新应用
+	state, err = svc.RedoEdit(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("RedoEdit() error = %v", err)
+	}
+	editState(t, state, "第一次修改", true, true)
+	if stored, _ := lessons.GetByID(ctx, lesson.ID); stored.Title != "第一次修改" || stored.Status != model.LessonStatusDraft {
+		t.Errorf("stored = (%q, %s), want redo persisted with status kept", stored.Title, stored.Status)
+	}
+
+	// 新编辑后已撤销的记录不可再重做
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Title: strPtr("另起炉灶")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if _, err := svc.RedoEdit(ctx, lesson.ID, userID); !errors.Is(err, ErrNothingToRedo) {
+		t.Errorf("RedoEdit() after new edit error = %v, want ErrNothingToRedo", err)
+	}
+	history, err := svc.ListEdits(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("ListEdits() error = %v", err)
+	}
+	if len(history.Edits) != 2 || !history.CanUndo || history.CanRedo {
+		t.Errorf("history = %d edits (undo %v, redo %v), want 2 applied and nothing to redo",
+			len(history.Edits), history.CanUndo, history.CanRedo)
+	}
+}
+
+func TestUndoEditPublishedLessonRestoresDraft(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := publishableLesson(userID, model.LessonStatusPublished)
+	lessons := newFakeLessonRepo(lesson)
+	svc := newTestLessonServiceWithEdits(lessons, &fakeLessonEditRepo{})
+
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Title: strPtr("草稿标题")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// 撤销后草稿与正式版一致，草稿被清除
+	state, err := svc.UndoEdit(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("UndoEdit() error = %v", err)
+	}
+	editState(t, state, "正式版标题", false, true)
+	stored, _ := lessons.GetByID(ctx, lesson.ID)
+	if stored.DraftContent != "" || stored.Title != "正式版标题" || stored.Version != 1 {
+		t.Errorf("stored = (%q, draft %q, v%d), want published lesson untouched", stored.Title, stored.DraftContent, stored.Version)
+	}
+
+	// 重做写回草稿，正式版仍不变
+	state, err = svc.RedoEdit(ctx, lesson.ID, userID)
+	if err != nil {
+		t.Fatalf("RedoEdit() error = %v", err)
+	}
+	editState(t, state, "草稿标题", true, false)
+	stored, _ = lessons.GetByID(ctx, lesson.ID)
+	if stored.DraftContent == "" || stored.Title != "正式版标题" {
+		t.Errorf("stored = (%q, draft %q), want redo kept in the draft", stored.Title, stored.DraftContent)
+	}
+}
+
+func TestUndoEditPermissions(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := publishableLesson(userID, model.LessonStatusDraft)
+	svc := newTestLessonServiceWithEdits(newFakeLessonRepo(lesson), &fakeLessonEditRepo{})
+
+	if _, err := svc.UndoEdit(ctx, lesson.ID, uuid.New()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("UndoEdit() by other user error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.RedoEdit(ctx, uuid.New(), userID); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("RedoEdit() on missing lesson error = %v, want ErrLessonNotFound", err)
+	}
+}
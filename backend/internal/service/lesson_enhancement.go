@@ -40,9 +40,11 @@ type LessonQualityReview struct {
 
 // VersionDiffField 版本差异字段。
 type VersionDiffField struct {
-	Field   string   `json:"field"`
-	Label   string   `json:"label"`
-	Changed bool     `json:"changed"`
+	Field   string `json:"field"`
+	Label   string `json:"label"`
+	Changed bool   `json:"changed"`
+	// Status 字段变化类型：unchanged、modified、added（原为空）、removed（改为空）
+	Status  string   `json:"status"`
 	Before  string   `json:"before,omitempty"`
 	After   string   `json:"after,omitempty"`
 	Added   []string `json:"added,omitempty"`
@@ -612,7 +614,7 @@ func (s *lessonService) resolveSnapshot(ctx context.Context, lesson *model.Lesso
 	}
 	versionData, err := s.versionRepo.GetByVersion(ctx, lesson.ID, version)
 	if err != nil {
-		return "", nil, errors.New("版本不存在")
+		return "", nil, ErrVersionNotFound
 	}
 
 	snapshot, err := parseLessonSnapshot(versionData.Content)
@@ -623,6 +625,27 @@ func (s *lessonService) resolveSnapshot(ctx context.Context, lesson *model.Lesso
 	return fmt.Sprintf("v%d", version), snapshot, nil
 }
 
+// 版本差异中单个字段的变化类型
+const (
+	VersionDiffUnchanged = "unchanged"
+	VersionDiffModified  = "modified"
+	VersionDiffAdded     = "added"
+	VersionDiffRemoved   = "removed"
+)
+
+func versionDiffStatus(before, after string) string {
+	switch {
+	case before == after:
+		return VersionDiffUnchanged
+	case before == "":
+		return VersionDiffAdded
+	case after == "":
+		return VersionDiffRemoved
+	default:
+		return VersionDiffModified
+	}
+}
+
 func (s *lessonService) CompareVersions(ctx context.Context, lessonID uuid.UUID, userID uuid.UUID, fromVersion, toVersion string) (*LessonVersionDiff, error) {
 	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
 	if err != nil {
@@ -674,6 +697,7 @@ func (s *lessonService) CompareVersions(ctx context.Context, lessonID uuid.UUID,
 			Field:   field.key,
 			Label:   field.label,
 			Changed: changed,
+			Status:  versionDiffStatus(before, after),
 			Before:  truncateDiffText(before),
 			After:   truncateDiffText(after),
 			Added:   added,
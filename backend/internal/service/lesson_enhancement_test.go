@@ -0,0 +1,95 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestVersionDiffStatus(t *testing.T) {
+	tests := []struct {
+		before, after string
+		want          string
+	}{
+		{before: "", after: "", want: VersionDiffUnchanged},
+		{before: "标题", after: "标题", want: VersionDiffUnchanged},
+		{before: "旧标题", after: "新标题", want: VersionDiffModified},
+		{before: "", after: "小组讨论", want: VersionDiffAdded},
+		{before: "教材", after: "", want: VersionDiffRemoved},
+	}
+
+	for _, tt := range tests {
+		if got := versionDiffStatus(tt.before, tt.after); got != tt.want {
+			t.Errorf("versionDiffStatus(%q, %q) = %q, want %q", tt.before, tt.after, got, tt.want)
+		}
+	}
+}
+
+func TestCompareVersionsFieldStatus(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := publishableLesson(userID, model.LessonStatusDraft)
+	lesson.Resources = "教材"
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+	if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{
+		Title: strPtr("新标题"), Activities: strPtr("小组讨论"), Resources: strPtr(""),
+	}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	diff, err := svc.CompareVersions(ctx, lesson.ID, userID, "v1", "current")
+	if err != nil {
+		t.Fatalf("CompareVersions() error = %v", err)
+	}
+	if diff.FromVersion != "v1" || diff.ToVersion != "current" || diff.ChangedFields != 3 {
+		t.Errorf("diff = %s -> %s with %d changes, want v1 -> current with 3", diff.FromVersion, diff.ToVersion, diff.ChangedFields)
+	}
+
+	status := make(map[string]string)
+	for i, field := range diff.Fields {
+		status[field.Field] = field.Status
+		if field.Changed != (field.Status != VersionDiffUnchanged) {
+			t.Errorf("field %s changed = %v but status = %s", field.Field, field.Changed, field.Status)
+		}
+		// 有变化的字段排在前面
+		if i > 0 && field.Changed && !diff.Fields[i-1].Changed {
+			t.Errorf("changed field %s listed after an unchanged one", field.Field)
+		}
+	}
+	want := map[string]string{
+		"title":      VersionDiffModified,
+		"activities": VersionDiffAdded,
+		"resources":  VersionDiffRemoved,
+		"content":    VersionDiffUnchanged,
+		"objectives": VersionDiffUnchanged,
+		"status":     VersionDiffUnchanged,
+	}
+	for field, wantStatus := range want {
+		if status[field] != wantStatus {
+			t.Errorf("field %s status = %q, want %q", field, status[field], wantStatus)
+		}
+	}
+}
+
+func TestCompareVersionsErrors(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	lesson := publishableLesson(userID, model.LessonStatusDraft)
+	svc := newTestLessonService(newFakeLessonRepo(lesson), &fakeVersionRepo{})
+
+	if _, err := svc.CompareVersions(ctx, lesson.ID, userID, "v9", "current"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("CompareVersions() with missing version error = %v, want ErrVersionNotFound", err)
+	}
+	if _, err := svc.CompareVersions(ctx, lesson.ID, userID, "abc", "current"); err == nil {
+		t.Error("CompareVersions() with malformed version should fail")
+	}
+	if _, err := svc.CompareVersions(ctx, lesson.ID, uuid.New(), "current", "current"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("CompareVersions() by other user error = %v, want ErrUnauthorized", err)
+	}
+}
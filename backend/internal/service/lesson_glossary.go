@@ -0,0 +1,283 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// 术语来源
+const (
+	// GlossarySourceKnowledge 解释来自知识库中的知识点描述
+	GlossarySourceKnowledge = "knowledge"
+	// GlossarySourceAgent 解释由 Agent 生成
+	GlossarySourceAgent = "agent"
+
+	// maxGlossaryTerms 术语表最多保留的条目数
+	maxGlossaryTerms = 30
+	// glossaryKnowledgeLimit 匹配术语时最多读取的知识点数
+	glossaryKnowledgeLimit = 500
+)
+
+// ErrGlossaryUnavailable 知识库未命中且 Agent 不可用时无法生成术语表
+var ErrGlossaryUnavailable = errors.New("术语提取服务暂不可用")
+
+// GlossaryTerm 术语及其解释
+type GlossaryTerm struct {
+	Term       string `json:"term"`
+	Definition string `json:"definition"`
+	Source     string `json:"source"`
+	// KnowledgeID 解释来自知识库时对应的知识点 ID
+	KnowledgeID string `json:"knowledge_id,omitempty"`
+}
+
+// LessonGlossary 教案术语表，Markdown 为可直接附在教案后的表格
+type LessonGlossary struct {
+	LessonID uuid.UUID      `json:"lesson_id"`
+	Terms    []GlossaryTerm `json:"terms"`
+	Markdown string         `json:"markdown"`
+	// Partial 为 true 表示 Agent 调用失败，仅返回知识库命中的术语
+	Partial bool `json:"partial"`
+}
+
+type agentGlossaryRequest struct {
+	LessonID   string   `json:"lessonId"`
+	Title      string   `json:"title"`
+	Subject    string   `json:"subject"`
+	Grade      string   `json:"grade"`
+	Content    string   `json:"content"`
+	KnownTerms []string `json:"knownTerms"`
+	MaxTerms   int      `json:"maxTerms"`
+}
+
+type agentGlossaryResponse struct {
+	Success bool `json:"success"`
+	Data    *struct {
+		Terms []struct {
+			Term       string `json:"term"`
+			Definition string `json:"definition"`
+		} `json:"terms"`
+	} `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+func (s *lessonService) Glossary(ctx context.Context, lessonID, userID uuid.UUID) (*LessonGlossary, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	// 与翻译一致：作者可提取自己的任意教案，其他人只能提取已发布的教案
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+
+	text := glossarySourceText(lesson)
+
+	var terms []GlossaryTerm
+	if s.knowledgeRepo != nil {
+		// 知识库按教案作者读取，其他人查看已发布教案时也使用作者的知识点解释
+		points, err := s.knowledgeRepo.ListKnowledgePoints(ctx, lesson.Subject, "", lesson.UserID.String(), glossaryKnowledgeLimit)
+		if err != nil {
+			logger.Warn(
+				"List knowledge points for glossary failed",
+				logger.String("lesson_id", lesson.ID.String()),
+				logger.Err(err),
+			)
+		} else {
+			terms = matchKnowledgeTerms(text, points)
+		}
+	}
+
+	glossary := &LessonGlossary{LessonID: lesson.ID}
+	agentTerms, agentErr := s.glossaryByAgent(ctx, lesson, text, terms)
+	if agentErr != nil {
+		logger.Warn(
+			"Agent glossary extraction failed, fallback to knowledge terms",
+			logger.String("lesson_id", lesson.ID.String()),
+			logger.Err(agentErr),
+		)
+		if len(terms) == 0 {
+			return nil, ErrGlossaryUnavailable
+		}
+		glossary.Partial = true
+	}
+	terms = mergeGlossaryTerms(text, terms, agentTerms)
+
+	glossary.Terms = terms
+	glossary.Markdown = renderGlossaryMarkdown(terms)
+	return glossary, nil
+}
+
+// glossarySourceText 拼接教案标题与各字段正文作为术语提取范围
+func glossarySourceText(lesson *model.Lesson) string {
+	parts := []string{
+		strings.TrimSpace(lesson.Title),
+		normalizeLessonText(lesson.Objectives),
+		normalizeLessonText(lesson.Content),
+		normalizeLessonText(lesson.Activities),
+		normalizeLessonText(lesson.Assessment),
+		normalizeLessonText(lesson.Resources),
+	}
+	kept := parts[:0]
+	for _, part := range parts {
+		if part != "" {
+			kept = append(kept, part)
+		}
+	}
+	return strings.Join(kept, "\n\n")
+}
+
+// matchKnowledgeTerms 选出名称出现在正文中且带有描述的知识点；单字名称误命中太多，直接忽略
+func matchKnowledgeTerms(text string, points []model.Knowledge) []GlossaryTerm {
+	terms := make([]GlossaryTerm, 0)
+	seen := map[string]bool{}
+	for _, point := range points {
+		name := strings.TrimSpace(point.Name)
+		definition := strings.TrimSpace(point.Description)
+		if len([]rune(name)) < 2 || definition == "" || seen[name] {
+			continue
+		}
+		if !strings.Contains(text, name) {
+			continue
+		}
+		seen[name] = true
+		terms = append(terms, GlossaryTerm{
+			Term:        name,
+			Definition:  definition,
+			Source:      GlossarySourceKnowledge,
+			KnowledgeID: point.ID,
+		})
+	}
+	return terms
+}
+
+// mergeGlossaryTerms 合并知识库与 Agent 的术语：知识库解释优先，Agent 返回的术语必须在正文中出现，
+// 结果按首次出现位置排序并截断到 maxGlossaryTerms
+func mergeGlossaryTerms(text string, knowledgeTerms, agentTerms []GlossaryTerm) []GlossaryTerm {
+	merged := make([]GlossaryTerm, 0, len(knowledgeTerms)+len(agentTerms))
+	seen := map[string]bool{}
+	for _, term := range knowledgeTerms {
+		seen[term.Term] = true
+		merged = append(merged, term)
+	}
+	for _, term := range agentTerms {
+		term.Term = strings.TrimSpace(term.Term)
+		term.Definition = strings.TrimSpace(term.Definition)
+		if term.Term == "" || term.Definition == "" || seen[term.Term] {
+			continue
+		}
+		if !strings.Contains(text, term.Term) {
+			continue
+		}
+		seen[term.Term] = true
+		term.Source = GlossarySourceAgent
+		merged = append(merged, term)
+	}
+
+	sort.SliceStable(merged, func(i, j int) bool {
+		return strings.Index(text, merged[i].Term) < strings.Index(text, merged[j].Term)
+	})
+	if len(merged) > maxGlossaryTerms {
+		merged = merged[:maxGlossaryTerms]
+	}
+	return merged
+}
+
+func renderGlossaryMarkdown(terms []GlossaryTerm) string {
+	if len(terms) == 0 {
+		return ""
+	}
+	var b strings.Builder
+	b.WriteString("## 术语表\n\n| 术语 | 解释 |\n| --- | --- |\n")
+	for _, term := range terms {
+		fmt.Fprintf(&b, "| %s | %s |\n", escapeGlossaryCell(term.Term), escapeGlossaryCell(term.Definition))
+	}
+	return strings.TrimRight(b.String(), "\n")
+}
+
+func escapeGlossaryCell(value string) string {
+	value = strings.ReplaceAll(value, "|", "\\|")
+	return strings.Join(strings.Fields(value), " ")
+}
+
+func (s *lessonService) glossaryByAgent(ctx context.Context, lesson *model.Lesson, text string, known []GlossaryTerm) ([]GlossaryTerm, error) {
+	if s.cfg == nil || strings.TrimSpace(s.cfg.URL) == "" || s.httpClient == nil {
+		return nil, errors.New("agent 术语服务未配置")
+	}
+
+	knownTerms := make([]string, 0, len(known))
+	for _, term := range known {
+		knownTerms = append(knownTerms, term.Term)
+	}
+	requestPayload := agentGlossaryRequest{
+		LessonID:   lesson.ID.String(),
+		Title:      strings.TrimSpace(lesson.Title),
+		Subject:    strings.TrimSpace(lesson.Subject),
+		Grade:      strings.TrimSpace(lesson.Grade),
+		Content:    text,
+		KnownTerms: knownTerms,
+		MaxTerms:   maxGlossaryTerms,
+	}
+
+	body, err := json.Marshal(requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal glossary request failed: %w", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	override := APIKeyOverrideFromContext(ctx)
+	if override.GenerationAPIKey != "" {
+		headers[HeaderGenerationAPIKey] = override.GenerationAPIKey
+	}
+	if s.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.cfg.APIKey
+	}
+
+	url := fmt.Sprintf("%s/api/glossary", strings.TrimRight(s.cfg.URL, "/"))
+	statusCode, respBody, err := doAgentRequestWithRetry(
+		ctx,
+		s.httpClient,
+		http.MethodPost,
+		url,
+		body,
+		headers,
+		"glossary",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("call glossary endpoint failed: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("glossary endpoint returned error: %d - %s", statusCode, string(respBody))
+	}
+
+	var response agentGlossaryResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal glossary response failed: %w", err)
+	}
+	if !response.Success {
+		if strings.TrimSpace(response.Error) != "" {
+			return nil, errors.New(strings.TrimSpace(response.Error))
+		}
+		return nil, errors.New("glossary extraction failed")
+	}
+	if response.Data == nil {
+		return nil, errors.New("glossary response is empty")
+	}
+
+	terms := make([]GlossaryTerm, 0, len(response.Data.Terms))
+	for _, item := range response.Data.Terms {
+		terms = append(terms, GlossaryTerm{Term: item.Term, Definition: item.Definition, Source: GlossarySourceAgent})
+	}
+	return terms, nil
+}
@@ -0,0 +1,174 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// newFakeGlossaryAgent 模拟 Agent 的 /api/glossary，status 非 200 时直接返回错误；received 记录收到的请求
+func newFakeGlossaryAgent(t *testing.T, status int, terms map[string]string, received *agentGlossaryRequest) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/glossary" {
+			http.NotFound(w, r)
+			return
+		}
+		if received != nil {
+			_ = json.NewDecoder(r.Body).Decode(received)
+		}
+		if status != http.StatusOK {
+			w.WriteHeader(status)
+			return
+		}
+		items := make([]map[string]string, 0, len(terms))
+		for term, definition := range terms {
+			items = append(items, map[string]string{"term": term, "definition": definition})
+		}
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data":    map[string]interface{}{"terms": items},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestMatchKnowledgeTerms(t *testing.T) {
+	text := "比较有理数的大小需要借助数轴，数轴上右边的数总比左边的大。"
+	points := []model.Knowledge{
+		{ID: "k1", Name: "数轴", Description: "规定了原点、正方向和单位长度的直线"},
+		{ID: "k2", Name: " 有理数 ", Description: " 整数和分数的统称 "},
+		{ID: "k3", Name: "数", Description: "单字名称误命中太多"},
+		{ID: "k4", Name: "绝对值", Description: "未在正文中出现"},
+		{ID: "k5", Name: "大小", Description: ""},
+		{ID: "k6", Name: "数轴", Description: "重复的知识点"},
+	}
+
+	want := []GlossaryTerm{
+		{Term: "数轴", Definition: "规定了原点、正方向和单位长度的直线", Source: GlossarySourceKnowledge, KnowledgeID: "k1"},
+		{Term: "有理数", Definition: "整数和分数的统称", Source: GlossarySourceKnowledge, KnowledgeID: "k2"},
+	}
+	if got := matchKnowledgeTerms(text, points); !reflect.DeepEqual(got, want) {
+		t.Errorf("matchKnowledgeTerms() = %+v, want %+v", got, want)
+	}
+}
+
+func TestMergeGlossaryTerms(t *testing.T) {
+	text := "有理数包括整数和分数，可以在数轴上表示。"
+	knowledge := []GlossaryTerm{
+		{Term: "数轴", Definition: "知识库解释", Source: GlossarySourceKnowledge, KnowledgeID: "k1"},
+	}
+	agent := []GlossaryTerm{
+		{Term: "数轴", Definition: "Agent 解释"},
+		{Term: " 有理数 ", Definition: " 整数和分数的统称 "},
+		{Term: "分数", Definition: ""},
+		{Term: "绝对值", Definition: "正文中没有出现"},
+	}
+
+	// 知识库解释优先；Agent 术语需在正文出现且有解释；按首次出现位置排序
+	want := []GlossaryTerm{
+		{Term: "有理数", Definition: "整数和分数的统称", Source: GlossarySourceAgent},
+		{Term: "数轴", Definition: "知识库解释", Source: GlossarySourceKnowledge, KnowledgeID: "k1"},
+	}
+	if got := mergeGlossaryTerms(text, knowledge, agent); !reflect.DeepEqual(got, want) {
+		t.Errorf("mergeGlossaryTerms() = %+v, want %+v", got, want)
+	}
+
+	var many []GlossaryTerm
+	var long strings.Builder
+	for i := 0; i < maxGlossaryTerms+5; i++ {
+		term := fmt.Sprintf("术语%02d", i)
+		long.WriteString(term)
+		many = append(many, GlossaryTerm{Term: term, Definition: "解释"})
+	}
+	if got := mergeGlossaryTerms(long.String(), nil, many); len(got) != maxGlossaryTerms || got[0].Term != "术语00" {
+		t.Errorf("mergeGlossaryTerms() kept %d terms starting at %q, want the first %d", len(got), got[0].Term, maxGlossaryTerms)
+	}
+}
+
+func TestRenderGlossaryMarkdown(t *testing.T) {
+	if got := renderGlossaryMarkdown(nil); got != "" {
+		t.Errorf("renderGlossaryMarkdown(nil) = %q, want empty", got)
+	}
+
+	got := renderGlossaryMarkdown([]GlossaryTerm{
+		{Term: "数轴", Definition: "规定了原点、正方向\n和单位长度的直线"},
+		{Term: "a|b", Definition: "含  竖线"},
+	})
+	want := "## 术语表\n\n| 术语 | 解释 |\n| --- | --- |\n" +
+		"| 数轴 | 规定了原点、正方向 和单位长度的直线 |\n" +
+		"| a\\|b | 含 竖线 |"
+	if got != want {
+		t.Errorf("renderGlossaryMarkdown() =\n%s\nwant\n%s", got, want)
+	}
+}
+
+func TestGlossary(t *testing.T) {
+	authorID := uuid.New()
+	points := []model.Knowledge{{ID: "k1", Subject: "数学", Name: "有理数", Description: "整数和分数的统称"}}
+	agentTerms := map[string]string{"数轴": "规定了原点、正方向和单位长度的直线"}
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		status      string
+		points      []model.Knowledge
+		agentStatus int
+		wantErr     error
+		wantTerms   []string
+		wantPartial bool
+	}{
+		{name: "knowledge and agent merged", userID: authorID, status: model.LessonStatusDraft, points: points, agentStatus: http.StatusOK, wantTerms: []string{"有理数", "数轴"}},
+		{name: "others read published lessons", userID: uuid.New(), status: model.LessonStatusPublished, points: points, agentStatus: http.StatusOK, wantTerms: []string{"有理数", "数轴"}},
+		{name: "others cannot read drafts", userID: uuid.New(), status: model.LessonStatusDraft, agentStatus: http.StatusOK, wantErr: ErrUnauthorized},
+		{name: "agent failure keeps knowledge terms", userID: authorID, status: model.LessonStatusDraft, points: points, agentStatus: http.StatusBadRequest, wantTerms: []string{"有理数"}, wantPartial: true},
+		{name: "agent failure without knowledge", userID: authorID, status: model.LessonStatusDraft, agentStatus: http.StatusBadRequest, wantErr: ErrGlossaryUnavailable},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := publishableLesson(authorID, tt.status)
+			lesson.Content = wrapLessonText("有理数可以在数轴上表示")
+			var received agentGlossaryRequest
+			agent := newFakeGlossaryAgent(t, tt.agentStatus, agentTerms, &received)
+			cfg := &config.AgentConfig{URL: agent.URL, Timeout: 5}
+			svc := NewLessonService(newFakeLessonRepo(lesson), &fakeFavoriteRepo{}, &fakeLikeRepo{}, nil, nil, nil,
+				&fakeKnowledgeRepo{points: tt.points}, nil, nil, cfg, nil, "").(*lessonService)
+
+			glossary, err := svc.Glossary(context.Background(), lesson.ID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Glossary() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			var terms []string
+			for _, term := range glossary.Terms {
+				terms = append(terms, term.Term)
+			}
+			if !reflect.DeepEqual(terms, tt.wantTerms) || glossary.Partial != tt.wantPartial {
+				t.Errorf("glossary = %q (partial %v), want %q (partial %v)", terms, glossary.Partial, tt.wantTerms, tt.wantPartial)
+			}
+			if !strings.Contains(glossary.Markdown, "| 有理数 | 整数和分数的统称 |") {
+				t.Errorf("markdown = %q, want the knowledge term row", glossary.Markdown)
+			}
+			// 已由知识库解释的术语告知 Agent，避免重复生成
+			if !reflect.DeepEqual(received.KnownTerms, []string{"有理数"}) || received.MaxTerms != maxGlossaryTerms {
+				t.Errorf("agent request known terms = %q, max %d, want [有理数] and %d", received.KnownTerms, received.MaxTerms, maxGlossaryTerms)
+			}
+		})
+	}
+}
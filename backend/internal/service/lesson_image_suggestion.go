@@ -0,0 +1,160 @@
+package service
+
+import (
+	"context"
+	"net/url"
+	"regexp"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// maxImageSuggestions 单个教案返回的配图建议上限
+const maxImageSuggestions = 8
+
+// ImageSearchLink 图库搜索链接
+type ImageSearchLink struct {
+	Source string `json:"source"`
+	URL    string `json:"url"`
+}
+
+// ImageSuggestion 配图建议
+type ImageSuggestion struct {
+	Section string            `json:"section"`
+	Keyword string            `json:"keyword"`
+	Query   string            `json:"query"`
+	Links   []ImageSearchLink `json:"links"`
+}
+
+// LessonImageSuggestions 教案配图建议结果
+type LessonImageSuggestions struct {
+	LessonID    uuid.UUID         `json:"lesson_id"`
+	Suggestions []ImageSuggestion `json:"suggestions"`
+}
+
+// imageQueryHints 按学科追加的图片类型提示词，使搜索结果更贴近教学用图
+var imageQueryHints = map[string]string{
+	"语文": "插图",
+	"数学": "示意图",
+	"英语": "illustration",
+	"物理": "实验示意图",
+	"化学": "实验",
+	"生物": "结构图",
+	"地理": "地图",
+	"历史": "历史图片",
+	"政治": "漫画",
+	"美术": "作品",
+	"音乐": "乐器",
+	"科学": "示意图",
+}
+
+// genericSectionTitles 通用教学环节名，不适合作为配图关键词
+var genericSectionTitles = []string{
+	"导入", "新课", "讲授", "练习", "巩固", "小结", "总结", "作业", "板书", "反思", "评价", "拓展", "环节", "活动",
+}
+
+var (
+	markdownHeadingPattern = regexp.MustCompile(`^#{1,6}\s+(.+)$`)
+	markdownBoldPattern    = regexp.MustCompile(`\*\*([^*\n]{2,30})\*\*`)
+	quotedTermPattern      = regexp.MustCompile(`[“「《]([^”」》\n]{2,20})[”」》]`)
+	headingNumberPattern   = regexp.MustCompile(`^[\d一二三四五六七八九十]+[.、．)）]\s*`)
+	headingSuffixPattern   = regexp.MustCompile(`[（(][^）)]*[）)]\s*$`)
+)
+
+func (s *lessonService) SuggestImages(ctx context.Context, lessonID, userID uuid.UUID) (*LessonImageSuggestions, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+
+	return &LessonImageSuggestions{
+		LessonID:    lesson.ID,
+		Suggestions: buildImageSuggestions(lesson),
+	}, nil
+}
+
+// buildImageSuggestions 从教案标题、内容的小节标题、加粗词和引号术语中提取关键词，
+// 生成图片搜索词及常用图库的搜索链接。按出现顺序去重，最多返回 maxImageSuggestions 条。
+func buildImageSuggestions(lesson *model.Lesson) []ImageSuggestion {
+	hint := imageQueryHints[strings.TrimSpace(lesson.Subject)]
+	if hint == "" {
+		hint = "教学插图"
+	}
+
+	suggestions := make([]ImageSuggestion, 0, maxImageSuggestions)
+	seen := make(map[string]bool)
+	add := func(section, keyword string) {
+		keyword = strings.TrimSpace(keyword)
+		if keyword == "" || seen[keyword] || isGenericSectionTitle(keyword) || len(suggestions) >= maxImageSuggestions {
+			return
+		}
+		seen[keyword] = true
+
+		query := keyword + " " + hint
+		suggestions = append(suggestions, ImageSuggestion{
+			Section: section,
+			Keyword: keyword,
+			Query:   query,
+			Links:   imageSearchLinks(query),
+		})
+	}
+
+	add("标题", lesson.Title)
+
+	section := "教学内容"
+	text := normalizeLessonText(lesson.Content) + "\n" + normalizeLessonText(lesson.Activities)
+	for _, line := range strings.Split(text, "\n") {
+		line = strings.TrimSpace(line)
+		if match := markdownHeadingPattern.FindStringSubmatch(line); match != nil {
+			section = cleanSectionTitle(match[1])
+			add(section, section)
+			continue
+		}
+		for _, match := range markdownBoldPattern.FindAllStringSubmatch(line, -1) {
+			term := strings.TrimSpace(match[1])
+			// 跳过“教师活动：”之类的字段标签
+			if strings.HasSuffix(term, "：") || strings.HasSuffix(term, ":") {
+				continue
+			}
+			add(section, term)
+		}
+		for _, match := range quotedTermPattern.FindAllStringSubmatch(line, -1) {
+			add(section, match[1])
+		}
+	}
+
+	return suggestions
+}
+
+func cleanSectionTitle(title string) string {
+	title = strings.Trim(strings.TrimSpace(title), "*")
+	title = headingNumberPattern.ReplaceAllString(title, "")
+	title = headingSuffixPattern.ReplaceAllString(title, "")
+	return strings.TrimSpace(title)
+}
+
+func isGenericSectionTitle(keyword string) bool {
+	if len([]rune(keyword)) > 6 {
+		return false
+	}
+	for _, generic := range genericSectionTitles {
+		if strings.Contains(keyword, generic) {
+			return true
+		}
+	}
+	return false
+}
+
+func imageSearchLinks(query string) []ImageSearchLink {
+	escaped := url.QueryEscape(query)
+	return []ImageSearchLink{
+		{Source: "Unsplash", URL: "https://unsplash.com/s/photos/" + url.PathEscape(query)},
+		{Source: "Pixabay", URL: "https://pixabay.com/images/search/" + url.PathEscape(query) + "/"},
+		{Source: "Wikimedia Commons", URL: "https://commons.wikimedia.org/w/index.php?search=" + escaped + "&title=Special:MediaSearch&type=image"},
+	}
+}
@@ -0,0 +1,114 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestBuildImageSuggestions(t *testing.T) {
+	tests := []struct {
+		name         string
+		lesson       *model.Lesson
+		wantKeywords []string
+		wantSections []string
+		wantQuery    string
+	}{
+		{
+			name: "headings, bold and quoted terms in order",
+			lesson: &model.Lesson{
+				Title:   "光合作用",
+				Subject: "生物",
+				Content: wrapLessonText("## 一、导入\n观察**叶绿体**的形态\n## 2. 光反应（第1课时）\n认识“类囊体”与**光合作用**"),
+			},
+			wantKeywords: []string{"光合作用", "叶绿体", "光反应", "类囊体"},
+			wantSections: []string{"标题", "导入", "光反应", "光反应"},
+			wantQuery:    "光合作用 结构图",
+		},
+		{
+			name: "field labels and generic section titles are skipped",
+			lesson: &model.Lesson{
+				Title:      "勾股定理",
+				Subject:    "数学",
+				Activities: wrapLessonText("### 课堂小结\n**教师活动：** 展示《赵爽弦图》"),
+			},
+			wantKeywords: []string{"勾股定理", "赵爽弦图"},
+			wantSections: []string{"标题", "课堂小结"},
+			wantQuery:    "勾股定理 示意图",
+		},
+		{
+			name:         "unknown subject uses generic hint",
+			lesson:       &model.Lesson{Title: "垃圾分类", Subject: "综合实践"},
+			wantKeywords: []string{"垃圾分类"},
+			wantSections: []string{"标题"},
+			wantQuery:    "垃圾分类 教学插图",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := buildImageSuggestions(tt.lesson)
+			keywords := make([]string, len(got))
+			sections := make([]string, len(got))
+			for i, s := range got {
+				keywords[i], sections[i] = s.Keyword, s.Section
+				if len(s.Links) != 3 {
+					t.Errorf("%q links = %d, want 3", s.Keyword, len(s.Links))
+				}
+			}
+			if strings.Join(keywords, ",") != strings.Join(tt.wantKeywords, ",") {
+				t.Errorf("keywords = %v, want %v", keywords, tt.wantKeywords)
+			}
+			if strings.Join(sections, ",") != strings.Join(tt.wantSections, ",") {
+				t.Errorf("sections = %v, want %v", sections, tt.wantSections)
+			}
+			if got[0].Query != tt.wantQuery {
+				t.Errorf("query = %q, want %q", got[0].Query, tt.wantQuery)
+			}
+		})
+	}
+}
+
+func TestBuildImageSuggestionsLimit(t *testing.T) {
+	var content strings.Builder
+	for i := 0; i < maxImageSuggestions+5; i++ {
+		fmt.Fprintf(&content, "## 知识点%d\n", i)
+	}
+	got := buildImageSuggestions(&model.Lesson{Title: "复习", Content: wrapLessonText(content.String())})
+	if len(got) != maxImageSuggestions {
+		t.Errorf("suggestions = %d, want %d", len(got), maxImageSuggestions)
+	}
+}
+
+func TestImageSearchLinksEscapeQuery(t *testing.T) {
+	links := imageSearchLinks("勾股定理 示意图")
+	want := map[string]string{
+		"Unsplash":          "https://unsplash.com/s/photos/%E5%8B%BE%E8%82%A1%E5%AE%9A%E7%90%86%20%E7%A4%BA%E6%84%8F%E5%9B%BE",
+		"Wikimedia Commons": "https://commons.wikimedia.org/w/index.php?search=%E5%8B%BE%E8%82%A1%E5%AE%9A%E7%90%86+%E7%A4%BA%E6%84%8F%E5%9B%BE&title=Special:MediaSearch&type=image",
+	}
+	for _, link := range links {
+		if w, ok := want[link.Source]; ok && link.URL != w {
+			t.Errorf("%s url = %q, want %q", link.Source, link.URL, w)
+		}
+	}
+}
+
+func TestSuggestImagesVisibility(t *testing.T) {
+	authorID, readerID := uuid.New(), uuid.New()
+	draft := publishableLesson(authorID, model.LessonStatusDraft)
+	svc := newTestLessonService(newFakeLessonRepo(draft), nil)
+
+	if _, err := svc.SuggestImages(context.Background(), draft.ID, readerID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("reader on draft error = %v, want ErrUnauthorized", err)
+	}
+	got, err := svc.SuggestImages(context.Background(), draft.ID, authorID)
+	if err != nil || got.LessonID != draft.ID || len(got.Suggestions) == 0 {
+		t.Errorf("author suggestions = %+v, %v", got, err)
+	}
+}
@@ -0,0 +1,347 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/safehttp"
+
+	"github.com/google/uuid"
+	"golang.org/x/net/html"
+	"golang.org/x/net/html/atom"
+)
+
+const (
+	// importMaxBodyBytes 导入网页的最大字节数
+	importMaxBodyBytes = 2 << 20
+	// importMinContentRunes 提取出的正文少于该字数时视为提取失败
+	importMinContentRunes = 50
+	// importMaxContentRunes 正文超出部分截断，避免把整站内容塞进教案
+	importMaxContentRunes = 50000
+)
+
+var (
+	ErrImportURLInvalid   = errors.New("URL 格式无效，仅支持 http/https")
+	ErrImportURLForbidden = errors.New("不允许导入内网或保留地址的网页")
+	ErrImportNotHTML      = errors.New("目标地址不是 HTML 网页")
+	ErrImportNoContent    = errors.New("未能从网页中提取到正文")
+)
+
+// ImportLessonURLRequest 从网页导入教案请求
+type ImportLessonURLRequest struct {
+	URL     string `json:"url" binding:"required,url,max=2048"`
+	Subject string `json:"subject" binding:"required,max=50"`
+	Grade   string `json:"grade" binding:"required,max=20"`
+	// Title 为空时使用网页标题
+	Title string `json:"title" binding:"max=200"`
+}
+
+// noiseTags 不属于正文的标签，连同子树整体丢弃
+var noiseTags = map[atom.Atom]bool{
+	atom.Script: true, atom.Style: true, atom.Noscript: true, atom.Template: true,
+	atom.Nav: true, atom.Header: true, atom.Footer: true, atom.Aside: true,
+	atom.Form: true, atom.Iframe: true, atom.Svg: true, atom.Button: true,
+	atom.Select: true, atom.Input: true, atom.Textarea: true, atom.Object: true,
+	atom.Embed: true, atom.Canvas: true, atom.Video: true, atom.Audio: true,
+}
+
+var whitespacePattern = regexp.MustCompile(`\s+`)
+
+// noiseAttrPattern class/id 命中时视为广告、导航、评论等非正文区块
+var noiseAttrPattern = regexp.MustCompile(`(?i)(^|[\s_-])(ad|ads|advert\w*|banner|sponsor\w*|nav\w*|menu|breadcrumbs?|sidebar|side-bar|footer|header|comments?|share|social|recommend\w*|related|popup|modal|cookie|subscribe|toolbar|pager|pagination)($|[\s_-])`)
+
+func (s *lessonService) ImportFromURL(ctx context.Context, userID uuid.UUID, req *ImportLessonURLRequest) (*model.Lesson, error) {
+	body, contentType, err := safehttp.Fetch(ctx, s.importClient, req.URL, importMaxBodyBytes)
+	switch {
+	case errors.Is(err, safehttp.ErrInvalidURL):
+		return nil, ErrImportURLInvalid
+	case errors.Is(err, safehttp.ErrForbiddenAddress):
+		return nil, ErrImportURLForbidden
+	case err != nil:
+		return nil, fmt.Errorf("抓取网页失败: %w", err)
+	}
+	if contentType != "" && !strings.Contains(strings.ToLower(contentType), "html") {
+		return nil, ErrImportNotHTML
+	}
+
+	title, content, err := extractArticle(body)
+	if err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(req.Title) != "" {
+		title = strings.TrimSpace(req.Title)
+	}
+	if title == "" {
+		title = "网页导入教案"
+	}
+	if runes := []rune(title); len(runes) > 200 {
+		title = string(runes[:200])
+	}
+
+	return s.Create(ctx, userID, &CreateLessonRequest{
+		Title:     title,
+		Subject:   req.Subject,
+		Grade:     req.Grade,
+		Duration:  45,
+		Content:   content,
+		Resources: fmt.Sprintf("来源：<%s>", req.URL),
+		Tags:      []string{"网页导入"},
+	})
+}
+
+// extractArticle 从 HTML 中提取标题与正文 Markdown：
+// 先去掉脚本、导航、广告等噪声区块，再优先取 <article>/<main>，否则取段落文字最多的容器
+func extractArticle(body []byte) (string, string, error) {
+	doc, err := html.Parse(bytes.NewReader(body))
+	if err != nil {
+		return "", "", ErrImportNoContent
+	}
+
+	title := pageTitle(doc)
+	removeNoise(doc)
+
+	root := findFirst(doc, atom.Article)
+	if root == nil {
+		root = findFirst(doc, atom.Main)
+	}
+	if root == nil {
+		root = densestContainer(doc)
+	}
+	if root == nil {
+		return "", "", ErrImportNoContent
+	}
+
+	var sb strings.Builder
+	renderMarkdown(&sb, root)
+	content := strings.TrimSpace(collapseBlankLines(trimMarkdownLines(sb.String())))
+	if len([]rune(content)) < importMinContentRunes {
+		return "", "", ErrImportNoContent
+	}
+	if runes := []rune(content); len(runes) > importMaxContentRunes {
+		content = string(runes[:importMaxContentRunes])
+	}
+	return title, content, nil
+}
+
+// pageTitle 优先使用 og:title，其次 <h1>，最后 <title>
+func pageTitle(doc *html.Node) string {
+	var ogTitle, h1, titleTag string
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode {
+			return true
+		}
+		switch n.DataAtom {
+		case atom.Meta:
+			if attr(n, "property") == "og:title" && ogTitle == "" {
+				ogTitle = strings.TrimSpace(attr(n, "content"))
+			}
+		case atom.H1:
+			if h1 == "" {
+				h1 = normalizeSpace(textContent(n))
+			}
+		case atom.Title:
+			if titleTag == "" {
+				titleTag = normalizeSpace(textContent(n))
+			}
+		}
+		return true
+	})
+	return firstNonEmpty(ogTitle, h1, titleTag)
+}
+
+func removeNoise(n *html.Node) {
+	for child := n.FirstChild; child != nil; {
+		next := child.NextSibling
+		if child.Type == html.CommentNode || (child.Type == html.ElementNode && isNoiseElement(child)) {
+			n.RemoveChild(child)
+		} else {
+			removeNoise(child)
+		}
+		child = next
+	}
+}
+
+func isNoiseElement(n *html.Node) bool {
+	if n.DataAtom == atom.Html || n.DataAtom == atom.Body {
+		return false
+	}
+	if noiseTags[n.DataAtom] {
+		return true
+	}
+	if hasAttr(n, "hidden") || attr(n, "aria-hidden") == "true" {
+		return true
+	}
+	if role := attr(n, "role"); role == "navigation" || role == "banner" || role == "complementary" {
+		return true
+	}
+	return noiseAttrPattern.MatchString(attr(n, "class")) || noiseAttrPattern.MatchString(attr(n, "id"))
+}
+
+// densestContainer 按直接子段落的文字量给容器打分（链接文字按负分处理），返回得分最高的容器
+func densestContainer(doc *html.Node) *html.Node {
+	var best *html.Node
+	bestScore := 0
+	walk(doc, func(n *html.Node) bool {
+		if n.Type != html.ElementNode || (n.DataAtom != atom.Div && n.DataAtom != atom.Section && n.DataAtom != atom.Td && n.DataAtom != atom.Body) {
+			return true
+		}
+		score := 0
+		for child := n.FirstChild; child != nil; child = child.NextSibling {
+			if child.Type != html.ElementNode {
+				continue
+			}
+			switch child.DataAtom {
+			case atom.P, atom.Pre, atom.Blockquote, atom.Ul, atom.Ol, atom.H2, atom.H3, atom.Table:
+				text := len([]rune(normalizeSpace(textContent(child))))
+				links := len([]rune(normalizeSpace(linkText(child))))
+				score += text - 2*links
+			}
+		}
+		if score > bestScore {
+			best, bestScore = n, score
+		}
+		return true
+	})
+	return best
+}
+
+// renderMarkdown 把正文子树转为简单 Markdown：标题、段落、列表、引用、代码块与换行
+func renderMarkdown(sb *strings.Builder, n *html.Node) {
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		switch child.Type {
+		case html.TextNode:
+			// 行内文本只折叠空白，保留与相邻行内元素之间的空格
+			sb.WriteString(whitespacePattern.ReplaceAllString(child.Data, " "))
+			continue
+		case html.ElementNode:
+		default:
+			continue
+		}
+
+		switch child.DataAtom {
+		case atom.H1, atom.H2, atom.H3, atom.H4, atom.H5, atom.H6:
+			level := int(child.Data[1] - '0')
+			if text := normalizeSpace(textContent(child)); text != "" {
+				sb.WriteString("\n\n" + strings.Repeat("#", level) + " " + text + "\n\n")
+			}
+		case atom.P, atom.Div, atom.Section, atom.Table, atom.Tr:
+			sb.WriteString("\n\n")
+			renderMarkdown(sb, child)
+			sb.WriteString("\n\n")
+		case atom.Li:
+			sb.WriteString("\n- ")
+			renderMarkdown(sb, child)
+		case atom.Ul, atom.Ol:
+			sb.WriteString("\n")
+			renderMarkdown(sb, child)
+			sb.WriteString("\n\n")
+		case atom.Blockquote:
+			if text := normalizeSpace(textContent(child)); text != "" {
+				sb.WriteString("\n\n> " + text + "\n\n")
+			}
+		case atom.Pre:
+			sb.WriteString("\n\n```\n" + strings.Trim(textContent(child), "\n") + "\n```\n\n")
+		case atom.Br:
+			sb.WriteString("\n")
+		case atom.Td, atom.Th:
+			renderMarkdown(sb, child)
+			sb.WriteString(" ")
+		case atom.Img:
+			// 图片不随导入复制，避免引用外站资源
+		default:
+			renderMarkdown(sb, child)
+		}
+	}
+}
+
+func walk(n *html.Node, visit func(*html.Node) bool) {
+	if !visit(n) {
+		return
+	}
+	for child := n.FirstChild; child != nil; child = child.NextSibling {
+		walk(child, visit)
+	}
+}
+
+func findFirst(doc *html.Node, tag atom.Atom) *html.Node {
+	var found *html.Node
+	walk(doc, func(n *html.Node) bool {
+		if found != nil {
+			return false
+		}
+		if n.Type == html.ElementNode && n.DataAtom == tag {
+			found = n
+			return false
+		}
+		return true
+	})
+	return found
+}
+
+func textContent(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(node *html.Node) bool {
+		if node.Type == html.TextNode {
+			sb.WriteString(node.Data)
+			sb.WriteString(" ")
+		}
+		return true
+	})
+	return sb.String()
+}
+
+func linkText(n *html.Node) string {
+	var sb strings.Builder
+	walk(n, func(node *html.Node) bool {
+		if node.Type == html.ElementNode && node.DataAtom == atom.A {
+			sb.WriteString(textContent(node))
+			return false
+		}
+		return true
+	})
+	return sb.String()
+}
+
+// trimMarkdownLines 去掉每行首尾空白（代码块内保持原样）
+func trimMarkdownLines(text string) string {
+	lines := strings.Split(text, "\n")
+	inFence := false
+	for i, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			inFence = !inFence
+			lines[i] = strings.TrimSpace(line)
+			continue
+		}
+		if !inFence {
+			lines[i] = strings.TrimSpace(line)
+		}
+	}
+	return strings.Join(lines, "\n")
+}
+
+func hasAttr(n *html.Node, key string) bool {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return true
+		}
+	}
+	return false
+}
+
+func attr(n *html.Node, key string) string {
+	for _, a := range n.Attr {
+		if a.Key == key {
+			return a.Val
+		}
+	}
+	return ""
+}
+
+func normalizeSpace(text string) string {
+	return strings.Join(strings.Fields(text), " ")
+}
@@ -0,0 +1,131 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/pkg/safehttp"
+
+	"github.com/google/uuid"
+)
+
+const importArticlePage = `<!DOCTYPE html>
+<html>
+<head>
+  <title>站点标题 - 教学资源网</title>
+  <meta property="og:title" content="有理数的加法">
+  <script>trackVisitor()</script>
+</head>
+<body>
+  <nav class="main-nav"><a href="/">首页</a><a href="/math">数学</a></nav>
+  <div class="ad-banner">限时优惠，点击购买</div>
+  <article>
+    <h2>教学目标</h2>
+    <p>理解有理数加法的意义，掌握同号两数、异号两数相加的法则，并能熟练进行有理数加法运算。</p>
+    <ul><li>同号相加，取相同符号</li><li>异号相加，取绝对值较大数的符号</li></ul>
+    <div class="share">分享到微信</div>
+    <blockquote>数学是思维的体操。</blockquote>
+  </article>
+  <aside>热门推荐：更多教案</aside>
+  <footer>版权所有</footer>
+</body>
+</html>`
+
+// newTestImportService 导入客户端保留安全校验，但把所有连接转到测试服务器
+func newTestImportService(srv *httptest.Server) (*lessonService, *fakeLessonRepo) {
+	repo := newFakeLessonRepo()
+	svc := newTestLessonService(repo, nil)
+	client := safehttp.NewClient(0)
+	client.Transport = &http.Transport{
+		DialContext: func(ctx context.Context, network, _ string) (net.Conn, error) {
+			var d net.Dialer
+			return d.DialContext(ctx, network, srv.Listener.Addr().String())
+		},
+	}
+	svc.importClient = client
+	return svc, repo
+}
+
+func TestImportFromURLExtractsArticleBody(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/html; charset=utf-8")
+		_, _ = w.Write([]byte(importArticlePage))
+	}))
+	defer srv.Close()
+	svc, repo := newTestImportService(srv)
+
+	lesson, err := svc.ImportFromURL(context.Background(), uuid.New(), &ImportLessonURLRequest{
+		URL: "http://teach.example.org/lesson/1", Subject: "数学", Grade: "七年级",
+	})
+	if err != nil {
+		t.Fatalf("ImportFromURL() error = %v", err)
+	}
+	if lesson.Title != "有理数的加法" {
+		t.Errorf("Title = %q, want og:title", lesson.Title)
+	}
+	if _, ok := repo.lessons[lesson.ID]; !ok {
+		t.Error("imported lesson was not saved")
+	}
+
+	content := normalizeLessonText(lesson.Content)
+	for _, want := range []string{"## 教学目标", "理解有理数加法的意义", "- 同号相加，取相同符号", "> 数学是思维的体操。"} {
+		if !strings.Contains(content, want) {
+			t.Errorf("content missing %q:\n%s", want, content)
+		}
+	}
+	for _, noise := range []string{"trackVisitor", "首页", "限时优惠", "分享到微信", "热门推荐", "版权所有"} {
+		if strings.Contains(content, noise) {
+			t.Errorf("content kept noise %q:\n%s", noise, content)
+		}
+	}
+	if !strings.Contains(lesson.Resources, "http://teach.example.org/lesson/1") {
+		t.Errorf("Resources = %q, want source url", lesson.Resources)
+	}
+}
+
+func TestImportFromURLErrors(t *testing.T) {
+	srv := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "http://192.168.1.1/router", http.StatusFound)
+		case "/json":
+			w.Header().Set("Content-Type", "application/json")
+			_, _ = w.Write([]byte(`{"title":"not html"}`))
+		case "/empty":
+			w.Header().Set("Content-Type", "text/html")
+			_, _ = w.Write([]byte(`<html><body><nav>菜单</nav><p>太短</p></body></html>`))
+		}
+	}))
+	defer srv.Close()
+	svc, _ := newTestImportService(srv)
+
+	tests := []struct {
+		name    string
+		url     string
+		wantErr error
+	}{
+		{name: "loopback", url: "http://127.0.0.1:8080/", wantErr: ErrImportURLForbidden},
+		{name: "private", url: "http://10.0.0.5/", wantErr: ErrImportURLForbidden},
+		{name: "link-local", url: "http://169.254.169.254/latest/meta-data/", wantErr: ErrImportURLForbidden},
+		{name: "redirect to private ip", url: "http://teach.example.org/redirect", wantErr: ErrImportURLForbidden},
+		{name: "unsupported scheme", url: "ftp://teach.example.org/", wantErr: ErrImportURLInvalid},
+		{name: "not html", url: "http://teach.example.org/json", wantErr: ErrImportNotHTML},
+		{name: "no content", url: "http://teach.example.org/empty", wantErr: ErrImportNoContent},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := svc.ImportFromURL(context.Background(), uuid.New(), &ImportLessonURLRequest{
+				URL: tt.url, Subject: "数学", Grade: "七年级",
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Errorf("ImportFromURL() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,38 @@
+package service
+
+import (
+	"context"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+// markLessonInteractions 为列表项标记当前用户是否已点赞、收藏：收藏与点赞各批量查询一次，
+// 不逐条查询；viewerID 为空（匿名访问）时不做标记，查询失败只记录日志，不影响列表返回
+func markLessonInteractions(ctx context.Context, favoriteRepo repository.FavoriteRepository, likeRepo repository.LikeRepository, viewerID *uuid.UUID, items []model.LessonListItem) {
+	if viewerID == nil || len(items) == 0 {
+		return
+	}
+
+	lessonIDs := make([]uuid.UUID, len(items))
+	for i, item := range items {
+		lessonIDs[i] = item.ID
+	}
+
+	favorited, err := favoriteRepo.BatchExists(ctx, *viewerID, lessonIDs)
+	if err != nil {
+		logger.Warn("Failed to load favorite flags for lesson list", logger.String("user_id", viewerID.String()), logger.Err(err))
+	}
+	liked, err := likeRepo.BatchExists(ctx, *viewerID, lessonIDs)
+	if err != nil {
+		logger.Warn("Failed to load like flags for lesson list", logger.String("user_id", viewerID.String()), logger.Err(err))
+	}
+
+	for i := range items {
+		items[i].IsFavorited = favorited[items[i].ID]
+		items[i].IsLiked = liked[items[i].ID]
+	}
+}
@@ -0,0 +1,118 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// listingLessonRepo 按创建顺序返回全部教案
+type listingLessonRepo struct {
+	*fakeLessonRepo
+	order []uuid.UUID
+}
+
+func (r *listingLessonRepo) List(ctx context.Context, filter repository.LessonFilter, page, pageSize int) ([]model.Lesson, int64, error) {
+	lessons := make([]model.Lesson, 0, len(r.order))
+	for _, id := range r.order {
+		lessons = append(lessons, *r.lessons[id])
+	}
+	return lessons, int64(len(lessons)), nil
+}
+
+// countingFavoriteRepo 记录批量查询次数，err 非空时批量查询失败
+type countingFavoriteRepo struct {
+	*fakeFavoriteRepo
+	batches int
+	err     error
+}
+
+func (r *countingFavoriteRepo) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	r.batches++
+	if r.err != nil {
+		return nil, r.err
+	}
+	return r.fakeFavoriteRepo.BatchExists(ctx, userID, lessonIDs)
+}
+
+type countingLikeRepo struct {
+	*fakeLikeRepo
+	batches int
+}
+
+func (r *countingLikeRepo) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	r.batches++
+	return r.fakeLikeRepo.BatchExists(ctx, userID, lessonIDs)
+}
+
+func TestListMarksLessonInteractions(t *testing.T) {
+	ctx := context.Background()
+	viewer, other := uuid.New(), uuid.New()
+	liked := &model.Lesson{Title: "仅点赞"}
+	favorited := &model.Lesson{Title: "仅收藏"}
+	both := &model.Lesson{Title: "点赞并收藏"}
+	neither := &model.Lesson{Title: "都没有"}
+	lessons := newFakeLessonRepo(liked, favorited, both, neither)
+	repo := &listingLessonRepo{fakeLessonRepo: lessons, order: []uuid.UUID{liked.ID, favorited.ID, both.ID, neither.ID}}
+
+	favoriteRepo := &countingFavoriteRepo{fakeFavoriteRepo: &fakeFavoriteRepo{marks: fakeMarks{
+		{viewer, favorited.ID}: true,
+		{viewer, both.ID}:      true,
+		{other, neither.ID}:    true,
+	}}}
+	likeRepo := &countingLikeRepo{fakeLikeRepo: &fakeLikeRepo{marks: fakeMarks{
+		{viewer, liked.ID}:  true,
+		{viewer, both.ID}:   true,
+		{other, neither.ID}: true,
+	}}}
+	svc := NewLessonService(repo, favoriteRepo, likeRepo, nil, nil, nil, nil, nil, nil, nil, nil, "")
+
+	items, _, err := svc.List(ctx, repository.LessonFilter{Viewer: &viewer}, 1, 20)
+	if err != nil {
+		t.Fatalf("List() error = %v", err)
+	}
+	want := map[string][2]bool{
+		"仅点赞":   {true, false},
+		"仅收藏":   {false, true},
+		"点赞并收藏": {true, true},
+		"都没有":   {false, false},
+	}
+	for _, item := range items {
+		if got := [2]bool{item.IsLiked, item.IsFavorited}; got != want[item.Title] {
+			t.Errorf("%s (liked, favorited) = %v, want %v", item.Title, got, want[item.Title])
+		}
+	}
+	// 每页收藏、点赞各查询一次，而不是逐条查询
+	if favoriteRepo.batches != 1 || likeRepo.batches != 1 {
+		t.Errorf("batch queries = (%d favorites, %d likes), want one each", favoriteRepo.batches, likeRepo.batches)
+	}
+
+	// 匿名访问不做标记也不查询
+	items, _, _ = svc.List(ctx, repository.LessonFilter{}, 1, 20)
+	for _, item := range items {
+		if item.IsLiked || item.IsFavorited {
+			t.Errorf("anonymous %s marked as (%v, %v)", item.Title, item.IsLiked, item.IsFavorited)
+		}
+	}
+	if favoriteRepo.batches != 1 || likeRepo.batches != 1 {
+		t.Errorf("anonymous list queried interactions")
+	}
+}
+
+func TestMarkLessonInteractionsToleratesErrors(t *testing.T) {
+	viewer := uuid.New()
+	lessonID := uuid.New()
+	items := []model.LessonListItem{{ID: lessonID}}
+	favoriteRepo := &countingFavoriteRepo{fakeFavoriteRepo: &fakeFavoriteRepo{}, err: errors.New("db down")}
+	likeRepo := &fakeLikeRepo{marks: fakeMarks{{viewer, lessonID}: true}}
+
+	markLessonInteractions(context.Background(), favoriteRepo, likeRepo, &viewer, items)
+	if items[0].IsFavorited || !items[0].IsLiked {
+		t.Errorf("item = (liked %v, favorited %v), want like flag kept when favorites fail", items[0].IsLiked, items[0].IsFavorited)
+	}
+}
@@ -0,0 +1,100 @@
+package service
+
+import (
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+)
+
+var ErrLessonIncomplete = errors.New("教案内容不完整，无法发布")
+
+// LessonMissingField 发布校验缺失的字段
+type LessonMissingField struct {
+	Field string `json:"field"`
+	Label string `json:"label"`
+}
+
+// LessonIncompleteError 发布校验未通过，errors.Is 可匹配 ErrLessonIncomplete
+type LessonIncompleteError struct {
+	Missing []LessonMissingField
+}
+
+func (e *LessonIncompleteError) Error() string {
+	labels := make([]string, len(e.Missing))
+	for i, field := range e.Missing {
+		labels[i] = field.Label
+	}
+	return "发布前请补充：" + strings.Join(labels, "、")
+}
+
+func (e *LessonIncompleteError) Unwrap() error {
+	return ErrLessonIncomplete
+}
+
+// publishRequiredFields 发布前必须填写的字段，按页面展示顺序排列
+var publishRequiredFields = []struct {
+	Field string
+	Label string
+	Value func(lesson *model.Lesson) string
+}{
+	{"title", "标题", func(l *model.Lesson) string { return l.Title }},
+	{"objectives", "教学目标", func(l *model.Lesson) string { return l.Objectives }},
+	{"content", "教学内容", func(l *model.Lesson) string { return l.Content }},
+}
+
+// validateLessonForPublish 校验发布所需字段，返回 *LessonIncompleteError 列出全部缺失项
+func validateLessonForPublish(lesson *model.Lesson) error {
+	var missing []LessonMissingField
+	for _, field := range publishRequiredFields {
+		if isBlankLessonField(field.Value(lesson)) {
+			missing = append(missing, LessonMissingField{Field: field.Field, Label: field.Label})
+		}
+	}
+	if len(missing) > 0 {
+		return &LessonIncompleteError{Missing: missing}
+	}
+	return nil
+}
+
+// isBlankLessonField 字段是否为空：纯文本去空白后为空，
+// JSON 字段（如 {}、{"text": ""}、{"items": []}）不含任何非空文本时也视为空
+func isBlankLessonField(raw string) bool {
+	raw = strings.TrimSpace(raw)
+	if raw == "" {
+		return true
+	}
+
+	var value interface{}
+	if err := json.Unmarshal([]byte(raw), &value); err != nil {
+		return false
+	}
+	return isBlankJSONValue(value)
+}
+
+func isBlankJSONValue(value interface{}) bool {
+	switch v := value.(type) {
+	case nil:
+		return true
+	case string:
+		return strings.TrimSpace(v) == ""
+	case map[string]interface{}:
+		for _, item := range v {
+			if !isBlankJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	case []interface{}:
+		for _, item := range v {
+			if !isBlankJSONValue(item) {
+				return false
+			}
+		}
+		return true
+	default:
+		// 数字、布尔值视为已填写
+		return false
+	}
+}
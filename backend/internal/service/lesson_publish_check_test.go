@@ -0,0 +1,96 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestIsBlankLessonField(t *testing.T) {
+	tests := []struct {
+		raw  string
+		want bool
+	}{
+		{raw: "", want: true},
+		{raw: "  \n ", want: true},
+		{raw: "{}", want: true},
+		{raw: `{"text": "  "}`, want: true},
+		{raw: `{"items": [], "note": null}`, want: true},
+		{raw: `{"items": [{"text": ""}]}`, want: true},
+		{raw: "纯文本目标", want: false},
+		{raw: `{"text": "理解有理数"}`, want: false},
+		{raw: `{"items": ["", "小组讨论"]}`, want: false},
+		{raw: `{"minutes": 0}`, want: false},
+		{raw: "{not json", want: false},
+	}
+
+	for _, tt := range tests {
+		if got := isBlankLessonField(tt.raw); got != tt.want {
+			t.Errorf("isBlankLessonField(%q) = %v, want %v", tt.raw, got, tt.want)
+		}
+	}
+}
+
+func TestValidateLessonForPublish(t *testing.T) {
+	lesson := &model.Lesson{Title: " ", Objectives: `{"text": ""}`, Content: wrapLessonText("正文")}
+
+	err := validateLessonForPublish(lesson)
+	var incomplete *LessonIncompleteError
+	if !errors.As(err, &incomplete) || !errors.Is(err, ErrLessonIncomplete) {
+		t.Fatalf("validateLessonForPublish() error = %v, want *LessonIncompleteError", err)
+	}
+	want := []LessonMissingField{{Field: "title", Label: "标题"}, {Field: "objectives", Label: "教学目标"}}
+	if !reflect.DeepEqual(incomplete.Missing, want) {
+		t.Errorf("Missing = %+v, want %+v", incomplete.Missing, want)
+	}
+	if got := err.Error(); got != "发布前请补充：标题、教学目标" {
+		t.Errorf("Error() = %q", got)
+	}
+
+	if err := validateLessonForPublish(publishableLesson(uuid.New(), model.LessonStatusDraft)); err != nil {
+		t.Errorf("validateLessonForPublish() on complete lesson error = %v", err)
+	}
+}
+
+func TestPublishRequiresCompleteLesson(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+
+	t.Run("incomplete draft stays unpublished", func(t *testing.T) {
+		lesson := publishableLesson(userID, model.LessonStatusDraft)
+		lesson.Content = wrapLessonText("")
+		repo := newFakeLessonRepo(lesson)
+		versions := &fakeVersionRepo{}
+		svc := newTestLessonService(repo, versions)
+
+		if err := svc.Publish(ctx, lesson.ID, userID); !errors.Is(err, ErrLessonIncomplete) {
+			t.Fatalf("Publish() error = %v, want ErrLessonIncomplete", err)
+		}
+		stored, _ := repo.GetByID(ctx, lesson.ID)
+		if stored.Status != model.LessonStatusDraft || stored.Version != 1 || len(versions.versions) != 0 {
+			t.Errorf("stored = (%s, v%d, %d versions), want untouched draft", stored.Status, stored.Version, len(versions.versions))
+		}
+	})
+
+	t.Run("published lesson checks the draft", func(t *testing.T) {
+		lesson := publishableLesson(userID, model.LessonStatusPublished)
+		repo := newFakeLessonRepo(lesson)
+		svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+		if _, err := svc.Update(ctx, lesson.ID, userID, &UpdateLessonRequest{Objectives: strPtr("")}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+		if err := svc.Publish(ctx, lesson.ID, userID); !errors.Is(err, ErrLessonIncomplete) {
+			t.Fatalf("Publish() error = %v, want ErrLessonIncomplete", err)
+		}
+		stored, _ := repo.GetByID(ctx, lesson.ID)
+		if stored.DraftContent == "" || normalizeLessonText(stored.Objectives) != "理解有理数" {
+			t.Errorf("stored objectives = %q, want published version and draft kept", stored.Objectives)
+		}
+	})
+}
@@ -0,0 +1,249 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+	"unicode"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// 可读性难度结论，相对教案标注的年级而言
+const (
+	ReadabilityEasy     = "easy"
+	ReadabilitySuitable = "suitable"
+	ReadabilityHard     = "hard"
+	ReadabilityUnknown  = "unknown"
+)
+
+const (
+	// readabilityMinUnits 字数过少时指标没有参考意义，不给出年级建议
+	readabilityMinUnits = 30
+	// readabilityLongSentence 超过该长度（字/词）的句子计为长句
+	readabilityLongSentence = 25
+	// readabilityHanPerMinute 中文阅读速度（字/分钟）
+	readabilityHanPerMinute = 300
+	// readabilityWordsPerMinute 英文阅读速度（词/分钟）
+	readabilityWordsPerMinute = 200
+	// maxReadabilityTopTerms 返回的高频专业词上限
+	maxReadabilityTopTerms = 10
+)
+
+// ReadabilityTerm 专业词及出现次数
+type ReadabilityTerm struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
+}
+
+// LessonReadability 教案可读性分析结果。句长以字计（英文按词计），专业词密度为每百字出现次数
+type LessonReadability struct {
+	LessonID            uuid.UUID         `json:"lesson_id"`
+	CharCount           int               `json:"char_count"`
+	SentenceCount       int               `json:"sentence_count"`
+	AvgSentenceLength   float64           `json:"avg_sentence_length"`
+	LongSentenceRatio   float64           `json:"long_sentence_ratio"`
+	TermCount           int               `json:"term_count"`
+	TermDensity         float64           `json:"term_density"`
+	TopTerms            []ReadabilityTerm `json:"top_terms"`
+	ReadingMinutes      int               `json:"reading_minutes"`
+	SuggestedGradeLevel int               `json:"suggested_grade_level"`
+	SuggestedGrade      string            `json:"suggested_grade"`
+	LessonGradeLevel    int               `json:"lesson_grade_level"`
+	Difficulty          string            `json:"difficulty"`
+	Comment             string            `json:"comment"`
+}
+
+var (
+	sentenceBreakPattern = regexp.MustCompile(`[。！？!?；;\n]+|\.\s+`)
+	markdownMarkPattern  = regexp.MustCompile(`(?m)^\s*(#{1,6}|[-*+>]|\d+[.、)）])\s+|[*_` + "`" + `|]`)
+	latinWordPattern     = regexp.MustCompile(`[A-Za-z]+(?:['-][A-Za-z]+)*|\d+(?:\.\d+)?`)
+	// academicTermPattern 以学科术语常见后缀结尾的词，如“勾股定理”“二次函数”“光合作用”
+	academicTermPattern = regexp.MustCompile(`\p{Han}{2}(定理|定律|公式|函数|方程|原理|概念|反应|作用|结构|效应|守恒|元素|分子|原子|细胞|坐标|向量|矢量|频率|概率|系数|修辞|语法|时态|句型)`)
+)
+
+// sentenceLengthGrades 平均句长阈值及对应年级，超出最后一档视为十二年级
+var sentenceLengthGrades = []struct {
+	max   float64
+	level int
+}{
+	{10, 2}, {15, 4}, {20, 6}, {25, 8}, {32, 10},
+}
+
+// termDensityGrades 专业词密度阈值及对应年级
+var termDensityGrades = []struct {
+	max   float64
+	level int
+}{
+	{0.5, 2}, {1.5, 4}, {3, 6}, {5, 8}, {8, 10},
+}
+
+// AnalyzeReadability 分析教案可读性并给出适合的年级建议；已发布教案所有人可查，草稿仅作者可查
+func (s *lessonService) AnalyzeReadability(ctx context.Context, lessonID, userID uuid.UUID) (*LessonReadability, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+	return analyzeLessonReadability(lesson), nil
+}
+
+func analyzeLessonReadability(lesson *model.Lesson) *LessonReadability {
+	parts := make([]string, 0, 4)
+	for _, raw := range []string{lesson.Objectives, lesson.Content, lesson.Activities, lesson.Assessment} {
+		if text := normalizeLessonText(raw); text != "" {
+			parts = append(parts, text)
+		}
+	}
+	raw := strings.Join(parts, "\n")
+	text := markdownMarkPattern.ReplaceAllString(raw, "")
+
+	result := &LessonReadability{
+		LessonID:         lesson.ID,
+		TopTerms:         make([]ReadabilityTerm, 0),
+		LessonGradeLevel: lesson.GradeLevel,
+		Difficulty:       ReadabilityUnknown,
+	}
+
+	han, words := countReadingUnits(text)
+	result.CharCount = han + words
+	result.ReadingMinutes = int(math.Ceil(float64(han)/readabilityHanPerMinute + float64(words)/readabilityWordsPerMinute))
+	if result.ReadingMinutes < 1 && result.CharCount > 0 {
+		result.ReadingMinutes = 1
+	}
+
+	longSentences := 0
+	for _, sentence := range sentenceBreakPattern.Split(text, -1) {
+		h, w := countReadingUnits(sentence)
+		if h+w == 0 {
+			continue
+		}
+		result.SentenceCount++
+		if h+w > readabilityLongSentence {
+			longSentences++
+		}
+	}
+	if result.SentenceCount > 0 {
+		result.AvgSentenceLength = roundTo(float64(result.CharCount)/float64(result.SentenceCount), 1)
+		result.LongSentenceRatio = roundTo(float64(longSentences)/float64(result.SentenceCount), 2)
+	}
+
+	// 加粗标记会被清理掉，专业词需在原文上识别
+	result.TopTerms, result.TermCount = collectReadabilityTerms(raw)
+	if result.CharCount > 0 {
+		result.TermDensity = roundTo(float64(result.TermCount)*100/float64(result.CharCount), 2)
+	}
+
+	if result.CharCount < readabilityMinUnits {
+		result.Comment = "教案内容过少，无法评估可读性"
+		return result
+	}
+
+	level := suggestReadabilityGrade(result.AvgSentenceLength, result.TermDensity)
+	result.SuggestedGradeLevel = level
+	result.SuggestedGrade = model.GradeLabel(level)
+	result.Difficulty, result.Comment = readabilityVerdict(level, lesson.GradeLevel)
+	return result
+}
+
+// countReadingUnits 统计汉字数与英文单词（含数字）数
+func countReadingUnits(text string) (han, words int) {
+	for _, r := range text {
+		if unicode.Is(unicode.Han, r) {
+			han++
+		}
+	}
+	return han, len(latinWordPattern.FindAllString(text, -1))
+}
+
+// collectReadabilityTerms 识别专业词：学科术语后缀词、加粗词与引号内术语，按出现次数降序
+func collectReadabilityTerms(text string) ([]ReadabilityTerm, int) {
+	counts := make(map[string]int)
+	total := 0
+	for _, term := range academicTermPattern.FindAllString(text, -1) {
+		counts[term]++
+		total++
+	}
+	for _, pattern := range []*regexp.Regexp{markdownBoldPattern, quotedTermPattern} {
+		for _, match := range pattern.FindAllStringSubmatch(text, -1) {
+			term := strings.TrimSpace(match[1])
+			if term == "" {
+				continue
+			}
+			counts[term]++
+			total++
+		}
+	}
+
+	terms := make([]ReadabilityTerm, 0, len(counts))
+	for term, count := range counts {
+		terms = append(terms, ReadabilityTerm{Term: term, Count: count})
+	}
+	sort.Slice(terms, func(i, j int) bool {
+		if terms[i].Count != terms[j].Count {
+			return terms[i].Count > terms[j].Count
+		}
+		return terms[i].Term < terms[j].Term
+	})
+	if len(terms) > maxReadabilityTopTerms {
+		terms = terms[:maxReadabilityTopTerms]
+	}
+	return terms, total
+}
+
+// suggestReadabilityGrade 句长与专业词密度分别映射到年级后按 6:4 加权
+func suggestReadabilityGrade(avgSentenceLength, termDensity float64) int {
+	bySentence := model.GradeLevelMax
+	for _, item := range sentenceLengthGrades {
+		if avgSentenceLength <= item.max {
+			bySentence = item.level
+			break
+		}
+	}
+	byTerms := model.GradeLevelMax
+	for _, item := range termDensityGrades {
+		if termDensity <= item.max {
+			byTerms = item.level
+			break
+		}
+	}
+
+	level := int(math.Round(0.6*float64(bySentence) + 0.4*float64(byTerms)))
+	if level < model.GradeLevelMin {
+		return model.GradeLevelMin
+	}
+	if level > model.GradeLevelMax {
+		return model.GradeLevelMax
+	}
+	return level
+}
+
+// readabilityVerdict 对比建议年级与教案年级：高出两级及以上偏难，低三级及以上偏易
+func readabilityVerdict(suggested, lessonLevel int) (string, string) {
+	label := model.GradeLabel(suggested)
+	if lessonLevel == model.GradeLevelUnknown {
+		return ReadabilityUnknown, fmt.Sprintf("内容难度约适合%s，教案未标注可识别的年级", label)
+	}
+
+	diff := suggested - lessonLevel
+	switch {
+	case diff >= 2:
+		return ReadabilityHard, fmt.Sprintf("内容难度约适合%s，对%s学生可能偏难，建议缩短句子或减少专业术语", label, model.GradeLabel(lessonLevel))
+	case diff <= -3:
+		return ReadabilityEasy, fmt.Sprintf("内容难度约适合%s，对%s学生可能偏浅，可适当增加深度", label, model.GradeLabel(lessonLevel))
+	default:
+		return ReadabilitySuitable, fmt.Sprintf("内容难度约适合%s，与教案年级基本匹配", label)
+	}
+}
+
+func roundTo(value float64, digits int) float64 {
+	scale := math.Pow(10, float64(digits))
+	return math.Round(value*scale) / scale
+}
@@ -0,0 +1,161 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// readabilityLesson 按给定年级与正文构造教案
+func readabilityLesson(grade, content string) *model.Lesson {
+	return &model.Lesson{
+		ID:         uuid.New(),
+		UserID:     uuid.New(),
+		Grade:      grade,
+		GradeLevel: model.NormalizeGrade(grade),
+		Content:    wrapLessonText(content),
+		Status:     model.LessonStatusDraft,
+	}
+}
+
+func TestAnalyzeLessonReadability(t *testing.T) {
+	// 每句 7 字、无专业词
+	simple := strings.Repeat("我们一起读课文。", 10)
+	// 每句 35 字、含“勾股定理”“二次函数”“计算公式”三个专业词
+	dense := strings.Repeat("根据勾股定理与二次函数的性质推导直角三角形斜边长度的计算公式并验证结论。", 3)
+
+	tests := []struct {
+		name           string
+		grade          string
+		content        string
+		wantChars      int
+		wantSentences  int
+		wantAvg        float64
+		wantLongRatio  float64
+		wantTermCount  int
+		wantDensity    float64
+		wantLevel      int
+		wantDifficulty string
+	}{
+		{
+			name: "short sentences suit lower grades", grade: "二年级", content: simple,
+			wantChars: 70, wantSentences: 10, wantAvg: 7, wantLevel: 2, wantDifficulty: ReadabilitySuitable,
+		},
+		{
+			name: "simple text is easy for middle school", grade: "八年级", content: simple,
+			wantChars: 70, wantSentences: 10, wantAvg: 7, wantLevel: 2, wantDifficulty: ReadabilityEasy,
+		},
+		{
+			name: "long sentences with dense terms are hard", grade: "七年级", content: dense,
+			wantChars: 105, wantSentences: 3, wantAvg: 35, wantLongRatio: 1, wantTermCount: 9, wantDensity: 8.57,
+			wantLevel: 12, wantDifficulty: ReadabilityHard,
+		},
+		{
+			name: "unrecognized grade still gets a suggestion", grade: "", content: dense,
+			wantChars: 105, wantSentences: 3, wantAvg: 35, wantLongRatio: 1, wantTermCount: 9, wantDensity: 8.57,
+			wantLevel: 12, wantDifficulty: ReadabilityUnknown,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := analyzeLessonReadability(readabilityLesson(tt.grade, tt.content))
+
+			if got.CharCount != tt.wantChars || got.SentenceCount != tt.wantSentences {
+				t.Errorf("chars = %d sentences = %d, want %d and %d", got.CharCount, got.SentenceCount, tt.wantChars, tt.wantSentences)
+			}
+			if got.AvgSentenceLength != tt.wantAvg || got.LongSentenceRatio != tt.wantLongRatio {
+				t.Errorf("avg = %v long ratio = %v, want %v and %v", got.AvgSentenceLength, got.LongSentenceRatio, tt.wantAvg, tt.wantLongRatio)
+			}
+			if got.TermCount != tt.wantTermCount || got.TermDensity != tt.wantDensity {
+				t.Errorf("terms = %d density = %v, want %d and %v", got.TermCount, got.TermDensity, tt.wantTermCount, tt.wantDensity)
+			}
+			if got.SuggestedGradeLevel != tt.wantLevel || got.SuggestedGrade != model.GradeLabel(tt.wantLevel) {
+				t.Errorf("suggested = %d %q, want %d", got.SuggestedGradeLevel, got.SuggestedGrade, tt.wantLevel)
+			}
+			if got.Difficulty != tt.wantDifficulty || got.Comment == "" {
+				t.Errorf("Difficulty = %q comment = %q, want %q", got.Difficulty, got.Comment, tt.wantDifficulty)
+			}
+			if got.ReadingMinutes != 1 {
+				t.Errorf("ReadingMinutes = %d, want 1", got.ReadingMinutes)
+			}
+		})
+	}
+}
+
+func TestAnalyzeLessonReadabilityTopTerms(t *testing.T) {
+	content := strings.Repeat("根据勾股定理与二次函数的性质推导直角三角形斜边长度的计算公式并验证结论。", 3) +
+		"\n观察**叶绿体**的形态，理解“光合作用”。"
+	got := analyzeLessonReadability(readabilityLesson("七年级", content))
+
+	// 同频按词排序；加粗与引号内术语同样计入，引号内的“光合作用”同时命中术语后缀
+	want := []ReadabilityTerm{
+		{Term: "二次函数", Count: 3}, {Term: "勾股定理", Count: 3}, {Term: "计算公式", Count: 3},
+		{Term: "光合作用", Count: 2}, {Term: "叶绿体", Count: 1},
+	}
+	if !reflect.DeepEqual(got.TopTerms, want) {
+		t.Errorf("TopTerms = %+v, want %+v", got.TopTerms, want)
+	}
+	if got.TermCount != 12 {
+		t.Errorf("TermCount = %d, want 12", got.TermCount)
+	}
+}
+
+func TestAnalyzeLessonReadabilityReadingTime(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{name: "900 han characters", content: strings.Repeat("学生阅读课文。", 150), want: 3},
+		{name: "900 han characters and 100 english words", content: strings.Repeat("学生阅读课文。", 150) + strings.Repeat("read the text aloud ", 25), want: 4},
+		{name: "tiny text rounds up to one minute", content: "认识数字", want: 1},
+		{name: "empty", content: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := analyzeLessonReadability(readabilityLesson("三年级", tt.content)).ReadingMinutes; got != tt.want {
+				t.Errorf("ReadingMinutes = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAnalyzeLessonReadabilityTooShort(t *testing.T) {
+	got := analyzeLessonReadability(readabilityLesson("三年级", "## 目标\n认识数字"))
+
+	if got.CharCount != 6 || got.Difficulty != ReadabilityUnknown || got.SuggestedGradeLevel != 0 || got.SuggestedGrade != "" {
+		t.Errorf("readability = %+v, want no grade suggestion for short text", got)
+	}
+	if got.Comment != "教案内容过少，无法评估可读性" {
+		t.Errorf("Comment = %q", got.Comment)
+	}
+}
+
+func TestAnalyzeReadabilityPermissions(t *testing.T) {
+	ctx := context.Background()
+	draft := readabilityLesson("三年级", "认识数字")
+	published := readabilityLesson("三年级", "认识数字")
+	published.Status = model.LessonStatusPublished
+	svc := newTestLessonService(newFakeLessonRepo(draft, published), nil)
+
+	if _, err := svc.AnalyzeReadability(ctx, draft.ID, draft.UserID); err != nil {
+		t.Errorf("AnalyzeReadability() by owner error = %v", err)
+	}
+	if _, err := svc.AnalyzeReadability(ctx, draft.ID, uuid.New()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("AnalyzeReadability() on others' draft error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.AnalyzeReadability(ctx, published.ID, uuid.New()); err != nil {
+		t.Errorf("AnalyzeReadability() on published lesson error = %v", err)
+	}
+	if _, err := svc.AnalyzeReadability(ctx, uuid.New(), draft.UserID); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("AnalyzeReadability() on missing lesson error = %v, want ErrLessonNotFound", err)
+	}
+}
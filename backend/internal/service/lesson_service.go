@@ -8,10 +8,13 @@ import (
 	"net/http"
 	"strconv"
 	"strings"
+	"time"
 
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/safehttp"
 
 	"github.com/google/uuid"
 )
@@ -20,8 +23,34 @@ var (
 	ErrLessonNotFound  = errors.New("教案不存在")
 	ErrUnauthorized    = errors.New("无权操作此教案")
 	ErrCommentNotFound = errors.New("评论不存在")
+
+	ErrLessonTitleRequired = errors.New("教案标题不能为空")
+	ErrVersionNotFound     = errors.New("版本不存在")
+
+	ErrGenerationSourceNotFound = errors.New("该教案没有关联的生成记录")
+	ErrInvalidGenerationSource  = errors.New("生成记录不存在、未完成或已关联其他教案")
+
+	ErrConfirmTokenRequired = errors.New("该操作需要二次确认")
+	ErrConfirmTokenInvalid  = errors.New("确认令牌无效或已过期，请重新确认")
 )
 
+const (
+	// confirmActionDeleteLesson 删除教案的二次确认操作名
+	confirmActionDeleteLesson = "lesson:delete"
+	// confirmActionPurgeLesson 永久清除教案的二次确认操作名
+	confirmActionPurgeLesson = "lesson:purge"
+	// confirmTokenTTL 二次确认令牌有效期
+	confirmTokenTTL = 2 * time.Minute
+	// defaultLessonDuration 默认课时（分钟），与 lessons.duration 列默认值一致
+	defaultLessonDuration = 45
+)
+
+// DeleteConfirmation 删除二次确认令牌
+type DeleteConfirmation struct {
+	Token     string `json:"confirm_token"`
+	ExpiresAt int64  `json:"expires_at"`
+}
+
 // CreateLessonRequest 创建教案请求
 type CreateLessonRequest struct {
 	Title      string   `json:"title" binding:"required,max=200"`
@@ -34,21 +63,34 @@ type CreateLessonRequest struct {
 	Assessment string   `json:"assessment"`
 	Resources  string   `json:"resources"`
 	Tags       []string `json:"tags"`
+	// CoAuthors 联合署名，仅用于展示，与协作者权限无关
+	CoAuthors []string `json:"co_authors" binding:"omitempty,max=10,dive,max=50"`
+	// ContentType 文本字段格式，默认 markdown；富文本编辑器保存时传 html
+	ContentType string `json:"content_type" binding:"omitempty,oneof=markdown html"`
+	// GenerationID 可选：教案由某次 AI 生成结果保存而来时传入，用于追溯来源
+	GenerationID *uuid.UUID `json:"generation_id"`
 }
 
-// UpdateLessonRequest 更新教案请求
+// UpdateLessonRequest 更新教案请求，按 PATCH 语义处理：
+// 指针字段为 nil（请求中未出现）时保持不变，传空值则清空
 type UpdateLessonRequest struct {
-	Title      string   `json:"title" binding:"max=200"`
-	Subject    string   `json:"subject" binding:"max=50"`
-	Grade      string   `json:"grade" binding:"max=20"`
-	Duration   int      `json:"duration"`
-	Objectives string   `json:"objectives"`
-	Content    string   `json:"content"`
-	Activities string   `json:"activities"`
-	Assessment string   `json:"assessment"`
-	Resources  string   `json:"resources"`
-	Tags       []string `json:"tags"`
-	Status     string   `json:"status"`
+	// Title 不能清空，传空值返回 ErrLessonTitleRequired
+	Title   *string `json:"title" binding:"omitempty,max=200"`
+	Subject *string `json:"subject" binding:"omitempty,max=50"`
+	Grade   *string `json:"grade" binding:"omitempty,max=20"`
+	// Duration 传 0 恢复默认课时
+	Duration   *int      `json:"duration" binding:"omitempty,min=0,max=600"`
+	Objectives *string   `json:"objectives"`
+	Content    *string   `json:"content"`
+	Activities *string   `json:"activities"`
+	Assessment *string   `json:"assessment"`
+	Resources  *string   `json:"resources"`
+	Tags       *[]string `json:"tags"`
+	Status     string    `json:"status"`
+	// CoAuthors 为 nil 时保持不变，传空数组清空联合署名
+	CoAuthors *[]string `json:"co_authors" binding:"omitempty,max=10,dive,max=50"`
+	// ContentType 为空时保持不变
+	ContentType string `json:"content_type" binding:"omitempty,oneof=markdown html"`
 }
 
 // LessonService 教案服务接口
@@ -56,26 +98,61 @@ type LessonService interface {
 	Create(ctx context.Context, userID uuid.UUID, req *CreateLessonRequest) (*model.Lesson, error)
 	GetByID(ctx context.Context, id uuid.UUID, currentUserID *uuid.UUID) (*model.LessonDetail, error)
 	Update(ctx context.Context, id uuid.UUID, userID uuid.UUID, req *UpdateLessonRequest) (*model.Lesson, error)
-	Delete(ctx context.Context, id, userID uuid.UUID) error
+	// ConfirmDelete 申请删除二次确认令牌，permanent 为 true 时令牌用于永久清除
+	ConfirmDelete(ctx context.Context, id, userID uuid.UUID, permanent bool) (*DeleteConfirmation, error)
+	Delete(ctx context.Context, id, userID uuid.UUID, confirmToken string) error
+	// Purge 永久清除教案（含已删除的教案），不可恢复
+	Purge(ctx context.Context, id, userID uuid.UUID, confirmToken string) error
 	List(ctx context.Context, filter repository.LessonFilter, page, pageSize int) ([]model.LessonListItem, int64, error)
 	ListByUser(ctx context.Context, userID uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error)
 	Publish(ctx context.Context, id, userID uuid.UUID) error
-	Search(ctx context.Context, query string, page, pageSize int) ([]model.LessonListItem, int64, error)
+	Search(ctx context.Context, query string, viewerID *uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error)
 	ListVersions(ctx context.Context, lessonID uuid.UUID, userID uuid.UUID) ([]model.LessonVersion, error)
 	GetVersion(ctx context.Context, lessonID uuid.UUID, version int, userID uuid.UUID) (*model.LessonVersion, error)
 	RollbackToVersion(ctx context.Context, lessonID uuid.UUID, version int, userID uuid.UUID) (*model.Lesson, error)
+	LabelVersion(ctx context.Context, lessonID uuid.UUID, version int, userID uuid.UUID, label string) (*model.LessonVersion, error)
+	ListEdits(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditHistory, error)
+	UndoEdit(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditState, error)
+	RedoEdit(ctx context.Context, lessonID, userID uuid.UUID) (*LessonEditState, error)
 	ReviewQuality(ctx context.Context, lessonID uuid.UUID, userID uuid.UUID) (*LessonQualityReview, error)
+	CheckCompliance(ctx context.Context, lessonID, userID uuid.UUID) (*LessonComplianceReport, error)
+	AnalyzeReadability(ctx context.Context, lessonID, userID uuid.UUID) (*LessonReadability, error)
 	CompareVersions(ctx context.Context, lessonID uuid.UUID, userID uuid.UUID, fromVersion, toVersion string) (*LessonVersionDiff, error)
+	Translate(ctx context.Context, lessonID, userID uuid.UUID, target string, saveAsNew bool) (*LessonTranslation, error)
+	StudentVersion(ctx context.Context, lessonID, userID uuid.UUID, mode string, saveAsNew bool) (*LessonStudentVersion, error)
+	Glossary(ctx context.Context, lessonID, userID uuid.UUID) (*LessonGlossary, error)
+	Beautify(ctx context.Context, lessonID, userID uuid.UUID, preview bool) (*LessonBeautify, error)
+	ImportFromURL(ctx context.Context, userID uuid.UUID, req *ImportLessonURLRequest) (*model.Lesson, error)
+	GetSourceGeneration(ctx context.Context, lessonID, userID uuid.UUID) (*LessonGenerationSource, error)
+	SuggestImages(ctx context.Context, lessonID, userID uuid.UUID) (*LessonImageSuggestions, error)
+	GenerateCover(ctx context.Context, lessonID, userID uuid.UUID) (*LessonCover, error)
+	GetCoverURL(ctx context.Context, lessonID uuid.UUID) (string, error)
+	Restore(ctx context.Context, lessonID, userID uuid.UUID) error
+	ArchiveInactive(ctx context.Context, inactiveDays int) (int64, error)
+	RunArchiveScheduler(ctx context.Context, inactiveDays, hour int)
 }
 
 // lessonService 教案服务实现
 type lessonService struct {
-	lessonRepo   repository.LessonRepository
-	favoriteRepo repository.FavoriteRepository
-	likeRepo     repository.LikeRepository
-	versionRepo  repository.VersionRepository
-	cfg          *config.AgentConfig
-	httpClient   *http.Client
+	lessonRepo     repository.LessonRepository
+	favoriteRepo   repository.FavoriteRepository
+	likeRepo       repository.LikeRepository
+	versionRepo    repository.VersionRepository
+	editRepo       repository.LessonEditRepository
+	generationRepo repository.GenerationRepository
+	knowledgeRepo  repository.KnowledgeRepository
+	jwtManager     *jwt.Manager
+	confirmStore   ConfirmTokenStore
+	cfg            *config.AgentConfig
+	httpClient     *http.Client
+	// maxVersions 每个教案保留的最近版本数，0 表示不限制
+	maxVersions int
+	// importClient 抓取外部网页的客户端，禁止访问内网地址
+	importClient *http.Client
+	// complianceRules 课标合规规则，为空时使用内置规则
+	complianceRules []config.ComplianceRule
+	// uploadDir 上传文件目录，自动生成的封面保存在其 covers 子目录
+	uploadDir string
 }
 
 // NewLessonService 创建教案服务
@@ -84,21 +161,80 @@ func NewLessonService(
 	favoriteRepo repository.FavoriteRepository,
 	likeRepo repository.LikeRepository,
 	versionRepo repository.VersionRepository,
+	editRepo repository.LessonEditRepository,
+	generationRepo repository.GenerationRepository,
+	knowledgeRepo repository.KnowledgeRepository,
+	jwtManager *jwt.Manager,
+	confirmStore ConfirmTokenStore,
 	cfg *config.AgentConfig,
+	lessonCfg *config.LessonConfig,
+	uploadDir string,
 ) LessonService {
 	var httpClient *http.Client
 	if cfg != nil {
 		httpClient = newAgentHTTPClient(cfg)
 	}
+	maxVersions := 0
+	var complianceRules []config.ComplianceRule
+	if lessonCfg != nil {
+		maxVersions = lessonCfg.MaxVersions
+		complianceRules = lessonCfg.ComplianceRules
+	}
 
 	return &lessonService{
-		lessonRepo:   lessonRepo,
-		favoriteRepo: favoriteRepo,
-		likeRepo:     likeRepo,
-		versionRepo:  versionRepo,
-		cfg:          cfg,
-		httpClient:   httpClient,
+		lessonRepo:     lessonRepo,
+		favoriteRepo:   favoriteRepo,
+		likeRepo:       likeRepo,
+		versionRepo:    versionRepo,
+		editRepo:       editRepo,
+		generationRepo: generationRepo,
+		knowledgeRepo:  knowledgeRepo,
+		jwtManager:     jwtManager,
+		confirmStore:   confirmStore,
+		cfg:            cfg,
+		httpClient:     httpClient,
+		maxVersions:    maxVersions,
+		importClient:   safehttp.NewClient(0),
+
+		complianceRules: complianceRules,
+		uploadDir:       uploadDir,
+	}
+}
+
+// saveVersion 保存版本快照并按保留数量清理旧版本；清理失败不影响本次保存，下次写入时会再次清理
+func (s *lessonService) saveVersion(ctx context.Context, snapshot *model.LessonVersion) error {
+	if err := s.versionRepo.Create(ctx, snapshot); err != nil {
+		return err
+	}
+	if s.maxVersions > 0 {
+		_, _ = s.versionRepo.Prune(ctx, snapshot.LessonID, s.maxVersions)
+	}
+	return nil
+}
+
+// snapshotVersion 将教案当前内容保存为版本号 lesson.Version 的快照，已发布内容标记为关键版本；
+// 调用方随后递增 lesson.Version。版本功能未启用时跳过
+func (s *lessonService) snapshotVersion(ctx context.Context, lesson *model.Lesson, userID uuid.UUID, summary string) error {
+	if s.versionRepo == nil {
+		return nil
+	}
+
+	contentSnapshot, err := buildLessonSnapshot(lesson)
+	if err != nil {
+		return fmt.Errorf("生成版本快照失败: %w", err)
+	}
+	snapshot := &model.LessonVersion{
+		LessonID:      lesson.ID,
+		VersionNumber: lesson.Version,
+		Content:       contentSnapshot,
+		ChangeSummary: fmt.Sprintf("%s（版本 %d）", summary, lesson.Version),
+		CreatedBy:     &userID,
+		IsKey:         lesson.Status == model.LessonStatusPublished,
 	}
+	if err := s.saveVersion(ctx, snapshot); err != nil {
+		return fmt.Errorf("保存版本快照失败: %w", err)
+	}
+	return nil
 }
 
 func buildLessonSnapshot(lesson *model.Lesson) (string, error) {
@@ -114,6 +250,9 @@ func buildLessonSnapshot(lesson *model.Lesson) (string, error) {
 		"duration":   lesson.Duration,
 		"status":     lesson.Status,
 		"tags":       lesson.Tags,
+		"co_authors": lesson.CoAuthors,
+		// content_type 随正文一起进入快照，回滚/发布草稿时格式与内容保持一致
+		"content_type": lesson.ContentType,
 	})
 	if err != nil {
 		return "", err
@@ -188,12 +327,29 @@ func applyLessonSnapshot(lesson *model.Lesson, snapshot string) error {
 	if tags, ok := data["tags"].(string); ok {
 		lesson.Tags = tags
 	}
+	if coAuthors, ok := data["co_authors"].(string); ok {
+		lesson.CoAuthors = coAuthors
+	}
+	if contentType, ok := data["content_type"].(string); ok && contentType != "" {
+		lesson.ContentType = contentType
+	}
 
 	return nil
 }
 
 func (s *lessonService) Create(ctx context.Context, userID uuid.UUID, req *CreateLessonRequest) (*model.Lesson, error) {
-	tagsJSON, _ := json.Marshal(req.Tags)
+	tags := req.Tags
+	if req.GenerationID != nil {
+		generation, err := s.generationRepo.GetByID(ctx, *req.GenerationID)
+		if err != nil || generation.UserID != userID ||
+			generation.Status != model.GenerationStatusCompleted || generation.LessonID != nil {
+			return nil, ErrInvalidGenerationSource
+		}
+		// 由生成结果保存的教案自动补充标签，用户填写的标签优先
+		tags = mergeLessonTags(req.Tags, generationTags(generation))
+	}
+
+	tagsJSON, _ := json.Marshal(tags)
 
 	// 将objectives和content包装为JSON对象字符串（因为数据库是jsonb类型）
 	// 使用简单的字符串包装，避免双重编码
@@ -212,13 +368,20 @@ func (s *lessonService) Create(ctx context.Context, userID uuid.UUID, req *Creat
 		Assessment: req.Assessment,
 		Resources:  req.Resources,
 		Tags:       string(tagsJSON),
+		CoAuthors:  encodeCoAuthors(req.CoAuthors),
 		Status:     model.LessonStatusDraft,
 	}
+	lesson.ContentType = normalizeContentType(req.ContentType)
 
 	if err := s.lessonRepo.Create(ctx, lesson); err != nil {
 		return nil, err
 	}
 
+	if req.GenerationID != nil {
+		// 关联失败（如并发保存了同一生成结果）不影响教案创建
+		_ = s.generationRepo.LinkLesson(ctx, *req.GenerationID, lesson.ID, lesson.Version)
+	}
+
 	return lesson, nil
 }
 
@@ -231,6 +394,14 @@ func (s *lessonService) GetByID(ctx context.Context, id uuid.UUID, currentUserID
 	// 增加浏览量
 	_ = s.lessonRepo.IncrementViewCount(ctx, id)
 
+	// 作者查看时返回草稿，其他人始终看到正式版
+	hasDraft := false
+	if currentUserID != nil && *currentUserID == lesson.UserID && lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(lesson, lesson.DraftContent); err == nil {
+			hasDraft = true
+		}
+	}
+
 	detail := &model.LessonDetail{
 		ID:            lesson.ID,
 		UserID:        lesson.UserID,
@@ -251,6 +422,12 @@ func (s *lessonService) GetByID(ctx context.Context, id uuid.UUID, currentUserID
 		CommentCount:  lesson.CommentCount,
 		CreatedAt:     lesson.CreatedAt,
 		PublishedAt:   lesson.PublishedAt,
+		HasDraft:      hasDraft,
+		CoverURL:      lesson.CoverURL,
+		ArchivedAt:    lesson.ArchivedAt,
+	}
+	if hasDraft {
+		detail.DraftSavedAt = lesson.DraftSavedAt
 	}
 
 	// 解析标签
@@ -266,6 +443,11 @@ func (s *lessonService) GetByID(ctx context.Context, id uuid.UUID, currentUserID
 		}
 		detail.AuthorAvatar = lesson.User.AvatarURL
 	}
+	detail.CoAuthors = decodeCoAuthors(lesson.CoAuthors)
+	detail.Authors = lessonAuthors(detail.AuthorName, detail.CoAuthors)
+	detail.ContentType = normalizeContentType(lesson.ContentType)
+	// 草稿可能改过年级，按展示值重新计算
+	detail.GradeLevel = model.NormalizeGrade(lesson.Grade)
 
 	// 检查是否已收藏/点赞
 	if currentUserID != nil {
@@ -285,85 +467,199 @@ func (s *lessonService) Update(ctx context.Context, id uuid.UUID, userID uuid.UU
 	if lesson.UserID != userID {
 		return nil, ErrUnauthorized
 	}
+	if req.Title != nil && strings.TrimSpace(*req.Title) == "" {
+		return nil, ErrLessonTitleRequired
+	}
+	before, _ := editableSnapshot(lesson)
 
-	// 保存当前版本快照
-	if s.versionRepo != nil {
-		contentSnapshot, err := buildLessonSnapshot(lesson)
+	// 已发布教案的编辑进入草稿，避免影响正在被浏览的正式版；显式下线/归档时直接生效
+	if lesson.Status == model.LessonStatusPublished && (req.Status == "" || req.Status == model.LessonStatusPublished) {
+		draft, err := s.saveDraft(ctx, lesson, req)
 		if err != nil {
-			return nil, fmt.Errorf("生成版本快照失败: %w", err)
-		}
-
-		snapshot := &model.LessonVersion{
-			LessonID:      lesson.ID,
-			VersionNumber: lesson.Version,
-			Content:       contentSnapshot,
-			ChangeSummary: fmt.Sprintf("编辑前快照（版本 %d）", lesson.Version),
-			CreatedBy:     &userID,
+			return nil, err
 		}
+		after, _ := buildLessonSnapshot(draft)
+		s.recordEdit(ctx, lesson.ID, userID, before, after)
+		return draft, nil
+	}
 
-		if err := s.versionRepo.Create(ctx, snapshot); err != nil {
-			return nil, fmt.Errorf("保存版本快照失败: %w", err)
-		}
+	// 保存当前版本快照
+	if err := s.snapshotVersion(ctx, lesson, userID, "编辑前快照"); err != nil {
+		return nil, err
 	}
 
 	// 递增版本号
 	lesson.Version++
 
-	if req.Title != "" {
-		lesson.Title = req.Title
+	// 下线/归档时若有未发布的草稿，先并入正文
+	if lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(lesson, lesson.DraftContent); err != nil {
+			return nil, fmt.Errorf("解析草稿失败: %w", err)
+		}
+		lesson.DraftContent = ""
+		lesson.DraftSavedAt = nil
+	}
+
+	applyLessonUpdate(lesson, req)
+	if req.Status != "" {
+		lesson.Status = req.Status
+	}
+	if lesson.Status != model.LessonStatusArchived {
+		lesson.ArchivedAt = nil
+	}
+
+	if err := s.lessonRepo.Update(ctx, lesson); err != nil {
+		return nil, err
 	}
-	if req.Subject != "" {
-		lesson.Subject = req.Subject
+
+	after, _ := buildLessonSnapshot(lesson)
+	s.recordEdit(ctx, lesson.ID, userID, before, after)
+	return lesson, nil
+}
+
+// applyLessonUpdate 将编辑请求中出现的字段写入教案（不含状态），未出现的字段保持不变
+func applyLessonUpdate(lesson *model.Lesson, req *UpdateLessonRequest) {
+	if req.Title != nil {
+		lesson.Title = *req.Title
 	}
-	if req.Grade != "" {
-		lesson.Grade = req.Grade
+	if req.Subject != nil {
+		lesson.Subject = *req.Subject
 	}
-	if req.Duration > 0 {
-		lesson.Duration = req.Duration
+	if req.Grade != nil {
+		lesson.Grade = *req.Grade
 	}
-	if req.Objectives != "" {
-		// 检查是否已经是有效的 JSON
-		if strings.HasPrefix(strings.TrimSpace(req.Objectives), "{") {
-			lesson.Objectives = req.Objectives
-		} else {
-			// 包装为 JSON 对象
-			lesson.Objectives = fmt.Sprintf(`{"text": %s}`, strconv.Quote(req.Objectives))
+	if req.Duration != nil {
+		lesson.Duration = *req.Duration
+		if lesson.Duration <= 0 {
+			lesson.Duration = defaultLessonDuration
 		}
 	}
-	if req.Content != "" {
-		// 检查是否已经是有效的 JSON
-		if strings.HasPrefix(strings.TrimSpace(req.Content), "{") {
-			lesson.Content = req.Content
-		} else {
-			// 包装为 JSON 对象
-			lesson.Content = fmt.Sprintf(`{"text": %s}`, strconv.Quote(req.Content))
-		}
+	if req.Objectives != nil {
+		lesson.Objectives = wrapLessonText(*req.Objectives)
 	}
-	if req.Activities != "" {
-		lesson.Activities = req.Activities
+	if req.Content != nil {
+		lesson.Content = wrapLessonText(*req.Content)
 	}
-	if req.Assessment != "" {
-		lesson.Assessment = req.Assessment
+	if req.Activities != nil {
+		lesson.Activities = *req.Activities
 	}
-	if req.Resources != "" {
-		lesson.Resources = req.Resources
+	if req.Assessment != nil {
+		lesson.Assessment = *req.Assessment
 	}
-	if len(req.Tags) > 0 {
-		tagsJSON, _ := json.Marshal(req.Tags)
+	if req.Resources != nil {
+		lesson.Resources = *req.Resources
+	}
+	if req.Tags != nil {
+		tags := *req.Tags
+		if tags == nil {
+			tags = []string{}
+		}
+		tagsJSON, _ := json.Marshal(tags)
 		lesson.Tags = string(tagsJSON)
 	}
-	if req.Status != "" {
-		lesson.Status = req.Status
+	if req.CoAuthors != nil {
+		lesson.CoAuthors = encodeCoAuthors(*req.CoAuthors)
+	}
+	if req.ContentType != "" {
+		lesson.ContentType = normalizeContentType(req.ContentType)
+	}
+}
+
+// wrapLessonText objectives/content 为 jsonb 列：已是 JSON 对象时原样保存，否则包装为 {"text": ...}
+func wrapLessonText(text string) string {
+	if strings.HasPrefix(strings.TrimSpace(text), "{") {
+		return text
+	}
+	return fmt.Sprintf(`{"text": %s}`, strconv.Quote(text))
+}
+
+// normalizeContentType 未知或为空的格式一律按 Markdown 处理，兼容旧数据
+func normalizeContentType(contentType string) string {
+	if strings.EqualFold(strings.TrimSpace(contentType), model.LessonContentTypeHTML) {
+		return model.LessonContentTypeHTML
 	}
+	return model.LessonContentTypeMarkdown
+}
 
+// saveDraft 已发布教案的编辑写入草稿，正式版保持不变，再次发布时才覆盖
+func (s *lessonService) saveDraft(ctx context.Context, lesson *model.Lesson, req *UpdateLessonRequest) (*model.Lesson, error) {
+	draft := *lesson
+	if lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(&draft, lesson.DraftContent); err != nil {
+			return nil, fmt.Errorf("解析草稿失败: %w", err)
+		}
+	}
+	applyLessonUpdate(&draft, req)
+	return s.storeDraft(ctx, lesson, &draft)
+}
+
+// storeDraft 以 draft 的内容替换已发布教案的草稿，返回带草稿内容的教案视图
+func (s *lessonService) storeDraft(ctx context.Context, lesson, draft *model.Lesson) (*model.Lesson, error) {
+	draft.Status = model.LessonStatusPublished
+	draftContent, err := buildLessonSnapshot(draft)
+	if err != nil {
+		return nil, fmt.Errorf("生成草稿失败: %w", err)
+	}
+
+	now := time.Now()
+	lesson.DraftContent = draftContent
+	lesson.DraftSavedAt = &now
 	if err := s.lessonRepo.Update(ctx, lesson); err != nil {
 		return nil, err
 	}
 
-	return lesson, nil
+	draft.DraftContent = draftContent
+	draft.DraftSavedAt = &now
+	return draft, nil
+}
+
+// ConfirmDelete 作者申请删除教案的二次确认令牌，令牌绑定用户、操作与教案，短时间内有效且只能使用一次
+func (s *lessonService) ConfirmDelete(ctx context.Context, id, userID uuid.UUID, permanent bool) (*DeleteConfirmation, error) {
+	action := confirmActionDeleteLesson
+	getLesson := s.lessonRepo.GetByID
+	if permanent {
+		// 永久清除也适用于已删除（回收站中）的教案
+		action = confirmActionPurgeLesson
+		getLesson = s.lessonRepo.GetByIDUnscoped
+	}
+
+	lesson, err := getLesson(ctx, id)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	token, expiresAt, err := s.jwtManager.GenerateConfirmToken(userID.String(), action, id.String(), confirmTokenTTL)
+	if err != nil {
+		return nil, err
+	}
+	return &DeleteConfirmation{Token: token, ExpiresAt: expiresAt}, nil
+}
+
+// consumeConfirmToken 校验二次确认令牌并标记为已使用，重放的令牌按无效处理；
+// 使用记录无法写入时拒绝操作，不退化为可重放
+func (s *lessonService) consumeConfirmToken(ctx context.Context, confirmToken string, userID uuid.UUID, action string, id uuid.UUID) error {
+	if confirmToken == "" {
+		return ErrConfirmTokenRequired
+	}
+	claims, err := s.jwtManager.ValidateConfirmToken(confirmToken, userID.String(), action, id.String())
+	if err != nil || claims.ID == "" || claims.ExpiresAt == nil {
+		return ErrConfirmTokenInvalid
+	}
+
+	fresh, err := s.confirmStore.Consume(ctx, claims.ID, claims.ExpiresAt.Time)
+	if err != nil {
+		return err
+	}
+	if !fresh {
+		return ErrConfirmTokenInvalid
+	}
+	return nil
 }
 
-func (s *lessonService) Delete(ctx context.Context, id, userID uuid.UUID) error {
+func (s *lessonService) Delete(ctx context.Context, id, userID uuid.UUID, confirmToken string) error {
 	lesson, err := s.lessonRepo.GetByID(ctx, id)
 	if err != nil {
 		return ErrLessonNotFound
@@ -373,9 +669,30 @@ func (s *lessonService) Delete(ctx context.Context, id, userID uuid.UUID) error
 		return ErrUnauthorized
 	}
 
+	if err := s.consumeConfirmToken(ctx, confirmToken, userID, confirmActionDeleteLesson, id); err != nil {
+		return err
+	}
+
 	return s.lessonRepo.Delete(ctx, id)
 }
 
+func (s *lessonService) Purge(ctx context.Context, id, userID uuid.UUID, confirmToken string) error {
+	lesson, err := s.lessonRepo.GetByIDUnscoped(ctx, id)
+	if err != nil {
+		return ErrLessonNotFound
+	}
+
+	if lesson.UserID != userID {
+		return ErrUnauthorized
+	}
+
+	if err := s.consumeConfirmToken(ctx, confirmToken, userID, confirmActionPurgeLesson, id); err != nil {
+		return err
+	}
+
+	return s.lessonRepo.Purge(ctx, id)
+}
+
 func (s *lessonService) List(ctx context.Context, filter repository.LessonFilter, page, pageSize int) ([]model.LessonListItem, int64, error) {
 	lessons, total, err := s.lessonRepo.List(ctx, filter, page, pageSize)
 	if err != nil {
@@ -386,6 +703,7 @@ func (s *lessonService) List(ctx context.Context, filter repository.LessonFilter
 	for i, l := range lessons {
 		items[i] = s.toListItem(l)
 	}
+	markLessonInteractions(ctx, s.favoriteRepo, s.likeRepo, filter.Viewer, items)
 
 	return items, total, nil
 }
@@ -414,12 +732,47 @@ func (s *lessonService) Publish(ctx context.Context, id, userID uuid.UUID) error
 		return ErrUnauthorized
 	}
 
+	// 已发布且没有草稿时无内容变化，不产生新版本
+	if lesson.Status == model.LessonStatusPublished && lesson.DraftContent == "" {
+		return nil
+	}
+
+	// 校验即将发布的内容：存在草稿时以草稿为准
+	candidate := *lesson
+	if lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(&candidate, lesson.DraftContent); err != nil {
+			return fmt.Errorf("解析草稿失败: %w", err)
+		}
+	}
+	if err := validateLessonForPublish(&candidate); err != nil {
+		return err
+	}
+
+	// 每次发布前保存快照：首次发布记录发布前的内容，再次发布记录被草稿覆盖的正式版
+	if err := s.snapshotVersion(ctx, lesson, userID, "发布前快照"); err != nil {
+		return err
+	}
+
+	// 存在草稿时，发布即用草稿覆盖正式版
+	if lesson.DraftContent != "" {
+		if err := applyLessonSnapshot(lesson, lesson.DraftContent); err != nil {
+			return fmt.Errorf("解析草稿失败: %w", err)
+		}
+		lesson.DraftContent = ""
+		lesson.DraftSavedAt = nil
+	}
+
+	lesson.Version++
 	lesson.Status = model.LessonStatusPublished
+	if lesson.PublishedAt == nil {
+		now := time.Now()
+		lesson.PublishedAt = &now
+	}
 	return s.lessonRepo.Update(ctx, lesson)
 }
 
-func (s *lessonService) Search(ctx context.Context, query string, page, pageSize int) ([]model.LessonListItem, int64, error) {
-	lessons, total, err := s.lessonRepo.Search(ctx, query, page, pageSize)
+func (s *lessonService) Search(ctx context.Context, query string, viewerID *uuid.UUID, page, pageSize int) ([]model.LessonListItem, int64, error) {
+	lessons, total, err := s.lessonRepo.Search(ctx, query, viewerID, page, pageSize)
 	if err != nil {
 		return nil, 0, err
 	}
@@ -428,11 +781,17 @@ func (s *lessonService) Search(ctx context.Context, query string, page, pageSize
 	for i, l := range lessons {
 		items[i] = s.toListItem(l)
 	}
+	markLessonInteractions(ctx, s.favoriteRepo, s.likeRepo, viewerID, items)
 
 	return items, total, nil
 }
 
 func (s *lessonService) toListItem(l model.Lesson) model.LessonListItem {
+	return lessonListItem(l)
+}
+
+// lessonListItem 将教案转换为列表项，作者名优先取全名
+func lessonListItem(l model.Lesson) model.LessonListItem {
 	item := model.LessonListItem{
 		ID:            l.ID,
 		Title:         l.Title,
@@ -446,6 +805,8 @@ func (s *lessonService) toListItem(l model.Lesson) model.LessonListItem {
 		FavoriteCount: l.FavoriteCount,
 		CreatedAt:     l.CreatedAt,
 		PublishedAt:   l.PublishedAt,
+		GradeLevel:    l.GradeLevel,
+		CoverURL:      l.CoverURL,
 	}
 
 	if l.User != nil {
@@ -455,6 +816,7 @@ func (s *lessonService) toListItem(l model.Lesson) model.LessonListItem {
 		}
 		item.AuthorAvatar = l.User.AvatarURL
 	}
+	item.Authors = lessonAuthors(item.AuthorName, decodeCoAuthors(l.CoAuthors))
 
 	return item
 }
@@ -487,6 +849,29 @@ func (s *lessonService) GetVersion(ctx context.Context, lessonID uuid.UUID, vers
 	return s.versionRepo.GetByVersion(ctx, lessonID, version)
 }
 
+// LabelVersion 给版本命名（空串取消命名），命名后的版本作为里程碑不受版本保留数量限制
+func (s *lessonService) LabelVersion(ctx context.Context, lessonID uuid.UUID, version int, userID uuid.UUID, label string) (*model.LessonVersion, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+	if s.versionRepo == nil {
+		return nil, ErrVersionNotFound
+	}
+
+	found, err := s.versionRepo.SetLabel(ctx, lessonID, version, strings.TrimSpace(label))
+	if err != nil {
+		return nil, err
+	}
+	if !found {
+		return nil, ErrVersionNotFound
+	}
+	return s.versionRepo.GetByVersion(ctx, lessonID, version)
+}
+
 func (s *lessonService) RollbackToVersion(ctx context.Context, lessonID uuid.UUID, version int, userID uuid.UUID) (*model.Lesson, error) {
 	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
 	if err != nil {
@@ -500,7 +885,23 @@ func (s *lessonService) RollbackToVersion(ctx context.Context, lessonID uuid.UUI
 	}
 	v, err := s.versionRepo.GetByVersion(ctx, lessonID, version)
 	if err != nil {
-		return nil, errors.New("版本不存在")
+		return nil, ErrVersionNotFound
+	}
+
+	// 已发布教案的回滚与编辑一样写入草稿（替换未发布的草稿），正式版保持不变
+	if lesson.Status == model.LessonStatusPublished {
+		before, _ := editableSnapshot(lesson)
+		draft := *lesson
+		if err := applyLessonSnapshot(&draft, v.Content); err != nil {
+			return nil, fmt.Errorf("解析版本快照失败: %w", err)
+		}
+		saved, err := s.storeDraft(ctx, lesson, &draft)
+		if err != nil {
+			return nil, err
+		}
+		after, _ := buildLessonSnapshot(saved)
+		s.recordEdit(ctx, lesson.ID, userID, before, after)
+		return saved, nil
 	}
 
 	// 先快照当前版本
@@ -515,9 +916,10 @@ func (s *lessonService) RollbackToVersion(ctx context.Context, lessonID uuid.UUI
 		Content:       contentSnapshot,
 		ChangeSummary: fmt.Sprintf("回滚前快照（版本 %d，目标版本 %d）", lesson.Version, version),
 		CreatedBy:     &userID,
+		IsKey:         lesson.Status == model.LessonStatusPublished,
 	}
 
-	if err := s.versionRepo.Create(ctx, snapshot); err != nil {
+	if err := s.saveVersion(ctx, snapshot); err != nil {
 		return nil, fmt.Errorf("保存回滚快照失败: %w", err)
 	}
 
@@ -525,9 +927,53 @@ func (s *lessonService) RollbackToVersion(ctx context.Context, lessonID uuid.UUI
 		return nil, fmt.Errorf("解析版本快照失败: %w", err)
 	}
 
+	// 残留的草稿随回滚一并清除，避免作者看到的草稿盖住回滚后的内容
+	lesson.DraftContent = ""
+	lesson.DraftSavedAt = nil
 	lesson.Version++
 	if err := s.lessonRepo.Update(ctx, lesson); err != nil {
 		return nil, err
 	}
+
+	// 回滚也计入编辑栈，可被撤销
+	after, _ := buildLessonSnapshot(lesson)
+	s.recordEdit(ctx, lesson.ID, userID, contentSnapshot, after)
 	return lesson, nil
 }
+
+// LessonGenerationSource 教案的来源生成记录
+type LessonGenerationSource struct {
+	Generation     *model.Generation `json:"generation"`
+	InitialVersion int               `json:"initial_version"`
+	CurrentVersion int               `json:"current_version"`
+	// Modified 教案自生成后是否经过编辑（版本号已前进）
+	Modified bool `json:"modified"`
+}
+
+func (s *lessonService) GetSourceGeneration(ctx context.Context, lessonID, userID uuid.UUID) (*LessonGenerationSource, error) {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	// 生成记录包含提示词与参数，仅作者可见
+	if lesson.UserID != userID {
+		return nil, ErrUnauthorized
+	}
+
+	generation, err := s.generationRepo.GetByLessonID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrGenerationSourceNotFound
+	}
+
+	initialVersion := 1
+	if generation.LessonVersion != nil {
+		initialVersion = *generation.LessonVersion
+	}
+
+	return &LessonGenerationSource{
+		Generation:     generation,
+		InitialVersion: initialVersion,
+		CurrentVersion: lesson.Version,
+		Modified:       lesson.Version > initialVersion || lesson.DraftContent != "",
+	}, nil
+}
@@ -0,0 +1,847 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"sort"
+	"testing"
+
+	"lesson-plan/backend/internal/config"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+var errRecordNotFound = errors.New("record not found")
+
+// fakeLessonRepo 内存中的教案仓库，删除为软删除，Purge 才真正移除
+type fakeLessonRepo struct {
+	repository.LessonRepository
+
+	lessons map[uuid.UUID]*model.Lesson
+	deleted map[uuid.UUID]bool
+}
+
+func newFakeLessonRepo(lessons ...*model.Lesson) *fakeLessonRepo {
+	r := &fakeLessonRepo{lessons: make(map[uuid.UUID]*model.Lesson), deleted: make(map[uuid.UUID]bool)}
+	for _, l := range lessons {
+		_ = r.Create(context.Background(), l)
+	}
+	return r
+}
+
+func (r *fakeLessonRepo) Create(ctx context.Context, lesson *model.Lesson) error {
+	if lesson.ID == uuid.Nil {
+		lesson.ID = uuid.New()
+	}
+	if lesson.Version == 0 {
+		lesson.Version = 1
+	}
+	if lesson.Status == "" {
+		lesson.Status = model.LessonStatusDraft
+	}
+	stored := *lesson
+	r.lessons[lesson.ID] = &stored
+	return nil
+}
+
+func (r *fakeLessonRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.Lesson, error) {
+	if r.deleted[id] {
+		return nil, errRecordNotFound
+	}
+	return r.GetByIDUnscoped(ctx, id)
+}
+
+func (r *fakeLessonRepo) GetByIDUnscoped(ctx context.Context, id uuid.UUID) (*model.Lesson, error) {
+	lesson, ok := r.lessons[id]
+	if !ok {
+		return nil, errRecordNotFound
+	}
+	copied := *lesson
+	return &copied, nil
+}
+
+func (r *fakeLessonRepo) Update(ctx context.Context, lesson *model.Lesson) error {
+	stored := *lesson
+	r.lessons[lesson.ID] = &stored
+	return nil
+}
+
+func (r *fakeLessonRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	r.deleted[id] = true
+	return nil
+}
+
+func (r *fakeLessonRepo) Purge(ctx context.Context, id uuid.UUID) error {
+	delete(r.lessons, id)
+	delete(r.deleted, id)
+	return nil
+}
+
+func (r *fakeLessonRepo) IncrementViewCount(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+// fakeVersionRepo 内存中的版本仓库
+type fakeVersionRepo struct {
+	repository.VersionRepository
+
+	versions []model.LessonVersion
+}
+
+func (r *fakeVersionRepo) Create(ctx context.Context, version *model.LessonVersion) error {
+	if version.ID == uuid.Nil {
+		version.ID = uuid.New()
+	}
+	r.versions = append(r.versions, *version)
+	return nil
+}
+
+func (r *fakeVersionRepo) ListByLessonID(ctx context.Context, lessonID uuid.UUID) ([]model.LessonVersion, error) {
+	var list []model.LessonVersion
+	for _, v := range r.versions {
+		if v.LessonID == lessonID {
+			list = append(list, v)
+		}
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].VersionNumber > list[j].VersionNumber })
+	return list, nil
+}
+
+func (r *fakeVersionRepo) GetByVersion(ctx context.Context, lessonID uuid.UUID, version int) (*model.LessonVersion, error) {
+	for _, v := range r.versions {
+		if v.LessonID == lessonID && v.VersionNumber == version {
+			copied := v
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+// Prune 与仓库实现相同的保留规则：关键版本与命名版本不计数也不删除
+func (r *fakeVersionRepo) Prune(ctx context.Context, lessonID uuid.UUID, keep int) (int64, error) {
+	if keep <= 0 {
+		return 0, nil
+	}
+	prunable := func(v model.LessonVersion) bool { return v.LessonID == lessonID && !v.IsKey && v.Label == "" }
+	var numbers []int
+	for _, v := range r.versions {
+		if prunable(v) {
+			numbers = append(numbers, v.VersionNumber)
+		}
+	}
+	if len(numbers) <= keep {
+		return 0, nil
+	}
+	sort.Sort(sort.Reverse(sort.IntSlice(numbers)))
+	cutoff := numbers[keep-1]
+
+	kept := r.versions[:0]
+	var removed int64
+	for _, v := range r.versions {
+		if prunable(v) && v.VersionNumber < cutoff {
+			removed++
+			continue
+		}
+		kept = append(kept, v)
+	}
+	r.versions = kept
+	return removed, nil
+}
+
+func (r *fakeVersionRepo) SetLabel(ctx context.Context, lessonID uuid.UUID, version int, label string) (bool, error) {
+	for i := range r.versions {
+		if r.versions[i].LessonID == lessonID && r.versions[i].VersionNumber == version {
+			r.versions[i].Label = label
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeMarks 用户对教案的点赞或收藏标记，键为 (用户, 教案)
+type fakeMarks map[[2]uuid.UUID]bool
+
+func (m fakeMarks) exists(userID, lessonID uuid.UUID) bool {
+	return m[[2]uuid.UUID{userID, lessonID}]
+}
+
+func (m fakeMarks) batchExists(userID uuid.UUID, lessonIDs []uuid.UUID) map[uuid.UUID]bool {
+	result := make(map[uuid.UUID]bool)
+	for _, id := range lessonIDs {
+		if m.exists(userID, id) {
+			result[id] = true
+		}
+	}
+	return result
+}
+
+type fakeFavoriteRepo struct {
+	repository.FavoriteRepository
+	marks fakeMarks
+}
+
+func (r *fakeFavoriteRepo) Exists(ctx context.Context, userID, lessonID uuid.UUID) (bool, error) {
+	return r.marks.exists(userID, lessonID), nil
+}
+
+func (r *fakeFavoriteRepo) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return r.marks.batchExists(userID, lessonIDs), nil
+}
+
+type fakeLikeRepo struct {
+	repository.LikeRepository
+	marks fakeMarks
+}
+
+func (r *fakeLikeRepo) Exists(ctx context.Context, userID, lessonID uuid.UUID) (bool, error) {
+	return r.marks.exists(userID, lessonID), nil
+}
+
+func (r *fakeLikeRepo) BatchExists(ctx context.Context, userID uuid.UUID, lessonIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	return r.marks.batchExists(userID, lessonIDs), nil
+}
+
+// newTestLessonService 创建只依赖教案与版本仓库的教案服务，versionRepo 为 nil 时不启用版本功能
+func newTestLessonService(lessonRepo repository.LessonRepository, versionRepo repository.VersionRepository) *lessonService {
+	return NewLessonService(lessonRepo, &fakeFavoriteRepo{}, &fakeLikeRepo{}, versionRepo, nil, nil, nil, nil, nil, nil, nil, "").(*lessonService)
+}
+
+// newTestLessonServiceWithAgent 创建调用 agentURL 处 Agent 的教案服务
+func newTestLessonServiceWithAgent(lessonRepo repository.LessonRepository, agentURL string) *lessonService {
+	cfg := &config.AgentConfig{URL: agentURL, Timeout: 5}
+	return NewLessonService(lessonRepo, &fakeFavoriteRepo{}, &fakeLikeRepo{}, nil, nil, nil, nil, nil, nil, cfg, nil, "").(*lessonService)
+}
+
+// newTestLessonServiceWithGenerations 创建可关联生成记录的教案服务
+func newTestLessonServiceWithGenerations(lessonRepo repository.LessonRepository, generationRepo repository.GenerationRepository) *lessonService {
+	return NewLessonService(lessonRepo, &fakeFavoriteRepo{}, &fakeLikeRepo{}, nil, nil, generationRepo, nil, nil, nil, nil, nil, "").(*lessonService)
+}
+
+func strPtr(s string) *string { return &s }
+
+// publishableLesson 满足发布校验的教案
+func publishableLesson(userID uuid.UUID, status string) *model.Lesson {
+	return &model.Lesson{
+		UserID:     userID,
+		Title:      "正式版标题",
+		Subject:    "数学",
+		Grade:      "七年级",
+		Objectives: wrapLessonText("理解有理数"),
+		Content:    wrapLessonText("正式版内容"),
+		Status:     status,
+	}
+}
+
+func TestUpdatePublishedLessonKeepsDraftSeparate(t *testing.T) {
+	ctx := context.Background()
+	authorID, readerID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name             string
+		status           string
+		wantAuthorTitle  string
+		wantReaderTitle  string
+		wantHasDraft     bool
+		wantVersionAfter int
+	}{
+		{
+			name:             "published lesson edits go to draft",
+			status:           model.LessonStatusPublished,
+			wantAuthorTitle:  "草稿标题",
+			wantReaderTitle:  "正式版标题",
+			wantHasDraft:     true,
+			wantVersionAfter: 1,
+		},
+		{
+			name:             "draft lesson edits apply directly",
+			status:           model.LessonStatusDraft,
+			wantAuthorTitle:  "草稿标题",
+			wantReaderTitle:  "草稿标题",
+			wantHasDraft:     false,
+			wantVersionAfter: 2,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := publishableLesson(authorID, tt.status)
+			repo := newFakeLessonRepo(lesson)
+			svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+			if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("草稿标题")}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+
+			authorView, err := svc.GetByID(ctx, lesson.ID, &authorID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if authorView.Title != tt.wantAuthorTitle || authorView.HasDraft != tt.wantHasDraft {
+				t.Errorf("author sees title %q (has_draft=%v), want %q (has_draft=%v)",
+					authorView.Title, authorView.HasDraft, tt.wantAuthorTitle, tt.wantHasDraft)
+			}
+			anonymousView, _ := svc.GetByID(ctx, lesson.ID, nil)
+			if anonymousView.Title != tt.wantReaderTitle {
+				t.Errorf("anonymous title = %q, want %q", anonymousView.Title, tt.wantReaderTitle)
+			}
+			readerView, _ := svc.GetByID(ctx, lesson.ID, &readerID)
+			if readerView.Title != tt.wantReaderTitle || readerView.HasDraft {
+				t.Errorf("reader sees title %q (has_draft=%v), want %q without draft", readerView.Title, readerView.HasDraft, tt.wantReaderTitle)
+			}
+
+			stored, _ := repo.GetByID(ctx, lesson.ID)
+			if stored.Version != tt.wantVersionAfter {
+				t.Errorf("version = %d, want %d", stored.Version, tt.wantVersionAfter)
+			}
+			if (stored.DraftContent != "") != tt.wantHasDraft {
+				t.Errorf("draft stored = %v, want %v", stored.DraftContent != "", tt.wantHasDraft)
+			}
+		})
+	}
+}
+
+func TestPublishAppliesDraft(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	repo := newFakeLessonRepo(lesson)
+	versions := &fakeVersionRepo{}
+	svc := newTestLessonService(repo, versions)
+
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("草稿标题")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := svc.Publish(ctx, lesson.ID, authorID); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+
+	stored, _ := repo.GetByID(ctx, lesson.ID)
+	if stored.Title != "草稿标题" {
+		t.Errorf("title after publish = %q, want draft title", stored.Title)
+	}
+	if stored.DraftContent != "" || stored.DraftSavedAt != nil {
+		t.Error("draft should be cleared after publish")
+	}
+	if stored.Version != 2 {
+		t.Errorf("version = %d, want 2", stored.Version)
+	}
+	if len(versions.versions) != 1 || versions.versions[0].VersionNumber != 1 {
+		t.Fatalf("versions = %+v, want a snapshot of version 1", versions.versions)
+	}
+
+	// 没有草稿时再次发布不产生新版本
+	if err := svc.Publish(ctx, lesson.ID, authorID); err != nil {
+		t.Fatalf("second Publish() error = %v", err)
+	}
+	if len(versions.versions) != 1 {
+		t.Errorf("versions = %d, want no new snapshot", len(versions.versions))
+	}
+}
+
+func TestUpdatePrunesVersionsKeepingKeyAndLabeled(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusDraft)
+	lesson.Version = 7
+	repo := newFakeLessonRepo(lesson)
+
+	// v1 为发布时的关键版本，v2 被命名，v3~v6 为普通编辑快照
+	versions := &fakeVersionRepo{}
+	for n := 1; n <= 6; n++ {
+		v := model.LessonVersion{LessonID: lesson.ID, VersionNumber: n, IsKey: n == 1}
+		if n == 2 {
+			v.Label = "开学版"
+		}
+		_ = versions.Create(ctx, &v)
+	}
+	svc := newTestLessonService(repo, versions)
+	svc.maxVersions = 2
+
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("新标题")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	var got []int
+	for _, v := range versions.versions {
+		got = append(got, v.VersionNumber)
+	}
+	sort.Ints(got)
+	want := []int{1, 2, 6, 7}
+	if len(got) != len(want) {
+		t.Fatalf("remaining versions = %v, want %v", got, want)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Fatalf("remaining versions = %v, want %v", got, want)
+		}
+	}
+}
+
+func TestCreateLessonLinksGeneration(t *testing.T) {
+	ctx := context.Background()
+	userID, otherID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name       string
+		generation model.Generation
+		wantErr    error
+	}{
+		{name: "completed generation is linked", generation: model.Generation{UserID: userID, Status: model.GenerationStatusCompleted}},
+		{name: "other user's generation", generation: model.Generation{UserID: otherID, Status: model.GenerationStatusCompleted}, wantErr: ErrInvalidGenerationSource},
+		{name: "failed generation", generation: model.Generation{UserID: userID, Status: model.GenerationStatusFailed}, wantErr: ErrInvalidGenerationSource},
+		{name: "generation already saved as a lesson", generation: model.Generation{UserID: userID, Status: model.GenerationStatusCompleted, LessonID: &otherID}, wantErr: ErrInvalidGenerationSource},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generationRepo := newFakeGenerationRepo()
+			generation := tt.generation
+			_ = generationRepo.Create(ctx, &generation)
+			lessonRepo := newFakeLessonRepo()
+			svc := newTestLessonServiceWithGenerations(lessonRepo, generationRepo)
+
+			lesson, err := svc.Create(ctx, userID, &CreateLessonRequest{
+				Title: "有理数", Subject: "数学", Grade: "七年级", GenerationID: &generation.ID,
+			})
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Create() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if len(lessonRepo.lessons) != 0 {
+					t.Error("lesson should not be created from an invalid generation")
+				}
+				return
+			}
+
+			stored, _ := generationRepo.GetByID(ctx, generation.ID)
+			if stored.LessonID == nil || *stored.LessonID != lesson.ID {
+				t.Errorf("generation lesson_id = %v, want %s", stored.LessonID, lesson.ID)
+			}
+			if stored.LessonVersion == nil || *stored.LessonVersion != 1 {
+				t.Errorf("generation lesson_version = %v, want 1", stored.LessonVersion)
+			}
+		})
+	}
+}
+
+func TestGetSourceGeneration(t *testing.T) {
+	ctx := context.Background()
+	authorID, readerID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name        string
+		userID      uuid.UUID
+		linked      bool
+		edits       int
+		wantErr     error
+		wantCurrent int
+		wantChanged bool
+	}{
+		{name: "untouched lesson", userID: authorID, linked: true, wantCurrent: 1},
+		{name: "edited twice", userID: authorID, linked: true, edits: 2, wantCurrent: 3, wantChanged: true},
+		{name: "lesson written by hand", userID: authorID, wantErr: ErrGenerationSourceNotFound},
+		{name: "only the author sees the source", userID: readerID, linked: true, wantErr: ErrUnauthorized},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			generationRepo := newFakeGenerationRepo()
+			lessonRepo := newFakeLessonRepo()
+			svc := newTestLessonServiceWithGenerations(lessonRepo, generationRepo)
+
+			req := &CreateLessonRequest{Title: "有理数", Subject: "数学", Grade: "七年级"}
+			var generation model.Generation
+			if tt.linked {
+				generation = model.Generation{UserID: authorID, Status: model.GenerationStatusCompleted}
+				_ = generationRepo.Create(ctx, &generation)
+				req.GenerationID = &generation.ID
+			}
+			lesson, err := svc.Create(ctx, authorID, req)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			for i := 0; i < tt.edits; i++ {
+				if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("修改后的标题")}); err != nil {
+					t.Fatalf("Update() error = %v", err)
+				}
+			}
+
+			source, err := svc.GetSourceGeneration(ctx, lesson.ID, tt.userID)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("GetSourceGeneration() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if source.Generation.ID != generation.ID {
+				t.Errorf("generation = %s, want %s", source.Generation.ID, generation.ID)
+			}
+			if source.InitialVersion != 1 || source.CurrentVersion != tt.wantCurrent || source.Modified != tt.wantChanged {
+				t.Errorf("versions = %d -> %d (modified=%v), want 1 -> %d (modified=%v)",
+					source.InitialVersion, source.CurrentVersion, source.Modified, tt.wantCurrent, tt.wantChanged)
+			}
+		})
+	}
+}
+
+func TestLessonContentType(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+
+	tests := []struct {
+		name   string
+		create string
+		update string
+		want   string
+	}{
+		{name: "defaults to markdown", want: model.LessonContentTypeMarkdown},
+		{name: "html is kept", create: "HTML", want: model.LessonContentTypeHTML},
+		{name: "unknown falls back to markdown", create: "docx", want: model.LessonContentTypeMarkdown},
+		{name: "empty update keeps type", create: "html", update: "", want: model.LessonContentTypeHTML},
+		{name: "update switches type", create: "html", update: "markdown", want: model.LessonContentTypeMarkdown},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := newTestLessonService(newFakeLessonRepo(), nil)
+			lesson, err := svc.Create(ctx, authorID, &CreateLessonRequest{Title: "分数的意义", ContentType: tt.create})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{ContentType: tt.update}); err != nil {
+				t.Fatalf("Update() error = %v", err)
+			}
+			detail, err := svc.GetByID(ctx, lesson.ID, &authorID)
+			if err != nil {
+				t.Fatalf("GetByID() error = %v", err)
+			}
+			if detail.ContentType != tt.want {
+				t.Errorf("content_type = %q, want %q", detail.ContentType, tt.want)
+			}
+		})
+	}
+}
+
+// fullLesson 各字段都有内容的草稿教案
+func fullLesson(userID uuid.UUID) *model.Lesson {
+	return &model.Lesson{
+		UserID:     userID,
+		Title:      "有理数的加法",
+		Subject:    "数学",
+		Grade:      "七年级",
+		Duration:   40,
+		Objectives: wrapLessonText("掌握法则"),
+		Content:    wrapLessonText("新课讲授"),
+		Activities: "小组讨论",
+		Assessment: "随堂检测",
+		Resources:  "课件",
+		Tags:       `["公开课"]`,
+		Status:     model.LessonStatusDraft,
+	}
+}
+
+func TestUpdateClearsOnlySentFields(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		body    string
+		check   func(t *testing.T, got *model.Lesson)
+		wantErr error
+	}{
+		{
+			name: "omitted fields untouched",
+			body: `{"activities": "独立练习"}`,
+			check: func(t *testing.T, got *model.Lesson) {
+				if got.Activities != "独立练习" || got.Assessment != "随堂检测" || got.Resources != "课件" || got.Duration != 40 ||
+					normalizeLessonText(got.Objectives) != "掌握法则" || got.Tags != `["公开课"]` || got.Title != "有理数的加法" {
+					t.Errorf("lesson = %+v, want only activities changed", got)
+				}
+			},
+		},
+		{
+			name: "empty strings clear sections",
+			body: `{"assessment": "", "resources": "", "objectives": ""}`,
+			check: func(t *testing.T, got *model.Lesson) {
+				if got.Assessment != "" || got.Resources != "" || normalizeLessonText(got.Objectives) != "" {
+					t.Errorf("assessment = %q resources = %q objectives = %q, want cleared", got.Assessment, got.Resources, got.Objectives)
+				}
+				if got.Activities != "小组讨论" || normalizeLessonText(got.Content) != "新课讲授" {
+					t.Errorf("unsent fields changed: activities = %q content = %q", got.Activities, got.Content)
+				}
+			},
+		},
+		{
+			name: "null is treated as omitted",
+			body: `{"assessment": null, "tags": null}`,
+			check: func(t *testing.T, got *model.Lesson) {
+				if got.Assessment != "随堂检测" || got.Tags != `["公开课"]` {
+					t.Errorf("assessment = %q tags = %s, want unchanged", got.Assessment, got.Tags)
+				}
+			},
+		},
+		{
+			name: "empty tags array clears tags",
+			body: `{"tags": []}`,
+			check: func(t *testing.T, got *model.Lesson) {
+				if got.Tags != "[]" {
+					t.Errorf("tags = %s, want []", got.Tags)
+				}
+			},
+		},
+		{
+			name: "duration zero resets to default",
+			body: `{"duration": 0}`,
+			check: func(t *testing.T, got *model.Lesson) {
+				if got.Duration != defaultLessonDuration {
+					t.Errorf("duration = %d, want default %d", got.Duration, defaultLessonDuration)
+				}
+			},
+		},
+		{
+			name:    "title cannot be cleared",
+			body:    `{"title": "  "}`,
+			wantErr: ErrLessonTitleRequired,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			authorID := uuid.New()
+			lesson := fullLesson(authorID)
+			repo := newFakeLessonRepo(lesson)
+			svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+			// 与 handler 一致，通过 JSON 绑定区分未传与传空值
+			var req UpdateLessonRequest
+			if err := json.Unmarshal([]byte(tt.body), &req); err != nil {
+				t.Fatalf("decode request: %v", err)
+			}
+			_, err := svc.Update(ctx, lesson.ID, authorID, &req)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Update() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				if repo.lessons[lesson.ID].Title != "有理数的加法" {
+					t.Error("lesson changed despite error")
+				}
+				return
+			}
+			tt.check(t, repo.lessons[lesson.ID])
+		})
+	}
+}
+
+func TestUpdatePublishedLessonDraftClearsFields(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := fullLesson(authorID)
+	lesson.Status = model.LessonStatusPublished
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, &fakeVersionRepo{})
+
+	draft, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Resources: strPtr("")})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// 草稿中清空，正式版保持不变
+	if draft.Resources != "" || draft.Assessment != "随堂检测" {
+		t.Errorf("draft resources = %q assessment = %q", draft.Resources, draft.Assessment)
+	}
+	if stored := repo.lessons[lesson.ID]; stored.Resources != "课件" || stored.DraftContent == "" {
+		t.Errorf("published resources = %q draft saved = %v, want published copy untouched", stored.Resources, stored.DraftContent != "")
+	}
+}
+
+func TestVersionHistoryAndRollback(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	repo := newFakeLessonRepo()
+	versions := &fakeVersionRepo{}
+	svc := newTestLessonService(repo, versions)
+
+	lesson, err := svc.Create(ctx, authorID, &CreateLessonRequest{
+		Title: "初稿", Subject: "数学", Grade: "七年级", Objectives: "理解有理数", Content: "初稿内容",
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	for _, title := range []string{"第二版", "第三版"} {
+		if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr(title)}); err != nil {
+			t.Fatalf("Update() error = %v", err)
+		}
+	}
+
+	// 每次编辑保存编辑前的版本，列表按版本号倒序
+	list, err := svc.ListVersions(ctx, lesson.ID, authorID)
+	if err != nil {
+		t.Fatalf("ListVersions() error = %v", err)
+	}
+	if len(list) != 2 || list[0].VersionNumber != 2 || list[1].VersionNumber != 1 {
+		t.Fatalf("versions = %+v, want snapshots of v2 and v1", list)
+	}
+	v1, err := svc.GetVersion(ctx, lesson.ID, 1, authorID)
+	if err != nil {
+		t.Fatalf("GetVersion() error = %v", err)
+	}
+	var content map[string]interface{}
+	if err := json.Unmarshal([]byte(v1.Content), &content); err != nil || content["title"] != "初稿" {
+		t.Errorf("v1 content = %s, want the original title", v1.Content)
+	}
+
+	rolled, err := svc.RollbackToVersion(ctx, lesson.ID, 1, authorID)
+	if err != nil {
+		t.Fatalf("RollbackToVersion() error = %v", err)
+	}
+	if rolled.Title != "初稿" || rolled.Version != 4 {
+		t.Errorf("rolled back = (%q, v%d), want v1 content as v4", rolled.Title, rolled.Version)
+	}
+	if stored, _ := repo.GetByID(ctx, lesson.ID); stored.Title != "初稿" || stored.Version != 4 {
+		t.Errorf("stored = (%q, v%d), want rollback persisted", stored.Title, stored.Version)
+	}
+
+	// 回滚前的内容也被保存，可以再回到回滚前
+	list, _ = svc.ListVersions(ctx, lesson.ID, authorID)
+	if len(list) != 3 || list[0].VersionNumber != 3 {
+		t.Fatalf("versions = %+v, want v3 saved before the rollback", list)
+	}
+	if err := json.Unmarshal([]byte(list[0].Content), &content); err != nil || content["title"] != "第三版" {
+		t.Errorf("v3 content = %s, want the pre-rollback title", list[0].Content)
+	}
+	if _, err := svc.RollbackToVersion(ctx, lesson.ID, 9, authorID); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("RollbackToVersion() to missing version error = %v, want ErrVersionNotFound", err)
+	}
+	if _, err := svc.RollbackToVersion(ctx, lesson.ID, 1, uuid.New()); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("RollbackToVersion() by other user error = %v, want ErrUnauthorized", err)
+	}
+
+	// 首次发布同样保存发布前的版本
+	if err := svc.Publish(ctx, lesson.ID, authorID); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	list, _ = svc.ListVersions(ctx, lesson.ID, authorID)
+	if len(list) != 4 || list[0].VersionNumber != 4 || list[0].IsKey {
+		t.Errorf("versions = %+v, want the unpublished v4 saved on publish", list)
+	}
+}
+
+func TestRollbackPublishedLessonWritesDraft(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, &fakeVersionRepo{})
+	original := lesson.Version
+
+	// 发布一次草稿留下原正式版的快照，再编辑出一份未发布的草稿
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("第二版")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if err := svc.Publish(ctx, lesson.ID, authorID); err != nil {
+		t.Fatalf("Publish() error = %v", err)
+	}
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("待发布草稿")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	published := repo.lessons[lesson.ID].Version
+
+	rolled, err := svc.RollbackToVersion(ctx, lesson.ID, original, authorID)
+	if err != nil {
+		t.Fatalf("RollbackToVersion() error = %v", err)
+	}
+	if rolled.Title != "正式版标题" || rolled.Status != model.LessonStatusPublished {
+		t.Errorf("rolled back = (%q, %s), want the old version as a published draft", rolled.Title, rolled.Status)
+	}
+
+	// 正式版不变，回滚内容替换了原先的草稿
+	stored, _ := repo.GetByID(ctx, lesson.ID)
+	if stored.Title != "第二版" || stored.Version != published || stored.DraftContent == "" {
+		t.Errorf("stored = (%q, v%d, has draft %v), want published v%d untouched with a draft", stored.Title, stored.Version, stored.DraftContent != "", published)
+	}
+	authorView, _ := svc.GetByID(ctx, lesson.ID, &authorID)
+	if authorView.Title != "正式版标题" || !authorView.HasDraft {
+		t.Errorf("author sees %q (has_draft=%v), want the rolled-back draft", authorView.Title, authorView.HasDraft)
+	}
+	readerView, _ := svc.GetByID(ctx, lesson.ID, nil)
+	if readerView.Title != "第二版" {
+		t.Errorf("reader sees %q, want the published title", readerView.Title)
+	}
+}
+
+func TestRollbackUnpublishedLessonClearsDraft(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusDraft)
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, &fakeVersionRepo{})
+	original := lesson.Version
+
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("第二版")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	// 残留的旧草稿（如历史数据）不应盖住回滚结果
+	repo.lessons[lesson.ID].DraftContent = `{"title":"旧草稿"}`
+
+	if _, err := svc.RollbackToVersion(ctx, lesson.ID, original, authorID); err != nil {
+		t.Fatalf("RollbackToVersion() error = %v", err)
+	}
+	stored, _ := repo.GetByID(ctx, lesson.ID)
+	if stored.Title != "正式版标题" || stored.DraftContent != "" || stored.DraftSavedAt != nil {
+		t.Errorf("stored = (%q, draft %q), want rollback applied with the draft cleared", stored.Title, stored.DraftContent)
+	}
+	if view, _ := svc.GetByID(ctx, lesson.ID, &authorID); view.Title != "正式版标题" || view.HasDraft {
+		t.Errorf("author sees %q (has_draft=%v), want the rolled-back content", view.Title, view.HasDraft)
+	}
+}
+
+func TestLabelVersion(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusDraft)
+	lesson.Version = 4
+	repo := newFakeLessonRepo(lesson)
+	versions := &fakeVersionRepo{}
+	for n := 1; n <= 3; n++ {
+		_ = versions.Create(ctx, &model.LessonVersion{LessonID: lesson.ID, VersionNumber: n})
+	}
+	svc := newTestLessonService(repo, versions)
+	svc.maxVersions = 1
+
+	labeled, err := svc.LabelVersion(ctx, lesson.ID, 1, authorID, "  公开课最终版 ")
+	if err != nil {
+		t.Fatalf("LabelVersion() error = %v", err)
+	}
+	if labeled.VersionNumber != 1 || labeled.Label != "公开课最终版" {
+		t.Errorf("labeled = (v%d, %q), want v1 with trimmed label", labeled.VersionNumber, labeled.Label)
+	}
+
+	// 命名的里程碑不受保留数量限制
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("新标题")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	list, _ := svc.ListVersions(ctx, lesson.ID, authorID)
+	if len(list) != 2 || list[0].VersionNumber != 4 || list[1].VersionNumber != 1 {
+		t.Fatalf("versions = %+v, want latest snapshot and the milestone", list)
+	}
+
+	// 取消命名后恢复为普通版本，下次写入时被清理
+	if cleared, err := svc.LabelVersion(ctx, lesson.ID, 1, authorID, " "); err != nil || cleared.Label != "" {
+		t.Fatalf("LabelVersion(clear) = %+v, %v", cleared, err)
+	}
+	if _, err := svc.Update(ctx, lesson.ID, authorID, &UpdateLessonRequest{Title: strPtr("再改一次")}); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	if list, _ = svc.ListVersions(ctx, lesson.ID, authorID); len(list) != 1 || list[0].VersionNumber != 5 {
+		t.Errorf("versions = %+v, want only the latest snapshot", list)
+	}
+
+	if _, err := svc.LabelVersion(ctx, lesson.ID, 9, authorID, "不存在"); !errors.Is(err, ErrVersionNotFound) {
+		t.Errorf("LabelVersion() on missing version error = %v, want ErrVersionNotFound", err)
+	}
+	if _, err := svc.LabelVersion(ctx, lesson.ID, 5, uuid.New(), "别人的"); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("LabelVersion() by other user error = %v, want ErrUnauthorized", err)
+	}
+}
@@ -0,0 +1,365 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// 学案生成方式
+const (
+	// StudentVersionModeAuto 优先调用 Agent，失败或未配置时回退规则转换
+	StudentVersionModeAuto = "auto"
+	// StudentVersionModeAgent 只使用 Agent 改写
+	StudentVersionModeAgent = "agent"
+	// StudentVersionModeRule 只使用规则转换，不调用模型
+	StudentVersionModeRule = "rule"
+
+	studentVersionTag = "student-version"
+)
+
+// ErrUnsupportedStudentVersionMode 不支持的学案生成方式
+var ErrUnsupportedStudentVersionMode = errors.New("不支持的学案生成方式")
+
+// LessonStudentVersion 基于教案生成的学生学案
+type LessonStudentVersion struct {
+	LessonID      uuid.UUID  `json:"lesson_id"`
+	Mode          string     `json:"mode"`
+	Title         string     `json:"title"`
+	Objectives    string     `json:"objectives"`
+	Content       string     `json:"content"`
+	Activities    string     `json:"activities"`
+	Assessment    string     `json:"assessment"`
+	Resources     string     `json:"resources"`
+	SavedLessonID *uuid.UUID `json:"saved_lesson_id,omitempty"`
+}
+
+type agentStudentVersionRequest struct {
+	LessonID   string `json:"lessonId"`
+	Title      string `json:"title"`
+	Subject    string `json:"subject"`
+	Grade      string `json:"grade"`
+	Objectives string `json:"objectives"`
+	Content    string `json:"content"`
+	Activities string `json:"activities"`
+	Assessment string `json:"assessment"`
+	Resources  string `json:"resources"`
+}
+
+type agentStudentVersionResponse struct {
+	Success bool `json:"success"`
+	Data    *struct {
+		Title      string `json:"title"`
+		Objectives string `json:"objectives"`
+		Content    string `json:"content"`
+		Activities string `json:"activities"`
+		Assessment string `json:"assessment"`
+		Resources  string `json:"resources"`
+	} `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+var (
+	// teacherSectionPattern 整节属于教师视角的标题
+	teacherSectionPattern = regexp.MustCompile(`教师活动|教师行为|设计意图|教学反思|板书设计|教学准备|教师准备|教学建议|参考答案`)
+	// teacherLinePattern 以“教师：”“师：”“设计意图：”等开头的单行
+	teacherLinePattern          = regexp.MustCompile(`^\s*(?:[-*+]\s+|\d+[.、]\s*)?(?:\*\*)?(?:教师活动|教师|老师|师|设计意图|教学反思)(?:\*\*)?\s*[：:]`)
+	markdownHeadingLevelPattern = regexp.MustCompile(`^(#{1,6})\s+(.*)$`)
+)
+
+// IsSupportedStudentVersionMode 判断学案生成方式是否受支持
+func IsSupportedStudentVersionMode(mode string) bool {
+	switch mode {
+	case StudentVersionModeAuto, StudentVersionModeAgent, StudentVersionModeRule:
+		return true
+	}
+	return false
+}
+
+func (s *lessonService) StudentVersion(ctx context.Context, lessonID, userID uuid.UUID, mode string, saveAsNew bool) (*LessonStudentVersion, error) {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	if mode == "" {
+		mode = StudentVersionModeAuto
+	}
+	if !IsSupportedStudentVersionMode(mode) {
+		return nil, ErrUnsupportedStudentVersionMode
+	}
+
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	// 与翻译一致：作者可转换自己的任意教案，其他人只能转换已发布的教案
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+
+	ruleVersion := buildRuleStudentVersion(lesson)
+	version := ruleVersion
+	if mode != StudentVersionModeRule {
+		agentVersion, err := s.studentVersionByAgent(ctx, lesson)
+		switch {
+		case err == nil:
+			version = mergeStudentVersion(agentVersion, ruleVersion)
+		case mode == StudentVersionModeAgent:
+			return nil, err
+		}
+	}
+
+	if saveAsNew {
+		saved := buildStudentLesson(lesson, version, userID)
+		if err := s.lessonRepo.Create(ctx, saved); err != nil {
+			return nil, fmt.Errorf("保存学案失败: %w", err)
+		}
+		version.SavedLessonID = &saved.ID
+	}
+
+	return version, nil
+}
+
+// buildRuleStudentVersion 按规则去掉教师视角内容，保留学习目标与任务
+func buildRuleStudentVersion(lesson *model.Lesson) *LessonStudentVersion {
+	return &LessonStudentVersion{
+		LessonID:   lesson.ID,
+		Mode:       StudentVersionModeRule,
+		Title:      studentVersionTitle(lesson.Title),
+		Objectives: stripTeacherContent(normalizeLessonText(lesson.Objectives)),
+		Content:    stripTeacherContent(normalizeLessonText(lesson.Content)),
+		Activities: stripTeacherContent(normalizeLessonText(lesson.Activities)),
+		Assessment: stripTeacherContent(normalizeLessonText(lesson.Assessment)),
+		Resources:  stripTeacherContent(normalizeLessonText(lesson.Resources)),
+	}
+}
+
+// mergeStudentVersion Agent 未返回的字段回退规则转换结果
+func mergeStudentVersion(agent, rule *LessonStudentVersion) *LessonStudentVersion {
+	return &LessonStudentVersion{
+		LessonID:   rule.LessonID,
+		Mode:       StudentVersionModeAgent,
+		Title:      firstNonEmpty(agent.Title, rule.Title),
+		Objectives: firstNonEmpty(agent.Objectives, rule.Objectives),
+		Content:    firstNonEmpty(agent.Content, rule.Content),
+		Activities: firstNonEmpty(agent.Activities, rule.Activities),
+		Assessment: firstNonEmpty(agent.Assessment, rule.Assessment),
+		Resources:  firstNonEmpty(agent.Resources, rule.Resources),
+	}
+}
+
+func studentVersionTitle(title string) string {
+	title = strings.TrimSpace(title)
+	if strings.HasSuffix(title, "（学案）") {
+		return title
+	}
+	return title + "（学案）"
+}
+
+// stripTeacherContent 删除 Markdown 中的教师视角内容：
+// 标题命中教师关键词的整节、以“教师：”等开头的行、表格中的教师活动列
+func stripTeacherContent(text string) string {
+	if strings.TrimSpace(text) == "" {
+		return ""
+	}
+
+	lines := strings.Split(text, "\n")
+	kept := make([]string, 0, len(lines))
+	skipLevel := 0
+	var dropColumns map[int]bool
+
+	for _, line := range lines {
+		trimmed := strings.TrimSpace(line)
+
+		if m := markdownHeadingLevelPattern.FindStringSubmatch(trimmed); m != nil {
+			level := len(m[1])
+			if skipLevel > 0 && level > skipLevel {
+				continue
+			}
+			skipLevel = 0
+			if teacherSectionPattern.MatchString(m[2]) {
+				skipLevel = level
+				continue
+			}
+		} else if skipLevel > 0 {
+			continue
+		}
+
+		if strings.HasPrefix(trimmed, "|") {
+			cells := splitTableRow(trimmed)
+			if dropColumns == nil {
+				// 表头行：记录需要删除的教师列
+				dropColumns = map[int]bool{}
+				for i, cell := range cells {
+					if teacherSectionPattern.MatchString(cell) {
+						dropColumns[i] = true
+					}
+				}
+			}
+			if len(dropColumns) == 0 {
+				kept = append(kept, line)
+				continue
+			}
+			if row := joinTableRow(cells, dropColumns); row != "" {
+				kept = append(kept, row)
+			}
+			continue
+		}
+		dropColumns = nil
+
+		if teacherLinePattern.MatchString(line) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+
+	return strings.TrimSpace(collapseBlankLines(strings.Join(kept, "\n")))
+}
+
+func splitTableRow(row string) []string {
+	row = strings.TrimSuffix(strings.TrimPrefix(row, "|"), "|")
+	cells := strings.Split(row, "|")
+	for i := range cells {
+		cells[i] = strings.TrimSpace(cells[i])
+	}
+	return cells
+}
+
+// joinTableRow 删除指定列后重新拼接表格行；删列后只剩空单元格的行直接丢弃
+func joinTableRow(cells []string, dropColumns map[int]bool) string {
+	kept := make([]string, 0, len(cells))
+	empty := true
+	for i, cell := range cells {
+		if dropColumns[i] {
+			continue
+		}
+		kept = append(kept, cell)
+		if cell != "" {
+			empty = false
+		}
+	}
+	if empty {
+		return ""
+	}
+	return "| " + strings.Join(kept, " | ") + " |"
+}
+
+func collapseBlankLines(text string) string {
+	for strings.Contains(text, "\n\n\n") {
+		text = strings.ReplaceAll(text, "\n\n\n", "\n\n")
+	}
+	return text
+}
+
+func (s *lessonService) studentVersionByAgent(ctx context.Context, lesson *model.Lesson) (*LessonStudentVersion, error) {
+	if s.cfg == nil || strings.TrimSpace(s.cfg.URL) == "" || s.httpClient == nil {
+		return nil, errors.New("agent 学案服务未配置")
+	}
+
+	requestPayload := agentStudentVersionRequest{
+		LessonID:   lesson.ID.String(),
+		Title:      strings.TrimSpace(lesson.Title),
+		Subject:    strings.TrimSpace(lesson.Subject),
+		Grade:      strings.TrimSpace(lesson.Grade),
+		Objectives: normalizeLessonText(lesson.Objectives),
+		Content:    normalizeLessonText(lesson.Content),
+		Activities: normalizeLessonText(lesson.Activities),
+		Assessment: normalizeLessonText(lesson.Assessment),
+		Resources:  normalizeLessonText(lesson.Resources),
+	}
+
+	body, err := json.Marshal(requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal student version request failed: %w", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	override := APIKeyOverrideFromContext(ctx)
+	if override.GenerationAPIKey != "" {
+		headers[HeaderGenerationAPIKey] = override.GenerationAPIKey
+	}
+	if s.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.cfg.APIKey
+	}
+
+	url := fmt.Sprintf("%s/api/student-version", strings.TrimRight(s.cfg.URL, "/"))
+	statusCode, respBody, err := doAgentRequestWithRetry(
+		ctx,
+		s.httpClient,
+		http.MethodPost,
+		url,
+		body,
+		headers,
+		"student_version",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("call student version endpoint failed: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("student version endpoint returned error: %d - %s", statusCode, string(respBody))
+	}
+
+	var response agentStudentVersionResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal student version response failed: %w", err)
+	}
+	if !response.Success {
+		if strings.TrimSpace(response.Error) != "" {
+			return nil, errors.New(strings.TrimSpace(response.Error))
+		}
+		return nil, errors.New("student version failed")
+	}
+	if response.Data == nil {
+		return nil, errors.New("student version response is empty")
+	}
+
+	return &LessonStudentVersion{
+		LessonID:   lesson.ID,
+		Mode:       StudentVersionModeAgent,
+		Title:      response.Data.Title,
+		Objectives: response.Data.Objectives,
+		Content:    response.Data.Content,
+		Activities: response.Data.Activities,
+		Assessment: response.Data.Assessment,
+		Resources:  response.Data.Resources,
+	}, nil
+}
+
+// buildStudentLesson 将学案映射为新教案草稿，标签沿用原教案并追加学案标签
+func buildStudentLesson(source *model.Lesson, version *LessonStudentVersion, userID uuid.UUID) *model.Lesson {
+	var tags []string
+	if source.Tags != "" {
+		_ = json.Unmarshal([]byte(source.Tags), &tags)
+	}
+	tags = append(tags, studentVersionTag)
+	tagsJSON, _ := json.Marshal(tags)
+
+	title := version.Title
+	if len([]rune(title)) > 200 {
+		title = string([]rune(title)[:200])
+	}
+
+	return &model.Lesson{
+		UserID:      userID,
+		Title:       title,
+		Subject:     source.Subject,
+		Grade:       source.Grade,
+		Duration:    source.Duration,
+		Objectives:  fmt.Sprintf(`{"text": %s}`, strconv.Quote(version.Objectives)),
+		Content:     fmt.Sprintf(`{"text": %s}`, strconv.Quote(version.Content)),
+		Activities:  version.Activities,
+		Assessment:  version.Assessment,
+		Resources:   version.Resources,
+		Tags:        string(tagsJSON),
+		Status:      model.LessonStatusDraft,
+		ContentType: model.LessonContentTypeMarkdown,
+	}
+}
@@ -0,0 +1,135 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestStripTeacherContent(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want string
+	}{
+		{
+			name: "teacher section dropped with its subsections",
+			in:   "## 学习目标\n理解勾股定理\n\n## 教学准备\n准备三角板\n### 教师准备\n课件\n\n## 课堂练习\n完成练习 1",
+			want: "## 学习目标\n理解勾股定理\n\n## 课堂练习\n完成练习 1",
+		},
+		{
+			name: "sibling subsection after teacher subsection kept",
+			in:   "## 新课讲授\n### 设计意图\n激发兴趣\n### 学生活动\n分组讨论",
+			want: "## 新课讲授\n### 学生活动\n分组讨论",
+		},
+		{
+			name: "teacher lines dropped",
+			in:   "- 教师：提问导入\n- 学生：回答问题\n师: 总结\n**设计意图**：巩固\n1. 老师：板书\n2. 独立完成例题",
+			want: "- 学生：回答问题\n2. 独立完成例题",
+		},
+		{
+			name: "teacher table column dropped",
+			in:   "| 环节 | 教师活动 | 学生活动 |\n| --- | --- | --- |\n| 导入 | 播放视频 | 观看思考 |\n| 小结 | 归纳要点 |  |",
+			want: "| 环节 | 学生活动 |\n| --- | --- |\n| 导入 | 观看思考 |\n| 小结 |  |",
+		},
+		{
+			name: "reference answers dropped",
+			in:   "## 课后作业\n1. 计算 3+4\n\n## 参考答案\n1. 7",
+			want: "## 课后作业\n1. 计算 3+4",
+		},
+		{
+			name: "student-only content untouched",
+			in:   "## 学习任务\n阅读课文并回答问题",
+			want: "## 学习任务\n阅读课文并回答问题",
+		},
+		{name: "blank", in: "  \n", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := stripTeacherContent(tt.in); got != tt.want {
+				t.Errorf("stripTeacherContent() =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestStudentVersionRuleMode(t *testing.T) {
+	ctx := context.Background()
+	ownerID := uuid.New()
+	lesson := &model.Lesson{
+		UserID:     ownerID,
+		Title:      "勾股定理",
+		Subject:    "数学",
+		Grade:      "八年级",
+		Objectives: wrapLessonText("理解勾股定理\n设计意图：为证明做铺垫"),
+		Content:    wrapLessonText("## 探究\n学生动手拼图\n\n## 板书设计\na²+b²=c²"),
+		Activities: "教师：巡视指导\n小组合作",
+		Tags:       `["几何"]`,
+		Status:     model.LessonStatusDraft,
+	}
+	repo := newFakeLessonRepo(lesson)
+	svc := newTestLessonService(repo, nil)
+
+	version, err := svc.StudentVersion(ctx, lesson.ID, ownerID, StudentVersionModeRule, true)
+	if err != nil {
+		t.Fatalf("StudentVersion() error = %v", err)
+	}
+	if version.Title != "勾股定理（学案）" {
+		t.Errorf("Title = %q", version.Title)
+	}
+	for field, text := range map[string]string{"objectives": version.Objectives, "content": version.Content, "activities": version.Activities} {
+		for _, teacherOnly := range []string{"设计意图", "板书设计", "a²+b²=c²", "巡视指导"} {
+			if strings.Contains(text, teacherOnly) {
+				t.Errorf("%s kept teacher-only %q: %s", field, teacherOnly, text)
+			}
+		}
+	}
+	if !strings.Contains(version.Content, "学生动手拼图") || version.Activities != "小组合作" {
+		t.Errorf("student content lost: content=%q activities=%q", version.Content, version.Activities)
+	}
+
+	if version.SavedLessonID == nil {
+		t.Fatal("SavedLessonID = nil, want saved handout")
+	}
+	saved := repo.lessons[*version.SavedLessonID]
+	if saved == nil || saved.Status != model.LessonStatusDraft || !strings.Contains(saved.Tags, studentVersionTag) || !strings.Contains(saved.Tags, "几何") {
+		t.Errorf("saved handout = %+v", saved)
+	}
+
+	// 其他人不能转换未发布的教案
+	if _, err := svc.StudentVersion(ctx, lesson.ID, uuid.New(), StudentVersionModeRule, false); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("StudentVersion() by other user error = %v, want ErrUnauthorized", err)
+	}
+	if _, err := svc.StudentVersion(ctx, lesson.ID, ownerID, "magic", false); !errors.Is(err, ErrUnsupportedStudentVersionMode) {
+		t.Errorf("StudentVersion() unknown mode error = %v, want ErrUnsupportedStudentVersionMode", err)
+	}
+}
+
+func TestStudentVersionAutoFallsBackToRules(t *testing.T) {
+	ownerID := uuid.New()
+	lesson := &model.Lesson{
+		UserID:  ownerID,
+		Title:   "有理数",
+		Content: wrapLessonText("## 练习\n完成习题\n## 教学反思\n效果良好"),
+		Status:  model.LessonStatusDraft,
+	}
+	svc := newTestLessonService(newFakeLessonRepo(lesson), nil)
+
+	// 未配置 Agent：auto 回退规则转换，agent 模式直接报错
+	version, err := svc.StudentVersion(context.Background(), lesson.ID, ownerID, "", false)
+	if err != nil {
+		t.Fatalf("StudentVersion(auto) error = %v", err)
+	}
+	if version.Mode != StudentVersionModeRule || version.Content != "## 练习\n完成习题" {
+		t.Errorf("StudentVersion(auto) = mode %q content %q", version.Mode, version.Content)
+	}
+	if _, err := svc.StudentVersion(context.Background(), lesson.ID, ownerID, StudentVersionModeAgent, false); err == nil {
+		t.Error("StudentVersion(agent) without agent error = nil, want error")
+	}
+}
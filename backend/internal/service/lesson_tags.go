@@ -0,0 +1,129 @@
+package service
+
+import (
+	"encoding/json"
+	"strings"
+	"unicode"
+
+	"lesson-plan/backend/internal/model"
+)
+
+const (
+	// maxLessonTags 自动标签数量上限
+	maxLessonTags = 8
+	// maxTagLength 标签最大长度（字符），更长的多为句子而非关键词
+	maxTagLength = 12
+)
+
+// tagSeparators 拆分“讲授法、讨论法”这类并列短语
+var tagSeparators = "、，,；;/|"
+
+// generateLessonTags 根据生成参数与生成结果抽取教案标签：
+// 学科、年级、用户关键词优先，其次是课题、教学方法和重点中的短语，按出现顺序去重
+func generateLessonTags(req *model.GenerationRequest, data *GeneratedLessonData) []string {
+	collector := newTagCollector()
+	if req != nil {
+		collector.Add(req.Subject, req.Grade)
+		collector.Add(req.Keywords...)
+		collector.Add(req.Topic)
+	}
+	if data != nil {
+		collector.Add(data.TeachingMethods...)
+		collector.Add(data.KeyPoints...)
+	}
+	if req != nil {
+		collector.Add(styleTag(req.Style))
+	}
+	return collector.Tags()
+}
+
+// generationTags 从已保存的生成记录中恢复标签，解析失败时返回空
+func generationTags(generation *model.Generation) []string {
+	var req model.GenerationRequest
+	if err := json.Unmarshal([]byte(generation.Parameters), &req); err != nil {
+		return nil
+	}
+	var data GeneratedLessonData
+	if err := json.Unmarshal([]byte(generation.Result), &data); err != nil {
+		return generateLessonTags(&req, nil)
+	}
+	return generateLessonTags(&req, &data)
+}
+
+// mergeLessonTags 合并用户填写的标签与自动标签：用户标签原样保留在前，
+// 自动标签只补足到 maxLessonTags 个
+func mergeLessonTags(manual, auto []string) []string {
+	collector := newTagCollector()
+	for _, tag := range manual {
+		tag = strings.TrimSpace(tag)
+		key := strings.ToLower(tag)
+		if tag == "" || collector.seen[key] {
+			continue
+		}
+		collector.seen[key] = true
+		collector.tags = append(collector.tags, tag)
+	}
+	collector.Add(auto...)
+	return collector.Tags()
+}
+
+// styleTag 预设教学风格取其中文名作为标签，自由文本风格不生成标签
+func styleTag(style string) string {
+	if preset := findStylePreset(style); preset != nil {
+		return preset.Name
+	}
+	return ""
+}
+
+// tagCollector 按加入顺序收集去重后的标签
+type tagCollector struct {
+	tags []string
+	seen map[string]bool
+}
+
+func newTagCollector() *tagCollector {
+	return &tagCollector{seen: make(map[string]bool)}
+}
+
+// Add 加入候选文本，按分隔符拆分后逐个规范化；超长或过短的片段丢弃
+func (c *tagCollector) Add(values ...string) {
+	for _, value := range values {
+		for _, part := range strings.FieldsFunc(value, func(r rune) bool {
+			return strings.ContainsRune(tagSeparators, r) || r == '\n'
+		}) {
+			if len(c.tags) >= maxLessonTags {
+				return
+			}
+			tag := normalizeTag(part)
+			length := len([]rune(tag))
+			if length < 2 || length > maxTagLength {
+				continue
+			}
+			key := strings.ToLower(tag)
+			if c.seen[key] {
+				continue
+			}
+			c.seen[key] = true
+			c.tags = append(c.tags, tag)
+		}
+	}
+}
+
+// Tags 返回已收集的标签，无标签时返回空切片以便序列化为 []
+func (c *tagCollector) Tags() []string {
+	if c.tags == nil {
+		return []string{}
+	}
+	return c.tags
+}
+
+// normalizeTag 去掉列表序号、首尾标点与空白
+func normalizeTag(s string) string {
+	s = strings.TrimSpace(s)
+	s = strings.TrimLeftFunc(s, func(r rune) bool {
+		return unicode.IsDigit(r) || r == '.' || r == '、' || r == '-' || r == '*' || unicode.IsSpace(r)
+	})
+	return strings.TrimFunc(s, func(r rune) bool {
+		return unicode.IsPunct(r) || unicode.IsSpace(r)
+	})
+}
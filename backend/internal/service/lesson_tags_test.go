@@ -0,0 +1,120 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestGenerateLessonTags(t *testing.T) {
+	tests := []struct {
+		name string
+		req  *model.GenerationRequest
+		data *GeneratedLessonData
+		want []string
+	}{
+		{
+			name: "request fields come first then result phrases",
+			req:  &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数的加法", Keywords: []string{"数轴"}, Style: "interactive"},
+			data: &GeneratedLessonData{
+				TeachingMethods: []string{"讲授法、讨论法"},
+				KeyPoints:       []string{"1. 有理数加法法则", "异号两数相加"},
+			},
+			want: []string{"数学", "七年级", "数轴", "有理数的加法", "讲授法", "讨论法", "有理数加法法则", "异号两数相加"},
+		},
+		{
+			name: "duplicates ignore case and punctuation",
+			req:  &model.GenerationRequest{Subject: "英语", Keywords: []string{"Grammar", "grammar。", " 英语 "}},
+			want: []string{"英语", "Grammar"},
+		},
+		{
+			name: "too short and too long fragments dropped",
+			req:  &model.GenerationRequest{Subject: "物", Topic: "探究影响滑动摩擦力大小的因素并设计实验验证猜想"},
+			data: &GeneratedLessonData{KeyPoints: []string{"摩擦力；控制变量法"}},
+			want: []string{"摩擦力", "控制变量法"},
+		},
+		{
+			name: "preset style adds its name, free text style does not",
+			req:  &model.GenerationRequest{Subject: "语文", Style: "多用古诗词"},
+			want: []string{"语文"},
+		},
+		{
+			name: "capped at max tags",
+			req:  &model.GenerationRequest{Keywords: []string{"甲甲", "乙乙", "丙丙", "丁丁", "戊戊", "己己", "庚庚", "辛辛", "壬壬", "癸癸"}},
+			want: []string{"甲甲", "乙乙", "丙丙", "丁丁", "戊戊", "己己", "庚庚", "辛辛"},
+		},
+		{name: "nothing to extract", want: []string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := generateLessonTags(tt.req, tt.data); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("generateLessonTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestMergeLessonTags(t *testing.T) {
+	tests := []struct {
+		name   string
+		manual []string
+		auto   []string
+		want   []string
+	}{
+		{name: "manual first, auto fills up", manual: []string{"公开课"}, auto: []string{"数学", "七年级"}, want: []string{"公开课", "数学", "七年级"}},
+		{name: "auto duplicate of manual dropped", manual: []string{"Math"}, auto: []string{"math", "七年级"}, want: []string{"Math", "七年级"}},
+		{name: "manual kept verbatim even if short", manual: []string{" A ", "", "a"}, auto: []string{"数学"}, want: []string{"A", "数学"}},
+		{
+			name:   "auto only fills up to the cap",
+			manual: []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7"},
+			auto:   []string{"数学", "七年级"},
+			want:   []string{"t1", "t2", "t3", "t4", "t5", "t6", "t7", "数学"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := mergeLessonTags(tt.manual, tt.auto); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("mergeLessonTags() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCreateLessonFromGenerationAddsTags(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	params, _ := json.Marshal(model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数的加法"})
+	result, _ := json.Marshal(GeneratedLessonData{KeyPoints: []string{"加法法则"}})
+	generation := &model.Generation{UserID: userID, Status: model.GenerationStatusCompleted, Parameters: string(params), Result: string(result)}
+	generationRepo := newFakeGenerationRepo()
+	_ = generationRepo.Create(ctx, generation)
+	svc := newTestLessonServiceWithGenerations(newFakeLessonRepo(), generationRepo)
+
+	lesson, err := svc.Create(ctx, userID, &CreateLessonRequest{
+		Title: "有理数", Subject: "数学", Grade: "七年级", Tags: []string{"公开课"}, GenerationID: &generation.ID,
+	})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	var tags []string
+	_ = json.Unmarshal([]byte(lesson.Tags), &tags)
+	if want := []string{"公开课", "数学", "七年级", "有理数的加法", "加法法则"}; !reflect.DeepEqual(tags, want) {
+		t.Errorf("Tags = %q, want %q", tags, want)
+	}
+
+	// 手动创建的教案不自动打标签
+	manual, err := svc.Create(ctx, userID, &CreateLessonRequest{Title: "手写", Subject: "数学", Grade: "七年级", Tags: []string{"公开课"}})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if manual.Tags != `["公开课"]` {
+		t.Errorf("manual Tags = %s, want only the user's tags", manual.Tags)
+	}
+}
@@ -0,0 +1,226 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrUnsupportedTranslationTarget 不支持的目标语言
+var ErrUnsupportedTranslationTarget = errors.New("不支持的目标语言")
+
+// translationTargets 支持的目标语言（代码 -> 语言名称，传给 Agent 作为提示）
+var translationTargets = map[string]string{
+	"en": "English",
+	"zh": "简体中文",
+	"ja": "日本語",
+}
+
+// LessonTranslation 教案翻译结果。
+type LessonTranslation struct {
+	LessonID      uuid.UUID  `json:"lesson_id"`
+	Target        string     `json:"target"`
+	Title         string     `json:"title"`
+	Subject       string     `json:"subject"`
+	Grade         string     `json:"grade"`
+	Objectives    string     `json:"objectives"`
+	Content       string     `json:"content"`
+	Activities    string     `json:"activities"`
+	Assessment    string     `json:"assessment"`
+	Resources     string     `json:"resources"`
+	SavedLessonID *uuid.UUID `json:"saved_lesson_id,omitempty"`
+}
+
+type agentLessonTranslateRequest struct {
+	LessonID       string `json:"lessonId"`
+	TargetLanguage string `json:"targetLanguage"`
+	TargetCode     string `json:"targetCode"`
+	Title          string `json:"title"`
+	Subject        string `json:"subject"`
+	Grade          string `json:"grade"`
+	Objectives     string `json:"objectives"`
+	Content        string `json:"content"`
+	Activities     string `json:"activities"`
+	Assessment     string `json:"assessment"`
+	Resources      string `json:"resources"`
+}
+
+type agentLessonTranslateResponse struct {
+	Success bool `json:"success"`
+	Data    *struct {
+		Title      string `json:"title"`
+		Subject    string `json:"subject"`
+		Grade      string `json:"grade"`
+		Objectives string `json:"objectives"`
+		Content    string `json:"content"`
+		Activities string `json:"activities"`
+		Assessment string `json:"assessment"`
+		Resources  string `json:"resources"`
+	} `json:"data"`
+	Error string `json:"error,omitempty"`
+}
+
+// IsSupportedTranslationTarget 判断目标语言是否受支持
+func IsSupportedTranslationTarget(target string) bool {
+	_, ok := translationTargets[target]
+	return ok
+}
+
+func (s *lessonService) Translate(ctx context.Context, lessonID, userID uuid.UUID, target string, saveAsNew bool) (*LessonTranslation, error) {
+	target = strings.ToLower(strings.TrimSpace(target))
+	if !IsSupportedTranslationTarget(target) {
+		return nil, ErrUnsupportedTranslationTarget
+	}
+
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return nil, ErrLessonNotFound
+	}
+	// 作者可翻译自己的任意教案，其他人只能翻译已发布的教案
+	if lesson.UserID != userID && lesson.Status != model.LessonStatusPublished {
+		return nil, ErrUnauthorized
+	}
+
+	translation, err := s.translateByAgent(ctx, lesson, target)
+	if err != nil {
+		return nil, err
+	}
+
+	if saveAsNew {
+		saved := buildTranslatedLesson(lesson, translation, userID)
+		if err := s.lessonRepo.Create(ctx, saved); err != nil {
+			return nil, fmt.Errorf("保存译文教案失败: %w", err)
+		}
+		translation.SavedLessonID = &saved.ID
+	}
+
+	return translation, nil
+}
+
+func (s *lessonService) translateByAgent(ctx context.Context, lesson *model.Lesson, target string) (*LessonTranslation, error) {
+	if s.cfg == nil || strings.TrimSpace(s.cfg.URL) == "" || s.httpClient == nil {
+		return nil, errors.New("agent 翻译服务未配置")
+	}
+
+	requestPayload := agentLessonTranslateRequest{
+		LessonID:       lesson.ID.String(),
+		TargetLanguage: translationTargets[target],
+		TargetCode:     target,
+		Title:          strings.TrimSpace(lesson.Title),
+		Subject:        strings.TrimSpace(lesson.Subject),
+		Grade:          strings.TrimSpace(lesson.Grade),
+		Objectives:     normalizeLessonText(lesson.Objectives),
+		Content:        normalizeLessonText(lesson.Content),
+		Activities:     normalizeLessonText(lesson.Activities),
+		Assessment:     normalizeLessonText(lesson.Assessment),
+		Resources:      normalizeLessonText(lesson.Resources),
+	}
+
+	body, err := json.Marshal(requestPayload)
+	if err != nil {
+		return nil, fmt.Errorf("marshal translate request failed: %w", err)
+	}
+
+	headers := map[string]string{
+		"Content-Type": "application/json",
+	}
+	override := APIKeyOverrideFromContext(ctx)
+	if override.GenerationAPIKey != "" {
+		headers[HeaderGenerationAPIKey] = override.GenerationAPIKey
+	}
+	if s.cfg.APIKey != "" {
+		headers["Authorization"] = "Bearer " + s.cfg.APIKey
+	}
+
+	url := fmt.Sprintf("%s/api/translate", strings.TrimRight(s.cfg.URL, "/"))
+	statusCode, respBody, err := doAgentRequestWithRetry(
+		ctx,
+		s.httpClient,
+		http.MethodPost,
+		url,
+		body,
+		headers,
+		"translate",
+	)
+	if err != nil {
+		return nil, fmt.Errorf("call translate endpoint failed: %w", err)
+	}
+	if statusCode != http.StatusOK {
+		return nil, fmt.Errorf("translate endpoint returned error: %d - %s", statusCode, string(respBody))
+	}
+
+	var response agentLessonTranslateResponse
+	if err := json.Unmarshal(respBody, &response); err != nil {
+		return nil, fmt.Errorf("unmarshal translate response failed: %w", err)
+	}
+	if !response.Success {
+		if strings.TrimSpace(response.Error) != "" {
+			return nil, errors.New(strings.TrimSpace(response.Error))
+		}
+		return nil, errors.New("translate failed")
+	}
+	if response.Data == nil {
+		return nil, errors.New("translate response is empty")
+	}
+
+	// 译文缺失的字段回退原文，避免另存时丢内容
+	return &LessonTranslation{
+		LessonID:   lesson.ID,
+		Target:     target,
+		Title:      firstNonEmpty(response.Data.Title, requestPayload.Title),
+		Subject:    firstNonEmpty(response.Data.Subject, requestPayload.Subject),
+		Grade:      firstNonEmpty(response.Data.Grade, requestPayload.Grade),
+		Objectives: firstNonEmpty(response.Data.Objectives, requestPayload.Objectives),
+		Content:    firstNonEmpty(response.Data.Content, requestPayload.Content),
+		Activities: firstNonEmpty(response.Data.Activities, requestPayload.Activities),
+		Assessment: firstNonEmpty(response.Data.Assessment, requestPayload.Assessment),
+		Resources:  firstNonEmpty(response.Data.Resources, requestPayload.Resources),
+	}, nil
+}
+
+// buildTranslatedLesson 将译文映射为新教案草稿，标签沿用原教案并追加语言标签
+func buildTranslatedLesson(source *model.Lesson, translation *LessonTranslation, userID uuid.UUID) *model.Lesson {
+	var tags []string
+	if source.Tags != "" {
+		_ = json.Unmarshal([]byte(source.Tags), &tags)
+	}
+	tags = append(tags, "lang:"+translation.Target)
+	tagsJSON, _ := json.Marshal(tags)
+
+	title := translation.Title
+	if len([]rune(title)) > 200 {
+		title = string([]rune(title)[:200])
+	}
+
+	return &model.Lesson{
+		UserID:     userID,
+		Title:      title,
+		Subject:    source.Subject,
+		Grade:      source.Grade,
+		Duration:   source.Duration,
+		Objectives: fmt.Sprintf(`{"text": %s}`, strconv.Quote(translation.Objectives)),
+		Content:    fmt.Sprintf(`{"text": %s}`, strconv.Quote(translation.Content)),
+		Activities: translation.Activities,
+		Assessment: translation.Assessment,
+		Resources:  translation.Resources,
+		Tags:       string(tagsJSON),
+		Status:     model.LessonStatusDraft,
+	}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, value := range values {
+		if strings.TrimSpace(value) != "" {
+			return strings.TrimSpace(value)
+		}
+	}
+	return ""
+}
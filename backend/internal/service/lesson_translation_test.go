@@ -0,0 +1,101 @@
+package service
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// newFakeTranslateAgent 模拟 Agent 的 /api/translate，只翻译标题与内容
+func newFakeTranslateAgent(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path != "/api/translate" {
+			http.NotFound(w, r)
+			return
+		}
+		var req agentLessonTranslateRequest
+		_ = json.NewDecoder(r.Body).Decode(&req)
+		_ = json.NewEncoder(w).Encode(map[string]interface{}{
+			"success": true,
+			"data": map[string]string{
+				"title":   "[" + req.TargetCode + "] " + req.Title,
+				"content": "[" + req.TargetCode + "] " + req.Content,
+			},
+		})
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestTranslateLesson(t *testing.T) {
+	authorID, otherID := uuid.New(), uuid.New()
+
+	tests := []struct {
+		name      string
+		status    string
+		userID    uuid.UUID
+		target    string
+		saveAsNew bool
+		wantErr   error
+		wantTitle string
+	}{
+		{name: "unsupported target", status: model.LessonStatusDraft, userID: authorID, target: "fr", wantErr: ErrUnsupportedTranslationTarget},
+		{name: "author translates own draft", status: model.LessonStatusDraft, userID: authorID, target: "EN", wantTitle: "[en] 正式版标题"},
+		{name: "others cannot translate drafts", status: model.LessonStatusDraft, userID: otherID, target: "en", wantErr: ErrUnauthorized},
+		{name: "others translate published lessons", status: model.LessonStatusPublished, userID: otherID, target: "ja", wantTitle: "[ja] 正式版标题"},
+		{name: "save as new draft", status: model.LessonStatusPublished, userID: otherID, target: "en", saveAsNew: true, wantTitle: "[en] 正式版标题"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := publishableLesson(authorID, tt.status)
+			lesson.Activities = "小组讨论"
+			lesson.Tags = `["数学"]`
+			repo := newFakeLessonRepo(lesson)
+			svc := newTestLessonServiceWithAgent(repo, newFakeTranslateAgent(t).URL)
+
+			got, err := svc.Translate(context.Background(), lesson.ID, tt.userID, tt.target, tt.saveAsNew)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Translate() error = %v", err)
+			}
+			if got.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", got.Title, tt.wantTitle)
+			}
+			// 译文缺失的字段回退原文
+			if got.Activities != "小组讨论" {
+				t.Errorf("activities = %q, want the original text", got.Activities)
+			}
+
+			if !tt.saveAsNew {
+				if got.SavedLessonID != nil || len(repo.lessons) != 1 {
+					t.Error("translation should not be saved")
+				}
+				return
+			}
+			if got.SavedLessonID == nil {
+				t.Fatal("saved lesson id is empty")
+			}
+			saved, _ := repo.GetByID(context.Background(), *got.SavedLessonID)
+			if saved.UserID != tt.userID || saved.Status != model.LessonStatusDraft {
+				t.Errorf("saved lesson owner/status = %s/%s, want %s/draft", saved.UserID, saved.Status, tt.userID)
+			}
+			if saved.Tags != `["数学","lang:en"]` {
+				t.Errorf("saved tags = %s, want language tag appended", saved.Tags)
+			}
+		})
+	}
+}
@@ -49,7 +49,16 @@ func (s *likeService) Like(ctx context.Context, userID, lessonID uuid.UUID) erro
 	return nil
 }
 
+// Unlike 取消操作幂等：记录不存在时直接返回成功，不触发计数重算
 func (s *likeService) Unlike(ctx context.Context, userID, lessonID uuid.UUID) error {
+	exists, err := s.likeRepo.Exists(ctx, userID, lessonID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return nil
+	}
+
 	if err := s.likeRepo.Delete(ctx, userID, lessonID); err != nil {
 		return err
 	}
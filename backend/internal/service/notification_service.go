@@ -0,0 +1,336 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/mailer"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// maxDigestItems 单封摘要邮件最多列出的通知数，其余留到下一次
+	maxDigestItems = 100
+	// notificationExcerptLength 通知中评论摘录的最大长度（字符）
+	notificationExcerptLength = 200
+)
+
+var ErrInvalidNotifyMode = errors.New("通知偏好只能是 realtime、daily 或 off")
+
+// DigestResult 一次摘要发送的统计
+type DigestResult struct {
+	Users         int `json:"users"`
+	Sent          int `json:"sent"`
+	Failed        int `json:"failed"`
+	Notifications int `json:"notifications"`
+}
+
+// NotificationService 通知服务接口
+type NotificationService interface {
+	NotifyComment(ctx context.Context, comment *model.Comment)
+	GetPreference(ctx context.Context, userID uuid.UUID) (string, error)
+	UpdatePreference(ctx context.Context, userID uuid.UUID, mode string) error
+	SendDigests(ctx context.Context) (*DigestResult, error)
+	RunDigestScheduler(ctx context.Context, hour int)
+}
+
+// notificationService 通知服务实现
+type notificationService struct {
+	notificationRepo repository.NotificationRepository
+	userRepo         repository.UserRepository
+	lessonRepo       repository.LessonRepository
+	commentRepo      repository.CommentRepository
+	mailer           mailer.Sender
+	// lessonURL 前端教案详情页地址前缀
+	lessonURL string
+	now       func() time.Time
+}
+
+// NewNotificationService 创建通知服务
+func NewNotificationService(
+	notificationRepo repository.NotificationRepository,
+	userRepo repository.UserRepository,
+	lessonRepo repository.LessonRepository,
+	commentRepo repository.CommentRepository,
+	mailSender mailer.Sender,
+	lessonURL string,
+) NotificationService {
+	return &notificationService{
+		notificationRepo: notificationRepo,
+		userRepo:         userRepo,
+		lessonRepo:       lessonRepo,
+		commentRepo:      commentRepo,
+		mailer:           mailSender,
+		lessonURL:        strings.TrimRight(strings.TrimSpace(lessonURL), "/"),
+		now:              time.Now,
+	}
+}
+
+// NotifyComment 为新评论通知教案作者，回复同时通知被回复的评论作者；
+// 在后台执行，邮件发送不阻塞评论请求
+func (s *notificationService) NotifyComment(ctx context.Context, comment *model.Comment) {
+	ctx = context.WithoutCancel(ctx)
+	go func() {
+		if err := s.notifyComment(ctx, comment); err != nil {
+			logger.Warn("Failed to create comment notification",
+				logger.String("comment_id", comment.ID.String()),
+				logger.String("error", err.Error()),
+			)
+		}
+	}()
+}
+
+func (s *notificationService) notifyComment(ctx context.Context, comment *model.Comment) error {
+	lesson, err := s.lessonRepo.GetByID(ctx, comment.LessonID)
+	if err != nil {
+		return err
+	}
+	actor, err := s.userRepo.GetByID(ctx, comment.UserID)
+	if err != nil {
+		return err
+	}
+
+	recipients := map[uuid.UUID]string{lesson.UserID: model.NotificationTypeComment}
+	if comment.ParentID != nil {
+		if parent, err := s.commentRepo.GetByID(ctx, *comment.ParentID); err == nil {
+			recipients[parent.UserID] = model.NotificationTypeReply
+		}
+	}
+	delete(recipients, comment.UserID)
+
+	for userID, notificationType := range recipients {
+		user, err := s.userRepo.GetByID(ctx, userID)
+		if err != nil {
+			continue
+		}
+
+		notification := &model.Notification{
+			UserID:      user.ID,
+			Type:        notificationType,
+			ActorID:     actor.ID,
+			ActorName:   firstNonEmpty(actor.FullName, actor.Username),
+			LessonID:    lesson.ID,
+			LessonTitle: lesson.Title,
+			CommentID:   comment.ID,
+			Content:     truncateRunes(comment.Content, notificationExcerptLength),
+		}
+		if user.NotifyMode == model.NotifyModeOff {
+			notification.EmailStatus = model.NotificationEmailSkipped
+		}
+		if err := s.notificationRepo.Create(ctx, notification); err != nil {
+			return err
+		}
+
+		// 每日摘要由定时任务发送；实时发送失败的保持 pending，由下一次摘要补发
+		if user.NotifyMode == model.NotifyModeRealtime {
+			s.sendRealtime(ctx, user, notification)
+		}
+	}
+	return nil
+}
+
+func (s *notificationService) sendRealtime(ctx context.Context, user *model.User, notification *model.Notification) {
+	subject := notificationSubject(notification)
+	body := fmt.Sprintf("%s，您好：\n\n%s\n\n%s\n\n如需减少邮件，可在个人设置中将评论通知改为每日摘要或关闭。",
+		firstNonEmpty(user.FullName, user.Username), s.formatNotification(notification), s.lessonLink(notification.LessonID))
+	if err := s.mailer.Send(ctx, user.Email, subject, body); err != nil {
+		logger.Warn("Failed to send notification email",
+			logger.String("notification_id", notification.ID.String()),
+			logger.String("error", err.Error()),
+		)
+		return
+	}
+	now := s.now()
+	_, _ = s.notificationRepo.UpdateEmailStatus(ctx, []uuid.UUID{notification.ID},
+		model.NotificationEmailPending, model.NotificationEmailSent, &now)
+}
+
+func (s *notificationService) GetPreference(ctx context.Context, userID uuid.UUID) (string, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+	return firstNonEmpty(user.NotifyMode, model.NotifyModeRealtime), nil
+}
+
+// UpdatePreference 修改通知偏好；关闭时未发送的通知不再补发
+func (s *notificationService) UpdatePreference(ctx context.Context, userID uuid.UUID, mode string) error {
+	mode = strings.ToLower(strings.TrimSpace(mode))
+	switch mode {
+	case model.NotifyModeRealtime, model.NotifyModeDaily, model.NotifyModeOff:
+	default:
+		return ErrInvalidNotifyMode
+	}
+
+	if err := s.userRepo.UpdateNotifyMode(ctx, userID, mode); err != nil {
+		return err
+	}
+	if mode == model.NotifyModeOff {
+		return s.notificationRepo.SkipPendingByUser(ctx, userID)
+	}
+	return nil
+}
+
+// SendDigests 为每个有待发送通知的用户汇总发送一封摘要邮件；
+// 先认领再发送，发送失败时退回 pending 等待下次
+func (s *notificationService) SendDigests(ctx context.Context) (*DigestResult, error) {
+	userIDs, err := s.notificationRepo.ListDigestUserIDs(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &DigestResult{Users: len(userIDs)}
+	for _, userID := range userIDs {
+		if ctx.Err() != nil {
+			return result, ctx.Err()
+		}
+
+		count, err := s.sendDigest(ctx, userID)
+		if err != nil {
+			result.Failed++
+			logger.Warn("Failed to send notification digest",
+				logger.String("user_id", userID.String()),
+				logger.String("error", err.Error()),
+			)
+			continue
+		}
+		if count > 0 {
+			result.Sent++
+			result.Notifications += count
+		}
+	}
+	return result, nil
+}
+
+func (s *notificationService) sendDigest(ctx context.Context, userID uuid.UUID) (int, error) {
+	user, err := s.userRepo.GetByID(ctx, userID)
+	if err != nil {
+		return 0, err
+	}
+	notifications, err := s.notificationRepo.ListPendingByUser(ctx, userID, maxDigestItems)
+	if err != nil || len(notifications) == 0 {
+		return 0, err
+	}
+
+	ids := make([]uuid.UUID, len(notifications))
+	for i, n := range notifications {
+		ids[i] = n.ID
+	}
+	now := s.now()
+	claimed, err := s.notificationRepo.UpdateEmailStatus(ctx, ids, model.NotificationEmailPending, model.NotificationEmailSent, &now)
+	if err != nil || claimed == 0 {
+		// 已被其他实例认领
+		return 0, err
+	}
+
+	subject, body := s.buildDigest(user, notifications)
+	if err := s.mailer.Send(ctx, user.Email, subject, body); err != nil {
+		_, _ = s.notificationRepo.UpdateEmailStatus(ctx, ids, model.NotificationEmailSent, model.NotificationEmailPending, nil)
+		return 0, err
+	}
+	return len(notifications), nil
+}
+
+// buildDigest 按教案分组汇总通知，教案顺序与通知时间顺序一致
+func (s *notificationService) buildDigest(user *model.User, notifications []model.Notification) (string, string) {
+	order := make([]uuid.UUID, 0)
+	groups := make(map[uuid.UUID][]model.Notification)
+	for _, n := range notifications {
+		if _, ok := groups[n.LessonID]; !ok {
+			order = append(order, n.LessonID)
+		}
+		groups[n.LessonID] = append(groups[n.LessonID], n)
+	}
+
+	var sb strings.Builder
+	sb.WriteString(fmt.Sprintf("%s，您好：\n\n您有 %d 条新的评论通知：\n", firstNonEmpty(user.FullName, user.Username), len(notifications)))
+	for _, lessonID := range order {
+		items := groups[lessonID]
+		sb.WriteString(fmt.Sprintf("\n《%s》（%d 条）\n", items[0].LessonTitle, len(items)))
+		for _, n := range items {
+			sb.WriteString("- " + s.formatNotification(&n) + "\n")
+		}
+		if link := s.lessonLink(lessonID); link != "" {
+			sb.WriteString(link + "\n")
+		}
+	}
+	sb.WriteString("\n如需调整通知方式，可在个人设置中修改评论通知偏好。")
+
+	subject := fmt.Sprintf("评论通知每日摘要：%d 条新消息", len(notifications))
+	return subject, sb.String()
+}
+
+func (s *notificationService) formatNotification(n *model.Notification) string {
+	return fmt.Sprintf("%s %s%s：%s", n.CreatedAt.Format("01-02 15:04"), n.ActorName, notificationAction(n.Type), n.Content)
+}
+
+func (s *notificationService) lessonLink(lessonID uuid.UUID) string {
+	if s.lessonURL == "" {
+		return ""
+	}
+	return s.lessonURL + "/" + lessonID.String()
+}
+
+// RunDigestScheduler 每天在 hour 点发送摘要，阻塞直到 ctx 取消
+func (s *notificationService) RunDigestScheduler(ctx context.Context, hour int) {
+	for {
+		timer := time.NewTimer(time.Until(nextDigestTime(s.now(), hour)))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		result, err := s.SendDigests(ctx)
+		if err != nil {
+			logger.Error("Notification digest run failed", logger.String("error", err.Error()))
+			continue
+		}
+		logger.Info("Notification digest sent",
+			logger.Int("users", result.Users),
+			logger.Int("sent", result.Sent),
+			logger.Int("failed", result.Failed),
+			logger.Int("notifications", result.Notifications),
+		)
+	}
+}
+
+// nextDigestTime 下一个 hour 点整（本地时间），当前已过该时刻则为次日
+func nextDigestTime(now time.Time, hour int) time.Time {
+	next := time.Date(now.Year(), now.Month(), now.Day(), hour, 0, 0, 0, now.Location())
+	if !next.After(now) {
+		next = next.AddDate(0, 0, 1)
+	}
+	return next
+}
+
+// notificationSubject 实时通知邮件标题
+func notificationSubject(n *model.Notification) string {
+	if n.Type == model.NotificationTypeReply {
+		return fmt.Sprintf("%s 回复了您在《%s》的评论", n.ActorName, n.LessonTitle)
+	}
+	return fmt.Sprintf("%s 评论了《%s》", n.ActorName, n.LessonTitle)
+}
+
+func notificationAction(notificationType string) string {
+	if notificationType == model.NotificationTypeReply {
+		return " 回复了您的评论"
+	}
+	return " 评论"
+}
+
+func truncateRunes(s string, max int) string {
+	runes := []rune(strings.TrimSpace(s))
+	if len(runes) <= max {
+		return string(runes)
+	}
+	return string(runes[:max]) + "…"
+}
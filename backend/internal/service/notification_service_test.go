@@ -0,0 +1,382 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+func (r *fakeUserRepo) UpdateNotifyMode(ctx context.Context, id uuid.UUID, mode string) error {
+	if user, ok := r.users[id]; ok {
+		user.NotifyMode = mode
+	}
+	return nil
+}
+
+// fakeNotificationRepo 内存中的通知仓库，摘要用户按 users 中的通知偏好过滤
+type fakeNotificationRepo struct {
+	repository.NotificationRepository
+
+	mu            sync.Mutex
+	users         *fakeUserRepo
+	notifications []*model.Notification
+	clock         time.Time
+}
+
+func newFakeNotificationRepo(users *fakeUserRepo) *fakeNotificationRepo {
+	return &fakeNotificationRepo{users: users, clock: time.Date(2026, 10, 16, 8, 0, 0, 0, time.UTC)}
+}
+
+func (r *fakeNotificationRepo) Create(ctx context.Context, n *model.Notification) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if n.ID == uuid.Nil {
+		n.ID = uuid.New()
+	}
+	if n.EmailStatus == "" {
+		n.EmailStatus = model.NotificationEmailPending
+	}
+	// 每条通知晚一分钟，保证时间顺序确定
+	r.clock = r.clock.Add(time.Minute)
+	n.CreatedAt = r.clock
+	stored := *n
+	r.notifications = append(r.notifications, &stored)
+	return nil
+}
+
+func (r *fakeNotificationRepo) ListDigestUserIDs(ctx context.Context) ([]uuid.UUID, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	seen := make(map[uuid.UUID]bool)
+	var ids []uuid.UUID
+	for _, n := range r.notifications {
+		user, ok := r.users.users[n.UserID]
+		if n.EmailStatus != model.NotificationEmailPending || !ok || user.NotifyMode == model.NotifyModeOff || seen[n.UserID] {
+			continue
+		}
+		seen[n.UserID] = true
+		ids = append(ids, n.UserID)
+	}
+	return ids, nil
+}
+
+func (r *fakeNotificationRepo) ListPendingByUser(ctx context.Context, userID uuid.UUID, limit int) ([]model.Notification, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []model.Notification
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.EmailStatus == model.NotificationEmailPending && len(list) < limit {
+			list = append(list, *n)
+		}
+	}
+	return list, nil
+}
+
+func (r *fakeNotificationRepo) UpdateEmailStatus(ctx context.Context, ids []uuid.UUID, fromStatus, toStatus string, at *time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var updated int64
+	for _, n := range r.notifications {
+		for _, id := range ids {
+			if n.ID == id && n.EmailStatus == fromStatus {
+				n.EmailStatus = toStatus
+				n.EmailedAt = at
+				updated++
+			}
+		}
+	}
+	return updated, nil
+}
+
+func (r *fakeNotificationRepo) SkipPendingByUser(ctx context.Context, userID uuid.UUID) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	for _, n := range r.notifications {
+		if n.UserID == userID && n.EmailStatus == model.NotificationEmailPending {
+			n.EmailStatus = model.NotificationEmailSkipped
+		}
+	}
+	return nil
+}
+
+// statuses 用户各条通知的邮件状态，按创建顺序
+func (r *fakeNotificationRepo) statuses(userID uuid.UUID) []string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	var list []string
+	for _, n := range r.notifications {
+		if n.UserID == userID {
+			list = append(list, n.EmailStatus)
+		}
+	}
+	return list
+}
+
+// scriptedMailer 按收件人决定是否发送失败，记录成功发送的邮件
+type scriptedMailer struct {
+	fakeMailer
+
+	failFor map[string]bool
+}
+
+func (m *scriptedMailer) Send(ctx context.Context, to, subject, body string) error {
+	if m.failFor[to] {
+		return errors.New("smtp unavailable")
+	}
+	return m.fakeMailer.Send(ctx, to, subject, body)
+}
+
+type notificationFixture struct {
+	svc           *notificationService
+	notifications *fakeNotificationRepo
+	users         *fakeUserRepo
+	mail          *scriptedMailer
+	author        *model.User
+	commenter     *model.User
+	lessons       []*model.Lesson
+}
+
+// newNotificationFixture 作者拥有两篇教案，评论者在上面发表评论
+func newNotificationFixture(t *testing.T, authorMode string) *notificationFixture {
+	author := activeUser(t, "author@example.com", "Passw0rd!")
+	author.FullName = "王老师"
+	author.NotifyMode = authorMode
+	commenter := activeUser(t, "commenter@example.com", "Passw0rd!")
+	commenter.FullName = "李老师"
+	commenter.NotifyMode = model.NotifyModeRealtime
+	users := newFakeUserRepo(author, commenter)
+
+	lessons := []*model.Lesson{
+		{ID: uuid.New(), UserID: author.ID, Title: "勾股定理"},
+		{ID: uuid.New(), UserID: author.ID, Title: "有理数"},
+	}
+	notifications := newFakeNotificationRepo(users)
+	mail := &scriptedMailer{failFor: map[string]bool{}}
+	svc := NewNotificationService(notifications, users, newFakeLessonRepo(lessons...), newFakeCommentRepo(), mail,
+		"https://lesson.example.com/lessons/").(*notificationService)
+	svc.now = func() time.Time { return time.Date(2026, 10, 17, 20, 0, 0, 0, time.UTC) }
+
+	return &notificationFixture{svc: svc, notifications: notifications, users: users, mail: mail, author: author, commenter: commenter, lessons: lessons}
+}
+
+// comment 评论者在第 i 篇教案下发表评论
+func (f *notificationFixture) comment(t *testing.T, i int, content string) {
+	t.Helper()
+	err := f.svc.notifyComment(context.Background(), &model.Comment{ID: uuid.New(), LessonID: f.lessons[i].ID, UserID: f.commenter.ID, Content: content})
+	if err != nil {
+		t.Fatalf("notifyComment() error = %v", err)
+	}
+}
+
+func TestNotifyCommentFollowsPreference(t *testing.T) {
+	tests := []struct {
+		mode       string
+		wantStatus string
+		wantMails  int
+	}{
+		{mode: model.NotifyModeRealtime, wantStatus: model.NotificationEmailSent, wantMails: 1},
+		{mode: model.NotifyModeDaily, wantStatus: model.NotificationEmailPending, wantMails: 0},
+		{mode: model.NotifyModeOff, wantStatus: model.NotificationEmailSkipped, wantMails: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mode, func(t *testing.T) {
+			f := newNotificationFixture(t, tt.mode)
+			f.comment(t, 0, "讲解很清楚")
+
+			if got := f.notifications.statuses(f.author.ID); len(got) != 1 || got[0] != tt.wantStatus {
+				t.Fatalf("author notifications = %v, want one %s", got, tt.wantStatus)
+			}
+			if len(f.mail.sent) != tt.wantMails {
+				t.Fatalf("sent %d mails, want %d", len(f.mail.sent), tt.wantMails)
+			}
+			if tt.wantMails > 0 {
+				m := f.mail.sent[0]
+				if m.to != "author@example.com" || m.subject != "李老师 评论了《勾股定理》" {
+					t.Errorf("mail = %+v", m)
+				}
+				if !strings.Contains(m.body, "李老师 评论：讲解很清楚") || !strings.Contains(m.body, "https://lesson.example.com/lessons/"+f.lessons[0].ID.String()) {
+					t.Errorf("mail body = %q, want excerpt and lesson link", m.body)
+				}
+			}
+			// 评论者不会收到自己评论的通知
+			if got := f.notifications.statuses(f.commenter.ID); len(got) != 0 {
+				t.Errorf("commenter notifications = %v, want none", got)
+			}
+		})
+	}
+}
+
+func TestRealtimeSendFailureLeftForDigest(t *testing.T) {
+	f := newNotificationFixture(t, model.NotifyModeRealtime)
+	f.mail.failFor["author@example.com"] = true
+	f.comment(t, 0, "第一条")
+
+	if got := f.notifications.statuses(f.author.ID); got[0] != model.NotificationEmailPending {
+		t.Fatalf("status after failed realtime send = %v, want pending", got)
+	}
+
+	delete(f.mail.failFor, "author@example.com")
+	result, err := f.svc.SendDigests(context.Background())
+	if err != nil || result.Sent != 1 || result.Notifications != 1 {
+		t.Fatalf("SendDigests() = %+v, %v; want the failed realtime mail resent", result, err)
+	}
+}
+
+func TestSendDigestsAggregatesPerUser(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, model.NotifyModeDaily)
+	f.comment(t, 0, "第一条")
+	f.comment(t, 1, "第二条")
+	f.comment(t, 0, "第三条")
+
+	// 另一位关闭通知的作者，其待发送通知不会进入摘要
+	quiet := activeUser(t, "quiet@example.com", "Passw0rd!")
+	quiet.NotifyMode = model.NotifyModeOff
+	f.users.users[quiet.ID] = quiet
+	_ = f.notifications.Create(ctx, &model.Notification{UserID: quiet.ID, LessonID: f.lessons[0].ID, LessonTitle: "勾股定理"})
+
+	result, err := f.svc.SendDigests(ctx)
+	if err != nil {
+		t.Fatalf("SendDigests() error = %v", err)
+	}
+	if *result != (DigestResult{Users: 1, Sent: 1, Notifications: 3}) {
+		t.Errorf("SendDigests() = %+v, want one digest with 3 notifications", result)
+	}
+	if len(f.mail.sent) != 1 {
+		t.Fatalf("sent %d mails, want 1 digest", len(f.mail.sent))
+	}
+
+	digest := f.mail.sent[0]
+	if digest.to != "author@example.com" || digest.subject != "评论通知每日摘要：3 条新消息" {
+		t.Errorf("digest = %s / %s", digest.to, digest.subject)
+	}
+	// 按教案分组，组内按时间排序，教案顺序与首条通知一致
+	first := strings.Index(digest.body, "《勾股定理》（2 条）")
+	second := strings.Index(digest.body, "《有理数》（1 条）")
+	if first < 0 || second < first {
+		t.Fatalf("digest body groups out of order:\n%s", digest.body)
+	}
+	if a, c := strings.Index(digest.body, "第一条"), strings.Index(digest.body, "第三条"); a < first || c > second || a > c {
+		t.Errorf("lesson group items misplaced:\n%s", digest.body)
+	}
+	if !strings.HasPrefix(digest.body, "王老师，您好") {
+		t.Errorf("digest greeting = %q", strings.SplitN(digest.body, "\n", 2)[0])
+	}
+
+	for _, status := range f.notifications.statuses(f.author.ID) {
+		if status != model.NotificationEmailSent {
+			t.Errorf("author statuses = %v, want all sent", f.notifications.statuses(f.author.ID))
+			break
+		}
+	}
+
+	// 已发送的通知不会重复进入下一次摘要
+	result, _ = f.svc.SendDigests(ctx)
+	if result.Sent != 0 || len(f.mail.sent) != 1 {
+		t.Errorf("second SendDigests() = %+v, want nothing sent", result)
+	}
+}
+
+func TestSendDigestsFailureRevertsToPending(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, model.NotifyModeDaily)
+	f.comment(t, 0, "第一条")
+	f.comment(t, 1, "第二条")
+	f.mail.failFor["author@example.com"] = true
+
+	result, err := f.svc.SendDigests(ctx)
+	if err != nil || result.Failed != 1 || result.Sent != 0 {
+		t.Fatalf("SendDigests() = %+v, %v; want one failure", result, err)
+	}
+	if got := f.notifications.statuses(f.author.ID); got[0] != model.NotificationEmailPending || got[1] != model.NotificationEmailPending {
+		t.Errorf("statuses after failure = %v, want pending for retry", got)
+	}
+
+	delete(f.mail.failFor, "author@example.com")
+	if result, _ := f.svc.SendDigests(ctx); result.Sent != 1 || result.Notifications != 2 {
+		t.Errorf("retry SendDigests() = %+v, want both notifications sent", result)
+	}
+}
+
+func TestUpdatePreference(t *testing.T) {
+	ctx := context.Background()
+	f := newNotificationFixture(t, model.NotifyModeDaily)
+	f.comment(t, 0, "第一条")
+
+	if err := f.svc.UpdatePreference(ctx, f.author.ID, "weekly"); !errors.Is(err, ErrInvalidNotifyMode) {
+		t.Errorf("UpdatePreference(weekly) error = %v, want ErrInvalidNotifyMode", err)
+	}
+	if err := f.svc.UpdatePreference(ctx, f.author.ID, " OFF "); err != nil {
+		t.Fatalf("UpdatePreference(off) error = %v", err)
+	}
+	if mode, _ := f.svc.GetPreference(ctx, f.author.ID); mode != model.NotifyModeOff {
+		t.Errorf("GetPreference() = %q, want off", mode)
+	}
+	// 关闭后未发送的通知不再补发
+	if got := f.notifications.statuses(f.author.ID); got[0] != model.NotificationEmailSkipped {
+		t.Errorf("pending after off = %v, want skipped", got)
+	}
+	if result, _ := f.svc.SendDigests(ctx); result.Users != 0 || len(f.mail.sent) != 0 {
+		t.Errorf("SendDigests() after off = %+v, want no digest", result)
+	}
+
+	// 未设置过偏好的用户默认实时
+	f.users.users[f.commenter.ID].NotifyMode = ""
+	if mode, _ := f.svc.GetPreference(ctx, f.commenter.ID); mode != model.NotifyModeRealtime {
+		t.Errorf("GetPreference() default = %q, want realtime", mode)
+	}
+}
+
+func TestNextDigestTime(t *testing.T) {
+	loc := time.FixedZone("CST", 8*3600)
+	tests := []struct {
+		now  time.Time
+		want time.Time
+	}{
+		{time.Date(2026, 10, 16, 7, 30, 0, 0, loc), time.Date(2026, 10, 16, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 10, 16, 8, 0, 0, 0, loc), time.Date(2026, 10, 17, 8, 0, 0, 0, loc)},
+		{time.Date(2026, 12, 31, 21, 0, 0, 0, loc), time.Date(2027, 1, 1, 8, 0, 0, 0, loc)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.now.Format(time.RFC3339), func(t *testing.T) {
+			if got := nextDigestTime(tt.now, 8); !got.Equal(tt.want) {
+				t.Errorf("nextDigestTime() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestNotifyReplyNotifiesParentAuthor(t *testing.T) {
+	f := newNotificationFixture(t, model.NotifyModeDaily)
+	replier := activeUser(t, "replier@example.com", "Passw0rd!")
+	replier.FullName = "赵老师"
+	f.users.users[replier.ID] = replier
+	parent := &model.Comment{ID: uuid.New(), LessonID: f.lessons[0].ID, UserID: f.commenter.ID}
+	f.svc.commentRepo = newFakeCommentRepo(parent)
+
+	reply := &model.Comment{ID: uuid.New(), LessonID: f.lessons[0].ID, UserID: replier.ID, ParentID: &parent.ID, Content: "同意"}
+	if err := f.svc.notifyComment(context.Background(), reply); err != nil {
+		t.Fatalf("notifyComment() error = %v", err)
+	}
+
+	// 教案作者收到评论通知（每日摘要），被回复者收到实时回复通知
+	if got := f.notifications.statuses(f.author.ID); len(got) != 1 || got[0] != model.NotificationEmailPending {
+		t.Errorf("author notifications = %v, want one pending", got)
+	}
+	if len(f.mail.sent) != 1 || f.mail.sent[0].to != "commenter@example.com" || f.mail.sent[0].subject != "赵老师 回复了您在《勾股定理》的评论" {
+		t.Fatalf("sent = %+v, want realtime reply mail to the parent author", f.mail.sent)
+	}
+	if !strings.Contains(f.mail.sent[0].body, "赵老师 回复了您的评论：同意") {
+		t.Errorf("reply body = %q", f.mail.sent[0].body)
+	}
+}
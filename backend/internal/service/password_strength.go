@@ -0,0 +1,84 @@
+package service
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// ErrWeakPassword 密码强度不足
+var ErrWeakPassword = errors.New("密码强度不足")
+
+const (
+	// minPasswordLength 密码最少字符数
+	minPasswordLength = 8
+	// minPasswordCharClasses 小写、大写、数字、符号中至少包含的类别数
+	minPasswordCharClasses = 3
+)
+
+// commonPasswords 常见弱密码，比较时忽略大小写
+var commonPasswords = map[string]bool{
+	"password":    true,
+	"password1":   true,
+	"password123": true,
+	"passw0rd":    true,
+	"p@ssw0rd":    true,
+	"p@ssword1":   true,
+	"qwerty123":   true,
+	"qwer1234":    true,
+	"1qaz2wsx":    true,
+	"1q2w3e4r":    true,
+	"abc12345":    true,
+	"abcd1234":    true,
+	"admin123":    true,
+	"admin@123":   true,
+	"welcome1":    true,
+	"welcome123":  true,
+	"iloveyou1":   true,
+	"letmein1":    true,
+	"12345678":    true,
+	"123456789":   true,
+	"1234567890":  true,
+	"88888888":    true,
+	"11111111":    true,
+	"aa123456":    true,
+	"a1234567":    true,
+	"zxcvbnm1":    true,
+}
+
+// ValidatePasswordStrength 校验密码强度：至少 8 个字符，小写、大写、数字、符号至少包含三类，且不是常见弱密码
+func ValidatePasswordStrength(pw string) error {
+	if utf8.RuneCountInString(pw) < minPasswordLength {
+		return fmt.Errorf("%w: 密码长度至少为 %d 位", ErrWeakPassword, minPasswordLength)
+	}
+
+	var lower, upper, digit, symbol bool
+	for _, r := range pw {
+		switch {
+		case unicode.IsLower(r):
+			lower = true
+		case unicode.IsUpper(r):
+			upper = true
+		case unicode.IsDigit(r):
+			digit = true
+		case !unicode.IsSpace(r):
+			symbol = true
+		}
+	}
+	classes := 0
+	for _, present := range []bool{lower, upper, digit, symbol} {
+		if present {
+			classes++
+		}
+	}
+	if classes < minPasswordCharClasses {
+		return fmt.Errorf("%w: 需包含小写字母、大写字母、数字、符号中的至少 %d 类", ErrWeakPassword, minPasswordCharClasses)
+	}
+
+	if commonPasswords[strings.ToLower(pw)] {
+		return fmt.Errorf("%w: 该密码过于常见", ErrWeakPassword)
+	}
+	return nil
+}
@@ -0,0 +1,84 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func TestValidatePasswordStrength(t *testing.T) {
+	tests := []struct {
+		name    string
+		pw      string
+		wantErr bool
+	}{
+		{name: "lower upper digit", pw: "Teach2026", wantErr: false},
+		{name: "lower digit symbol", pw: "teach#2026", wantErr: false},
+		{name: "all four classes", pw: "Teach#2026", wantErr: false},
+		{name: "non-ascii counts by rune", pw: "教案设计Ab1!", wantErr: false},
+		{name: "empty", pw: "", wantErr: true},
+		{name: "too short", pw: "Ab1!xyz", wantErr: true},
+		{name: "short multibyte over eight bytes", pw: "教案Ab1!x", wantErr: true},
+		{name: "digits only", pw: "20262026", wantErr: true},
+		{name: "lower and digit only", pw: "teach2026", wantErr: true},
+		{name: "spaces are not symbols", pw: "teach 2026", wantErr: true},
+		{name: "common password", pw: "P@ssw0rd", wantErr: true},
+		{name: "common password ignores case", pw: "ADMIN@123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidatePasswordStrength(tt.pw)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ValidatePasswordStrength(%q) error = %v, wantErr %v", tt.pw, err, tt.wantErr)
+			}
+			if err != nil && !errors.Is(err, ErrWeakPassword) {
+				t.Errorf("ValidatePasswordStrength(%q) error = %v, want ErrWeakPassword", tt.pw, err)
+			}
+		})
+	}
+}
+
+func TestPasswordStrengthEnforced(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("register", func(t *testing.T) {
+		users := newFakeUserRepo()
+		auth, _ := newTestAuthService(t, users, &fakeMailer{})
+		_, err := auth.Register(ctx, &RegisterRequest{Username: "teacher", Email: "teacher@example.com", Password: "password"})
+		if !errors.Is(err, ErrWeakPassword) {
+			t.Fatalf("Register() error = %v, want ErrWeakPassword", err)
+		}
+		if len(users.users) != 0 {
+			t.Errorf("users = %d, want no account created", len(users.users))
+		}
+	})
+
+	t.Run("change password", func(t *testing.T) {
+		user := activeUser(t, "teacher@example.com", "Passw0rd!")
+		users := newFakeUserRepo(user)
+		svc, _ := newTestUserService(users, &fakeMailer{})
+
+		if err := svc.ChangePassword(ctx, user.ID, "Passw0rd!", "12345678"); !errors.Is(err, ErrWeakPassword) {
+			t.Fatalf("ChangePassword() error = %v, want ErrWeakPassword", err)
+		}
+		stored, _ := users.GetByID(ctx, user.ID)
+		if bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("Passw0rd!")) != nil {
+			t.Error("weak new password replaced the old one")
+		}
+
+		if err := svc.ChangePassword(ctx, uuid.New(), "Passw0rd!", "Teach#2026"); !errors.Is(err, ErrUserNotFound) {
+			t.Errorf("ChangePassword() for missing user error = %v, want ErrUserNotFound", err)
+		}
+		if err := svc.ChangePassword(ctx, user.ID, "Passw0rd!", "Teach#2026"); err != nil {
+			t.Fatalf("ChangePassword() error = %v", err)
+		}
+		stored, _ = users.GetByID(ctx, user.ID)
+		if bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte("Teach#2026")) != nil {
+			t.Error("strong new password was not saved")
+		}
+	})
+}
@@ -0,0 +1,296 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"text/template"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+// ErrInvalidPromptTemplate prompt 模板语法错误或渲染失败
+var ErrInvalidPromptTemplate = errors.New("prompt 模板无效")
+
+// defaultPromptTemplate 内置的生成 prompt 模板，未自定义或恢复默认时使用
+const defaultPromptTemplate = `{{if .Interdisciplinary}}请生成一份融合{{.Subject}}的{{.Grade}}年级跨学科教案，主题是：{{.Topic}}。{{else}}请生成一份{{.Subject}}学科{{.Grade}}年级的教案，主题是：{{.Topic}}。{{end}}
+
+要求：
+- 课时时长：{{.Duration}}分钟
+- 难度：{{.Difficulty}}
+- 教学风格：{{.Style}}
+- 详略程度：{{.DetailLevel}}
+- 课型：{{.LessonType}}
+
+{{.LessonType}}环节结构：
+{{range .LessonStructure}}- {{.}}
+{{end}}{{if .Interdisciplinary}}
+跨学科整合要求：
+- 以真实问题或项目任务为主线，让{{.Subject}}的知识在同一任务中协同运用，避免各学科内容简单拼接
+{{range .Subjects}}- 写明{{.}}的核心概念及对应的教学目标
+{{end}}- 设计需要综合运用多学科知识完成的探究活动与成果展示
+- 评价同时覆盖各学科知识掌握与综合实践能力
+{{end}}{{if .Preset}}
+{{.Preset.Name}}教学要求：
+{{range .Preset.Instructions}}- {{.}}
+{{end}}{{end}}{{if .Objectives}}
+教学目标：
+{{range .Objectives}}- {{.}}
+{{end}}{{end}}{{if .Keywords}}
+关键知识点：
+{{range .Keywords}}- {{.}}
+{{end}}{{end}}`
+
+var defaultPromptTmpl = template.Must(template.New("prompt").Parse(defaultPromptTemplate))
+
+// PromptTemplate 生成 prompt 模板
+type PromptTemplate struct {
+	Content   string     `json:"content"`
+	IsDefault bool       `json:"is_default"`
+	UpdatedBy string     `json:"updated_by,omitempty"`
+	UpdatedAt *time.Time `json:"updated_at,omitempty"`
+}
+
+// PromptTemplateData 模板可用变量
+type PromptTemplateData struct {
+	// Subject 学科展示名，跨学科时为“数学、物理”形式的组合
+	Subject string
+	// Subjects 学科组合，首个为主学科
+	Subjects []string
+	// Interdisciplinary 组合了两个及以上学科
+	Interdisciplinary bool
+	Grade             string
+	Topic             string
+	Duration          int
+	Difficulty        string
+	// Style 教学风格显示名，命中预设时为预设中文名
+	Style string
+	// Preset 命中的教学风格预设，自由文本风格时为 nil
+	Preset     *StylePreset
+	Objectives []string
+	Keywords   []string
+	// DetailLevel 详略级别展示名及篇幅要求，如“简案（……，全文约 1500 字）”
+	DetailLevel string
+	// LessonType 课型展示名，如“实验课”
+	LessonType string
+	// LessonStructure 课型对应的环节结构要求
+	LessonStructure []string
+}
+
+// PromptTemplateService prompt 模板管理服务
+type PromptTemplateService interface {
+	Get(ctx context.Context) *PromptTemplate
+	Update(ctx context.Context, content string, userID uuid.UUID) (*PromptTemplate, error)
+	Reset(ctx context.Context, userID uuid.UUID) (*PromptTemplate, error)
+	Preview(ctx context.Context, content string, req *model.GenerationRequest) (string, error)
+	Render(req *model.GenerationRequest) (string, error)
+}
+
+type promptTemplateService struct {
+	mu sync.RWMutex
+
+	storePath string
+	current   PromptTemplate
+	tmpl      *template.Template
+}
+
+// NewPromptTemplateService 创建 prompt 模板服务，storePath 中的自定义模板无效时回退内置模板
+func NewPromptTemplateService(storePath string) PromptTemplateService {
+	svc := &promptTemplateService{storePath: storePath}
+	svc.useDefault()
+
+	if stored, err := svc.loadFromDisk(); err == nil && stored != nil {
+		if tmpl, err := parsePromptTemplate(stored.Content); err == nil {
+			stored.IsDefault = false
+			svc.current = *stored
+			svc.tmpl = tmpl
+		}
+	}
+	return svc
+}
+
+// NewPromptTemplateData 由生成请求构造模板变量
+func NewPromptTemplateData(req *model.GenerationRequest) PromptTemplateData {
+	subjects := generationSubjects(req)
+	data := PromptTemplateData{
+		Subject:           describeSubjects(req),
+		Subjects:          subjects,
+		Interdisciplinary: len(subjects) > 1,
+		Grade:             req.Grade,
+		Topic:             req.Topic,
+		Duration:          req.Duration,
+		Difficulty:        req.Difficulty,
+		Style:             req.Style,
+		Objectives:        req.Objectives,
+		Keywords:          req.Keywords,
+	}
+	data.DetailLevel = describeDetailLevel(req.DetailLevel)
+	lessonType := lessonTypePresets[normalizeLessonType(req.LessonType)]
+	data.LessonType = lessonType.Name
+	data.LessonStructure = lessonType.Structure
+	if preset := findStylePreset(req.Style); preset != nil {
+		data.Style = preset.Name
+		data.Preset = preset
+	}
+	return data
+}
+
+func (s *promptTemplateService) Get(ctx context.Context) *PromptTemplate {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	current := s.current
+	return &current
+}
+
+func (s *promptTemplateService) Update(ctx context.Context, content string, userID uuid.UUID) (*PromptTemplate, error) {
+	tmpl, err := parsePromptTemplate(content)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	updated := PromptTemplate{
+		Content:   content,
+		UpdatedBy: userID.String(),
+		UpdatedAt: &now,
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := s.persist(&updated); err != nil {
+		return nil, err
+	}
+	s.current = updated
+	s.tmpl = tmpl
+	return &updated, nil
+}
+
+func (s *promptTemplateService) Reset(ctx context.Context, userID uuid.UUID) (*PromptTemplate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if err := os.Remove(s.storePath); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, err
+	}
+	s.useDefault()
+
+	current := s.current
+	return &current, nil
+}
+
+func (s *promptTemplateService) Preview(ctx context.Context, content string, req *model.GenerationRequest) (string, error) {
+	if req == nil {
+		req = samplePromptRequest()
+	}
+	if strings.TrimSpace(content) == "" {
+		s.mu.RLock()
+		tmpl := s.tmpl
+		s.mu.RUnlock()
+		return executePromptTemplate(tmpl, req)
+	}
+
+	tmpl, err := parsePromptTemplate(content)
+	if err != nil {
+		return "", err
+	}
+	return executePromptTemplate(tmpl, req)
+}
+
+func (s *promptTemplateService) Render(req *model.GenerationRequest) (string, error) {
+	s.mu.RLock()
+	tmpl := s.tmpl
+	s.mu.RUnlock()
+	return executePromptTemplate(tmpl, req)
+}
+
+func (s *promptTemplateService) useDefault() {
+	s.current = PromptTemplate{Content: defaultPromptTemplate, IsDefault: true}
+	s.tmpl = defaultPromptTmpl
+}
+
+func (s *promptTemplateService) loadFromDisk() (*PromptTemplate, error) {
+	raw, err := os.ReadFile(s.storePath)
+	if err != nil {
+		if errors.Is(err, os.ErrNotExist) {
+			return nil, nil
+		}
+		return nil, err
+	}
+
+	var stored PromptTemplate
+	if err := json.Unmarshal(raw, &stored); err != nil {
+		return nil, err
+	}
+	if strings.TrimSpace(stored.Content) == "" {
+		return nil, nil
+	}
+	return &stored, nil
+}
+
+func (s *promptTemplateService) persist(tpl *PromptTemplate) error {
+	if err := os.MkdirAll(filepath.Dir(s.storePath), 0755); err != nil {
+		return err
+	}
+
+	body, err := json.MarshalIndent(tpl, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	tempPath := s.storePath + ".tmp"
+	if err := os.WriteFile(tempPath, body, 0644); err != nil {
+		return err
+	}
+
+	return os.Rename(tempPath, s.storePath)
+}
+
+// parsePromptTemplate 解析模板并用示例请求试渲染，提前暴露引用不存在字段等运行期错误
+func parsePromptTemplate(content string) (*template.Template, error) {
+	if strings.TrimSpace(content) == "" {
+		return nil, fmt.Errorf("%w: 模板内容不能为空", ErrInvalidPromptTemplate)
+	}
+
+	tmpl, err := template.New("prompt").Parse(content)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+
+	if _, err := executePromptTemplate(tmpl, samplePromptRequest()); err != nil {
+		return nil, err
+	}
+	return tmpl, nil
+}
+
+// samplePromptRequest 校验和预览模板用的示例请求
+func samplePromptRequest() *model.GenerationRequest {
+	return &model.GenerationRequest{
+		Subject:    "数学",
+		Grade:      "七年级",
+		Topic:      "一元一次方程",
+		Duration:   45,
+		Difficulty: "medium",
+		Style:      "interactive",
+		Objectives: []string{"理解方程的概念"},
+		Keywords:   []string{"等式的性质"},
+	}
+}
+
+func executePromptTemplate(tmpl *template.Template, req *model.GenerationRequest) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewPromptTemplateData(req)); err != nil {
+		return "", fmt.Errorf("%w: %v", ErrInvalidPromptTemplate, err)
+	}
+	return buf.String(), nil
+}
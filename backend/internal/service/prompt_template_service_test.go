@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func TestRenderPromptTemplateVariables(t *testing.T) {
+	tests := []struct {
+		name        string
+		content     string
+		req         *model.GenerationRequest
+		want        []string
+		wantMissing []string
+	}{
+		{
+			name:    "scalar variables",
+			content: "{{.Subject}}|{{.Grade}}|{{.Topic}}|{{.Duration}}|{{.Difficulty}}|{{.Style}}",
+			req:     &model.GenerationRequest{Subject: "数学", Grade: "七年级", Topic: "有理数", Duration: 40, Difficulty: "easy", Style: "自由探究"},
+			want:    []string{"数学|七年级|有理数|40|easy|自由探究"},
+		},
+		{
+			name:    "lists are ranged",
+			content: "{{range .Objectives}}[{{.}}]{{end}}{{range .Keywords}}<{{.}}>{{end}}",
+			req:     &model.GenerationRequest{Topic: "有理数", Objectives: []string{"认识负数", "会比较大小"}, Keywords: []string{"数轴"}},
+			want:    []string{"[认识负数][会比较大小]<数轴>"},
+		},
+		{
+			name:    "combined subjects are interdisciplinary",
+			content: "{{.Subject}}|{{.Interdisciplinary}}|{{range .Subjects}}{{.}};{{end}}",
+			req:     &model.GenerationRequest{Subject: "数学+物理", Subjects: []string{"物理", "信息技术"}, Topic: "速度"},
+			want:    []string{"数学、物理、信息技术|true|数学;物理;信息技术;"},
+		},
+		{
+			name:    "style preset and lesson type",
+			content: "{{.Style}}|{{if .Preset}}preset{{end}}|{{.LessonType}}",
+			req:     &model.GenerationRequest{Topic: "浮力", Style: "interactive", LessonType: "experiment"},
+			want:    []string{"探究型|preset|实验课"},
+		},
+		{
+			name:        "default template skips empty sections",
+			content:     defaultPromptTemplate,
+			req:         &model.GenerationRequest{Subject: "语文", Grade: "三年级", Topic: "秋天的雨", Duration: 45},
+			want:        []string{"请生成一份语文学科三年级年级的教案，主题是：秋天的雨。", "课时时长：45分钟"},
+			wantMissing: []string{"跨学科整合要求", "教学目标：", "关键知识点："},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewPromptTemplateService(filepath.Join(t.TempDir(), "prompt.json"))
+			got, err := svc.Preview(context.Background(), tt.content, tt.req)
+			if err != nil {
+				t.Fatalf("Preview() error = %v", err)
+			}
+			for _, want := range tt.want {
+				if !strings.Contains(got, want) {
+					t.Errorf("rendered = %q, want it to contain %q", got, want)
+				}
+			}
+			for _, missing := range tt.wantMissing {
+				if strings.Contains(got, missing) {
+					t.Errorf("rendered = %q, should not contain %q", got, missing)
+				}
+			}
+		})
+	}
+}
+
+func TestPromptTemplateRejectsInvalidContent(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+	}{
+		{name: "empty", content: "  "},
+		{name: "syntax error", content: "{{.Topic"},
+		{name: "unknown field", content: "{{.Teacher}}"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewPromptTemplateService(filepath.Join(t.TempDir(), "prompt.json"))
+			if _, err := svc.Update(context.Background(), tt.content, uuid.New()); !errors.Is(err, ErrInvalidPromptTemplate) {
+				t.Errorf("Update() error = %v, want ErrInvalidPromptTemplate", err)
+			}
+			if !svc.Get(context.Background()).IsDefault {
+				t.Error("invalid template should not replace the default")
+			}
+		})
+	}
+}
+
+func TestPromptTemplateUpdatePersistsAndResets(t *testing.T) {
+	ctx := context.Background()
+	storePath := filepath.Join(t.TempDir(), "templates", "prompt.json")
+	req := &model.GenerationRequest{Subject: "数学", Topic: "有理数"}
+
+	svc := NewPromptTemplateService(storePath)
+	if _, err := svc.Update(ctx, "自定义：{{.Topic}}", uuid.New()); err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+
+	// 重启后从磁盘加载自定义模板
+	reloaded := NewPromptTemplateService(storePath)
+	if got, _ := reloaded.Render(req); got != "自定义：有理数" {
+		t.Errorf("Render() after reload = %q", got)
+	}
+	if reloaded.Get(ctx).IsDefault {
+		t.Error("reloaded template should not be marked default")
+	}
+
+	// 预览不影响当前模板
+	if _, err := reloaded.Preview(ctx, "预览：{{.Topic}}", req); err != nil {
+		t.Fatalf("Preview() error = %v", err)
+	}
+	if got, _ := reloaded.Render(req); got != "自定义：有理数" {
+		t.Errorf("Render() after preview = %q", got)
+	}
+
+	if _, err := reloaded.Reset(ctx, uuid.New()); err != nil {
+		t.Fatalf("Reset() error = %v", err)
+	}
+	if got, _ := NewPromptTemplateService(storePath).Render(req); !strings.HasPrefix(got, "请生成一份数学学科") {
+		t.Errorf("Render() after reset = %q, want default template", got)
+	}
+}
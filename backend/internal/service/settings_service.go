@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// 用户设置默认值，与 user_settings 表的列默认值一致
+const (
+	defaultSettingsTheme    = "light"
+	defaultSettingsLanguage = "zh-CN"
+)
+
+// UpdateSettingsRequest 更新用户设置请求，未提供的字段保持不变；默认学科/年级传空串表示清除
+type UpdateSettingsRequest struct {
+	Theme          *string `json:"theme" binding:"omitempty,oneof=light dark auto"`
+	Language       *string `json:"language" binding:"omitempty,oneof=zh-CN en-US"`
+	EmailNotify    *bool   `json:"email_notify"`
+	DefaultSubject *string `json:"default_subject" binding:"omitempty,max=50"`
+	DefaultGrade   *string `json:"default_grade" binding:"omitempty,max=20"`
+}
+
+// SettingsService 用户设置服务接口
+type SettingsService interface {
+	// Get 获取用户设置，尚未保存过时返回默认值
+	Get(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error)
+	Update(ctx context.Context, userID uuid.UUID, req *UpdateSettingsRequest) (*model.UserSettings, error)
+}
+
+// settingsService 用户设置服务实现
+type settingsService struct {
+	settingsRepo repository.UserSettingsRepository
+}
+
+// NewSettingsService 创建用户设置服务
+func NewSettingsService(settingsRepo repository.UserSettingsRepository) SettingsService {
+	return &settingsService{settingsRepo: settingsRepo}
+}
+
+// defaultUserSettings 未保存过设置的用户使用的默认值
+func defaultUserSettings(userID uuid.UUID) *model.UserSettings {
+	return &model.UserSettings{
+		UserID:      userID,
+		Theme:       defaultSettingsTheme,
+		Language:    defaultSettingsLanguage,
+		EmailNotify: true,
+	}
+}
+
+func (s *settingsService) Get(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error) {
+	settings, err := s.settingsRepo.GetByUserID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return defaultUserSettings(userID), nil
+		}
+		return nil, err
+	}
+	return settings, nil
+}
+
+func (s *settingsService) Update(ctx context.Context, userID uuid.UUID, req *UpdateSettingsRequest) (*model.UserSettings, error) {
+	settings, err := s.Get(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if req.Theme != nil {
+		settings.Theme = *req.Theme
+	}
+	if req.Language != nil {
+		settings.Language = *req.Language
+	}
+	if req.EmailNotify != nil {
+		settings.EmailNotify = *req.EmailNotify
+	}
+	if req.DefaultSubject != nil {
+		settings.DefaultSubject = strings.TrimSpace(*req.DefaultSubject)
+	}
+	if req.DefaultGrade != nil {
+		settings.DefaultGrade = strings.TrimSpace(*req.DefaultGrade)
+	}
+
+	if err := s.settingsRepo.Upsert(ctx, settings); err != nil {
+		return nil, err
+	}
+	return settings, nil
+}
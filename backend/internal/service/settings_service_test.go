@@ -0,0 +1,106 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// fakeSettingsRepo 内存中的用户设置仓库，按 user_id 插入或覆盖
+type fakeSettingsRepo struct {
+	repository.UserSettingsRepository
+
+	settings map[uuid.UUID]model.UserSettings
+	upserts  int
+	err      error
+}
+
+func newFakeSettingsRepo() *fakeSettingsRepo {
+	return &fakeSettingsRepo{settings: make(map[uuid.UUID]model.UserSettings)}
+}
+
+func (r *fakeSettingsRepo) GetByUserID(ctx context.Context, userID uuid.UUID) (*model.UserSettings, error) {
+	if r.err != nil {
+		return nil, r.err
+	}
+	settings, ok := r.settings[userID]
+	if !ok {
+		return nil, gorm.ErrRecordNotFound
+	}
+	return &settings, nil
+}
+
+func (r *fakeSettingsRepo) Upsert(ctx context.Context, settings *model.UserSettings) error {
+	r.upserts++
+	r.settings[settings.UserID] = *settings
+	return nil
+}
+
+func TestGetSettingsDefaults(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := newFakeSettingsRepo()
+	svc := NewSettingsService(repo)
+
+	settings, err := svc.Get(ctx, userID)
+	if err != nil {
+		t.Fatalf("Get() error = %v", err)
+	}
+	want := model.UserSettings{UserID: userID, Theme: "light", Language: "zh-CN", EmailNotify: true}
+	if *settings != want {
+		t.Errorf("Get() = %+v, want defaults %+v", *settings, want)
+	}
+	if repo.upserts != 0 {
+		t.Errorf("Get() wrote %d rows, want defaults returned without saving", repo.upserts)
+	}
+
+	// 查询失败不回落到默认值，避免覆盖已保存的设置
+	repo.err = errors.New("db down")
+	if _, err := svc.Get(ctx, userID); !errors.Is(err, repo.err) {
+		t.Errorf("Get() with repository error = %v, want %v", err, repo.err)
+	}
+	if _, err := svc.Update(ctx, userID, &UpdateSettingsRequest{Theme: strPtr("dark")}); !errors.Is(err, repo.err) || repo.upserts != 0 {
+		t.Errorf("Update() with repository error = %v after %d upserts, want error and nothing saved", err, repo.upserts)
+	}
+}
+
+func TestUpdateSettings(t *testing.T) {
+	ctx := context.Background()
+	userID := uuid.New()
+	repo := newFakeSettingsRepo()
+	svc := NewSettingsService(repo)
+	off := false
+
+	// 首次保存：未提供的字段取默认值，零值 false 也会写入
+	settings, err := svc.Update(ctx, userID, &UpdateSettingsRequest{EmailNotify: &off, DefaultSubject: strPtr(" 数学 ")})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	want := model.UserSettings{UserID: userID, Theme: "light", Language: "zh-CN", EmailNotify: false, DefaultSubject: "数学"}
+	if *settings != want || repo.settings[userID] != want {
+		t.Errorf("first Update() = %+v, stored %+v, want %+v", *settings, repo.settings[userID], want)
+	}
+
+	// 再次保存只修改提供的字段，空串清除默认学科
+	settings, err = svc.Update(ctx, userID, &UpdateSettingsRequest{Theme: strPtr("dark"), DefaultGrade: strPtr("七年级"), DefaultSubject: strPtr("")})
+	if err != nil {
+		t.Fatalf("Update() error = %v", err)
+	}
+	want = model.UserSettings{UserID: userID, Theme: "dark", Language: "zh-CN", EmailNotify: false, DefaultGrade: "七年级"}
+	if *settings != want || repo.settings[userID] != want {
+		t.Errorf("second Update() = %+v, stored %+v, want %+v", *settings, repo.settings[userID], want)
+	}
+	if len(repo.settings) != 1 || repo.upserts != 2 {
+		t.Errorf("stored %d rows after %d upserts, want one row per user", len(repo.settings), repo.upserts)
+	}
+
+	if got, _ := svc.Get(ctx, userID); *got != want {
+		t.Errorf("Get() after update = %+v, want %+v", *got, want)
+	}
+}
@@ -0,0 +1,149 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/base64"
+	"errors"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrShareNotFound = errors.New("分享链接不存在或已撤销")
+	ErrShareExpired  = errors.New("分享链接已过期")
+)
+
+// maxShareExpiresInHours 分享有效期上限（一年）
+const maxShareExpiresInHours = 24 * 365
+
+// CreateShareRequest 创建分享链接请求，ExpiresInHours 为 0 表示永不过期
+type CreateShareRequest struct {
+	ExpiresInHours int  `json:"expires_in_hours" binding:"min=0,max=8760"`
+	AllowDownload  bool `json:"allow_download"`
+}
+
+// ShareService 教案分享服务接口
+type ShareService interface {
+	Create(ctx context.Context, lessonID, userID uuid.UUID, req *CreateShareRequest) (*model.LessonShare, error)
+	List(ctx context.Context, lessonID, userID uuid.UUID) ([]model.LessonShare, error)
+	Revoke(ctx context.Context, lessonID, shareID, userID uuid.UUID) error
+	Resolve(ctx context.Context, token string) (*model.LessonShare, *model.LessonDetail, error)
+}
+
+// shareService 教案分享服务实现
+type shareService struct {
+	shareRepo     repository.ShareRepository
+	lessonRepo    repository.LessonRepository
+	lessonService LessonService
+	now           func() time.Time
+}
+
+// NewShareService 创建教案分享服务
+func NewShareService(
+	shareRepo repository.ShareRepository,
+	lessonRepo repository.LessonRepository,
+	lessonService LessonService,
+) ShareService {
+	return &shareService{
+		shareRepo:     shareRepo,
+		lessonRepo:    lessonRepo,
+		lessonService: lessonService,
+		now:           time.Now,
+	}
+}
+
+func (s *shareService) Create(ctx context.Context, lessonID, userID uuid.UUID, req *CreateShareRequest) (*model.LessonShare, error) {
+	if err := s.checkOwner(ctx, lessonID, userID); err != nil {
+		return nil, err
+	}
+
+	token, err := newShareToken()
+	if err != nil {
+		return nil, err
+	}
+
+	share := &model.LessonShare{
+		LessonID:      lessonID,
+		UserID:        userID,
+		Token:         token,
+		AllowDownload: req.AllowDownload,
+	}
+	if req.ExpiresInHours > 0 {
+		hours := req.ExpiresInHours
+		if hours > maxShareExpiresInHours {
+			hours = maxShareExpiresInHours
+		}
+		expiresAt := s.now().Add(time.Duration(hours) * time.Hour)
+		share.ExpiresAt = &expiresAt
+	}
+
+	if err := s.shareRepo.Create(ctx, share); err != nil {
+		return nil, err
+	}
+	return share, nil
+}
+
+func (s *shareService) List(ctx context.Context, lessonID, userID uuid.UUID) ([]model.LessonShare, error) {
+	if err := s.checkOwner(ctx, lessonID, userID); err != nil {
+		return nil, err
+	}
+	return s.shareRepo.ListByLessonID(ctx, lessonID)
+}
+
+func (s *shareService) Revoke(ctx context.Context, lessonID, shareID, userID uuid.UUID) error {
+	if err := s.checkOwner(ctx, lessonID, userID); err != nil {
+		return err
+	}
+
+	share, err := s.shareRepo.GetByID(ctx, shareID)
+	if err != nil || share.LessonID != lessonID {
+		return ErrShareNotFound
+	}
+	return s.shareRepo.Delete(ctx, shareID)
+}
+
+// Resolve 通过 token 获取分享的教案，以匿名身份读取，作者未发布的草稿不会泄露
+func (s *shareService) Resolve(ctx context.Context, token string) (*model.LessonShare, *model.LessonDetail, error) {
+	share, err := s.shareRepo.GetByToken(ctx, token)
+	if err != nil {
+		return nil, nil, ErrShareNotFound
+	}
+	if share.IsExpired(s.now()) {
+		return nil, nil, ErrShareExpired
+	}
+
+	// token 本身即访问凭证，访客不属于任何租户，按 ID 读取分享的教案
+	lesson, err := s.lessonService.GetByID(tenant.WithoutTenant(ctx), share.LessonID, nil)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	_ = s.shareRepo.IncrementViewCount(ctx, share.ID)
+	return share, lesson, nil
+}
+
+func (s *shareService) checkOwner(ctx context.Context, lessonID, userID uuid.UUID) error {
+	lesson, err := s.lessonRepo.GetByID(ctx, lessonID)
+	if err != nil {
+		return ErrLessonNotFound
+	}
+	if lesson.UserID != userID {
+		return ErrUnauthorized
+	}
+	return nil
+}
+
+// newShareToken 生成 URL 安全的随机分享 token
+func newShareToken() (string, error) {
+	buf := make([]byte, 24)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
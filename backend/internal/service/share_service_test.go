@@ -0,0 +1,162 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/internal/repository"
+
+	"github.com/google/uuid"
+)
+
+// fakeShareRepo 内存中的分享仓库
+type fakeShareRepo struct {
+	repository.ShareRepository
+
+	shares map[uuid.UUID]*model.LessonShare
+}
+
+func newFakeShareRepo() *fakeShareRepo {
+	return &fakeShareRepo{shares: make(map[uuid.UUID]*model.LessonShare)}
+}
+
+func (r *fakeShareRepo) Create(ctx context.Context, share *model.LessonShare) error {
+	if share.ID == uuid.Nil {
+		share.ID = uuid.New()
+	}
+	stored := *share
+	r.shares[share.ID] = &stored
+	return nil
+}
+
+func (r *fakeShareRepo) GetByID(ctx context.Context, id uuid.UUID) (*model.LessonShare, error) {
+	share, ok := r.shares[id]
+	if !ok {
+		return nil, errRecordNotFound
+	}
+	copied := *share
+	return &copied, nil
+}
+
+func (r *fakeShareRepo) GetByToken(ctx context.Context, token string) (*model.LessonShare, error) {
+	for _, share := range r.shares {
+		if share.Token == token {
+			copied := *share
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakeShareRepo) Delete(ctx context.Context, id uuid.UUID) error {
+	delete(r.shares, id)
+	return nil
+}
+
+func (r *fakeShareRepo) IncrementViewCount(ctx context.Context, id uuid.UUID) error {
+	if share, ok := r.shares[id]; ok {
+		share.ViewCount++
+	}
+	return nil
+}
+
+func TestShareLinkAccess(t *testing.T) {
+	ctx := context.Background()
+	authorID := uuid.New()
+
+	tests := []struct {
+		name       string
+		req        CreateShareRequest
+		revoke     bool
+		token      string
+		advance    time.Duration
+		wantErr    error
+		wantExpiry time.Duration
+	}{
+		{name: "never expires", req: CreateShareRequest{AllowDownload: true}, advance: 24 * 365 * 2 * time.Hour},
+		{name: "within expiry", req: CreateShareRequest{ExpiresInHours: 48}, advance: 47 * time.Hour, wantExpiry: 48 * time.Hour},
+		{name: "expired exactly at deadline", req: CreateShareRequest{ExpiresInHours: 48}, advance: 48 * time.Hour, wantErr: ErrShareExpired, wantExpiry: 48 * time.Hour},
+		{name: "expiry is capped at one year", req: CreateShareRequest{ExpiresInHours: 100000}, wantExpiry: maxShareExpiresInHours * time.Hour},
+		{name: "revoked link", req: CreateShareRequest{}, revoke: true, wantErr: ErrShareNotFound},
+		{name: "unknown token", req: CreateShareRequest{}, token: "not-a-token", wantErr: ErrShareNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lesson := publishableLesson(authorID, model.LessonStatusPublished)
+			lesson.DraftContent = `{"title":"未发布的草稿"}`
+			lessonRepo := newFakeLessonRepo(lesson)
+			shareRepo := newFakeShareRepo()
+			svc := NewShareService(shareRepo, lessonRepo, newTestLessonService(lessonRepo, nil)).(*shareService)
+			now := time.Date(2026, 3, 1, 8, 0, 0, 0, time.UTC)
+			svc.now = func() time.Time { return now }
+
+			share, err := svc.Create(ctx, lesson.ID, authorID, &tt.req)
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+			if share.AllowDownload != tt.req.AllowDownload {
+				t.Errorf("allow_download = %v, want %v", share.AllowDownload, tt.req.AllowDownload)
+			}
+			if tt.wantExpiry == 0 && share.ExpiresAt != nil {
+				t.Errorf("expires_at = %v, want never", share.ExpiresAt)
+			}
+			if tt.wantExpiry > 0 && (share.ExpiresAt == nil || !share.ExpiresAt.Equal(now.Add(tt.wantExpiry))) {
+				t.Errorf("expires_at = %v, want %v", share.ExpiresAt, now.Add(tt.wantExpiry))
+			}
+			if tt.revoke {
+				if err := svc.Revoke(ctx, lesson.ID, share.ID, authorID); err != nil {
+					t.Fatalf("Revoke() error = %v", err)
+				}
+			}
+
+			token := share.Token
+			if tt.token != "" {
+				token = tt.token
+			}
+			now = now.Add(tt.advance)
+			_, detail, err := svc.Resolve(ctx, token)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("Resolve() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			if detail.Title != lesson.Title || detail.HasDraft {
+				t.Errorf("visitor sees %q (has_draft=%v), want published %q", detail.Title, detail.HasDraft, lesson.Title)
+			}
+			if stored, _ := shareRepo.GetByID(ctx, share.ID); stored.ViewCount != 1 {
+				t.Errorf("view count = %d, want 1", stored.ViewCount)
+			}
+		})
+	}
+}
+
+func TestShareLinkOwnerOnly(t *testing.T) {
+	ctx := context.Background()
+	authorID, otherID := uuid.New(), uuid.New()
+	lesson := publishableLesson(authorID, model.LessonStatusPublished)
+	lessonRepo := newFakeLessonRepo(lesson)
+	shareRepo := newFakeShareRepo()
+	svc := NewShareService(shareRepo, lessonRepo, newTestLessonService(lessonRepo, nil))
+
+	if _, err := svc.Create(ctx, lesson.ID, otherID, &CreateShareRequest{}); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Create() by other user error = %v, want ErrUnauthorized", err)
+	}
+	share, err := svc.Create(ctx, lesson.ID, authorID, &CreateShareRequest{})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+	if err := svc.Revoke(ctx, lesson.ID, share.ID, otherID); !errors.Is(err, ErrUnauthorized) {
+		t.Errorf("Revoke() by other user error = %v, want ErrUnauthorized", err)
+	}
+	if err := svc.Revoke(ctx, uuid.New(), share.ID, authorID); !errors.Is(err, ErrLessonNotFound) {
+		t.Errorf("Revoke() on another lesson error = %v, want ErrLessonNotFound", err)
+	}
+	if _, _, err := svc.Resolve(ctx, share.Token); err != nil {
+		t.Errorf("share should still resolve, got %v", err)
+	}
+}
@@ -12,6 +12,8 @@ import (
 	"sync"
 	"time"
 
+	"lesson-plan/backend/pkg/tenant"
+
 	"github.com/google/uuid"
 )
 
@@ -43,6 +45,13 @@ type LessonTemplate struct {
 	UsageCount     int       `json:"usage_count"`
 	CreatedAt      time.Time `json:"created_at"`
 	UpdatedAt      time.Time `json:"updated_at"`
+
+	// Public 公开到模板市场，同租户的其他教师可浏览、使用和复制
+	Public bool `json:"public"`
+	// CopiedFrom 从模板市场复制而来时记录来源模板 ID
+	CopiedFrom string `json:"copied_from,omitempty"`
+	// TenantID 模板所属租户，公开模板只在同租户内可见
+	TenantID string `json:"tenant_id,omitempty"`
 }
 
 // CreateLessonTemplateRequest 创建模板请求。
@@ -62,6 +71,7 @@ type CreateLessonTemplateRequest struct {
 	Assessment     string   `json:"assessment"`
 	Resources      string   `json:"resources"`
 	Tags           []string `json:"tags"`
+	Public         bool     `json:"public"`
 }
 
 // AppliedTemplatePayload 模板复用返回结构（可直接用于生成页表单）。
@@ -84,6 +94,9 @@ type TemplateService interface {
 	Create(ctx context.Context, userID uuid.UUID, req *CreateLessonTemplateRequest) (*LessonTemplate, error)
 	Delete(ctx context.Context, id string, userID uuid.UUID) error
 	Apply(ctx context.Context, id string, userID uuid.UUID) (*AppliedTemplatePayload, error)
+	ListPublic(ctx context.Context, userID uuid.UUID) ([]LessonTemplate, error)
+	SetPublic(ctx context.Context, id string, userID uuid.UUID, public bool) (*LessonTemplate, error)
+	Copy(ctx context.Context, id string, userID uuid.UUID) (*LessonTemplate, error)
 }
 
 type templateStore struct {
@@ -229,20 +242,38 @@ func (s *templateService) persist() error {
 	return os.Rename(tempPath, s.storePath)
 }
 
-func (s *templateService) visibleToUser(tpl LessonTemplate, userID uuid.UUID) bool {
-	if tpl.BuiltIn {
+func (s *templateService) visibleToUser(ctx context.Context, tpl LessonTemplate, userID uuid.UUID) bool {
+	if tpl.BuiltIn || s.ownedBy(tpl, userID) {
 		return true
 	}
-	return strings.EqualFold(tpl.OwnerID, userID.String())
+	return tpl.Public && sameTemplateTenant(ctx, tpl)
+}
+
+func (s *templateService) ownedBy(tpl LessonTemplate, userID uuid.UUID) bool {
+	return !tpl.BuiltIn && strings.EqualFold(tpl.OwnerID, userID.String())
 }
 
+// sameTemplateTenant 模板是否属于当前请求的租户；早期模板未记录租户，归入默认租户
+func sameTemplateTenant(ctx context.Context, tpl LessonTemplate) bool {
+	current := tenant.FromContext(ctx)
+	if current == "" {
+		return true
+	}
+	owner := tpl.TenantID
+	if owner == "" {
+		owner = tenant.DefaultTenant
+	}
+	return owner == current
+}
+
+// List 个人模板库：内置模板与自己的模板，他人公开的模板通过 ListPublic 浏览
 func (s *templateService) List(_ context.Context, userID uuid.UUID) ([]LessonTemplate, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
 	result := make([]LessonTemplate, 0, len(s.templates))
 	for _, tpl := range s.templates {
-		if s.visibleToUser(tpl, userID) {
+		if tpl.BuiltIn || s.ownedBy(tpl, userID) {
 			result = append(result, tpl)
 		}
 	}
@@ -260,7 +291,7 @@ func (s *templateService) List(_ context.Context, userID uuid.UUID) ([]LessonTem
 	return result, nil
 }
 
-func (s *templateService) Get(_ context.Context, id string, userID uuid.UUID) (*LessonTemplate, error) {
+func (s *templateService) Get(ctx context.Context, id string, userID uuid.UUID) (*LessonTemplate, error) {
 	s.mu.RLock()
 	defer s.mu.RUnlock()
 
@@ -268,7 +299,7 @@ func (s *templateService) Get(_ context.Context, id string, userID uuid.UUID) (*
 	if !ok {
 		return nil, ErrTemplateNotFound
 	}
-	if !s.visibleToUser(tpl, userID) {
+	if !s.visibleToUser(ctx, tpl, userID) {
 		return nil, ErrTemplateForbidden
 	}
 
@@ -276,7 +307,7 @@ func (s *templateService) Get(_ context.Context, id string, userID uuid.UUID) (*
 	return &copyTpl, nil
 }
 
-func (s *templateService) Create(_ context.Context, userID uuid.UUID, req *CreateLessonTemplateRequest) (*LessonTemplate, error) {
+func (s *templateService) Create(ctx context.Context, userID uuid.UUID, req *CreateLessonTemplateRequest) (*LessonTemplate, error) {
 	if req == nil {
 		return nil, errors.New("请求不能为空")
 	}
@@ -309,6 +340,8 @@ func (s *templateService) Create(_ context.Context, userID uuid.UUID, req *Creat
 		UsageCount:     0,
 		CreatedAt:      now,
 		UpdatedAt:      now,
+		Public:         req.Public,
+		TenantID:       tenant.FromContext(ctx),
 	}
 	if tpl.Duration <= 0 {
 		tpl.Duration = 45
@@ -382,3 +415,93 @@ func (s *templateService) Apply(ctx context.Context, id string, userID uuid.UUID
 		Tags:         tpl.Tags,
 	}, nil
 }
+
+// ListPublic 模板市场：同租户内他人公开的模板，按使用次数与更新时间排序
+func (s *templateService) ListPublic(ctx context.Context, userID uuid.UUID) ([]LessonTemplate, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	result := make([]LessonTemplate, 0)
+	for _, tpl := range s.templates {
+		if tpl.BuiltIn || !tpl.Public || s.ownedBy(tpl, userID) || !sameTemplateTenant(ctx, tpl) {
+			continue
+		}
+		result = append(result, tpl)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].UsageCount != result[j].UsageCount {
+			return result[i].UsageCount > result[j].UsageCount
+		}
+		return result[i].UpdatedAt.After(result[j].UpdatedAt)
+	})
+
+	return result, nil
+}
+
+// SetPublic 公开或取消公开自己的模板，内置模板不可修改
+func (s *templateService) SetPublic(_ context.Context, id string, userID uuid.UUID, public bool) (*LessonTemplate, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	templateID := strings.TrimSpace(id)
+	tpl, ok := s.templates[templateID]
+	if !ok {
+		return nil, ErrTemplateNotFound
+	}
+	if !s.ownedBy(tpl, userID) {
+		return nil, ErrTemplateForbidden
+	}
+
+	previous := tpl
+	tpl.Public = public
+	tpl.UpdatedAt = time.Now().UTC()
+	s.templates[templateID] = tpl
+	if err := s.persist(); err != nil {
+		s.templates[templateID] = previous
+		return nil, fmt.Errorf("保存模板失败: %w", err)
+	}
+
+	copyTpl := tpl
+	return &copyTpl, nil
+}
+
+// Copy 将可见模板复制为自己的私有模板，副本默认不公开，来源模板使用次数加一
+func (s *templateService) Copy(ctx context.Context, id string, userID uuid.UUID) (*LessonTemplate, error) {
+	source, err := s.Get(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	now := time.Now().UTC()
+	tpl := *source
+	tpl.ID = "tpl-" + uuid.NewString()
+	tpl.Tags = append([]string(nil), source.Tags...)
+	tpl.BuiltIn = false
+	tpl.OwnerID = userID.String()
+	tpl.UsageCount = 0
+	tpl.Public = false
+	tpl.CopiedFrom = source.ID
+	tpl.TenantID = tenant.FromContext(ctx)
+	tpl.CreatedAt = now
+	tpl.UpdatedAt = now
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.templates[tpl.ID] = tpl
+	if current, ok := s.templates[source.ID]; ok {
+		current.UsageCount++
+		s.templates[source.ID] = current
+	}
+	if err := s.persist(); err != nil {
+		delete(s.templates, tpl.ID)
+		if current, ok := s.templates[source.ID]; ok {
+			current.UsageCount--
+			s.templates[source.ID] = current
+		}
+		return nil, fmt.Errorf("复制模板失败: %w", err)
+	}
+
+	copyTpl := tpl
+	return &copyTpl, nil
+}
@@ -0,0 +1,123 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+)
+
+func TestPublicTemplateMarket(t *testing.T) {
+	ownerID, teacherID := uuid.New(), uuid.New()
+	schoolA := tenant.WithTenant(context.Background(), "school-a")
+	schoolB := tenant.WithTenant(context.Background(), "school-b")
+
+	tests := []struct {
+		name        string
+		public      bool
+		viewerCtx   context.Context
+		viewerID    uuid.UUID
+		wantListed  bool
+		wantCopyErr error
+	}{
+		{name: "public template listed for colleagues", public: true, viewerCtx: schoolA, viewerID: teacherID, wantListed: true},
+		{name: "private template hidden", public: false, viewerCtx: schoolA, viewerID: teacherID, wantCopyErr: ErrTemplateForbidden},
+		{name: "own template not in market", public: true, viewerCtx: schoolA, viewerID: ownerID},
+		{name: "other tenant cannot see", public: true, viewerCtx: schoolB, viewerID: teacherID, wantCopyErr: ErrTemplateForbidden},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := NewTemplateService(filepath.Join(t.TempDir(), "templates.json"))
+			tpl, err := svc.Create(schoolA, ownerID, &CreateLessonTemplateRequest{Name: "单元复习课", Subject: "数学", Tags: []string{"复习"}, Public: tt.public})
+			if err != nil {
+				t.Fatalf("Create() error = %v", err)
+			}
+
+			market, err := svc.ListPublic(tt.viewerCtx, tt.viewerID)
+			if err != nil {
+				t.Fatalf("ListPublic() error = %v", err)
+			}
+			listed := false
+			for _, item := range market {
+				listed = listed || item.ID == tpl.ID
+			}
+			if listed != tt.wantListed {
+				t.Errorf("listed = %v, want %v", listed, tt.wantListed)
+			}
+
+			copied, err := svc.Copy(tt.viewerCtx, tpl.ID, tt.viewerID)
+			if !errors.Is(err, tt.wantCopyErr) {
+				t.Fatalf("Copy() error = %v, want %v", err, tt.wantCopyErr)
+			}
+			if tt.wantCopyErr != nil {
+				return
+			}
+			if copied.ID == tpl.ID || copied.OwnerID != tt.viewerID.String() || copied.CopiedFrom != tpl.ID || copied.Public || copied.UsageCount != 0 {
+				t.Errorf("copy = %+v, want private copy owned by viewer", copied)
+			}
+			if copied.Name != tpl.Name || len(copied.Tags) != 1 || copied.Tags[0] != "复习" {
+				t.Errorf("copy content = %q %v, want %q [复习]", copied.Name, copied.Tags, tpl.Name)
+			}
+			source, _ := svc.Get(schoolA, tpl.ID, ownerID)
+			if source.UsageCount != 1 {
+				t.Errorf("source usage = %d, want 1", source.UsageCount)
+			}
+
+			mine, _ := svc.List(tt.viewerCtx, tt.viewerID)
+			found := false
+			for _, item := range mine {
+				found = found || item.ID == copied.ID
+			}
+			if !found {
+				t.Error("copy missing from personal library")
+			}
+		})
+	}
+}
+
+func TestSetTemplatePublic(t *testing.T) {
+	ctx := context.Background()
+	ownerID, teacherID := uuid.New(), uuid.New()
+	storePath := filepath.Join(t.TempDir(), "templates.json")
+	svc := NewTemplateService(storePath)
+	tpl, err := svc.Create(ctx, ownerID, &CreateLessonTemplateRequest{Name: "实验课"})
+	if err != nil {
+		t.Fatalf("Create() error = %v", err)
+	}
+
+	tests := []struct {
+		name       string
+		id         string
+		userID     uuid.UUID
+		public     bool
+		wantErr    error
+		wantListed bool
+	}{
+		{name: "other user cannot publish", id: tpl.ID, userID: teacherID, public: true, wantErr: ErrTemplateForbidden},
+		{name: "built-in template cannot be changed", id: "builtin-primary-inquiry", userID: ownerID, public: true, wantErr: ErrTemplateForbidden},
+		{name: "unknown template", id: "tpl-missing", userID: ownerID, public: true, wantErr: ErrTemplateNotFound},
+		{name: "owner publishes", id: tpl.ID, userID: ownerID, public: true, wantListed: true},
+		{name: "owner unpublishes", id: tpl.ID, userID: ownerID, public: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := svc.SetPublic(ctx, tt.id, tt.userID, tt.public); !errors.Is(err, tt.wantErr) {
+				t.Fatalf("SetPublic() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+			// 重新加载确认已持久化
+			market, _ := NewTemplateService(storePath).ListPublic(ctx, teacherID)
+			if listed := len(market) == 1 && market[0].ID == tpl.ID; listed != tt.wantListed {
+				t.Errorf("listed after reload = %v, want %v", listed, tt.wantListed)
+			}
+		})
+	}
+}
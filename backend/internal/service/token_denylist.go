@@ -0,0 +1,92 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lesson-plan/backend/pkg/database"
+
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// tokenDenylistKeyPrefix 登出令牌黑名单的 Redis 键前缀，键名为前缀加 jti
+	tokenDenylistKeyPrefix = "auth:denylist:"
+	// userRevokedBeforeKeyPrefix 用户令牌吊销时间的 Redis 键前缀，键名为前缀加用户 ID
+	userRevokedBeforeKeyPrefix = "auth:revoked_before:"
+)
+
+// TokenDenylist 已登出令牌黑名单：按 jti 记录，保存到令牌原本的过期时间为止；
+// 另按用户记录吊销时间，该时间之前签发的令牌一律无效（用于封禁等需要立即下线的场景）
+type TokenDenylist interface {
+	Revoke(ctx context.Context, jti string, expiresAt time.Time) error
+	IsRevoked(ctx context.Context, jti string) (bool, error)
+	RevokeUser(ctx context.Context, userID string) error
+	IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error)
+}
+
+// redisTokenDenylist 基于 Redis 的令牌黑名单，多实例部署时共享
+type redisTokenDenylist struct {
+	// userRevocationTTL 用户吊销记录的保存时长，取刷新令牌有效期，届时旧令牌已自然过期
+	userRevocationTTL time.Duration
+}
+
+// NewTokenDenylist 创建令牌黑名单，需先初始化 Redis；refreshExpiry 为刷新令牌有效期
+func NewTokenDenylist(refreshExpiry time.Duration) TokenDenylist {
+	return &redisTokenDenylist{userRevocationTTL: refreshExpiry}
+}
+
+// Revoke 将令牌加入黑名单，TTL 为令牌剩余有效期；已过期的令牌无需记录
+func (d *redisTokenDenylist) Revoke(ctx context.Context, jti string, expiresAt time.Time) error {
+	ttl := time.Until(expiresAt)
+	if ttl <= 0 {
+		return nil
+	}
+	return database.Set(ctx, tokenDenylistKeyPrefix+jti, true, ttl)
+}
+
+func (d *redisTokenDenylist) IsRevoked(ctx context.Context, jti string) (bool, error) {
+	return database.Exists(ctx, tokenDenylistKeyPrefix+jti)
+}
+
+// RevokeUser 吊销用户此前签发的全部令牌（访问令牌与刷新令牌），记录当前时间（秒）
+func (d *redisTokenDenylist) RevokeUser(ctx context.Context, userID string) error {
+	return database.Set(ctx, userRevokedBeforeKeyPrefix+userID, time.Now().Unix(), d.userRevocationTTL)
+}
+
+// IsUserRevoked 令牌签发时间早于用户的吊销时间时视为已吊销。
+// iat 只精确到秒，无法区分与吊销同一秒内签发的令牌是在吊销之前还是之后：这里按未吊销处理，
+// 保证解封或重置密码后立即重新登录拿到的令牌可用；代价是吊销前同一秒内签发的令牌会保留到自然过期，
+// 封禁时这类令牌也无法再登录或刷新（均检查账号状态）
+func (d *redisTokenDenylist) IsUserRevoked(ctx context.Context, userID string, issuedAt time.Time) (bool, error) {
+	var revokedAt int64
+	if err := database.Get(ctx, userRevokedBeforeKeyPrefix+userID, &revokedAt); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return false, nil
+		}
+		return false, err
+	}
+	return issuedAt.Unix() < revokedAt, nil
+}
+
+// Logout 登出：访问令牌（及客户端提供的刷新令牌）加入黑名单，到期前不能再使用；
+// 刷新令牌为空或已失效时忽略，不影响登出结果
+func (s *authService) Logout(ctx context.Context, accessToken, refreshToken string) error {
+	jti, expiresAt, err := s.jwtManager.ExtractTokenID(accessToken)
+	if err != nil {
+		return err
+	}
+	if err := s.denylist.Revoke(ctx, jti, expiresAt); err != nil {
+		return err
+	}
+
+	if refreshToken == "" {
+		return nil
+	}
+	jti, expiresAt, err = s.jwtManager.ExtractTokenID(refreshToken)
+	if err != nil {
+		return nil
+	}
+	return s.denylist.Revoke(ctx, jti, expiresAt)
+}
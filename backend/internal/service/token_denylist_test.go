@@ -0,0 +1,68 @@
+package service
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"lesson-plan/backend/internal/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+func TestLogoutRevokesTokens(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	newTestRedis(t)
+	user := activeUser(t, "teacher@example.com", "Passw0rd!")
+	auth, jwtManager := newTestAuthService(t, newFakeUserRepo(user), &fakeMailer{})
+
+	r := gin.New()
+	r.GET("/me", middleware.AuthMiddleware(jwtManager, nil, auth.denylist), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	login := func() *LoginResponse {
+		t.Helper()
+		resp, err := auth.Login(ctx, &LoginRequest{Username: user.Email, Password: "Passw0rd!"})
+		if err != nil {
+			t.Fatalf("Login() error = %v", err)
+		}
+		return resp
+	}
+
+	session, other := login(), login()
+	if code := get(session.AccessToken); code != http.StatusOK {
+		t.Fatalf("GET /me before logout = %d, want 200", code)
+	}
+
+	if err := auth.Logout(ctx, session.AccessToken, session.RefreshToken); err != nil {
+		t.Fatalf("Logout() error = %v", err)
+	}
+	if code := get(session.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("GET /me with logged out token = %d, want 401", code)
+	}
+	if _, err := auth.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Error("RefreshToken() with logged out refresh token should fail")
+	}
+
+	// 只作废本次会话，同一用户的其他会话不受影响
+	if code := get(other.AccessToken); code != http.StatusOK {
+		t.Errorf("GET /me with another session = %d, want 200", code)
+	}
+
+	// 刷新令牌无效时忽略，访问令牌无效时报错
+	if err := auth.Logout(ctx, other.AccessToken, "garbage"); err != nil {
+		t.Errorf("Logout() with invalid refresh token error = %v, want nil", err)
+	}
+	if err := auth.Logout(ctx, "garbage", ""); err == nil {
+		t.Error("Logout() with invalid access token should fail")
+	}
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"lesson-plan/backend/pkg/logger"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// avatarURLPrefix 头像访问地址前缀，由静态路由映射到上传目录的 avatars 子目录
+	avatarURLPrefix = "/uploads/avatars/"
+	// defaultAvatarMaxSize 未配置上传大小限制时的头像大小上限
+	defaultAvatarMaxSize = 5 << 20
+)
+
+var (
+	ErrAvatarTooLarge       = errors.New("头像文件过大")
+	ErrAvatarTypeNotAllowed = errors.New("头像仅支持 JPEG、PNG、WebP 格式")
+)
+
+// avatarExtensions 允许的头像类型及保存扩展名；类型按文件内容嗅探，不信任客户端声明
+var avatarExtensions = map[string]string{
+	"image/jpeg": ".jpg",
+	"image/png":  ".png",
+	"image/webp": ".webp",
+}
+
+// AvatarDir 头像在上传目录下的保存位置
+func AvatarDir(uploadDir string) string {
+	return filepath.Join(uploadDir, "avatars")
+}
+
+// UploadAvatar 保存头像到上传目录并更新用户头像地址，文件名为随机 UUID，旧头像文件随之删除
+func (s *userService) UploadAvatar(ctx context.Context, id uuid.UUID, r io.Reader, size int64) (string, error) {
+	if s.uploadDir == "" {
+		return "", errors.New("未配置上传目录，无法保存头像")
+	}
+	maxSize := s.maxUploadSize
+	if maxSize <= 0 {
+		maxSize = defaultAvatarMaxSize
+	}
+	if size > maxSize {
+		return "", fmt.Errorf("%w，最大 %d MB", ErrAvatarTooLarge, maxSize>>20)
+	}
+
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return "", ErrUserNotFound
+	}
+
+	reader := bufio.NewReaderSize(r, 512)
+	head, err := reader.Peek(512)
+	if err != nil && !errors.Is(err, io.EOF) {
+		return "", fmt.Errorf("读取头像失败: %w", err)
+	}
+	mimeType := http.DetectContentType(head)
+	ext, ok := avatarExtensions[mimeType]
+	if !ok || (s.allowedUploadTypes != nil && !s.allowedUploadTypes[mimeType]) {
+		return "", ErrAvatarTypeNotAllowed
+	}
+
+	dir := AvatarDir(s.uploadDir)
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return "", fmt.Errorf("创建头像目录失败: %w", err)
+	}
+	name := uuid.New().String() + ext
+	path := filepath.Join(dir, name)
+	if err := writeLimitedFile(path, reader, maxSize); err != nil {
+		return "", err
+	}
+
+	avatarURL := avatarURLPrefix + name
+	oldURL := user.AvatarURL
+	user.AvatarURL = avatarURL
+	if err := s.userRepo.Update(ctx, user); err != nil {
+		_ = os.Remove(path)
+		return "", err
+	}
+
+	s.removeAvatarFile(oldURL)
+	return avatarURL, nil
+}
+
+// writeLimitedFile 写入文件，实际内容超过 maxSize 时删除已写部分；客户端声明的大小不可信
+func writeLimitedFile(path string, r io.Reader, maxSize int64) error {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0644)
+	if err != nil {
+		return fmt.Errorf("保存头像失败: %w", err)
+	}
+	written, err := io.Copy(file, io.LimitReader(r, maxSize+1))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && written > maxSize {
+		err = fmt.Errorf("%w，最大 %d MB", ErrAvatarTooLarge, maxSize>>20)
+	}
+	if err != nil {
+		_ = os.Remove(path)
+		if errors.Is(err, ErrAvatarTooLarge) {
+			return err
+		}
+		return fmt.Errorf("保存头像失败: %w", err)
+	}
+	return nil
+}
+
+// removeAvatarFile 删除之前上传的头像；外部链接或手填地址不处理，删除失败只记日志
+func (s *userService) removeAvatarFile(avatarURL string) {
+	if !strings.HasPrefix(avatarURL, avatarURLPrefix) {
+		return
+	}
+	name := strings.TrimPrefix(avatarURL, avatarURLPrefix)
+	if _, err := uuid.Parse(strings.TrimSuffix(name, filepath.Ext(name))); err != nil {
+		return
+	}
+	if err := os.Remove(filepath.Join(AvatarDir(s.uploadDir), name)); err != nil && !errors.Is(err, os.ErrNotExist) {
+		logger.Warn("Failed to remove old avatar", logger.String("path", name), logger.Err(err))
+	}
+}
@@ -0,0 +1,26 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ExportData 导出用户的全部个人数据（资料、设置、登录记录、教案、评论、批注、生成记录、文档、收藏、点赞、关注、分享、API Key、通知）。
+// 只导出用户自己产生的数据；凭据类字段（密码哈希、API Key 哈希）不导出
+func (s *userService) ExportData(ctx context.Context, id uuid.UUID) (*model.UserDataExport, error) {
+	data, err := s.dataRepo.Export(ctx, id)
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrUserNotFound
+		}
+		return nil, err
+	}
+	data.ExportedAt = time.Now()
+	return data, nil
+}
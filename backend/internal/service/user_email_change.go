@@ -0,0 +1,140 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/url"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+)
+
+const (
+	// emailChangeAction 邮箱变更令牌的操作标识
+	emailChangeAction = "user:change-email"
+	// emailChangeTokenTTL 邮箱验证链接有效期
+	emailChangeTokenTTL = 24 * time.Hour
+)
+
+var (
+	ErrEmailUnchanged          = errors.New("新邮箱与当前邮箱相同")
+	ErrEmailChangeTokenInvalid = errors.New("邮箱验证链接无效或已失效")
+	ErrEmailChangeTokenExpired = errors.New("邮箱验证链接已过期，请重新发起修改")
+)
+
+// EmailChangeRequest 邮箱变更待验证信息
+type EmailChangeRequest struct {
+	PendingEmail string `json:"pending_email"`
+	ExpiresAt    int64  `json:"expires_at"`
+}
+
+// emailChangeResource 令牌同时绑定旧邮箱与新邮箱：期间邮箱已被改过的旧链接自动失效
+func emailChangeResource(oldEmail, newEmail string) string {
+	return oldEmail + "\n" + newEmail
+}
+
+// RequestEmailChange 向新邮箱发送验证链接，确认前邮箱保持不变
+func (s *userService) RequestEmailChange(ctx context.Context, id uuid.UUID, newEmail string) (*EmailChangeRequest, error) {
+	user, err := s.userRepo.GetByID(ctx, id)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+
+	newEmail = strings.ToLower(strings.TrimSpace(newEmail))
+	if strings.EqualFold(newEmail, user.Email) {
+		return nil, ErrEmailUnchanged
+	}
+
+	// 邮箱全局唯一，查重不受租户限制
+	exists, err := s.userRepo.ExistsByEmail(tenant.WithoutTenant(ctx), newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserExists
+	}
+
+	token, expiresAt, err := s.jwtManager.GenerateConfirmToken(
+		user.ID.String(), emailChangeAction, emailChangeResource(user.Email, newEmail), emailChangeTokenTTL,
+	)
+	if err != nil {
+		return nil, fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+
+	body := fmt.Sprintf(
+		"%s，您好：\n\n您正在将账号邮箱修改为 %s。请在 24 小时内打开以下链接完成验证：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件，邮箱不会被修改。",
+		user.Username, newEmail, s.emailConfirmLink(token),
+	)
+	if err := s.mailer.Send(ctx, newEmail, "请验证您的新邮箱", body); err != nil {
+		return nil, err
+	}
+
+	return &EmailChangeRequest{PendingEmail: newEmail, ExpiresAt: expiresAt}, nil
+}
+
+// ConfirmEmailChange 校验验证链接中的令牌并生效新邮箱；令牌已绑定用户，无需登录
+func (s *userService) ConfirmEmailChange(ctx context.Context, token string) (*EmailChangeRequest, error) {
+	claims, err := s.jwtManager.ParseConfirmToken(strings.TrimSpace(token), emailChangeAction)
+	if err != nil {
+		if errors.Is(err, jwt.ErrExpiredToken) {
+			return nil, ErrEmailChangeTokenExpired
+		}
+		return nil, ErrEmailChangeTokenInvalid
+	}
+
+	parts := strings.SplitN(claims.Resource, "\n", 2)
+	userID, err := uuid.Parse(claims.UserID)
+	if len(parts) != 2 || err != nil {
+		return nil, ErrEmailChangeTokenInvalid
+	}
+	oldEmail, newEmail := parts[0], parts[1]
+
+	// 验证链接可能在其他设备上打开，请求所带的租户不一定是用户所属租户
+	globalCtx := tenant.WithoutTenant(ctx)
+	user, err := s.userRepo.GetByID(globalCtx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !strings.EqualFold(user.Email, oldEmail) {
+		return nil, ErrEmailChangeTokenInvalid
+	}
+
+	exists, err := s.userRepo.ExistsByEmail(globalCtx, newEmail)
+	if err != nil {
+		return nil, err
+	}
+	if exists {
+		return nil, ErrUserExists
+	}
+
+	// 能打开发往新邮箱的链接，即视为新邮箱已验证
+	now := time.Now()
+	user.Email = newEmail
+	user.EmailVerifiedAt = &now
+	if err := s.userRepo.Update(globalCtx, user); err != nil {
+		return nil, err
+	}
+	return &EmailChangeRequest{PendingEmail: newEmail, ExpiresAt: claims.ExpiresAt.Unix()}, nil
+}
+
+func (s *userService) emailConfirmLink(token string) string {
+	return appendTokenQuery(s.confirmURL, token)
+}
+
+// appendTokenQuery 把令牌以 token 查询参数追加到前端页面地址，未配置地址时直接返回令牌
+func appendTokenQuery(base, token string) string {
+	base = strings.TrimSpace(base)
+	if base == "" {
+		return token
+	}
+	separator := "?"
+	if strings.Contains(base, "?") {
+		separator = "&"
+	}
+	return base + separator + "token=" + url.QueryEscape(token)
+}
@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/pkg/jwt"
+)
+
+func (r *fakeUserRepo) ExistsByEmail(ctx context.Context, email string) (bool, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Email, email) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// newTestUserService 创建只依赖用户仓库与邮件发送的用户服务
+func newTestUserService(users *fakeUserRepo, mail *fakeMailer) (*userService, *jwt.Manager) {
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	svc := NewUserService(users, nil, nil, nil, jwtManager, nil, mail, "https://lesson.example.com/confirm-email", nil)
+	return svc.(*userService), jwtManager
+}
+
+func TestEmailChangeTakesEffectOnlyAfterVerification(t *testing.T) {
+	ctx := context.Background()
+	user := activeUser(t, "old@example.com", "Passw0rd!")
+	users := newFakeUserRepo(user)
+	mail := &fakeMailer{}
+	svc, _ := newTestUserService(users, mail)
+
+	pending, err := svc.RequestEmailChange(ctx, user.ID, "  New@Example.com ")
+	if err != nil {
+		t.Fatalf("RequestEmailChange() error = %v", err)
+	}
+	if pending.PendingEmail != "new@example.com" {
+		t.Errorf("PendingEmail = %q, want normalized new email", pending.PendingEmail)
+	}
+	if len(mail.sent) != 1 || mail.sent[0].to != "new@example.com" {
+		t.Fatalf("sent = %+v, want one mail to the new address", mail.sent)
+	}
+
+	// 验证前邮箱保持不变
+	stored, _ := users.GetByID(ctx, user.ID)
+	if stored.Email != "old@example.com" {
+		t.Fatalf("email before confirm = %q, want unchanged", stored.Email)
+	}
+
+	token := mail.lastToken(t)
+	if _, err := svc.ConfirmEmailChange(ctx, token); err != nil {
+		t.Fatalf("ConfirmEmailChange() error = %v", err)
+	}
+	stored, _ = users.GetByID(ctx, user.ID)
+	if stored.Email != "new@example.com" || stored.EmailVerifiedAt == nil {
+		t.Errorf("after confirm email = %q verified = %v, want new verified email", stored.Email, stored.EmailVerifiedAt)
+	}
+
+	// 同一链接再次使用：绑定的旧邮箱已不匹配
+	if _, err := svc.ConfirmEmailChange(ctx, token); !errors.Is(err, ErrEmailChangeTokenInvalid) {
+		t.Errorf("reused ConfirmEmailChange() error = %v, want ErrEmailChangeTokenInvalid", err)
+	}
+}
+
+func TestConfirmEmailChangeRejectsBadTokens(t *testing.T) {
+	ctx := context.Background()
+
+	tests := []struct {
+		name    string
+		token   func(t *testing.T, jwtManager *jwt.Manager, userID string) string
+		wantErr error
+	}{
+		{
+			name: "expired",
+			token: func(t *testing.T, jwtManager *jwt.Manager, userID string) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(userID, emailChangeAction, emailChangeResource("old@example.com", "new@example.com"), -time.Minute)
+				return token
+			},
+			wantErr: ErrEmailChangeTokenExpired,
+		},
+		{
+			name: "superseded by another change",
+			token: func(t *testing.T, jwtManager *jwt.Manager, userID string) string {
+				// 旧链接绑定的旧邮箱已被另一次变更改掉
+				return mustConfirmToken(t, jwtManager, userID, emailChangeResource("older@example.com", "new@example.com"))
+			},
+			wantErr: ErrEmailChangeTokenInvalid,
+		},
+		{
+			name: "new email taken meanwhile",
+			token: func(t *testing.T, jwtManager *jwt.Manager, userID string) string {
+				return mustConfirmToken(t, jwtManager, userID, emailChangeResource("old@example.com", "taken@example.com"))
+			},
+			wantErr: ErrUserExists,
+		},
+		{
+			name: "other action",
+			token: func(t *testing.T, jwtManager *jwt.Manager, userID string) string {
+				token, _, _ := jwtManager.GenerateConfirmToken(userID, "lesson:delete", emailChangeResource("old@example.com", "new@example.com"), time.Hour)
+				return token
+			},
+			wantErr: ErrEmailChangeTokenInvalid,
+		},
+		{
+			name: "garbage",
+			token: func(t *testing.T, jwtManager *jwt.Manager, userID string) string {
+				return "not-a-token"
+			},
+			wantErr: ErrEmailChangeTokenInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			user := activeUser(t, "old@example.com", "Passw0rd!")
+			users := newFakeUserRepo(user, activeUser(t, "taken@example.com", "Passw0rd!"))
+			svc, jwtManager := newTestUserService(users, &fakeMailer{})
+
+			_, err := svc.ConfirmEmailChange(ctx, tt.token(t, jwtManager, user.ID.String()))
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ConfirmEmailChange() error = %v, want %v", err, tt.wantErr)
+			}
+			stored, _ := users.GetByID(ctx, user.ID)
+			if stored.Email != "old@example.com" {
+				t.Errorf("email = %q, want unchanged", stored.Email)
+			}
+		})
+	}
+}
+
+func TestRequestEmailChangeRejectsInvalidTargets(t *testing.T) {
+	ctx := context.Background()
+	user := activeUser(t, "old@example.com", "Passw0rd!")
+	mail := &fakeMailer{}
+	svc, _ := newTestUserService(newFakeUserRepo(user, activeUser(t, "taken@example.com", "Passw0rd!")), mail)
+
+	if _, err := svc.RequestEmailChange(ctx, user.ID, "OLD@example.com"); !errors.Is(err, ErrEmailUnchanged) {
+		t.Errorf("RequestEmailChange(same) error = %v, want ErrEmailUnchanged", err)
+	}
+	if _, err := svc.RequestEmailChange(ctx, user.ID, "taken@example.com"); !errors.Is(err, ErrUserExists) {
+		t.Errorf("RequestEmailChange(taken) error = %v, want ErrUserExists", err)
+	}
+	if len(mail.sent) != 0 {
+		t.Errorf("sent %d mails, want none", len(mail.sent))
+	}
+}
+
+func mustConfirmToken(t *testing.T, jwtManager *jwt.Manager, userID, resource string) string {
+	t.Helper()
+	token, _, err := jwtManager.GenerateConfirmToken(userID, emailChangeAction, resource, time.Hour)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return token
+}
@@ -0,0 +1,172 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/database"
+	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+)
+
+const (
+	// emailVerifyAction 注册邮箱验证令牌的操作标识
+	emailVerifyAction = "user:verify-email"
+	// emailVerifyTokenTTL 注册验证链接有效期
+	emailVerifyTokenTTL = 24 * time.Hour
+	// emailVerifyResendCooldown 重发验证邮件的最小间隔
+	emailVerifyResendCooldown = time.Minute
+
+	// emailVerifyKeyPrefix 当前有效验证令牌 jti 的 Redis 键前缀，键名为前缀加用户 ID；重发后旧链接失效
+	emailVerifyKeyPrefix = "auth:verify:"
+	// emailVerifyCooldownKeyPrefix 重发冷却的 Redis 键前缀
+	emailVerifyCooldownKeyPrefix = "auth:verify-cooldown:"
+)
+
+var (
+	ErrEmailNotVerified          = errors.New("邮箱尚未验证，请先完成邮箱验证")
+	ErrEmailAlreadyVerified      = errors.New("邮箱已验证，无需重复验证")
+	ErrEmailVerifyTokenInvalid   = errors.New("验证链接无效或已失效")
+	ErrEmailVerifyTokenExpired   = errors.New("验证链接已过期，请重新发送验证邮件")
+	ErrVerificationResendTooSoon = errors.New("验证邮件发送过于频繁，请稍后再试")
+)
+
+// EmailVerificationStore 记录每个用户当前有效的验证令牌，保证链接一次有效、重发后旧链接作废
+type EmailVerificationStore interface {
+	Save(ctx context.Context, userID, jti string, ttl time.Duration) error
+	// Current 返回用户当前有效的令牌 jti，没有时返回空串
+	Current(ctx context.Context, userID string) (string, error)
+	Delete(ctx context.Context, userID string) error
+	// AcquireResend 占用重发冷却窗口，冷却中返回 false
+	AcquireResend(ctx context.Context, userID string, cooldown time.Duration) (bool, error)
+}
+
+// redisEmailVerificationStore 基于 Redis 的验证令牌存储，键随令牌有效期过期
+type redisEmailVerificationStore struct{}
+
+// NewEmailVerificationStore 创建验证令牌存储，需先初始化 Redis
+func NewEmailVerificationStore() EmailVerificationStore {
+	return &redisEmailVerificationStore{}
+}
+
+func (st *redisEmailVerificationStore) Save(ctx context.Context, userID, jti string, ttl time.Duration) error {
+	return database.Set(ctx, emailVerifyKeyPrefix+userID, jti, ttl)
+}
+
+func (st *redisEmailVerificationStore) Current(ctx context.Context, userID string) (string, error) {
+	var jti string
+	if err := database.Get(ctx, emailVerifyKeyPrefix+userID, &jti); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", err
+	}
+	return jti, nil
+}
+
+func (st *redisEmailVerificationStore) Delete(ctx context.Context, userID string) error {
+	return database.Delete(ctx, emailVerifyKeyPrefix+userID)
+}
+
+func (st *redisEmailVerificationStore) AcquireResend(ctx context.Context, userID string, cooldown time.Duration) (bool, error) {
+	return database.SetNX(ctx, emailVerifyCooldownKeyPrefix+userID, true, cooldown)
+}
+
+// sendVerificationEmail 签发验证令牌并发送验证邮件；令牌同时绑定注册邮箱，邮箱变更后旧链接失效
+func (s *authService) sendVerificationEmail(ctx context.Context, user *model.User) error {
+	token, _, err := s.jwtManager.GenerateConfirmToken(user.ID.String(), emailVerifyAction, user.Email, emailVerifyTokenTTL)
+	if err != nil {
+		return fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+	claims, err := s.jwtManager.ParseConfirmToken(token, emailVerifyAction)
+	if err != nil {
+		return fmt.Errorf("生成验证令牌失败: %w", err)
+	}
+	if err := s.verifications.Save(ctx, user.ID.String(), claims.ID, emailVerifyTokenTTL); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"%s，您好：\n\n感谢注册备课系统。请在 24 小时内打开以下链接验证邮箱并激活账号：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件。",
+		user.Username, appendTokenQuery(s.verifyURL, token),
+	)
+	return s.mailer.Send(ctx, user.Email, "请验证您的注册邮箱", body)
+}
+
+// VerifyEmail 校验注册验证链接并激活账号；令牌已绑定用户，无需登录
+func (s *authService) VerifyEmail(ctx context.Context, token string) (*model.User, error) {
+	claims, err := s.jwtManager.ParseConfirmToken(strings.TrimSpace(token), emailVerifyAction)
+	if err != nil {
+		if errors.Is(err, jwt.ErrExpiredToken) {
+			return nil, ErrEmailVerifyTokenExpired
+		}
+		return nil, ErrEmailVerifyTokenInvalid
+	}
+	userID, err := uuid.Parse(claims.UserID)
+	if err != nil {
+		return nil, ErrEmailVerifyTokenInvalid
+	}
+
+	// 验证链接可能在其他设备上打开，请求所带的租户不一定是用户所属租户
+	globalCtx := tenant.WithoutTenant(ctx)
+	user, err := s.userRepo.GetByID(globalCtx, userID)
+	if err != nil {
+		return nil, ErrUserNotFound
+	}
+	if !user.PendingEmailVerification() {
+		return nil, ErrEmailAlreadyVerified
+	}
+	if !strings.EqualFold(user.Email, claims.Resource) {
+		return nil, ErrEmailVerifyTokenInvalid
+	}
+
+	current, err := s.verifications.Current(ctx, user.ID.String())
+	if err != nil {
+		return nil, err
+	}
+	if current == "" || current != claims.ID {
+		return nil, ErrEmailVerifyTokenInvalid
+	}
+
+	now := time.Now()
+	if err := s.userRepo.MarkEmailVerified(globalCtx, user.ID, now); err != nil {
+		return nil, err
+	}
+	if err := s.verifications.Delete(ctx, user.ID.String()); err != nil {
+		logger.Warn("Failed to delete email verification token",
+			logger.String("user_id", user.ID.String()),
+			logger.Err(err),
+		)
+	}
+
+	user.Status = model.StatusActive
+	user.EmailVerifiedAt = &now
+	return user, nil
+}
+
+// ResendVerification 重新发送验证邮件，之前的验证链接随之失效；
+// 邮箱未注册或已验证时不发信也不报错，避免通过该接口探测邮箱是否存在及其状态
+func (s *authService) ResendVerification(ctx context.Context, email string) error {
+	globalCtx := tenant.WithoutTenant(ctx)
+	user, err := s.userRepo.GetByEmail(globalCtx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || !user.PendingEmailVerification() {
+		return nil
+	}
+
+	ok, err := s.verifications.AcquireResend(ctx, user.ID.String(), emailVerifyResendCooldown)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return ErrVerificationResendTooSoon
+	}
+	return s.sendVerificationEmail(ctx, user)
+}
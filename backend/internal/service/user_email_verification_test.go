@@ -0,0 +1,146 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+func (r *fakeUserRepo) MarkEmailVerified(ctx context.Context, id uuid.UUID, verifiedAt time.Time) error {
+	user, ok := r.users[id]
+	if !ok {
+		return errRecordNotFound
+	}
+	user.Status = model.StatusActive
+	user.EmailVerifiedAt = &verifiedAt
+	return nil
+}
+
+// registerPending 注册一个待验证邮箱的账号，返回账号与注册邮件中的验证令牌
+func registerPending(t *testing.T, auth *authService, mail *fakeMailer) (*model.User, string) {
+	t.Helper()
+	user, err := auth.Register(context.Background(), &RegisterRequest{
+		Username: "teacher", Email: "Teacher@Example.com", Password: "Teach#2026",
+	})
+	if err != nil {
+		t.Fatalf("Register() error = %v", err)
+	}
+	return user, mail.lastToken(t)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	mail := &fakeMailer{}
+	users := newFakeUserRepo()
+	auth, _ := newTestAuthService(t, users, mail)
+	login := func() error {
+		_, err := auth.Login(ctx, &LoginRequest{Username: "teacher@example.com", Password: "Teach#2026"})
+		return err
+	}
+
+	user, token := registerPending(t, auth, mail)
+	if user.Status != model.StatusInactive || user.EmailVerifiedAt != nil {
+		t.Fatalf("registered user = (%s, %v), want inactive and unverified", user.Status, user.EmailVerifiedAt)
+	}
+	if err := login(); !errors.Is(err, ErrEmailNotVerified) {
+		t.Errorf("Login() before verification error = %v, want ErrEmailNotVerified", err)
+	}
+
+	verified, err := auth.VerifyEmail(ctx, token)
+	if err != nil {
+		t.Fatalf("VerifyEmail() error = %v", err)
+	}
+	if verified.Status != model.StatusActive || verified.EmailVerifiedAt == nil {
+		t.Errorf("verified user = (%s, %v), want active with verification time", verified.Status, verified.EmailVerifiedAt)
+	}
+	if stored, _ := users.GetByID(ctx, user.ID); stored.Status != model.StatusActive || stored.EmailVerifiedAt == nil {
+		t.Errorf("stored user = (%s, %v), want activated", stored.Status, stored.EmailVerifiedAt)
+	}
+	if err := login(); err != nil {
+		t.Errorf("Login() after verification error = %v", err)
+	}
+
+	// 链接只能使用一次
+	if _, err := auth.VerifyEmail(ctx, token); !errors.Is(err, ErrEmailAlreadyVerified) {
+		t.Errorf("VerifyEmail() reused error = %v, want ErrEmailAlreadyVerified", err)
+	}
+}
+
+func TestVerifyEmailInvalidTokens(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	mail := &fakeMailer{}
+	auth, jwtManager := newTestAuthService(t, newFakeUserRepo(), mail)
+	user, _ := registerPending(t, auth, mail)
+
+	sign := func(action, resource string, ttl time.Duration) string {
+		token, _, err := jwtManager.GenerateConfirmToken(user.ID.String(), action, resource, ttl)
+		if err != nil {
+			t.Fatalf("GenerateConfirmToken() error = %v", err)
+		}
+		return token
+	}
+
+	tests := []struct {
+		name    string
+		token   string
+		wantErr error
+	}{
+		{name: "expired", token: sign(emailVerifyAction, user.Email, -time.Minute), wantErr: ErrEmailVerifyTokenExpired},
+		{name: "malformed", token: "not-a-token", wantErr: ErrEmailVerifyTokenInvalid},
+		{name: "other action", token: sign("lesson:delete", user.Email, time.Hour), wantErr: ErrEmailVerifyTokenInvalid},
+		{name: "other email", token: sign(emailVerifyAction, "other@example.com", time.Hour), wantErr: ErrEmailVerifyTokenInvalid},
+		// 签名有效但不是最近一次发出的链接
+		{name: "not the current link", token: sign(emailVerifyAction, user.Email, time.Hour), wantErr: ErrEmailVerifyTokenInvalid},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if _, err := auth.VerifyEmail(ctx, tt.token); !errors.Is(err, tt.wantErr) {
+				t.Errorf("VerifyEmail() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	mail := &fakeMailer{}
+	users := newFakeUserRepo()
+	auth, _ := newTestAuthService(t, users, mail)
+	_, oldToken := registerPending(t, auth, mail)
+
+	if err := auth.ResendVerification(ctx, " TEACHER@example.com "); err != nil {
+		t.Fatalf("ResendVerification() error = %v", err)
+	}
+	newToken := mail.lastToken(t)
+	if err := auth.ResendVerification(ctx, "teacher@example.com"); !errors.Is(err, ErrVerificationResendTooSoon) {
+		t.Errorf("ResendVerification() within cooldown error = %v, want ErrVerificationResendTooSoon", err)
+	}
+
+	// 重发后旧链接失效，新链接可用
+	if _, err := auth.VerifyEmail(ctx, oldToken); !errors.Is(err, ErrEmailVerifyTokenInvalid) {
+		t.Errorf("VerifyEmail() with old link error = %v, want ErrEmailVerifyTokenInvalid", err)
+	}
+	if _, err := auth.VerifyEmail(ctx, newToken); err != nil {
+		t.Fatalf("VerifyEmail() with new link error = %v", err)
+	}
+
+	// 已验证与未注册的邮箱返回相同结果且不发信，无法据此探测账号状态
+	sent := len(mail.sent)
+	for _, email := range []string{"teacher@example.com", "nobody@example.com"} {
+		if err := auth.ResendVerification(ctx, email); err != nil {
+			t.Errorf("ResendVerification(%s) error = %v, want nil", email, err)
+		}
+	}
+	if len(mail.sent) != sent {
+		t.Errorf("sent %d more mails, want none", len(mail.sent)-sent)
+	}
+}
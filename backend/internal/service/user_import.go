@@ -0,0 +1,269 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"net/mail"
+	"strings"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
+
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// maxUserImportRows 单次批量导入的用户数上限
+	maxUserImportRows = 1000
+	// initialPasswordLength 批量导入生成的初始密码长度
+	initialPasswordLength = 12
+	// initialPasswordAlphabet 初始密码字符集，去掉了 0/O、1/l/I 等易混淆字符
+	initialPasswordAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZabcdefghijkmnpqrstuvwxyz23456789"
+)
+
+// 批量导入单行结果
+const (
+	UserImportCreated = "created"
+	UserImportSkipped = "skipped"
+	UserImportFailed  = "failed"
+)
+
+var ErrUserImportInvalid = errors.New("CSV 文件格式错误")
+
+// userImportColumns 表头别名到字段的映射，表头不区分大小写
+var userImportColumns = map[string]string{
+	"username":  "username",
+	"用户名":       "username",
+	"email":     "email",
+	"邮箱":        "email",
+	"full_name": "full_name",
+	"fullname":  "full_name",
+	"name":      "full_name",
+	"姓名":        "full_name",
+	"role":      "role",
+	"角色":        "role",
+}
+
+// UserImportRow 单行导入结果，InitialPassword 仅在创建成功时返回一次，便于通知失败时由管理员线下分发
+type UserImportRow struct {
+	Line            int    `json:"line"`
+	Username        string `json:"username"`
+	Email           string `json:"email"`
+	Status          string `json:"status"`
+	Reason          string `json:"reason,omitempty"`
+	InitialPassword string `json:"initial_password,omitempty"`
+	Notified        bool   `json:"notified"`
+}
+
+// UserImportReport 批量导入结果报告
+type UserImportReport struct {
+	Total   int             `json:"total"`
+	Created int             `json:"created"`
+	Skipped int             `json:"skipped"`
+	Failed  int             `json:"failed"`
+	Rows    []UserImportRow `json:"rows"`
+}
+
+// ImportUsers 从 CSV 批量创建用户，逐行处理，单行失败不影响其他行。
+// 表头需包含 username、email，可选 full_name、role（teacher/student，默认 teacher）；
+// 文件内重复或已存在的用户名/邮箱跳过，新用户归入当前租户并邮件通知初始密码
+func (s *userService) ImportUsers(ctx context.Context, r io.Reader) (*UserImportReport, error) {
+	records, err := readUserImportCSV(r)
+	if err != nil {
+		return nil, err
+	}
+
+	report := &UserImportReport{Rows: make([]UserImportRow, 0, len(records))}
+	seenUsernames := make(map[string]bool, len(records))
+	seenEmails := make(map[string]bool, len(records))
+	globalCtx := tenant.WithoutTenant(ctx)
+
+	for _, record := range records {
+		row := UserImportRow{
+			Line:     record.line,
+			Username: strings.TrimSpace(record.fields["username"]),
+			Email:    strings.ToLower(strings.TrimSpace(record.fields["email"])),
+		}
+		row.Status, row.Reason = s.importUser(ctx, globalCtx, record.fields, &row, seenUsernames, seenEmails)
+
+		switch row.Status {
+		case UserImportCreated:
+			report.Created++
+		case UserImportSkipped:
+			report.Skipped++
+		default:
+			report.Failed++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	report.Total = len(report.Rows)
+	return report, nil
+}
+
+// importUser 处理单行，返回行状态与原因；创建成功时写入初始密码与通知结果
+func (s *userService) importUser(
+	ctx, globalCtx context.Context,
+	fields map[string]string,
+	row *UserImportRow,
+	seenUsernames, seenEmails map[string]bool,
+) (string, string) {
+	if n := len([]rune(row.Username)); n < 3 || n > 50 {
+		return UserImportFailed, "用户名长度需为 3-50 个字符"
+	}
+	if addr, err := mail.ParseAddress(row.Email); err != nil || addr.Address != row.Email || len(row.Email) > 100 {
+		return UserImportFailed, "邮箱格式错误"
+	}
+	role := strings.ToLower(strings.TrimSpace(fields["role"]))
+	if role == "" {
+		role = model.RoleTeacher
+	}
+	if role != model.RoleTeacher && role != model.RoleStudent {
+		return UserImportFailed, "角色只能是 teacher 或 student"
+	}
+
+	usernameKey := strings.ToLower(row.Username)
+	if seenUsernames[usernameKey] || seenEmails[row.Email] {
+		return UserImportSkipped, "文件内用户名或邮箱重复"
+	}
+	seenUsernames[usernameKey] = true
+	seenEmails[row.Email] = true
+
+	// 用户名和邮箱全局唯一，查重不受租户限制
+	exists, err := s.userRepo.ExistsByUsername(globalCtx, row.Username)
+	if err != nil {
+		return UserImportFailed, "查询用户失败"
+	}
+	if !exists {
+		exists, err = s.userRepo.ExistsByEmail(globalCtx, row.Email)
+		if err != nil {
+			return UserImportFailed, "查询用户失败"
+		}
+	}
+	if exists {
+		return UserImportSkipped, ErrUserExists.Error()
+	}
+
+	password, err := generateInitialPassword()
+	if err != nil {
+		return UserImportFailed, "生成初始密码失败"
+	}
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.DefaultCost)
+	if err != nil {
+		return UserImportFailed, "生成初始密码失败"
+	}
+
+	user := &model.User{
+		Username:     row.Username,
+		Email:        row.Email,
+		PasswordHash: string(hashedPassword),
+		FullName:     strings.TrimSpace(fields["full_name"]),
+		Role:         role,
+		Status:       model.StatusActive,
+	}
+	if err := s.userRepo.Create(ctx, user); err != nil {
+		return UserImportFailed, "创建用户失败"
+	}
+	row.InitialPassword = password
+
+	body := fmt.Sprintf(
+		"%s，您好：\n\n管理员已为您开通备课系统账号。\n\n用户名：%s\n初始密码：%s\n\n请登录后尽快修改密码。",
+		firstNonEmpty(user.FullName, user.Username), user.Username, password,
+	)
+	if err := s.mailer.Send(ctx, user.Email, "您的备课系统账号已开通", body); err != nil {
+		logger.Warn("Failed to send account notification",
+			logger.String("user_id", user.ID.String()),
+			logger.String("error", err.Error()),
+		)
+		return UserImportCreated, "账号已创建，通知邮件发送失败"
+	}
+	row.Notified = true
+	return UserImportCreated, ""
+}
+
+type userImportRecord struct {
+	line   int
+	fields map[string]string
+}
+
+// readUserImportCSV 解析 CSV，兼容 Excel 导出的 UTF-8 BOM；跳过空行
+func readUserImportCSV(r io.Reader) ([]userImportRecord, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	data = bytes.TrimPrefix(data, []byte("\xef\xbb\xbf"))
+
+	reader := csv.NewReader(bytes.NewReader(data))
+	reader.FieldsPerRecord = -1
+	reader.TrimLeadingSpace = true
+
+	header, err := reader.Read()
+	if err != nil {
+		return nil, fmt.Errorf("%w: 缺少表头", ErrUserImportInvalid)
+	}
+	columns := make([]string, len(header))
+	present := make(map[string]bool, len(header))
+	for i, name := range header {
+		columns[i] = userImportColumns[strings.ToLower(strings.TrimSpace(name))]
+		present[columns[i]] = true
+	}
+	if !present["username"] || !present["email"] {
+		return nil, fmt.Errorf("%w: 表头需包含 username 和 email", ErrUserImportInvalid)
+	}
+
+	var records []userImportRecord
+	for {
+		values, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrUserImportInvalid, err)
+		}
+
+		line, _ := reader.FieldPos(0)
+		fields := make(map[string]string, len(columns))
+		empty := true
+		for i, value := range values {
+			if i >= len(columns) || columns[i] == "" {
+				continue
+			}
+			fields[columns[i]] = value
+			if strings.TrimSpace(value) != "" {
+				empty = false
+			}
+		}
+		if empty {
+			continue
+		}
+		if len(records) >= maxUserImportRows {
+			return nil, fmt.Errorf("%w: 单次最多导入 %d 个用户", ErrUserImportInvalid, maxUserImportRows)
+		}
+		records = append(records, userImportRecord{line: line, fields: fields})
+	}
+	if len(records) == 0 {
+		return nil, fmt.Errorf("%w: 没有可导入的数据", ErrUserImportInvalid)
+	}
+	return records, nil
+}
+
+// generateInitialPassword 生成随机初始密码
+func generateInitialPassword() (string, error) {
+	alphabet := big.NewInt(int64(len(initialPasswordAlphabet)))
+	buf := make([]byte, initialPasswordLength)
+	for i := range buf {
+		n, err := rand.Int(rand.Reader, alphabet)
+		if err != nil {
+			return "", err
+		}
+		buf[i] = initialPasswordAlphabet[n.Int64()]
+	}
+	return string(buf), nil
+}
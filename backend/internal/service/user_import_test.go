@@ -0,0 +1,158 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (r *fakeUserRepo) Create(ctx context.Context, user *model.User) error {
+	if user.ID == uuid.Nil {
+		user.ID = uuid.New()
+	}
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+func (r *fakeUserRepo) ExistsByUsername(ctx context.Context, username string) (bool, error) {
+	for _, user := range r.users {
+		if strings.EqualFold(user.Username, username) {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// failingMailer 发信总是失败
+type failingMailer struct{}
+
+func (failingMailer) Send(ctx context.Context, to, subject, body string) error {
+	return errors.New("smtp unavailable")
+}
+
+func TestImportUsersReport(t *testing.T) {
+	ctx := context.Background()
+	users := newFakeUserRepo(activeUser(t, "existing@example.com", "Passw0rd!"))
+	mail := &fakeMailer{}
+	svc, _ := newTestUserService(users, mail)
+
+	csvData := "\xef\xbb\xbf用户名,邮箱,姓名,角色\n" +
+		"zhangsan,ZhangSan@Example.com,张三,\n" + // 2 成功，邮箱转小写、默认教师
+		"lisi,lisi@example.com,李四,student\n" + // 3 成功
+		"ZHANGSAN,other@example.com,,\n" + // 4 文件内用户名重复
+		"wangwu,lisi@example.com,,\n" + // 5 文件内邮箱重复
+		"existing,new@example.com,,\n" + // 6 已存在的用户名
+		"zhaoliu,existing@example.com,,\n" + // 7 已存在的邮箱
+		"ab,ab@example.com,,\n" + // 8 用户名过短
+		"qianqi,not-an-email,,\n" + // 9 邮箱格式错误
+		"sunba,sunba@example.com,,admin\n" + // 10 不允许导入管理员
+		",,,\n" + // 11 空行跳过
+		"zhoujiu,zhoujiu@example.com\n" // 12 缺少可选列
+
+	report, err := svc.ImportUsers(ctx, strings.NewReader(csvData))
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+
+	type rowResult struct {
+		line   int
+		status string
+	}
+	want := []rowResult{
+		{2, UserImportCreated}, {3, UserImportCreated}, {4, UserImportSkipped}, {5, UserImportSkipped},
+		{6, UserImportSkipped}, {7, UserImportSkipped}, {8, UserImportFailed}, {9, UserImportFailed},
+		{10, UserImportFailed}, {12, UserImportCreated},
+	}
+	var got []rowResult
+	for _, row := range report.Rows {
+		got = append(got, rowResult{row.Line, row.Status})
+		if row.Status != UserImportCreated && (row.Reason == "" || row.InitialPassword != "") {
+			t.Errorf("line %d = %+v, want reason and no password", row.Line, row)
+		}
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Fatalf("rows = %v, want %v", got, want)
+	}
+	if report.Total != 10 || report.Created != 3 || report.Skipped != 4 || report.Failed != 3 {
+		t.Errorf("report totals = %d/%d/%d/%d, want 10/3/4/3", report.Total, report.Created, report.Skipped, report.Failed)
+	}
+
+	// 成功行：账号可用初始密码登录，并收到通知邮件
+	first := report.Rows[0]
+	if first.Email != "zhangsan@example.com" || !first.Notified || len(first.InitialPassword) != initialPasswordLength {
+		t.Errorf("created row = %+v", first)
+	}
+	var created *model.User
+	for _, u := range users.users {
+		if u.Username == "zhangsan" {
+			created = u
+		}
+	}
+	if created == nil || created.Role != model.RoleTeacher || created.FullName != "张三" || created.Status != model.StatusActive {
+		t.Fatalf("created user = %+v", created)
+	}
+	if bcrypt.CompareHashAndPassword([]byte(created.PasswordHash), []byte(first.InitialPassword)) != nil {
+		t.Error("stored hash does not match the initial password")
+	}
+	if len(mail.sent) != 3 || mail.sent[0].to != "zhangsan@example.com" || !strings.Contains(mail.sent[0].body, first.InitialPassword) {
+		t.Errorf("sent = %+v, want one notification per created user with the password", mail.sent)
+	}
+	if len(users.users) != 4 {
+		t.Errorf("users = %d, want existing plus 3 imported", len(users.users))
+	}
+}
+
+func TestImportUsersNotificationFailure(t *testing.T) {
+	users := newFakeUserRepo()
+	svc, _ := newTestUserService(users, nil)
+	svc.mailer = failingMailer{}
+
+	report, err := svc.ImportUsers(context.Background(), strings.NewReader("username,email\nzhangsan,zhangsan@example.com\n"))
+	if err != nil {
+		t.Fatalf("ImportUsers() error = %v", err)
+	}
+	// 发信失败不回滚账号，初始密码留给管理员线下分发
+	row := report.Rows[0]
+	if row.Status != UserImportCreated || row.Notified || row.InitialPassword == "" || row.Reason == "" {
+		t.Errorf("row = %+v, want created without notification", row)
+	}
+	if len(users.users) != 1 {
+		t.Errorf("users = %d, want 1", len(users.users))
+	}
+}
+
+func TestImportUsersRejectsMalformedFile(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+	}{
+		{name: "empty file", data: ""},
+		{name: "missing email column", data: "username,name\nzhangsan,张三\n"},
+		{name: "header only", data: "username,email\n"},
+		{name: "only blank rows", data: "username,email\n,\n"},
+		{name: "broken quoting", data: "username,email\n\"zhangsan,zhangsan@example.com\n"},
+		{name: "too many rows", data: "username,email\n" + strings.Repeat("user,user@example.com\n", maxUserImportRows+1)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users := newFakeUserRepo()
+			svc, _ := newTestUserService(users, &fakeMailer{})
+
+			if _, err := svc.ImportUsers(context.Background(), strings.NewReader(tt.data)); !errors.Is(err, ErrUserImportInvalid) {
+				t.Fatalf("ImportUsers() error = %v, want ErrUserImportInvalid", err)
+			}
+			if len(users.users) != 0 {
+				t.Errorf("users = %d, want none created", len(users.users))
+			}
+		})
+	}
+}
@@ -0,0 +1,193 @@
+package service
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/database"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
+
+	"github.com/google/uuid"
+	"github.com/redis/go-redis/v9"
+	"golang.org/x/crypto/bcrypt"
+)
+
+const (
+	// passwordResetTokenTTL 重置链接有效期
+	passwordResetTokenTTL = 30 * time.Minute
+	// passwordResetCooldown 同一账号两次发送重置邮件的最小间隔
+	passwordResetCooldown = time.Minute
+
+	// passwordResetKeyPrefix 重置令牌的 Redis 键前缀，键名为前缀加令牌哈希，值为用户 ID
+	passwordResetKeyPrefix = "auth:reset:"
+	// passwordResetUserKeyPrefix 用户当前有效令牌哈希的 Redis 键前缀；重新申请后旧链接失效
+	passwordResetUserKeyPrefix = "auth:reset-user:"
+	// passwordResetCooldownKeyPrefix 发送冷却的 Redis 键前缀
+	passwordResetCooldownKeyPrefix = "auth:reset-cooldown:"
+)
+
+// ErrPasswordResetTokenInvalid 重置令牌不存在、已过期或已被使用；三者不作区分
+var ErrPasswordResetTokenInvalid = errors.New("重置链接无效或已过期，请重新申请")
+
+// ResetPasswordRequest 通过邮件链接重置密码
+type ResetPasswordRequest struct {
+	Token       string `json:"token" binding:"required"`
+	NewPassword string `json:"new_password" binding:"required,min=6,max=100"`
+}
+
+// PasswordResetStore 保存一次性重置令牌，令牌只存哈希
+type PasswordResetStore interface {
+	// Save 保存令牌并使该用户之前的令牌失效
+	Save(ctx context.Context, tokenHash, userID string, ttl time.Duration) error
+	// Consume 取出并删除令牌，返回对应的用户 ID；令牌不存在时返回空串
+	Consume(ctx context.Context, tokenHash string) (string, error)
+	// AcquireRequest 占用发送冷却窗口，冷却中返回 false
+	AcquireRequest(ctx context.Context, userID string, cooldown time.Duration) (bool, error)
+}
+
+// redisPasswordResetStore 基于 Redis 的重置令牌存储，键随令牌有效期过期
+type redisPasswordResetStore struct{}
+
+// NewPasswordResetStore 创建重置令牌存储，需先初始化 Redis
+func NewPasswordResetStore() PasswordResetStore {
+	return &redisPasswordResetStore{}
+}
+
+func (st *redisPasswordResetStore) Save(ctx context.Context, tokenHash, userID string, ttl time.Duration) error {
+	var previous string
+	if err := database.Get(ctx, passwordResetUserKeyPrefix+userID, &previous); err != nil && !errors.Is(err, redis.Nil) {
+		return err
+	}
+	if previous != "" {
+		if err := database.Delete(ctx, passwordResetKeyPrefix+previous); err != nil {
+			return err
+		}
+	}
+	if err := database.Set(ctx, passwordResetKeyPrefix+tokenHash, userID, ttl); err != nil {
+		return err
+	}
+	return database.Set(ctx, passwordResetUserKeyPrefix+userID, tokenHash, ttl)
+}
+
+// Consume 使用 GETDEL 原子取出，同一令牌并发提交也只有一次成功
+func (st *redisPasswordResetStore) Consume(ctx context.Context, tokenHash string) (string, error) {
+	var userID string
+	if err := database.GetDel(ctx, passwordResetKeyPrefix+tokenHash, &userID); err != nil {
+		if errors.Is(err, redis.Nil) {
+			return "", nil
+		}
+		return "", err
+	}
+	if err := database.Delete(ctx, passwordResetUserKeyPrefix+userID); err != nil {
+		return "", err
+	}
+	return userID, nil
+}
+
+func (st *redisPasswordResetStore) AcquireRequest(ctx context.Context, userID string, cooldown time.Duration) (bool, error) {
+	return database.SetNX(ctx, passwordResetCooldownKeyPrefix+userID, true, cooldown)
+}
+
+// newPasswordResetToken 生成 32 字节随机令牌，返回明文与存储用的哈希
+func newPasswordResetToken() (token, tokenHash string, err error) {
+	buf := make([]byte, 32)
+	if _, err := rand.Read(buf); err != nil {
+		return "", "", err
+	}
+	token = base64.RawURLEncoding.EncodeToString(buf)
+	return token, hashPasswordResetToken(token), nil
+}
+
+func hashPasswordResetToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// ForgotPassword 向账号邮箱发送重置链接；邮箱未注册、账号不可用或处于冷却期时同样返回成功，
+// 避免通过该接口探测邮箱是否存在
+func (s *authService) ForgotPassword(ctx context.Context, email string) error {
+	globalCtx := tenant.WithoutTenant(ctx)
+	user, err := s.userRepo.GetByEmail(globalCtx, strings.ToLower(strings.TrimSpace(email)))
+	if err != nil || user.Status != model.StatusActive {
+		return nil
+	}
+
+	ok, err := s.resets.AcquireRequest(ctx, user.ID.String(), passwordResetCooldown)
+	if err != nil {
+		return err
+	}
+	if !ok {
+		return nil
+	}
+
+	token, tokenHash, err := newPasswordResetToken()
+	if err != nil {
+		return fmt.Errorf("生成重置令牌失败: %w", err)
+	}
+	if err := s.resets.Save(ctx, tokenHash, user.ID.String(), passwordResetTokenTTL); err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf(
+		"%s，您好：\n\n我们收到了重置账号密码的请求。请在 30 分钟内打开以下链接设置新密码，链接只能使用一次：\n\n%s\n\n如果这不是您本人的操作，请忽略本邮件，密码不会被修改。",
+		user.Username, appendTokenQuery(s.resetURL, token),
+	)
+	// 发信失败只记日志：返回错误会让已注册邮箱与未注册邮箱的响应不同
+	if err := s.mailer.Send(ctx, user.Email, "重置您的账号密码", body); err != nil {
+		logger.Warn("Failed to send password reset email",
+			logger.String("user_id", user.ID.String()),
+			logger.Err(err),
+		)
+	}
+	return nil
+}
+
+// ResetPassword 校验重置令牌并设置新密码，成功后该用户已签发的令牌全部失效；密码强度不足时不消耗令牌，用户可修改后重试
+func (s *authService) ResetPassword(ctx context.Context, token, newPassword string) error {
+	token = strings.TrimSpace(token)
+	if token == "" {
+		return ErrPasswordResetTokenInvalid
+	}
+	if err := ValidatePasswordStrength(newPassword); err != nil {
+		return err
+	}
+
+	userIDStr, err := s.resets.Consume(ctx, hashPasswordResetToken(token))
+	if err != nil {
+		return err
+	}
+	userID, err := uuid.Parse(userIDStr)
+	if err != nil {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	// 重置链接可能在其他设备上打开，请求所带的租户不一定是用户所属租户
+	globalCtx := tenant.WithoutTenant(ctx)
+	user, err := s.userRepo.GetByID(globalCtx, userID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Status != model.StatusActive {
+		return ErrPasswordResetTokenInvalid
+	}
+
+	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
+	if err != nil {
+		return err
+	}
+	user.PasswordHash = string(hashedPassword)
+	if err := s.userRepo.Update(globalCtx, user); err != nil {
+		return err
+	}
+	// 旧密码可能已泄露：吊销此前签发的全部令牌，持有旧会话的设备需用新密码重新登录
+	return s.denylist.RevokeUser(ctx, user.ID.String())
+}
@@ -0,0 +1,196 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/url"
+	"regexp"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/jwt"
+
+	"github.com/google/uuid"
+	"golang.org/x/crypto/bcrypt"
+)
+
+func (r *fakeUserRepo) GetByEmail(ctx context.Context, email string) (*model.User, error) {
+	for _, user := range r.users {
+		if user.Email == email {
+			copied := *user
+			return &copied, nil
+		}
+	}
+	return nil, errRecordNotFound
+}
+
+func (r *fakeUserRepo) Update(ctx context.Context, user *model.User) error {
+	stored := *user
+	r.users[user.ID] = &stored
+	return nil
+}
+
+// fakeMailer 记录发出的邮件
+type fakeMailer struct {
+	mu   sync.Mutex
+	sent []sentMail
+}
+
+type sentMail struct {
+	to, subject, body string
+}
+
+func (m *fakeMailer) Send(ctx context.Context, to, subject, body string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.sent = append(m.sent, sentMail{to: to, subject: subject, body: body})
+	return nil
+}
+
+var mailTokenPattern = regexp.MustCompile(`token=([^\s&]+)`)
+
+// lastToken 最近一封邮件链接中的令牌
+func (m *fakeMailer) lastToken(t *testing.T) string {
+	t.Helper()
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if len(m.sent) == 0 {
+		t.Fatal("no mail sent")
+	}
+	match := mailTokenPattern.FindStringSubmatch(m.sent[len(m.sent)-1].body)
+	if match == nil {
+		t.Fatalf("mail has no token link: %s", m.sent[len(m.sent)-1].body)
+	}
+	token, _ := url.QueryUnescape(match[1])
+	return token
+}
+
+// newTestAuthService 创建使用内存 Redis 存储令牌的认证服务
+func newTestAuthService(t *testing.T, userRepo *fakeUserRepo, mail *fakeMailer) (*authService, *jwt.Manager) {
+	t.Helper()
+	jwtManager := jwt.NewManager("test-secret", time.Hour, 24*time.Hour, "test")
+	svc := NewAuthService(userRepo, jwtManager, NewTokenDenylist(24*time.Hour),
+		NewEmailVerificationStore(), NewPasswordResetStore(), mail,
+		"https://lesson.example.com/verify", "https://lesson.example.com/reset-password")
+	return svc.(*authService), jwtManager
+}
+
+// activeUser 可登录的用户，密码为 password
+func activeUser(t *testing.T, email, password string) *model.User {
+	t.Helper()
+	hash, err := bcrypt.GenerateFromPassword([]byte(password), bcrypt.MinCost)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return &model.User{
+		ID:           uuid.New(),
+		Username:     strings.Split(email, "@")[0],
+		Email:        email,
+		PasswordHash: string(hash),
+		Role:         model.RoleTeacher,
+		Status:       model.StatusActive,
+	}
+}
+
+func TestResetPassword(t *testing.T) {
+	ctx := context.Background()
+	const newPassword = "N3w-Passw0rd!"
+
+	tests := []struct {
+		name    string
+		prepare func(t *testing.T, svc *authService, token string, fastForward func(time.Duration))
+		wantErr error
+	}{
+		{name: "valid token"},
+		{
+			name: "expired token",
+			prepare: func(t *testing.T, svc *authService, token string, fastForward func(time.Duration)) {
+				fastForward(passwordResetTokenTTL + time.Second)
+			},
+			wantErr: ErrPasswordResetTokenInvalid,
+		},
+		{
+			name: "reused token",
+			prepare: func(t *testing.T, svc *authService, token string, fastForward func(time.Duration)) {
+				if err := svc.ResetPassword(ctx, token, "F1rst-Reset!"); err != nil {
+					t.Fatalf("first ResetPassword() error = %v", err)
+				}
+			},
+			wantErr: ErrPasswordResetTokenInvalid,
+		},
+		{
+			name: "token replaced by a newer request",
+			prepare: func(t *testing.T, svc *authService, token string, fastForward func(time.Duration)) {
+				fastForward(passwordResetCooldown)
+				if err := svc.ForgotPassword(ctx, "teacher@example.com"); err != nil {
+					t.Fatalf("second ForgotPassword() error = %v", err)
+				}
+			},
+			wantErr: ErrPasswordResetTokenInvalid,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			mr := newTestRedis(t)
+			user := activeUser(t, "teacher@example.com", "0ld-Passw0rd!")
+			users := newFakeUserRepo(user)
+			mail := &fakeMailer{}
+			svc, jwtManager := newTestAuthService(t, users, mail)
+
+			// 重置前签发的会话令牌；iat 精度为秒，等到下一秒再重置，保证其签发早于吊销时间
+			oldToken, _, _ := jwtManager.GenerateAccessToken(user.ID.String(), user.Username, user.Email, user.Role, "")
+			oldClaims, _ := jwtManager.ValidateToken(oldToken)
+			if tt.wantErr == nil {
+				time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+			}
+
+			if err := svc.ForgotPassword(ctx, "teacher@example.com"); err != nil {
+				t.Fatalf("ForgotPassword() error = %v", err)
+			}
+			token := mail.lastToken(t)
+			if tt.prepare != nil {
+				tt.prepare(t, svc, token, mr.FastForward)
+			}
+
+			err := svc.ResetPassword(ctx, token, newPassword)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("ResetPassword() error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr != nil {
+				return
+			}
+
+			stored, _ := users.GetByID(ctx, user.ID)
+			if bcrypt.CompareHashAndPassword([]byte(stored.PasswordHash), []byte(newPassword)) != nil {
+				t.Error("password was not changed")
+			}
+			revoked, err := svc.denylist.IsUserRevoked(ctx, user.ID.String(), oldClaims.IssuedAt.Time)
+			if err != nil || !revoked {
+				t.Errorf("session issued before reset revoked = %v, %v, want true", revoked, err)
+			}
+		})
+	}
+}
+
+func TestResetPasswordWeakPasswordKeepsToken(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	user := activeUser(t, "teacher@example.com", "0ld-Passw0rd!")
+	mail := &fakeMailer{}
+	svc, _ := newTestAuthService(t, newFakeUserRepo(user), mail)
+
+	if err := svc.ForgotPassword(ctx, user.Email); err != nil {
+		t.Fatalf("ForgotPassword() error = %v", err)
+	}
+	token := mail.lastToken(t)
+	if err := svc.ResetPassword(ctx, token, "short"); !errors.Is(err, ErrWeakPassword) {
+		t.Fatalf("ResetPassword() with weak password error = %v, want ErrWeakPassword", err)
+	}
+	if err := svc.ResetPassword(ctx, token, "N3w-Passw0rd!"); err != nil {
+		t.Errorf("ResetPassword() after weak attempt error = %v", err)
+	}
+}
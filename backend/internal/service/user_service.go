@@ -3,11 +3,17 @@ package service
 import (
 	"context"
 	"errors"
+	"io"
 	"strings"
+	"time"
 
+	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/internal/model"
 	"lesson-plan/backend/internal/repository"
 	"lesson-plan/backend/pkg/jwt"
+	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/mailer"
+	"lesson-plan/backend/pkg/tenant"
 
 	"github.com/google/uuid"
 	"golang.org/x/crypto/bcrypt"
@@ -47,7 +53,18 @@ type LoginResponse struct {
 type UpdateUserRequest struct {
 	FullName  string `json:"full_name"`
 	AvatarURL string `json:"avatar_url"`
-	Email     string `json:"email"`
+	// Email 与当前邮箱不同时不会直接修改，而是向新邮箱发送验证链接（见 RequestEmailChange）
+	Email string `json:"email" binding:"omitempty,email"`
+}
+
+// ChangeEmailRequest 修改邮箱请求
+type ChangeEmailRequest struct {
+	Email string `json:"email" binding:"required,email,max=100"`
+}
+
+// ConfirmEmailRequest 确认邮箱变更请求
+type ConfirmEmailRequest struct {
+	Token string `json:"token" binding:"required"`
 }
 
 // AuthService 认证服务接口
@@ -55,27 +72,67 @@ type AuthService interface {
 	Register(ctx context.Context, req *RegisterRequest) (*model.User, error)
 	Login(ctx context.Context, req *LoginRequest) (*LoginResponse, error)
 	RefreshToken(ctx context.Context, refreshToken string) (*LoginResponse, error)
+	Logout(ctx context.Context, accessToken, refreshToken string) error
+	// VerifyEmail 校验注册验证链接并激活账号
+	VerifyEmail(ctx context.Context, token string) (*model.User, error)
+	// ResendVerification 向待验证账号重新发送验证邮件
+	ResendVerification(ctx context.Context, email string) error
+	// ForgotPassword 向账号邮箱发送一次性重置链接
+	ForgotPassword(ctx context.Context, email string) error
+	// ResetPassword 通过重置链接设置新密码
+	ResetPassword(ctx context.Context, token, newPassword string) error
 }
 
 // UserService 用户服务接口
 type UserService interface {
 	GetProfile(ctx context.Context, id uuid.UUID) (*model.UserProfile, error)
 	UpdateProfile(ctx context.Context, id uuid.UUID, req *UpdateUserRequest) (*model.User, error)
+	// UploadAvatar 保存上传的头像并返回访问地址，size 为客户端声明的文件大小
+	UploadAvatar(ctx context.Context, id uuid.UUID, r io.Reader, size int64) (string, error)
 	ChangePassword(ctx context.Context, id uuid.UUID, oldPassword, newPassword string) error
 	GetByID(ctx context.Context, id uuid.UUID) (*model.User, error)
+	RequestEmailChange(ctx context.Context, id uuid.UUID, newEmail string) (*EmailChangeRequest, error)
+	ConfirmEmailChange(ctx context.Context, token string) (*EmailChangeRequest, error)
+	ImportUsers(ctx context.Context, r io.Reader) (*UserImportReport, error)
+	SetStatus(ctx context.Context, adminID, targetID uuid.UUID, status string) error
+	// ExportData 导出用户的全部个人数据
+	ExportData(ctx context.Context, id uuid.UUID) (*model.UserDataExport, error)
 }
 
 // authService 认证服务实现
 type authService struct {
-	userRepo   repository.UserRepository
-	jwtManager *jwt.Manager
+	userRepo      repository.UserRepository
+	jwtManager    *jwt.Manager
+	denylist      TokenDenylist
+	verifications EmailVerificationStore
+	resets        PasswordResetStore
+	mailer        mailer.Sender
+	// verifyURL 前端注册邮箱验证页地址
+	verifyURL string
+	// resetURL 前端重置密码页地址
+	resetURL string
 }
 
 // NewAuthService 创建认证服务
-func NewAuthService(userRepo repository.UserRepository, jwtManager *jwt.Manager) AuthService {
+func NewAuthService(
+	userRepo repository.UserRepository,
+	jwtManager *jwt.Manager,
+	denylist TokenDenylist,
+	verifications EmailVerificationStore,
+	resets PasswordResetStore,
+	mailSender mailer.Sender,
+	verifyURL string,
+	resetURL string,
+) AuthService {
 	return &authService{
-		userRepo:   userRepo,
-		jwtManager: jwtManager,
+		userRepo:      userRepo,
+		jwtManager:    jwtManager,
+		denylist:      denylist,
+		verifications: verifications,
+		resets:        resets,
+		mailer:        mailSender,
+		verifyURL:     verifyURL,
+		resetURL:      resetURL,
 	}
 }
 
@@ -83,8 +140,11 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	normalizedUsername := req.Username
 	normalizedEmail := strings.ToLower(strings.TrimSpace(req.Email))
 
+	// 用户名和邮箱全局唯一（登录时无需指定租户），查重不受租户限制；新用户归入当前请求的租户
+	globalCtx := tenant.WithoutTenant(ctx)
+
 	// 检查用户名是否存在
-	exists, err := s.userRepo.ExistsByUsername(ctx, normalizedUsername)
+	exists, err := s.userRepo.ExistsByUsername(globalCtx, normalizedUsername)
 	if err != nil {
 		return nil, err
 	}
@@ -93,7 +153,7 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*mode
 	}
 
 	// 检查邮箱是否存在
-	exists, err = s.userRepo.ExistsByEmail(ctx, normalizedEmail)
+	exists, err = s.userRepo.ExistsByEmail(globalCtx, normalizedEmail)
 	if err != nil {
 		return nil, err
 	}
@@ -101,6 +161,10 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*mode
 		return nil, ErrUserExists
 	}
 
+	if err := ValidatePasswordStrength(req.Password); err != nil {
+		return nil, err
+	}
+
 	// 加密密码
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(req.Password), bcrypt.DefaultCost)
 	if err != nil {
@@ -113,13 +177,22 @@ func (s *authService) Register(ctx context.Context, req *RegisterRequest) (*mode
 		PasswordHash: string(hashedPassword),
 		FullName:     req.FullName,
 		Role:         model.RoleTeacher,
-		Status:       model.StatusActive,
+		// 验证邮箱后才激活，防止冒用他人邮箱注册
+		Status: model.StatusInactive,
 	}
 
 	if err := s.userRepo.Create(ctx, user); err != nil {
 		return nil, err
 	}
 
+	// 发送失败不影响注册结果，用户可通过重发接口再次获取验证邮件
+	if err := s.sendVerificationEmail(ctx, user); err != nil {
+		logger.Error("Failed to send verification email",
+			logger.String("user_id", user.ID.String()),
+			logger.Err(err),
+		)
+	}
+
 	return user, nil
 }
 
@@ -134,6 +207,8 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		err  error
 	)
 
+	// 按全局唯一的用户名/邮箱查找，租户以用户记录为准写入令牌
+	ctx = tenant.WithoutTenant(ctx)
 	if strings.Contains(identifier, "@") {
 		user, err = s.userRepo.GetByEmail(ctx, strings.ToLower(identifier))
 	} else {
@@ -146,21 +221,25 @@ func (s *authService) Login(ctx context.Context, req *LoginRequest) (*LoginRespo
 		return nil, ErrInvalidCredentials
 	}
 
-	if user.Status != model.StatusActive {
-		return nil, ErrUserInactive
-	}
-
 	if err := bcrypt.CompareHashAndPassword([]byte(user.PasswordHash), []byte(req.Password)); err != nil {
 		return nil, ErrInvalidCredentials
 	}
 
+	// 密码正确后再提示账号状态，避免未知密码时探测账号是否待验证
+	if user.PendingEmailVerification() {
+		return nil, ErrEmailNotVerified
+	}
+	if user.Status != model.StatusActive {
+		return nil, ErrUserInactive
+	}
+
 	// 生成令牌
-	accessToken, expiresAt, err := s.jwtManager.GenerateAccessToken(user.ID.String(), user.Username, user.Email, user.Role)
+	accessToken, expiresAt, err := s.jwtManager.GenerateAccessToken(user.ID.String(), user.Username, user.Email, user.Role, user.TenantID)
 	if err != nil {
 		return nil, err
 	}
 
-	refreshToken, _, err := s.jwtManager.GenerateRefreshToken(user.ID.String(), user.Username, user.Email, user.Role)
+	refreshToken, _, err := s.jwtManager.GenerateRefreshToken(user.ID.String(), user.Username, user.Email, user.Role, user.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -181,13 +260,23 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*L
 	if err != nil {
 		return nil, err
 	}
+	if revoked, err := s.denylist.IsRevoked(ctx, claims.ID); err == nil && revoked {
+		return nil, jwt.ErrRevokedToken
+	}
+	var issuedAt time.Time
+	if claims.IssuedAt != nil {
+		issuedAt = claims.IssuedAt.Time
+	}
+	if revoked, err := s.denylist.IsUserRevoked(ctx, claims.UserID, issuedAt); err == nil && revoked {
+		return nil, jwt.ErrRevokedToken
+	}
 
 	userID, err := uuid.Parse(claims.UserID)
 	if err != nil {
 		return nil, err
 	}
 
-	user, err := s.userRepo.GetByID(ctx, userID)
+	user, err := s.userRepo.GetByID(tenant.WithoutTenant(ctx), userID)
 	if err != nil {
 		return nil, ErrUserNotFound
 	}
@@ -196,12 +285,12 @@ func (s *authService) RefreshToken(ctx context.Context, refreshToken string) (*L
 		return nil, ErrUserInactive
 	}
 
-	accessToken, expiresAt, err := s.jwtManager.GenerateAccessToken(user.ID.String(), user.Username, user.Email, user.Role)
+	accessToken, expiresAt, err := s.jwtManager.GenerateAccessToken(user.ID.String(), user.Username, user.Email, user.Role, user.TenantID)
 	if err != nil {
 		return nil, err
 	}
 
-	newRefreshToken, _, err := s.jwtManager.GenerateRefreshToken(user.ID.String(), user.Username, user.Email, user.Role)
+	newRefreshToken, _, err := s.jwtManager.GenerateRefreshToken(user.ID.String(), user.Username, user.Email, user.Role, user.TenantID)
 	if err != nil {
 		return nil, err
 	}
@@ -219,6 +308,18 @@ type userService struct {
 	userRepo     repository.UserRepository
 	lessonRepo   repository.LessonRepository
 	favoriteRepo repository.FavoriteRepository
+	dataRepo     repository.UserDataRepository
+	jwtManager   *jwt.Manager
+	denylist     TokenDenylist
+	mailer       mailer.Sender
+	// confirmURL 前端邮箱验证页地址
+	confirmURL string
+	// uploadDir 上传文件目录，头像保存在其 avatars 子目录
+	uploadDir string
+	// maxUploadSize 单个上传文件的大小上限（字节）
+	maxUploadSize int64
+	// allowedUploadTypes 允许上传的 MIME 类型，为空时不额外限制
+	allowedUploadTypes map[string]bool
 }
 
 // NewUserService 创建用户服务
@@ -226,12 +327,34 @@ func NewUserService(
 	userRepo repository.UserRepository,
 	lessonRepo repository.LessonRepository,
 	favoriteRepo repository.FavoriteRepository,
+	dataRepo repository.UserDataRepository,
+	jwtManager *jwt.Manager,
+	denylist TokenDenylist,
+	mailSender mailer.Sender,
+	confirmURL string,
+	uploadCfg *config.UploadConfig,
 ) UserService {
-	return &userService{
+	s := &userService{
 		userRepo:     userRepo,
 		lessonRepo:   lessonRepo,
 		favoriteRepo: favoriteRepo,
+		dataRepo:     dataRepo,
+		jwtManager:   jwtManager,
+		denylist:     denylist,
+		mailer:       mailSender,
+		confirmURL:   confirmURL,
+	}
+	if uploadCfg != nil {
+		s.uploadDir = uploadCfg.StoragePath
+		s.maxUploadSize = uploadCfg.MaxSize
+		if len(uploadCfg.AllowedTypes) > 0 {
+			s.allowedUploadTypes = make(map[string]bool, len(uploadCfg.AllowedTypes))
+			for _, t := range uploadCfg.AllowedTypes {
+				s.allowedUploadTypes[strings.ToLower(strings.TrimSpace(t))] = true
+			}
+		}
 	}
+	return s
 }
 
 func (s *userService) GetProfile(ctx context.Context, id uuid.UUID) (*model.UserProfile, error) {
@@ -265,16 +388,7 @@ func (s *userService) UpdateProfile(ctx context.Context, id uuid.UUID, req *Upda
 	if req.AvatarURL != "" {
 		user.AvatarURL = req.AvatarURL
 	}
-	if req.Email != "" && req.Email != user.Email {
-		exists, err := s.userRepo.ExistsByEmail(ctx, req.Email)
-		if err != nil {
-			return nil, err
-		}
-		if exists {
-			return nil, ErrUserExists
-		}
-		user.Email = req.Email
-	}
+	// 邮箱变更需验证新邮箱，不在这里直接修改
 
 	if err := s.userRepo.Update(ctx, user); err != nil {
 		return nil, err
@@ -293,6 +407,10 @@ func (s *userService) ChangePassword(ctx context.Context, id uuid.UUID, oldPassw
 		return ErrInvalidCredentials
 	}
 
+	if err := ValidatePasswordStrength(newPassword); err != nil {
+		return err
+	}
+
 	hashedPassword, err := bcrypt.GenerateFromPassword([]byte(newPassword), bcrypt.DefaultCost)
 	if err != nil {
 		return err
@@ -0,0 +1,44 @@
+package service
+
+import (
+	"context"
+	"errors"
+
+	"lesson-plan/backend/internal/model"
+
+	"github.com/google/uuid"
+)
+
+var (
+	ErrCannotChangeOwnStatus = errors.New("不能修改自己的账号状态")
+	ErrInvalidUserStatus     = errors.New("无效的用户状态")
+)
+
+// SetStatus 管理员修改用户状态（封禁/解封）。非 active 状态下用户无法登录、刷新令牌或使用 API Key，
+// 并立即吊销其已签发的登录令牌；管理员不能修改自己的状态，避免把自己锁在系统外
+func (s *userService) SetStatus(ctx context.Context, adminID, targetID uuid.UUID, status string) error {
+	switch status {
+	case model.StatusActive, model.StatusInactive, model.StatusBanned:
+	default:
+		return ErrInvalidUserStatus
+	}
+	if adminID == targetID {
+		return ErrCannotChangeOwnStatus
+	}
+
+	user, err := s.userRepo.GetByID(ctx, targetID)
+	if err != nil {
+		return ErrUserNotFound
+	}
+	if user.Status == status {
+		return nil
+	}
+
+	if err := s.userRepo.UpdateStatus(ctx, targetID, status); err != nil {
+		return err
+	}
+	if status != model.StatusActive {
+		return s.denylist.RevokeUser(ctx, targetID.String())
+	}
+	return nil
+}
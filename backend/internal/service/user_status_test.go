@@ -0,0 +1,159 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"lesson-plan/backend/internal/middleware"
+	"lesson-plan/backend/internal/model"
+	"lesson-plan/backend/pkg/database"
+
+	"github.com/gin-gonic/gin"
+	"github.com/google/uuid"
+)
+
+func (r *fakeUserRepo) UpdateStatus(ctx context.Context, id uuid.UUID, status string) error {
+	user, ok := r.users[id]
+	if !ok {
+		return errRecordNotFound
+	}
+	user.Status = status
+	return nil
+}
+
+func (r *fakeUserRepo) UpdateLastLogin(ctx context.Context, id uuid.UUID) error {
+	return nil
+}
+
+func TestSetStatusValidation(t *testing.T) {
+	ctx := context.Background()
+	adminID := uuid.New()
+	user := activeUser(t, "teacher@example.com", "Passw0rd!")
+	svc := NewUserService(newFakeUserRepo(user), nil, nil, nil, nil, nil, nil, "", nil)
+
+	tests := []struct {
+		name     string
+		adminID  uuid.UUID
+		targetID uuid.UUID
+		status   string
+		wantErr  error
+	}{
+		{name: "unknown status", adminID: adminID, targetID: user.ID, status: "deleted", wantErr: ErrInvalidUserStatus},
+		{name: "own status", adminID: user.ID, targetID: user.ID, status: model.StatusBanned, wantErr: ErrCannotChangeOwnStatus},
+		{name: "missing user", adminID: adminID, targetID: uuid.New(), status: model.StatusBanned, wantErr: ErrUserNotFound},
+		{name: "unchanged status is a no-op", adminID: adminID, targetID: user.ID, status: model.StatusActive},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if err := svc.SetStatus(ctx, tt.adminID, tt.targetID, tt.status); !errors.Is(err, tt.wantErr) {
+				t.Errorf("SetStatus() error = %v, want %v", err, tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestBanRevokesSessions(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+	ctx := context.Background()
+	newTestRedis(t)
+	user := activeUser(t, "teacher@example.com", "Passw0rd!")
+	users := newFakeUserRepo(user)
+	auth, jwtManager := newTestAuthService(t, users, &fakeMailer{})
+	userSvc := NewUserService(users, nil, nil, nil, jwtManager, auth.denylist, nil, "", nil)
+	adminID := uuid.New()
+
+	r := gin.New()
+	r.GET("/me", middleware.AuthMiddleware(jwtManager, nil, auth.denylist), func(c *gin.Context) {
+		c.Status(http.StatusOK)
+	})
+	get := func(token string) int {
+		req := httptest.NewRequest(http.MethodGet, "/me", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, req)
+		return w.Code
+	}
+	login := func() (*LoginResponse, error) {
+		return auth.Login(ctx, &LoginRequest{Username: user.Email, Password: "Passw0rd!"})
+	}
+
+	session, err := login()
+	if err != nil {
+		t.Fatalf("Login() error = %v", err)
+	}
+	if code := get(session.AccessToken); code != http.StatusOK {
+		t.Fatalf("GET /me before ban = %d, want 200", code)
+	}
+
+	// iat 精度为秒，等到下一秒再封禁，保证会话签发早于吊销时间
+	time.Sleep(time.Until(time.Now().Truncate(time.Second).Add(time.Second)))
+	if err := userSvc.SetStatus(ctx, adminID, user.ID, model.StatusBanned); err != nil {
+		t.Fatalf("SetStatus(banned) error = %v", err)
+	}
+
+	if code := get(session.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("GET /me with existing token after ban = %d, want 401", code)
+	}
+	if _, err := auth.RefreshToken(ctx, session.RefreshToken); err == nil {
+		t.Error("RefreshToken() after ban should fail")
+	}
+	if _, err := login(); !errors.Is(err, ErrUserInactive) {
+		t.Errorf("Login() after ban error = %v, want ErrUserInactive", err)
+	}
+
+	// 解封后立即重新登录（可能与封禁在同一秒内）签发的令牌可用，旧令牌仍然无效
+	if err := userSvc.SetStatus(ctx, adminID, user.ID, model.StatusActive); err != nil {
+		t.Fatalf("SetStatus(active) error = %v", err)
+	}
+	relogin, err := login()
+	if err != nil {
+		t.Fatalf("Login() after unban error = %v", err)
+	}
+	if code := get(relogin.AccessToken); code != http.StatusOK {
+		t.Errorf("GET /me with token issued after unban = %d, want 200", code)
+	}
+	if code := get(session.AccessToken); code != http.StatusUnauthorized {
+		t.Errorf("GET /me with pre-ban token after unban = %d, want 401", code)
+	}
+}
+
+func TestIsUserRevokedSecondPrecision(t *testing.T) {
+	ctx := context.Background()
+	newTestRedis(t)
+	denylist := NewTokenDenylist(24 * time.Hour)
+	userID := uuid.NewString()
+
+	if revoked, err := denylist.IsUserRevoked(ctx, userID, time.Now()); err != nil || revoked {
+		t.Fatalf("IsUserRevoked() without revocation = %v, %v, want false", revoked, err)
+	}
+	if err := denylist.RevokeUser(ctx, userID); err != nil {
+		t.Fatalf("RevokeUser() error = %v", err)
+	}
+	var revokedAt int64
+	if err := database.Get(ctx, userRevokedBeforeKeyPrefix+userID, &revokedAt); err != nil {
+		t.Fatalf("read revocation time: %v", err)
+	}
+
+	tests := []struct {
+		name     string
+		issuedAt time.Time
+		want     bool
+	}{
+		{name: "issued a second earlier", issuedAt: time.Unix(revokedAt-1, 999_000_000), want: true},
+		{name: "issued in the same second", issuedAt: time.Unix(revokedAt, 0), want: false},
+		{name: "issued later", issuedAt: time.Unix(revokedAt+1, 0), want: false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			revoked, err := denylist.IsUserRevoked(ctx, userID, tt.issuedAt)
+			if err != nil || revoked != tt.want {
+				t.Errorf("IsUserRevoked() = %v, %v, want %v", revoked, err, tt.want)
+			}
+		})
+	}
+}
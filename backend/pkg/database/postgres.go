@@ -7,6 +7,7 @@ import (
 
 	"lesson-plan/backend/internal/config"
 	"lesson-plan/backend/pkg/logger"
+	"lesson-plan/backend/pkg/tenant"
 
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
@@ -36,6 +37,11 @@ func InitPostgres(cfg *config.PostgresConfig) (*gorm.DB, error) {
 		return nil, fmt.Errorf("failed to connect to postgres: %w", err)
 	}
 
+	// 按 context 中的租户自动过滤/填充 tenant_id
+	if err := tenant.RegisterGORMCallbacks(db); err != nil {
+		return nil, fmt.Errorf("failed to register tenant callbacks: %w", err)
+	}
+
 	sqlDB, err := db.DB()
 	if err != nil {
 		return nil, fmt.Errorf("failed to get sql.DB: %w", err)
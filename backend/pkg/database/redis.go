@@ -74,6 +74,15 @@ func GetString(ctx context.Context, key string) (string, error) {
 	return redisClient.Get(ctx, key).Result()
 }
 
+// GetDel 原子地获取并删除值，适合一次性令牌
+func GetDel(ctx context.Context, key string, dest interface{}) error {
+	data, err := redisClient.GetDel(ctx, key).Bytes()
+	if err != nil {
+		return err
+	}
+	return json.Unmarshal(data, dest)
+}
+
 // Delete 删除键
 func Delete(ctx context.Context, keys ...string) error {
 	return redisClient.Del(ctx, keys...).Err()
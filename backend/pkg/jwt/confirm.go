@@ -0,0 +1,90 @@
+package jwt
+
+import (
+	"errors"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+	"github.com/google/uuid"
+)
+
+// ErrConfirmMismatch 确认令牌与当前操作不匹配
+var ErrConfirmMismatch = errors.New("confirm token does not match the operation")
+
+// ConfirmClaims 危险操作二次确认令牌声明，绑定用户、操作与资源
+type ConfirmClaims struct {
+	UserID   string `json:"user_id"`
+	Action   string `json:"action"`
+	Resource string `json:"resource"`
+	jwt.RegisteredClaims
+}
+
+// confirmKey 确认令牌使用派生密钥签名，避免与访问令牌互相冒用
+func (m *Manager) confirmKey() []byte {
+	return append(append([]byte{}, m.secretKey...), []byte(":confirm")...)
+}
+
+// GenerateConfirmToken 生成短时效的二次确认令牌
+func (m *Manager) GenerateConfirmToken(userID, action, resource string, ttl time.Duration) (string, int64, error) {
+	now := time.Now()
+	expiresAt := now.Add(ttl)
+
+	claims := &ConfirmClaims{
+		UserID:   userID,
+		Action:   action,
+		Resource: resource,
+		RegisteredClaims: jwt.RegisteredClaims{
+			ExpiresAt: jwt.NewNumericDate(expiresAt),
+			IssuedAt:  jwt.NewNumericDate(now),
+			NotBefore: jwt.NewNumericDate(now),
+			Issuer:    m.issuer,
+			ID:        uuid.New().String(),
+		},
+	}
+
+	token := jwt.NewWithClaims(jwt.SigningMethodHS256, claims)
+	tokenString, err := token.SignedString(m.confirmKey())
+	if err != nil {
+		return "", 0, err
+	}
+
+	return tokenString, expiresAt.Unix(), nil
+}
+
+// ParseConfirmToken 校验签名与有效期并返回声明，操作不一致时返回 ErrConfirmMismatch；
+// 用于资源信息需要从令牌中取出的场景（如邮箱变更链接）
+func (m *Manager) ParseConfirmToken(tokenString, action string) (*ConfirmClaims, error) {
+	token, err := jwt.ParseWithClaims(tokenString, &ConfirmClaims{}, func(token *jwt.Token) (interface{}, error) {
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+			return nil, ErrInvalidToken
+		}
+		return m.confirmKey(), nil
+	})
+	if err != nil {
+		if errors.Is(err, jwt.ErrTokenExpired) {
+			return nil, ErrExpiredToken
+		}
+		return nil, ErrInvalidToken
+	}
+
+	claims, ok := token.Claims.(*ConfirmClaims)
+	if !ok || !token.Valid {
+		return nil, ErrInvalidClaims
+	}
+	if claims.Action != action {
+		return nil, ErrConfirmMismatch
+	}
+	return claims, nil
+}
+
+// ValidateConfirmToken 校验二次确认令牌并返回声明，用户、操作或资源不一致时返回 ErrConfirmMismatch
+func (m *Manager) ValidateConfirmToken(tokenString, userID, action, resource string) (*ConfirmClaims, error) {
+	claims, err := m.ParseConfirmToken(tokenString, action)
+	if err != nil {
+		return nil, err
+	}
+	if claims.UserID != userID || claims.Resource != resource {
+		return nil, ErrConfirmMismatch
+	}
+	return claims, nil
+}
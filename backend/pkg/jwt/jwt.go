@@ -12,6 +12,7 @@ var (
 	ErrInvalidToken  = errors.New("invalid token")
 	ErrExpiredToken  = errors.New("token has expired")
 	ErrInvalidClaims = errors.New("invalid token claims")
+	ErrRevokedToken  = errors.New("token has been revoked")
 )
 
 // Claims JWT声明
@@ -20,6 +21,7 @@ type Claims struct {
 	Username string `json:"username"`
 	Email    string `json:"email"`
 	Role     string `json:"role"`
+	TenantID string `json:"tenant_id,omitempty"`
 	jwt.RegisteredClaims
 }
 
@@ -49,15 +51,15 @@ func NewManager(secret string, expiry, refreshExpiry time.Duration, issuer strin
 }
 
 // GenerateTokenPair 生成Token对
-func (m *Manager) GenerateTokenPair(userID, username, email, role string) (*TokenPair, error) {
+func (m *Manager) GenerateTokenPair(userID, username, email, role, tenantID string) (*TokenPair, error) {
 	// 生成Access Token
-	accessToken, expiresAt, err := m.generateToken(userID, username, email, role, m.expiry)
+	accessToken, expiresAt, err := m.generateToken(userID, username, email, role, tenantID, m.expiry)
 	if err != nil {
 		return nil, err
 	}
 
 	// 生成Refresh Token
-	refreshToken, _, err := m.generateToken(userID, username, email, role, m.refreshExpiry)
+	refreshToken, _, err := m.generateToken(userID, username, email, role, tenantID, m.refreshExpiry)
 	if err != nil {
 		return nil, err
 	}
@@ -70,17 +72,17 @@ func (m *Manager) GenerateTokenPair(userID, username, email, role string) (*Toke
 }
 
 // GenerateAccessToken 生成Access Token
-func (m *Manager) GenerateAccessToken(userID, username, email, role string) (string, int64, error) {
-	return m.generateToken(userID, username, email, role, m.expiry)
+func (m *Manager) GenerateAccessToken(userID, username, email, role, tenantID string) (string, int64, error) {
+	return m.generateToken(userID, username, email, role, tenantID, m.expiry)
 }
 
 // GenerateRefreshToken 生成Refresh Token
-func (m *Manager) GenerateRefreshToken(userID, username, email, role string) (string, int64, error) {
-	return m.generateToken(userID, username, email, role, m.refreshExpiry)
+func (m *Manager) GenerateRefreshToken(userID, username, email, role, tenantID string) (string, int64, error) {
+	return m.generateToken(userID, username, email, role, tenantID, m.refreshExpiry)
 }
 
 // generateToken 生成Token
-func (m *Manager) generateToken(userID, username, email, role string, expiry time.Duration) (string, int64, error) {
+func (m *Manager) generateToken(userID, username, email, role, tenantID string, expiry time.Duration) (string, int64, error) {
 	now := time.Now()
 	expiresAt := now.Add(expiry)
 
@@ -89,6 +91,7 @@ func (m *Manager) generateToken(userID, username, email, role string, expiry tim
 		Username: username,
 		Email:    email,
 		Role:     role,
+		TenantID: tenantID,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(expiresAt),
 			IssuedAt:  jwt.NewNumericDate(now),
@@ -128,7 +131,6 @@ func (m *Manager) ValidateToken(tokenString string) (*Claims, error) {
 	if !ok || !token.Valid {
 		return nil, ErrInvalidClaims
 	}
-
 	return claims, nil
 }
 
@@ -139,7 +141,7 @@ func (m *Manager) RefreshToken(refreshToken string) (*TokenPair, error) {
 		return nil, err
 	}
 
-	return m.GenerateTokenPair(claims.UserID, claims.Username, claims.Email, claims.Role)
+	return m.GenerateTokenPair(claims.UserID, claims.Username, claims.Email, claims.Role, claims.TenantID)
 }
 
 // ExtractUserID 从Token中提取用户ID
@@ -151,6 +153,18 @@ func (m *Manager) ExtractUserID(tokenString string) (string, error) {
 	return claims.UserID, nil
 }
 
+// ExtractTokenID 从有效Token中提取 jti 与过期时间，用于登出时把令牌加入黑名单
+func (m *Manager) ExtractTokenID(tokenString string) (string, time.Time, error) {
+	claims, err := m.ValidateToken(tokenString)
+	if err != nil {
+		return "", time.Time{}, err
+	}
+	if claims.ID == "" || claims.ExpiresAt == nil {
+		return "", time.Time{}, ErrInvalidClaims
+	}
+	return claims.ID, claims.ExpiresAt.Time, nil
+}
+
 // IsTokenExpired 检查Token是否过期
 func (m *Manager) IsTokenExpired(tokenString string) bool {
 	_, err := m.ValidateToken(tokenString)
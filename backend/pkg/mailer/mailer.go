@@ -0,0 +1,98 @@
+// Package mailer 发送系统通知邮件。
+package mailer
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"mime"
+	"net"
+	"net/smtp"
+	"strconv"
+	"strings"
+	"time"
+
+	"lesson-plan/backend/pkg/logger"
+)
+
+// Config 邮件发送配置
+type Config struct {
+	Host     string
+	Port     int
+	Username string
+	Password string
+	From     string
+}
+
+// Sender 邮件发送接口
+type Sender interface {
+	Send(ctx context.Context, to, subject, body string) error
+}
+
+// New 创建邮件发送器；未配置 SMTP 主机时返回只写日志的发送器，便于本地开发
+func New(cfg Config) Sender {
+	if strings.TrimSpace(cfg.Host) == "" {
+		return &logSender{}
+	}
+	if cfg.Port == 0 {
+		cfg.Port = 587
+	}
+	return &smtpSender{cfg: cfg}
+}
+
+type smtpSender struct {
+	cfg Config
+}
+
+func (s *smtpSender) Send(ctx context.Context, to, subject, body string) error {
+	addr := net.JoinHostPort(s.cfg.Host, strconv.Itoa(s.cfg.Port))
+
+	var auth smtp.Auth
+	if s.cfg.Username != "" {
+		auth = smtp.PlainAuth("", s.cfg.Username, s.cfg.Password, s.cfg.Host)
+	}
+
+	msg := buildMessage(s.cfg.From, to, subject, body)
+
+	// net/smtp 不支持 context，放到协程中执行，调用方取消时不再等待
+	done := make(chan error, 1)
+	go func() {
+		done <- smtp.SendMail(addr, auth, s.cfg.From, []string{to}, msg)
+	}()
+
+	select {
+	case err := <-done:
+		if err != nil {
+			return fmt.Errorf("发送邮件失败: %w", err)
+		}
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+// buildMessage 组装 UTF-8 纯文本邮件，主题按 RFC 2047 编码以支持中文
+func buildMessage(from, to, subject, body string) []byte {
+	var buf bytes.Buffer
+	buf.WriteString("From: " + from + "\r\n")
+	buf.WriteString("To: " + to + "\r\n")
+	buf.WriteString("Subject: " + mime.BEncoding.Encode("UTF-8", subject) + "\r\n")
+	buf.WriteString("Date: " + time.Now().Format(time.RFC1123Z) + "\r\n")
+	buf.WriteString("MIME-Version: 1.0\r\n")
+	buf.WriteString("Content-Type: text/plain; charset=UTF-8\r\n")
+	buf.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	buf.WriteString("\r\n")
+	buf.WriteString(strings.ReplaceAll(body, "\n", "\r\n"))
+	return buf.Bytes()
+}
+
+type logSender struct{}
+
+func (s *logSender) Send(_ context.Context, to, subject, body string) error {
+	logger.Info("邮件未配置 SMTP，仅记录日志",
+		logger.String("to", to),
+		logger.String("subject", subject),
+		logger.String("body", body),
+	)
+	return nil
+}
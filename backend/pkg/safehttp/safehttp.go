@@ -0,0 +1,183 @@
+// Package safehttp 提供抓取用户提供 URL 时使用的 HTTP 客户端，防止 SSRF 访问内网地址。
+package safehttp
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+var (
+	// ErrInvalidURL URL 格式不合法或协议不受支持
+	ErrInvalidURL = errors.New("URL 格式无效，仅支持 http/https")
+	// ErrForbiddenAddress 目标地址属于内网、回环或保留网段
+	ErrForbiddenAddress = errors.New("禁止访问内网或保留地址")
+	// ErrTooLarge 响应体超过上限
+	ErrTooLarge = errors.New("响应内容过大")
+)
+
+const (
+	defaultTimeout      = 15 * time.Second
+	defaultMaxRedirects = 5
+)
+
+// blockedNetworks 除标准库判断之外额外禁止的保留网段
+var blockedNetworks = mustParseCIDRs(
+	"0.0.0.0/8",       // 本网络
+	"100.64.0.0/10",   // 运营商级 NAT
+	"192.0.0.0/24",    // IETF 协议分配
+	"192.0.2.0/24",    // 文档示例
+	"198.18.0.0/15",   // 基准测试
+	"198.51.100.0/24", // 文档示例
+	"203.0.113.0/24",  // 文档示例
+	"240.0.0.0/4",     // 保留
+	"64:ff9b::/96",    // NAT64，可映射到内网 IPv4
+)
+
+func mustParseCIDRs(cidrs ...string) []*net.IPNet {
+	networks := make([]*net.IPNet, 0, len(cidrs))
+	for _, cidr := range cidrs {
+		_, network, err := net.ParseCIDR(cidr)
+		if err != nil {
+			panic(err)
+		}
+		networks = append(networks, network)
+	}
+	return networks
+}
+
+// IsForbiddenIP 判断 IP 是否属于禁止访问的内网、回环、链路本地、组播或保留地址
+func IsForbiddenIP(ip net.IP) bool {
+	if ip == nil {
+		return true
+	}
+	if v4 := ip.To4(); v4 != nil {
+		ip = v4
+	}
+	if ip.IsLoopback() || ip.IsPrivate() || ip.IsUnspecified() ||
+		ip.IsLinkLocalUnicast() || ip.IsLinkLocalMulticast() ||
+		ip.IsInterfaceLocalMulticast() || ip.IsMulticast() {
+		return true
+	}
+	for _, network := range blockedNetworks {
+		if network.Contains(ip) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateURL 校验协议与主机；字面量 IP 直接判断，域名在建立连接时按解析结果再校验
+func ValidateURL(raw string) (*url.URL, error) {
+	parsed, err := url.Parse(strings.TrimSpace(raw))
+	if err != nil {
+		return nil, ErrInvalidURL
+	}
+	if parsed.Scheme != "http" && parsed.Scheme != "https" {
+		return nil, ErrInvalidURL
+	}
+	if parsed.Hostname() == "" || parsed.User != nil {
+		return nil, ErrInvalidURL
+	}
+
+	host := strings.ToLower(strings.TrimSuffix(parsed.Hostname(), "."))
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return nil, ErrForbiddenAddress
+	}
+	if ip := net.ParseIP(host); ip != nil && IsForbiddenIP(ip) {
+		return nil, ErrForbiddenAddress
+	}
+	return parsed, nil
+}
+
+// dialControl 在真正建立 TCP 连接前校验解析后的 IP，防止 DNS 重绑定绕过 ValidateURL
+func dialControl(_, address string, _ syscall.RawConn) error {
+	host, _, err := net.SplitHostPort(address)
+	if err != nil {
+		return ErrForbiddenAddress
+	}
+	if IsForbiddenIP(net.ParseIP(host)) {
+		return ErrForbiddenAddress
+	}
+	return nil
+}
+
+// NewClient 创建禁止访问内网地址的 HTTP 客户端：不走系统代理（代理会绕过 IP 校验），
+// 每次重定向都重新校验目标 URL，连接阶段校验实际 IP
+func NewClient(timeout time.Duration) *http.Client {
+	if timeout <= 0 {
+		timeout = defaultTimeout
+	}
+
+	dialer := &net.Dialer{
+		Timeout: 5 * time.Second,
+		Control: dialControl,
+	}
+	transport := &http.Transport{
+		Proxy:                 nil,
+		DialContext:           dialer.DialContext,
+		TLSHandshakeTimeout:   5 * time.Second,
+		ResponseHeaderTimeout: timeout,
+		MaxIdleConns:          10,
+		IdleConnTimeout:       30 * time.Second,
+	}
+
+	return &http.Client{
+		Timeout:   timeout,
+		Transport: transport,
+		CheckRedirect: func(req *http.Request, via []*http.Request) error {
+			if len(via) >= defaultMaxRedirects {
+				return fmt.Errorf("重定向次数超过 %d 次", defaultMaxRedirects)
+			}
+			_, err := ValidateURL(req.URL.String())
+			return err
+		},
+	}
+}
+
+// Fetch 校验 URL 后发起 GET 请求，读取不超过 maxBytes 的响应体，返回响应体与 Content-Type
+func Fetch(ctx context.Context, client *http.Client, rawURL string, maxBytes int64) ([]byte, string, error) {
+	target, err := ValidateURL(rawURL)
+	if err != nil {
+		return nil, "", err
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, target.String(), nil)
+	if err != nil {
+		return nil, "", ErrInvalidURL
+	}
+	req.Header.Set("User-Agent", "LessonPlanImporter/1.0")
+	req.Header.Set("Accept", "text/html,application/xhtml+xml")
+
+	resp, err := client.Do(req)
+	if err != nil {
+		// 重定向或连接阶段的校验失败会被包装在 url.Error 中
+		for _, sentinel := range []error{ErrForbiddenAddress, ErrInvalidURL} {
+			if errors.Is(err, sentinel) {
+				return nil, "", sentinel
+			}
+		}
+		return nil, "", fmt.Errorf("请求失败: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, "", fmt.Errorf("目标地址返回状态码 %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(io.LimitReader(resp.Body, maxBytes+1))
+	if err != nil {
+		return nil, "", fmt.Errorf("读取响应失败: %w", err)
+	}
+	if int64(len(body)) > maxBytes {
+		return nil, "", ErrTooLarge
+	}
+	return body, resp.Header.Get("Content-Type"), nil
+}
@@ -0,0 +1,82 @@
+package tenant
+
+import (
+	"reflect"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/gorm/schema"
+)
+
+const (
+	tenantFieldName  = "TenantID"
+	tenantColumnName = "tenant_id"
+)
+
+// RegisterGORMCallbacks 注册租户隔离回调：
+// 对含 TenantID 字段的模型，查询/更新/删除自动追加 tenant_id 条件，创建时自动填充租户。
+// 租户取自 statement 的 context（WithContext 传入），context 中没有租户时不做处理，
+// 以便后台任务按 ID 操作数据。Raw/Exec 原生 SQL 不经过这些回调，需自行带上租户条件。
+func RegisterGORMCallbacks(db *gorm.DB) error {
+	if err := db.Callback().Create().Before("gorm:create").Register("tenant:create", fillTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Query().Before("gorm:query").Register("tenant:query", scopeTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Row().Before("gorm:row").Register("tenant:row", scopeTenant); err != nil {
+		return err
+	}
+	if err := db.Callback().Update().Before("gorm:update").Register("tenant:update", scopeTenant); err != nil {
+		return err
+	}
+	return db.Callback().Delete().Before("gorm:delete").Register("tenant:delete", scopeTenant)
+}
+
+func tenantField(db *gorm.DB) (*schema.Field, string) {
+	if db.Statement.Schema == nil {
+		return nil, ""
+	}
+	tenantID := FromContext(db.Statement.Context)
+	if tenantID == "" {
+		return nil, ""
+	}
+	field := db.Statement.Schema.LookUpField(tenantFieldName)
+	if field == nil || field.DBName != tenantColumnName {
+		return nil, ""
+	}
+	return field, tenantID
+}
+
+func scopeTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+	db.Statement.AddClause(clause.Where{Exprs: []clause.Expression{
+		clause.Eq{Column: clause.Column{Table: clause.CurrentTable, Name: field.DBName}, Value: tenantID},
+	}})
+}
+
+func fillTenant(db *gorm.DB) {
+	field, tenantID := tenantField(db)
+	if field == nil {
+		return
+	}
+
+	ctx := db.Statement.Context
+	reflectValue := db.Statement.ReflectValue
+	switch reflectValue.Kind() {
+	case reflect.Slice, reflect.Array:
+		for i := 0; i < reflectValue.Len(); i++ {
+			elem := reflect.Indirect(reflectValue.Index(i))
+			if _, isZero := field.ValueOf(ctx, elem); isZero {
+				_ = field.Set(ctx, elem, tenantID)
+			}
+		}
+	case reflect.Struct:
+		if _, isZero := field.ValueOf(ctx, reflectValue); isZero {
+			_ = field.Set(ctx, reflectValue, tenantID)
+		}
+	}
+}
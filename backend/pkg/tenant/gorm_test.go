@@ -0,0 +1,149 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+)
+
+// scopedRow 带租户字段的模型
+type scopedRow struct {
+	ID       int
+	TenantID string
+	Title    string
+}
+
+// globalRow 不带租户字段的模型
+type globalRow struct {
+	ID   int
+	Name string
+}
+
+// newDryRunDB 只生成 SQL、不连接数据库的 GORM 实例
+func newDryRunDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(postgres.New(postgres.Config{DSN: "host=127.0.0.1 user=test dbname=test sslmode=disable"}),
+		&gorm.Config{DryRun: true, DisableAutomaticPing: true, SkipDefaultTransaction: true})
+	if err != nil {
+		t.Fatalf("open dry-run db: %v", err)
+	}
+	if err := RegisterGORMCallbacks(db); err != nil {
+		t.Fatalf("RegisterGORMCallbacks() error = %v", err)
+	}
+	return db
+}
+
+func TestTenantScopedStatements(t *testing.T) {
+	db := newDryRunDB(t)
+	schoolA := WithTenant(context.Background(), "school-a")
+
+	tests := []struct {
+		name     string
+		run      func(db *gorm.DB) *gorm.Statement
+		wantSQL  string
+		wantVars []interface{}
+	}{
+		{
+			name: "query is scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				var rows []scopedRow
+				return db.WithContext(schoolA).Where("id = ?", 7).Find(&rows).Statement
+			},
+			wantSQL:  `SELECT * FROM "scoped_rows" WHERE id = $1 AND "scoped_rows"."tenant_id" = $2`,
+			wantVars: []interface{}{7, "school-a"},
+		},
+		{
+			name: "count is scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				var n int64
+				return db.WithContext(schoolA).Model(&scopedRow{}).Count(&n).Statement
+			},
+			wantSQL:  `SELECT count(*) FROM "scoped_rows" WHERE "scoped_rows"."tenant_id" = $1`,
+			wantVars: []interface{}{"school-a"},
+		},
+		{
+			name: "update is scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				return db.WithContext(schoolA).Model(&scopedRow{}).Where("id = ?", 7).Update("title", "x").Statement
+			},
+			wantSQL:  `UPDATE "scoped_rows" SET "title"=$1 WHERE id = $2 AND "scoped_rows"."tenant_id" = $3`,
+			wantVars: []interface{}{"x", 7, "school-a"},
+		},
+		{
+			name: "delete is scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				return db.WithContext(schoolA).Where("id = ?", 7).Delete(&scopedRow{}).Statement
+			},
+			wantSQL:  `DELETE FROM "scoped_rows" WHERE id = $1 AND "scoped_rows"."tenant_id" = $2`,
+			wantVars: []interface{}{7, "school-a"},
+		},
+		{
+			name: "context without tenant is not scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				var rows []scopedRow
+				return db.WithContext(WithoutTenant(schoolA)).Where("id = ?", 7).Find(&rows).Statement
+			},
+			wantSQL:  `SELECT * FROM "scoped_rows" WHERE id = $1`,
+			wantVars: []interface{}{7},
+		},
+		{
+			name: "models without tenant column are not scoped",
+			run: func(db *gorm.DB) *gorm.Statement {
+				var rows []globalRow
+				return db.WithContext(schoolA).Find(&rows).Statement
+			},
+			wantSQL: `SELECT * FROM "global_rows"`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stmt := tt.run(db.Session(&gorm.Session{NewDB: true}))
+			if got := strings.TrimSpace(stmt.SQL.String()); got != tt.wantSQL {
+				t.Errorf("SQL = %s\nwant  %s", got, tt.wantSQL)
+			}
+			if len(stmt.Vars) != len(tt.wantVars) {
+				t.Fatalf("vars = %v, want %v", stmt.Vars, tt.wantVars)
+			}
+			for i := range tt.wantVars {
+				if stmt.Vars[i] != tt.wantVars[i] {
+					t.Errorf("vars[%d] = %v, want %v", i, stmt.Vars[i], tt.wantVars[i])
+				}
+			}
+		})
+	}
+}
+
+func TestTenantFilledOnCreate(t *testing.T) {
+	db := newDryRunDB(t)
+	ctx := WithTenant(context.Background(), "school-a")
+
+	tests := []struct {
+		name string
+		rows []*scopedRow
+		want []string
+	}{
+		{name: "single row gets request tenant", rows: []*scopedRow{{Title: "a"}}, want: []string{"school-a"}},
+		{name: "explicit tenant is kept", rows: []*scopedRow{{Title: "a", TenantID: "school-b"}}, want: []string{"school-b"}},
+		{name: "batch rows are filled", rows: []*scopedRow{{Title: "a"}, {Title: "b", TenantID: "school-b"}}, want: []string{"school-a", "school-b"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			session := db.Session(&gorm.Session{NewDB: true}).WithContext(ctx)
+			if len(tt.rows) == 1 {
+				session.Create(tt.rows[0])
+			} else {
+				session.Create(tt.rows)
+			}
+			for i, row := range tt.rows {
+				if row.TenantID != tt.want[i] {
+					t.Errorf("rows[%d].TenantID = %q, want %q", i, row.TenantID, tt.want[i])
+				}
+			}
+		})
+	}
+}
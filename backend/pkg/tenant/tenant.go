@@ -0,0 +1,33 @@
+package tenant
+
+import (
+	"context"
+	"strings"
+)
+
+// DefaultTenant 未配置多租户时所有数据归属的默认租户
+const DefaultTenant = "default"
+
+type contextKey struct{}
+
+// WithTenant 将租户写入 context，供 repository 层自动过滤
+func WithTenant(ctx context.Context, tenantID string) context.Context {
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	return context.WithValue(ctx, contextKey{}, strings.TrimSpace(tenantID))
+}
+
+// FromContext 从 context 中读取租户，未设置时返回空字符串（不做租户过滤）
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	tenantID, _ := ctx.Value(contextKey{}).(string)
+	return tenantID
+}
+
+// WithoutTenant 返回不做租户过滤的 context，仅用于按全局唯一键查找（如登录时按用户名查用户）
+func WithoutTenant(ctx context.Context) context.Context {
+	return WithTenant(ctx, "")
+}
@@ -12,6 +12,8 @@ CREATE INDEX knowledge_point_userId IF NOT EXISTS FOR (k:KnowledgePoint) ON (k.u
 CREATE INDEX knowledge_point_documentId IF NOT EXISTS FOR (k:KnowledgePoint) ON (k.documentId);
 CREATE INDEX knowledge_point_subject IF NOT EXISTS FOR (k:KnowledgePoint) ON (k.subject);
 CREATE INDEX knowledge_point_grade IF NOT EXISTS FOR (k:KnowledgePoint) ON (k.grade);
+// 按时间查看图谱快照（asOf）
+CREATE INDEX knowledge_point_createdAt IF NOT EXISTS FOR (k:KnowledgePoint) ON (k.createdAt);
 
 // 验证完成
 RETURN '知识图谱数据库初始化完成！知识点将由用户上传文档动态生成。' AS Status;
@@ -3,6 +3,7 @@
 -- 创建扩展
 CREATE EXTENSION IF NOT EXISTS "uuid-ossp";
 CREATE EXTENSION IF NOT EXISTS "pgcrypto";
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
 
 -- ==================== 用户表 ====================
 CREATE TABLE IF NOT EXISTS users (
@@ -23,6 +24,12 @@ CREATE TABLE IF NOT EXISTS users (
 ALTER TABLE users ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
 CREATE INDEX IF NOT EXISTS idx_users_deleted_at ON users(deleted_at);
 
+-- 评论通知邮件偏好：realtime 实时、daily 每日摘要、off 关闭
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_mode VARCHAR(20) NOT NULL DEFAULT 'realtime';
+
+-- 注册邮箱验证时间：自助注册的用户验证前为空且状态为 inactive
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
 -- 用户表索引
 CREATE INDEX idx_users_email ON users(email);
 CREATE INDEX idx_users_username ON users(username);
@@ -73,6 +80,34 @@ CREATE TABLE IF NOT EXISTS lessons (
 ALTER TABLE lessons ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
 CREATE INDEX IF NOT EXISTS idx_lessons_deleted_at ON lessons(deleted_at);
 
+-- 已发布教案的待发布草稿
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS draft_content TEXT;
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS draft_saved_at TIMESTAMP;
+
+-- 联合署名（仅展示）
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS co_authors JSONB NOT NULL DEFAULT '[]';
+
+-- 正文格式（markdown / html）
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS content_type VARCHAR(20) NOT NULL DEFAULT 'markdown';
+
+-- 年级标准值（1-12，0 为无法识别），由应用根据 grade 计算，grade 保留原始写法
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS grade_level SMALLINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_lessons_grade_level ON lessons(grade_level);
+
+-- 封面地址（上传目录内路径），可自动生成
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS cover_url VARCHAR(500);
+
+-- 自动归档时间：长期无互动的已发布教案被归档，作者恢复后清空
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+-- 教案全文检索：标题（权重 A）与正文文本（权重 B），simple 配置；
+-- 每个汉字两侧插入空格使其逐字成词，查询时连续汉字按短语（<->）匹配，见 repository/lesson_search.go
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', regexp_replace(coalesce(title, ''), '([\u3400-\u9fff\uf900-\ufaff])', ' \1 ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(coalesce(content ->> 'text', content::text, ''), '([\u3400-\u9fff\uf900-\ufaff])', ' \1 ', 'g')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_lessons_search_vector ON lessons USING gin (search_vector);
+
 -- 教案表索引
 CREATE INDEX idx_lessons_user_id ON lessons(user_id);
 CREATE INDEX idx_lessons_subject ON lessons(subject);
@@ -100,10 +135,31 @@ CREATE TABLE IF NOT EXISTS lesson_versions (
 ALTER TABLE lesson_versions ADD COLUMN IF NOT EXISTS deleted_at TIMESTAMP;
 CREATE INDEX IF NOT EXISTS idx_lesson_versions_deleted_at ON lesson_versions(deleted_at);
 
+-- 发布时的关键版本，不受版本保留数量限制
+ALTER TABLE lesson_versions ADD COLUMN IF NOT EXISTS is_key BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- 版本名称；命名的版本作为里程碑，不受版本保留数量限制
+ALTER TABLE lesson_versions ADD COLUMN IF NOT EXISTS label VARCHAR(50) NOT NULL DEFAULT '';
+
 -- 版本表索引
 CREATE INDEX idx_lesson_versions_lesson_id ON lesson_versions(lesson_id);
 CREATE INDEX idx_lesson_versions_created_at ON lesson_versions(created_at DESC);
 
+-- ==================== 教案编辑记录表 ====================
+-- 教案编辑操作记录，支持多步撤销/重做；undone 为 true 的记录可重做，新编辑会删除它们
+CREATE TABLE IF NOT EXISTS lesson_edits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    before JSONB NOT NULL,
+    after JSONB NOT NULL,
+    fields JSONB NOT NULL DEFAULT '[]',
+    undone BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_edits_lesson_created ON lesson_edits(lesson_id, created_at DESC);
+
 -- ==================== 教案评论表 ====================
 CREATE TABLE IF NOT EXISTS lesson_comments (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -125,6 +181,120 @@ CREATE INDEX idx_lesson_comments_lesson_id ON lesson_comments(lesson_id);
 CREATE INDEX idx_lesson_comments_user_id ON lesson_comments(user_id);
 CREATE INDEX idx_lesson_comments_parent_id ON lesson_comments(parent_id);
 
+-- 兼容旧库：评论点赞数，hot 排序使用
+ALTER TABLE lesson_comments ADD COLUMN IF NOT EXISTS like_count INTEGER NOT NULL DEFAULT 0;
+
+-- 评论点赞表
+CREATE TABLE IF NOT EXISTS lesson_comment_likes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    comment_id UUID NOT NULL REFERENCES lesson_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(user_id, comment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_likes_comment_id ON lesson_comment_likes(comment_id);
+
+-- 评论删除/恢复审计，content 保存操作时的评论内容快照
+CREATE TABLE IF NOT EXISTS lesson_comment_audits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    comment_id UUID NOT NULL,
+    lesson_id UUID NOT NULL,
+    actor_id UUID NOT NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('delete', 'restore')),
+    content TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_tenant_id ON lesson_comment_audits(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_comment_id ON lesson_comment_audits(comment_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_lesson_id ON lesson_comment_audits(lesson_id);
+
+-- ==================== 教案批注表 ====================
+CREATE TABLE IF NOT EXISTS lesson_annotations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field VARCHAR(30) NOT NULL CHECK (field IN ('title', 'objectives', 'content', 'activities', 'assessment', 'resources')),
+    start_offset INTEGER NOT NULL DEFAULT 0,
+    end_offset INTEGER NOT NULL DEFAULT 0,
+    quote TEXT,
+    content TEXT NOT NULL,
+    suggestion TEXT,
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    CHECK (start_offset >= 0 AND end_offset >= start_offset)
+);
+
+-- 批注表索引
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_lesson_field ON lesson_annotations(lesson_id, field, start_offset);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_user_id ON lesson_annotations(user_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_status ON lesson_annotations(status);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_deleted_at ON lesson_annotations(deleted_at);
+
+-- ==================== 教案分享表 ====================
+CREATE TABLE IF NOT EXISTS lesson_shares (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,
+    allow_download BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+-- 分享表索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lesson_shares_token ON lesson_shares(token);
+CREATE INDEX IF NOT EXISTS idx_lesson_shares_lesson_id ON lesson_shares(lesson_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_shares_deleted_at ON lesson_shares(deleted_at);
+
+-- ==================== 用户关注表 ====================
+CREATE TABLE IF NOT EXISTS user_follows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    following_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (follower_id <> following_id)
+);
+
+-- 关注表索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_follows_pair ON user_follows(follower_id, following_id);
+CREATE INDEX IF NOT EXISTS idx_user_follows_following_id ON user_follows(following_id);
+
+-- ==================== 用户设置表 ====================
+-- 用户偏好设置，每个用户至多一行；未保存过设置的用户由服务端返回默认值
+CREATE TABLE IF NOT EXISTS user_settings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    theme VARCHAR(20) NOT NULL DEFAULT 'light',
+    language VARCHAR(10) NOT NULL DEFAULT 'zh-CN',
+    email_notify BOOLEAN NOT NULL DEFAULT TRUE,
+    default_subject VARCHAR(50),
+    default_grade VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- ==================== 收藏夹表 ====================
+CREATE TABLE IF NOT EXISTS favorite_folders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 收藏夹表索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorite_folders_user_name ON favorite_folders(user_id, name);
+
 -- ==================== 教案收藏表 ====================
 CREATE TABLE IF NOT EXISTS lesson_favorites (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -139,6 +309,10 @@ CREATE TABLE IF NOT EXISTS lesson_favorites (
 CREATE INDEX idx_lesson_favorites_user_id ON lesson_favorites(user_id);
 CREATE INDEX idx_lesson_favorites_lesson_id ON lesson_favorites(lesson_id);
 
+-- 兼容旧库：收藏所属收藏夹，为空表示未分组
+ALTER TABLE lesson_favorites ADD COLUMN IF NOT EXISTS folder_id UUID REFERENCES favorite_folders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_lesson_favorites_folder_id ON lesson_favorites(folder_id);
+
 -- ==================== AI生成记录表 ====================
 CREATE TABLE IF NOT EXISTS generation_logs (
     id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
@@ -174,10 +348,11 @@ CREATE TABLE IF NOT EXISTS generations (
     id UUID PRIMARY KEY DEFAULT gen_random_uuid(),
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     lesson_id UUID REFERENCES lessons(id) ON DELETE SET NULL,
+    lesson_version INTEGER,
     prompt TEXT NOT NULL,
     parameters JSONB,
     result TEXT,
-    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
+    status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'review')),
     token_count INTEGER DEFAULT 0,
     duration_ms BIGINT DEFAULT 0,
     error_msg TEXT,
@@ -189,6 +364,81 @@ CREATE TABLE IF NOT EXISTS generations (
 CREATE INDEX idx_generations_user_id ON generations(user_id);
 CREATE INDEX idx_generations_status ON generations(status);
 CREATE INDEX idx_generations_created_at ON generations(created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_generations_lesson_id ON generations(lesson_id);
+-- 生成历史按提示词搜索
+CREATE INDEX IF NOT EXISTS idx_generations_prompt_trgm ON generations USING gin (prompt gin_trgm_ops);
+
+-- 兼容旧库：生成记录关联教案初始版本
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS lesson_version INTEGER;
+-- 生成各阶段事件日志
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS events JSONB NOT NULL DEFAULT '[]';
+-- 内容审核：未通过审核的生成记录进入 review 状态等待人工复核
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS review_reason TEXT;
+ALTER TABLE generations DROP CONSTRAINT IF EXISTS generations_status_check;
+ALTER TABLE generations ADD CONSTRAINT generations_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'review'));
+-- 重试生成：新生成记录指向被重试的原记录
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS retry_of UUID REFERENCES generations(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_generations_retry_of ON generations(retry_of);
+
+-- ==================== 生成参数预设表 ====================
+CREATE TABLE IF NOT EXISTS generation_presets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    subject VARCHAR(50),
+    grade VARCHAR(20),
+    duration INTEGER DEFAULT 45,
+    style VARCHAR(50),
+    difficulty VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+-- 预设表索引
+CREATE UNIQUE INDEX IF NOT EXISTS idx_generation_presets_user_name ON generation_presets(user_id, name);
+
+-- ==================== API Key 表 ====================
+-- 第三方集成使用的长期访问令牌，只保存哈希
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    last_used_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
+
+-- ==================== 通知表 ====================
+-- 评论通知，记录邮件发送状态，每日摘要按 pending 聚合
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    type VARCHAR(20) NOT NULL CHECK (type IN ('comment', 'reply')),
+    actor_id UUID NOT NULL,
+    actor_name VARCHAR(100),
+    lesson_id UUID NOT NULL,
+    lesson_title VARCHAR(200),
+    comment_id UUID NOT NULL,
+    content TEXT,
+    email_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (email_status IN ('pending', 'sent', 'skipped')),
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_id ON notifications(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_email_status ON notifications(email_status);
 
 -- ==================== 知识点映射表 ====================
 -- 用于PostgreSQL和Neo4j之间的映射
@@ -229,7 +479,7 @@ CREATE TABLE IF NOT EXISTS knowledge_documents (
     user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
     title VARCHAR(200) NOT NULL,
     file_name VARCHAR(255) NOT NULL,
-    file_type VARCHAR(20) NOT NULL CHECK (file_type IN ('txt', 'md')),
+    file_type VARCHAR(20) NOT NULL CHECK (file_type IN ('txt', 'md', 'pdf', 'docx')),
     file_size INTEGER NOT NULL,
     content TEXT NOT NULL,
     status VARCHAR(20) DEFAULT 'pending' CHECK (status IN ('pending', 'processing', 'completed', 'failed')),
@@ -248,10 +498,26 @@ CREATE INDEX idx_knowledge_documents_status ON knowledge_documents(status);
 CREATE INDEX idx_knowledge_documents_created_at ON knowledge_documents(created_at DESC);
 CREATE INDEX idx_knowledge_documents_subject ON knowledge_documents(subject);
 
+-- 兼容旧库：内容哈希用于重复上传检测
+ALTER TABLE knowledge_documents ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_user_hash ON knowledge_documents(user_id, content_hash);
+
 -- 知识文档更新触发器
 CREATE TRIGGER update_knowledge_documents_updated_at BEFORE UPDATE ON knowledge_documents
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+-- ==================== 多租户 ====================
+-- 兼容旧库：核心表按租户隔离，历史数据归入 default 租户
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE knowledge_documents ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_tenant_status ON lessons(tenant_id, status);
+CREATE INDEX IF NOT EXISTS idx_generations_tenant_id ON generations(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_tenant_id ON knowledge_documents(tenant_id);
+
 -- ==================== 触发器函数 ====================
 
 -- 更新 updated_at 字段的触发器函数
@@ -276,6 +542,9 @@ CREATE TRIGGER update_lesson_comments_updated_at BEFORE UPDATE ON lesson_comment
 CREATE TRIGGER update_knowledge_mappings_updated_at BEFORE UPDATE ON knowledge_mappings
     FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
 
+CREATE TRIGGER update_user_settings_updated_at BEFORE UPDATE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
 -- 教案版本自动递增触发器
 CREATE OR REPLACE FUNCTION increment_lesson_version()
 RETURNS TRIGGER AS $$
@@ -0,0 +1,24 @@
+-- Migration: 20261016090000_alter_lessons_add_draft_columns
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 已发布教案的编辑写入草稿列，再次发布时覆盖正式版
+-- Risk: low
+-- Notes: 草稿与正式版分离存储，回滚会丢弃未发布草稿
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE IF EXISTS lessons
+  ADD COLUMN IF NOT EXISTS draft_content TEXT;
+
+ALTER TABLE IF EXISTS lessons
+  ADD COLUMN IF NOT EXISTS draft_saved_at TIMESTAMP;
+
+-- [ROLLBACK]
+-- ALTER TABLE IF EXISTS lessons
+--   DROP COLUMN IF EXISTS draft_saved_at;
+--
+-- ALTER TABLE IF EXISTS lessons
+--   DROP COLUMN IF EXISTS draft_content;
+
+COMMIT;
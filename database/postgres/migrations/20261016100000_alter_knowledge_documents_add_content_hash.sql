@@ -0,0 +1,28 @@
+-- Migration: 20261016100000_alter_knowledge_documents_add_content_hash
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 知识文档增加内容哈希列，上传时检测重复文档并复用处理结果
+-- Risk: low
+-- Notes: 回填历史数据哈希；sha256() 需 PostgreSQL 11+
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE IF EXISTS knowledge_documents
+  ADD COLUMN IF NOT EXISTS content_hash VARCHAR(64);
+
+-- 历史数据回填：与后端 sha256(content) 十六进制结果一致
+UPDATE knowledge_documents
+  SET content_hash = encode(sha256(convert_to(content, 'UTF8')), 'hex')
+  WHERE content_hash IS NULL;
+
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_user_hash
+  ON knowledge_documents(user_id, content_hash);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_knowledge_documents_user_hash;
+--
+-- ALTER TABLE IF EXISTS knowledge_documents
+--   DROP COLUMN IF EXISTS content_hash;
+
+COMMIT;
@@ -0,0 +1,38 @@
+-- Migration: 20261016110000_create_lesson_annotations
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 新增教案批注表，支持针对字段/文本区间的批注与修订建议
+-- Risk: low
+-- Notes: 新表，回滚直接删除
+
+BEGIN;
+
+-- [FORWARD]
+CREATE TABLE IF NOT EXISTS lesson_annotations (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    field VARCHAR(30) NOT NULL CHECK (field IN ('title', 'objectives', 'content', 'activities', 'assessment', 'resources')),
+    start_offset INTEGER NOT NULL DEFAULT 0,
+    end_offset INTEGER NOT NULL DEFAULT 0,
+    quote TEXT,
+    content TEXT NOT NULL,
+    suggestion TEXT,
+    status VARCHAR(20) DEFAULT 'open' CHECK (status IN ('open', 'resolved')),
+    resolved_by UUID REFERENCES users(id) ON DELETE SET NULL,
+    resolved_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP,
+    CHECK (start_offset >= 0 AND end_offset >= start_offset)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_lesson_field ON lesson_annotations(lesson_id, field, start_offset);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_user_id ON lesson_annotations(user_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_status ON lesson_annotations(status);
+CREATE INDEX IF NOT EXISTS idx_lesson_annotations_deleted_at ON lesson_annotations(deleted_at);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS lesson_annotations;
+
+COMMIT;
@@ -0,0 +1,30 @@
+-- Migration: 20261016120000_create_lesson_shares
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 新增教案分享链接表，支持免登录只读访问、过期时间与下载开关
+-- Risk: low
+-- Notes: 新表，回滚直接删除
+
+BEGIN;
+
+-- [FORWARD]
+CREATE TABLE IF NOT EXISTS lesson_shares (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    token VARCHAR(64) NOT NULL,
+    allow_download BOOLEAN NOT NULL DEFAULT FALSE,
+    expires_at TIMESTAMP,
+    view_count INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_lesson_shares_token ON lesson_shares(token);
+CREATE INDEX IF NOT EXISTS idx_lesson_shares_lesson_id ON lesson_shares(lesson_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_shares_deleted_at ON lesson_shares(deleted_at);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS lesson_shares;
+
+COMMIT;
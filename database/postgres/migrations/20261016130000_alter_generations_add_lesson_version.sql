@@ -0,0 +1,19 @@
+-- Migration: 20261016130000_alter_generations_add_lesson_version
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 生成记录关联由其创建的教案初始版本，支持追溯教案来源
+-- Risk: low
+-- Notes: 仅新增可空列与索引，历史记录保持为空
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS lesson_version INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_generations_lesson_id ON generations(lesson_id);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_generations_lesson_id;
+-- ALTER TABLE generations DROP COLUMN IF EXISTS lesson_version;
+
+COMMIT;
@@ -0,0 +1,31 @@
+-- Migration: 20261016140000_alter_core_tables_add_tenant_id
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 用户、教案、生成记录、知识文档新增 tenant_id，支持多校共用部署的数据隔离
+-- Risk: medium
+-- Notes: 历史数据归入 default 租户；大表加带默认值的列在 PG11+ 为元数据操作
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE users ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+ALTER TABLE knowledge_documents ADD COLUMN IF NOT EXISTS tenant_id VARCHAR(64) NOT NULL DEFAULT 'default';
+
+CREATE INDEX IF NOT EXISTS idx_users_tenant_id ON users(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_lessons_tenant_status ON lessons(tenant_id, status);
+CREATE INDEX IF NOT EXISTS idx_generations_tenant_id ON generations(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_knowledge_documents_tenant_id ON knowledge_documents(tenant_id);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_knowledge_documents_tenant_id;
+-- DROP INDEX IF EXISTS idx_generations_tenant_id;
+-- DROP INDEX IF EXISTS idx_lessons_tenant_status;
+-- DROP INDEX IF EXISTS idx_users_tenant_id;
+-- ALTER TABLE knowledge_documents DROP COLUMN IF EXISTS tenant_id;
+-- ALTER TABLE generations DROP COLUMN IF EXISTS tenant_id;
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS tenant_id;
+-- ALTER TABLE users DROP COLUMN IF EXISTS tenant_id;
+
+COMMIT;
@@ -0,0 +1,25 @@
+-- Migration: 20261016150000_create_user_follows
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 新增用户关注关系表，支持关注同行并查看其新发布教案
+-- Risk: low
+-- Notes: 新表，回滚直接删除
+
+BEGIN;
+
+-- [FORWARD]
+CREATE TABLE IF NOT EXISTS user_follows (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    follower_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    following_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    CHECK (follower_id <> following_id)
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_user_follows_pair ON user_follows(follower_id, following_id);
+CREATE INDEX IF NOT EXISTS idx_user_follows_following_id ON user_follows(following_id);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS user_follows;
+
+COMMIT;
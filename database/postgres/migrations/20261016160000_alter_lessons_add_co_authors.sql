@@ -0,0 +1,16 @@
+-- Migration: 20261016160000_alter_lessons_add_co_authors
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 教案新增联合署名字段，仅用于展示多作者
+-- Risk: low
+-- Notes: 仅新增带默认值的列，历史教案署名为空
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS co_authors JSONB NOT NULL DEFAULT '[]';
+
+-- [ROLLBACK]
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS co_authors;
+
+COMMIT;
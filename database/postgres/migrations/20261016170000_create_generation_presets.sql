@@ -0,0 +1,29 @@
+-- Migration: 20261016170000_create_generation_presets
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 新增生成参数预设表，保存常用的学科/年级/风格组合
+-- Risk: low
+-- Notes: 新表，回滚直接删除
+
+BEGIN;
+
+-- [FORWARD]
+CREATE TABLE IF NOT EXISTS generation_presets (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    subject VARCHAR(50),
+    grade VARCHAR(20),
+    duration INTEGER DEFAULT 45,
+    style VARCHAR(50),
+    difficulty VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_generation_presets_user_name ON generation_presets(user_id, name);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS generation_presets;
+
+COMMIT;
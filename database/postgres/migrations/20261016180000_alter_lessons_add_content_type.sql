@@ -0,0 +1,16 @@
+-- Migration: 20261016180000_alter_lessons_add_content_type
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 教案新增正文格式字段，区分富文本 HTML 与 Markdown
+-- Risk: low
+-- Notes: 仅新增带默认值的列，历史教案按 markdown 处理
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS content_type VARCHAR(20) NOT NULL DEFAULT 'markdown';
+
+-- [ROLLBACK]
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS content_type;
+
+COMMIT;
@@ -0,0 +1,16 @@
+-- Migration: 20261016190000_alter_lesson_versions_add_is_key
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 版本表新增关键版本标记，配合版本保留数量限制
+-- Risk: low
+-- Notes: 历史版本均视为普通版本，超出 lesson.max_versions 时会被清理
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE lesson_versions ADD COLUMN IF NOT EXISTS is_key BOOLEAN NOT NULL DEFAULT FALSE;
+
+-- [ROLLBACK]
+-- ALTER TABLE lesson_versions DROP COLUMN IF EXISTS is_key;
+
+COMMIT;
@@ -0,0 +1,39 @@
+-- Migration: 20261016200000_alter_lessons_add_grade_level
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 教案新增年级标准值字段，统一“七年级/初一/Grade 7”等写法用于筛选与排序
+-- Risk: low
+-- Notes: 新增带默认值的列并回填常见写法，原 grade 列保留为展示值
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS grade_level SMALLINT NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS idx_lessons_grade_level ON lessons(grade_level);
+
+-- 回填常见写法（去空白、小写、去掉上下册后缀后匹配）；其余写法在教案下次保存时由应用归一化
+UPDATE lessons l
+SET grade_level = m.level
+FROM (VALUES
+    ('一年级', 1), ('1年级', 1), ('grade1', 1), ('g1', 1), ('year1', 1), ('1', 1), ('二年级', 2), ('2年级', 2),
+    ('grade2', 2), ('g2', 2), ('year2', 2), ('2', 2), ('三年级', 3), ('3年级', 3), ('grade3', 3), ('g3', 3),
+    ('year3', 3), ('3', 3), ('四年级', 4), ('4年级', 4), ('grade4', 4), ('g4', 4), ('year4', 4), ('4', 4),
+    ('五年级', 5), ('5年级', 5), ('grade5', 5), ('g5', 5), ('year5', 5), ('5', 5), ('六年级', 6), ('6年级', 6),
+    ('grade6', 6), ('g6', 6), ('year6', 6), ('6', 6), ('七年级', 7), ('7年级', 7), ('grade7', 7), ('g7', 7),
+    ('year7', 7), ('7', 7), ('八年级', 8), ('8年级', 8), ('grade8', 8), ('g8', 8), ('year8', 8), ('8', 8),
+    ('九年级', 9), ('9年级', 9), ('grade9', 9), ('g9', 9), ('year9', 9), ('9', 9), ('十年级', 10), ('10年级', 10),
+    ('grade10', 10), ('g10', 10), ('year10', 10), ('10', 10), ('十一年级', 11), ('11年级', 11), ('grade11', 11),
+    ('g11', 11), ('year11', 11), ('11', 11), ('十二年级', 12), ('12年级', 12), ('grade12', 12), ('g12', 12),
+    ('year12', 12), ('12', 12), ('小学一年级', 1), ('小一', 1), ('小学二年级', 2), ('小二', 2), ('小学三年级', 3), ('小三', 3),
+    ('小学四年级', 4), ('小四', 4), ('小学五年级', 5), ('小五', 5), ('小学六年级', 6), ('小六', 6), ('初中一年级', 7), ('初一', 7),
+    ('初1', 7), ('高中一年级', 10), ('高一', 10), ('高1', 10), ('初中二年级', 8), ('初二', 8), ('初2', 8), ('高中二年级', 11),
+    ('高二', 11), ('高2', 11), ('初中三年级', 9), ('初三', 9), ('初3', 9), ('高中三年级', 12), ('高三', 12), ('高3', 12)
+) AS m(alias, level)
+WHERE l.grade_level = 0
+  AND regexp_replace(lower(regexp_replace(l.grade, '\s+', '', 'g')), '(\(.*\)|（.*）|[上下](学期|册)?)$', '') = m.alias;
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_lessons_grade_level;
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS grade_level;
+
+COMMIT;
@@ -0,0 +1,32 @@
+-- Migration: 20261016210000_create_api_keys
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 新增 API Key 表，供第三方系统以长期令牌访问接口
+-- Risk: low
+-- Notes: 新表，只存 SHA-256 哈希；回滚直接删除
+
+BEGIN;
+
+-- [FORWARD]
+CREATE TABLE IF NOT EXISTS api_keys (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    name VARCHAR(100) NOT NULL,
+    prefix VARCHAR(16) NOT NULL,
+    key_hash VARCHAR(64) NOT NULL,
+    scopes JSONB NOT NULL DEFAULT '[]',
+    last_used_at TIMESTAMP,
+    expires_at TIMESTAMP,
+    revoked_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_api_keys_key_hash ON api_keys(key_hash);
+CREATE INDEX IF NOT EXISTS idx_api_keys_user_id ON api_keys(user_id);
+CREATE INDEX IF NOT EXISTS idx_api_keys_tenant_id ON api_keys(tenant_id);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS api_keys;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Migration: 20261016220000_add_generations_prompt_search_index
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 为生成记录提示词添加 trigram 索引，支持历史全文搜索
+-- Risk: low
+-- Notes: 需要 pg_trgm 扩展；大表建议改用 CREATE INDEX CONCURRENTLY 在事务外执行；回滚保留扩展
+
+BEGIN;
+
+-- [FORWARD]
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+-- 生成历史按提示词搜索（ILIKE），trigram 索引对中文同样有效
+CREATE INDEX IF NOT EXISTS idx_generations_prompt_trgm ON generations USING gin (prompt gin_trgm_ops);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_generations_prompt_trgm;
+
+COMMIT;
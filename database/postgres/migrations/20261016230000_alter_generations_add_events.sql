@@ -0,0 +1,17 @@
+-- Migration: 20261016230000_alter_generations_add_events
+-- Author: team-backend
+-- Date(UTC): 2026-10-16
+-- Description: 生成记录新增阶段事件日志
+-- Risk: low
+-- Notes: 带默认值的新增列，PG11+ 不重写表；回滚丢弃事件数据
+
+BEGIN;
+
+-- [FORWARD]
+-- 生成各阶段事件（开始、调用 Agent、解析、保存），用于分析耗时分布
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS events JSONB NOT NULL DEFAULT '[]';
+
+-- [ROLLBACK]
+-- ALTER TABLE generations DROP COLUMN IF EXISTS events;
+
+COMMIT;
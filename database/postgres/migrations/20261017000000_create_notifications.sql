@@ -0,0 +1,39 @@
+-- Migration: 20261017000000_create_notifications
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增评论通知表与用户通知偏好
+-- Risk: low
+-- Notes: 新表与带默认值的新增列，不重写表；回滚丢弃通知记录与偏好
+
+BEGIN;
+
+-- [FORWARD]
+-- 评论通知邮件偏好：realtime 实时、daily 每日摘要、off 关闭
+ALTER TABLE users ADD COLUMN IF NOT EXISTS notify_mode VARCHAR(20) NOT NULL DEFAULT 'realtime';
+
+-- 评论通知，记录邮件发送状态，每日摘要按 pending 聚合
+CREATE TABLE IF NOT EXISTS notifications (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    type VARCHAR(20) NOT NULL CHECK (type IN ('comment', 'reply')),
+    actor_id UUID NOT NULL,
+    actor_name VARCHAR(100),
+    lesson_id UUID NOT NULL,
+    lesson_title VARCHAR(200),
+    comment_id UUID NOT NULL,
+    content TEXT,
+    email_status VARCHAR(20) NOT NULL DEFAULT 'pending' CHECK (email_status IN ('pending', 'sent', 'skipped')),
+    emailed_at TIMESTAMP,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_notifications_user_id ON notifications(user_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_tenant_id ON notifications(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_notifications_email_status ON notifications(email_status);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS notifications;
+-- ALTER TABLE users DROP COLUMN IF EXISTS notify_mode;
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- Migration: 20261017010000_create_lesson_edits
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增教案编辑操作记录表（撤销/重做栈）
+-- Risk: low
+-- Notes: 新表，每个教案仅保留最近 50 条；回滚丢弃编辑记录
+
+BEGIN;
+
+-- [FORWARD]
+-- 教案编辑操作记录，支持多步撤销/重做；undone 为 true 的记录可重做，新编辑会删除它们
+CREATE TABLE IF NOT EXISTS lesson_edits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    lesson_id UUID NOT NULL REFERENCES lessons(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    before JSONB NOT NULL,
+    after JSONB NOT NULL,
+    fields JSONB NOT NULL DEFAULT '[]',
+    undone BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_edits_lesson_created ON lesson_edits(lesson_id, created_at DESC);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS lesson_edits;
+
+COMMIT;
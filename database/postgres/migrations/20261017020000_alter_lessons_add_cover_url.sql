@@ -0,0 +1,17 @@
+-- Migration: 20261017020000_alter_lessons_add_cover_url
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 教案新增封面地址
+-- Risk: low
+-- Notes: 可空新增列，不重写表；回滚丢弃封面地址，封面文件需手动清理
+
+BEGIN;
+
+-- [FORWARD]
+-- 教案封面地址（上传目录内路径），可由 POST /lessons/:id/auto-cover 自动生成
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS cover_url VARCHAR(500);
+
+-- [ROLLBACK]
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS cover_url;
+
+COMMIT;
@@ -0,0 +1,24 @@
+-- Migration: 20261017030000_alter_generations_add_review
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 生成记录新增人工复核状态与审核原因
+-- Risk: medium
+-- Notes: 替换 status CHECK 约束；新增列可空不重写表；回滚前将待复核记录标记为失败
+
+BEGIN;
+
+-- [FORWARD]
+-- 内容审核：未通过审核的生成记录进入 review 状态，等待人工复核
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS review_reason TEXT;
+ALTER TABLE generations DROP CONSTRAINT IF EXISTS generations_status_check;
+ALTER TABLE generations ADD CONSTRAINT generations_status_check
+    CHECK (status IN ('pending', 'processing', 'completed', 'failed', 'review'));
+
+-- [ROLLBACK]
+-- UPDATE generations SET status = 'failed', error_msg = '内容未通过人工复核' WHERE status = 'review';
+-- ALTER TABLE generations DROP CONSTRAINT IF EXISTS generations_status_check;
+-- ALTER TABLE generations ADD CONSTRAINT generations_status_check
+--     CHECK (status IN ('pending', 'processing', 'completed', 'failed'));
+-- ALTER TABLE generations DROP COLUMN IF EXISTS review_reason;
+
+COMMIT;
@@ -0,0 +1,17 @@
+-- Migration: 20261017040000_add_lesson_versions_label
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 教案版本增加名称（里程碑）
+-- Risk: low
+-- Notes: 新增列带默认值，对已有版本无影响；回滚会丢失已设置的版本名称
+
+BEGIN;
+
+-- [FORWARD]
+-- 版本名称；命名的版本作为里程碑，不受版本保留数量限制
+ALTER TABLE lesson_versions ADD COLUMN IF NOT EXISTS label VARCHAR(50) NOT NULL DEFAULT '';
+
+-- [ROLLBACK]
+-- ALTER TABLE lesson_versions DROP COLUMN IF EXISTS label;
+
+COMMIT;
@@ -0,0 +1,19 @@
+-- Migration: 20261017050000_alter_generations_add_retry_of
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 生成记录新增重试来源 retry_of
+-- Risk: low
+-- Notes: 新增列可空不重写表；原记录删除时置空
+
+BEGIN;
+
+-- [FORWARD]
+-- 重试生成：新生成记录指向被重试的原记录
+ALTER TABLE generations ADD COLUMN IF NOT EXISTS retry_of UUID REFERENCES generations(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_generations_retry_of ON generations(retry_of);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_generations_retry_of;
+-- ALTER TABLE generations DROP COLUMN IF EXISTS retry_of;
+
+COMMIT;
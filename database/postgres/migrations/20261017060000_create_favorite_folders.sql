@@ -0,0 +1,29 @@
+-- Migration: 20261017060000_create_favorite_folders
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增收藏夹表，收藏记录关联收藏夹
+-- Risk: low
+-- Notes: 新建表与可空新增列，不重写表；删除收藏夹时收藏移出到未分组；回滚丢弃收藏夹分组
+
+BEGIN;
+
+-- [FORWARD]
+-- 收藏夹：同一用户下名称唯一
+CREATE TABLE IF NOT EXISTS favorite_folders (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_favorite_folders_user_name ON favorite_folders(user_id, name);
+
+-- 收藏所属收藏夹，为空表示未分组
+ALTER TABLE lesson_favorites ADD COLUMN IF NOT EXISTS folder_id UUID REFERENCES favorite_folders(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_lesson_favorites_folder_id ON lesson_favorites(folder_id);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_lesson_favorites_folder_id;
+-- ALTER TABLE lesson_favorites DROP COLUMN IF EXISTS folder_id;
+-- DROP TABLE IF EXISTS favorite_folders;
+
+COMMIT;
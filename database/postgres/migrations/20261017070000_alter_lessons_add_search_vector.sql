@@ -0,0 +1,23 @@
+-- Migration: 20261017070000_alter_lessons_add_search_vector
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 教案新增全文检索 tsvector 生成列与 GIN 索引
+-- Risk: medium
+-- Notes: 需要 PostgreSQL 12+；添加 STORED 生成列会重写 lessons 表并持有排他锁，需在低峰期执行；汉字范围须与 lesson_search.go 中 isSearchHan 一致
+
+BEGIN;
+
+-- [FORWARD]
+-- 教案全文检索：标题（权重 A）与正文文本（权重 B），simple 配置；
+-- 每个汉字两侧插入空格使其逐字成词，查询时连续汉字按短语（<->）匹配，见 repository/lesson_search.go
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS search_vector TSVECTOR GENERATED ALWAYS AS (
+    setweight(to_tsvector('simple', regexp_replace(coalesce(title, ''), '([\u3400-\u9fff\uf900-\ufaff])', ' \1 ', 'g')), 'A') ||
+    setweight(to_tsvector('simple', regexp_replace(coalesce(content ->> 'text', content::text, ''), '([\u3400-\u9fff\uf900-\ufaff])', ' \1 ', 'g')), 'B')
+) STORED;
+CREATE INDEX IF NOT EXISTS idx_lessons_search_vector ON lessons USING gin (search_vector);
+
+-- [ROLLBACK]
+-- DROP INDEX IF EXISTS idx_lessons_search_vector;
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS search_vector;
+
+COMMIT;
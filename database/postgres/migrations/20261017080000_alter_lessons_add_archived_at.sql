@@ -0,0 +1,17 @@
+-- Migration: 20261017080000_alter_lessons_add_archived_at
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 教案新增自动归档时间 archived_at
+-- Risk: low
+-- Notes: 新增可空列不重写表
+
+BEGIN;
+
+-- [FORWARD]
+-- 自动归档：长期无互动的已发布教案归档时间，作者恢复后清空
+ALTER TABLE lessons ADD COLUMN IF NOT EXISTS archived_at TIMESTAMP;
+
+-- [ROLLBACK]
+-- ALTER TABLE lessons DROP COLUMN IF EXISTS archived_at;
+
+COMMIT;
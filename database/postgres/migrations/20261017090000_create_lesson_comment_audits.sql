@@ -0,0 +1,31 @@
+-- Migration: 20261017090000_create_lesson_comment_audits
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增评论删除/恢复审计表
+-- Risk: low
+-- Notes: 新建表，不影响现有数据；不设外键以便评论或教案被物理删除后审计仍保留
+
+BEGIN;
+
+-- [FORWARD]
+-- 评论删除/恢复审计，content 保存操作时的评论内容快照
+CREATE TABLE IF NOT EXISTS lesson_comment_audits (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    tenant_id VARCHAR(64) NOT NULL DEFAULT 'default',
+    comment_id UUID NOT NULL,
+    lesson_id UUID NOT NULL,
+    actor_id UUID NOT NULL,
+    actor_role VARCHAR(20) NOT NULL,
+    action VARCHAR(20) NOT NULL CHECK (action IN ('delete', 'restore')),
+    content TEXT,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_tenant_id ON lesson_comment_audits(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_comment_id ON lesson_comment_audits(comment_id);
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_audits_lesson_id ON lesson_comment_audits(lesson_id);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS lesson_comment_audits;
+
+COMMIT;
@@ -0,0 +1,20 @@
+-- Migration: 20261017100000_alter_users_add_email_verified_at
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 用户新增注册邮箱验证时间 email_verified_at
+-- Risk: medium
+-- Notes: 新增可空列并回填存量用户，UPDATE 扫描全表，用户量大时请在低峰期执行
+
+BEGIN;
+
+-- [FORWARD]
+-- 注册邮箱验证时间：自助注册的用户验证前为空且状态为 inactive
+ALTER TABLE users ADD COLUMN IF NOT EXISTS email_verified_at TIMESTAMP;
+
+-- 存量用户视为已验证，避免被管理员停用的 inactive 账号被误判为待验证
+UPDATE users SET email_verified_at = created_at WHERE email_verified_at IS NULL;
+
+-- [ROLLBACK]
+-- ALTER TABLE users DROP COLUMN IF EXISTS email_verified_at;
+
+COMMIT;
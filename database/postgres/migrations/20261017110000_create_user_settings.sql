@@ -0,0 +1,31 @@
+-- Migration: 20261017110000_create_user_settings
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增用户设置表
+-- Risk: low
+-- Notes: 新建表，不影响现有数据；user_id 唯一，服务端按 user_id upsert
+
+BEGIN;
+
+-- [FORWARD]
+-- 用户偏好设置，每个用户至多一行；未保存过设置的用户由服务端返回默认值
+CREATE TABLE IF NOT EXISTS user_settings (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    user_id UUID NOT NULL UNIQUE REFERENCES users(id) ON DELETE CASCADE,
+    theme VARCHAR(20) NOT NULL DEFAULT 'light',
+    language VARCHAR(10) NOT NULL DEFAULT 'zh-CN',
+    email_notify BOOLEAN NOT NULL DEFAULT TRUE,
+    default_subject VARCHAR(50),
+    default_grade VARCHAR(20),
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+);
+
+DROP TRIGGER IF EXISTS update_user_settings_updated_at ON user_settings;
+CREATE TRIGGER update_user_settings_updated_at BEFORE UPDATE ON user_settings
+    FOR EACH ROW EXECUTE FUNCTION update_updated_at_column();
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS user_settings;
+
+COMMIT;
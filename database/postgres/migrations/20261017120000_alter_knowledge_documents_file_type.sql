@@ -0,0 +1,22 @@
+-- Migration: 20261017120000_alter_knowledge_documents_file_type
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 知识文档允许 PDF、DOCX 文件类型
+-- Risk: low
+-- Notes: 替换 file_type CHECK 约束；回滚时 pdf/docx 文档的内容已是纯文本，改记为 txt
+
+BEGIN;
+
+-- [FORWARD]
+-- 知识文档支持 PDF、DOCX，上传时提取为纯文本存入 content
+ALTER TABLE knowledge_documents DROP CONSTRAINT IF EXISTS knowledge_documents_file_type_check;
+ALTER TABLE knowledge_documents ADD CONSTRAINT knowledge_documents_file_type_check
+    CHECK (file_type IN ('txt', 'md', 'pdf', 'docx'));
+
+-- [ROLLBACK]
+-- UPDATE knowledge_documents SET file_type = 'txt' WHERE file_type IN ('pdf', 'docx');
+-- ALTER TABLE knowledge_documents DROP CONSTRAINT IF EXISTS knowledge_documents_file_type_check;
+-- ALTER TABLE knowledge_documents ADD CONSTRAINT knowledge_documents_file_type_check
+--     CHECK (file_type IN ('txt', 'md'));
+
+COMMIT;
@@ -0,0 +1,28 @@
+-- Migration: 20261017130000_create_lesson_comment_likes
+-- Author: team-backend
+-- Date(UTC): 2026-10-17
+-- Description: 新增评论点赞表与评论点赞数，评论 hot 排序按点赞数
+-- Risk: low
+-- Notes: 新建表与带默认值的新增列，不重写表；回滚丢弃评论点赞记录
+
+BEGIN;
+
+-- [FORWARD]
+ALTER TABLE lesson_comments ADD COLUMN IF NOT EXISTS like_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS lesson_comment_likes (
+    id UUID PRIMARY KEY DEFAULT uuid_generate_v4(),
+    comment_id UUID NOT NULL REFERENCES lesson_comments(id) ON DELETE CASCADE,
+    user_id UUID NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+
+    UNIQUE(user_id, comment_id)
+);
+
+CREATE INDEX IF NOT EXISTS idx_lesson_comment_likes_comment_id ON lesson_comment_likes(comment_id);
+
+-- [ROLLBACK]
+-- DROP TABLE IF EXISTS lesson_comment_likes;
+-- ALTER TABLE lesson_comments DROP COLUMN IF EXISTS like_count;
+
+COMMIT;
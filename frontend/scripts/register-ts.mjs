@@ -0,0 +1,4 @@
+// 通过 node --import 注册 .ts 加载钩子
+import { register } from 'node:module';
+
+register('./ts-loader.mjs', import.meta.url);
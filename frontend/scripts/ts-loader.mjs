@@ -0,0 +1,25 @@
+// Node 模块加载钩子：用 TypeScript 编译器把 .ts 文件转为 ES 模块，供 node --test 直接运行单元测试
+import { readFile } from 'node:fs/promises';
+import ts from 'typescript';
+
+export async function resolve(specifier, context, nextResolve) {
+  if (specifier.endsWith('.ts') && (specifier.startsWith('.') || specifier.startsWith('file:'))) {
+    return { url: new URL(specifier, context.parentURL).href, format: 'module', shortCircuit: true };
+  }
+  return nextResolve(specifier, context);
+}
+
+export async function load(url, context, nextLoad) {
+  if (!url.endsWith('.ts')) {
+    return nextLoad(url, context);
+  }
+  const source = await readFile(new URL(url), 'utf8');
+  const { outputText } = ts.transpileModule(source, {
+    fileName: url,
+    compilerOptions: {
+      module: ts.ModuleKind.ESNext,
+      target: ts.ScriptTarget.ES2020,
+    },
+  });
+  return { format: 'module', source: outputText, shortCircuit: true };
+}
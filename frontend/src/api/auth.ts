@@ -50,6 +50,195 @@ export async function updateProfile(data: Partial<User>): Promise<User> {
   return response.data.data;
 }
 
+export interface EmailChangeResult {
+  pending_email: string;
+  expires_at: number;
+}
+
+/**
+ * 发起邮箱修改：向新邮箱发送验证链接，确认后才生效
+ */
+export async function requestEmailChange(email: string): Promise<EmailChangeResult> {
+  const response = await api.post<ApiResponse<EmailChangeResult>>('/users/email/change', { email });
+  return response.data.data;
+}
+
+/**
+ * 验证注册邮箱并激活账号（验证链接落地页调用）
+ */
+export async function verifyEmail(token: string): Promise<User> {
+  const response = await api.get<ApiResponse<User>>('/auth/verify', { params: { token } });
+  return response.data.data;
+}
+
+/**
+ * 重新发送注册验证邮件
+ */
+export async function resendVerification(email: string): Promise<void> {
+  await api.post('/auth/resend-verification', { email });
+}
+
+/**
+ * 发送重置密码邮件，邮箱是否注册都返回成功
+ */
+export async function forgotPassword(email: string): Promise<void> {
+  await api.post('/auth/forgot-password', { email });
+}
+
+/**
+ * 通过邮件中的一次性链接重置密码
+ */
+export async function resetPassword(token: string, newPassword: string): Promise<void> {
+  await api.post('/auth/reset-password', { token, new_password: newPassword });
+}
+
+/**
+ * 确认邮箱修改（验证链接落地页调用）
+ */
+export async function confirmEmailChange(token: string): Promise<EmailChangeResult> {
+  const response = await api.post<ApiResponse<EmailChangeResult>>('/auth/confirm-email', { token });
+  return response.data.data;
+}
+
+export type ApiKeyScope = 'read' | 'write' | 'generate';
+
+export interface ApiKeyInfo {
+  id: string;
+  name: string;
+  prefix: string;
+  scopes: ApiKeyScope[];
+  active: boolean;
+  last_used_at?: string;
+  expires_at?: string;
+  revoked_at?: string;
+  created_at: string;
+}
+
+/**
+ * 获取当前用户的 API Key 列表
+ */
+export async function listApiKeys(): Promise<ApiKeyInfo[]> {
+  const response = await api.get<ApiResponse<ApiKeyInfo[]>>('/api-keys');
+  return response.data.data;
+}
+
+/**
+ * 创建 API Key，返回的 key 明文只出现这一次
+ */
+export async function createApiKey(data: {
+  name: string;
+  scopes?: ApiKeyScope[];
+  expires_in_days?: number;
+}): Promise<ApiKeyInfo & { key: string }> {
+  const response = await api.post<ApiResponse<ApiKeyInfo & { key: string }>>('/api-keys', data);
+  return response.data.data;
+}
+
+/**
+ * 吊销 API Key
+ */
+export async function revokeApiKey(id: string): Promise<void> {
+  await api.delete(`/api-keys/${id}`);
+}
+
+export interface UserImportRow {
+  line: number;
+  username: string;
+  email: string;
+  status: 'created' | 'skipped' | 'failed';
+  reason?: string;
+  initial_password?: string;
+  notified: boolean;
+}
+
+export interface UserImportReport {
+  total: number;
+  created: number;
+  skipped: number;
+  failed: number;
+  rows: UserImportRow[];
+}
+
+/**
+ * 管理员上传 CSV 批量创建用户（表头：username,email,full_name,role）
+ */
+export async function importUsers(file: File): Promise<UserImportReport> {
+  const formData = new FormData();
+  formData.append('file', file);
+  const response = await api.post<ApiResponse<UserImportReport>>('/admin/users/import', formData, {
+    headers: {
+      'Content-Type': 'multipart/form-data',
+    },
+  });
+  return response.data.data;
+}
+
+/**
+ * 上传头像（JPEG/PNG/WebP），返回保存后的访问地址
+ */
+export async function uploadAvatar(file: File): Promise<string> {
+  const formData = new FormData();
+  formData.append('avatar', file);
+  const response = await api.post<ApiResponse<{ avatar_url: string }>>('/users/avatar', formData, {
+    headers: {
+      'Content-Type': 'multipart/form-data',
+    },
+  });
+  return response.data.data.avatar_url;
+}
+
+/**
+ * 导出当前用户的全部个人数据（JSON 文件）
+ */
+export async function exportMyData(): Promise<Blob> {
+  const response = await api.get<Blob>('/users/me/data-export', { responseType: 'blob' });
+  return response.data;
+}
+
+/** 评论通知偏好：实时邮件、每日摘要、关闭 */
+export type NotifyMode = 'realtime' | 'daily' | 'off';
+
+/**
+ * 获取评论通知偏好
+ */
+export async function getNotificationPreference(): Promise<NotifyMode> {
+  const response = await api.get<ApiResponse<{ mode: NotifyMode }>>('/users/notification-preference');
+  return response.data.data.mode;
+}
+
+/**
+ * 修改评论通知偏好
+ */
+export async function updateNotificationPreference(mode: NotifyMode): Promise<NotifyMode> {
+  const response = await api.put<ApiResponse<{ mode: NotifyMode }>>('/users/notification-preference', { mode });
+  return response.data.data.mode;
+}
+
+/** 用户设置，未保存过时后端返回默认值 */
+export interface UserSettings {
+  theme: 'light' | 'dark' | 'auto';
+  language: 'zh-CN' | 'en-US';
+  email_notify: boolean;
+  default_subject: string;
+  default_grade: string;
+}
+
+/**
+ * 获取用户设置
+ */
+export async function getUserSettings(): Promise<UserSettings> {
+  const response = await api.get<ApiResponse<UserSettings>>('/users/settings');
+  return response.data.data;
+}
+
+/**
+ * 更新用户设置，只提交需要修改的字段
+ */
+export async function updateUserSettings(data: Partial<UserSettings>): Promise<UserSettings> {
+  const response = await api.put<ApiResponse<UserSettings>>('/users/settings', data);
+  return response.data.data;
+}
+
 /**
  * 修改密码
  */
@@ -58,8 +247,8 @@ export async function changePassword(data: { oldPassword: string; newPassword: s
 }
 
 /**
- * 退出登录
+ * 退出登录：服务端作废当前访问令牌，传入刷新令牌时一并作废
  */
-export async function logout(): Promise<void> {
-  await api.post('/auth/logout');
+export async function logout(refreshToken?: string): Promise<void> {
+  await api.post('/auth/logout', refreshToken ? { refresh_token: refreshToken } : undefined);
 }
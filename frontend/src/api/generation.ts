@@ -3,7 +3,9 @@ import api, { agentApi } from './index';
 import { useAuthStore } from '@/stores/auth';
 import type { 
   GenerateLessonRequest,
-  ApiResponse
+  ApiResponse,
+  GenerationPreset,
+  SaveGenerationPresetRequest
 } from '@/types';
 
 // 后端返回的生成响应结构
@@ -19,9 +21,16 @@ interface BackendGenerationResponse {
   activities?: string;
   assessment?: string;
   resources?: string;
+  // 根据主题与关键词自动生成的标签
+  tags?: string[];
   token_count: number;
   duration_ms: number;
   error_message?: string;
+  // status 为 fallback 时的提示：AI 不可用，返回的是待填写的骨架模板；partial 时列出需补充的内容
+  notice?: string;
+  // Agent 响应部分字段解析失败，只保留了其余内容
+  partial?: boolean;
+  missing_fields?: string[];
 }
 
 /**
@@ -34,6 +43,29 @@ export async function generateLesson(
   return response.data.data;
 }
 
+/**
+ * 获取生成参数预设
+ */
+export async function listGenerationPresets(): Promise<GenerationPreset[]> {
+  const response = await api.get<ApiResponse<GenerationPreset[]>>('/generate/presets');
+  return response.data.data || [];
+}
+
+/**
+ * 保存生成参数预设（同名覆盖）
+ */
+export async function saveGenerationPreset(payload: SaveGenerationPresetRequest): Promise<GenerationPreset> {
+  const response = await api.post<ApiResponse<GenerationPreset>>('/generate/presets', payload);
+  return response.data.data;
+}
+
+/**
+ * 删除生成参数预设
+ */
+export async function deleteGenerationPreset(id: string): Promise<void> {
+  await api.delete(`/generate/presets/${id}`);
+}
+
 // 统计数据结构（与后端GenerationStats对应）
 export interface DashboardStats {
   total_count: number;
@@ -54,6 +86,40 @@ export async function getGenerationStats(): Promise<DashboardStats> {
 }
 
 
+export interface GenerationTimeBucket {
+  bucket_start: string;
+  count: number;
+  tokens: number;
+}
+
+export interface GenerationTimeseries {
+  granularity: 'day' | 'week';
+  range: string;
+  since: string;
+  points: GenerationTimeBucket[];
+}
+
+/**
+ * 获取按天/周聚合的生成次数与 token 时间序列（range 形如 30d、12w）
+ */
+export async function getGenerationTimeseries(
+  granularity: 'day' | 'week' = 'day',
+  range = '30d',
+): Promise<GenerationTimeseries> {
+  const response = await api.get<ApiResponse<GenerationTimeseries>>('/generate/stats/timeseries', {
+    params: { granularity, range },
+  });
+  return response.data.data;
+}
+
+// 生成阶段事件，elapsed_ms 为距 started 的毫秒数
+export interface GenerationEvent {
+  stage: 'started' | 'agent_call' | 'agent_done' | 'parsed' | 'saved' | 'failed';
+  at: string;
+  elapsed_ms: number;
+  detail?: string;
+}
+
 export interface GenerationHistoryItem {
   id: string;
   status: string;
@@ -63,6 +129,20 @@ export interface GenerationHistoryItem {
   error_msg?: string;
   created_at: string;
   completed_at?: string;
+  // GenerationEvent 数组的 JSON 字符串
+  events?: string;
+}
+
+export function parseGenerationEvents(raw?: string): GenerationEvent[] {
+  if (!raw) {
+    return [];
+  }
+  try {
+    const events = JSON.parse(raw);
+    return Array.isArray(events) ? events : [];
+  } catch {
+    return [];
+  }
 }
 
 export interface GenerationHistoryResponse {
@@ -118,6 +198,22 @@ export async function getGenerationHistory(page: number = 1, pageSize: number =
   return normalizeHistory(response.data.data || {}, page, pageSize);
 }
 
+export async function searchGenerationHistory(
+  query: string,
+  page: number = 1,
+  pageSize: number = 10
+): Promise<GenerationHistoryResponse> {
+  const response = await api.get<ApiResponse<RawGenerationHistoryResponse>>('/generate/history/search', {
+    params: {
+      q: query,
+      page,
+      page_size: pageSize,
+    },
+  });
+
+  return normalizeHistory(response.data.data || {}, page, pageSize);
+}
+
 export async function getLangSmithUsage(page: number = 1, pageSize: number = 10): Promise<LangSmithUsageResponse> {
   try {
     const response = await api.get<ApiResponse<LangSmithUsageResponse>>('/generate/langsmith/usage', {
@@ -31,6 +31,8 @@ const MAX_RETRY_ATTEMPTS = 2;
 const RETRY_BASE_DELAY_MS = 250;
 const TRACE_ID_HEADER = 'X-Trace-ID';
 const REQUEST_ID_HEADER = 'X-Request-ID';
+const DEVICE_ID_HEADER = 'X-Device-ID';
+const DEVICE_ID_STORAGE_KEY = 'device_id';
 
 type RetryRequestConfig = InternalAxiosRequestConfig & {
   _retryCount?: number;
@@ -45,6 +47,20 @@ function createTraceId(): string {
   return `${Date.now().toString(36)}-${Math.random().toString(36).slice(2, 10)}`;
 }
 
+// 设备标识持久化在本地，供服务端按设备做点赞等操作的防刷限流
+function getDeviceId(): string {
+  try {
+    let deviceId = localStorage.getItem(DEVICE_ID_STORAGE_KEY);
+    if (!deviceId) {
+      deviceId = createTraceId();
+      localStorage.setItem(DEVICE_ID_STORAGE_KEY, deviceId);
+    }
+    return deviceId;
+  } catch {
+    return '';
+  }
+}
+
 function readHeader(config: RetryRequestConfig, headerName: string): string | undefined {
   if (!config.headers) {
     return undefined;
@@ -162,6 +178,11 @@ function applyRequestDefaults(config: RetryRequestConfig): RetryRequestConfig {
   writeHeader(config, TRACE_ID_HEADER, traceId);
   writeHeader(config, REQUEST_ID_HEADER, traceId);
 
+  const deviceId = getDeviceId();
+  if (deviceId) {
+    writeHeader(config, DEVICE_ID_HEADER, deviceId);
+  }
+
   return config;
 }
 
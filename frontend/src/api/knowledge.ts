@@ -1,4 +1,5 @@
 import api from './index';
+import type { PaginatedResponse } from '@/types';
 
 export interface KnowledgeDocument {
   id: string;
@@ -31,10 +32,17 @@ export function uploadDocument(formData: FormData, onProgress?: (percent: number
   });
 }
 
-/** 获取文档列表 */
-export function listDocuments(page = 1, pageSize = 20) {
-  return api.get<{ data: KnowledgeDocument[] }>('/knowledge/documents', {
-    params: { page, page_size: pageSize },
+/** 文档列表筛选条件，未填写的条件不筛选 */
+export interface DocumentListFilter {
+  status?: KnowledgeDocument['status'];
+  subject?: string;
+  grade?: string;
+}
+
+/** 分页获取文档列表 */
+export function listDocuments(page = 1, pageSize = 10, filter: DocumentListFilter = {}) {
+  return api.get<{ data: PaginatedResponse<KnowledgeDocument> }>('/knowledge/documents', {
+    params: { page, page_size: pageSize, ...filter },
   });
 }
 
@@ -43,9 +51,15 @@ export function deleteDocument(id: string) {
   return api.delete(`/knowledge/documents/${id}`);
 }
 
+/** 重新处理文档，清空上次结果后重建知识图谱；处理中的文档返回 409 */
+export function reprocessDocument(id: string) {
+  return api.post(`/knowledge/documents/${id}/reprocess`);
+}
+
 // 保持向后兼容的命名空间导出
 export const knowledgeApi = {
   uploadDocument,
   listDocuments,
   deleteDocument,
+  reprocessDocument,
 };
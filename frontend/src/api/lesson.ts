@@ -3,6 +3,8 @@ import type {
   Lesson,
   LessonVersion,
   LessonQualityReview,
+  LessonComplianceReport,
+  LessonReadability,
   LessonVersionDiff,
   ExportLayout,
   ApiResponse,
@@ -17,6 +19,9 @@ type RawLesson = Lesson & {
   key_points?: string[];
   difficult_points?: string[];
   teaching_methods?: string[];
+  content_type?: Lesson['contentType'];
+  grade_level?: number;
+  cover_url?: string;
 };
 
 type RawPaginatedLessonResponse = {
@@ -38,9 +43,18 @@ function normalizeLesson(raw: RawLesson): Lesson {
     teachingMethods: raw.teachingMethods || raw.teaching_methods || [],
     createdAt: raw.createdAt || raw.created_at || '',
     updatedAt: raw.updatedAt || raw.updated_at || '',
+    contentType: raw.contentType || raw.content_type || 'markdown',
+    gradeLevel: raw.gradeLevel ?? raw.grade_level ?? 0,
+    // 封面文件不直接对外暴露，统一经接口读取
+    coverUrl: raw.coverUrl || raw.cover_url ? lessonCoverUrl(raw.id) : undefined,
   } as Lesson;
 }
 
+/** 教案封面图片地址 */
+export function lessonCoverUrl(lessonId: string): string {
+  return `${api.defaults.baseURL || ''}/lessons/${lessonId}/cover`;
+}
+
 function normalizeLessonPage(raw: RawPaginatedLessonResponse): PaginatedResponse<Lesson> {
   return {
     items: (raw.items || []).map(normalizeLesson),
@@ -60,9 +74,19 @@ export async function getLessons(
     grade?: string; 
     status?: string;
     keyword?: string;
+    // 按标签筛选，默认包含任一标签即可，tags_match 为 all 时须包含全部标签
+    tag?: string[];
+    tags_match?: 'any' | 'all';
+    // 默认按创建时间倒序；grade 按年级标准值排序，其余按浏览、点赞、收藏数排序
+    sort?: 'created' | 'created_at' | 'grade' | 'view_count' | 'like_count' | 'favorite_count';
+    order?: 'asc' | 'desc';
   }
 ): Promise<PaginatedResponse<Lesson>> {
-  const response = await api.get<ApiResponse<RawPaginatedLessonResponse>>('/lessons', { params });
+  // 数组参数按 tag=a&tag=b 重复传参，与后端 QueryArray 一致
+  const response = await api.get<ApiResponse<RawPaginatedLessonResponse>>('/lessons', {
+    params,
+    paramsSerializer: { indexes: null },
+  });
   return normalizeLessonPage(response.data.data);
 }
 
@@ -82,6 +106,19 @@ export async function createLesson(data: Partial<Lesson>): Promise<Lesson> {
   return normalizeLesson(response.data.data);
 }
 
+/**
+ * 抓取网页正文导入为教案草稿
+ */
+export async function importLessonFromUrl(data: {
+  url: string;
+  subject: string;
+  grade: string;
+  title?: string;
+}): Promise<Lesson> {
+  const response = await api.post<ApiResponse<RawLesson>>('/lessons/import-url', data);
+  return normalizeLesson(response.data.data);
+}
+
 /**
  * 更新教案
  */
@@ -91,10 +128,29 @@ export async function updateLesson(id: string, data: Partial<Lesson>): Promise<L
 }
 
 /**
- * 删除教案
+ * 删除教案：先申请短时效确认令牌，再携带令牌执行删除
  */
 export async function deleteLesson(id: string): Promise<void> {
-  await api.delete(`/lessons/${id}`);
+  const confirm = await api.post<ApiResponse<{ confirm_token: string; expires_at: number }>>(
+    `/lessons/${id}/confirm-delete`,
+  );
+  await api.delete(`/lessons/${id}`, {
+    headers: { 'X-Confirm-Token': confirm.data.data.confirm_token },
+  });
+}
+
+/**
+ * 永久清除教案（含已删除的教案），不可恢复；确认令牌只能使用一次
+ */
+export async function purgeLesson(id: string): Promise<void> {
+  const confirm = await api.post<ApiResponse<{ confirm_token: string; expires_at: number }>>(
+    `/lessons/${id}/confirm-delete`,
+    undefined,
+    { params: { permanent: true } },
+  );
+  await api.delete(`/lessons/${id}/purge`, {
+    headers: { 'X-Confirm-Token': confirm.data.data.confirm_token },
+  });
 }
 
 /**
@@ -104,6 +160,13 @@ export async function publishLesson(id: string): Promise<void> {
   await api.post(`/lessons/${id}/publish`);
 }
 
+/**
+ * 恢复已归档的教案（曾发布过的恢复为已发布，否则恢复为草稿）
+ */
+export async function restoreLesson(id: string): Promise<void> {
+  await api.post(`/lessons/${id}/restore`);
+}
+
 /**
  * 获取教案版本列表
  */
@@ -120,6 +183,14 @@ export async function getLessonVersion(lessonId: string, version: number): Promi
   return response.data.data;
 }
 
+/**
+ * 给版本命名（里程碑），label 为空表示取消命名
+ */
+export async function labelLessonVersion(lessonId: string, version: number, label: string): Promise<LessonVersion> {
+  const response = await api.post<ApiResponse<LessonVersion>>(`/lessons/${lessonId}/versions/${version}/label`, { label });
+  return response.data.data;
+}
+
 /**
  * 回滚到指定版本
  */
@@ -128,6 +199,90 @@ export async function rollbackToVersion(lessonId: string, version: number): Prom
   return normalizeLesson(response.data.data);
 }
 
+/** 教案编辑操作记录，fields 为改动字段名的 JSON 数组字符串 */
+export interface LessonEditRecord {
+  id: string;
+  lesson_id: string;
+  user_id: string;
+  fields: string;
+  undone: boolean;
+  created_at: string;
+}
+
+export interface LessonEditHistory {
+  edits: LessonEditRecord[];
+  can_undo: boolean;
+  can_redo: boolean;
+}
+
+export interface LessonEditState {
+  lesson: Lesson;
+  can_undo: boolean;
+  can_redo: boolean;
+}
+
+/**
+ * 获取教案编辑操作记录
+ */
+export async function getLessonEdits(lessonId: string): Promise<LessonEditHistory> {
+  const response = await api.get<ApiResponse<LessonEditHistory>>(`/lessons/${lessonId}/edits`);
+  return response.data.data;
+}
+
+/**
+ * 撤销最近一次编辑
+ */
+export async function undoLessonEdit(lessonId: string): Promise<LessonEditState> {
+  const response = await api.post<ApiResponse<{ lesson: RawLesson; can_undo: boolean; can_redo: boolean }>>(`/lessons/${lessonId}/undo`);
+  const { lesson, ...state } = response.data.data;
+  return { ...state, lesson: normalizeLesson(lesson) };
+}
+
+/**
+ * 重做最近一次撤销的编辑
+ */
+export async function redoLessonEdit(lessonId: string): Promise<LessonEditState> {
+  const response = await api.post<ApiResponse<{ lesson: RawLesson; can_undo: boolean; can_redo: boolean }>>(`/lessons/${lessonId}/redo`);
+  const { lesson, ...state } = response.data.data;
+  return { ...state, lesson: normalizeLesson(lesson) };
+}
+
+export interface LessonCover {
+  lesson_id: string;
+  url: string;
+  format: 'svg';
+  width: number;
+  height: number;
+  palette: { from: string; to: string; accent: string };
+}
+
+/**
+ * 根据学科与标题自动生成占位封面
+ */
+export async function generateLessonCover(lessonId: string): Promise<LessonCover> {
+  const response = await api.post<ApiResponse<LessonCover>>(`/lessons/${lessonId}/auto-cover`);
+  return response.data.data;
+}
+
+export interface LessonBeautifyResult {
+  lesson_id: string;
+  changed: boolean;
+  /** 排版后有变化的字段：objectives / content / activities / assessment / resources */
+  fields: Record<string, string>;
+  /** 是否已保存；预览时为 false */
+  applied: boolean;
+}
+
+/**
+ * 规范化教案排版（统一标点、清理空行、列表对齐），preview 为 true 时只返回结果不保存
+ */
+export async function beautifyLesson(lessonId: string, preview = false): Promise<LessonBeautifyResult> {
+  const response = await api.post<ApiResponse<LessonBeautifyResult>>(`/lessons/${lessonId}/beautify`, null, {
+    params: preview ? { preview: true } : undefined,
+  });
+  return response.data.data;
+}
+
 /**
  * 获取教案质量审查结果
  */
@@ -136,6 +291,22 @@ export async function getLessonQualityReview(lessonId: string): Promise<LessonQu
   return response.data.data;
 }
 
+/**
+ * 对照课标规则检查教案
+ */
+export async function checkLessonCompliance(lessonId: string): Promise<LessonComplianceReport> {
+  const response = await api.post<ApiResponse<LessonComplianceReport>>(`/lessons/${lessonId}/compliance-check`);
+  return response.data.data;
+}
+
+/**
+ * 获取教案可读性分析与年级建议
+ */
+export async function getLessonReadability(lessonId: string): Promise<LessonReadability> {
+  const response = await api.get<ApiResponse<LessonReadability>>(`/lessons/${lessonId}/readability`);
+  return response.data.data;
+}
+
 /**
  * 获取教案版本差异
  */
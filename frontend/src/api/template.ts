@@ -24,3 +24,18 @@ export async function applyLessonTemplate(id: string): Promise<AppliedLessonTemp
   const response = await api.post<ApiResponse<AppliedLessonTemplate>>(`/templates/${id}/apply`);
   return response.data.data;
 }
+
+export async function listPublicLessonTemplates(): Promise<LessonTemplate[]> {
+  const response = await api.get<ApiResponse<LessonTemplate[]>>('/templates/public');
+  return response.data.data;
+}
+
+export async function setLessonTemplatePublic(id: string, isPublic: boolean): Promise<LessonTemplate> {
+  const response = await api.put<ApiResponse<LessonTemplate>>(`/templates/${id}/visibility`, { public: isPublic });
+  return response.data.data;
+}
+
+export async function copyLessonTemplate(id: string): Promise<LessonTemplate> {
+  const response = await api.post<ApiResponse<LessonTemplate>>(`/templates/${id}/copy`);
+  return response.data.data;
+}
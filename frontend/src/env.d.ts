@@ -9,6 +9,8 @@ declare module '*.vue' {
 interface ImportMetaEnv {
   readonly VITE_API_BASE_URL: string;
   readonly VITE_WS_BASE_URL: string;
+  // 外链白名单域名，逗号分隔，匹配的外链不弹跳转确认
+  readonly VITE_TRUSTED_LINK_DOMAINS?: string;
 }
 
 interface ImportMeta {
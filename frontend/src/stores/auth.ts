@@ -95,6 +95,19 @@ export const useAuthStore = defineStore('auth', () => {
     error.value = null;
   }
 
+  // 主动退出：先通知服务端作废令牌，失败也清除本地登录状态
+  async function signOut() {
+    try {
+      if (token.value) {
+        await authApi.logout(refreshToken.value ?? undefined);
+      }
+    } catch {
+      // 令牌已失效等情况无需处理
+    } finally {
+      logout();
+    }
+  }
+
   // 清除错误
   function clearError() {
     error.value = null;
@@ -121,6 +134,7 @@ export const useAuthStore = defineStore('auth', () => {
     fetchUser,
     updateUser,
     logout,
+    signOut,
     clearError,
   };
 }, {
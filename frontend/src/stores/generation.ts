@@ -23,6 +23,7 @@ function parseList(text: string): string[] {
 function toGeneratedLesson(response: Record<string, any>, request: GenerateLessonRequest): GeneratedLesson {
   const objectives = response.objectives ? parseObjectives(response.objectives) : { knowledge: '', process: '', emotion: '' };
   return {
+    generationId: response.id,
     title: response.title || request.topic,
     objectives,
     keyPoints: parseList(response.key_points || ''),
@@ -42,6 +43,7 @@ function toGeneratedLesson(response: Record<string, any>, request: GenerateLesso
       homework: response.assessment || '',
     },
     evaluation: response.assessment || '',
+    tags: response.tags || [],
   };
 }
 
@@ -55,6 +57,7 @@ export const useGenerationStore = defineStore('generation', () => {
   const generatedLesson = ref<GeneratedLesson | null>(null);
   const progress = ref<GenerationProgress[]>([]);
   const error = ref<string | null>(null);
+  const notice = ref<string | null>(null);
 
   // 节点映射
   const nodeLabels: Record<string, string> = {
@@ -87,6 +90,7 @@ export const useGenerationStore = defineStore('generation', () => {
     isGenerating.value = true;
     generatedLesson.value = null;
     error.value = null;
+    notice.value = null;
     initProgress();
 
     // 标记第一步为运行中
@@ -97,8 +101,19 @@ export const useGenerationStore = defineStore('generation', () => {
       
       if (result.status === 'completed') {
         generatedLesson.value = toGeneratedLesson(result, request);
+        if (result.partial) {
+          notice.value = result.notice || '部分内容解析失败，请检查后补充';
+        }
         // 标记所有节点为完成
         progress.value.forEach(p => { p.status = 'completed'; });
+      } else if (result.status === 'fallback') {
+        // AI 不可用：展示骨架模板供手动填写
+        generatedLesson.value = toGeneratedLesson(result, request);
+        notice.value = result.notice || 'AI 不可用，已生成模板';
+        progress.value.forEach(p => { p.status = 'pending'; });
+      } else if (result.status === 'review') {
+        // 内容未通过安全审核：等待人工复核，不展示生成结果
+        error.value = result.notice || '生成内容需人工复核';
       } else {
         error.value = result.error_message || '生成失败';
       }
@@ -115,6 +130,7 @@ export const useGenerationStore = defineStore('generation', () => {
     generatedLesson.value = null;
     progress.value = [];
     error.value = null;
+    notice.value = null;
   }
 
   // 获取节点标签
@@ -128,6 +144,7 @@ export const useGenerationStore = defineStore('generation', () => {
     generatedLesson,
     progress,
     error,
+    notice,
     
     // 方法
     generateLesson,
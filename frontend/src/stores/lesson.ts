@@ -173,6 +173,24 @@ export const useLessonStore = defineStore('lesson', () => {
     }
   }
 
+  // 恢复已归档的教案
+  async function restoreLesson(id: string) {
+    try {
+      await lessonApi.restoreLesson(id);
+
+      // 恢复后的状态由后端决定，重新获取详情
+      await fetchLesson(id);
+
+      const index = lessons.value.findIndex(l => l.id === id);
+      if (index !== -1 && currentLesson.value) {
+        lessons.value[index].status = currentLesson.value.status;
+      }
+    } catch (err) {
+      error.value = err instanceof Error ? err.message : '恢复失败';
+      throw err;
+    }
+  }
+
   // 设置筛选条件
   function setFilters(newFilters: Partial<typeof filters.value>) {
     filters.value = { ...filters.value, ...newFilters };
@@ -218,6 +236,7 @@ export const useLessonStore = defineStore('lesson', () => {
     updateLesson,
     deleteLesson,
     publishLesson,
+    restoreLesson,
     setFilters,
     resetFilters,
     clearCurrentLesson,
@@ -53,6 +53,12 @@ export interface Lesson {
   content: LessonContent;
   evaluation: string;
   reflection?: string;
+  // 文本字段格式：富文本编辑器产出 html，AI 生成为 markdown
+  contentType?: LessonContentType;
+  // 年级标准值：1-12 对应一年级至高三，0 为无法识别（grade 保留原始写法）
+  gradeLevel?: number;
+  // 封面图片地址，未设置封面时为空
+  coverUrl?: string;
   status: LessonStatus;
   version: number;
   metadata?: LessonMetadata;
@@ -62,6 +68,8 @@ export interface Lesson {
 
 export type LessonStatus = 'draft' | 'published' | 'archived';
 
+export type LessonContentType = 'markdown' | 'html';
+
 export interface LessonObjectives {
   knowledge: string;
   process: string;
@@ -99,14 +107,22 @@ export interface TokenUsage {
 // 教案生成相关类型
 export interface GenerateLessonRequest {
   subject: string;
+  /** 跨学科教案中与 subject 组合的其他学科，最多 3 个 */
+  subjects?: string[];
   grade: string;
   topic: string;
   duration: number;
   style?: string;
+  /** 详略级别：简案 / 标准 / 详案，默认 standard */
+  detail_level?: 'brief' | 'standard' | 'detailed';
+  /** 课型：新授课 / 复习课 / 实验课，决定教学环节结构，默认 new */
+  lesson_type?: 'new' | 'review' | 'experiment';
   requirements?: string;
 }
 
 export interface GeneratedLesson {
+  /** 生成记录 ID，保存教案时回传用于追溯来源 */
+  generationId?: string;
   title: string;
   objectives: LessonObjectives;
   keyPoints: string[];
@@ -115,6 +131,8 @@ export interface GeneratedLesson {
   content: LessonContent;
   evaluation: string;
   reflection?: string;
+  /** 自动生成的标签 */
+  tags?: string[];
 }
 
 export interface GenerationProgress {
@@ -132,6 +150,8 @@ export interface LessonVersion {
   version: number;
   content: string;
   changeLog?: string;
+  /** 版本名称（里程碑），为空表示未命名 */
+  label?: string;
   createdBy?: string;
   createdAt: string;
 }
@@ -156,10 +176,54 @@ export interface LessonQualityReview {
   auto_approved: boolean;
 }
 
+export interface LessonComplianceItem {
+  rule_id: string;
+  name: string;
+  passed: boolean;
+  fields: string[];
+  reason?: string;
+  suggestion?: string;
+}
+
+// 课标合规检查结果，missing 为未满足的规则
+export interface LessonComplianceReport {
+  lesson_id: string;
+  compliant: boolean;
+  total_rules: number;
+  passed_rules: number;
+  missing: LessonComplianceItem[];
+  items: LessonComplianceItem[];
+}
+
+export interface LessonReadabilityTerm {
+  term: string;
+  count: number;
+}
+
+// 教案可读性分析，句长以字计，term_density 为每百字专业词次数
+export interface LessonReadability {
+  lesson_id: string;
+  char_count: number;
+  sentence_count: number;
+  avg_sentence_length: number;
+  long_sentence_ratio: number;
+  term_count: number;
+  term_density: number;
+  top_terms: LessonReadabilityTerm[];
+  reading_minutes: number;
+  suggested_grade_level: number;
+  suggested_grade: string;
+  lesson_grade_level: number;
+  difficulty: 'easy' | 'suitable' | 'hard' | 'unknown';
+  comment: string;
+}
+
 export interface LessonVersionDiffField {
   field: string;
   label: string;
   changed: boolean;
+  // 变化类型：added 原为空，removed 改为空
+  status: 'unchanged' | 'modified' | 'added' | 'removed';
   before?: string;
   after?: string;
   added?: string[];
@@ -202,6 +266,8 @@ export interface LessonTemplate {
   usage_count: number;
   created_at: string;
   updated_at: string;
+  public?: boolean;
+  copied_from?: string;
 }
 
 export interface CreateLessonTemplateRequest {
@@ -220,8 +286,25 @@ export interface CreateLessonTemplateRequest {
   assessment?: string;
   resources?: string;
   tags?: string[];
+  public?: boolean;
+}
+
+export interface GenerationPreset {
+  id: string;
+  name: string;
+  subject: string;
+  grade: string;
+  duration: number;
+  style: string;
+  difficulty: string;
+  created_at: string;
+  updated_at: string;
 }
 
+export type SaveGenerationPresetRequest = Pick<GenerationPreset, 'name' | 'subject' | 'grade' | 'duration' | 'style'> & {
+  difficulty?: string;
+};
+
 export interface AppliedLessonTemplate {
   template_id: string;
   name: string;
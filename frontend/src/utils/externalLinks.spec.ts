@@ -0,0 +1,96 @@
+import assert from 'node:assert/strict';
+import { describe, it } from 'node:test';
+
+import {
+  classifyLink,
+  isTrustedHost,
+  parseTrustedDomains,
+  renderSafeLink,
+  type LinkPolicy,
+} from './externalLinks.ts';
+
+const policy: LinkPolicy = {
+  origin: 'https://lesson.example.com',
+  trustedDomains: ['example.org', 'edu.cn'],
+};
+
+describe('parseTrustedDomains', () => {
+  it('normalizes wildcard, case, trailing dot and blanks', () => {
+    assert.deepEqual(parseTrustedDomains(' *.Example.org , edu.cn. ,, '), ['example.org', 'edu.cn']);
+    assert.deepEqual(parseTrustedDomains(undefined), []);
+  });
+});
+
+describe('isTrustedHost', () => {
+  const cases: Array<[string, boolean]> = [
+    ['example.org', true],
+    ['www.example.org', true],
+    ['a.b.example.org', true],
+    ['WWW.Example.ORG.', true],
+    ['badexample.org', false],
+    ['example.org.evil.com', false],
+    ['example.com', false],
+  ];
+  for (const [host, want] of cases) {
+    it(`${host} -> ${want}`, () => {
+      assert.equal(isTrustedHost(host, policy.trustedDomains), want);
+    });
+  }
+});
+
+describe('classifyLink', () => {
+  const cases: Array<[string, string]> = [
+    ['javascript:alert(1)', 'blocked'],
+    ['  JavaScript:alert(1)', 'blocked'],
+    ['java\tscript:alert(1)', 'blocked'],
+    ['data:text/html;base64,PHNjcmlwdD4=', 'blocked'],
+    ['vbscript:msgbox(1)', 'blocked'],
+    ['', 'blocked'],
+    ['#section-2', 'internal'],
+    ['/lessons/42', 'internal'],
+    ['https://lesson.example.com/lessons/42', 'internal'],
+    ['//evil.com/phish', 'external'],
+    ['https://evil.com/phish', 'external'],
+    ['https://docs.example.org/guide', 'trusted'],
+    ['mailto:teacher@example.com', 'trusted'],
+  ];
+  for (const [href, want] of cases) {
+    it(`${JSON.stringify(href)} -> ${want}`, () => {
+      assert.equal(classifyLink(href, policy), want);
+    });
+  }
+});
+
+describe('renderSafeLink', () => {
+  it('renders blocked protocols as plain text', () => {
+    assert.equal(renderSafeLink('javascript:alert(1)', null, '点我', policy), '点我');
+    assert.equal(renderSafeLink('data:text/html,<script>alert(1)</script>', null, '图片', policy), '图片');
+  });
+
+  it('marks only non-whitelisted links for confirmation', () => {
+    const external = renderSafeLink('//evil.com/phish', null, '外链', policy);
+    assert.match(external, /data-external-link="true"/);
+    assert.match(external, /target="_blank"/);
+    assert.match(external, /rel="nofollow noopener noreferrer"/);
+
+    const trusted = renderSafeLink('https://www.example.org/a', null, '白名单', policy);
+    assert.doesNotMatch(trusted, /data-external-link/);
+    assert.match(trusted, /target="_blank"/);
+    assert.match(trusted, /rel="nofollow noopener noreferrer"/);
+
+    const internal = renderSafeLink('/lessons/42', null, '站内', policy);
+    assert.equal(internal, '<a href="/lessons/42">站内</a>');
+  });
+
+  it('escapes href and title attributes', () => {
+    const html = renderSafeLink(
+      'https://evil.com/?q="onmouseover="alert(1)',
+      'a "quoted" <b>&title</b>',
+      '链接',
+      policy,
+    );
+    assert.match(html, /href="https:\/\/evil\.com\/\?q=&quot;onmouseover=&quot;alert\(1\)"/);
+    assert.match(html, /title="a &quot;quoted&quot; &lt;b&gt;&amp;title&lt;\/b&gt;"/);
+    assert.doesNotMatch(html, /"onmouseover=/);
+  });
+});
@@ -0,0 +1,113 @@
+// 允许在 Markdown 中出现的链接协议，其余（javascript:、data: 等）一律降级为纯文本
+const SAFE_PROTOCOLS = ['http:', 'https:', 'mailto:'];
+
+const EXTERNAL_LINK_REL = 'nofollow noopener noreferrer';
+
+export type LinkKind = 'internal' | 'trusted' | 'external' | 'blocked';
+
+export interface LinkPolicy {
+  origin: string;
+  trustedDomains: string[];
+}
+
+function currentOrigin(): string {
+  if (typeof window === 'undefined') {
+    return 'http://localhost';
+  }
+  return window.location.origin;
+}
+
+export function parseTrustedDomains(raw?: string): string[] {
+  return String(raw || '')
+    .split(',')
+    .map((domain) => domain.trim().toLowerCase().replace(/^\*\./, '').replace(/\.$/, ''))
+    .filter(Boolean);
+}
+
+export function defaultLinkPolicy(): LinkPolicy {
+  return {
+    origin: currentOrigin(),
+    trustedDomains: parseTrustedDomains(import.meta.env.VITE_TRUSTED_LINK_DOMAINS),
+  };
+}
+
+// 白名单域名同时匹配其子域名，例如 example.com 匹配 www.example.com
+export function isTrustedHost(hostname: string, trustedDomains: string[]): boolean {
+  const host = hostname.toLowerCase().replace(/\.$/, '');
+  return trustedDomains.some((domain) => host === domain || host.endsWith(`.${domain}`));
+}
+
+export function classifyLink(href: string, policy: LinkPolicy = defaultLinkPolicy()): LinkKind {
+  const raw = String(href || '').trim();
+  if (!raw) {
+    return 'blocked';
+  }
+  // 页内锚点与站内相对路径
+  if (raw.startsWith('#') || (raw.startsWith('/') && !raw.startsWith('//'))) {
+    return 'internal';
+  }
+
+  let url: URL;
+  try {
+    url = new URL(raw, policy.origin);
+  } catch {
+    return 'blocked';
+  }
+
+  if (!SAFE_PROTOCOLS.includes(url.protocol)) {
+    return 'blocked';
+  }
+  if (url.protocol === 'mailto:') {
+    return 'trusted';
+  }
+  if (url.origin === new URL(policy.origin).origin) {
+    return 'internal';
+  }
+  if (isTrustedHost(url.hostname, policy.trustedDomains)) {
+    return 'trusted';
+  }
+  return 'external';
+}
+
+export function linkHostname(href: string): string {
+  try {
+    return new URL(href).hostname;
+  } catch {
+    return href;
+  }
+}
+
+function escapeAttribute(value: string): string {
+  return value
+    .replace(/&/g, '&amp;')
+    .replace(/"/g, '&quot;')
+    .replace(/</g, '&lt;')
+    .replace(/>/g, '&gt;');
+}
+
+// 按链接类型生成 <a> 标签：站内链接原样保留；白名单外链新窗口打开并加 rel；
+// 非白名单外链额外打上 data-external-link，由渲染组件在点击时弹出跳转确认；
+// 不安全协议只输出链接文字
+export function renderSafeLink(
+  href: string,
+  title: string | null | undefined,
+  text: string,
+  policy: LinkPolicy = defaultLinkPolicy(),
+): string {
+  const kind = classifyLink(href, policy);
+  if (kind === 'blocked') {
+    return text;
+  }
+
+  const attrs = [`href="${escapeAttribute(href.trim())}"`];
+  if (title) {
+    attrs.push(`title="${escapeAttribute(title)}"`);
+  }
+  if (kind === 'trusted' || kind === 'external') {
+    attrs.push('target="_blank"', `rel="${EXTERNAL_LINK_REL}"`);
+  }
+  if (kind === 'external') {
+    attrs.push('data-external-link="true"');
+  }
+  return `<a ${attrs.join(' ')}>${text}</a>`;
+}
@@ -26,6 +26,10 @@ export default defineConfig(({ mode }) => {
           target: backendTarget,
           changeOrigin: true,
         },
+        '/uploads': {
+          target: backendTarget,
+          changeOrigin: true,
+        },
         '/agent': {
           target: agentTarget,
           changeOrigin: true,